@@ -0,0 +1,256 @@
+package accounts
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/nodeguarder/hostenv"
+)
+
+// Account databases
+const (
+	passwdPath = "/etc/passwd"
+	groupPath  = "/etc/group"
+	shadowPath = "/etc/shadow"
+)
+
+// Event is an account change worth reporting
+type Event struct {
+	Type      string
+	Severity  string
+	Message   string
+	Timestamp int64
+	Details   string
+}
+
+// user is the part of a passwd/shadow entry we diff
+type user struct {
+	UID      string
+	GID      string
+	Home     string
+	Shell    string
+	Password string // sha256 of the shadow hash field, never the hash itself
+}
+
+// Watcher diffs local users and groups between runs.
+// Files are only re-parsed when their mtime changes.
+type Watcher struct {
+	mtimes map[string]time.Time
+	users  map[string]user
+	groups map[string][]string // group -> members
+	primed bool
+}
+
+// New creates an account watcher
+func New() *Watcher {
+	return &Watcher{mtimes: make(map[string]time.Time)}
+}
+
+// Check returns events for accounts added, removed or modified since the last call.
+// The first call only records the baseline.
+func (w *Watcher) Check() ([]Event, error) {
+	if !w.modified() {
+		return nil, nil
+	}
+
+	users, err := readUsers()
+	if err != nil {
+		return nil, err
+	}
+	groups, err := readGroups()
+	if err != nil {
+		return nil, err
+	}
+
+	if !w.primed {
+		w.users, w.groups, w.primed = users, groups, true
+		return nil, nil
+	}
+
+	now := time.Now().Unix()
+	var events []Event
+	add := func(severity, msg string, details map[string]interface{}) {
+		d, _ := json.Marshal(details)
+		events = append(events, Event{Type: "security", Severity: severity, Message: msg, Timestamp: now, Details: string(d)})
+	}
+
+	for _, name := range sortedKeys(users) {
+		u := users[name]
+		old, existed := w.users[name]
+		if !existed {
+			severity := "warning"
+			if u.UID == "0" {
+				severity = "error"
+			}
+			add(severity, fmt.Sprintf("New user %s with UID %s (shell %s)", name, u.UID, u.Shell),
+				map[string]interface{}{"kind": "user_added", "user": name, "uid": u.UID, "gid": u.GID, "shell": u.Shell, "home": u.Home})
+			continue
+		}
+		if old.UID != u.UID {
+			severity := "warning"
+			if u.UID == "0" {
+				severity = "error"
+			}
+			add(severity, fmt.Sprintf("UID of user %s changed from %s to %s", name, old.UID, u.UID),
+				map[string]interface{}{"kind": "uid_changed", "user": name, "old": old.UID, "new": u.UID})
+		}
+		if old.Shell != u.Shell {
+			add("warning", fmt.Sprintf("Shell of user %s changed from %s to %s", name, old.Shell, u.Shell),
+				map[string]interface{}{"kind": "shell_changed", "user": name, "old": old.Shell, "new": u.Shell})
+		}
+		if old.Password != "" && u.Password != "" && old.Password != u.Password {
+			add("info", fmt.Sprintf("Password changed for user %s", name),
+				map[string]interface{}{"kind": "password_changed", "user": name})
+		}
+	}
+	for _, name := range sortedKeys(w.users) {
+		if _, ok := users[name]; !ok {
+			add("warning", fmt.Sprintf("User %s (UID %s) was removed", name, w.users[name].UID),
+				map[string]interface{}{"kind": "user_removed", "user": name, "uid": w.users[name].UID})
+		}
+	}
+
+	for _, name := range sortedKeys(groups) {
+		old, existed := w.groups[name]
+		if !existed {
+			add("info", fmt.Sprintf("New group %s", name), map[string]interface{}{"kind": "group_added", "group": name})
+		}
+		for _, member := range diff(groups[name], old) {
+			severity := "info"
+			if privilegedGroup(name) {
+				severity = "warning"
+			}
+			add(severity, fmt.Sprintf("User %s added to group %s", member, name),
+				map[string]interface{}{"kind": "group_member_added", "group": name, "user": member})
+		}
+		for _, member := range diff(old, groups[name]) {
+			add("info", fmt.Sprintf("User %s removed from group %s", member, name),
+				map[string]interface{}{"kind": "group_member_removed", "group": name, "user": member})
+		}
+	}
+	for _, name := range sortedKeys(w.groups) {
+		if _, ok := groups[name]; !ok {
+			add("info", fmt.Sprintf("Group %s was removed", name), map[string]interface{}{"kind": "group_removed", "group": name})
+		}
+	}
+
+	w.users, w.groups = users, groups
+	return events, nil
+}
+
+// modified reports whether any account file changed since the last call
+func (w *Watcher) modified() bool {
+	changed := false
+	for _, path := range []string{passwdPath, groupPath, shadowPath} {
+		info, err := os.Stat(hostenv.Path(path))
+		if err != nil {
+			continue
+		}
+		if last, ok := w.mtimes[path]; !ok || !last.Equal(info.ModTime()) {
+			w.mtimes[path] = info.ModTime()
+			changed = true
+		}
+	}
+	return changed
+}
+
+// privilegedGroup reports groups that grant root or near-root access
+func privilegedGroup(name string) bool {
+	switch name {
+	case "root", "sudo", "wheel", "admin", "adm", "docker", "lxd", "disk", "shadow":
+		return true
+	}
+	return false
+}
+
+func readUsers() (map[string]user, error) {
+	users := make(map[string]user)
+	err := readColonFile(passwdPath, func(fields []string) {
+		if len(fields) < 7 {
+			return
+		}
+		users[fields[0]] = user{UID: fields[2], GID: fields[3], Home: fields[5], Shell: fields[6]}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// shadow is optional (non-root agents cannot read it)
+	readColonFile(shadowPath, func(fields []string) {
+		if len(fields) < 2 {
+			return
+		}
+		if u, ok := users[fields[0]]; ok {
+			sum := sha256.Sum256([]byte(fields[1]))
+			u.Password = fmt.Sprintf("%x", sum)
+			users[fields[0]] = u
+		}
+	})
+	return users, nil
+}
+
+func readGroups() (map[string][]string, error) {
+	groups := make(map[string][]string)
+	err := readColonFile(groupPath, func(fields []string) {
+		if len(fields) < 4 {
+			return
+		}
+		var members []string
+		for _, m := range strings.Split(fields[3], ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				members = append(members, m)
+			}
+		}
+		groups[fields[0]] = members
+	})
+	return groups, err
+}
+
+// readColonFile calls fn with the fields of every non-comment line
+func readColonFile(path string, fn func(fields []string)) error {
+	f, err := os.Open(hostenv.Path(path))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fn(strings.Split(line, ":"))
+	}
+	return scanner.Err()
+}
+
+// diff returns the entries of a that are not in b
+func diff(a, b []string) []string {
+	seen := make(map[string]bool, len(b))
+	for _, s := range b {
+		seen[s] = true
+	}
+	var out []string
+	for _, s := range a {
+		if !seen[s] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
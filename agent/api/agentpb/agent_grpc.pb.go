@@ -0,0 +1,262 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.1
+// source: agent.proto
+
+// gRPC transport between agent and dashboard, an alternative to the REST
+// endpoints under /api/v1/agent. Every call authenticates with the
+// "server-id" and "api-secret" metadata keys.
+
+package agentpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	AgentService_Register_FullMethodName     = "/nodeguarder.agent.v1.AgentService/Register"
+	AgentService_PushMetrics_FullMethodName  = "/nodeguarder.agent.v1.AgentService/PushMetrics"
+	AgentService_PushEvents_FullMethodName   = "/nodeguarder.agent.v1.AgentService/PushEvents"
+	AgentService_StreamConfig_FullMethodName = "/nodeguarder.agent.v1.AgentService/StreamConfig"
+)
+
+// AgentServiceClient is the client API for AgentService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AgentServiceClient interface {
+	// Register announces the agent (POST /api/v1/agent/register)
+	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*Ack, error)
+	// PushMetrics delivers one metrics sample (POST /api/v1/agent/metrics)
+	PushMetrics(ctx context.Context, in *MetricsRequest, opts ...grpc.CallOption) (*Ack, error)
+	// PushEvents delivers a batch of events (POST /api/v1/agent/events)
+	PushEvents(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (*Ack, error)
+	// StreamConfig sends the agent configuration on connect and again whenever
+	// it changes (GET /api/v1/agent/config)
+	StreamConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (AgentService_StreamConfigClient, error)
+}
+
+type agentServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAgentServiceClient(cc grpc.ClientConnInterface) AgentServiceClient {
+	return &agentServiceClient{cc}
+}
+
+func (c *agentServiceClient) Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*Ack, error) {
+	out := new(Ack)
+	err := c.cc.Invoke(ctx, AgentService_Register_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) PushMetrics(ctx context.Context, in *MetricsRequest, opts ...grpc.CallOption) (*Ack, error) {
+	out := new(Ack)
+	err := c.cc.Invoke(ctx, AgentService_PushMetrics_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) PushEvents(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (*Ack, error) {
+	out := new(Ack)
+	err := c.cc.Invoke(ctx, AgentService_PushEvents_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *agentServiceClient) StreamConfig(ctx context.Context, in *ConfigRequest, opts ...grpc.CallOption) (AgentService_StreamConfigClient, error) {
+	stream, err := c.cc.NewStream(ctx, &AgentService_ServiceDesc.Streams[0], AgentService_StreamConfig_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &agentServiceStreamConfigClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type AgentService_StreamConfigClient interface {
+	Recv() (*AgentConfig, error)
+	grpc.ClientStream
+}
+
+type agentServiceStreamConfigClient struct {
+	grpc.ClientStream
+}
+
+func (x *agentServiceStreamConfigClient) Recv() (*AgentConfig, error) {
+	m := new(AgentConfig)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AgentServiceServer is the server API for AgentService service.
+// All implementations must embed UnimplementedAgentServiceServer
+// for forward compatibility
+type AgentServiceServer interface {
+	// Register announces the agent (POST /api/v1/agent/register)
+	Register(context.Context, *RegisterRequest) (*Ack, error)
+	// PushMetrics delivers one metrics sample (POST /api/v1/agent/metrics)
+	PushMetrics(context.Context, *MetricsRequest) (*Ack, error)
+	// PushEvents delivers a batch of events (POST /api/v1/agent/events)
+	PushEvents(context.Context, *EventsRequest) (*Ack, error)
+	// StreamConfig sends the agent configuration on connect and again whenever
+	// it changes (GET /api/v1/agent/config)
+	StreamConfig(*ConfigRequest, AgentService_StreamConfigServer) error
+	mustEmbedUnimplementedAgentServiceServer()
+}
+
+// UnimplementedAgentServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedAgentServiceServer struct {
+}
+
+func (UnimplementedAgentServiceServer) Register(context.Context, *RegisterRequest) (*Ack, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedAgentServiceServer) PushMetrics(context.Context, *MetricsRequest) (*Ack, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushMetrics not implemented")
+}
+func (UnimplementedAgentServiceServer) PushEvents(context.Context, *EventsRequest) (*Ack, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushEvents not implemented")
+}
+func (UnimplementedAgentServiceServer) StreamConfig(*ConfigRequest, AgentService_StreamConfigServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamConfig not implemented")
+}
+func (UnimplementedAgentServiceServer) mustEmbedUnimplementedAgentServiceServer() {}
+
+// UnsafeAgentServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AgentServiceServer will
+// result in compilation errors.
+type UnsafeAgentServiceServer interface {
+	mustEmbedUnimplementedAgentServiceServer()
+}
+
+func RegisterAgentServiceServer(s grpc.ServiceRegistrar, srv AgentServiceServer) {
+	s.RegisterService(&AgentService_ServiceDesc, srv)
+}
+
+func _AgentService_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).Register(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_PushMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MetricsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).PushMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_PushMetrics_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).PushMetrics(ctx, req.(*MetricsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_PushEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AgentServiceServer).PushEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AgentService_PushEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AgentServiceServer).PushEvents(ctx, req.(*EventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AgentService_StreamConfig_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ConfigRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AgentServiceServer).StreamConfig(m, &agentServiceStreamConfigServer{stream})
+}
+
+type AgentService_StreamConfigServer interface {
+	Send(*AgentConfig) error
+	grpc.ServerStream
+}
+
+type agentServiceStreamConfigServer struct {
+	grpc.ServerStream
+}
+
+func (x *agentServiceStreamConfigServer) Send(m *AgentConfig) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// AgentService_ServiceDesc is the grpc.ServiceDesc for AgentService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AgentService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "nodeguarder.agent.v1.AgentService",
+	HandlerType: (*AgentServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _AgentService_Register_Handler,
+		},
+		{
+			MethodName: "PushMetrics",
+			Handler:    _AgentService_PushMetrics_Handler,
+		},
+		{
+			MethodName: "PushEvents",
+			Handler:    _AgentService_PushEvents_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamConfig",
+			Handler:       _AgentService_StreamConfig_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "agent.proto",
+}
@@ -12,9 +12,15 @@ import (
 	"net/http"
     "os"
     "path/filepath"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/yourusername/nodeguarder/cron"
+	"github.com/yourusername/nodeguarder/packages"
 	"github.com/yourusername/nodeguarder/queue"
+	"github.com/yourusername/nodeguarder/remediate"
+	"github.com/yourusername/nodeguarder/scripts"
 )
 
 var ErrUnauthorized = errors.New("unauthorized")
@@ -25,25 +31,36 @@ type Client struct {
 	serverID   string
 	apiSecret  string
 	httpClient *http.Client
+	tlsConfig  *tls.Config
 	queue      *queue.Queue
+	delta      *metricsDelta
+	grpc       *grpcTransport // nil unless UseGRPC was called
+	signMu     sync.RWMutex
+	signKey    []byte // request signing key, nil while requests carry the secret (see signing.go)
+	updates    chan struct{}  // config stream signals, nil unless WatchConfig was called
 }
 
 // NewClient creates a new API client
 func NewClient(baseURL, serverID, apiSecret string, disableSSLVerify bool) *Client {
-	return &Client{
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: disableSSLVerify,
+	}
+	c := &Client{
 		baseURL:   baseURL,
 		serverID:  serverID,
 		apiSecret: apiSecret,
+		tlsConfig: tlsConfig,
 		queue:     nil, // Queue will be set separately
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: disableSSLVerify,
-				},
-			},
+		delta:     newMetricsDelta(),
+	}
+	c.httpClient = &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &signingTransport{
+			base:   &http.Transport{TLSClientConfig: tlsConfig},
+			client: c,
 		},
 	}
+	return c
 }
 
 // SetQueue attaches a resilience queue to the client
@@ -60,12 +77,16 @@ type RegisterRequest struct {
 	AgentVersion      string `json:"agent_version"`
 	APISecret         string `json:"api_secret"`
 	RegistrationToken string `json:"registration_token"`
+	PackageID         string `json:"package_id,omitempty"`
+	Arch              string `json:"arch,omitempty"`          // binary flavour in the dashboard's download manifest
+	BinarySHA256      string `json:"binary_sha256,omitempty"` // running agent binary
+	ConfigSHA256      string `json:"config_sha256,omitempty"` // config file as loaded at startup
 }
 
 // MetricsRequest represents the metrics push payload
 type MetricsRequest struct {
 	ServerID  string                 `json:"server_id"`
-	APISecret string                 `json:"api_secret"`
+	APISecret string                 `json:"api_secret,omitempty"`
 	Timestamp int64                  `json:"timestamp"`
 	Metrics   map[string]interface{} `json:"metrics"`
 }
@@ -73,7 +94,7 @@ type MetricsRequest struct {
 // EventsRequest represents the events push payload
 type EventsRequest struct {
 	ServerID  string  `json:"server_id"`
-	APISecret string  `json:"api_secret"`
+	APISecret string  `json:"api_secret,omitempty"`
 	Events    []Event `json:"events"`
 }
 
@@ -84,6 +105,10 @@ type Event struct {
 	Message   string `json:"message"`
 	Timestamp int64  `json:"timestamp"`
 	Details   string `json:"details,omitempty"`
+
+	// Set on events replayed from the offline queue that were queued repeatedly
+	Occurrences   int   `json:"occurrences,omitempty"`
+	LastTimestamp int64 `json:"last_timestamp,omitempty"`
 }
 
 // AgentConfig matches the backend configuration
@@ -91,6 +116,10 @@ type AgentConfig struct {
 	DriftIgnore       []string          `json:"drift_ignore"`
 	DriftPaths        []string          `json:"drift_paths"`
     DriftInterval     int               `json:"drift_interval"`
+	Interval          int               `json:"interval"` // Push interval set for this server (0 = the agent's own)
+	NetworkDriftEnabled bool            `json:"network_drift_enabled"` // Track listening ports
+	EgressMonitorEnabled bool           `json:"egress_monitor_enabled"` // eBPF outbound connection monitoring
+	EgressAllow       []string          `json:"egress_allow"`           // [process@]address[:port] rules for expected destinations
     HealthEnabled     bool              `json:"health_enabled"` 
     HealthSustainDuration int           `json:"health_sustain_duration"`
     CronEnabled       bool              `json:"cron_enabled"`
@@ -100,8 +129,16 @@ type AgentConfig struct {
 	CronTimeouts      map[string]int    `json:"cron_timeouts"`
 	CollectLogs       bool              `json:"collect_logs"`
 	Thresholds        ResourceThresholds `json:"thresholds"`
+	DiskExcludeFSTypes []string         `json:"disk_exclude_fstypes"` // Filesystem types left out of disk metrics
+	DiskExcludePaths  []string          `json:"disk_exclude_paths"`   // Mount points (and below) left out of disk metrics
 	OfflineTimeout    int               `json:"offline_timeout"`
     Uninstall         bool              `json:"uninstall"`
+	Scripts           []scripts.Command `json:"scripts"` // Pending script executions
+	TerminalSession   string            `json:"terminal_session"` // Remote terminal session to connect to
+	CronPauses        []cron.PauseRequest `json:"cron_pauses"`    // Pending pause/resume changes
+	Remediations      []remediate.Command `json:"remediations"`   // Pending service restarts / process kills
+	DriftRebaseline   bool              `json:"drift_rebaseline"` // Accept current state as the new drift baseline
+	TargetVersion     string            `json:"target_version"`   // Version assigned by a staged rollout (empty = the published version)
 }
 
 // ResourceThresholds configures warning/critical levels
@@ -114,30 +151,89 @@ type ResourceThresholds struct {
 	DiskCritical    float64 `json:"disk_critical"`
 }
 
+// UseClientCertificate presents the certificate returned by get on connections
+// to the dashboard (mutual TLS). Call it before UseGRPC.
+func (c *Client) UseClientCertificate(get func(*tls.CertificateRequestInfo) (*tls.Certificate, error)) {
+	c.tlsConfig.GetClientCertificate = get
+}
+
+// CloseIdleConnections drops kept-alive connections so the next request
+// handshakes again, e.g. with a renewed client certificate
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
+
+// RequestCertificate has the dashboard sign a client certificate for the
+// PEM encoded CSR. Always sent over REST.
+func (c *Client) RequestCertificate(csrPEM []byte) ([]byte, error) {
+	var resp struct {
+		Certificate string `json:"certificate"`
+	}
+	err := c.post("/api/v1/agent/certificate", map[string]string{
+		"server_id":  c.serverID,
+		"api_secret": c.secret(),
+		"csr":        string(csrPEM),
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(resp.Certificate), nil
+}
+
 // Register registers the agent with the dashboard
 func (c *Client) Register(req RegisterRequest) error {
 	// Populate fields from client config
 	req.ServerID = c.serverID
 	req.APISecret = c.apiSecret
 	
-	return c.post("/api/v1/agent/register", req, nil)
+	if c.grpc != nil {
+		return c.grpc.register(req)
+	}
+	header, err := c.postHeader("/api/v1/agent/register", req, nil)
+	if err != nil {
+		return err
+	}
+	c.setSigning(header.Get("X-Agent-Signing") == signingScheme)
+	return nil
 }
 
-// PushMetrics sends metrics to the dashboard, or queues them if unavailable
+// MetricsResponse is the dashboard's answer to a metrics push
+type MetricsResponse struct {
+	FullSync bool `json:"full_sync"` // send the full process and cron job lists next time
+}
+
+// PushMetrics sends metrics to the dashboard, or queues them if unavailable.
+// Unchanged process lists and cron jobs are sent as deltas (see delta.go).
 func (c *Client) PushMetrics(metrics map[string]interface{}) error {
+	payload, baseline := c.delta.encode(metrics)
 	req := MetricsRequest{
 		ServerID:  c.serverID,
-		APISecret: c.apiSecret,
+		APISecret: c.secret(),
 		Timestamp: time.Now().Unix(),
-		Metrics:   metrics,
+		Metrics:   payload,
 	}
 
-	err := c.post("/api/v1/agent/metrics", req, nil)
+	var resp MetricsResponse
+	var err error
+	if c.grpc != nil {
+		resp, err = c.grpc.pushMetrics(req)
+	} else {
+		err = c.post("/api/v1/agent/metrics", req, &resp)
+	}
+	c.delta.commit(baseline, err == nil, resp.FullSync)
 	if err != nil {
-		// If dashboard is unreachable and we have a queue, queue the metrics
+		// If dashboard is unreachable and we have a queue, queue the full
+		// metrics (replayed late, so the dashboard must not treat it as a delta)
 		if c.queue != nil {
 			c.queue.SetConnected(false)
-			queueErr := c.queue.PushMetrics(metrics)
+			queued := make(map[string]interface{}, len(metrics)+1)
+			for k, v := range metrics {
+				queued[k] = v
+			}
+			if sync, ok := payload["sync"].(map[string]interface{}); ok {
+				queued["sync"] = map[string]interface{}{"session": sync["session"], "seq": sync["seq"], "full": true}
+			}
+			queueErr := c.queue.PushMetrics(queued)
 			if queueErr != nil {
 				log.Printf("Warning: Failed to queue metrics: %v", queueErr)
 			}
@@ -162,11 +258,16 @@ func (c *Client) PushEvents(events []Event) error {
 
 	req := EventsRequest{
 		ServerID:  c.serverID,
-		APISecret: c.apiSecret,
+		APISecret: c.secret(),
 		Events:    events,
 	}
 
-	err := c.post("/api/v1/agent/events", req, nil)
+	var err error
+	if c.grpc != nil {
+		err = c.grpc.pushEvents(events)
+	} else {
+		err = c.post("/api/v1/agent/events", req, nil)
+	}
 	if err != nil {
 		// If dashboard is unreachable and we have a queue, queue the events
 		if c.queue != nil {
@@ -194,9 +295,13 @@ func (c *Client) PushEvents(events []Event) error {
 
 // GetConfig fetches the dynamic configuration from the dashboard
 func (c *Client) GetConfig() (*AgentConfig, error) {
+	if c.grpc != nil {
+		return c.grpc.getConfig()
+	}
+
 	var config AgentConfig
 	// Pass auth params in query string as per handler implementation
-	endpoint := fmt.Sprintf("/api/v1/agent/config?server_id=%s&api_secret=%s", c.serverID, c.apiSecret)
+	endpoint := "/api/v1/agent/config?" + c.authQuery()
 	
 	// We use a custom GET request here since c.post is for POST
 	url := c.baseURL + endpoint
@@ -241,16 +346,22 @@ func (c *Client) GetConfig() (*AgentConfig, error) {
 
 // post sends a POST request to the given endpoint
 func (c *Client) post(endpoint string, payload interface{}, response interface{}) error {
+	_, err := c.postHeader(endpoint, payload, response)
+	return err
+}
+
+// postHeader is post, also returning the response headers
+func (c *Client) postHeader(endpoint string, payload interface{}, response interface{}) (http.Header, error) {
 	url := c.baseURL + endpoint
 
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -258,29 +369,71 @@ func (c *Client) post(endpoint string, payload interface{}, response interface{}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 
 	if resp.StatusCode == 401 {
-		return ErrUnauthorized
+		return nil, ErrUnauthorized
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
 	}
 
 	if response != nil {
 		if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
+			return nil, fmt.Errorf("failed to decode response: %w", err)
 		}
 	}
 
+	return resp.Header, nil
+}
+
+// postRaw sends a pre-encoded JSON body to the given endpoint
+func (c *Client) postRaw(endpoint string, body []byte) error {
+	resp, err := c.Forward("POST", endpoint, http.Header{"Content-Type": {"application/json"}}, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 401 {
+		return ErrUnauthorized
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
 	return nil
 }
 
+// Forward sends an arbitrary request with the given headers to the dashboard and returns
+// the raw response. Used by the relay to pass through traffic from other agents; requests
+// another agent already signed keep its signature. The caller must close the body.
+func (c *Client) Forward(method, endpoint string, header http.Header, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.baseURL+endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("User-Agent", "nodeguarder-agent/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	return resp, nil
+}
+
 // FlushQueue attempts to send all queued items to the dashboard
 func (c *Client) FlushQueue() (sent int, failed int, err error) {
 	if c.queue == nil {
@@ -313,7 +466,7 @@ func (c *Client) FlushQueue() (sent int, failed int, err error) {
 
 			sendErr = c.post("/api/v1/agent/metrics", MetricsRequest{
 				ServerID:  c.serverID,
-				APISecret: c.apiSecret,
+				APISecret: c.secret(),
 				Timestamp: item.Timestamp,
 				Metrics:   metrics,
 			}, nil)
@@ -327,12 +480,29 @@ func (c *Client) FlushQueue() (sent int, failed int, err error) {
 				c.queue.MarkFailed(item.ID, fmt.Sprintf("unmarshal error: %v", err))
 				continue
 			}
+			if item.Occurrences > 1 {
+				for i := range events {
+					events[i].Occurrences = item.Occurrences
+					events[i].LastTimestamp = item.LastTimestamp
+				}
+			}
 
 			sendErr = c.post("/api/v1/agent/events", EventsRequest{
 				ServerID:  c.serverID,
-				APISecret: c.apiSecret,
+				APISecret: c.secret(),
 				Events:    events,
 			}, nil)
+		} else if item.Type == "relay" {
+			// Replay a request buffered on behalf of a downstream agent
+			var relayed queue.RelayPayload
+			if err := json.Unmarshal([]byte(item.Payload), &relayed); err != nil {
+				log.Printf("Failed to unmarshal queued relay item: %v", err)
+				failed++
+				c.queue.MarkFailed(item.ID, fmt.Sprintf("unmarshal error: %v", err))
+				continue
+			}
+
+			sendErr = c.postRaw(relayed.Path, []byte(relayed.Body))
 		}
 
 		if sendErr != nil {
@@ -354,6 +524,85 @@ func (c *Client) FlushQueue() (sent int, failed int, err error) {
 	return sent, failed, nil
 }
 
+// PushScriptResult reports the outcome of a dispatched script execution
+func (c *Client) PushScriptResult(result scripts.Result) error {
+	return c.post("/api/v1/agent/scripts/result", struct {
+		ServerID  string `json:"server_id"`
+		APISecret string `json:"api_secret,omitempty"`
+		scripts.Result
+	}{
+		ServerID:  c.serverID,
+		APISecret: c.secret(),
+		Result:    result,
+	}, nil)
+}
+
+// ReportCronPause reports the outcome of a cron pause/resume
+func (c *Client) ReportCronPause(result cron.PauseResult) error {
+	return c.post("/api/v1/agent/cron/pause", struct {
+		ServerID  string `json:"server_id"`
+		APISecret string `json:"api_secret,omitempty"`
+		cron.PauseResult
+	}{
+		ServerID:    c.serverID,
+		APISecret:   c.secret(),
+		PauseResult: result,
+	}, nil)
+}
+
+// PushPackages uploads the full installed package inventory
+func (c *Client) PushPackages(manager string, inventory []packages.Package) error {
+	return c.post("/api/v1/agent/packages", struct {
+		ServerID  string             `json:"server_id"`
+		APISecret string             `json:"api_secret,omitempty"`
+		Manager   string             `json:"manager"`
+		Packages  []packages.Package `json:"packages"`
+	}{c.serverID, c.secret(), manager, inventory}, nil)
+}
+
+// ReportRemediation reports the outcome of a remediation command
+func (c *Client) ReportRemediation(result remediate.Result) error {
+	return c.post("/api/v1/agent/remediation/result", struct {
+		ServerID  string `json:"server_id"`
+		APISecret string `json:"api_secret,omitempty"`
+		remediate.Result
+	}{
+		ServerID:  c.serverID,
+		APISecret: c.secret(),
+		Result:    result,
+	}, nil)
+}
+
+// TerminalInput long-polls keystrokes for a remote terminal session
+func (c *Client) TerminalInput(sessionID string) ([]byte, bool, error) {
+	var resp struct {
+		Data   []byte `json:"data"`
+		Closed bool   `json:"closed"`
+	}
+	err := c.post("/api/v1/agent/terminal/"+sessionID+"/input", struct {
+		ServerID  string `json:"server_id"`
+		APISecret string `json:"api_secret,omitempty"`
+	}{c.serverID, c.secret()}, &resp)
+	if err != nil {
+		// Session gone on the dashboard side
+		if strings.Contains(err.Error(), "status 404") || strings.Contains(err.Error(), "status 410") {
+			return nil, true, nil
+		}
+		return nil, false, err
+	}
+	return resp.Data, resp.Closed, nil
+}
+
+// TerminalOutput sends shell output for a remote terminal session
+func (c *Client) TerminalOutput(sessionID string, data []byte, closed bool) error {
+	return c.post("/api/v1/agent/terminal/"+sessionID+"/output", struct {
+		ServerID  string `json:"server_id"`
+		APISecret string `json:"api_secret,omitempty"`
+		Data      []byte `json:"data"`
+		Closed    bool   `json:"closed"`
+	}{c.serverID, c.secret(), data, closed}, nil)
+}
+
 // UploadLogs uploads a zip file of logs to the dashboard
 func (c *Client) UploadLogs(filePath string) error {
     file, err := os.Open(filePath)
@@ -367,7 +616,9 @@ func (c *Client) UploadLogs(filePath string) error {
 
     // Add API Secret and Server ID fields
     _ = writer.WriteField("server_id", c.serverID)
-    _ = writer.WriteField("api_secret", c.apiSecret)
+    if secret := c.secret(); secret != "" {
+        _ = writer.WriteField("api_secret", secret)
+    }
 
     part, err := writer.CreateFormFile("logs", filepath.Base(filePath))
     if err != nil {
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+)
+
+// Delta transmission policy
+const (
+	// fullSyncEvery forces a full process list and cron job list every N pushes
+	fullSyncEvery = 10
+	// processChangePoints is the CPU or memory change (in percentage points)
+	// that makes a process list worth resending
+	processChangePoints = 5.0
+)
+
+// metricsDelta leaves the process list out of a push while it is unchanged
+// and sends cron jobs as changes against the last acknowledged push. Pushes
+// are numbered within a session (the agent start time) so the dashboard can
+// detect a missed push and ask for a full sync.
+type metricsDelta struct {
+	mu        sync.Mutex
+	session   int64
+	seq       int64
+	sinceFull int
+	forceFull bool
+	processes []processEntry             // last sent list, nil before the first push
+	cronJobs  map[string]json.RawMessage // Command -> record, as last sent
+}
+
+type processEntry struct {
+	PID    int32   `json:"pid"`
+	Name   string  `json:"name"`
+	User   string  `json:"user"`
+	CPU    float64 `json:"cpu"`
+	Memory float64 `json:"memory"`
+}
+
+// deltaBaseline is what the dashboard holds once a push is acknowledged
+type deltaBaseline struct {
+	full      bool
+	processes []processEntry
+	cronJobs  map[string]json.RawMessage
+}
+
+func newMetricsDelta() *metricsDelta {
+	return &metricsDelta{session: time.Now().Unix()}
+}
+
+// encode returns the payload to send for metrics and the baseline to commit
+// once the dashboard accepted it. metrics itself is not modified.
+func (d *metricsDelta) encode(metrics map[string]interface{}) (map[string]interface{}, deltaBaseline) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.seq++
+	full := d.forceFull || d.cronJobs == nil || d.sinceFull+1 >= fullSyncEvery
+	payload := make(map[string]interface{}, len(metrics)+1)
+	for k, v := range metrics {
+		payload[k] = v
+	}
+	payload["sync"] = map[string]interface{}{"session": d.session, "seq": d.seq, "full": full}
+	next := deltaBaseline{full: full, processes: d.processes, cronJobs: d.cronJobs}
+
+	if raw, ok := metrics["processes"]; ok {
+		var procs []processEntry
+		if data, err := json.Marshal(raw); err == nil && json.Unmarshal(data, &procs) == nil {
+			if !full && d.processes != nil && !processesChanged(d.processes, procs) {
+				delete(payload, "processes")
+			} else {
+				next.processes = procs
+			}
+		}
+	}
+
+	if raw, ok := metrics["cron_jobs"]; ok {
+		var jobs []json.RawMessage
+		if data, err := json.Marshal(raw); err == nil && json.Unmarshal(data, &jobs) == nil {
+			current := make(map[string]json.RawMessage, len(jobs))
+			for _, job := range jobs {
+				var r struct{ Command string }
+				json.Unmarshal(job, &r)
+				current[r.Command] = job
+			}
+			next.cronJobs = current
+			if !full {
+				delete(payload, "cron_jobs")
+				upsert, removed := cronJobChanges(d.cronJobs, current)
+				if len(upsert) > 0 || len(removed) > 0 {
+					payload["cron_jobs_delta"] = map[string]interface{}{"upsert": upsert, "removed": removed}
+				}
+			}
+		}
+	}
+	return payload, next
+}
+
+// commit records the outcome of a push: the baseline on success, a full sync
+// for the next push if it failed or the dashboard asked for one
+func (d *metricsDelta) commit(b deltaBaseline, ok, fullSync bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !ok {
+		d.forceFull = true
+		return
+	}
+	d.processes, d.cronJobs = b.processes, b.cronJobs
+	d.forceFull = fullSync
+	if b.full {
+		d.sinceFull = 0
+	} else {
+		d.sinceFull++
+	}
+}
+
+// processesChanged reports a different set or order of processes, or a CPU or
+// memory change of at least processChangePoints
+func processesChanged(prev, cur []processEntry) bool {
+	if len(prev) != len(cur) {
+		return true
+	}
+	for i := range cur {
+		p, c := prev[i], cur[i]
+		if p.PID != c.PID || p.Name != c.Name || p.User != c.User {
+			return true
+		}
+		if math.Abs(p.CPU-c.CPU) >= processChangePoints || math.Abs(p.Memory-c.Memory) >= processChangePoints {
+			return true
+		}
+	}
+	return false
+}
+
+// cronJobChanges lists the jobs added or changed and the commands removed
+func cronJobChanges(prev, cur map[string]json.RawMessage) ([]json.RawMessage, []string) {
+	upsert := []json.RawMessage{}
+	removed := []string{}
+	for cmd, job := range cur {
+		if old, ok := prev[cmd]; !ok || string(old) != string(job) {
+			upsert = append(upsert, job)
+		}
+	}
+	for cmd := range prev {
+		if _, ok := cur[cmd]; !ok {
+			removed = append(removed, cmd)
+		}
+	}
+	return upsert, removed
+}
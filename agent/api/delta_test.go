@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestMetricsDeltaOmitsUnchanged(t *testing.T) {
+	d := newMetricsDelta()
+	type job struct {
+		Command      string
+		LastExitCode int
+	}
+	procs := []processEntry{{PID: 1, Name: "nginx", User: "www", CPU: 10}}
+	metrics := func(cpu float64, jobs ...job) map[string]interface{} {
+		p := append([]processEntry(nil), procs...)
+		p[0].CPU = cpu
+		return map[string]interface{}{"cpu_percent": 1.0, "processes": p, "cron_jobs": jobs}
+	}
+
+	payload, b := d.encode(metrics(10, job{"backup", 0}, job{"rotate", 0}))
+	if payload["sync"].(map[string]interface{})["full"] != true || payload["cron_jobs"] == nil || payload["processes"] == nil {
+		t.Fatalf("first push must be a full sync: %v", payload)
+	}
+	d.commit(b, true, false)
+
+	// CPU jitter only, one job changed, one removed
+	payload, b = d.encode(metrics(12, job{"backup", 1}))
+	if _, ok := payload["processes"]; ok {
+		t.Error("unchanged process list must be left out")
+	}
+	if _, ok := payload["cron_jobs"]; ok {
+		t.Error("cron jobs must be sent as a delta")
+	}
+	delta := payload["cron_jobs_delta"].(map[string]interface{})
+	if up, rm := delta["upsert"].([]json.RawMessage), delta["removed"].([]string); len(up) != 1 || len(rm) != 1 || rm[0] != "rotate" {
+		t.Errorf("unexpected delta %v", delta)
+	}
+	d.commit(b, true, false)
+
+	// The dashboard missed a push: the next one is full again
+	payload, b = d.encode(metrics(40, job{"backup", 1}))
+	if payload["processes"] == nil {
+		t.Error("a CPU change above the threshold must resend the process list")
+	}
+	d.commit(b, true, true)
+	payload, _ = d.encode(metrics(40, job{"backup", 1}))
+	if payload["sync"].(map[string]interface{})["full"] != true || payload["cron_jobs"] == nil {
+		t.Errorf("full_sync from the dashboard must force a full push: %v", payload)
+	}
+}
@@ -0,0 +1,248 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/yourusername/nodeguarder/api/agentpb"
+)
+
+// Config stream policy
+const (
+	grpcCallTimeout     = 30 * time.Second
+	firstConfigTimeout  = 10 * time.Second
+	configStreamBackoff = 5 * time.Second
+)
+
+// grpcTransport carries registration, metrics, events and config over one
+// multiplexed HTTP/2 connection. Config is pushed by the dashboard on a stream
+// instead of being polled.
+type grpcTransport struct {
+	conn   *grpc.ClientConn
+	client agentpb.AgentServiceClient
+	md     metadata.MD
+
+	mu      sync.Mutex
+	pending *AgentConfig  // pushed config not yet returned by GetConfig
+	last    *AgentConfig  // config last returned by GetConfig
+	err     error         // why the stream is down, nil while connected
+	ready   chan struct{} // closed once the first config (or error) arrived
+	started bool
+	updates chan struct{}
+}
+
+// UseGRPC switches Register, PushMetrics, PushEvents and GetConfig to the gRPC
+// transport at address (host:port); the other endpoints stay on REST. The
+// connection uses TLS when the dashboard URL does.
+func (c *Client) UseGRPC(address string, useTLS bool) error {
+	creds := insecure.NewCredentials()
+	if useTLS {
+		creds = credentials.NewTLS(c.tlsConfig.Clone())
+	}
+	conn, err := grpc.Dial(address,
+		grpc.WithTransportCredentials(creds),
+		grpc.WithUserAgent("nodeguarder-agent/1.0"),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{Time: time.Minute, Timeout: 20 * time.Second}),
+	)
+	if err != nil {
+		return fmt.Errorf("grpc dial %s: %w", address, err)
+	}
+	c.grpc = &grpcTransport{
+		conn:    conn,
+		client:  agentpb.NewAgentServiceClient(conn),
+		md:      metadata.Pairs("server-id", c.serverID, "api-secret", c.apiSecret),
+		ready:   make(chan struct{}),
+		updates: make(chan struct{}, 1),
+	}
+	return nil
+}
+
+// ConfigUpdates signals that the dashboard has a new configuration or
+// commands. It is nil (never ready) on the REST transport without WatchConfig.
+func (c *Client) ConfigUpdates() <-chan struct{} {
+	if c.grpc == nil {
+		return c.updates
+	}
+	return c.grpc.updates
+}
+
+// Close releases the gRPC connection, if any
+func (c *Client) Close() {
+	if c.grpc != nil {
+		c.grpc.conn.Close()
+	}
+}
+
+func (t *grpcTransport) context() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), grpcCallTimeout)
+	return metadata.NewOutgoingContext(ctx, t.md), cancel
+}
+
+func (t *grpcTransport) register(req RegisterRequest) error {
+	ctx, cancel := t.context()
+	defer cancel()
+	_, err := t.client.Register(ctx, &agentpb.RegisterRequest{
+		Hostname:          req.Hostname,
+		OsName:            req.OSName,
+		OsVersion:         req.OSVersion,
+		AgentVersion:      req.AgentVersion,
+		RegistrationToken: req.RegistrationToken,
+		PackageId:         req.PackageID,
+		Arch:              req.Arch,
+		BinarySha256:      req.BinarySHA256,
+		ConfigSha256:      req.ConfigSHA256,
+	})
+	return grpcError(err)
+}
+
+func (t *grpcTransport) pushMetrics(req MetricsRequest) (MetricsResponse, error) {
+	body, err := json.Marshal(req.Metrics)
+	if err != nil {
+		return MetricsResponse{}, fmt.Errorf("failed to marshal metrics: %w", err)
+	}
+	ctx, cancel := t.context()
+	defer cancel()
+	ack, err := t.client.PushMetrics(ctx, &agentpb.MetricsRequest{Timestamp: req.Timestamp, MetricsJson: body})
+	if err != nil {
+		return MetricsResponse{}, grpcError(err)
+	}
+	return MetricsResponse{FullSync: ack.FullSync}, nil
+}
+
+func (t *grpcTransport) pushEvents(events []Event) error {
+	req := &agentpb.EventsRequest{Events: make([]*agentpb.Event, len(events))}
+	for i, e := range events {
+		req.Events[i] = &agentpb.Event{Type: e.Type, Severity: e.Severity, Message: e.Message, Timestamp: e.Timestamp, Details: e.Details}
+	}
+	ctx, cancel := t.context()
+	defer cancel()
+	_, err := t.client.PushEvents(ctx, req)
+	return grpcError(err)
+}
+
+// getConfig returns the latest pushed configuration, starting the stream on
+// first use. Until the first config arrives it waits briefly; afterwards it
+// returns the last known config while the stream reconnects.
+func (t *grpcTransport) getConfig() (*AgentConfig, error) {
+	t.mu.Lock()
+	if !t.started {
+		t.started = true
+		go t.streamConfig()
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-t.ready:
+	case <-time.After(firstConfigTimeout):
+		return nil, errors.New("no configuration received on the grpc stream")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.pending != nil {
+		t.last, t.pending = t.pending, nil
+	}
+	if t.last == nil {
+		return nil, t.err
+	}
+	if t.err != nil {
+		log.Printf("Warning: grpc config stream down, using last config: %v", t.err)
+	}
+	cfg := *t.last
+	// Dispatched actions are delivered once (pending cron pauses are resent
+	// by the dashboard until the agent reports them, as with REST)
+	t.last.Scripts, t.last.Remediations = nil, nil
+	t.last.DriftRebaseline, t.last.TerminalSession = false, ""
+	return &cfg, nil
+}
+
+// streamConfig keeps the config stream open, reconnecting after errors
+func (t *grpcTransport) streamConfig() {
+	for {
+		err := t.receiveConfig()
+		t.mu.Lock()
+		t.err = grpcError(err)
+		t.signalReady()
+		t.mu.Unlock()
+		if status.Code(err) == codes.Canceled {
+			return // connection closed
+		}
+		time.Sleep(configStreamBackoff)
+	}
+}
+
+func (t *grpcTransport) receiveConfig() error {
+	ctx := metadata.NewOutgoingContext(context.Background(), t.md)
+	stream, err := t.client.StreamConfig(ctx, &agentpb.ConfigRequest{})
+	if err != nil {
+		return err
+	}
+	for {
+		msg, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		var cfg AgentConfig
+		if err := json.Unmarshal(msg.ConfigJson, &cfg); err != nil {
+			log.Printf("Warning: invalid config on grpc stream: %v", err)
+			continue
+		}
+
+		t.mu.Lock()
+		if t.pending != nil {
+			mergeOneShots(&cfg, t.pending)
+		}
+		t.pending, t.err = &cfg, nil
+		t.signalReady()
+		t.mu.Unlock()
+
+		select {
+		case t.updates <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// signalReady releases the first getConfig; callers hold t.mu
+func (t *grpcTransport) signalReady() {
+	select {
+	case <-t.ready:
+	default:
+		close(t.ready)
+	}
+}
+
+// mergeOneShots carries the one-shot actions of a config the agent has not
+// picked up yet into its successor, so none is lost between two reads
+func mergeOneShots(next, prev *AgentConfig) {
+	next.Scripts = append(prev.Scripts, next.Scripts...)
+	next.Remediations = append(prev.Remediations, next.Remediations...)
+	next.DriftRebaseline = next.DriftRebaseline || prev.DriftRebaseline
+	if next.TerminalSession == "" {
+		next.TerminalSession = prev.TerminalSession
+	}
+}
+
+// grpcError maps rejected credentials to ErrUnauthorized like the REST transport
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if status.Code(err) == codes.Unauthenticated {
+		return ErrUnauthorized
+	}
+	return fmt.Errorf("grpc request failed: %w", err)
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/yourusername/nodeguarder/api/agentpb"
+)
+
+// fakeDashboard pushes batches of configs and checks credentials
+type fakeDashboard struct {
+	agentpb.UnimplementedAgentServiceServer
+	batches chan []string // configs to push
+	sent    chan struct{} // a batch was pushed
+	events  chan *agentpb.EventsRequest
+}
+
+func (f *fakeDashboard) authorized(ctx context.Context) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return len(md.Get("api-secret")) == 1 && md.Get("api-secret")[0] == "secret"
+}
+
+func (f *fakeDashboard) PushEvents(ctx context.Context, req *agentpb.EventsRequest) (*agentpb.Ack, error) {
+	if !f.authorized(ctx) {
+		return nil, status.Error(codes.Unauthenticated, "Authentication failed")
+	}
+	f.events <- req
+	return &agentpb.Ack{}, nil
+}
+
+func (f *fakeDashboard) StreamConfig(_ *agentpb.ConfigRequest, stream agentpb.AgentService_StreamConfigServer) error {
+	for {
+		select {
+		case batch := <-f.batches:
+			for _, c := range batch {
+				if err := stream.Send(&agentpb.AgentConfig{ConfigJson: []byte(c)}); err != nil {
+					return err
+				}
+			}
+			f.sent <- struct{}{}
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+func startFakeDashboard(t *testing.T, f *fakeDashboard) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	agentpb.RegisterAgentServiceServer(srv, f)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func TestGRPCConfigStreamKeepsOneShots(t *testing.T) {
+	f := &fakeDashboard{batches: make(chan []string, 2), sent: make(chan struct{}, 2)}
+	c := NewClient("http://unused", "srv-1", "secret", false)
+	if err := c.UseGRPC(startFakeDashboard(t, f), false); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	f.batches <- []string{`{"drift_interval":300,"drift_rebaseline":true}`}
+	cfg, err := c.GetConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DriftInterval != 300 || !cfg.DriftRebaseline {
+		t.Fatalf("unexpected first config %+v", cfg)
+	}
+
+	// Two pushes before the next read: the newer settings win, but the script
+	// dispatched with the first must not be lost
+	f.batches <- []string{`{"drift_interval":120,"scripts":[{"result_id":7,"name":"cleanup"}]}`, `{"drift_interval":60}`}
+	<-f.sent
+	<-f.sent
+	time.Sleep(50 * time.Millisecond)
+	select {
+	case <-c.ConfigUpdates():
+	default:
+		t.Error("expected a config update signal")
+	}
+	cfg, err = c.GetConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DriftInterval != 60 || len(cfg.Scripts) != 1 || cfg.Scripts[0].ResultID != 7 || cfg.DriftRebaseline {
+		t.Errorf("unexpected merged config %+v", cfg)
+	}
+
+	// Without new pushes the last config is returned, minus the one-shots
+	cfg, _ = c.GetConfig()
+	if cfg.DriftInterval != 60 || len(cfg.Scripts) != 0 {
+		t.Errorf("one-shot actions must be delivered once, got %+v", cfg)
+	}
+}
+
+func TestGRPCPushEventsAndAuth(t *testing.T) {
+	f := &fakeDashboard{events: make(chan *agentpb.EventsRequest, 1)}
+	addr := startFakeDashboard(t, f)
+
+	c := NewClient("http://unused", "srv-1", "secret", false)
+	if err := c.UseGRPC(addr, false); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if err := c.PushEvents([]Event{{Type: "drift", Severity: "warning", Message: "changed", Timestamp: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if req := <-f.events; len(req.Events) != 1 || req.Events[0].Message != "changed" {
+		t.Errorf("unexpected events %+v", req.Events)
+	}
+
+	bad := NewClient("http://unused", "srv-1", "wrong", false)
+	if err := bad.UseGRPC(addr, false); err != nil {
+		t.Fatal(err)
+	}
+	defer bad.Close()
+	if err := bad.PushEvents([]Event{{Type: "drift"}}); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestSignatureVector(t *testing.T) {
+	// Same vector as the dashboard's signing test, so both sides stay in sync
+	got := requestSignature(signingKey("secret"), "POST", "/api/v1/agent/metrics", "1700000000", "0123456789abcdef", []byte(`{"server_id":"srv-1"}`))
+	if want := "e74f742da20106918d637a127ee9bef30aa6e086a6ec2b128bd5a9d3b6d8ff49"; got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+}
+
+func TestSignedRequestsAfterRegistration(t *testing.T) {
+	key := signingKey("secret")
+	var lastBody string
+	var verified bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		lastBody = string(body)
+		// The dashboard is mounted under /ng behind the proxy, which strips it
+		uri := strings.TrimPrefix(r.URL.RequestURI(), "/ng")
+		sig := requestSignature(key, r.Method, uri, r.Header.Get("X-Agent-Timestamp"), r.Header.Get("X-Agent-Nonce"), body)
+		verified = r.Header.Get("X-Agent-Signature") == sig && r.Header.Get("X-Agent-ID") == "srv-1"
+		if uri == "/api/v1/agent/register" {
+			w.Header().Set("X-Agent-Signing", "hmac-sha256")
+		}
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL+"/ng", "srv-1", "secret", false)
+	if err := c.PushEvents([]Event{{Type: "test"}}); err != nil {
+		t.Fatal(err)
+	}
+	if verified || !strings.Contains(lastBody, `"api_secret":"secret"`) {
+		t.Fatalf("before registration requests carry the secret: %s", lastBody)
+	}
+
+	if err := c.Register(RegisterRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(lastBody, `"api_secret":"secret"`) {
+		t.Error("registration must still carry the secret")
+	}
+
+	if err := c.PushEvents([]Event{{Type: "test"}}); err != nil {
+		t.Fatal(err)
+	}
+	if !verified {
+		t.Error("push after registration must be signed")
+	}
+	if strings.Contains(lastBody, "secret") {
+		t.Errorf("signed push must not carry the secret: %s", lastBody)
+	}
+	if q := c.authQuery(); q != "server_id=srv-1" {
+		t.Errorf("authQuery = %q, want the server ID only", q)
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// streamBackoff is the pause after a failed config stream request
+const streamBackoff = 30 * time.Second
+
+// WatchConfig long-polls /api/v1/agent/stream in the background so config
+// changes and commands (log requests, uninstall, scripts, ...) reach the agent
+// right away; ConfigUpdates signals them. Dashboards without the endpoint
+// leave the agent on plain polling. The gRPC transport streams config itself.
+func (c *Client) WatchConfig() {
+	if c.grpc != nil || c.updates != nil {
+		return
+	}
+	c.updates = make(chan struct{}, 1)
+	go func() {
+		var seq int64 = -1 // none yet: the dashboard starts from its current state
+		for {
+			next, changed, err := c.waitForChange(seq)
+			if err == errStreamUnsupported {
+				log.Println("Config stream not supported by the dashboard, polling only")
+				return
+			}
+			if err != nil {
+				time.Sleep(streamBackoff)
+				continue
+			}
+			seq = next
+			if changed {
+				select {
+				case c.updates <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+}
+
+var errStreamUnsupported = errors.New("config stream not supported")
+
+// waitForChange holds one stream request open until the dashboard reports a
+// change or its wait ends; seq -1 starts a new stream
+func (c *Client) waitForChange(seq int64) (int64, bool, error) {
+	endpoint := "/api/v1/agent/stream?" + c.authQuery()
+	if seq >= 0 {
+		endpoint += fmt.Sprintf("&since=%d", seq)
+	}
+	req, err := http.NewRequest("GET", c.baseURL+endpoint, nil)
+	if err != nil {
+		return seq, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "nodeguarder-agent/1.0")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return seq, false, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return seq, false, errStreamUnsupported
+	case http.StatusUnauthorized:
+		return seq, false, ErrUnauthorized
+	default:
+		return seq, false, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Seq     int64 `json:"seq"`
+		Changed bool  `json:"changed"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return seq, false, fmt.Errorf("failed to decode stream response: %w", err)
+	}
+	return result.Seq, result.Changed, nil
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchConfigSignalsChanges(t *testing.T) {
+	var calls atomic.Int32
+	sinceSeen := make(chan string, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/agent/stream" || r.URL.Query().Get("api_secret") != "secret" {
+			http.NotFound(w, r)
+			return
+		}
+		n := calls.Add(1)
+		sinceSeen <- r.URL.Query().Get("since")
+		if n == 1 {
+			fmt.Fprint(w, `{"seq":4,"changed":false}`)
+			return
+		}
+		if n == 2 {
+			fmt.Fprint(w, `{"seq":5,"changed":true}`)
+			return
+		}
+		time.Sleep(time.Second) // hold further polls open
+		fmt.Fprint(w, `{"seq":5,"changed":false}`)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "srv-1", "secret", false)
+	if c.ConfigUpdates() != nil {
+		t.Fatal("ConfigUpdates must be nil before WatchConfig")
+	}
+	c.WatchConfig()
+
+	select {
+	case <-c.ConfigUpdates():
+	case <-time.After(5 * time.Second):
+		t.Fatal("no config update signalled")
+	}
+	if first, second := <-sinceSeen, <-sinceSeen; first != "" || second != "4" {
+		t.Errorf("since = %q then %q, want none then 4", first, second)
+	}
+}
+
+func TestWaitForChangeUnsupported(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	c := NewClient(srv.URL, "srv-1", "secret", false)
+	if _, _, err := c.waitForChange(-1); err != errStreamUnsupported {
+		t.Errorf("expected errStreamUnsupported from an older dashboard, got %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package authwatch
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/nodeguarder/logtail"
+	"github.com/yourusername/nodeguarder/state"
+)
+
+// authLogPaths are tried in order when journald is unavailable
+var authLogPaths = []string{"/var/log/auth.log", "/var/log/secure"}
+
+var (
+	failedPattern  = regexp.MustCompile(`Failed (?:password|publickey) for (?:invalid user )?(\S+) from (\S+)`)
+	invalidPattern = regexp.MustCompile(`Invalid user (\S*) from (\S+)`)
+	sudoPattern    = regexp.MustCompile(`sudo(?:\[\d+\])?:\s+(\S+) : .*COMMAND=(.*)$`)
+)
+
+// Config controls brute-force detection
+type Config struct {
+	Enabled          bool `yaml:"enabled" json:"enabled"`
+	FailureThreshold int  `yaml:"failure_threshold" json:"failure_threshold"` // Failed logins from one IP within the window
+	Window           int  `yaml:"window" json:"window"`                       // Seconds
+	ReportSudo       bool `yaml:"report_sudo" json:"report_sudo"`             // Emit an event for sudo sessions
+}
+
+// Event is a security finding from the auth logs
+type Event struct {
+	Type      string
+	Severity  string
+	Message   string
+	Timestamp int64
+	Details   string
+}
+
+// Watcher tails sshd/sudo logs for failed logins and privilege escalation
+type Watcher struct {
+	cfg       Config
+	logs      *logtail.Reader
+	lastCheck int64
+	failures  map[string][]int64  // source IP -> failure timestamps within window
+	users     map[string][]string // source IP -> attempted usernames
+	alerted   map[string]int64    // source IP -> last alert time
+	state     *state.Namespace    // persisted log position, nil = memory only
+}
+
+// New creates an auth watcher
+func New(cfg Config) *Watcher {
+	return &Watcher{
+		cfg:       cfg,
+		logs:      logtail.NewReader(true), // Don't replay old auth history on startup
+		lastCheck: time.Now().Unix(),
+		failures:  make(map[string][]int64),
+		users:     make(map[string][]string),
+		alerted:   make(map[string]int64),
+	}
+}
+
+// maxResumeLookback caps how much of the journal is read after a restart
+const maxResumeLookback = time.Hour
+
+// SetState persists the journal position and auth log offset, so lines
+// logged while the agent was down are still checked after a restart
+func (w *Watcher) SetState(ns *state.Namespace) {
+	w.state = ns
+	w.logs.SetState(ns)
+	if value, ok, _ := ns.Get("last_check"); ok {
+		if since, err := strconv.ParseInt(value, 10, 64); err == nil {
+			w.lastCheck = max(since, time.Now().Add(-maxResumeLookback).Unix())
+		}
+	}
+}
+
+// Check reads new auth log lines and returns security events
+func (w *Watcher) Check() ([]Event, error) {
+	if !w.cfg.Enabled {
+		return nil, nil
+	}
+
+	now := time.Now().Unix()
+	lines, err := logtail.Journal([]string{"_COMM=sshd", "_COMM=sshd-session", "_COMM=sudo"}, w.lastCheck, nil)
+	if err != nil {
+		lines, err = w.logs.File(authLogPaths, isAuthLine)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read auth logs: %w", err)
+		}
+	}
+	w.lastCheck = now
+	w.state.Put("last_check", strconv.FormatInt(now, 10))
+
+	sudoByUser := make(map[string][]string)
+	for _, line := range lines {
+		if m := failedPattern.FindStringSubmatch(line); m != nil {
+			w.recordFailure(m[2], m[1], now)
+		} else if m := invalidPattern.FindStringSubmatch(line); m != nil {
+			w.recordFailure(m[2], m[1], now)
+		} else if m := sudoPattern.FindStringSubmatch(line); m != nil {
+			sudoByUser[m[1]] = append(sudoByUser[m[1]], strings.TrimSpace(m[2]))
+		}
+	}
+
+	var events []Event
+	if ev := w.bruteForceEvent(now); ev != nil {
+		events = append(events, *ev)
+	}
+	if w.cfg.ReportSudo {
+		for user, cmds := range sudoByUser {
+			details, _ := json.Marshal(map[string]interface{}{"user": user, "commands": cmds})
+			events = append(events, Event{
+				Type:      "security",
+				Severity:  "info",
+				Message:   fmt.Sprintf("sudo session by %s (%d command(s))", user, len(cmds)),
+				Timestamp: now,
+				Details:   string(details),
+			})
+		}
+	}
+
+	return events, nil
+}
+
+func (w *Watcher) recordFailure(ip, user string, now int64) {
+	w.failures[ip] = append(w.failures[ip], now)
+	if len(w.users[ip]) < 10 && !contains(w.users[ip], user) {
+		w.users[ip] = append(w.users[ip], user)
+	}
+}
+
+// bruteForceEvent summarizes source IPs over the threshold that haven't been reported recently
+func (w *Watcher) bruteForceEvent(now int64) *Event {
+	window := int64(w.cfg.Window)
+	if window <= 0 {
+		window = 300
+	}
+	threshold := w.cfg.FailureThreshold
+	if threshold <= 0 {
+		threshold = 10
+	}
+
+	counts := make(map[string]int)
+	total := 0
+	for ip, times := range w.failures {
+		// Drop failures outside the window
+		kept := times[:0]
+		for _, t := range times {
+			if now-t <= window {
+				kept = append(kept, t)
+			}
+		}
+		if len(kept) == 0 {
+			delete(w.failures, ip)
+			delete(w.users, ip)
+			if now-w.alerted[ip] > window {
+				delete(w.alerted, ip)
+			}
+			continue
+		}
+		w.failures[ip] = kept
+
+		// Re-alert on the same source at most once per window
+		if len(kept) >= threshold && now-w.alerted[ip] > window {
+			counts[ip] = len(kept)
+			total += len(kept)
+			w.alerted[ip] = now
+		}
+	}
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	ips := make([]string, 0, len(counts))
+	for ip := range counts {
+		ips = append(ips, ip)
+	}
+	sort.Slice(ips, func(i, j int) bool { return counts[ips[i]] > counts[ips[j]] })
+
+	users := make(map[string][]string, len(ips))
+	for _, ip := range ips {
+		users[ip] = w.users[ip]
+	}
+	details, _ := json.Marshal(map[string]interface{}{
+		"source_ips": counts,
+		"users":      users,
+		"window":     window,
+	})
+
+	return &Event{
+		Type:      "security",
+		Severity:  "warning",
+		Message:   fmt.Sprintf("Possible SSH brute-force: %d failed logins from %d source(s) in %ds (top: %s x%d)", total, len(ips), window, ips[0], counts[ips[0]]),
+		Timestamp: now,
+		Details:   string(details),
+	}
+}
+
+func isAuthLine(line string) bool {
+	return strings.Contains(line, "sshd") || strings.Contains(line, "sudo")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
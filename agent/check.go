@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yourusername/nodeguarder/api"
+	"github.com/yourusername/nodeguarder/config"
+	"github.com/yourusername/nodeguarder/cron"
+	"github.com/yourusername/nodeguarder/drift"
+	"github.com/yourusername/nodeguarder/ebpf"
+	"github.com/yourusername/nodeguarder/hostenv"
+)
+
+// checkCronLookback is how far back -check reads the cron journal
+const checkCronLookback = 24 * time.Hour
+
+// patternList collects a repeatable flag
+type patternList []string
+
+func (p *patternList) String() string { return strings.Join(*p, ",") }
+
+func (p *patternList) Set(v string) error {
+	*p = append(*p, v)
+	return nil
+}
+
+// runCheck loads the config, runs one collection, one drift scan and one cron
+// log parse, and prints what would be sent without contacting the dashboard.
+// Drift ignore patterns normally come from the dashboard, so they are passed
+// on the command line to try them out.
+func runCheck(configPath string, driftIgnore []string) int {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	hostenv.SetRoot(cfg.HostRoot)
+	fmt.Printf("✓ Config %s loaded\n", configPath)
+	fmt.Printf("  Server ID: %s\n  Dashboard: %s\n  Interval:  %ds\n", cfg.ServerID, cfg.DashboardURL, cfg.Interval)
+	problems := 0
+
+	// Drift scan with the patterns given on the command line
+	driftPaths := cfg.DriftPaths
+	if len(driftPaths) == 0 {
+		driftPaths = []string{"/etc"}
+	}
+	driftPaths = hostPaths(driftPaths)
+	driftDetector := drift.New(driftPaths)
+	if err := driftDetector.SetIgnore(driftIgnore); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		problems++
+	}
+	tracked, ignored, err := driftDetector.Preview()
+	if err != nil {
+		fmt.Printf("❌ Drift scan failed: %v\n", err)
+		problems++
+	} else {
+		fmt.Printf("\nDrift (%s): %d files tracked, %d ignored\n", strings.Join(driftPaths, ", "), len(tracked), len(ignored))
+		for _, path := range ignored {
+			fmt.Printf("  ignored: %s\n", path)
+		}
+	}
+
+	// Cron log parse over the recent journal (or the whole log file)
+	hostEnv = hostenv.Detect()
+	cronMonitor := cron.New(cfg.CronLogPath)
+	cronMonitor.SetContainerized(hostEnv.Container)
+	cronMonitor.SetIgnore(cfg.CronIgnore)
+	cronMonitor.SetTimeouts(cfg.CronGlobalTimeout, cfg.CronTimeouts)
+	cronMonitor.SetSince(time.Now().Add(-checkCronLookback).Unix())
+	var events []api.Event
+	cronEvents, err := cronMonitor.Check()
+	if err != nil {
+		fmt.Printf("❌ Cron log parse failed: %v\n", err)
+		problems++
+	}
+	for _, cronEvent := range cronEvents {
+		if cronEvent.ExitCode != 0 {
+			events = append(events, cronAPIEvent(cronEvent))
+		}
+	}
+	fmt.Printf("\nCron: %d jobs tracked, %d failures in the logs\n", len(cronMonitor.GetTrackedJobs()), len(events))
+
+	// One collection, as pushed on every interval (eBPF is not loaded, its
+	// report shows what this host supports)
+	ebpfUnavailable = ebpf.Unavailable(cfg.EBPFBTFPath, errors.New("not loaded in -check mode"))
+	_, metricsMap, err := collectMetrics(cronMonitor, cfg)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+
+	payload, _ := json.MarshalIndent(metricsMap, "", "  ")
+	fmt.Printf("\nWould send to %s/api/v1/agent/metrics:\n%s\n", cfg.DashboardURL, payload)
+	if len(events) > 0 {
+		payload, _ = json.MarshalIndent(events, "", "  ")
+		fmt.Printf("\nWould send to %s/api/v1/agent/events:\n%s\n", cfg.DashboardURL, payload)
+	}
+
+	if problems > 0 {
+		fmt.Printf("\n❌ %d problem(s) found\n", problems)
+		return 1
+	}
+	fmt.Println("\n✅ Check passed (nothing was sent)")
+	return 0
+}
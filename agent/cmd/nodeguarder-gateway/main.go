@@ -0,0 +1,105 @@
+// Command nodeguarder-gateway terminates agent traffic at a branch office, buffers
+// metrics and events to disk, and syncs them to the central dashboard when the WAN allows.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/yourusername/nodeguarder/api"
+	"github.com/yourusername/nodeguarder/config"
+	"github.com/yourusername/nodeguarder/queue"
+	"github.com/yourusername/nodeguarder/relay"
+)
+
+var Version = "1.0.1"
+
+func main() {
+	configPath := flag.String("config", config.DefaultGatewayConfigPath, "Path to gateway configuration file")
+	flag.Parse()
+
+	cfg, err := config.LoadGateway(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	log.Printf("Starting NodeGuarder Gateway v%s", Version)
+	log.Printf("Dashboard: %s", cfg.DashboardURL)
+
+	gw, err := newGateway(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer gw.Close()
+	gw.server.Start()
+
+	flushTicker := time.NewTicker(time.Duration(cfg.FlushInterval) * time.Second)
+	defer flushTicker.Stop()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	for {
+		select {
+		case <-flushTicker.C:
+			// Pushes are buffered while the dashboard is down, so always drain
+			gw.flush()
+
+		case sig := <-sigChan:
+			log.Printf("Received signal %v, shutting down...", sig)
+			return
+		}
+	}
+}
+
+// gateway is the relay in store-and-forward mode with its disk queue
+type gateway struct {
+	queue  *queue.Queue
+	client *api.Client
+	server *relay.Server
+}
+
+// newGateway opens the queue and sets up the relay; the listener is started by the caller
+func newGateway(cfg *config.GatewayConfig) (*gateway, error) {
+	if err := os.MkdirAll(filepath.Dir(cfg.QueuePath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+	q, err := queue.NewQueue(cfg.QueuePath, cfg.QueueMaxItems)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open queue: %w", err)
+	}
+	q.SetMaxMB(cfg.QueueMaxMB)
+
+	// Downstream agents authenticate with their own credentials; the gateway has none
+	apiClient := api.NewClient(cfg.DashboardURL, "", "", cfg.DisableSSLVerify)
+	apiClient.SetQueue(q)
+
+	server := relay.New(cfg.Listen, apiClient, q)
+	server.SetStoreAndForward(true)
+	if cfg.TLSCert != "" && cfg.TLSKey != "" {
+		server.SetTLS(cfg.TLSCert, cfg.TLSKey)
+	}
+	return &gateway{queue: q, client: apiClient, server: server}, nil
+}
+
+// flush syncs buffered pushes to the dashboard
+func (g *gateway) flush() {
+	sent, failed, err := g.client.FlushQueue()
+	if err != nil {
+		log.Printf("Queue flush attempt: %d sent, %d failed - error: %v", sent, failed, err)
+	} else if sent > 0 {
+		log.Printf("✓ Queue flush: %d sent successfully, %d failed", sent, failed)
+	}
+}
+
+// Close stops the listener and closes the queue
+func (g *gateway) Close() {
+	g.server.Close()
+	g.queue.Close()
+}
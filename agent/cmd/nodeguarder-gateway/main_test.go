@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yourusername/nodeguarder/config"
+)
+
+// fakeDashboard accepts srv-1 with the secret "good" and records the pushes it
+// receives; while down it drops every connection
+type fakeDashboard struct {
+	*httptest.Server
+	down   atomic.Bool
+	mu     sync.Mutex
+	pushes []string
+}
+
+func newFakeDashboard(t *testing.T) *fakeDashboard {
+	d := &fakeDashboard{}
+	d.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d.down.Load() {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		if r.Method == http.MethodGet {
+			if r.URL.Query().Get("api_secret") != "good" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"interval":30,"collect_logs":true,"target_version":"1.2.0"}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		d.mu.Lock()
+		d.pushes = append(d.pushes, r.URL.Path+" "+string(body))
+		d.mu.Unlock()
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	t.Cleanup(d.Close)
+	return d
+}
+
+func (d *fakeDashboard) received() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.pushes...)
+}
+
+// startGateway runs a gateway on a free local port and returns its base URL
+func startGateway(t *testing.T, dashboardURL string) (*gateway, string) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	gw, err := newGateway(&config.GatewayConfig{
+		Listen:        addr,
+		DashboardURL:  dashboardURL,
+		QueuePath:     filepath.Join(t.TempDir(), "gateway", "queue.db"),
+		QueueMaxItems: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(gw.Close)
+	gw.server.Start()
+
+	base := "http://" + addr
+	for i := 0; ; i++ {
+		resp, err := http.Get(base + "/health")
+		if err == nil {
+			resp.Body.Close()
+			return gw, base
+		}
+		if i == 50 {
+			t.Fatalf("gateway not listening: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestGatewayStoresAndForwards(t *testing.T) {
+	dashboard := newFakeDashboard(t)
+	gw, base := startGateway(t, dashboard.URL)
+
+	request := func(method, path, body string) (int, string) {
+		t.Helper()
+		req, _ := http.NewRequest(method, base+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(data)
+	}
+
+	// Connected: requests pass through and the dashboard vouches for srv-1
+	if code, _ := request("GET", "/api/v1/agent/config?server_id=srv-1&api_secret=good", ""); code != http.StatusOK {
+		t.Fatalf("config: status %d", code)
+	}
+	if code, _ := request("POST", "/api/v1/agent/metrics", `{"server_id":"srv-1","api_secret":"good","n":0}`); code != http.StatusOK {
+		t.Fatalf("connected push: status %d", code)
+	}
+
+	// WAN outage: pushes are acknowledged and buffered
+	dashboard.down.Store(true)
+	for _, push := range []string{`{"server_id":"srv-1","api_secret":"good","n":1}`, `{"server_id":"srv-1","api_secret":"good","n":2}`} {
+		if code, body := request("POST", "/api/v1/agent/metrics", push); code != http.StatusAccepted {
+			t.Fatalf("push while down: status %d, %s", code, body)
+		}
+	}
+	if code, _ := request("POST", "/api/v1/agent/events", `{"server_id":"srv-1","api_secret":"bad"}`); code != http.StatusUnauthorized {
+		t.Errorf("wrong secret while down: status %d, want 401", code)
+	}
+	if code, _ := request("POST", "/api/v1/agent/events", `{"server_id":"srv-9","api_secret":"good"}`); code != http.StatusBadGateway {
+		t.Errorf("unknown agent while down: status %d, want 502", code)
+	}
+	code, body := request("GET", "/api/v1/agent/config?server_id=srv-1&api_secret=good", "")
+	var cfg map[string]interface{}
+	json.Unmarshal([]byte(body), &cfg)
+	if code != http.StatusOK || cfg["interval"] != float64(30) || cfg["collect_logs"] != false || cfg["target_version"] != nil {
+		t.Errorf("cached config: status %d, %s", code, body)
+	}
+	if n, _ := gw.queue.GetSize(); n != 2 {
+		t.Fatalf("queued %d pushes, want 2", n)
+	}
+
+	// Back online: the flush delivers the buffered pushes in order
+	dashboard.down.Store(false)
+	gw.flush()
+	got := dashboard.received()
+	if len(got) != 3 || !strings.Contains(got[1], `"n":1`) || !strings.Contains(got[2], `"n":2`) {
+		t.Fatalf("dashboard received %q", got)
+	}
+	for _, push := range got {
+		if !strings.HasPrefix(push, "/api/v1/agent/metrics ") || !strings.Contains(push, `"api_secret":"good"`) {
+			t.Errorf("push %q not replayed as sent", push)
+		}
+	}
+	if n, _ := gw.queue.GetSize(); n != 0 {
+		t.Errorf("%d pushes left in the queue after the flush", n)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/shirou/gopsutil/v3/mem"
 	"github.com/shirou/gopsutil/v3/process"
 	"sort"
+	"time"
 )
 
 // Metrics represents system telemetry data
@@ -19,6 +20,9 @@ type Metrics struct {
 	MemUsedMB    uint64  `json:"mem_used_mb"`
 	DiskTotalGB  uint64  `json:"disk_total_gb"`
 	DiskUsedGB   uint64  `json:"disk_used_gb"`
+	DiskPercent  float64     `json:"disk_percent"` // Fullest non-excluded mount
+	DiskMount    string      `json:"disk_mount"`   // Mount the disk figures refer to
+	DiskMounts   []DiskMount `json:"disk_mounts"`
 	LoadAvg1     float64 `json:"load_avg_1"`
 	LoadAvg5     float64 `json:"load_avg_5"`
 	LoadAvg15    float64 `json:"load_avg_15"`
@@ -46,8 +50,52 @@ type SystemInfo struct {
 	Platform      string `json:"platform"`
 }
 
+// Sample is a reading of the core metrics taken between pushes, sent in
+// batches with the next push (no process list or per-mount breakdown)
+type Sample struct {
+	Timestamp    int64   `json:"timestamp"`
+	CPUPercent   float64 `json:"cpu_percent"`
+	MemTotalMB   uint64  `json:"mem_total_mb"`
+	MemUsedMB    uint64  `json:"mem_used_mb"`
+	DiskTotalGB  uint64  `json:"disk_total_gb"`
+	DiskUsedGB   uint64  `json:"disk_used_gb"`
+	LoadAvg1     float64 `json:"load_avg_1"`
+	LoadAvg5     float64 `json:"load_avg_5"`
+	LoadAvg15    float64 `json:"load_avg_15"`
+	ProcessCount int     `json:"process_count"`
+	Uptime       uint64  `json:"uptime"`
+}
+
 // Collect gathers all system metrics
 func Collect() (*Metrics, error) {
+	metrics := collectCore()
+
+	// Top Processes
+	metrics.Processes = collectTopProcesses()
+
+	return metrics, nil
+}
+
+// CollectSample takes a sample of the core metrics
+func CollectSample() Sample {
+	m := collectCore()
+	return Sample{
+		Timestamp:    time.Now().Unix(),
+		CPUPercent:   m.CPUPercent,
+		MemTotalMB:   m.MemTotalMB,
+		MemUsedMB:    m.MemUsedMB,
+		DiskTotalGB:  m.DiskTotalGB,
+		DiskUsedGB:   m.DiskUsedGB,
+		LoadAvg1:     m.LoadAvg1,
+		LoadAvg5:     m.LoadAvg5,
+		LoadAvg15:    m.LoadAvg15,
+		ProcessCount: m.ProcessCount,
+		Uptime:       m.Uptime,
+	}
+}
+
+// collectCore gathers everything but the process list
+func collectCore() *Metrics {
 	metrics := &Metrics{}
 
 	// CPU usage
@@ -62,14 +110,23 @@ func Collect() (*Metrics, error) {
 		metrics.MemUsedMB = vmem.Used / 1024 / 1024
 	}
 
-	// Disk usage (root partition)
-	if diskUsage, err := disk.Usage("/"); err == nil {
+	// Disk usage: the fullest real filesystem (snaps, overlays, bind mounts and
+	// other configured exclusions left out), falling back to the root partition
+	metrics.DiskMounts = collectDiskMounts()
+	if fullest, ok := fullestMount(metrics.DiskMounts); ok {
+		metrics.DiskTotalGB = fullest.TotalMB / 1024
+		metrics.DiskUsedGB = fullest.UsedMB / 1024
+		metrics.DiskPercent = fullest.Percent
+		metrics.DiskMount = fullest.Mount
+	} else if diskUsage, err := disk.Usage("/"); err == nil {
 		metrics.DiskTotalGB = diskUsage.Total / 1024 / 1024 / 1024
 		metrics.DiskUsedGB = diskUsage.Used / 1024 / 1024 / 1024
+		metrics.DiskPercent = diskUsage.UsedPercent
+		metrics.DiskMount = "/"
 	}
 
-	// Load average (Linux/Unix only)
-	if runtime.GOOS == "linux" {
+	// Load average (Unix only: Linux, FreeBSD, macOS)
+	if runtime.GOOS != "windows" {
 		if loadAvg, err := load.Avg(); err == nil {
 			metrics.LoadAvg1 = loadAvg.Load1
 			metrics.LoadAvg5 = loadAvg.Load5
@@ -87,13 +144,10 @@ func Collect() (*Metrics, error) {
 		metrics.Uptime = uptime
 	}
 
-	// Top Processes
-	metrics.Processes = collectTopProcesses()
-
-	return metrics, nil
+	return metrics
 }
 
-// collectTopProcesses gathers top 5 processes by CPU and Memory
+// collectTopProcesses gathers the top processes by CPU and Memory
 func collectTopProcesses() []ProcessInfo {
 	procs, err := process.Processes()
 	if err != nil {
@@ -127,11 +181,11 @@ func collectTopProcesses() []ProcessInfo {
 		return parsedProcs[i].CPU > parsedProcs[j].CPU
 	})
 
-	// Take top 5
+	// Take top N (smaller in lite builds)
 	top := []ProcessInfo{}
 	count := 0
 	for _, p := range parsedProcs {
-		if count >= 5 {
+		if count >= topProcessCount {
 			break
 		}
 		top = append(top, p)
@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shirou/gopsutil/v3/disk"
+)
+
+// Default disk exclusions, used until the dashboard delivers its own
+var (
+	DefaultDiskExcludeFSTypes = []string{"squashfs", "overlay", "tmpfs", "devtmpfs", "nsfs", "autofs"}
+	DefaultDiskExcludePaths   = []string{"/snap", "/var/lib/docker", "/var/lib/containers", "/run"}
+)
+
+// DiskMount is the usage of one mounted filesystem that counts towards disk health
+type DiskMount struct {
+	Mount   string  `json:"mount"`
+	Device  string  `json:"device"`
+	FSType  string  `json:"fstype"`
+	TotalMB uint64  `json:"total_mb"`
+	UsedMB  uint64  `json:"used_mb"`
+	Percent float64 `json:"percent"`
+}
+
+var diskExclusions = struct {
+	sync.RWMutex
+	fstypes []string
+	paths   []string
+}{fstypes: DefaultDiskExcludeFSTypes, paths: DefaultDiskExcludePaths}
+
+// SetDiskExclusions replaces the filesystem types and mount paths left out of
+// disk metrics. A nil list (dashboard without the setting) keeps the current one.
+func SetDiskExclusions(fstypes, paths []string) {
+	diskExclusions.Lock()
+	defer diskExclusions.Unlock()
+	if fstypes != nil {
+		diskExclusions.fstypes = fstypes
+	}
+	if paths != nil {
+		diskExclusions.paths = paths
+	}
+}
+
+// DiskExcluded reports whether a mount is left out of disk metrics: its fstype is
+// listed, or its mount point is a listed path, lies below one, or matches a glob
+func DiskExcluded(mount, fstype string, fstypes, paths []string) bool {
+	for _, t := range fstypes {
+		if strings.EqualFold(t, fstype) {
+			return true
+		}
+	}
+	for _, p := range paths {
+		if strings.ContainsAny(p, "*?[") {
+			if ok, _ := filepath.Match(p, mount); ok {
+				return true
+			}
+			continue
+		}
+		p = filepath.Clean(p)
+		if mount == p || strings.HasPrefix(mount, strings.TrimSuffix(p, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// collectDiskMounts returns the usage of each physical filesystem that is not
+// excluded. Bind mounts of the same device are counted once, under the shortest
+// mount point.
+func collectDiskMounts() []DiskMount {
+	partitions, err := disk.Partitions(false)
+	if err != nil {
+		return nil
+	}
+
+	diskExclusions.RLock()
+	fstypes, paths := diskExclusions.fstypes, diskExclusions.paths
+	diskExclusions.RUnlock()
+
+	byDevice := map[string]disk.PartitionStat{}
+	for _, p := range partitions {
+		if DiskExcluded(p.Mountpoint, p.Fstype, fstypes, paths) {
+			continue
+		}
+		if seen, ok := byDevice[p.Device]; ok && len(seen.Mountpoint) <= len(p.Mountpoint) {
+			continue
+		}
+		byDevice[p.Device] = p
+	}
+
+	mounts := []DiskMount{}
+	for _, p := range byDevice {
+		usage, err := disk.Usage(p.Mountpoint)
+		if err != nil || usage.Total == 0 {
+			continue
+		}
+		mounts = append(mounts, DiskMount{
+			Mount:   p.Mountpoint,
+			Device:  p.Device,
+			FSType:  p.Fstype,
+			TotalMB: usage.Total / 1024 / 1024,
+			UsedMB:  usage.Used / 1024 / 1024,
+			Percent: usage.UsedPercent,
+		})
+	}
+	sort.Slice(mounts, func(i, j int) bool { return mounts[i].Mount < mounts[j].Mount })
+	return mounts
+}
+
+// fullestMount returns the mount closest to full, which drives disk alerts
+func fullestMount(mounts []DiskMount) (DiskMount, bool) {
+	var fullest DiskMount
+	for _, m := range mounts {
+		if m.Percent > fullest.Percent || fullest.Mount == "" {
+			fullest = m
+		}
+	}
+	return fullest, fullest.Mount != ""
+}
@@ -0,0 +1,6 @@
+//go:build !lite
+
+package collector
+
+// topProcessCount is the number of processes reported per metrics push
+const topProcessCount = 5
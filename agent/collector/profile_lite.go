@@ -0,0 +1,6 @@
+//go:build lite
+
+package collector
+
+// topProcessCount is reduced in lite builds to keep scans and payloads small
+const topProcessCount = 3
@@ -6,14 +6,23 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 
 	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
+
+	"github.com/yourusername/nodeguarder/authwatch"
+	"github.com/yourusername/nodeguarder/drift"
+	"github.com/yourusername/nodeguarder/portcheck"
 )
 
 const (
-	DefaultConfigPath = "/etc/nodeguarder-agent/config.yaml"
-	DefaultInterval   = 60 // seconds
+	DefaultConfigPath  = "/etc/nodeguarder-agent/config.yaml"
+	DefaultInterval    = 60            // seconds
+	MinSampleInterval  = 5             // seconds
+	DefaultJitter      = 10            // percent
+	MaxJitter          = 50            // percent
+	DefaultQueueMaxAge = 7 * 24 * 3600 // seconds
 )
 
 type (
@@ -21,11 +30,18 @@ type (
 		ServerID          string `yaml:"server_id" json:"server_id"`
 		APISecret         string `yaml:"api_secret" json:"api_secret"`
 		DashboardURL      string `yaml:"dashboard_url" json:"dashboard_url"`
+		Transport         string `yaml:"transport" json:"transport"`       // "rest" (default) or "grpc"
+		GRPCAddress       string `yaml:"grpc_address" json:"grpc_address"` // host:port of the dashboard gRPC listener
 		RegistrationToken string `yaml:"registration_token" json:"registration_token"`
+		PackageID         string `yaml:"package_id" json:"package_id"` // Install package that onboarded this host
 		Interval          int    `yaml:"interval" json:"interval"`
+		SampleInterval    int    `yaml:"sample_interval" json:"sample_interval"` // Seconds between samples batched into each push (0 = one sample per push)
+		Jitter            int    `yaml:"jitter" json:"jitter"` // Percent the push, drift and queue flush intervals vary by (default 10, 0 = off)
 		Thresholds        Thresholds `yaml:"thresholds" json:"thresholds"`
 		DriftPaths        []string   `yaml:"drift_paths" json:"drift_paths"`
         DriftInterval     int        `yaml:"drift_interval" json:"drift_interval"` // Seconds
+        DriftThrottle     drift.Throttle `yaml:"drift_throttle" json:"drift_throttle"` // Rate limits and priority of drift scans
+        NetworkDriftEnabled bool     `yaml:"network_drift_enabled" json:"network_drift_enabled"`
         HealthEnabled     bool       `yaml:"health_enabled" json:"health_enabled"`
         HealthSustainDuration int    `yaml:"health_sustain_duration" json:"health_sustain_duration"`
         CronEnabled       bool       `yaml:"cron_enabled" json:"cron_enabled"`
@@ -35,8 +51,37 @@ type (
         CronGlobalTimeout int        `yaml:"cron_global_timeout" json:"cron_global_timeout"`
         CronTimeouts      map[string]int `yaml:"cron_timeouts" json:"cron_timeouts"`
         DisableSSLVerify  bool       `yaml:"disable_ssl_verify" json:"disable_ssl_verify"`
+        MTLS              bool       `yaml:"mtls" json:"mtls"`           // Authenticate with a client certificate issued by the dashboard
+        MTLSCert          string     `yaml:"mtls_cert" json:"mtls_cert"` // Default: agent.crt next to the config file
+        MTLSKey           string     `yaml:"mtls_key" json:"mtls_key"`   // Default: agent.key next to the config file
+        RelayListen       string     `yaml:"relay_listen" json:"relay_listen"`     // e.g. "10.0.5.1:8090" - accept pushes from isolated agents
+        RelayTLSCert      string     `yaml:"relay_tls_cert" json:"relay_tls_cert"`
+        RelayTLSKey       string     `yaml:"relay_tls_key" json:"relay_tls_key"`
+        TerminalEnabled   bool       `yaml:"terminal_enabled" json:"terminal_enabled"` // Opt-in: allow remote terminal sessions from the dashboard
+        RemediationEnabled bool      `yaml:"remediation_enabled" json:"remediation_enabled"` // Opt-in: allow service restarts / SIGTERM from the dashboard
+        RemediationUnits  []string   `yaml:"remediation_units" json:"remediation_units"`     // Optional allow-list of restartable units
+        ScriptsEnabled    bool       `yaml:"scripts_enabled" json:"scripts_enabled"` // Opt-in: allow dashboard script runner on this host
+        AuthWatch         authwatch.Config `yaml:"auth_watch" json:"auth_watch"` // SSH brute-force / sudo monitoring
+        PackageInventory  bool       `yaml:"package_inventory" json:"package_inventory"` // Track installed packages (default true)
+        AccountWatch      bool       `yaml:"account_watch" json:"account_watch"` // Report user/group changes (default true)
+        PortChecks        []portcheck.Check `yaml:"port_checks" json:"port_checks"` // Local services that must accept TCP connections
+        EBPFBufferPages   int        `yaml:"ebpf_buffer_pages" json:"ebpf_buffer_pages"` // Per-CPU perf buffer size in pages (default 1)
+        EBPFBTFPath       string     `yaml:"ebpf_btf_path" json:"ebpf_btf_path"` // BTF file for kernels without CONFIG_DEBUG_INFO_BTF (e.g. from BTFHub)
+        QueueMaxItems     int        `yaml:"queue_max_items" json:"queue_max_items"` // Item cap of the offline queue (0 = build default: 1000, lite 200)
+        QueueMaxMB        int        `yaml:"queue_max_mb" json:"queue_max_mb"` // Disk cap of the offline queue (0 = build default: 50MB, lite 10MB)
+        QueueMaxAge       int        `yaml:"queue_max_age" json:"queue_max_age"` // Seconds queued items are kept (default 7 days, 0 = no limit)
+        QueueOverflow     string     `yaml:"queue_overflow" json:"queue_overflow"` // "drop" (default) or "downsample": average old metrics when the queue is full
+        UpdateRollbackMinutes int    `yaml:"update_rollback_minutes" json:"update_rollback_minutes"` // Minutes an updated agent has to complete a push before it is rolled back (default 10)
+        HostRoot          string     `yaml:"host_root" json:"host_root"` // Where the host's filesystem is mounted when running in a container (e.g. /host)
+        HealthListen      string     `yaml:"health_listen" json:"health_listen"` // Local /healthz endpoint (default 127.0.0.1:9810, "off" to disable)
+        Debug             bool       `yaml:"debug" json:"debug"`               // Opt-in: pprof on debug_listen and a periodic self-report in the log
+        DebugListen       string     `yaml:"debug_listen" json:"debug_listen"` // pprof address (default 127.0.0.1:6060)
         CollectLogs       bool       `yaml:"-" json:"collect_logs"`   // Runtime only
         Uninstall         bool       `yaml:"-" json:"uninstall"`       // Runtime only
+        TargetVersion     string     `yaml:"-" json:"target_version"`  // Runtime only: version assigned by a staged rollout
+
+        localInterval     int // interval from the config file, while the dashboard overrides it
+        dashboardInterval int // interval set from the dashboard (0 = none)
 	}
 
 	Thresholds struct {
@@ -55,11 +100,22 @@ func Load(path string) (*Config, error) {
 
 	cfg := Config{
 		Interval: DefaultInterval,
+		Jitter:   DefaultJitter,
+		DriftThrottle: drift.DefaultThrottle,
+		QueueMaxAge:   DefaultQueueMaxAge,
 		Thresholds: Thresholds{
 			CPU:    90,
 			Memory: 95,
 			Disk:   90,
 		},
+		AccountWatch: true,
+		PackageInventory: true,
+		AuthWatch: authwatch.Config{
+			Enabled:          true,
+			FailureThreshold: 10,
+			Window:           300,
+			ReportSudo:       true,
+		},
 	}
 	if err := yaml.Unmarshal(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
@@ -75,7 +131,47 @@ func Load(path string) (*Config, error) {
 	if cfg.DashboardURL == "" {
 		return nil, fmt.Errorf("dashboard_url is required")
 	}
+	switch cfg.Transport {
+	case "", "rest":
+	case "grpc":
+		if cfg.GRPCAddress == "" {
+			return nil, fmt.Errorf("grpc_address is required with transport: grpc")
+		}
+	default:
+		return nil, fmt.Errorf("unknown transport %q (use rest or grpc)", cfg.Transport)
+	}
+	if cfg.UpdateRollbackMinutes < 0 {
+		return nil, fmt.Errorf("update_rollback_minutes must not be negative")
+	}
+	if cfg.QueueMaxItems < 0 || cfg.QueueMaxMB < 0 || cfg.QueueMaxAge < 0 {
+		return nil, fmt.Errorf("queue_max_items, queue_max_mb and queue_max_age must not be negative")
+	}
+	switch cfg.QueueOverflow {
+	case "", "drop", "downsample":
+	default:
+		return nil, fmt.Errorf("unknown queue_overflow %q (use drop or downsample)", cfg.QueueOverflow)
+	}
 	// Interval default handled in initialization
+	if cfg.SampleInterval > 0 && cfg.SampleInterval < MinSampleInterval {
+		cfg.SampleInterval = MinSampleInterval
+	}
+	cfg.localInterval = cfg.Interval
+	if cfg.Jitter < 0 {
+		cfg.Jitter = 0
+	} else if cfg.Jitter > MaxJitter {
+		cfg.Jitter = MaxJitter
+	}
+	if cfg.DriftThrottle.Nice < 0 {
+		cfg.DriftThrottle.Nice = 0
+	} else if cfg.DriftThrottle.Nice > 19 {
+		cfg.DriftThrottle.Nice = 19
+	}
+	if cfg.MTLSCert == "" {
+		cfg.MTLSCert = filepath.Join(filepath.Dir(path), "agent.crt")
+	}
+	if cfg.MTLSKey == "" {
+		cfg.MTLSKey = filepath.Join(filepath.Dir(path), "agent.key")
+	}
 
 	return &cfg, nil
 }
@@ -100,6 +196,81 @@ func (c *Config) Save(path string) error {
 	return nil
 }
 
+// Reload applies the locally owned settings of next (the config file as just
+// loaded) that can change while the agent runs, and returns the names of
+// changed settings that only take effect after a restart. Settings managed
+// from the dashboard (thresholds, drift, health and cron options) are left
+// to the next config refresh.
+func (c *Config) Reload(next *Config) (restart []string) {
+	if next.Interval > 0 {
+		c.localInterval = next.Interval
+		if c.dashboardInterval == 0 {
+			c.Interval = next.Interval
+		}
+	}
+	c.SampleInterval = next.SampleInterval
+	c.Jitter = next.Jitter
+	c.DriftThrottle = next.DriftThrottle
+	c.QueueMaxItems = next.QueueMaxItems
+	c.QueueMaxMB = next.QueueMaxMB
+	c.QueueMaxAge = next.QueueMaxAge
+	c.QueueOverflow = next.QueueOverflow
+	c.RegistrationToken = next.RegistrationToken
+	c.PackageID = next.PackageID
+	c.TerminalEnabled = next.TerminalEnabled
+	c.RemediationEnabled = next.RemediationEnabled
+	c.RemediationUnits = next.RemediationUnits
+	c.ScriptsEnabled = next.ScriptsEnabled
+	c.AuthWatch = next.AuthWatch
+	c.PackageInventory = next.PackageInventory
+	c.AccountWatch = next.AccountWatch
+	c.PortChecks = next.PortChecks
+
+	fixed := []struct {
+		name      string
+		old, next interface{}
+	}{
+		{"server_id", c.ServerID, next.ServerID},
+		{"api_secret", c.APISecret, next.APISecret},
+		{"dashboard_url", c.DashboardURL, next.DashboardURL},
+		{"transport", c.Transport, next.Transport},
+		{"grpc_address", c.GRPCAddress, next.GRPCAddress},
+		{"disable_ssl_verify", c.DisableSSLVerify, next.DisableSSLVerify},
+		{"mtls", c.MTLS, next.MTLS},
+		{"mtls_cert", c.MTLSCert, next.MTLSCert},
+		{"mtls_key", c.MTLSKey, next.MTLSKey},
+		{"relay_listen", c.RelayListen, next.RelayListen},
+		{"relay_tls_cert", c.RelayTLSCert, next.RelayTLSCert},
+		{"relay_tls_key", c.RelayTLSKey, next.RelayTLSKey},
+		{"cron_log_path", c.CronLogPath, next.CronLogPath},
+		{"drift_paths", c.DriftPaths, next.DriftPaths},
+		{"ebpf_buffer_pages", c.EBPFBufferPages, next.EBPFBufferPages},
+		{"ebpf_btf_path", c.EBPFBTFPath, next.EBPFBTFPath},
+		{"update_rollback_minutes", c.UpdateRollbackMinutes, next.UpdateRollbackMinutes},
+		{"host_root", c.HostRoot, next.HostRoot},
+		{"health_listen", c.HealthListen, next.HealthListen},
+		{"debug", c.Debug, next.Debug},
+		{"debug_listen", c.DebugListen, next.DebugListen},
+	}
+	for _, f := range fixed {
+		if !reflect.DeepEqual(f.old, f.next) {
+			restart = append(restart, f.name)
+		}
+	}
+	return restart
+}
+
+// SetDashboardInterval applies the push interval set for this server on the
+// dashboard; 0 returns to the interval of the config file
+func (c *Config) SetDashboardInterval(seconds int) {
+	c.dashboardInterval = seconds
+	if seconds > 0 {
+		c.Interval = seconds
+	} else if c.localInterval > 0 {
+		c.Interval = c.localInterval
+	}
+}
+
 // GenerateDefault creates a new configuration with generated credentials
 func GenerateDefault(dashboardURL string) *Config {
 	return &Config{
@@ -107,6 +278,9 @@ func GenerateDefault(dashboardURL string) *Config {
 		APISecret:    generateSecret(),
 		DashboardURL: dashboardURL,
 		Interval:     DefaultInterval,
+		Jitter:       DefaultJitter,
+		DriftThrottle: drift.DefaultThrottle,
+		QueueMaxAge:   DefaultQueueMaxAge,
 		Thresholds: Thresholds{
 			CPU:    90,
 			Memory: 95,
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	write := func(body string) *Config {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(body), 0600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cfg
+	}
+
+	cfg := write("server_id: srv-1\napi_secret: s\ndashboard_url: https://a\ninterval: 60\n")
+	cfg.Thresholds.CPU = 70 // set by the dashboard
+
+	next := write("server_id: srv-1\napi_secret: s\ndashboard_url: https://b\ninterval: 30\nscripts_enabled: true\nthresholds:\n  cpu: 99\n")
+	restart := cfg.Reload(next)
+
+	if cfg.Interval != 30 || !cfg.ScriptsEnabled {
+		t.Errorf("runtime settings not applied: interval=%d scripts=%v", cfg.Interval, cfg.ScriptsEnabled)
+	}
+	if cfg.Thresholds.CPU != 70 {
+		t.Errorf("dashboard-managed thresholds must be kept, got cpu=%d", cfg.Thresholds.CPU)
+	}
+	if cfg.DashboardURL != "https://a" || !reflect.DeepEqual(restart, []string{"dashboard_url"}) {
+		t.Errorf("dashboard_url must wait for a restart: url=%s restart=%v", cfg.DashboardURL, restart)
+	}
+}
+
+func TestLoadJitter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	base := "server_id: srv-1\napi_secret: s\ndashboard_url: https://a\n"
+	for body, want := range map[string]int{
+		base:                  DefaultJitter,
+		base + "jitter: 0\n":  0,
+		base + "jitter: 25\n": 25,
+		base + "jitter: 90\n": MaxJitter,
+	} {
+		if err := os.WriteFile(path, []byte(body), 0600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Jitter != want {
+			t.Errorf("jitter = %d, want %d for\n%s", cfg.Jitter, want, body)
+		}
+	}
+}
+
+func TestLoadDriftThrottle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	body := "server_id: srv-1\napi_secret: s\ndashboard_url: https://a\ndrift_throttle:\n  files_per_sec: 200\n  nice: 40\n"
+	if err := os.WriteFile(path, []byte(body), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	th := cfg.DriftThrottle
+	if th.FilesPerSec != 200 || th.Nice != 19 || !th.SkipUnchanged {
+		t.Errorf("drift_throttle = %+v, want 200 files/s, nice clamped to 19 and skip_unchanged kept", th)
+	}
+}
+
+func TestLoadQueueLimits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	base := "server_id: srv-1\napi_secret: s\ndashboard_url: https://a\n"
+	load := func(body string) (*Config, error) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(body), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return Load(path)
+	}
+
+	cfg, err := load(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.QueueMaxAge != DefaultQueueMaxAge || cfg.QueueMaxItems != 0 || cfg.QueueMaxMB != 0 {
+		t.Errorf("unexpected queue defaults: items=%d mb=%d age=%d", cfg.QueueMaxItems, cfg.QueueMaxMB, cfg.QueueMaxAge)
+	}
+	cfg, err = load(base + "queue_max_items: 5000\nqueue_max_mb: 200\nqueue_max_age: 0\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.QueueMaxItems != 5000 || cfg.QueueMaxMB != 200 || cfg.QueueMaxAge != 0 {
+		t.Errorf("queue limits not loaded: items=%d mb=%d age=%d", cfg.QueueMaxItems, cfg.QueueMaxMB, cfg.QueueMaxAge)
+	}
+	if _, err := load(base + "queue_max_items: -1\n"); err == nil {
+		t.Error("negative queue_max_items must be rejected")
+	}
+}
+
+func TestDashboardInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	load := func(body string) *Config {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(body), 0600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cfg
+	}
+
+	cfg := load("server_id: srv-1\napi_secret: s\ndashboard_url: https://a\ninterval: 60\n")
+	cfg.SetDashboardInterval(15)
+	if cfg.Interval != 15 {
+		t.Fatalf("dashboard interval not applied: %d", cfg.Interval)
+	}
+
+	// Editing the file keeps the dashboard interval in effect
+	cfg.Reload(load("server_id: srv-1\napi_secret: s\ndashboard_url: https://a\ninterval: 120\n"))
+	if cfg.Interval != 15 {
+		t.Errorf("reload must keep the dashboard interval, got %d", cfg.Interval)
+	}
+
+	// Clearing it on the dashboard returns to the file
+	cfg.SetDashboardInterval(0)
+	if cfg.Interval != 120 {
+		t.Errorf("interval = %d, want the file's 120", cfg.Interval)
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	DefaultGatewayConfigPath = "/etc/nodeguarder-gateway/config.yaml"
+	DefaultGatewayQueuePath  = "/var/lib/nodeguarder-gateway/queue.db"
+)
+
+// GatewayConfig configures the standalone edge gateway
+type GatewayConfig struct {
+	Listen           string `yaml:"listen" json:"listen"`               // e.g. "0.0.0.0:8090"
+	DashboardURL     string `yaml:"dashboard_url" json:"dashboard_url"` // Central dashboard
+	QueuePath        string `yaml:"queue_path" json:"queue_path"`
+	QueueMaxItems    int    `yaml:"queue_max_items" json:"queue_max_items"`
+	QueueMaxMB       int    `yaml:"queue_max_mb" json:"queue_max_mb"`     // Disk cap of queued payloads (0 = none)
+	FlushInterval    int    `yaml:"flush_interval" json:"flush_interval"` // Seconds
+	DisableSSLVerify bool   `yaml:"disable_ssl_verify" json:"disable_ssl_verify"`
+	TLSCert          string `yaml:"tls_cert" json:"tls_cert"`
+	TLSKey           string `yaml:"tls_key" json:"tls_key"`
+}
+
+// LoadGateway reads the gateway configuration file from the given path
+func LoadGateway(path string) (*GatewayConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg := GatewayConfig{
+		Listen:        "0.0.0.0:8090",
+		QueuePath:     DefaultGatewayQueuePath,
+		QueueMaxItems: 50000, // Branch offices can be cut off for hours
+		QueueMaxMB:    500,
+		FlushInterval: 15,
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if cfg.DashboardURL == "" {
+		return nil, fmt.Errorf("dashboard_url is required")
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 15
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/yourusername/nodeguarder/config"
+	"github.com/yourusername/nodeguarder/hostenv"
+)
+
+// bootstrapContainerConfig writes a config from NODEGUARDER_* environment
+// variables when none exists yet. The DaemonSet mounts the config directory
+// from the node, so each node gets its own server_id and api_secret on the
+// first start and keeps them across pod restarts.
+func bootstrapContainerConfig(configPath string) error {
+	dashboardURL := os.Getenv("NODEGUARDER_DASHBOARD_URL")
+	if dashboardURL == "" {
+		return nil
+	}
+	if _, err := os.Stat(configPath); !os.IsNotExist(err) {
+		return nil
+	}
+
+	cfg := config.GenerateDefault(dashboardURL)
+	cfg.RegistrationToken = os.Getenv("NODEGUARDER_REGISTRATION_TOKEN")
+	cfg.PackageID = os.Getenv("NODEGUARDER_PACKAGE_ID")
+	cfg.HostRoot = os.Getenv("NODEGUARDER_HOST_ROOT")
+	cfg.DisableSSLVerify = os.Getenv("NODEGUARDER_INSECURE") == "true"
+
+	if err := cfg.Save(configPath); err != nil {
+		return err
+	}
+	log.Printf("📝 Created %s from the environment (server ID %s)", configPath, cfg.ServerID)
+	return nil
+}
+
+// hostPaths maps host paths (drift paths) to where the agent sees them
+func hostPaths(paths []string) []string {
+	if hostenv.Root() == "" {
+		return paths
+	}
+	mapped := make([]string, len(paths))
+	for i, p := range paths {
+		mapped[i] = hostenv.Path(p)
+	}
+	return mapped
+}
@@ -0,0 +1,146 @@
+package cron
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	osuser "os/user"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"syscall"
+)
+
+// CaptureDir holds the output of failed wrapped jobs. Jobs run as any user,
+// so it is world-writable with the sticky bit, like /tmp.
+const CaptureDir = "/var/tmp/nodeguarder-cron"
+
+// CaptureLines is how many trailing output lines are kept per failed run
+const CaptureLines = 50
+
+// maxCaptureLine truncates very long output lines
+const maxCaptureLine = 512
+
+// Capture is the tail of a failed job's output, written by the -wrap wrapper
+type Capture struct {
+	Command    string   `json:"command"`
+	ExitCode   int      `json:"exit_code"`
+	FinishedAt int64    `json:"finished_at"`
+	Output     []string `json:"output"`
+}
+
+// wrapperPattern matches the wrapper prefix in crontab lines, e.g.
+// "/opt/nodeguarder-agent/nodeguarder-agent -wrap -- /usr/local/bin/backup.sh"
+var wrapperPattern = regexp.MustCompile(`^\S*nodeguarder-agent\s+--?wrap(\s+--)?\s+`)
+
+// unwrapCommand strips the output-capture wrapper so jobs are tracked (and
+// configured) under their real command whether or not they are wrapped
+func unwrapCommand(command string) string {
+	if loc := wrapperPattern.FindStringIndex(command); loc != nil {
+		return command[loc[1]:]
+	}
+	return command
+}
+
+// capturePath names the capture file by owner UID and command hash, so users
+// cannot overwrite (or pre-create) each other's captures in the shared directory
+func capturePath(uid int, command string) string {
+	return filepath.Join(CaptureDir, fmt.Sprintf("%d-%s.json", uid, captureKey(command)))
+}
+
+func captureKey(command string) string {
+	sum := sha256.Sum256([]byte(command))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ensureCaptureDir creates the capture directory with /tmp-like permissions
+// (Mkdir applies the umask and drops the sticky bit, hence the Chmod)
+func ensureCaptureDir() error {
+	if err := os.Mkdir(CaptureDir, 0700); err != nil {
+		if os.IsExist(err) {
+			return nil
+		}
+		return err
+	}
+	return os.Chmod(CaptureDir, os.ModeSticky|0777)
+}
+
+// PrepareCaptureDir creates the capture directory at agent startup, before any
+// job can claim it
+func PrepareCaptureDir() {
+	if err := ensureCaptureDir(); err != nil {
+		log.Printf("Warning: Failed to create cron capture directory: %v", err)
+		return
+	}
+	info, err := os.Lstat(CaptureDir)
+	if err != nil {
+		return
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && st.Uid != 0 && os.Geteuid() == 0 {
+		log.Printf("⚠️  %s is not owned by root, cron output capture may be tampered with", CaptureDir)
+		return
+	}
+	os.Chmod(CaptureDir, os.ModeSticky|0777)
+}
+
+// loadCapture returns the output of the latest failed run of command finished
+// at or after since. Captures must be owned by the UID in their name, and by
+// the job's user when known, so one user cannot forge another's output.
+func loadCapture(command, user string, since int64) []string {
+	wantUID := -1
+	if user != "" {
+		if u, err := osuser.Lookup(user); err == nil {
+			wantUID, _ = strconv.Atoi(u.Uid)
+		}
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(CaptureDir, "*-"+captureKey(command)+".json"))
+	var latest *Capture
+	for _, path := range paths {
+		var uid int
+		if _, err := fmt.Sscanf(filepath.Base(path), "%d-", &uid); err != nil {
+			continue
+		}
+		if wantUID >= 0 && uid != wantUID {
+			continue
+		}
+		c, err := readCapture(path, uid)
+		if err != nil || c.Command != command || c.FinishedAt < since {
+			continue
+		}
+		if latest == nil || c.FinishedAt > latest.FinishedAt {
+			latest = c
+		}
+	}
+	if latest == nil {
+		return nil
+	}
+	return latest.Output
+}
+
+func readCapture(path string, uid int) (*Capture, error) {
+	f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NOFOLLOW, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !info.Mode().IsRegular() || !ok || int(st.Uid) != uid {
+		return nil, fmt.Errorf("%s: unexpected owner or type", path)
+	}
+
+	var c Capture
+	if err := json.NewDecoder(io.LimitReader(f, 256*1024)).Decode(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
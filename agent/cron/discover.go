@@ -0,0 +1,195 @@
+package cron
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DiscoveredJob is a job defined in a crontab, known before it ever runs
+type DiscoveredJob struct {
+	Command  string
+	User     string
+	Schedule string // e.g. "*/5 * * * *" or "@daily"
+	Source   string // file (or cron.<period> directory) defining the job
+}
+
+// periodicDirs are run by run-parts from /etc/crontab (or anacron)
+var periodicDirs = map[string]string{
+	"/etc/cron.hourly":  "@hourly",
+	"/etc/cron.daily":   "@daily",
+	"/etc/cron.weekly":  "@weekly",
+	"/etc/cron.monthly": "@monthly",
+}
+
+// discoveryInterval throttles crontab rescans
+const discoveryInterval = 5 * time.Minute
+
+var envLinePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\s*=`)
+
+// discoverJobs reads all crontabs (crontabSources) and periodic script directories
+func discoverJobs() []DiscoveredJob {
+	var jobs []DiscoveredJob
+
+	for _, pattern := range crontabSources {
+		files, _ := filepath.Glob(pattern)
+		for _, path := range files {
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() || skipCronFile(filepath.Base(path)) {
+				continue
+			}
+			owner := filepath.Base(path) // user crontabs are named after their owner
+			if isSystemCrontab(path) {
+				owner = "" // system crontabs carry a user column
+			}
+			jobs = append(jobs, parseCrontab(path, owner)...)
+		}
+	}
+	for dir, schedule := range periodicDirs {
+		for _, path := range cronFiles(dir) {
+			if info, err := os.Stat(path); err != nil || info.Mode()&0111 == 0 {
+				continue // run-parts only runs executables
+			}
+			jobs = append(jobs, DiscoveredJob{Command: path, User: "root", Schedule: schedule, Source: dir})
+		}
+	}
+	return jobs
+}
+
+// isSystemCrontab reports whether a crontab has a user column
+func isSystemCrontab(path string) bool {
+	return path == "/etc/crontab" || filepath.Dir(path) == "/etc/cron.d"
+}
+
+// cronFiles lists the regular files in dir that cron would read
+func cronFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.Type().IsRegular() || skipCronFile(name) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	return files
+}
+
+// skipCronFile mirrors cron/run-parts ignoring hidden, backup and package-manager files
+func skipCronFile(name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasSuffix(name, "~") || name == "placeholder" {
+		return true
+	}
+	for _, suffix := range []string{".dpkg-old", ".dpkg-new", ".dpkg-dist", ".rpmsave", ".rpmnew", ".swp"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCrontab parses a crontab file. System crontabs (/etc/crontab, cron.d)
+// carry a user column; user crontabs pass their owner instead.
+func parseCrontab(path, owner string) []DiscoveredJob {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var jobs []DiscoveredJob
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || envLinePattern.MatchString(line) {
+			continue
+		}
+
+		scheduleFields := 5
+		if strings.HasPrefix(line, "@") {
+			scheduleFields = 1 // @daily, @reboot, ...
+		}
+		userFields := 0
+		if owner == "" {
+			userFields = 1
+		}
+
+		fields, command := splitFields(line, scheduleFields+userFields)
+		if command == "" {
+			continue
+		}
+		job := DiscoveredJob{
+			Command:  unwrapCommand(command),
+			User:     owner,
+			Schedule: strings.Join(fields[:scheduleFields], " "),
+			Source:   path,
+		}
+		if owner == "" {
+			job.User = fields[scheduleFields]
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// splitFields returns the first n whitespace-separated fields and the rest of
+// the line verbatim (commands keep their internal spacing, as cron logs them)
+func splitFields(line string, n int) ([]string, string) {
+	fields := make([]string, 0, n)
+	rest := line
+	for len(fields) < n {
+		rest = strings.TrimLeft(rest, " \t")
+		end := strings.IndexAny(rest, " \t")
+		if rest == "" || end < 0 {
+			return fields, ""
+		}
+		fields = append(fields, rest[:end])
+		rest = rest[end:]
+	}
+	return fields, strings.TrimSpace(rest)
+}
+
+// refreshDiscovered merges crontab-defined jobs into the tracked set so they are
+// listed before their first run. Called with m.mu held.
+func (m *Monitor) refreshDiscovered(now int64) {
+	if now-m.lastDiscovery < int64(discoveryInterval/time.Second) {
+		return
+	}
+	m.lastDiscovery = now
+
+	seen := make(map[string]bool)
+	for _, job := range discoverJobs() {
+		if !m.autoDiscover && !m.configured(job.Command) {
+			continue
+		}
+		seen[job.Command] = true
+
+		record, exists := m.lastSeenJobs[job.Command]
+		if !exists {
+			record = &JobRecord{Command: job.Command}
+			m.lastSeenJobs[job.Command] = record
+		}
+		record.User = job.User
+		record.Schedule = job.Schedule
+		record.Source = job.Source
+	}
+
+	// Jobs removed from their crontab: drop if never run, otherwise age out via Cleanup
+	for cmd, record := range m.lastSeenJobs {
+		if record.Source == "" || seen[cmd] {
+			continue
+		}
+		if record.LastExecTime == 0 {
+			delete(m.lastSeenJobs, cmd)
+		} else {
+			record.Source = ""
+			record.Schedule = ""
+		}
+	}
+}
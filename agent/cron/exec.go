@@ -0,0 +1,81 @@
+package cron
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// jobShells run the crontab command line given to "-c"
+var jobShells = map[string]bool{
+	"sh": true, "bash": true, "dash": true, "ash": true, "ksh": true, "zsh": true, "busybox": true,
+}
+
+// ExecCommand returns the crontab command behind a process argv captured by the
+// eBPF exec probe: the "-c" argument of cron's shell, otherwise the argv itself
+func ExecCommand(argv []string) string {
+	if len(argv) == 0 {
+		return ""
+	}
+	if len(argv) >= 3 && jobShells[filepath.Base(argv[0])] && argv[1] == "-c" {
+		return unwrapCommand(argv[2])
+	}
+	return unwrapCommand(strings.Join(argv, " "))
+}
+
+// execMatches reports whether a job command is the one a process exec'd. A
+// truncated argv only has to be a prefix of the command.
+func execMatches(command string, status ExitStatus) bool {
+	execCmd := normalizeCommand(ExecCommand(status.Argv))
+	if execCmd == "" {
+		return false
+	}
+	command = normalizeCommand(command)
+	if status.ArgvTruncated {
+		return strings.HasPrefix(command, execCmd)
+	}
+	return command == execCmd
+}
+
+// jobForExec returns the running job whose command the exited process exec'd,
+// which attributes the exit even when PIDs don't line up (namespaces, PID reuse)
+func (m *Monitor) jobForExec(status ExitStatus) *JobRecord {
+	if len(status.Argv) == 0 {
+		return nil
+	}
+	for _, record := range m.lastSeenJobs {
+		if record.ActivePID != 0 && execMatches(record.Command, status) {
+			return record
+		}
+	}
+	return nil
+}
+
+// takeOrphan removes and returns the early exit belonging to a job start: by PID
+// (direct, fork or namespace match) or by the command the process exec'd
+func (m *Monitor) takeOrphan(pid int32, command string) (orphanExit, bool) {
+	if orphan, ok := m.orphanedExits[pid]; ok {
+		m.dropOrphan(orphan)
+		return orphan, true
+	}
+	for _, o := range m.orphanedExits {
+		if o.ParentPid == pid || (o.NsPid != 0 && o.NsPid == pid) || (o.NsParentPid != 0 && o.NsParentPid == pid) {
+			m.dropOrphan(o)
+			return o, true
+		}
+	}
+	for _, o := range m.orphanedExits {
+		if execMatches(command, o.ExitStatus) {
+			m.dropOrphan(o)
+			return o, true
+		}
+	}
+	return orphanExit{}, false
+}
+
+// dropOrphan removes an orphan under both the keys it was stored by
+func (m *Monitor) dropOrphan(o orphanExit) {
+	delete(m.orphanedExits, o.Pid)
+	if o.NsPid != 0 {
+		delete(m.orphanedExits, o.NsPid)
+	}
+}
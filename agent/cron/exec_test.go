@@ -0,0 +1,62 @@
+package cron
+
+import "testing"
+
+func TestExecCommand(t *testing.T) {
+	tests := []struct {
+		argv []string
+		want string
+	}{
+		{[]string{"/bin/sh", "-c", "/opt/backup.sh --full"}, "/opt/backup.sh --full"},
+		{[]string{"/usr/bin/bash", "-c", "cd /srv && make"}, "cd /srv && make"},
+		{[]string{"/opt/backup.sh", "--full"}, "/opt/backup.sh --full"},
+		{[]string{"/bin/sh", "/opt/run.sh"}, "/bin/sh /opt/run.sh"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := ExecCommand(tt.argv); got != tt.want {
+			t.Errorf("ExecCommand(%q) = %q, want %q", tt.argv, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateJobStatusByExec(t *testing.T) {
+	m := New("")
+	m.lastSeenJobs["/opt/backup.sh --full"] = &JobRecord{Command: "/opt/backup.sh --full", ActivePID: 100, StartTime: 1}
+	m.lastSeenJobs["/opt/report.sh"] = &JobRecord{Command: "/opt/report.sh", ActivePID: 200, StartTime: 1}
+
+	// The PIDs point at the report job, the exec'd command at the backup
+	status := ExitStatus{ExitCode: 2, Argv: []string{"/bin/sh", "-c", "/opt/backup.sh  --full"}}
+	if !m.UpdateJobStatusByPID(200, 1, 0, 0, status) {
+		t.Fatal("expected the exit to match a job")
+	}
+	if rec := m.lastSeenJobs["/opt/backup.sh --full"]; rec.ActivePID != 0 || rec.LastExitCode != 2 {
+		t.Errorf("backup job not updated: %+v", rec)
+	}
+	if rec := m.lastSeenJobs["/opt/report.sh"]; rec.ActivePID != 200 {
+		t.Errorf("report job should still be running: %+v", rec)
+	}
+
+	// A truncated argv matches by prefix
+	m.lastSeenJobs["/opt/report.sh"].Command = "/opt/report.sh --with-a-very-long-argument"
+	status = ExitStatus{ExitCode: 1, Argv: []string{"/bin/sh", "-c", "/opt/report.sh --with-a-v"}, ArgvTruncated: true}
+	if !m.UpdateJobStatusByPID(999, 1, 0, 0, status) {
+		t.Fatal("expected the truncated argv to match by prefix")
+	}
+}
+
+func TestOrphanMatchedByExec(t *testing.T) {
+	m := New("")
+	status := ExitStatus{ExitCode: 1, Argv: []string{"/bin/sh", "-c", "/opt/backup.sh"}}
+	if m.UpdateJobStatusByPID(300, 299, 7, 6, status) {
+		t.Fatal("expected an orphan without a running job")
+	}
+
+	orphan, ok := m.takeOrphan(12345, "/opt/backup.sh")
+	if !ok || orphan.ExitCode != 1 {
+		t.Fatalf("orphan not found by command: %+v %v", orphan, ok)
+	}
+	if len(m.orphanedExits) != 0 {
+		t.Errorf("orphan should be removed under both PIDs, left %v", m.orphanedExits)
+	}
+}
@@ -0,0 +1,83 @@
+package cron
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExitStatus describes how a job process terminated, as captured by eBPF.
+// ExitCode keeps the shell convention (128+signal when killed) so cron_ignore
+// rules written against exit codes keep matching.
+type ExitStatus struct {
+	ExitCode   int
+	Signal     int // terminating signal, 0 for a normal exit
+	CoreDumped bool
+	OOMKilled  bool // picked as victim by the kernel OOM killer
+
+	Argv          []string // argv the process exec'd (cron's "sh -c <command>"), if the exec probe saw it
+	ArgvTruncated bool
+	Source        string // how the exit was captured, "" for eBPF
+}
+
+// signalNames covers the signals a cron job realistically dies from
+var signalNames = map[int]string{
+	1:  "SIGHUP",
+	2:  "SIGINT",
+	3:  "SIGQUIT",
+	4:  "SIGILL",
+	5:  "SIGTRAP",
+	6:  "SIGABRT",
+	7:  "SIGBUS",
+	8:  "SIGFPE",
+	9:  "SIGKILL",
+	10: "SIGUSR1",
+	11: "SIGSEGV",
+	12: "SIGUSR2",
+	13: "SIGPIPE",
+	14: "SIGALRM",
+	15: "SIGTERM",
+	24: "SIGXCPU",
+	25: "SIGXFSZ",
+}
+
+func signalName(sig int) string {
+	if name, ok := signalNames[sig]; ok {
+		return name
+	}
+	return fmt.Sprintf("signal %d", sig)
+}
+
+// Describe renders the status for failure messages, e.g. "exited with code 1 (General Error)",
+// "killed by SIGSEGV (Segmentation Fault, core dumped)" or "killed by the OOM killer (SIGKILL)"
+func (s ExitStatus) Describe() string {
+	if s.Signal == 0 {
+		return fmt.Sprintf("exited with code %d (%s)", s.ExitCode, getExitCodeDescription(s.ExitCode))
+	}
+	if s.OOMKilled && s.Signal == 9 {
+		return "killed by the OOM killer (SIGKILL)"
+	}
+
+	// A plain SIGKILL stays "OOM/Manual": kernels without signal->oom_mm can't tell
+	desc := getExitCodeDescription(128 + s.Signal)
+	if s.CoreDumped {
+		desc += ", core dumped"
+	}
+	return fmt.Sprintf("killed by %s (%s)", signalName(s.Signal), desc)
+}
+
+// captureNote names the source of a status (eBPF unless set), with the exec'd
+// command when known
+func (s ExitStatus) captureNote() string {
+	source := s.Source
+	if source == "" {
+		source = "eBPF"
+	}
+	if len(s.Argv) == 0 {
+		return fmt.Sprintf("(captured via %s)", source)
+	}
+	cmd := strings.Join(s.Argv, " ")
+	if s.ArgvTruncated {
+		cmd += "..."
+	}
+	return fmt.Sprintf("(captured via %s: %s)", source, cmd)
+}
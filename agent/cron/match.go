@@ -0,0 +1,130 @@
+package cron
+
+import (
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// jobMatcher resolves a command to its cron_ignore / cron_timeouts key. Keys take three forms:
+//   - "re:<regexp>" matched against the normalized command
+//   - a key containing "*" is a glob; "*" matches any run of characters,
+//     including spaces and slashes, everything else is literal
+//   - anything else must equal the command
+//
+// Commands and keys are normalized (surrounding and repeated whitespace collapsed)
+// first. An exact key wins over patterns; patterns are tried in key order.
+type jobMatcher struct {
+	exact    map[string]string // normalized key -> config key
+	patterns []jobPattern
+}
+
+type jobPattern struct {
+	key string
+	re  *regexp.Regexp
+}
+
+// normalizeCommand collapses whitespace so "a  b " and "a b" name the same job
+func normalizeCommand(cmd string) string {
+	return strings.Join(strings.Fields(cmd), " ")
+}
+
+// compileJobKey turns a glob or "re:" key into a regexp; plain keys return nil
+func compileJobKey(key string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(key, "re:") {
+		return regexp.Compile(strings.TrimPrefix(key, "re:"))
+	}
+	key = normalizeCommand(key)
+	if !strings.Contains(key, "*") {
+		return nil, nil
+	}
+	parts := strings.Split(key, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// newJobMatcher compiles the keys of a cron_ignore or cron_timeouts map
+func newJobMatcher(keys []string) jobMatcher {
+	sort.Strings(keys)
+	jm := jobMatcher{exact: make(map[string]string)}
+	for _, key := range keys {
+		re, err := compileJobKey(key)
+		if err != nil {
+			log.Printf("⚠️  Ignoring invalid cron job pattern %q: %v", key, err)
+			continue
+		}
+		if re != nil {
+			jm.patterns = append(jm.patterns, jobPattern{key: key, re: re})
+		}
+		if !strings.HasPrefix(key, "re:") {
+			// A glob key may also be a literal command containing "*"
+			jm.exact[normalizeCommand(key)] = key
+		}
+	}
+	return jm
+}
+
+// match returns the config key covering a command
+func (jm jobMatcher) match(cmd string) (string, bool) {
+	cmd = normalizeCommand(cmd)
+	if key, ok := jm.exact[cmd]; ok {
+		return key, true
+	}
+	for _, p := range jm.patterns {
+		if p.re.MatchString(cmd) {
+			return p.key, true
+		}
+	}
+	return "", false
+}
+
+func ignoreKeys(ignores map[string][]int) []string {
+	keys := make([]string, 0, len(ignores))
+	for k := range ignores {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func timeoutKeys(timeouts map[string]int) []string {
+	keys := make([]string, 0, len(timeouts))
+	for k := range timeouts {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// ignoredExit reports whether an exit code is ignored for a command. Called with m.mu held.
+func (m *Monitor) ignoredExit(cmd string, exitCode int) bool {
+	key, ok := m.ignoreMatch.match(cmd)
+	if !ok {
+		return false
+	}
+	for _, code := range m.ignores[key] {
+		if code == exitCode {
+			return true
+		}
+	}
+	return false
+}
+
+// timeoutFor returns the timeout of a command: its own or the global one. Called with m.mu held.
+func (m *Monitor) timeoutFor(cmd string) int {
+	if key, ok := m.timeoutMatch.match(cmd); ok {
+		return m.timeouts[key]
+	}
+	return m.globalTimeout
+}
+
+// configured reports whether a command has an ignore or timeout entry, which
+// is what tracks it when auto-discovery is off. Called with m.mu held.
+func (m *Monitor) configured(cmd string) bool {
+	if _, ok := m.timeoutMatch.match(cmd); ok {
+		return true
+	}
+	_, ok := m.ignoreMatch.match(cmd)
+	return ok
+}
@@ -0,0 +1,65 @@
+package cron
+
+import "testing"
+
+func TestJobMatcher(t *testing.T) {
+	jm := newJobMatcher([]string{
+		"/usr/local/bin/backup.sh",
+		"/usr/bin/find /tmp -name '*.log' -delete",
+		"/opt/report.sh --date=*",
+		"re:^rsync .* /mnt/backup/[0-9]+$",
+		"re:(",
+	})
+
+	tests := []struct {
+		cmd  string
+		key  string
+		want bool
+	}{
+		{"/usr/local/bin/backup.sh", "/usr/local/bin/backup.sh", true},
+		{"  /usr/local/bin/backup.sh ", "/usr/local/bin/backup.sh", true},
+		{"/usr/local/bin/backup.sh --full", "", false},
+		{"/usr/bin/find  /tmp -name '*.log' -delete", "/usr/bin/find /tmp -name '*.log' -delete", true},
+		{"/opt/report.sh --date=2026-10-15 --out /var/reports/x", "/opt/report.sh --date=*", true},
+		{"/opt/report.sh", "", false},
+		{"rsync -a /srv /mnt/backup/20261015", "re:^rsync .* /mnt/backup/[0-9]+$", true},
+		{"rsync -a /srv /mnt/backup/latest", "", false},
+	}
+	for _, tt := range tests {
+		key, ok := jm.match(tt.cmd)
+		if ok != tt.want || key != tt.key {
+			t.Errorf("match(%q) = %q, %v; want %q, %v", tt.cmd, key, ok, tt.key, tt.want)
+		}
+	}
+}
+
+func TestIgnoreAndTimeoutPatterns(t *testing.T) {
+	m := New("")
+	m.SetConfig(Config{
+		CronIgnore:        map[string][]int{"/opt/sync.sh *": {1, 2}},
+		CronGlobalTimeout: 300,
+		CronTimeouts:      map[string]int{"re:^/opt/etl/": 3600, "/opt/etl/nightly.sh": 60},
+		CronEnabled:       true,
+	})
+
+	if !m.ignoredExit("/opt/sync.sh --since 1760000000", 2) {
+		t.Error("exit 2 should be ignored through the glob key")
+	}
+	if m.ignoredExit("/opt/sync.sh --since 1760000000", 3) {
+		t.Error("exit 3 is not in the ignore list")
+	}
+	if got := m.timeoutFor("/opt/etl/load.sh customers"); got != 3600 {
+		t.Errorf("regex timeout = %d, want 3600", got)
+	}
+	if got := m.timeoutFor("/opt/etl/nightly.sh"); got != 60 {
+		t.Errorf("exact key should win over patterns, got %d", got)
+	}
+	if got := m.timeoutFor("/usr/bin/other"); got != 300 {
+		t.Errorf("unmatched job should use the global timeout, got %d", got)
+	}
+
+	m.lastSeenJobs["/opt/sync.sh --since 1760000000"] = &JobRecord{Command: "/opt/sync.sh --since 1760000000", LastExitCode: 1}
+	if events := m.checkBPFFailures(); len(events) != 0 {
+		t.Errorf("ignored eBPF failure produced %d events", len(events))
+	}
+}
@@ -1,15 +1,15 @@
 package cron
 
 import (
-	"bufio"
 	"fmt"
 	"log"
-	"os"
-	"os/exec"
 	"regexp"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/yourusername/nodeguarder/logtail"
+	"github.com/yourusername/nodeguarder/state"
 )
 
 // Monitor tracks cron jobs and detects failures
@@ -18,13 +18,19 @@ type Monitor struct {
 	lastCheckTime int64
 	lastSeenJobs  map[string]*JobRecord
 	ignores       map[string][]int
+	ignoreMatch   jobMatcher
 	globalTimeout int
 	timeouts      map[string]int
+	timeoutMatch  jobMatcher
 	logPath       string
-	fileOffsets   map[string]int64
+	logs          *logtail.Reader
 	enabled       bool
 	autoDiscover  bool
     orphanedExits map[int32]orphanExit
+    pidMode       string // "" matches global and namespace PIDs, "host" only global, "container" only namespace
+    lastDiscovery int64  // last crontab discovery pass
+    pendingRuns   []CronRun // finished runs not yet sent to the dashboard
+	state         *state.Namespace // persisted jobs and log position, nil = memory only
 }
 
 // Config holds the configuration for the cron monitor
@@ -39,10 +45,14 @@ type Config struct {
 // JobRecord tracks the state of a specific cron job
 type JobRecord struct {
 	Command      string
+	User         string // owning user (from the crontab or cron log)
+	Schedule     string // crontab schedule, empty if only seen in logs
+	Source       string // crontab defining the job, empty once removed from it
 	LastExecTime int64
 	ActivePID    int32
 	StartTime    int64
 	LastExitCode int
+	LastSignal   int // terminating signal of the last run (eBPF only), 0 for a normal exit
 	LastErrorMsg string
 	FailureCount int
 	LastDuration int64
@@ -53,7 +63,9 @@ type JobRecord struct {
 type CronEvent struct {
 	Timestamp    int64
 	ExitCode     int
+	Signal       int
 	ErrorMessage string
+	Output       []string // last output lines, when the job runs under the -wrap wrapper
 	JobCommand   string
 	Type         string
 }
@@ -67,7 +79,7 @@ func New(logPath string) *Monitor {
 		lastCheckTime: time.Now().Unix(),
 		lastSeenJobs:  make(map[string]*JobRecord),
 		ignores:       make(map[string][]int),
-		fileOffsets:   make(map[string]int64),
+		logs:          logtail.NewReader(false),
 		logPath:       logPath,
 		enabled:       true, // Default to true until config loaded
 		autoDiscover:  true, // Default to true
@@ -84,11 +96,25 @@ func (m *Monitor) SetEnabled(enabled bool) {
     m.enabled = enabled
 }
 
+// SetContainerized selects which eBPF PIDs identify jobs. Inside a container the
+// job PIDs we see are namespace-local; on a host they are global, and matching
+// namespace PIDs would collide with processes in unrelated containers.
+func (m *Monitor) SetContainerized(containerized bool) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    if containerized {
+        m.pidMode = "container"
+    } else {
+        m.pidMode = "host"
+    }
+}
+
 // SetIgnore updates the list of ignored exit codes per job
 func (m *Monitor) SetIgnore(ignores map[string][]int) {
     m.mu.Lock()
     defer m.mu.Unlock()
 	m.ignores = ignores
+	m.ignoreMatch = newJobMatcher(ignoreKeys(ignores))
 }
 
 // SetTimeouts updates the timeout configurations
@@ -100,6 +126,7 @@ func (m *Monitor) SetTimeouts(global int, overrides map[string]int) {
     }
 	m.globalTimeout = global
 	m.timeouts = overrides
+	m.timeoutMatch = newJobMatcher(timeoutKeys(overrides))
 }
 
 // SetConfig updates the monitor's configuration from a Config struct
@@ -107,12 +134,64 @@ func (m *Monitor) SetConfig(cfg Config) {
     m.mu.Lock()
     defer m.mu.Unlock()
 	m.ignores = cfg.CronIgnore
+	m.ignoreMatch = newJobMatcher(ignoreKeys(cfg.CronIgnore))
 	m.globalTimeout = cfg.CronGlobalTimeout
 	m.timeouts = cfg.CronTimeouts
+	m.timeoutMatch = newJobMatcher(timeoutKeys(cfg.CronTimeouts))
 	m.enabled = cfg.CronEnabled
 	m.autoDiscover = cfg.CronAutoDiscover
 }
 
+// maxResumeLookback caps how much of the journal is read after a restart
+const maxResumeLookback = time.Hour
+
+// savedState is what the monitor keeps in the state database
+type savedState struct {
+	Jobs      map[string]*JobRecord `json:"jobs"`
+	LastCheck int64                 `json:"last_check"`
+}
+
+// SetState persists the tracked jobs (failure counts, alert state), the journal
+// position and the cron log offset, so a restart neither loses nor replays them
+func (m *Monitor) SetState(ns *state.Namespace) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state = ns
+	m.logs.SetState(ns)
+
+	var saved savedState
+	ok, err := ns.GetJSON("monitor", &saved)
+	if err != nil {
+		log.Printf("⚠️  Ignoring saved cron state: %v", err)
+	}
+	if !ok {
+		return
+	}
+	for cmd, record := range saved.Jobs {
+		record.ActivePID = 0 // runs in flight during the restart cannot be followed
+		m.lastSeenJobs[cmd] = record
+	}
+	if since := time.Now().Add(-maxResumeLookback).Unix(); saved.LastCheck < since {
+		saved.LastCheck = since
+	}
+	m.lastCheckTime = saved.LastCheck
+}
+
+// saveState persists the tracked jobs. Called with m.mu held.
+func (m *Monitor) saveState() {
+	if err := m.state.PutJSON("monitor", savedState{Jobs: m.lastSeenJobs, LastCheck: m.lastCheckTime}); err != nil {
+		log.Printf("⚠️  Failed to save cron state: %v", err)
+	}
+}
+
+// SetSince makes the next Check read the journal from the given unix time
+// (cron log files are always read from the start on the first Check)
+func (m *Monitor) SetSince(since int64) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+	m.lastCheckTime = since
+}
+
 // Check scans system logs for cron events since the last check
 func (m *Monitor) Check() ([]CronEvent, error) {
     if !m.enabled {
@@ -147,6 +226,9 @@ func (m *Monitor) Check() ([]CronEvent, error) {
 	// Process each entry
     m.mu.Lock()
     defer m.mu.Unlock()
+
+    // List crontab-defined jobs before they ever run
+    m.refreshDiscovered(currentTime)
     
 	for _, entry := range entries {
 		event := m.processCronEntry(entry)
@@ -164,6 +246,7 @@ func (m *Monitor) Check() ([]CronEvent, error) {
     events = append(events, bpfEvents...)
 
 	m.lastCheckTime = currentTime
+	m.saveState()
 
 	return events, nil
 }
@@ -175,26 +258,13 @@ func (m *Monitor) checkBPFFailures() []CronEvent {
 
     for cmd, record := range m.lastSeenJobs {
         if record.LastExitCode != 0 && !record.AlertSent {
-            // Check ignores
-            ignored := false
-            if codes, ok := m.ignores[cmd]; ok {
-                // log.Printf("DEBUG: Checking ignore for %s (Code %d) vs %v", cmd, record.LastExitCode, codes)
-				for _, code := range codes {
-					if code == record.LastExitCode {
-						ignored = true
-
-                        break
-					}
-				}
-			} else {
-                 // log.Printf("DEBUG: No ignore rules for %s", cmd)
-            }
-            
-            if !ignored {
+            if !m.ignoredExit(cmd, record.LastExitCode) {
                 events = append(events, CronEvent{
                     JobCommand:   cmd,
                     ExitCode:     record.LastExitCode,
+                    Signal:       record.LastSignal,
                     ErrorMessage: record.LastErrorMsg, // Set by UpdateJobStatusByPID
+                    Output:       loadCapture(cmd, record.User, record.StartTime),
                     Timestamp:    record.LastExecTime,
                     Type:         "cron_error", // Standard cron error
                 })
@@ -208,73 +278,17 @@ func (m *Monitor) checkBPFFailures() []CronEvent {
 
 // getCronEntriesFromJournal reads cron events from journalctl
 func (m *Monitor) getCronEntriesFromJournal(since int64) ([]string, error) {
-	sinceStr := fmt.Sprintf("@%d", since)
-
-	cmd := exec.Command("journalctl", "--unit=cron.service", "--since="+sinceStr, "--no-pager", "-o", "short-precise")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, err
-	}
-
-	var entries []string
-	scanner := bufio.NewScanner(strings.NewReader(string(output)))
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.Contains(line, "CRON") || strings.Contains(line, "cron") {
-			entries = append(entries, line)
-		}
-	}
-
-	return entries, nil
+	return logtail.Journal([]string{"--unit=cron.service"}, since, isCronLine)
 }
 
 // getCronEntriesFromSyslog reads cron events from /var/log/syslog
 func (m *Monitor) getCronEntriesFromSyslog(since int64) ([]string, error) {
-	file, err := os.Open(m.logPath)
-	if err != nil {
-		// Try /var/log/messages for older systems
-		file, err = os.Open("/var/log/messages")
-		if err != nil {
-			return nil, err
-		}
-	}
-	defer file.Close()
-
-	// Get file info for size check
-	fi, err := file.Stat()
-	if err != nil {
-		return nil, err
-	}
-
-	// Determine start position
-	var startPos int64 = 0
-	if offset, ok := m.fileOffsets[m.logPath]; ok {
-		// Log rotation detection: if current size is less than last offset, start from 0
-		if fi.Size() >= offset {
-			startPos = offset
-		}
-	}
-
-	// Seek to start position
-	if _, err := file.Seek(startPos, 0); err != nil {
-		return nil, err
-	}
-
-	var entries []string
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.Contains(line, "CRON") || strings.Contains(line, "cron") {
-			entries = append(entries, line)
-		}
-	}
-
-    // Update offset (account for read bytes, close enough approximation with Size or Tell)
-    // Using current file size is safest after full read
-    m.fileOffsets[m.logPath] = fi.Size()
+	// Try /var/log/messages for older systems
+	return m.logs.File([]string{m.logPath, "/var/log/messages"}, isCronLine)
+}
 
-	return entries, nil
+func isCronLine(line string) bool {
+	return strings.Contains(line, "CRON") || strings.Contains(line, "cron")
 }
 
 // Pre-compiled regex patterns
@@ -324,7 +338,7 @@ func (m *Monitor) processCronEntry(entry string) *CronEvent {
 
 		// Try to extract command from log
 		if matches := cmdPattern.FindStringSubmatch(entry); len(matches) > 1 {
-			event.JobCommand = matches[1]
+			event.JobCommand = unwrapCommand(matches[1])
 		}
 
 		// Try to extract exit code
@@ -333,20 +347,24 @@ func (m *Monitor) processCronEntry(entry string) *CronEvent {
 		}
 
 		// Check if we should ignore this failure
-		if event.JobCommand != "" {
-			if codes, ok := m.ignores[event.JobCommand]; ok {
-				for _, code := range codes {
-					if code == event.ExitCode {
-						return nil // Ignored
-					}
-				}
-			}
+		if event.JobCommand != "" && m.ignoredExit(event.JobCommand, event.ExitCode) {
+			return nil // Ignored
 		}
 
 		// Enrich Error Message with Exit Code Meaning and User
 		desc := getExitCodeDescription(event.ExitCode)
 		event.ErrorMessage = fmt.Sprintf("Cron job failed: %s (User: %s) - %s (%d)", 
 			event.JobCommand, user, desc, event.ExitCode)
+		event.Output = loadCapture(event.JobCommand, user, event.Timestamp-300)
+
+		// Run history: same start as an eBPF-reported exit of this run, so the dashboard merges them
+		if event.JobCommand != "" {
+			startedAt := event.Timestamp
+			if record, ok := m.lastSeenJobs[event.JobCommand]; ok && record.StartTime > 0 {
+				startedAt = record.StartTime
+			}
+			m.recordRun(event.JobCommand, user, startedAt, event.Timestamp, event.ExitCode, 0)
+		}
 		
 		return event
 	}
@@ -370,7 +388,7 @@ func (m *Monitor) processCronEntry(entry string) *CronEvent {
 
 		var cmd string
 		if matches := cmdPattern.FindStringSubmatch(entry); len(matches) > 1 {
-			cmd = matches[1]
+			cmd = unwrapCommand(matches[1])
 		}
 		event.JobCommand = cmd
 
@@ -379,13 +397,14 @@ func (m *Monitor) processCronEntry(entry string) *CronEvent {
 			return nil
 		}
 
+		var user string
+		if matches := userPattern.FindStringSubmatch(entry); len(matches) > 1 {
+			user = matches[1]
+		}
+
 		// If Auto-Discovery is disabled, ONLY track if it is in timeouts or ignores (Allowlist)
-		if !m.autoDiscover {
-			_, hasTimeout := m.timeouts[cmd]
-			_, hasIgnore := m.ignores[cmd]
-			if !hasTimeout && !hasIgnore {
-				return nil
-			}
+		if !m.autoDiscover && !m.configured(cmd) {
+			return nil
 		}
 
 			// Update Job Record
@@ -393,66 +412,49 @@ func (m *Monitor) processCronEntry(entry string) *CronEvent {
                 record.LastExecTime = event.Timestamp
                 record.ActivePID = pid
                 record.StartTime = event.Timestamp
-                
-                // Check if we have an orphaned exit for this PID (Direct Match or NS Match)
-                orphan, ok := m.orphanedExits[pid]
-                // OR Check if we have an orphaned exit where ParentPid == pid (Fork Match)
-                if !ok {
-                   for opid, o := range m.orphanedExits {
-                       if o.ParentPid == pid || (o.NsPid != 0 && o.NsPid == pid) || (o.NsParentPid != 0 && o.NsParentPid == pid) {
-                           orphan = o
-                           ok = true
-                           delete(m.orphanedExits, opid) 
-                           break
-                       }
-                   }
-                } else {
-                    delete(m.orphanedExits, pid)
+                if record.User == "" {
+                    record.User = user
                 }
+                
+                // Check if we have an orphaned exit for this job (PID, fork, NS or exec'd command match)
+                orphan, ok := m.takeOrphan(pid, cmd)
 
                 if ok {
 
                     record.LastExitCode = orphan.ExitCode
+                    record.LastSignal = orphan.Signal
                      if orphan.ExitCode != 0 {
                         record.FailureCount++
-                        record.LastErrorMsg = fmt.Sprintf("Cron job failed: %s - Process exited with code %d (captured via eBPF)", cmd, orphan.ExitCode)
+                        record.LastErrorMsg = fmt.Sprintf("Cron job failed: %s - Process %s %s", cmd, orphan.Describe(), orphan.captureNote())
                         record.AlertSent = false // Ensure we alert
                      }
                      record.ActivePID = 0 // Validated as finished
+                     m.recordRun(cmd, record.User, event.Timestamp, orphan.Timestamp, orphan.ExitCode, orphan.Signal)
                 }
                 
 			} else {
                 // New job seen
                 rec := &JobRecord{
                     Command:      event.JobCommand,
+                    User:         user,
                     LastExecTime: event.Timestamp,
                     ActivePID:    pid,
                     StartTime:    event.Timestamp,
                     AlertSent:    false,
                 }
                 // Check orphans
-                orphan, ok := m.orphanedExits[pid]
-                 if !ok {
-                   for opid, o := range m.orphanedExits {
-                       if o.ParentPid == pid || (o.NsPid != 0 && o.NsPid == pid) || (o.NsParentPid != 0 && o.NsParentPid == pid) {
-                           orphan = o
-                           ok = true
-                           delete(m.orphanedExits, opid)
-                           break
-                       }
-                   }
-                } else {
-                    delete(m.orphanedExits, pid)
-                }
+                orphan, ok := m.takeOrphan(pid, event.JobCommand)
 
                 if ok {
 
                     rec.LastExitCode = orphan.ExitCode
+                    rec.LastSignal = orphan.Signal
                     if orphan.ExitCode != 0 {
                         rec.FailureCount = 1
-                        rec.LastErrorMsg = fmt.Sprintf("Cron job failed: %s - Process exited with code %d (captured via eBPF)", event.JobCommand, orphan.ExitCode)
+                        rec.LastErrorMsg = fmt.Sprintf("Cron job failed: %s - Process %s %s", event.JobCommand, orphan.Describe(), orphan.captureNote())
                     }
                     rec.ActivePID = 0
+                    m.recordRun(event.JobCommand, user, event.Timestamp, orphan.Timestamp, orphan.ExitCode, orphan.Signal)
                 }
                 m.lastSeenJobs[event.JobCommand] = rec
             }
@@ -520,44 +522,53 @@ func (m *Monitor) UpdateJobStatus(command string, exitCode int, errorMsg string)
 
 // orphanExit represents a BPF exit event that arrived before the start log
 type orphanExit struct {
-    ExitCode    int
+    ExitStatus
+    Pid         int32
     ParentPid   int32
     NsPid       int32
     NsParentPid int32
     Timestamp   int64
 }
 
-// UpdateJobStatusByPID updates a job's status by matching its ActivePID
-func (m *Monitor) UpdateJobStatusByPID(pid int32, parentPid int32, nsPid int32, nsParentPid int32, exitCode int) {
+// UpdateJobStatusByPID updates a job's status by matching the command the process
+// exec'd (when the exec probe captured its argv), then by its ActivePID.
+// It reports false when no job matched and the exit was kept as an orphan.
+func (m *Monitor) UpdateJobStatusByPID(pid int32, parentPid int32, nsPid int32, nsParentPid int32, status ExitStatus) bool {
     m.mu.Lock()
     defer m.mu.Unlock()
 
+    matched := m.jobForExec(status)
+    if matched == nil {
+        for _, record := range m.lastSeenJobs {
+            // Match Global PIDs OR Namespace PIDs (restricted once the environment is known)
+            matchGlobal := m.pidMode != "container" && ((record.ActivePID == pid) || (record.ActivePID != 0 && record.ActivePID == parentPid))
+            matchNs := m.pidMode != "host" && ((nsPid != 0 && record.ActivePID == nsPid) || (nsParentPid != 0 && record.ActivePID != 0 && record.ActivePID == nsParentPid))
+            if matchGlobal || matchNs {
+                matched = record
+                break
+            }
+        }
+    }
 
+    found := matched != nil
+    if found {
+        record := matched
 
-    found := false
-    for _, record := range m.lastSeenJobs {
-        // Match Global PIDs OR Namespace PIDs
-        matchGlobal := (record.ActivePID == pid) || (record.ActivePID != 0 && record.ActivePID == parentPid)
-        matchNs := (nsPid != 0 && record.ActivePID == nsPid) || (nsParentPid != 0 && record.ActivePID != 0 && record.ActivePID == nsParentPid)
-
-        if matchGlobal || matchNs {
-            found = true
-
-            record.LastExecTime = time.Now().Unix()
-            record.LastExitCode = exitCode
-            record.LastDuration = record.LastExecTime - record.StartTime
-            record.ActivePID = 0 
-            record.AlertSent = false 
-            
-            if exitCode != 0 {
-                record.FailureCount++
-                record.LastErrorMsg = fmt.Sprintf("Process exited with code %d (captured via eBPF)", exitCode)
-            } else {
-                record.FailureCount = 0
-                record.LastErrorMsg = ""
-            }
-            break
+        record.LastExecTime = time.Now().Unix()
+        record.LastExitCode = status.ExitCode
+        record.LastSignal = status.Signal
+        record.LastDuration = record.LastExecTime - record.StartTime
+        record.ActivePID = 0 
+        record.AlertSent = false 
+        
+        if status.ExitCode != 0 {
+            record.FailureCount++
+            record.LastErrorMsg = fmt.Sprintf("Process %s %s", status.Describe(), status.captureNote())
+        } else {
+            record.FailureCount = 0
+            record.LastErrorMsg = ""
         }
+        m.recordRun(record.Command, record.User, record.StartTime, record.LastExecTime, status.ExitCode, status.Signal)
     }
     
     if !found {
@@ -567,7 +578,8 @@ func (m *Monitor) UpdateJobStatusByPID(pid int32, parentPid int32, nsPid int32,
         
         // We key by BOTH Global PID and Namespace PID to allow lookup by either
         orphan := orphanExit{
-            ExitCode:    exitCode,
+            ExitStatus:  status,
+            Pid:         pid,
             ParentPid:   parentPid,
             NsPid:       nsPid,
             NsParentPid: nsParentPid,
@@ -575,9 +587,11 @@ func (m *Monitor) UpdateJobStatusByPID(pid int32, parentPid int32, nsPid int32,
         }
         
         // Store by Global PID
-        m.orphanedExits[pid] = orphan
+        if m.pidMode != "container" {
+            m.orphanedExits[pid] = orphan
+        }
         // Store by NS PID if different
-        if nsPid != 0 && nsPid != pid {
+        if m.pidMode != "host" && nsPid != 0 && nsPid != pid {
              m.orphanedExits[nsPid] = orphan
 
         } else {
@@ -592,6 +606,7 @@ func (m *Monitor) UpdateJobStatusByPID(pid int32, parentPid int32, nsPid int32,
             }
         }
     }
+    return found
 }
 
 // GetTrackedJobs returns all currently tracked cron jobs
@@ -609,10 +624,14 @@ func (m *Monitor) Cleanup() {
     retention := int64(7 * 24 * 60 * 60) // 7 days (covers weekly jobs and weekends)
 
     for cmd, record := range m.lastSeenJobs {
+        if record.Source != "" {
+            continue // Still defined in a crontab
+        }
         if now - record.LastExecTime > retention {
              delete(m.lastSeenJobs, cmd)
         }
     }
+    m.saveState()
 }
 
 // checkLongRunningJobs checks if active jobs have exceeded their timeout
@@ -621,11 +640,7 @@ func (m *Monitor) checkLongRunningJobs() []CronEvent {
 	now := time.Now().Unix()
 
 	for cmd, record := range m.lastSeenJobs {
-        // Determine Timeout
-        timeout := m.globalTimeout
-        if t, ok := m.timeouts[cmd]; ok {
-            timeout = t
-        }
+        timeout := m.timeoutFor(cmd)
 
 		if record.ActivePID > 0 {
 			// Check if process is still running
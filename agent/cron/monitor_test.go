@@ -1,12 +1,15 @@
 package cron
 
 import (
+	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/yourusername/nodeguarder/state"
 )
 
 func TestMonitorCreation(t *testing.T) {
-	monitor := New()
+	monitor := New("")
 	if monitor == nil {
 		t.Fatal("Failed to create monitor")
 	}
@@ -16,7 +19,7 @@ func TestMonitorCreation(t *testing.T) {
 }
 
 func TestJobStatusTracking(t *testing.T) {
-	monitor := New()
+	monitor := New("")
 
 	// Track a successful job
 	monitor.UpdateJobStatus("/usr/local/bin/backup.sh", 0, "")
@@ -54,7 +57,7 @@ func TestJobStatusTracking(t *testing.T) {
 }
 
 func TestGetTrackedJobs(t *testing.T) {
-	monitor := New()
+	monitor := New("")
 
 	monitor.UpdateJobStatus("job1", 0, "")
 	monitor.UpdateJobStatus("job2", 1, "error")
@@ -67,7 +70,7 @@ func TestGetTrackedJobs(t *testing.T) {
 }
 
 func TestProcessCronEntryFailureDetection(t *testing.T) {
-	monitor := New()
+	monitor := New("")
 
 	// Test failure detection
 	entry := "Dec 7 10:30:45 server CRON[12345]: (root) CMD (/usr/local/bin/backup.sh) (FAILED exit code 1)"
@@ -87,7 +90,7 @@ func TestProcessCronEntryFailureDetection(t *testing.T) {
 }
 
 func TestProcessCronEntrySuccessIgnored(t *testing.T) {
-	monitor := New()
+	monitor := New("")
 
 	// Success entries should not generate events (unless tracked with prior failure)
 	entry := "Dec 7 10:30:45 server CRON[12345]: (root) CMD (/usr/local/bin/cleanup.sh)"
@@ -99,7 +102,7 @@ func TestProcessCronEntrySuccessIgnored(t *testing.T) {
 }
 
 func TestTimestampGeneration(t *testing.T) {
-	monitor := New()
+	monitor := New("")
 	monitor.lastCheckTime = time.Now().Unix() - 3600 // 1 hour ago
 
 	entry := "CRON failed /test/job (FAILED exit code 1)"
@@ -109,3 +112,33 @@ func TestTimestampGeneration(t *testing.T) {
 		t.Error("Expected timestamp to be set")
 	}
 }
+
+func TestStatePersistsAcrossRestart(t *testing.T) {
+	store, err := state.Open(filepath.Join(t.TempDir(), state.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	monitor := New("")
+	monitor.SetState(store.Namespace("cron"))
+	monitor.UpdateJobStatus("/usr/local/bin/backup.sh", 1, "connection timeout")
+	monitor.lastSeenJobs["/usr/local/bin/backup.sh"].ActivePID = 4242
+	monitor.Cleanup() // saves the state
+
+	restarted := New("")
+	restarted.SetState(store.Namespace("cron"))
+	record, ok := restarted.lastSeenJobs["/usr/local/bin/backup.sh"]
+	if !ok {
+		t.Fatal("Expected the tracked job to be restored")
+	}
+	if record.FailureCount != 1 || record.LastErrorMsg != "connection timeout" {
+		t.Errorf("Restored record = %+v", record)
+	}
+	if record.ActivePID != 0 {
+		t.Error("Expected runs in flight to be dropped on restore")
+	}
+	if restarted.lastCheckTime < time.Now().Add(-maxResumeLookback).Unix() {
+		t.Error("Expected the journal position to be restored within the lookback")
+	}
+}
@@ -0,0 +1,177 @@
+package cron
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// pauseMarker prefixes crontab lines commented out by a dashboard pause
+const pauseMarker = "#nodeguarder-paused# "
+
+// crontabSources lists the system and user crontab locations
+var crontabSources = []string{
+	"/etc/crontab",
+	"/etc/cron.d/*",
+	"/var/spool/cron/crontabs/*", // Debian/Ubuntu
+	"/var/spool/cron/*",          // RHEL/CentOS
+}
+
+// PauseRequest is the desired pause state of a job, as set in the dashboard
+type PauseRequest struct {
+	Command string `json:"command"`
+	Paused  bool   `json:"paused"`
+}
+
+// PauseResult reports the outcome of applying a PauseRequest
+type PauseResult struct {
+	Command string   `json:"command"`
+	Paused  bool     `json:"paused"`
+	Files   []string `json:"files"`   // Crontabs that were modified
+	Backups []string `json:"backups"` // Copies taken before modification
+	Error   string   `json:"error,omitempty"`
+}
+
+// ApplyPause comments out (or restores) every crontab line running the command.
+// Each modified crontab is backed up into backupDir first. Already-applied
+// requests are a no-op, so the desired state can be delivered repeatedly.
+func ApplyPause(req PauseRequest, backupDir string) PauseResult {
+	res := PauseResult{Command: req.Command, Paused: req.Paused}
+	command := strings.TrimSpace(req.Command)
+	if command == "" {
+		res.Error = "empty command"
+		return res
+	}
+
+	found := false
+	for _, pattern := range crontabSources {
+		files, _ := filepath.Glob(pattern)
+		for _, file := range files {
+			info, err := os.Stat(file)
+			if err != nil || info.IsDir() {
+				continue
+			}
+
+			updated, matched, changed, err := rewriteCrontab(file, isSystemCrontab(file), command, req.Paused)
+			if err != nil {
+				res.Error = err.Error()
+				return res
+			}
+			found = found || matched
+			if !changed {
+				continue
+			}
+
+			backup, err := backupCrontab(file, backupDir)
+			if err != nil {
+				res.Error = err.Error()
+				return res
+			}
+			res.Backups = append(res.Backups, backup)
+
+			if err := writeCrontab(file, updated, info); err != nil {
+				res.Error = err.Error()
+				return res
+			}
+			res.Files = append(res.Files, file)
+		}
+	}
+
+	if !found {
+		res.Error = "no crontab entry found for command"
+	}
+	return res
+}
+
+// rewriteCrontab returns the crontab content with matching lines paused or
+// resumed. System crontabs (/etc/crontab, cron.d) carry a user column.
+func rewriteCrontab(file string, system bool, command string, pause bool) (content string, matched bool, changed bool, err error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", false, false, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	defer f.Close()
+
+	var out strings.Builder
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		isPaused := strings.HasPrefix(line, pauseMarker)
+		entry := strings.TrimPrefix(line, pauseMarker)
+
+		if isCronEntryFor(entry, system, command) {
+			matched = true
+			if pause && !isPaused {
+				line = pauseMarker + entry
+				changed = true
+			} else if !pause && isPaused {
+				line = entry
+				changed = true
+			}
+		}
+		out.WriteString(line)
+		out.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return "", false, false, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	return out.String(), matched, changed, nil
+}
+
+// isCronEntryFor reports whether a crontab line (not a comment) runs exactly
+// the command, as discovered jobs report it (without the -wrap wrapper)
+func isCronEntryFor(line string, system bool, command string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || envLinePattern.MatchString(line) {
+		return false
+	}
+	n := 5
+	if strings.HasPrefix(line, "@") {
+		n = 1 // @daily, @reboot, ...
+	}
+	if system {
+		n++ // user column
+	}
+	_, entry := splitFields(line, n)
+	return entry != "" && (entry == command || unwrapCommand(entry) == command)
+}
+
+func backupCrontab(file, backupDir string) (string, error) {
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	name := strings.ReplaceAll(strings.TrimPrefix(file, "/"), "/", "_")
+	backup := filepath.Join(backupDir, fmt.Sprintf("%s.%d", name, time.Now().Unix()))
+	if err := os.WriteFile(backup, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+	return backup, nil
+}
+
+// writeCrontab replaces the file atomically so cron never reads a partial crontab.
+// Mode and ownership are preserved (user crontabs must stay owned by their user).
+func writeCrontab(file, content string, info os.FileInfo) error {
+	tmp := file + ".nodeguarder.tmp"
+	if err := os.WriteFile(tmp, []byte(content), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		os.Chown(tmp, int(st.Uid), int(st.Gid))
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", file, err)
+	}
+	return nil
+}
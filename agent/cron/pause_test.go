@@ -0,0 +1,72 @@
+package cron
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestCrontab(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "crontab")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPauseMatchesExactCommand(t *testing.T) {
+	path := writeTestCrontab(t, `SHELL=/bin/sh
+0 2 * * * backup.sh
+0 3 * * * /opt/x/backup.sh
+0 4 * * * old-backup.sh
+@daily backup.sh --full
+`)
+
+	content, matched, changed, err := rewriteCrontab(path, false, "backup.sh", true)
+	if err != nil || !matched || !changed {
+		t.Fatalf("matched=%v changed=%v err=%v", matched, changed, err)
+	}
+	want := `SHELL=/bin/sh
+` + pauseMarker + `0 2 * * * backup.sh
+0 3 * * * /opt/x/backup.sh
+0 4 * * * old-backup.sh
+@daily backup.sh --full
+`
+	if content != want {
+		t.Errorf("paused crontab:\n%s\nwant:\n%s", content, want)
+	}
+
+	// Resuming restores the line
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	content, _, changed, err = rewriteCrontab(path, false, "backup.sh", false)
+	if err != nil || !changed || strings.Contains(content, pauseMarker) {
+		t.Errorf("resume: changed=%v err=%v\n%s", changed, err, content)
+	}
+}
+
+func TestPauseSystemCrontabSkipsUserColumn(t *testing.T) {
+	path := writeTestCrontab(t, `*/5 * * * * root /opt/x/backup.sh
+*/5 * * * * backup backup.sh
+@reboot root /usr/local/bin/nodeguarder-agent -wrap /opt/x/backup.sh
+`)
+
+	content, matched, _, err := rewriteCrontab(path, true, "/opt/x/backup.sh", true)
+	if err != nil || !matched {
+		t.Fatalf("matched=%v err=%v", matched, err)
+	}
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	for i, paused := range []bool{true, false, true} {
+		if strings.HasPrefix(lines[i], pauseMarker) != paused {
+			t.Errorf("line %d %q: paused = %v, want %v", i, lines[i], !paused, paused)
+		}
+	}
+
+	// The user column is not part of the command
+	if _, matched, _, _ := rewriteCrontab(path, true, "backup backup.sh", true); matched {
+		t.Error("matched a command including the user column")
+	}
+}
@@ -0,0 +1,41 @@
+package cron
+
+// maxPendingRuns bounds the run history buffered between metric pushes
+const maxPendingRuns = 500
+
+// CronRun is one finished execution of a job, sent to the dashboard's run history
+type CronRun struct {
+	Command   string `json:"command"`
+	User      string `json:"user,omitempty"`
+	StartedAt int64  `json:"started_at"`
+	Duration  int64  `json:"duration"`
+	ExitCode  int    `json:"exit_code"`
+	Signal    int    `json:"signal,omitempty"`
+}
+
+// recordRun buffers a finished run. Called with m.mu held.
+func (m *Monitor) recordRun(command, user string, startedAt, finishedAt int64, exitCode, signal int) {
+	if startedAt <= 0 || startedAt > finishedAt {
+		startedAt = finishedAt // start unknown, or only seen after the exit (orphaned exit)
+	}
+	m.pendingRuns = append(m.pendingRuns, CronRun{
+		Command:   command,
+		User:      user,
+		StartedAt: startedAt,
+		Duration:  finishedAt - startedAt,
+		ExitCode:  exitCode,
+		Signal:    signal,
+	})
+	if len(m.pendingRuns) > maxPendingRuns {
+		m.pendingRuns = m.pendingRuns[len(m.pendingRuns)-maxPendingRuns:]
+	}
+}
+
+// DrainRuns returns and clears the runs finished since the last call
+func (m *Monitor) DrainRuns() []CronRun {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	runs := m.pendingRuns
+	m.pendingRuns = nil
+	return runs
+}
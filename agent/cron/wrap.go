@@ -0,0 +1,161 @@
+package cron
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RunWrapped runs a cron job command (argv after "-wrap --"), passing its
+// output through unchanged. If the job fails, the last CaptureLines lines are
+// saved to CaptureDir for the agent to attach to the failure event; a
+// successful run clears any earlier capture. Returns the job's exit code.
+func RunWrapped(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: nodeguarder-agent -wrap -- <command> [args...]")
+		return 2
+	}
+
+	tail := &lineTail{max: CaptureLines}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	stdout, _ := cmd.StdoutPipe()
+	stderr, _ := cmd.StderrPipe()
+
+	exitCode := 0
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "nodeguarder-agent: %v\n", err)
+		tail.add(err.Error())
+		exitCode = 127
+	} else {
+		// Forward termination signals so the job can clean up
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+		go func() {
+			for sig := range sigs {
+				cmd.Process.Signal(sig)
+			}
+		}()
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go tail.copy(&wg, stdout, os.Stdout)
+		go tail.copy(&wg, stderr, os.Stderr)
+		wg.Wait()
+
+		err := cmd.Wait()
+		signal.Stop(sigs)
+		close(sigs)
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
+			if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+				exitCode = 128 + int(ws.Signal()) // shell convention, as reported by eBPF
+			}
+		} else if err != nil {
+			exitCode = 1
+		}
+	}
+
+	command := jobCommand(args)
+	path := capturePath(os.Getuid(), command)
+	if exitCode == 0 {
+		os.Remove(path)
+		return 0
+	}
+
+	data, _ := json.Marshal(Capture{
+		Command:    command,
+		ExitCode:   exitCode,
+		FinishedAt: time.Now().Unix(),
+		Output:     tail.lines(),
+	})
+	if err := ensureCaptureDir(); err == nil {
+		tmp := fmt.Sprintf("%s.tmp-%d", path, os.Getpid())
+		if err := os.WriteFile(tmp, data, 0600); err == nil {
+			if os.Rename(tmp, path) != nil {
+				os.Remove(tmp)
+			}
+		}
+	}
+	return exitCode
+}
+
+// jobCommand recovers the command as cron logs it: cron runs crontab lines via
+// "/bin/sh -c <line>", so the parent's argv holds the exact line. Falls back to
+// the wrapped argv when run some other way.
+func jobCommand(args []string) string {
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", os.Getppid())); err == nil {
+		parts := strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+		if len(parts) >= 3 && strings.HasSuffix(parts[0], "sh") && parts[1] == "-c" {
+			return unwrapCommand(strings.TrimSpace(parts[2]))
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+// lineTail keeps the last max lines written by several streams
+type lineTail struct {
+	mu  sync.Mutex
+	max int
+	buf []string
+}
+
+func (t *lineTail) add(line string) {
+	if len(line) > maxCaptureLine {
+		line = line[:maxCaptureLine] + "…"
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, line)
+	if len(t.buf) > t.max {
+		t.buf = t.buf[len(t.buf)-t.max:]
+	}
+}
+
+func (t *lineTail) lines() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.buf...)
+}
+
+// copy passes r through to w while recording its lines. Reads in bounded
+// chunks so a job printing huge unterminated lines can't grow the buffer.
+func (t *lineTail) copy(wg *sync.WaitGroup, r io.Reader, w io.Writer) {
+	defer wg.Done()
+	reader := bufio.NewReader(r)
+	var line []byte
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if len(chunk) > 0 {
+			w.Write(chunk)
+			if room := maxCaptureLine + 1 - len(line); room > 0 {
+				if len(chunk) < room {
+					room = len(chunk)
+				}
+				line = append(line, chunk[:room]...)
+			}
+			if chunk[len(chunk)-1] == '\n' {
+				t.add(string(bytes.TrimRight(line, "\r\n")))
+				line = line[:0]
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			if len(line) > 0 {
+				t.add(string(line))
+			}
+			return
+		}
+	}
+}
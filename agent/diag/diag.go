@@ -0,0 +1,121 @@
+// Package diag reports the agent's own resource usage and, in debug mode,
+// serves pprof on localhost to diagnose agents that slowly leak memory.
+package diag
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultListen is the pprof address used when debug is on
+const DefaultListen = "127.0.0.1:6060"
+
+// Runtime is the agent process usage sent with agent self-metrics
+type Runtime struct {
+	RSSBytes       uint64  `json:"rss_bytes"` // 0 where /proc is unavailable
+	Goroutines     int     `json:"goroutines"`
+	HeapAllocBytes uint64  `json:"heap_alloc_bytes"`
+	HeapSysBytes   uint64  `json:"heap_sys_bytes"`
+	NumGC          uint32  `json:"num_gc"`
+	GCPauseTotalMs float64 `json:"gc_pause_total_ms"`
+	LastGCPauseMs  float64 `json:"last_gc_pause_ms"`
+}
+
+// Collect reads the current usage of the agent process
+func Collect() Runtime {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	r := Runtime{
+		RSSBytes:       rss(),
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: m.HeapAlloc,
+		HeapSysBytes:   m.HeapSys,
+		NumGC:          m.NumGC,
+		GCPauseTotalMs: float64(m.PauseTotalNs) / 1e6,
+	}
+	if m.NumGC > 0 {
+		r.LastGCPauseMs = float64(m.PauseNs[(m.NumGC+255)%256]) / 1e6
+	}
+	return r
+}
+
+// String formats the usage for the periodic self-report log line
+func (r Runtime) String() string {
+	return fmt.Sprintf("rss=%.1fMB heap=%.1fMB/%.1fMB goroutines=%d gc=%d (pause total %.1fms, last %.2fms)",
+		mb(r.RSSBytes), mb(r.HeapAllocBytes), mb(r.HeapSysBytes), r.Goroutines, r.NumGC, r.GCPauseTotalMs, r.LastGCPauseMs)
+}
+
+// rss returns the resident set size from /proc/self/statm
+func rss() uint64 {
+	data, err := os.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 {
+		return 0
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return pages * uint64(os.Getpagesize())
+}
+
+func mb(b uint64) float64 {
+	return float64(b) / (1 << 20)
+}
+
+// Server serves the pprof endpoints under /debug/pprof/
+type Server struct {
+	listener   net.Listener
+	httpServer *http.Server
+}
+
+// Listen starts pprof on addr. Profiles expose process internals, so addr
+// should stay on localhost (use an SSH tunnel to fetch them).
+func Listen(addr string) (*Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	s := &Server{
+		listener: ln,
+		httpServer: &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+	}
+	go func() {
+		if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("❌ pprof listener stopped: %v", err)
+		}
+	}()
+	return s, nil
+}
+
+// Addr returns the address pprof listens on
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close shuts down the listener
+func (s *Server) Close() error {
+	return s.httpServer.Close()
+}
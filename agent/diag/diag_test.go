@@ -0,0 +1,35 @@
+package diag
+
+import (
+	"net/http"
+	"runtime"
+	"testing"
+)
+
+func TestCollect(t *testing.T) {
+	runtime.GC()
+	r := Collect()
+	if r.Goroutines < 1 || r.HeapAllocBytes == 0 || r.NumGC == 0 {
+		t.Errorf("implausible runtime stats: %+v", r)
+	}
+	if runtime.GOOS == "linux" && r.RSSBytes == 0 {
+		t.Error("RSS must be read from /proc on Linux")
+	}
+}
+
+func TestPprofListener(t *testing.T) {
+	s, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	resp, err := http.Get("http://" + s.Addr() + "/debug/pprof/goroutine?debug=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("goroutine profile: %s", resp.Status)
+	}
+}
@@ -5,87 +5,319 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+
+	"github.com/yourusername/nodeguarder/state"
 )
 
 // FileState tracks detailed file attributes
 type FileState struct {
-	Hash string
-	Size int64
-	Mode os.FileMode
+	Hash    string
+	Size    int64
+	Mode    os.FileMode
+	ModTime int64 // mtime (ns), used to skip rehashing unchanged files
+	CTime   int64 // ctime (ns), 0 where unavailable
 }
 
 // Detector monitors file system changes
 type Detector struct {
 	paths     []string
 	lastState map[string]FileState // path -> FileState
-	ignores   []string
+	ignores   []ignoreRule
+	contents  map[string]string    // path -> content of small text files (for diffs)
+	readHash  map[string]string    // path -> hash at which contents was last refreshed
+	diffs     map[string]string    // path -> unified diff from the last Check
+	attribute Attributor
+	writers   map[string]string    // path -> process that modified it, from the last Check
+	changes   []FileChange         // every change from the last Check
+	throttle  Throttle
+	state     *state.Namespace // persisted baseline, nil = memory only
+}
+
+// savedBaseline is the baseline kept in the state database
+type savedBaseline struct {
+	Paths []string             `json:"paths"`
+	Files map[string]FileState `json:"files"`
 }
 
+// FileChange is one changed file, as listed in drift event details
+type FileChange struct {
+	Path   string `json:"path"`
+	Change string `json:"change"`           // created, modified or deleted
+	Detail string `json:"detail,omitempty"` // e.g. "Content changed, Perms: 644->600"
+	By     string `json:"by,omitempty"`     // attributed writer
+}
+
+// Attributor names the process that last wrote a file, if known
+type Attributor func(path string) (string, bool)
+
 // New creates a new drift detector for the given paths
 func New(paths []string) *Detector {
 	return &Detector{
 		paths:     paths,
 		lastState: make(map[string]FileState),
-		ignores:   []string{},
+		ignores:   []ignoreRule{},
+		contents:  make(map[string]string),
+		readHash:  make(map[string]string),
+		throttle:  DefaultThrottle,
 	}
 }
 
-// SetIgnore updates the list of ignored patterns
-func (d *Detector) SetIgnore(patterns []string) {
-	d.ignores = patterns
+// SetState persists the baseline, so changes made while the agent was
+// stopped are reported after a restart instead of becoming the new baseline.
+// A saved baseline of other paths is discarded.
+func (d *Detector) SetState(ns *state.Namespace) error {
+	d.state = ns
+	var saved savedBaseline
+	ok, err := ns.GetJSON("baseline", &saved)
+	if err != nil || !ok {
+		return err
+	}
+	if !equalPaths(saved.Paths, d.paths) || len(saved.Files) == 0 {
+		return nil
+	}
+	d.lastState = saved.Files
+	return nil
 }
 
-// SetPaths updates the monitored paths and resets state to avoid false positives
-func (d *Detector) SetPaths(paths []string) {
-	// Check if paths changed (simple length check or deep compare)
-	// For simplicity, we just check length or if the content is different
-	changed := false
-	if len(d.paths) != len(paths) {
-		changed = true
+// saveBaseline persists the current baseline. A failure only costs the
+// baseline surviving a restart, so it is logged rather than returned.
+func (d *Detector) saveBaseline() {
+	var err error
+	if len(d.lastState) == 0 {
+		err = d.state.Delete("baseline")
 	} else {
-		for i, p := range d.paths {
-			if p != paths[i] {
-				changed = true
-				break
-			}
+		err = d.state.PutJSON("baseline", savedBaseline{Paths: d.paths, Files: d.lastState})
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to save drift baseline: %v", err)
+	}
+}
+
+// equalPaths reports whether two path lists are the same, in order
+func equalPaths(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// SetThrottle limits the rate and priority of subsequent scans
+func (d *Detector) SetThrottle(t Throttle) {
+	d.throttle = t
+}
+
+// SetIgnore updates the list of ignored patterns (globs, "re:" regexes and
+// "dir/" prefixes). Invalid patterns are skipped and reported in the error.
+func (d *Detector) SetIgnore(patterns []string) error {
+	rules := make([]ignoreRule, 0, len(patterns))
+	var invalid []string
+	for _, p := range patterns {
+		rule, err := compileIgnore(p)
+		if err != nil {
+			invalid = append(invalid, err.Error())
+			continue
 		}
+		rules = append(rules, rule)
 	}
+	d.ignores = rules
+	if len(invalid) > 0 {
+		return fmt.Errorf("ignoring invalid drift patterns: %s", strings.Join(invalid, "; "))
+	}
+	return nil
+}
 
-	if changed {
+// SetPaths updates the monitored paths and resets state to avoid false positives
+func (d *Detector) SetPaths(paths []string) {
+	if !equalPaths(d.paths, paths) {
 		d.paths = paths
 		// Clear state to force a fresh baseline
 		d.lastState = make(map[string]FileState)
+		d.contents = make(map[string]string)
+		d.readHash = make(map[string]string)
+		d.saveBaseline()
+	}
+}
+
+// Acknowledge refreshes the baseline for a file the agent changed itself,
+// so the change is not reported as drift
+func (d *Detector) Acknowledge(path string) {
+	if _, tracked := d.lastState[path]; !tracked {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		delete(d.lastState, path)
+		d.saveBaseline()
+		return
+	}
+	chksum, err := calculateFileChecksum(path)
+	if err != nil {
+		return
+	}
+	d.lastState[path] = fileState(chksum, info)
+	d.readHash[path] = chksum
+	if text, ok := readText(path, info.Size()); ok {
+		d.contents[path] = text
+	} else {
+		delete(d.contents, path)
 	}
+	d.saveBaseline()
+}
+
+// SetAttributor enables "modified by" attribution of changed files
+func (d *Detector) SetAttributor(a Attributor) {
+	d.attribute = a
+}
+
+// Writers returns the attributed writer of each file changed in the last Check
+func (d *Detector) Writers() map[string]string {
+	return d.writers
+}
+
+// byWriter returns a " by <process>" suffix and remembers it for Writers
+func (d *Detector) byWriter(path string) string {
+	if d.attribute == nil {
+		return ""
+	}
+	who, ok := d.attribute(path)
+	if !ok {
+		return ""
+	}
+	d.writers[path] = who
+	return " by " + who
+}
+
+// Rebaseline accepts the current file state as the new baseline without reporting changes
+func (d *Detector) Rebaseline() error {
+	currentState, err := d.scan(nil)
+	if err != nil {
+		return fmt.Errorf("failed to calculate state: %w", err)
+	}
+	d.lastState = currentState
+	d.contents = make(map[string]string)
+	d.readHash = make(map[string]string)
+	d.updateContents(currentState)
+	d.saveBaseline()
+	return nil
+}
+
+// Changes returns every file changed in the last Check, sorted by path
+func (d *Detector) Changes() []FileChange {
+	return d.changes
+}
+
+// Diffs returns unified diffs of the text files changed in the last Check
+func (d *Detector) Diffs() map[string]string {
+	return d.diffs
+}
+
+// updateContents keeps the content of small text files whose hash is new,
+// so the next change can be shown as a diff
+func (d *Detector) updateContents(currentState map[string]FileState) {
+	for path, state := range currentState {
+		if d.readHash[path] == state.Hash {
+			continue
+		}
+		d.readHash[path] = state.Hash
+		if text, ok := readText(path, state.Size); ok {
+			d.contents[path] = text
+		} else {
+			delete(d.contents, path)
+		}
+	}
+	for path := range d.readHash {
+		if _, ok := currentState[path]; !ok {
+			delete(d.readHash, path)
+			delete(d.contents, path)
+		}
+	}
+}
+
+// Preview scans the monitored paths without touching the baseline and
+// returns the files a first Check would track and those the ignore patterns
+// leave out, both sorted
+func (d *Detector) Preview() (tracked, ignored []string, err error) {
+	all, err := calculateState(d.paths, nil, nil, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to calculate state: %w", err)
+	}
+	kept, err := calculateState(d.paths, d.ignores, nil, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to calculate state: %w", err)
+	}
+	for path := range all {
+		if _, ok := kept[path]; ok {
+			tracked = append(tracked, path)
+		} else {
+			ignored = append(ignored, path)
+		}
+	}
+	sort.Strings(tracked)
+	sort.Strings(ignored)
+	return tracked, ignored, nil
 }
 
 // Check calculates the current state and returns details about changes
 func (d *Detector) Check() (changed bool, summary string, err error) {
-	currentState, err := calculateState(d.paths, d.ignores)
+	currentState, err := d.scan(d.lastState)
 	if err != nil {
 		return false, "", fmt.Errorf("failed to calculate state: %w", err)
 	}
 
 	// First run, just populate state
 	if len(d.lastState) == 0 {
+		d.contents = make(map[string]string)
+		d.readHash = make(map[string]string)
+		d.updateContents(currentState)
 		d.lastState = currentState
+		d.saveBaseline()
 		return false, "", nil
 	}
 
 	var changes []string
+	d.diffs = make(map[string]string)
+	d.writers = make(map[string]string)
+	d.changes = nil
+	diffBytes := 0
+	addDiff := func(path, oldText, newText string) {
+		if diffBytes >= maxDiffTotal {
+			return
+		}
+		if diff := unifiedDiff(path, oldText, newText); diff != "" {
+			d.diffs[path] = diff
+			diffBytes += len(diff)
+		}
+	}
 
 	// Check for added or modified files
 	for path, newState := range currentState {
 		oldState, exists := d.lastState[path]
 		if !exists {
-			changes = append(changes, fmt.Sprintf("File created: %s", path))
+			changes = append(changes, fmt.Sprintf("File created: %s%s", path, d.byWriter(path)))
+			d.changes = append(d.changes, FileChange{Path: path, Change: "created", By: d.writers[path]})
+			if text, ok := readText(path, newState.Size); ok {
+				addDiff(path, "", text)
+			}
 		} else {
 			// Check specific attributes
 			var diffs []string
 			if oldState.Hash != newState.Hash {
 				diffs = append(diffs, "Content changed")
+				if oldText, ok := d.contents[path]; ok {
+					if newText, ok := readText(path, newState.Size); ok {
+						addDiff(path, oldText, newText)
+					}
+				}
 			}
 			if oldState.Size != newState.Size {
 				diffs = append(diffs, fmt.Sprintf("Size: %d->%d B", oldState.Size, newState.Size))
@@ -99,7 +331,8 @@ func (d *Detector) Check() (changed bool, summary string, err error) {
 				if len(diffs) > 0 {
 					details = fmt.Sprintf(" (%s)", joinStrings(diffs, ", "))
 				}
-				changes = append(changes, fmt.Sprintf("File modified: %s%s", path, details))
+				changes = append(changes, fmt.Sprintf("File modified: %s%s%s", path, details, d.byWriter(path)))
+				d.changes = append(d.changes, FileChange{Path: path, Change: "modified", Detail: joinStrings(diffs, ", "), By: d.writers[path]})
 			}
 		}
 	}
@@ -111,6 +344,10 @@ func (d *Detector) Check() (changed bool, summary string, err error) {
 			// (If a path was removed from config, we reset state so we won't get here, 
 			// but good to be safe)
 			changes = append(changes, fmt.Sprintf("File deleted: %s", path))
+			d.changes = append(d.changes, FileChange{Path: path, Change: "deleted"})
+			if oldText, ok := d.contents[path]; ok {
+				addDiff(path, oldText, "")
+			}
 		}
 	}
 
@@ -119,11 +356,14 @@ func (d *Detector) Check() (changed bool, summary string, err error) {
         // fmt.Printf("DEBUG: Drift Check - Updating lastState. PrevHash: %v, NewHash: %v\n", d.lastState[changes[0]].Hash, currentState[changes[0]].Hash)
     }
 
+	d.updateContents(currentState)
 	d.lastState = currentState
+	d.saveBaseline()
 
 	if len(changes) > 0 {
 		// Sort changes for consistency
 		sort.Strings(changes)
+		sort.Slice(d.changes, func(i, j int) bool { return d.changes[i].Path < d.changes[j].Path })
 		
 		// Create summary
 		summary = changes[0]
@@ -137,8 +377,22 @@ func (d *Detector) Check() (changed bool, summary string, err error) {
 	return false, "", nil
 }
 
-// calculateState computes Hash, Size, and Mode of each file in the directory trees
-func calculateState(roots []string, ignores []string) (map[string]FileState, error) {
+// scan calculates the current state with the detector's throttle, reusing
+// hashes from prev for files that look unchanged (nil rehashes everything)
+func (d *Detector) scan(prev map[string]FileState) (state map[string]FileState, err error) {
+	if !d.throttle.SkipUnchanged {
+		prev = nil
+	}
+	withPriority(d.throttle, func() {
+		state, err = calculateState(d.paths, d.ignores, prev, newPacer(d.throttle))
+	})
+	return state, err
+}
+
+// calculateState computes Hash, Size, and Mode of each file in the directory trees.
+// Files whose size, mode, mtime and ctime match prev keep their previous hash
+// without being read; the pacer (if any) rate-limits the files that are read.
+func calculateState(roots []string, ignores []ignoreRule, prev map[string]FileState, p *pacer) (map[string]FileState, error) {
 	state := make(map[string]FileState)
 
 	for _, root := range roots {
@@ -156,8 +410,13 @@ func calculateState(roots []string, ignores []string) (map[string]FileState, err
 				return err
 			}
 
-			// Skip directories
+			// Skip directories (and don't descend into ignored ones)
 			if info.IsDir() {
+				if path != root {
+					if relPath, err := filepath.Rel(root, path); err == nil && ignoredDir(ignores, path, relPath) {
+						return filepath.SkipDir
+					}
+				}
 				return nil
 			}
 
@@ -168,18 +427,17 @@ func calculateState(roots []string, ignores []string) (map[string]FileState, err
 
 			// Check against ignore patterns
 			relPath, err := filepath.Rel(root, path)
-			if err == nil {
-				for _, pattern := range ignores {
-					if matched, _ := filepath.Match(pattern, relPath); matched {
-						return nil
-					}
-					if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
-						return nil
-					}
-				}
+			if err == nil && ignored(ignores, path, relPath) {
+				return nil
+			}
+
+			if old, ok := prev[path]; ok && unchanged(old, info) {
+				state[path] = old
+				return nil
 			}
 
 			// Calculate file checksum
+			p.wait(info.Size())
 			chksum, err := calculateFileChecksum(path)
 			if err != nil {
 				// If we can't read it (e.g. transient file), skip it
@@ -187,11 +445,7 @@ func calculateState(roots []string, ignores []string) (map[string]FileState, err
 				return nil
 			}
 
-			state[path] = FileState{
-				Hash: chksum,
-				Size: info.Size(),
-				Mode: info.Mode().Perm(),
-			}
+			state[path] = fileState(chksum, info)
 			return nil
 		})
 
@@ -203,6 +457,27 @@ func calculateState(roots []string, ignores []string) (map[string]FileState, err
 	return state, nil
 }
 
+// fileState records the hash and the attributes of a file
+func fileState(hash string, info os.FileInfo) FileState {
+	return FileState{
+		Hash:    hash,
+		Size:    info.Size(),
+		Mode:    info.Mode().Perm(),
+		ModTime: info.ModTime().UnixNano(),
+		CTime:   changeTime(info),
+	}
+}
+
+// unchanged reports whether a file still matches its previous state closely
+// enough to skip hashing it
+func unchanged(old FileState, info os.FileInfo) bool {
+	return old.ModTime != 0 &&
+		old.Size == info.Size() &&
+		old.Mode == info.Mode().Perm() &&
+		old.ModTime == info.ModTime().UnixNano() &&
+		old.CTime == changeTime(info)
+}
+
 // calculateFileChecksum computes SHA256 of a single file content
 func calculateFileChecksum(path string) (string, error) {
 	file, err := os.Open(path)
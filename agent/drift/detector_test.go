@@ -0,0 +1,52 @@
+package drift
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/nodeguarder/state"
+)
+
+func TestBaselineSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	store, err := state.Open(filepath.Join(t.TempDir(), state.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	conf := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(conf, []byte("port=80\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d := New([]string{dir})
+	d.SetState(store.Namespace("drift"))
+	if changed, _, err := d.Check(); err != nil || changed {
+		t.Fatalf("first check: changed=%v err=%v", changed, err)
+	}
+
+	// Changed while the agent was stopped
+	if err := os.WriteFile(conf, []byte("port=8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted := New([]string{dir})
+	if err := restarted.SetState(store.Namespace("drift")); err != nil {
+		t.Fatal(err)
+	}
+	changed, summary, err := restarted.Check()
+	if err != nil || !changed {
+		t.Fatalf("check after restart: changed=%v err=%v", changed, err)
+	}
+	if want := "File modified: " + conf; len(summary) < len(want) || summary[:len(want)] != want {
+		t.Errorf("summary = %q, want %q...", summary, want)
+	}
+
+	// A baseline of other paths is not restored
+	other := New([]string{t.TempDir()})
+	other.SetState(store.Namespace("drift"))
+	if len(other.lastState) != 0 {
+		t.Error("restored a baseline saved for other paths")
+	}
+}
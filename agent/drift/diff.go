@@ -0,0 +1,149 @@
+package drift
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	maxDiffFileSize = 64 * 1024  // Only text files up to this size are diffed
+	maxDiffLines    = 2000       // Per side; larger files only report "Content changed"
+	maxDiffTotal    = 256 * 1024 // Total diff bytes attached to one drift event
+	diffContext     = 3
+)
+
+// readText returns the content of a small text file, or false for binary/large/unreadable files
+func readText(path string, size int64) (string, bool) {
+	if size > maxDiffFileSize {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) > maxDiffFileSize {
+		return "", false
+	}
+	if strings.IndexByte(string(data), 0) >= 0 || !utf8.Valid(data) {
+		return "", false
+	}
+	return string(data), true
+}
+
+// unifiedDiff renders a unified diff (3 lines of context) between two versions of a file.
+// Returns "" if the files are too large to diff.
+func unifiedDiff(path, oldText, newText string) string {
+	a, b := splitLines(oldText), splitLines(newText)
+	if len(a) > maxDiffLines || len(b) > maxDiffLines {
+		return ""
+	}
+
+	// Longest common subsequence table (lcs[i][j] = LCS of a[i:], b[j:])
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// Edit script: ' ' keep, '-' delete, '+' insert
+	type op struct {
+		kind       byte
+		text       string
+		oldN, newN int // 1-based line numbers before this op
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, op{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{'-', a[i], i, j})
+			i++
+		default:
+			ops = append(ops, op{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	// Group changes into hunks with context
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", path, path)
+	for start := 0; start < len(ops); {
+		// Find next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+		hunkStart := start - diffContext
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		// Extend until a run of unchanged lines longer than 2*context
+		end := start
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				end += diffContext
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = run
+		}
+
+		oldCount, newCount := 0, 0
+		for _, o := range ops[hunkStart:end] {
+			if o.kind != '+' {
+				oldCount++
+			}
+			if o.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", hunkLine(ops[hunkStart].oldN, oldCount), oldCount, hunkLine(ops[hunkStart].newN, newCount), newCount)
+		for _, o := range ops[hunkStart:end] {
+			sb.WriteByte(o.kind)
+			sb.WriteString(o.text)
+			sb.WriteByte('\n')
+		}
+		start = end
+	}
+	return sb.String()
+}
+
+// hunkLine converts a 0-based position to the unified diff start line (0 for empty ranges)
+func hunkLine(pos, count int) int {
+	if count == 0 {
+		return pos
+	}
+	return pos + 1
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
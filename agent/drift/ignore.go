@@ -0,0 +1,87 @@
+package drift
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule is a compiled drift ignore pattern. Three forms are supported:
+//   - "re:<regexp>" matched against the absolute path
+//   - "<dir>/" (trailing slash) ignores everything below that directory;
+//     absolute, or relative to the monitored root
+//   - anything else is a filepath.Match glob tried on the relative path and base name
+type ignoreRule struct {
+	glob   string
+	re     *regexp.Regexp
+	prefix string
+}
+
+// compileIgnore parses a single ignore pattern
+func compileIgnore(pattern string) (ignoreRule, error) {
+	switch {
+	case strings.HasPrefix(pattern, "re:"):
+		re, err := regexp.Compile(strings.TrimPrefix(pattern, "re:"))
+		if err != nil {
+			return ignoreRule{}, fmt.Errorf("invalid regex %q: %w", pattern, err)
+		}
+		return ignoreRule{re: re}, nil
+	case strings.HasSuffix(pattern, "/"):
+		dir := filepath.Clean(pattern)
+		if dir == "/" || dir == "." {
+			return ignoreRule{}, fmt.Errorf("directory pattern %q would ignore everything", pattern)
+		}
+		return ignoreRule{prefix: dir}, nil
+	default:
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return ignoreRule{}, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+		return ignoreRule{glob: pattern}, nil
+	}
+}
+
+// underDir reports whether path equals dir or lies below it
+func underDir(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// matches reports whether a path (absolute, and relative to its root) is ignored
+func (r ignoreRule) matches(path, relPath string) bool {
+	switch {
+	case r.re != nil:
+		return r.re.MatchString(path)
+	case r.prefix != "":
+		if filepath.IsAbs(r.prefix) {
+			return underDir(path, r.prefix)
+		}
+		return underDir(relPath, r.prefix)
+	default:
+		if matched, _ := filepath.Match(r.glob, relPath); matched {
+			return true
+		}
+		matched, _ := filepath.Match(r.glob, filepath.Base(path))
+		return matched
+	}
+}
+
+// ignored reports whether any rule matches the path
+func ignored(rules []ignoreRule, path, relPath string) bool {
+	for _, r := range rules {
+		if r.matches(path, relPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredDir reports whether a directory is covered by a "dir/" prefix rule,
+// so the walk can skip it entirely
+func ignoredDir(rules []ignoreRule, path, relPath string) bool {
+	for _, r := range rules {
+		if r.prefix != "" && r.matches(path, relPath) {
+			return true
+		}
+	}
+	return false
+}
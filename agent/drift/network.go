@@ -0,0 +1,134 @@
+package drift
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// ListeningSocket is a socket accepting connections on the host
+type ListeningSocket struct {
+	Proto   string `json:"proto"` // tcp, tcp6
+	Address string `json:"address"`
+	Port    uint32 `json:"port"`
+	PID     int32  `json:"pid"`
+	Process string `json:"process"`
+}
+
+// key identifies a socket independently of the PID (restarts keep the same key)
+func (s ListeningSocket) key() string {
+	return fmt.Sprintf("%s/%s:%d/%s", s.Proto, s.Address, s.Port, s.Process)
+}
+
+func (s ListeningSocket) String() string {
+	name := s.Process
+	if name == "" {
+		name = "unknown"
+	}
+	return fmt.Sprintf("%s %s:%d (%s)", s.Proto, s.Address, s.Port, name)
+}
+
+// NetworkDetector reports listening sockets that appear or disappear
+type NetworkDetector struct {
+	lastState   map[string]ListeningSocket
+	initialized bool // the baseline was taken (it may be empty)
+	list        func() (map[string]ListeningSocket, error)
+}
+
+// NewNetworkDetector creates a listening-port drift detector
+func NewNetworkDetector() *NetworkDetector {
+	return &NetworkDetector{
+		lastState: make(map[string]ListeningSocket),
+		list:      listeningSockets,
+	}
+}
+
+// Check snapshots listening sockets and returns a summary of changes since the last check.
+// The first call only establishes the baseline.
+func (n *NetworkDetector) Check() (changed bool, summary string, changes []string, err error) {
+	current, err := n.list()
+	if err != nil {
+		return false, "", nil, fmt.Errorf("failed to list sockets: %w", err)
+	}
+
+	if !n.initialized {
+		n.lastState = current
+		n.initialized = true
+		return false, "", nil, nil
+	}
+
+	for key, sock := range current {
+		if _, ok := n.lastState[key]; !ok {
+			changes = append(changes, "Port opened: "+sock.String())
+		}
+	}
+	for key, sock := range n.lastState {
+		if _, ok := current[key]; !ok {
+			changes = append(changes, "Port closed: "+sock.String())
+		}
+	}
+	n.lastState = current
+
+	if len(changes) == 0 {
+		return false, "", nil, nil
+	}
+
+	sort.Strings(changes)
+	summary = changes[0]
+	if len(changes) > 1 {
+		summary = fmt.Sprintf("%s and %d others", changes[0], len(changes)-1)
+	}
+	return true, summary, changes, nil
+}
+
+// Reset drops the baseline (e.g. when the mode is toggled)
+func (n *NetworkDetector) Reset() {
+	n.lastState = make(map[string]ListeningSocket)
+	n.initialized = false
+}
+
+func listeningSockets() (map[string]ListeningSocket, error) {
+	conns, err := net.Connections("inet")
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[int32]string)
+	state := make(map[string]ListeningSocket)
+	for _, c := range conns {
+		// Only TCP LISTEN sockets; unconnected UDP sockets include short-lived
+		// client ports (DNS, NTP) and would make the signal noisy
+		if c.Status != "LISTEN" {
+			continue
+		}
+
+		sock := ListeningSocket{
+			Proto:   protoName(c),
+			Address: c.Laddr.IP,
+			Port:    c.Laddr.Port,
+			PID:     c.Pid,
+		}
+		if c.Pid > 0 {
+			name, ok := names[c.Pid]
+			if !ok {
+				if p, err := process.NewProcess(c.Pid); err == nil {
+					name, _ = p.Name()
+				}
+				names[c.Pid] = name
+			}
+			sock.Process = name
+		}
+		state[sock.key()] = sock
+	}
+	return state, nil
+}
+
+func protoName(c net.ConnectionStat) string {
+	if c.Family == 10 || strings.Contains(c.Laddr.IP, ":") { // AF_INET6
+		return "tcp6"
+	}
+	return "tcp"
+}
@@ -0,0 +1,50 @@
+package drift
+
+import "testing"
+
+// fakeSockets makes the detector see the given snapshots, one per Check
+func fakeSockets(n *NetworkDetector, snapshots ...[]ListeningSocket) {
+	n.list = func() (map[string]ListeningSocket, error) {
+		state := make(map[string]ListeningSocket)
+		if len(snapshots) > 0 {
+			for _, sock := range snapshots[0] {
+				state[sock.key()] = sock
+			}
+			snapshots = snapshots[1:]
+		}
+		return state, nil
+	}
+}
+
+func TestNetworkDriftFromNoListeningSockets(t *testing.T) {
+	ssh := ListeningSocket{Proto: "tcp", Address: "0.0.0.0", Port: 22, PID: 1, Process: "sshd"}
+	n := NewNetworkDetector()
+	fakeSockets(n, nil, nil, []ListeningSocket{ssh}, nil)
+
+	if changed, _, _, err := n.Check(); err != nil || changed {
+		t.Fatalf("baseline: changed=%v err=%v", changed, err)
+	}
+	if changed, _, _, _ := n.Check(); changed {
+		t.Error("still no sockets reported as a change")
+	}
+	changed, summary, changes, _ := n.Check()
+	if !changed || len(changes) != 1 || summary != "Port opened: tcp 0.0.0.0:22 (sshd)" {
+		t.Errorf("first port on an empty host: changed=%v %q %v", changed, summary, changes)
+	}
+	changed, summary, _, _ = n.Check()
+	if !changed || summary != "Port closed: tcp 0.0.0.0:22 (sshd)" {
+		t.Errorf("last port closed: changed=%v %q", changed, summary)
+	}
+}
+
+func TestNetworkDriftResetTakesNewBaseline(t *testing.T) {
+	web := ListeningSocket{Proto: "tcp", Address: "0.0.0.0", Port: 80, Process: "nginx"}
+	n := NewNetworkDetector()
+	fakeSockets(n, nil, []ListeningSocket{web})
+
+	n.Check()
+	n.Reset()
+	if changed, _, _, _ := n.Check(); changed {
+		t.Error("first check after Reset reported a change")
+	}
+}
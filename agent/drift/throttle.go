@@ -0,0 +1,58 @@
+package drift
+
+import (
+	"time"
+)
+
+// Throttle limits the resources a drift scan may use, so hashing large
+// trees does not spike CPU and disk on small VMs
+type Throttle struct {
+	FilesPerSec   int   `yaml:"files_per_sec"`  // Files hashed per second (0 = unlimited)
+	BytesPerSec   int64 `yaml:"bytes_per_sec"`  // Bytes read per second (0 = unlimited)
+	Nice          int   `yaml:"nice"`           // CPU nice of the scan (1-19, 0 = unchanged); also lowers its I/O priority
+	IOIdle        bool  `yaml:"io_idle"`        // Read only when the disk is otherwise idle
+	SkipUnchanged bool  `yaml:"skip_unchanged"` // Reuse the hash of files whose size, mtime and ctime did not change (default true)
+}
+
+// DefaultThrottle hashes at full speed but skips unchanged files
+var DefaultThrottle = Throttle{SkipUnchanged: true}
+
+// pacer sleeps between files to keep a scan under the throttle rates
+type pacer struct {
+	throttle Throttle
+	start    time.Time
+	files    int
+	bytes    int64
+	sleep    func(time.Duration) // replaced in tests
+}
+
+func newPacer(t Throttle) *pacer {
+	return &pacer{throttle: t, start: time.Now(), sleep: time.Sleep}
+}
+
+// wait accounts for a file about to be hashed and blocks until reading it
+// keeps the scan within the configured rates
+func (p *pacer) wait(size int64) {
+	if p == nil {
+		return
+	}
+	var due time.Duration
+	if p.throttle.FilesPerSec > 0 {
+		due = time.Duration(p.files) * time.Second / time.Duration(p.throttle.FilesPerSec)
+	}
+	if p.throttle.BytesPerSec > 0 {
+		if d := time.Duration(float64(p.bytes) / float64(p.throttle.BytesPerSec) * float64(time.Second)); d > due {
+			due = d
+		}
+	}
+	if elapsed := time.Since(p.start); due > elapsed {
+		p.sleep(due - elapsed)
+	}
+	p.files++
+	p.bytes += size
+}
+
+// unthrottled reports whether scans can run without a dedicated thread
+func (t Throttle) unthrottled() bool {
+	return t.Nice <= 0 && !t.IOIdle
+}
@@ -0,0 +1,56 @@
+//go:build linux
+
+package drift
+
+import (
+	"log"
+	"os"
+	"runtime"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	ioprioWhoProcess = 1
+	ioprioClassIdle  = 3
+	ioprioClassShift = 13
+)
+
+// withPriority runs a scan on its own OS thread with the configured nice and
+// I/O class, so only the scan is deprioritised and not the whole agent
+func withPriority(t Throttle, scan func()) {
+	if t.unthrottled() {
+		scan()
+		return
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// The thread is never unlocked: it exits with this goroutine instead
+		// of returning to the scheduler with a lowered priority
+		runtime.LockOSThread()
+		tid := unix.Gettid()
+		if t.Nice > 0 {
+			if err := unix.Setpriority(unix.PRIO_PROCESS, tid, t.Nice); err != nil {
+				log.Printf("⚠️ Failed to set drift scan nice: %v", err)
+			}
+		}
+		if t.IOIdle {
+			if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, uintptr(tid), ioprioClassIdle<<ioprioClassShift); errno != 0 {
+				log.Printf("⚠️ Failed to set drift scan I/O class: %v", errno)
+			}
+		}
+		scan()
+	}()
+	<-done
+}
+
+// changeTime returns the inode change time, which catches changes that
+// restore the mtime (e.g. touch -r or tar extraction)
+func changeTime(info os.FileInfo) int64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return st.Ctim.Nano()
+	}
+	return 0
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package drift
+
+import "os"
+
+// withPriority runs the scan directly: nice and I/O class are only applied on Linux
+func withPriority(t Throttle, scan func()) {
+	scan()
+}
+
+// changeTime is not tracked outside Linux; size and mtime still are
+func changeTime(info os.FileInfo) int64 {
+	return 0
+}
@@ -4,18 +4,19 @@
 
 char __license[] SEC("license") = "Dual MIT/GPL";
 
-// Struct definitions for Tracepoints
+// Struct definitions for Tracepoints. The fork context is relocated against
+// the kernel's BTF (CO-RE), so child_pid is found even where the layout differs
+// from these headers.
 struct trace_event_raw_sched_process_fork {
 	short type;
 	unsigned char flags;
 	unsigned char preempt_count;
 	int pid; // common_pid, not the pid we want
-    // offset 8
 	char parent_comm[16];
 	pid_t parent_pid;
 	char child_comm[16];
 	pid_t child_pid;
-};
+} __attribute__((preserve_access_index));
 
 // We don't use sched_process_template for exit code anymore
 struct trace_event_raw_sched_process_template {
@@ -25,6 +26,15 @@ struct trace_event_raw_sched_process_template {
 	int pid;
 };
 
+// Room for the NUL-separated argv of a job's shell ("/bin/sh\0-c\0<command>")
+#define ARGV_LEN 512
+
+struct exec_args {
+	u32 len;        // bytes of args in use
+	u32 truncated;  // argv was longer than ARGV_LEN
+	char args[ARGV_LEN];
+};
+
 struct event {
 	u32 pid;
     u32 parent_pid;
@@ -32,6 +42,10 @@ struct event {
     u32 ns_parent_pid;
 	int exit_code;
 	u8 comm[16];
+	int signal;      // terminating signal, 0 for a normal exit
+	int core_dumped;
+	int oom_killed;  // picked as victim by the OOM killer
+	struct exec_args exec; // argv of the first exec, len 0 if none was seen
 };
 
 struct {
@@ -41,6 +55,29 @@ struct {
 	__type(value, u8);
 } monitored_pids SEC(".maps");
 
+// argv of monitored processes, recorded at exec and sent with the exit
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, 10240);
+	__type(key, u32);
+	__type(value, struct exec_args);
+} exec_args SEC(".maps");
+
+// Per-CPU scratch space: argv and the event are too large for the BPF stack
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, u32);
+	__type(value, struct exec_args);
+} exec_heap SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, u32);
+	__type(value, struct event);
+} event_heap SEC(".maps");
+
 struct {
 	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
 	__uint(key_size, sizeof(u32));
@@ -65,6 +102,43 @@ int handle_fork(struct trace_event_raw_sched_process_fork *ctx) {
     return 0;
 }
 
+// Record the argv of a monitored process. Only the first exec is kept: cron's
+// child execs "/bin/sh -c <command>", which names the job even when the shell
+// later execs the script itself (and the exit's comm is just "sh" or the script).
+SEC("tracepoint/sched/sched_process_exec")
+int handle_exec(void *ctx) {
+    u32 pid = bpf_get_current_pid_tgid() >> 32;
+    if (!bpf_map_lookup_elem(&monitored_pids, &pid)) {
+        return 0;
+    }
+
+    u32 zero = 0;
+    struct exec_args *a = bpf_map_lookup_elem(&exec_heap, &zero);
+    if (!a) {
+        return 0;
+    }
+
+    struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+    struct mm_struct *mm = BPF_CORE_READ(task, mm);
+    unsigned long start = BPF_CORE_READ(mm, arg_start);
+    unsigned long end = BPF_CORE_READ(mm, arg_end);
+
+    unsigned long len = end - start;
+    a->truncated = 0;
+    if (len >= ARGV_LEN) {
+        len = ARGV_LEN - 1;
+        a->truncated = 1;
+    }
+    len &= ARGV_LEN - 1; // bound for the verifier
+    if (bpf_probe_read_user(a->args, len, (void *)start) != 0) {
+        return 0;
+    }
+    a->len = len;
+
+    bpf_map_update_elem(&exec_args, &pid, a, BPF_NOEXIST);
+    return 0;
+}
+
 static __always_inline u32 get_task_ns_pid(struct task_struct *task) {
     struct pid *pid_struct = BPF_CORE_READ(task, thread_pid);
     unsigned int level = BPF_CORE_READ(pid_struct, level);
@@ -82,9 +156,18 @@ int handle_exit(struct trace_event_raw_sched_process_template *ctx) {
     u8 *exists = bpf_map_lookup_elem(&monitored_pids, &pid);
 
     if (exists) {
-        struct event evt = {};
-        evt.pid = pid;
-        bpf_get_current_comm(&evt.comm, sizeof(evt.comm));
+        u32 zero = 0;
+        struct event *evt = bpf_map_lookup_elem(&event_heap, &zero);
+        if (!evt) {
+            bpf_map_delete_elem(&monitored_pids, &pid);
+            bpf_map_delete_elem(&exec_args, &pid);
+            return 0;
+        }
+        evt->pid = pid;
+        evt->signal = 0;
+        evt->core_dumped = 0;
+        evt->oom_killed = 0;
+        bpf_get_current_comm(&evt->comm, sizeof(evt->comm));
         
         // Read exit_code from task_struct
         struct task_struct *task = (struct task_struct *)bpf_get_current_task();
@@ -94,27 +177,45 @@ int handle_exit(struct trace_event_raw_sched_process_template *ctx) {
         exit_code = BPF_CORE_READ(task, exit_code);
         
         // Capture Parent PID (Global)
-        evt.parent_pid = BPF_CORE_READ(task, real_parent, pid);
+        evt->parent_pid = BPF_CORE_READ(task, real_parent, pid);
         
         // Capture Namespace PIDs
-        evt.ns_pid = get_task_ns_pid(task);
+        evt->ns_pid = get_task_ns_pid(task);
         
         struct task_struct *parent_task = BPF_CORE_READ(task, real_parent);
         if (parent_task) {
-            evt.ns_parent_pid = get_task_ns_pid(parent_task);
+            evt->ns_parent_pid = get_task_ns_pid(parent_task);
         } else {
-             evt.ns_parent_pid = 0;
+             evt->ns_parent_pid = 0;
         }
 
         
         // exit_code is (status << 8) | signal
-        evt.exit_code = (exit_code >> 8) & 0xFF;
+        evt->exit_code = (exit_code >> 8) & 0xFF;
         if ((exit_code & 0x7F) != 0) {
-             evt.exit_code = 128 + (exit_code & 0x7F);
+             evt->exit_code = 128 + (exit_code & 0x7F);
+             evt->signal = exit_code & 0x7F;
+             evt->core_dumped = (exit_code & 0x80) != 0;
+        }
+
+        // SIGKILL alone can't tell the OOM killer from "kill -9"
+        struct signal_struct *sig = BPF_CORE_READ(task, signal);
+        if (sig && bpf_core_field_exists(sig->oom_mm)) {
+             evt->oom_killed = BPF_CORE_READ(sig, oom_mm) != 0;
+        }
+
+        // argv from exec (the scratch event is reused, so always reset it)
+        struct exec_args *a = bpf_map_lookup_elem(&exec_args, &pid);
+        if (a) {
+            __builtin_memcpy(&evt->exec, a, sizeof(evt->exec));
+        } else {
+            evt->exec.len = 0;
+            evt->exec.truncated = 0;
         }
 
-        bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, &evt, sizeof(evt));
+        bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, evt, sizeof(*evt));
         bpf_map_delete_elem(&monitored_pids, &pid);
+        bpf_map_delete_elem(&exec_args, &pid);
     }
     return 0;
 }
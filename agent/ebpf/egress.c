@@ -0,0 +1,123 @@
+#include "vmlinux.h"
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+
+char __license[] SEC("license") = "Dual MIT/GPL";
+
+#define AF_INET 2
+#define AF_INET6 10
+#define IPPROTO_TCP 6
+#define TCP_SYN_SENT 2
+#define TCP_CLOSE 7
+
+// How far up the process tree to look for cron
+#define MAX_ANCESTORS 8
+
+// sock:inet_sock_set_state. The fields after family are not stable across
+// kernels (protocol grew from u8 to u16), so addresses are read from the socket.
+struct trace_event_raw_inet_sock_set_state {
+	short type;
+	unsigned char flags;
+	unsigned char preempt_count;
+	int pid;
+	const void *skaddr;
+	int oldstate;
+	int newstate;
+	u16 sport;
+	u16 dport;   // host byte order
+	u16 family;
+	u8 protocol; // low byte of the u16 on newer kernels (little endian)
+};
+
+struct egress_event {
+	u32 pid;
+	u32 uid;
+	u8 comm[16];
+	u8 daddr[16]; // IPv4 uses the first 4 bytes
+	u16 family;
+	u16 dport;
+	u32 from_cron; // cron is an ancestor of the process
+};
+
+// Destinations already reported per process name, so busy clients don't flood
+// the perf buffer. The agent keeps its own table; this only bounds the volume.
+struct egress_key {
+	u8 comm[16];
+	u8 daddr[16];
+	u16 family;
+	u16 dport;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 16384);
+	__type(key, struct egress_key);
+	__type(value, u8);
+} egress_seen SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(u32));
+	__uint(value_size, sizeof(u32));
+} egress_events SEC(".maps");
+
+static __always_inline int is_cron(const char *comm) {
+    return (comm[0] == 'c' && comm[1] == 'r' && comm[2] == 'o' && comm[3] == 'n') ||
+           (comm[0] == 'C' && comm[1] == 'R' && comm[2] == 'O' && comm[3] == 'N');
+}
+
+// A new outbound TCP connection: tcp_connect moves the socket from CLOSE to
+// SYN_SENT in the context of the connecting process.
+SEC("tracepoint/sock/inet_sock_set_state")
+int handle_connect(struct trace_event_raw_inet_sock_set_state *ctx) {
+    if (ctx->protocol != IPPROTO_TCP || ctx->oldstate != TCP_CLOSE || ctx->newstate != TCP_SYN_SENT) {
+        return 0;
+    }
+    if (ctx->family != AF_INET && ctx->family != AF_INET6) {
+        return 0;
+    }
+
+    struct egress_event evt;
+    __builtin_memset(&evt, 0, sizeof(evt));
+    evt.pid = bpf_get_current_pid_tgid() >> 32;
+    evt.uid = (u32)bpf_get_current_uid_gid();
+    evt.family = ctx->family;
+    evt.dport = ctx->dport;
+    bpf_get_current_comm(&evt.comm, sizeof(evt.comm));
+
+    struct sock *sk = (struct sock *)ctx->skaddr;
+    if (ctx->family == AF_INET) {
+        BPF_CORE_READ_INTO(&evt.daddr, sk, __sk_common.skc_daddr);
+    } else {
+        BPF_CORE_READ_INTO(&evt.daddr, sk, __sk_common.skc_v6_daddr);
+    }
+
+    struct egress_key key;
+    __builtin_memset(&key, 0, sizeof(key));
+    __builtin_memcpy(key.comm, evt.comm, sizeof(key.comm));
+    __builtin_memcpy(key.daddr, evt.daddr, sizeof(key.daddr));
+    key.family = evt.family;
+    key.dport = evt.dport;
+    u8 one = 1;
+    if (bpf_map_update_elem(&egress_seen, &key, &one, BPF_NOEXIST) != 0) {
+        return 0; // already reported
+    }
+
+    struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+    #pragma unroll
+    for (int i = 0; i < MAX_ANCESTORS; i++) {
+        task = BPF_CORE_READ(task, real_parent);
+        if (!task) {
+            break;
+        }
+        char comm[16];
+        BPF_CORE_READ_INTO(&comm, task, comm);
+        if (is_cron(comm)) {
+            evt.from_cron = 1;
+            break;
+        }
+    }
+
+    bpf_perf_event_output(ctx, &egress_events, BPF_F_CURRENT_CPU, &evt, sizeof(evt));
+    return 0;
+}
@@ -0,0 +1,125 @@
+//go:build linux && !lite
+
+package ebpf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+	"github.com/cilium/ebpf/rlimit"
+)
+
+// EgressProbe reports new outbound TCP connections. It is loaded separately from
+// the cron probes, so it can be turned on and off from the dashboard.
+type EgressProbe struct {
+	objs    EgressObjects
+	link    link.Link
+	rd      *perf.Reader
+	handler EgressHandler
+	once    sync.Once
+}
+
+// StartEgress loads and attaches the outbound connection probe. bufferPages sets
+// the per-CPU perf ring buffer size in memory pages (0 uses the default of 1 page),
+// btfPath the BTF file as for InitBPF.
+func StartEgress(bufferPages int, btfPath string, handler EgressHandler) (*EgressProbe, error) {
+	if err := rlimit.RemoveMemlock(); err != nil {
+		return nil, err
+	}
+	types, _, err := kernelTypes(btfPath)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &EgressProbe{handler: handler}
+	opts := &ebpf.CollectionOptions{Programs: ebpf.ProgramOptions{KernelTypes: types}}
+	if err := LoadEgressObjects(&p.objs, opts); err != nil {
+		return nil, err
+	}
+
+	tp, err := link.Tracepoint("sock", "inet_sock_set_state", p.objs.HandleConnect, nil)
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	p.link = tp
+
+	if bufferPages <= 0 {
+		bufferPages = 1
+	}
+	rd, err := perf.NewReader(p.objs.EgressEvents, bufferPages*os.Getpagesize())
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	p.rd = rd
+
+	go p.listen()
+	return p, nil
+}
+
+// Close detaches the probe and stops the listener
+func (p *EgressProbe) Close() {
+	p.once.Do(func() {
+		if p.rd != nil {
+			p.rd.Close()
+		}
+		if p.link != nil {
+			p.link.Close()
+		}
+		p.objs.Close()
+	})
+}
+
+func (p *EgressProbe) listen() {
+	// Matches struct egress_event in egress.c
+	var event struct {
+		Pid      uint32
+		UID      uint32
+		Comm     [16]byte
+		Daddr    [16]byte
+		Family   uint16
+		Dport    uint16
+		FromCron uint32
+	}
+
+	for {
+		record, err := p.rd.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				return
+			}
+			log.Printf("reading from egress perf reader: %s", err)
+			continue
+		}
+		if record.LostSamples != 0 {
+			log.Printf("egress perf buffer full, dropped %d samples", record.LostSamples)
+			continue
+		}
+		if err := binary.Read(bytes.NewReader(record.RawSample), binary.LittleEndian, &event); err != nil {
+			log.Printf("Failed to decode egress event: %v", err)
+			continue
+		}
+
+		ip := net.IP(append([]byte(nil), event.Daddr[:]...))
+		if event.Family == afInet {
+			ip = net.IPv4(event.Daddr[0], event.Daddr[1], event.Daddr[2], event.Daddr[3])
+		}
+		p.handler(EgressEvent{
+			Pid:      event.Pid,
+			UID:      event.UID,
+			Comm:     string(bytes.TrimRight(event.Comm[:], "\x00")),
+			DestIP:   ip,
+			DestPort: event.Dport,
+			FromCron: event.FromCron != 0,
+		})
+	}
+}
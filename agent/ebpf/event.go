@@ -0,0 +1,65 @@
+package ebpf
+
+import "net"
+
+// ProcessExitEvent represents a process exit event captured by eBPF
+type ProcessExitEvent struct {
+	Pid           uint32
+	ParentPid     uint32
+	NsPid         uint32
+	NsParentPid   uint32
+	ExitCode      int32 // exit status, or 128+signal when killed
+	Comm          [16]byte
+	Signal        int32 // terminating signal, 0 for a normal exit
+	CoreDumped    bool
+	OOMKilled     bool
+	Argv          []string // argv of the process's first exec (the job's "sh -c ..."), nil if not seen
+	ArgvTruncated bool     // argv was cut at the probe's buffer size
+	Source        string   // capture mechanism when not the eBPF probes (e.g. "proc connector")
+}
+
+// EventHandler callback type
+type EventHandler func(ProcessExitEvent)
+
+// Stats are the listener counters reported in agent self-metrics, so silent
+// degradation of zero-touch detection (dropped samples, reader restarts) is visible
+type Stats struct {
+	Enabled      bool   `json:"enabled"`
+	Received     uint64 `json:"received"`
+	Lost         uint64 `json:"lost"`
+	DecodeErrors uint64 `json:"decode_errors"`
+	Unmatched    uint64 `json:"unmatched"` // exits not matching a tracked cron job (kept as orphans)
+	Restarts     uint64 `json:"restarts"`  // perf reader re-initializations
+	BufferBytes  int    `json:"buffer_bytes"`
+
+	// Capability of this host, so the dashboard shows which nodes have Zero Touch
+	Level   string   `json:"level"`             // LevelFull, LevelBasic or LevelNone
+	Kernel  string   `json:"kernel,omitempty"`  // running kernel release
+	BTF     string   `json:"btf,omitempty"`     // "kernel" or the BTF file CO-RE relocations used
+	Probes  []string `json:"probes,omitempty"`  // attached tracepoints
+	Missing []string `json:"missing,omitempty"` // kernel features the probes need but lack
+	Error   string   `json:"error,omitempty"`   // why loading failed
+}
+
+// eBPF capability levels
+const (
+	LevelFull  = "full"  // fork/exec/exit probes: exit codes, argv and OOM kills
+	LevelBasic = "basic" // fork/exit probes: exit codes matched by PID only
+	LevelNone  = "none"  // cron failures come from log parsing
+)
+
+// afInet is AF_INET as reported by the egress probe (IPv6 is AF_INET6)
+const afInet = 2
+
+// EgressEvent is a new outbound TCP connection captured by the egress probe
+type EgressEvent struct {
+	Pid      uint32
+	UID      uint32
+	Comm     string
+	DestIP   net.IP
+	DestPort uint16
+	FromCron bool // cron is an ancestor of the process
+}
+
+// EgressHandler callback type
+type EgressHandler func(EgressEvent)
@@ -0,0 +1,97 @@
+//go:build linux && !lite
+
+package ebpf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/asm"
+	"github.com/cilium/ebpf/btf"
+	"github.com/cilium/ebpf/features"
+)
+
+// btfDir holds BTF files for kernels built without CONFIG_DEBUG_INFO_BTF,
+// named <kernel release>.btf (the layout of BTFHub archives)
+const btfDir = "/var/lib/nodeguarder-agent/btf"
+
+// kernelRelease returns the running kernel version, e.g. "5.4.0-150-generic"
+func kernelRelease() string {
+	data, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// kernelTypes finds the BTF the CO-RE relocations are resolved against. An
+// explicit btfPath wins; otherwise the kernel's own BTF (/sys/kernel/btf/vmlinux
+// or a vmlinux image under /boot) is used, then a file from btfDir. It returns
+// nil types when the kernel BTF can be used as is, and where the types came from.
+func kernelTypes(btfPath string) (*btf.Spec, string, error) {
+	if btfPath != "" {
+		spec, err := btf.LoadSpec(btfPath)
+		if err != nil {
+			return nil, "", fmt.Errorf("loading BTF from %s: %w", btfPath, err)
+		}
+		return spec, btfPath, nil
+	}
+	if _, err := btf.LoadKernelSpec(); err == nil {
+		return nil, "kernel", nil
+	}
+	release := kernelRelease()
+	if release != "" {
+		path := filepath.Join(btfDir, release+".btf")
+		if spec, err := btf.LoadSpec(path); err == nil {
+			return spec, path, nil
+		}
+	}
+	return nil, "", fmt.Errorf("kernel %s has no BTF (CONFIG_DEBUG_INFO_BTF); set ebpf_btf_path or put %s.btf from BTFHub in %s", release, release, btfDir)
+}
+
+// missingFeatures lists the kernel features the cron probes need but the
+// running kernel lacks. Probe errors other than "not supported" (usually
+// missing privileges) are returned as is.
+func missingFeatures() ([]string, error) {
+	checks := []struct {
+		name string
+		err  error
+	}{
+		{"tracepoint programs", features.HaveProgramType(ebpf.TracePoint)},
+		{"perf event array", features.HaveMapType(ebpf.PerfEventArray)},
+		{"per-CPU array", features.HaveMapType(ebpf.PerCPUArray)},
+		{"bpf_get_current_task", features.HaveProgramHelper(ebpf.TracePoint, asm.FnGetCurrentTask)},
+		{"bpf_perf_event_output", features.HaveProgramHelper(ebpf.TracePoint, asm.FnPerfEventOutput)},
+	}
+	var missing []string
+	for _, c := range checks {
+		switch {
+		case c.err == nil:
+		case errors.Is(c.err, ebpf.ErrNotSupported):
+			missing = append(missing, c.name)
+		default:
+			return missing, c.err
+		}
+	}
+	return missing, nil
+}
+
+// Unavailable describes the eBPF capability of a host where InitBPF failed, so
+// the dashboard shows why Zero Touch detection is off
+func Unavailable(btfPath string, err error) Stats {
+	s := Stats{Level: LevelNone, Kernel: kernelRelease()}
+	if err != nil {
+		s.Error = err.Error()
+	}
+	s.Missing, _ = missingFeatures()
+	if _, source, btfErr := kernelTypes(btfPath); btfErr == nil {
+		s.BTF = source
+	} else {
+		s.Missing = append(s.Missing, "BTF")
+	}
+	return s
+}
@@ -1,3 +1,4 @@
 package ebpf
 
-//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel Bpf cron_exit.c -- -I/usr/include/ -I.
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -tags linux,!lite Bpf cron_exit.c -- -I/usr/include/ -I.
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -tags linux,!lite Egress egress.c -- -I/usr/include/ -I.
@@ -1,3 +1,5 @@
+//go:build linux && !lite
+
 package ebpf
 
 
@@ -6,7 +8,14 @@ import (
     "encoding/binary"
 	"log"
     "errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/link"
 	"github.com/cilium/ebpf/perf"
 	"github.com/cilium/ebpf/rlimit"
@@ -17,24 +26,76 @@ type Loader struct {
 	objs   BpfObjects
 	linkFork link.Link
 	linkExit link.Link
+	linkExec link.Link
 	rd     *perf.Reader
+	bufferSize int
+	mu     sync.Mutex // guards rd and closed
+	closed bool
+	stats  counters
+	btfSource string   // where the CO-RE types came from
+	probes    []string // attached tracepoints
     EventHandler EventHandler
 }
 
-// InitBPF loads the BPF programs and maps
-func InitBPF() (*Loader, error) {
+// counters are updated by the listener and read by Stats
+type counters struct {
+	received     atomic.Uint64
+	lost         atomic.Uint64
+	decodeErrors atomic.Uint64
+	unmatched    atomic.Uint64
+	restarts     atomic.Uint64
+}
+
+// argvLen matches ARGV_LEN in cron_exit.c
+const argvLen = 512
+
+// splitArgv decodes the NUL-separated argv captured at exec
+func splitArgv(buf []byte, n uint32) []string {
+	if n == 0 || int(n) > len(buf) {
+		return nil
+	}
+	args := strings.Split(strings.TrimRight(string(buf[:n]), "\x00"), "\x00")
+	if len(args) == 1 && args[0] == "" {
+		return nil
+	}
+	return args
+}
+
+// Reader re-initialisation policy
+const (
+	maxConsecutiveErrors = 5
+	reopenBackoff        = 5 * time.Second
+)
+
+// InitBPF loads the BPF programs and maps. bufferPages sets the per-CPU perf
+// ring buffer size in memory pages (0 uses the default of 1 page). btfPath
+// points at a BTF file for kernels that don't ship their own (empty to detect).
+func InitBPF(bufferPages int, btfPath string) (*Loader, error) {
 	// Allow the current process to lock memory for eBPF resources.
 	if err := rlimit.RemoveMemlock(); err != nil {
 		return nil, err
 	}
 
+	// The objects are CO-RE: field offsets are resolved against the kernel's BTF
+	types, btfSource, err := kernelTypes(btfPath)
+	if err != nil {
+		return nil, err
+	}
+
 	// Load pre-compiled programs and maps into the kernel.
 	objs := BpfObjects{}
-	if err := LoadBpfObjects(&objs, nil); err != nil {
+	opts := &ebpf.CollectionOptions{Programs: ebpf.ProgramOptions{KernelTypes: types}}
+	if err := LoadBpfObjects(&objs, opts); err != nil {
+		if missing, _ := missingFeatures(); len(missing) > 0 {
+			return nil, fmt.Errorf("kernel %s lacks %s: %w", kernelRelease(), strings.Join(missing, ", "), err)
+		}
 		return nil, err
 	}
 
-	l := &Loader{objs: objs}
+	if bufferPages <= 0 {
+		bufferPages = 1
+	}
+	l := &Loader{objs: objs, bufferSize: bufferPages * os.Getpagesize(), btfSource: btfSource}
 
 	// Attach Tracepoint: sched_process_fork
 	tpFork, err := link.Tracepoint("sched", "sched_process_fork", objs.HandleFork, nil)
@@ -51,16 +112,27 @@ func InitBPF() (*Loader, error) {
 		return nil, err
 	}
 	l.linkExit = tpExit
+	l.probes = []string{"sched_process_fork", "sched_process_exit"}
+
+	// Attach Tracepoint: sched_process_exec (optional, adds the job's argv to exits)
+	probes := "fork/exit"
+	if tpExec, err := link.Tracepoint("sched", "sched_process_exec", objs.HandleExec, nil); err != nil {
+		log.Printf("⚠️  eBPF exec probe unavailable, cron exits are matched by PID only: %v", err)
+	} else {
+		l.linkExec = tpExec
+		l.probes = append(l.probes, "sched_process_exec")
+		probes = "fork/exec/exit"
+	}
 
 	// Open Perf Event Reader
-	rd, err := perf.NewReader(objs.Events, 4096)
+	rd, err := perf.NewReader(objs.Events, l.bufferSize)
 	if err != nil {
 		l.Close()
 		return nil, err
 	}
 	l.rd = rd
 
-	log.Println("✅ eBPF Probes Loaded (fork/exit)")
+	log.Printf("✅ eBPF Probes Loaded (%s, %d KB perf buffer per CPU, BTF: %s)", probes, l.bufferSize/1024, btfSource)
     
     // Start listening in background
     go l.listen()
@@ -68,30 +140,89 @@ func InitBPF() (*Loader, error) {
 	return l, nil
 }
 
+// Stats returns the listener counters and capability for agent self-metrics
+func (l *Loader) Stats() Stats {
+	level := LevelBasic
+	if l.linkExec != nil {
+		level = LevelFull
+	}
+	return Stats{
+		Enabled:      true,
+		Received:     l.stats.received.Load(),
+		Lost:         l.stats.lost.Load(),
+		DecodeErrors: l.stats.decodeErrors.Load(),
+		Unmatched:    l.stats.unmatched.Load(),
+		Restarts:     l.stats.restarts.Load(),
+		BufferBytes:  l.bufferSize,
+		Level:        level,
+		Kernel:       kernelRelease(),
+		BTF:          l.btfSource,
+		Probes:       l.probes,
+	}
+}
+
+// CountUnmatched records an exit event that did not match any tracked cron job
+func (l *Loader) CountUnmatched() {
+	l.stats.unmatched.Add(1)
+}
+
+// reader returns the current perf reader, or nil once the loader is closed
+func (l *Loader) reader() *perf.Reader {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil
+	}
+	return l.rd
+}
+
+// reopen replaces a failing perf reader, retrying until it succeeds or the loader is closed
+func (l *Loader) reopen() bool {
+	for {
+		l.mu.Lock()
+		if l.closed {
+			l.mu.Unlock()
+			return false
+		}
+		if l.rd != nil {
+			l.rd.Close()
+		}
+		rd, err := perf.NewReader(l.objs.Events, l.bufferSize)
+		if err == nil {
+			l.rd = rd
+			l.mu.Unlock()
+			l.stats.restarts.Add(1)
+			log.Println("✅ eBPF perf reader re-initialized")
+			return true
+		}
+		l.rd = nil
+		l.mu.Unlock()
+
+		log.Printf("⚠️  eBPF perf reader re-initialization failed: %v (retrying in %s)", err, reopenBackoff)
+		time.Sleep(reopenBackoff)
+	}
+}
+
 func (l *Loader) Close() {
+	l.mu.Lock()
+	l.closed = true
 	if l.rd != nil {
 		l.rd.Close()
 	}
+	l.mu.Unlock()
 	if l.linkFork != nil {
 		l.linkFork.Close()
 	}
 	if l.linkExit != nil {
 		l.linkExit.Close()
 	}
+	if l.linkExec != nil {
+		l.linkExec.Close()
+	}
 	l.objs.Close()
 }
 
 
-// ProcessExitEvent represents a process exit event captured by eBPF
-type ProcessExitEvent struct {
-    Pid      uint32
-    ParentPid uint32
-    NsPid    uint32
-    NsParentPid uint32
-    ExitCode int32
-    Comm     [16]byte
-}
-
 func (l *Loader) listen() {
     // Reusing the struct definition matching C code layout
 	var event struct {
@@ -101,25 +232,47 @@ func (l *Loader) listen() {
         NsParentPid uint32
 		ExitCode int32
 		Comm     [16]byte
+		Signal     int32
+		CoreDumped int32
+		OOMKilled  int32
+		ArgsLen       uint32
+		ArgsTruncated uint32
+		Args          [argvLen]byte
 	}
-	
+
+	consecutiveErrors := 0
 	for {
-		record, err := l.rd.Read()
+		rd := l.reader()
+		if rd == nil {
+			return
+		}
+		record, err := rd.Read()
 		if err != nil {
 			if errors.Is(err, perf.ErrClosed) {
 				return
 			}
 			log.Printf("reading from perf event reader: %s", err)
+			consecutiveErrors++
+			if consecutiveErrors >= maxConsecutiveErrors {
+				if !l.reopen() {
+					return
+				}
+				consecutiveErrors = 0
+			}
 			continue
 		}
+		consecutiveErrors = 0
 
 		if record.LostSamples != 0 {
+			l.stats.lost.Add(record.LostSamples)
 			log.Printf("perf event ring buffer full, dropped %d samples", record.LostSamples)
 			continue
 		}
+		l.stats.received.Add(1)
 
         // Deserialize event
         if err := binary.Read(bytes.NewReader(record.RawSample), binary.LittleEndian, &event); err != nil {
+            l.stats.decodeErrors.Add(1)
             log.Printf("Failed to decode BPF event: %v", err)
             continue
         }
@@ -133,14 +286,16 @@ func (l *Loader) listen() {
                 NsParentPid: event.NsParentPid,
                 ExitCode: event.ExitCode,
                 Comm:     event.Comm,
+                Signal:     event.Signal,
+                CoreDumped: event.CoreDumped != 0,
+                OOMKilled:  event.OOMKilled != 0,
+                Argv:          splitArgv(event.Args[:], event.ArgsLen),
+                ArgvTruncated: event.ArgsTruncated != 0,
             })
         }
 	}
 }
 
-// EventHandler callback type
-type EventHandler func(ProcessExitEvent)
-
 // SetEventHandler registers a callback for BPF events
 func (l *Loader) SetEventHandler(handler EventHandler) {
     l.EventHandler = handler
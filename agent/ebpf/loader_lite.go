@@ -0,0 +1,54 @@
+//go:build lite || !linux
+
+package ebpf
+
+import "errors"
+
+// ErrUnsupported is returned by InitBPF in lite builds, which ship without
+// the BPF objects to keep the binary small on ARMv6/low-memory devices, and
+// on other operating systems (FreeBSD, macOS).
+var ErrUnsupported = errors.New("eBPF is not available in this build (lite or non-Linux)")
+
+// Loader is a no-op placeholder in lite builds
+type Loader struct {
+	EventHandler EventHandler
+}
+
+// InitBPF always fails in lite builds; callers fall back to log parsing
+func InitBPF(bufferPages int, btfPath string) (*Loader, error) {
+	return nil, ErrUnsupported
+}
+
+func (l *Loader) Close() {}
+
+// Stats reports eBPF as disabled
+func (l *Loader) Stats() Stats {
+	return Unavailable("", ErrUnsupported)
+}
+
+// Unavailable reports eBPF as disabled
+func Unavailable(btfPath string, err error) Stats {
+	s := Stats{Level: LevelNone}
+	if err != nil {
+		s.Error = err.Error()
+	}
+	return s
+}
+
+// CountUnmatched is a no-op in lite builds
+func (l *Loader) CountUnmatched() {}
+
+// SetEventHandler registers a callback for BPF events
+func (l *Loader) SetEventHandler(handler EventHandler) {
+	l.EventHandler = handler
+}
+
+// EgressProbe is a no-op placeholder in lite builds
+type EgressProbe struct{}
+
+// StartEgress always fails in lite builds
+func StartEgress(bufferPages int, btfPath string, handler EgressHandler) (*EgressProbe, error) {
+	return nil, ErrUnsupported
+}
+
+func (p *EgressProbe) Close() {}
@@ -78,16 +78,46 @@ struct pid {
     struct upid numbers[8]; 
 } __attribute__((preserve_access_index));
 
+// Process arguments live in user memory between arg_start and arg_end
+struct mm_struct {
+    unsigned long arg_start;
+    unsigned long arg_end;
+} __attribute__((preserve_access_index));
+
+// signal->oom_mm is set once the OOM killer picks the task as its victim
+struct signal_struct {
+    struct mm_struct *oom_mm;
+} __attribute__((preserve_access_index));
+
+// Sockets: only the destination is read (egress monitoring)
+struct in6_addr {
+    union {
+        __u8 u6_addr8[16];
+    } in6_u;
+} __attribute__((preserve_access_index));
+
+struct sock_common {
+    __be32 skc_daddr;
+    struct in6_addr skc_v6_daddr;
+} __attribute__((preserve_access_index));
+
+struct sock {
+    struct sock_common __sk_common;
+} __attribute__((preserve_access_index));
+
 struct task_struct {
     /* ... bits we don't care about ... */
     
     // Core fields for PID and Parent
      pid_t pid;
+    char comm[16];
     struct task_struct *real_parent;
     struct pid *thread_pid;
     
     int exit_code;
     int exit_signal;
+    struct signal_struct *signal;
+    struct mm_struct *mm;
 } __attribute__((preserve_access_index));
 
 #endif
@@ -0,0 +1,312 @@
+// Package egress reports outbound TCP connections to destinations that are not
+// on the allowlist, e.g. a cron job suddenly talking to an unknown IP.
+package egress
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/nodeguarder/ebpf"
+)
+
+// maxTracked bounds the destinations remembered per agent run
+const maxTracked = 8192
+
+// Event is an unexpected outbound connection worth reporting
+type Event struct {
+	Type      string
+	Severity  string
+	Message   string
+	Timestamp int64
+	Details   string
+}
+
+// Rule is one allowlist entry: [process@]address[:port]. The address is an IP
+// or CIDR ("*" for any, IPv6 with a port in brackets), the process a comm or
+// executable name glob.
+type Rule struct {
+	Process string
+	Network *net.IPNet // nil matches any address
+	Port    int        // 0 matches any port
+}
+
+// ParseRule parses an allowlist entry such as "10.0.0.0/8", "*:443",
+// "apt@*:80", "[2001:db8::/32]:443" or "postgres@10.1.2.3:5432"
+func ParseRule(s string) (Rule, error) {
+	var r Rule
+	s = strings.TrimSpace(s)
+	if proc, rest, ok := strings.Cut(s, "@"); ok {
+		if proc == "" {
+			return r, fmt.Errorf("invalid egress rule %q: empty process name", s)
+		}
+		if _, err := filepath.Match(proc, ""); err != nil {
+			return r, fmt.Errorf("invalid egress rule %q: bad process glob", s)
+		}
+		r.Process, s = proc, rest
+	}
+
+	addr, port := s, ""
+	switch {
+	case strings.HasPrefix(s, "["):
+		end := strings.Index(s, "]")
+		if end < 0 {
+			return r, fmt.Errorf("invalid egress rule %q: missing ]", s)
+		}
+		addr = s[1:end]
+		if rest := s[end+1:]; rest != "" {
+			if !strings.HasPrefix(rest, ":") {
+				return r, fmt.Errorf("invalid egress rule %q", s)
+			}
+			port = rest[1:]
+		}
+	case strings.Count(s, ":") == 1:
+		addr, port, _ = strings.Cut(s, ":")
+	}
+
+	if port != "" && port != "*" {
+		p, err := strconv.Atoi(port)
+		if err != nil || p < 1 || p > 65535 {
+			return r, fmt.Errorf("invalid egress rule %q: bad port", s)
+		}
+		r.Port = p
+	}
+
+	switch {
+	case addr == "*" || addr == "":
+		if addr == "" && r.Port == 0 && r.Process == "" {
+			return r, fmt.Errorf("invalid egress rule %q: empty", s)
+		}
+	case strings.Contains(addr, "/"):
+		_, network, err := net.ParseCIDR(addr)
+		if err != nil {
+			return r, fmt.Errorf("invalid egress rule %q: %v", s, err)
+		}
+		r.Network = network
+	default:
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return r, fmt.Errorf("invalid egress rule %q: bad address", s)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		r.Network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	}
+	return r, nil
+}
+
+// ParseRules parses an allowlist, skipping blank entries
+func ParseRules(entries []string) ([]Rule, error) {
+	var rules []Rule
+	for _, e := range entries {
+		if strings.TrimSpace(e) == "" {
+			continue
+		}
+		r, err := ParseRule(e)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// Match reports whether the rule allows a connection. process lists the names
+// the process is known by (comm, executable path).
+func (r Rule) Match(process []string, ip net.IP, port int) bool {
+	if r.Port != 0 && r.Port != port {
+		return false
+	}
+	if r.Network != nil && !r.Network.Contains(ip) {
+		return false
+	}
+	if r.Process == "" {
+		return true
+	}
+	for _, name := range process {
+		if name == "" {
+			continue
+		}
+		if ok, _ := filepath.Match(r.Process, name); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(r.Process, filepath.Base(name)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// connection is a destination first seen for a process name
+type connection struct {
+	Comm     string
+	Exe      string
+	PID      uint32
+	UID      uint32
+	IP       net.IP
+	Port     uint16
+	FromCron bool
+	Seen     time.Time
+	reported bool
+}
+
+// Monitor collects outbound connections from the eBPF probe and reports the
+// ones the allowlist does not cover. Each process/destination pair is reported
+// once per agent run; allowlist changes apply to pairs not yet reported.
+type Monitor struct {
+	mu          sync.Mutex
+	probe       *ebpf.EgressProbe
+	startFailed bool
+	rules       []Rule
+	seen        map[string]*connection
+	dropped     int
+	self        uint32
+}
+
+// New creates a disabled monitor; Configure turns it on
+func New() *Monitor {
+	return &Monitor{seen: make(map[string]*connection), self: uint32(os.Getpid())}
+}
+
+// Configure applies the dashboard settings, loading or unloading the probe when
+// monitoring is toggled. A probe that failed to load is retried only after
+// monitoring was turned off and on again.
+func (m *Monitor) Configure(enabled bool, allow []string, bufferPages int, btfPath string) error {
+	rules, err := ParseRules(allow)
+
+	m.mu.Lock()
+	if err == nil {
+		m.rules = rules
+	}
+	probe := m.probe
+	if !enabled {
+		m.probe, m.startFailed = nil, false
+		m.seen = make(map[string]*connection)
+	}
+	start := enabled && probe == nil && !m.startFailed
+	m.mu.Unlock()
+
+	if !enabled && probe != nil {
+		probe.Close()
+		log.Println("Egress monitoring disabled")
+	}
+	if start {
+		p, startErr := ebpf.StartEgress(bufferPages, btfPath, m.Observe)
+		m.mu.Lock()
+		if startErr != nil {
+			m.startFailed = true
+		} else {
+			m.probe = p
+		}
+		m.mu.Unlock()
+		if startErr != nil {
+			log.Printf("⚠️  Egress monitoring unavailable: %v", startErr)
+		} else {
+			log.Println("✅ Egress monitoring enabled (eBPF outbound connection probe)")
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("egress allowlist not applied: %v", err)
+	}
+	return nil
+}
+
+// Observe records a connection from the probe
+func (m *Monitor) Observe(e ebpf.EgressEvent) {
+	if e.Pid == m.self || e.DestIP == nil || e.DestIP.IsLoopback() || e.DestIP.IsUnspecified() {
+		return
+	}
+	key := fmt.Sprintf("%s|%s|%d", e.Comm, e.DestIP, e.DestPort)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.seen[key]; ok {
+		return
+	}
+	if len(m.seen) >= maxTracked {
+		m.dropped++
+		return
+	}
+	// Resolve the executable while the process is (most likely) still running
+	exe, _ := os.Readlink(fmt.Sprintf("/proc/%d/exe", e.Pid))
+	m.seen[key] = &connection{
+		Comm: e.Comm, Exe: exe, PID: e.Pid, UID: e.UID,
+		IP: e.DestIP, Port: e.DestPort, FromCron: e.FromCron, Seen: time.Now(),
+	}
+}
+
+// Check returns events for connections not covered by the allowlist
+func (m *Monitor) Check() []Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var pending []*connection
+	for _, c := range m.seen {
+		if c.reported || m.allowed(c) {
+			continue
+		}
+		c.reported = true
+		pending = append(pending, c)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Seen.Before(pending[j].Seen) })
+
+	var events []Event
+	for _, c := range pending {
+		dest := net.JoinHostPort(c.IP.String(), strconv.Itoa(int(c.Port)))
+		process := c.Comm
+		if c.Exe != "" {
+			process = fmt.Sprintf("%s (%s)", c.Comm, c.Exe)
+		}
+		msg := fmt.Sprintf("Unexpected outbound connection: %s pid %d uid %d -> %s", process, c.PID, c.UID, dest)
+		if c.FromCron {
+			msg += " from a cron job"
+		}
+		details, _ := json.Marshal(map[string]interface{}{
+			"process": c.Comm, "exe": c.Exe, "pid": c.PID, "uid": c.UID,
+			"ip": c.IP.String(), "port": c.Port, "from_cron": c.FromCron,
+		})
+		events = append(events, Event{
+			Type:      "egress",
+			Severity:  "warning",
+			Message:   msg,
+			Timestamp: c.Seen.Unix(),
+			Details:   string(details),
+		})
+	}
+	if m.dropped > 0 {
+		log.Printf("⚠️  Egress monitor tracks at most %d destinations, %d new ones were ignored", maxTracked, m.dropped)
+		m.dropped = 0
+	}
+	return events
+}
+
+func (m *Monitor) allowed(c *connection) bool {
+	names := []string{c.Comm, c.Exe}
+	for _, r := range m.rules {
+		if r.Match(names, c.IP, int(c.Port)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Close unloads the probe
+func (m *Monitor) Close() {
+	m.mu.Lock()
+	probe := m.probe
+	m.probe = nil
+	m.mu.Unlock()
+	if probe != nil {
+		probe.Close()
+	}
+}
@@ -0,0 +1,81 @@
+package egress
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/nodeguarder/ebpf"
+)
+
+func TestParseRule(t *testing.T) {
+	valid := []string{"10.0.0.0/8", "*:443", ":53", "apt@*:80", "[2001:db8::/32]:443", "2001:db8::1", "postgres@10.1.2.3:5432", "backup-*@*"}
+	for _, s := range valid {
+		if _, err := ParseRule(s); err != nil {
+			t.Errorf("ParseRule(%q): %v", s, err)
+		}
+	}
+	invalid := []string{"", "@1.2.3.4", "1.2.3.4:99999", "10.0.0.0/33", "not-an-ip", "[::1", "[::1]443", "[x@1.2.3.4"}
+	for _, s := range invalid {
+		if _, err := ParseRule(s); err == nil {
+			t.Errorf("ParseRule(%q) should fail", s)
+		}
+	}
+}
+
+func TestRuleMatch(t *testing.T) {
+	tests := []struct {
+		rule    string
+		process []string
+		ip      string
+		port    int
+		want    bool
+	}{
+		{"10.0.0.0/8", []string{"curl"}, "10.2.3.4", 80, true},
+		{"10.0.0.0/8", []string{"curl"}, "11.2.3.4", 80, false},
+		{"*:443", []string{"curl"}, "203.0.113.9", 443, true},
+		{"*:443", []string{"curl"}, "203.0.113.9", 4444, false},
+		{"apt@*:80", []string{"http", "/usr/lib/apt/methods/http"}, "198.51.100.1", 80, false},
+		{"http@*:80", []string{"http", "/usr/lib/apt/methods/http"}, "198.51.100.1", 80, true},
+		{"/usr/lib/apt/methods/*@*", []string{"http", "/usr/lib/apt/methods/http"}, "198.51.100.1", 80, true},
+		{"[2001:db8::/32]:443", []string{"curl"}, "2001:db8::5", 443, true},
+		{"1.2.3.4", []string{"curl"}, "::ffff:1.2.3.4", 22, true},
+	}
+	for _, tt := range tests {
+		r, err := ParseRule(tt.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := r.Match(tt.process, net.ParseIP(tt.ip), tt.port); got != tt.want {
+			t.Errorf("%q.Match(%v, %s:%d) = %v, want %v", tt.rule, tt.process, tt.ip, tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestMonitorCheck(t *testing.T) {
+	m := New()
+	m.rules, _ = ParseRules([]string{"*:443"})
+
+	m.Observe(ebpf.EgressEvent{Pid: 100, Comm: "curl", DestIP: net.ParseIP("203.0.113.9"), DestPort: 4444, FromCron: true})
+	m.Observe(ebpf.EgressEvent{Pid: 100, Comm: "curl", DestIP: net.ParseIP("203.0.113.9"), DestPort: 4444})
+	m.Observe(ebpf.EgressEvent{Pid: 101, Comm: "curl", DestIP: net.ParseIP("203.0.113.9"), DestPort: 443})
+	m.Observe(ebpf.EgressEvent{Pid: 102, Comm: "curl", DestIP: net.ParseIP("127.0.0.1"), DestPort: 8080})
+	m.Observe(ebpf.EgressEvent{Pid: m.self, Comm: "nodeguarder", DestIP: net.ParseIP("203.0.113.1"), DestPort: 8080})
+
+	events := m.Check()
+	if len(events) != 1 {
+		t.Fatalf("expected one unexpected connection, got %d: %+v", len(events), events)
+	}
+	if !strings.Contains(events[0].Message, "203.0.113.9:4444 from a cron job") || events[0].Type != "egress" {
+		t.Errorf("unexpected event %+v", events[0])
+	}
+	if len(m.Check()) != 0 {
+		t.Error("a destination should only be reported once")
+	}
+
+	// Narrowing the allowlist reports pairs that were allowed before
+	m.rules = nil
+	if events := m.Check(); len(events) != 1 || !strings.Contains(events[0].Message, ":443") {
+		t.Errorf("expected the :443 connection after the allowlist changed, got %+v", events)
+	}
+}
@@ -0,0 +1,109 @@
+//go:build linux
+
+package fileaudit
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Tracker listens to fanotify close-after-write events on the mounts holding
+// the drift paths. It needs CAP_SYS_ADMIN (the agent runs as root).
+type Tracker struct {
+	writers
+	fd     int
+	marked map[string]bool
+}
+
+// Start initialises fanotify and marks the mounts of the given paths
+func Start(paths []string) (*Tracker, error) {
+	fd, err := unix.FanotifyInit(unix.FAN_CLASS_NOTIF|unix.FAN_CLOEXEC, unix.O_RDONLY|unix.O_LARGEFILE|unix.O_CLOEXEC)
+	if err != nil {
+		return nil, fmt.Errorf("fanotify unavailable: %w", err)
+	}
+	t := &Tracker{fd: fd, marked: make(map[string]bool)}
+	t.last = make(map[string]Writer)
+	t.SetPaths(paths)
+	go t.listen()
+	return t, nil
+}
+
+// SetPaths updates the monitored roots, marking mounts not yet watched
+func (t *Tracker) SetPaths(paths []string) {
+	t.setRoots(paths)
+	for _, p := range paths {
+		if t.marked[p] {
+			continue
+		}
+		if err := unix.FanotifyMark(t.fd, unix.FAN_MARK_ADD|unix.FAN_MARK_MOUNT, unix.FAN_CLOSE_WRITE, unix.AT_FDCWD, p); err != nil {
+			log.Printf("⚠️  File write attribution unavailable for %s: %v", p, err)
+			continue
+		}
+		t.marked[p] = true
+	}
+}
+
+// Close stops listening
+func (t *Tracker) Close() {
+	unix.Close(t.fd)
+}
+
+func (t *Tracker) listen() {
+	buf := make([]byte, 64*1024)
+	metaLen := int(unsafe.Sizeof(unix.FanotifyEventMetadata{}))
+	for {
+		n, err := unix.Read(t.fd, buf)
+		if err != nil {
+			if err == unix.EINTR || err == unix.EAGAIN {
+				continue
+			}
+			return // fd closed
+		}
+
+		for off := 0; off+metaLen <= n; {
+			meta := (*unix.FanotifyEventMetadata)(unsafe.Pointer(&buf[off]))
+			if meta.Event_len < uint32(metaLen) {
+				break
+			}
+			if meta.Fd >= 0 {
+				path, err := os.Readlink(fmt.Sprintf("/proc/self/fd/%d", meta.Fd))
+				unix.Close(int(meta.Fd))
+				if err == nil {
+					t.record(path, inspect(meta.Pid))
+				}
+			}
+			off += int(meta.Event_len)
+		}
+	}
+}
+
+// inspect reads the name and real UID of a process from /proc
+func inspect(pid int32) Writer {
+	w := Writer{PID: pid, UID: -1, Comm: "unknown", Time: time.Now()}
+	if comm, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid)); err == nil {
+		w.Comm = strings.TrimSpace(string(comm))
+	}
+	f, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return w
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) >= 2 && fields[0] == "Uid:" {
+			if uid, err := strconv.Atoi(fields[1]); err == nil {
+				w.UID = uid
+			}
+			break
+		}
+	}
+	return w
+}
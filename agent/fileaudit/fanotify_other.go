@@ -0,0 +1,21 @@
+//go:build !linux
+
+package fileaudit
+
+import "errors"
+
+// Tracker is unavailable outside Linux
+type Tracker struct {
+	writers
+}
+
+// Start always fails on platforms without fanotify
+func Start(paths []string) (*Tracker, error) {
+	return nil, errors.New("file write attribution requires Linux fanotify")
+}
+
+// SetPaths is a no-op outside Linux
+func (t *Tracker) SetPaths(paths []string) {}
+
+// Close is a no-op outside Linux
+func (t *Tracker) Close() {}
@@ -0,0 +1,101 @@
+// Package fileaudit records which process last wrote each file under the
+// drift paths, so drift events can name the modifier ("vim, uid 1000, pid 4242").
+package fileaudit
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxEntries bounds the number of remembered writers
+const maxEntries = 4096
+
+// writerTTL is how long a writer is kept; drift scans run every few minutes
+const writerTTL = time.Hour
+
+// Writer is the process that last closed a file after writing it
+type Writer struct {
+	PID  int32
+	UID  int // -1 when the process exited before it could be inspected
+	Comm string
+	Time time.Time
+}
+
+// String formats the writer for drift messages, e.g. "vim (uid 1000, pid 4242)"
+func (w Writer) String() string {
+	if w.UID < 0 {
+		return fmt.Sprintf("%s (pid %d)", w.Comm, w.PID)
+	}
+	return fmt.Sprintf("%s (uid %d, pid %d)", w.Comm, w.UID, w.PID)
+}
+
+// writers is the platform-independent bookkeeping shared by the trackers
+type writers struct {
+	mu    sync.Mutex
+	roots []string
+	last  map[string]Writer
+}
+
+func (ws *writers) setRoots(roots []string) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.roots = nil
+	for _, r := range roots {
+		ws.roots = append(ws.roots, filepath.Clean(r))
+	}
+}
+
+// record stores a writer if the path is under a monitored root
+func (ws *writers) record(path string, w Writer) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	watched := false
+	for _, root := range ws.roots {
+		if path == root || strings.HasPrefix(path, root+"/") {
+			watched = true
+			break
+		}
+	}
+	if !watched {
+		return
+	}
+
+	if len(ws.last) >= maxEntries {
+		ws.prune()
+	}
+	ws.last[path] = w
+}
+
+// prune drops expired entries, or the oldest ones if all are recent
+func (ws *writers) prune() {
+	cutoff := time.Now().Add(-writerTTL)
+	for path, w := range ws.last {
+		if w.Time.Before(cutoff) {
+			delete(ws.last, path)
+		}
+	}
+	for len(ws.last) >= maxEntries {
+		var oldest string
+		for path, w := range ws.last {
+			if oldest == "" || w.Time.Before(ws.last[oldest].Time) {
+				oldest = path
+			}
+		}
+		delete(ws.last, oldest)
+	}
+}
+
+// Writer returns the last recorded writer of a path
+func (ws *writers) Writer(path string) (Writer, bool) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	w, ok := ws.last[path]
+	if !ok || time.Since(w.Time) > writerTTL {
+		return Writer{}, false
+	}
+	return w, true
+}
@@ -0,0 +1,133 @@
+package hostenv
+
+import (
+	"os"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/host"
+)
+
+// Roles
+const (
+	RoleHost      = "host"
+	RoleGuest     = "guest"
+	RoleContainer = "container"
+)
+
+// Environment describes where the agent is running
+type Environment struct {
+	Type      string `json:"type"` // bare-metal, kvm, vmware, hyperv, xen, virtualbox, wsl, lxc, docker, podman, kubernetes, container
+	Role      string `json:"role"` // host, guest or container
+	Container bool   `json:"container"`
+	HostPID   bool   `json:"host_pid,omitempty"` // container sharing the host's PID namespace (hostPID)
+}
+
+// String returns a short label like "kvm (guest)"
+func (e Environment) String() string {
+	return e.Type + " (" + e.Role + ")"
+}
+
+// IsBareMetal reports whether hardware-level checks (SMART, sensors) are meaningful
+func (e Environment) IsBareMetal() bool {
+	return e.Role == RoleHost
+}
+
+// Detect inspects the kernel, DMI data and process 1 to classify the environment.
+// Containers are checked first because they also inherit the host's DMI data.
+func Detect() Environment {
+	if t := containerType(); t != "" {
+		return Environment{Type: t, Role: RoleContainer, Container: true, HostPID: sharesHostPIDNamespace()}
+	}
+	if isWSL() {
+		return Environment{Type: "wsl", Role: RoleGuest}
+	}
+	if t := vmType(); t != "" {
+		return Environment{Type: t, Role: RoleGuest}
+	}
+	return Environment{Type: "bare-metal", Role: RoleHost}
+}
+
+// containerType returns the container runtime, or "" when not in a container
+func containerType() string {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return "kubernetes"
+	}
+	if fileExists("/.dockerenv") {
+		return "docker"
+	}
+	if fileExists("/run/.containerenv") {
+		return "podman"
+	}
+
+	// systemd and LXC set container= in the environment of PID 1
+	if data, err := os.ReadFile("/proc/1/environ"); err == nil {
+		for _, kv := range strings.Split(string(data), "\x00") {
+			if v, ok := strings.CutPrefix(kv, "container="); ok && v != "" {
+				return strings.ToLower(v)
+			}
+		}
+	}
+
+	if data, err := os.ReadFile("/proc/1/cgroup"); err == nil {
+		cgroup := string(data)
+		switch {
+		case strings.Contains(cgroup, "kubepods"):
+			return "kubernetes"
+		case strings.Contains(cgroup, "docker"):
+			return "docker"
+		case strings.Contains(cgroup, "/lxc"):
+			return "lxc"
+		case strings.Contains(cgroup, "libpod"):
+			return "podman"
+		}
+	}
+	return ""
+}
+
+// isWSL detects the Microsoft kernel used by WSL 1 and 2
+func isWSL() bool {
+	data, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	if err != nil {
+		return false
+	}
+	release := strings.ToLower(string(data))
+	return strings.Contains(release, "microsoft") || strings.Contains(release, "wsl")
+}
+
+// vmType identifies the hypervisor from DMI data, falling back to gopsutil
+func vmType() string {
+	vendor := readDMI("sys_vendor")
+	product := readDMI("product_name")
+	switch {
+	case strings.Contains(vendor, "qemu"), strings.Contains(product, "kvm"), strings.Contains(product, "qemu"):
+		return "kvm"
+	case strings.Contains(vendor, "vmware"):
+		return "vmware"
+	case strings.Contains(vendor, "microsoft") && strings.Contains(product, "virtual"):
+		return "hyperv"
+	case strings.Contains(vendor, "xen"):
+		return "xen"
+	case strings.Contains(product, "virtualbox"):
+		return "virtualbox"
+	case strings.Contains(vendor, "amazon ec2"), strings.Contains(vendor, "google"):
+		return "kvm"
+	}
+
+	if system, role, err := host.Virtualization(); err == nil && role == RoleGuest && system != "" {
+		return system
+	}
+	return ""
+}
+
+func readDMI(name string) string {
+	data, err := os.ReadFile("/sys/class/dmi/id/" + name)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(string(data)))
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
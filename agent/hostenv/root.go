@@ -0,0 +1,59 @@
+package hostenv
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// root is where the host's filesystem is mounted when the agent runs in a
+// container (e.g. "/host" in the DaemonSet); "" when it runs on the host
+var root string
+
+// initPIDNamespace is the inode of the kernel's initial PID namespace
+const initPIDNamespace = "pid:[4026531836]"
+
+// SetRoot makes host files resolve under dir. gopsutil reads the host's
+// /proc, /sys, /etc and /var through its HOST_* variables, which are set
+// unless the environment already provides them.
+func SetRoot(dir string) {
+	if dir == "/" {
+		dir = ""
+	}
+	root = dir
+	if dir == "" {
+		return
+	}
+	for env, path := range map[string]string{
+		"HOST_ROOT": "/",
+		"HOST_PROC": "/proc",
+		"HOST_SYS":  "/sys",
+		"HOST_ETC":  "/etc",
+		"HOST_VAR":  "/var",
+		"HOST_RUN":  "/run",
+		"HOST_DEV":  "/dev",
+	} {
+		if os.Getenv(env) == "" {
+			os.Setenv(env, filepath.Join(dir, path))
+		}
+	}
+}
+
+// Root returns the host filesystem mount ("" on the host)
+func Root() string {
+	return root
+}
+
+// Path returns where a host path such as /etc/passwd is visible to the agent
+func Path(path string) string {
+	if root == "" {
+		return path
+	}
+	return filepath.Join(root, path)
+}
+
+// sharesHostPIDNamespace reports whether the agent sees the host's PIDs
+// (on the host, or a container started with hostPID)
+func sharesHostPIDNamespace() bool {
+	link, err := os.Readlink("/proc/self/ns/pid")
+	return err == nil && link == initPIDNamespace
+}
@@ -0,0 +1,48 @@
+package hostenv
+
+import (
+	"os"
+	"strings"
+)
+
+// DetectTimezone returns the host's IANA time zone name (e.g. "Europe/Berlin"),
+// so the dashboard can evaluate schedules in server local time. Checks TZ, the
+// /etc/localtime symlink and /etc/timezone; "" if it cannot be determined.
+func DetectTimezone() string {
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" {
+		if name := zoneName(tz); name != "" {
+			return name
+		}
+		if !strings.HasPrefix(tz, "/") {
+			return tz
+		}
+	}
+
+	if target, err := os.Readlink(Path("/etc/localtime")); err == nil {
+		if name := zoneName(target); name != "" {
+			return name
+		}
+	} else if os.IsNotExist(err) {
+		return "UTC" // glibc default without /etc/localtime
+	}
+
+	if data, err := os.ReadFile(Path("/etc/timezone")); err == nil {
+		if tz := strings.TrimSpace(string(data)); tz != "" {
+			return tz
+		}
+	}
+	return ""
+}
+
+// zoneName extracts the zone from a zoneinfo path like
+// "../usr/share/zoneinfo/posix/Europe/Berlin"
+func zoneName(path string) string {
+	i := strings.LastIndex(path, "zoneinfo/")
+	if i < 0 {
+		return ""
+	}
+	name := path[i+len("zoneinfo/"):]
+	name = strings.TrimPrefix(name, "posix/")
+	name = strings.TrimPrefix(name, "right/")
+	return name
+}
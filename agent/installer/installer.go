@@ -0,0 +1,178 @@
+// Package installer installs the agent as a service from the binary itself
+// (nodeguarder-agent --install): a systemd unit on Linux, the same layout the
+// dashboard's install script creates, a launchd daemon on macOS and an rc.d
+// script on FreeBSD. It removes it again (--uninstall).
+package installer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/yourusername/nodeguarder/config"
+)
+
+// Layout of an installed agent, shared with the dashboard's install script.
+// UnitPath (the service definition) depends on the OS.
+const (
+	ServiceName = "nodeguarder-agent"
+	InstallDir  = "/opt/nodeguarder-agent"
+	BinaryName  = "nodeguarder-agent"
+	DataDir     = "/var/lib/nodeguarder-agent"
+)
+
+// Options configures an installation
+type Options struct {
+	DashboardURL      string
+	ConfigPath        string
+	RegistrationToken string
+	PackageID         string // install package (script, ansible role...) that onboarded the host
+	Insecure          bool   // disable_ssl_verify in the generated config
+
+	// Overridden in tests
+	InstallDir string
+	UnitPath   string
+	DataDir    string
+}
+
+// runCommand runs a service manager command (replaced in tests)
+var runCommand = func(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %v: %s", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (o *Options) defaults() {
+	if o.ConfigPath == "" {
+		o.ConfigPath = config.DefaultConfigPath
+	}
+	if o.InstallDir == "" {
+		o.InstallDir = InstallDir
+	}
+	if o.UnitPath == "" {
+		o.UnitPath = UnitPath
+	}
+	if o.DataDir == "" {
+		o.DataDir = DataDir
+	}
+}
+
+// Install copies the running binary to the install directory, writes a config
+// (an existing one is kept, so reinstalling keeps the server's identity) and
+// the service definition, then enables and starts the service. Returns the config.
+func Install(opts Options) (*config.Config, error) {
+	opts.defaults()
+	if opts.UnitPath == "" {
+		return nil, fmt.Errorf("service installation is not supported on %s", runtime.GOOS)
+	}
+
+	cfg, err := config.Load(opts.ConfigPath)
+	if err != nil {
+		if _, statErr := os.Stat(opts.ConfigPath); statErr == nil {
+			return nil, fmt.Errorf("existing config %s is invalid: %w", opts.ConfigPath, err)
+		}
+		if opts.DashboardURL == "" {
+			return nil, fmt.Errorf("--dashboard-url is required for installation")
+		}
+		cfg = config.GenerateDefault(opts.DashboardURL)
+		cfg.RegistrationToken = opts.RegistrationToken
+		cfg.PackageID = opts.PackageID
+		cfg.DisableSSLVerify = opts.Insecure
+		if err := cfg.Save(opts.ConfigPath); err != nil {
+			return nil, fmt.Errorf("failed to save config: %w", err)
+		}
+	}
+
+	binaryPath := filepath.Join(opts.InstallDir, BinaryName)
+	if err := installBinary(binaryPath); err != nil {
+		return nil, fmt.Errorf("failed to install binary: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(opts.UnitPath), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(opts.UnitPath, []byte(serviceFile(binaryPath, opts.ConfigPath)), serviceFileMode); err != nil {
+		return nil, fmt.Errorf("failed to write service definition: %w", err)
+	}
+	return cfg, startService(opts.UnitPath)
+}
+
+// installBinary copies the running executable to path, unless it already runs from there
+func installBinary(path string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if resolved, err := filepath.EvalSymlinks(self); err == nil {
+		self = resolved
+	}
+	if target, err := filepath.Abs(path); err == nil && target == self {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	src, err := os.Open(self)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// Write next to the target and rename, so a running agent is never
+	// left with a half-written binary
+	tmp, err := os.CreateTemp(filepath.Dir(path), BinaryName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Uninstall stops and disables the service and removes its definition and the
+// install directory. With purge the config directory and agent data go too.
+func Uninstall(opts Options, purge bool) error {
+	opts.defaults()
+
+	if opts.UnitPath == "" {
+		return fmt.Errorf("service installation is not supported on %s", runtime.GOOS)
+	}
+
+	// Not installed or already stopped: keep removing the files
+	stopService(opts.UnitPath)
+
+	if err := os.Remove(opts.UnitPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove service definition: %w", err)
+	}
+	if err := serviceRemoved(); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(opts.InstallDir); err != nil {
+		return err
+	}
+	if purge {
+		if err := os.RemoveAll(filepath.Dir(opts.ConfigPath)); err != nil {
+			return err
+		}
+		if err := os.RemoveAll(opts.DataDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+//go:build linux
+
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallAndUninstall(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{
+		DashboardURL:      "https://dashboard.example.com",
+		ConfigPath:        filepath.Join(dir, "etc", "config.yaml"),
+		RegistrationToken: "tok",
+		InstallDir:        filepath.Join(dir, "opt"),
+		UnitPath:          filepath.Join(dir, "systemd", "nodeguarder-agent.service"),
+		DataDir:           filepath.Join(dir, "lib"),
+	}
+	var calls []string
+	defer func(old func(string, ...string) error) { runCommand = old }(runCommand)
+	runCommand = func(name string, args ...string) error {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		return nil
+	}
+
+	cfg, err := Install(opts)
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if cfg.ServerID == "" || cfg.RegistrationToken != "tok" {
+		t.Fatalf("generated config = %+v", cfg)
+	}
+	if _, err := os.Stat(filepath.Join(opts.InstallDir, BinaryName)); err != nil {
+		t.Fatalf("binary not installed: %v", err)
+	}
+	unit, err := os.ReadFile(opts.UnitPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ExecStart=" + filepath.Join(opts.InstallDir, BinaryName) + " --config " + opts.ConfigPath; !strings.Contains(string(unit), want) {
+		t.Fatalf("unit lacks %q:\n%s", want, unit)
+	}
+	if got := strings.Join(calls, ", "); got != "systemctl daemon-reload, systemctl enable nodeguarder-agent, systemctl restart nodeguarder-agent" {
+		t.Fatalf("service manager calls = %s", got)
+	}
+
+	// Reinstalling keeps the server's identity
+	again, err := Install(Options{ConfigPath: opts.ConfigPath, InstallDir: opts.InstallDir, UnitPath: opts.UnitPath})
+	if err != nil {
+		t.Fatalf("reinstall: %v", err)
+	}
+	if again.ServerID != cfg.ServerID {
+		t.Fatalf("reinstall generated a new server ID")
+	}
+
+	if err := Uninstall(opts, false); err != nil {
+		t.Fatalf("Uninstall: %v", err)
+	}
+	for _, path := range []string{opts.UnitPath, opts.InstallDir} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s still exists after uninstall", path)
+		}
+	}
+	if _, err := os.Stat(opts.ConfigPath); err != nil {
+		t.Errorf("config removed without purge: %v", err)
+	}
+
+	if err := Uninstall(opts, true); err != nil {
+		t.Fatalf("Uninstall --purge: %v", err)
+	}
+	if _, err := os.Stat(opts.ConfigPath); !os.IsNotExist(err) {
+		t.Errorf("config kept after purge")
+	}
+}
@@ -0,0 +1,59 @@
+//go:build darwin
+
+package installer
+
+import (
+	"fmt"
+	"os"
+)
+
+// UnitPath is the launchd daemon of the installed agent
+const UnitPath = "/Library/LaunchDaemons/com.nodeguarder.agent.plist"
+
+// launchdLabel identifies the daemon in the system domain
+const launchdLabel = "com.nodeguarder.agent"
+
+const serviceFileMode os.FileMode = 0644
+
+// serviceFile returns the launchd property list keeping the agent running.
+// The agent writes its own log; launchd only keeps stderr (crashes).
+func serviceFile(binaryPath, configPath string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>%s</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>%s</string>
+		<string>--config</string>
+		<string>%s</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+	<key>ThrottleInterval</key>
+	<integer>10</integer>
+	<key>StandardErrorPath</key>
+	<string>/var/log/nodeguarder/agent.err</string>
+</dict>
+</plist>
+`, launchdLabel, binaryPath, configPath)
+}
+
+// startService (re)loads the daemon, which starts it (RunAtLoad)
+func startService(unitPath string) error {
+	runCommand("launchctl", "bootout", "system/"+launchdLabel) // not loaded yet on first install
+	return runCommand("launchctl", "bootstrap", "system", unitPath)
+}
+
+// stopService unloads the daemon, stopping the agent
+func stopService(unitPath string) error {
+	return runCommand("launchctl", "bootout", "system/"+launchdLabel)
+}
+
+func serviceRemoved() error {
+	return nil
+}
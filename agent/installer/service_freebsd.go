@@ -0,0 +1,58 @@
+//go:build freebsd
+
+package installer
+
+import (
+	"fmt"
+	"os"
+)
+
+// UnitPath is the rc.d script of the installed agent
+const UnitPath = "/usr/local/etc/rc.d/nodeguarder_agent"
+
+// rcName is the rc.d service name (rc.conf: nodeguarder_agent_enable)
+const rcName = "nodeguarder_agent"
+
+const serviceFileMode os.FileMode = 0755
+
+// serviceFile returns the rc.d script. daemon(8) supervises the agent and
+// restarts it when it exits (self-updates, rollbacks).
+func serviceFile(binaryPath, configPath string) string {
+	return fmt.Sprintf(`#!/bin/sh
+#
+# PROVIDE: %[1]s
+# REQUIRE: LOGIN NETWORKING
+# KEYWORD: shutdown
+
+. /etc/rc.subr
+
+name="%[1]s"
+rcvar="%[1]s_enable"
+pidfile="/var/run/${name}.pid"
+command="/usr/sbin/daemon"
+command_args="-r -R 10 -P ${pidfile} -S -T %[2]s %[3]s --config %[4]s"
+
+load_rc_config $name
+: ${%[1]s_enable:="NO"}
+
+run_rc_command "$1"
+`, rcName, ServiceName, binaryPath, configPath)
+}
+
+// startService enables the service in rc.conf and (re)starts the agent
+func startService(unitPath string) error {
+	if err := runCommand("sysrc", rcName+"_enable=YES"); err != nil {
+		return err
+	}
+	return runCommand("service", rcName, "restart")
+}
+
+// stopService stops the agent and removes it from rc.conf
+func stopService(unitPath string) error {
+	runCommand("service", rcName, "onestop")
+	return runCommand("sysrc", "-x", rcName+"_enable")
+}
+
+func serviceRemoved() error {
+	return nil
+}
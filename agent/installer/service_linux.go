@@ -0,0 +1,60 @@
+//go:build linux
+
+package installer
+
+import (
+	"fmt"
+	"os"
+)
+
+// UnitPath is the systemd unit of the installed agent
+const UnitPath = "/etc/systemd/system/nodeguarder-agent.service"
+
+const serviceFileMode os.FileMode = 0644
+
+// UnitFile returns the systemd unit running the agent binary with a config file
+func UnitFile(binaryPath, configPath string) string {
+	return fmt.Sprintf(`[Unit]
+Description=NodeGuarder Agent Monitoring Service
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+User=root
+ExecStart=%s --config %s
+ExecReload=/bin/kill -HUP $MAINPID
+Restart=always
+RestartSec=10
+StandardOutput=journal
+StandardError=journal
+SyslogIdentifier=%s
+
+[Install]
+WantedBy=multi-user.target
+`, binaryPath, configPath, ServiceName)
+}
+
+func serviceFile(binaryPath, configPath string) string {
+	return UnitFile(binaryPath, configPath)
+}
+
+// startService enables the unit and (re)starts the agent
+func startService(unitPath string) error {
+	for _, args := range [][]string{{"daemon-reload"}, {"enable", ServiceName}, {"restart", ServiceName}} {
+		if err := runCommand("systemctl", args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stopService stops and disables the agent
+func stopService(unitPath string) error {
+	return runCommand("systemctl", "disable", "--now", ServiceName)
+}
+
+// serviceRemoved tells systemd the unit is gone
+func serviceRemoved() error {
+	return runCommand("systemctl", "daemon-reload")
+}
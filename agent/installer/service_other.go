@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !freebsd
+
+package installer
+
+import "os"
+
+// UnitPath is empty: there is no service support on this OS
+const UnitPath = ""
+
+const serviceFileMode os.FileMode = 0644
+
+func serviceFile(binaryPath, configPath string) string { return "" }
+
+func startService(unitPath string) error { return nil }
+
+func stopService(unitPath string) error { return nil }
+
+func serviceRemoved() error { return nil }
@@ -0,0 +1,291 @@
+package integrity
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yourusername/nodeguarder/hostenv"
+)
+
+// Watched locations. These are checked independently of the drift paths and
+// ignore lists, and every change is reported as critical.
+const (
+	passwdPath  = "/etc/passwd"
+	sudoersPath = "/etc/sudoers"
+	sudoersDir  = "/etc/sudoers.d"
+)
+
+// Event is an integrity violation worth reporting
+type Event struct {
+	Type      string
+	Severity  string
+	Message   string
+	Timestamp int64
+	Details   string
+}
+
+// sshKey is one entry of an authorized_keys file
+type sshKey struct {
+	Type        string
+	Fingerprint string // SHA256:<base64>, as printed by ssh-keygen -l
+	Comment     string
+}
+
+// file is the parsed state of a watched file
+type file struct {
+	Owner string // user owning the authorized_keys file ("" for sudoers)
+	Mode  os.FileMode
+	Keys  map[string]sshKey // fingerprint -> key (authorized_keys)
+	Rules []string          // non-comment lines (sudoers)
+}
+
+// Watcher diffs authorized_keys of all users and the sudoers configuration
+// between runs. Files are re-read on every check rather than trusting mtimes,
+// which an attacker with root can reset.
+type Watcher struct {
+	files  map[string]file
+	primed bool
+}
+
+// New creates an integrity watcher
+func New() *Watcher {
+	return &Watcher{files: make(map[string]file)}
+}
+
+// Check returns critical events for SSH keys and sudoers rules added or removed
+// since the last call. The first call only records the baseline.
+func (w *Watcher) Check() ([]Event, error) {
+	current := make(map[string]file)
+
+	keyFiles, err := authorizedKeyFiles()
+	if err != nil {
+		return nil, err
+	}
+	for path, owner := range keyFiles {
+		if f, ok := load(path, owner, parseAuthorizedKeys); ok {
+			current[path] = f
+		}
+	}
+	for _, path := range sudoersFiles() {
+		if f, ok := load(path, "", parseSudoers); ok {
+			current[path] = f
+		}
+	}
+
+	if !w.primed {
+		w.files, w.primed = current, true
+		return nil, nil
+	}
+
+	now := time.Now().Unix()
+	var events []Event
+	add := func(msg string, details map[string]interface{}) {
+		d, _ := json.Marshal(details)
+		events = append(events, Event{Type: "security", Severity: "critical", Message: msg, Timestamp: now, Details: string(d)})
+	}
+
+	for _, path := range sortedPaths(current, w.files) {
+		cur, exists := current[path]
+		old, existed := w.files[path]
+
+		if isSudoers(path) {
+			for _, rule := range diff(cur.Rules, old.Rules) {
+				add(fmt.Sprintf("Sudoers rule added in %s: %s", path, rule),
+					map[string]interface{}{"kind": "sudoers_rule_added", "path": path, "rule": rule})
+			}
+			for _, rule := range diff(old.Rules, cur.Rules) {
+				add(fmt.Sprintf("Sudoers rule removed from %s: %s", path, rule),
+					map[string]interface{}{"kind": "sudoers_rule_removed", "path": path, "rule": rule})
+			}
+			if exists && !existed && len(cur.Rules) == 0 {
+				add(fmt.Sprintf("Sudoers file created: %s", path), map[string]interface{}{"kind": "sudoers_file_added", "path": path})
+			}
+		} else {
+			owner := cur.Owner
+			if !exists {
+				owner = old.Owner
+			}
+			for _, fp := range diffKeys(cur.Keys, old.Keys) {
+				k := cur.Keys[fp]
+				add(fmt.Sprintf("SSH key added for user %s: %s %s %s", owner, k.Type, fp, k.Comment),
+					map[string]interface{}{"kind": "ssh_key_added", "user": owner, "path": path, "key_type": k.Type, "fingerprint": fp, "comment": k.Comment})
+			}
+			for _, fp := range diffKeys(old.Keys, cur.Keys) {
+				k := old.Keys[fp]
+				add(fmt.Sprintf("SSH key removed for user %s: %s %s %s", owner, k.Type, fp, k.Comment),
+					map[string]interface{}{"kind": "ssh_key_removed", "user": owner, "path": path, "key_type": k.Type, "fingerprint": fp, "comment": k.Comment})
+			}
+		}
+
+		if exists && existed && cur.Mode != old.Mode {
+			add(fmt.Sprintf("Permissions of %s changed from %04o to %04o", path, old.Mode, cur.Mode),
+				map[string]interface{}{"kind": "mode_changed", "path": path, "old": fmt.Sprintf("%04o", old.Mode), "new": fmt.Sprintf("%04o", cur.Mode)})
+		}
+	}
+
+	w.files = current
+	return events, nil
+}
+
+// load stats and parses a watched file
+func load(path, owner string, parse func(string) (file, error)) (file, bool) {
+	info, err := os.Stat(hostenv.Path(path))
+	if err != nil || info.IsDir() {
+		return file{}, false
+	}
+	f, err := parse(path)
+	if err != nil {
+		return file{}, false
+	}
+	f.Owner = owner
+	f.Mode = info.Mode().Perm()
+	return f, true
+}
+
+// authorizedKeyFiles maps each user's authorized_keys files to the user name
+func authorizedKeyFiles() (map[string]string, error) {
+	f, err := os.Open(hostenv.Path(passwdPath))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	files := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 7 || fields[5] == "" || fields[5] == "/" {
+			continue
+		}
+		for _, name := range []string{"authorized_keys", "authorized_keys2"} {
+			path := filepath.Join(fields[5], ".ssh", name)
+			if _, seen := files[path]; !seen {
+				files[path] = fields[0]
+			}
+		}
+	}
+	return files, scanner.Err()
+}
+
+// sudoersFiles lists /etc/sudoers and everything in /etc/sudoers.d. Files sudo
+// itself would skip (backups, names with dots) are included on purpose.
+func sudoersFiles() []string {
+	files := []string{sudoersPath}
+	entries, err := os.ReadDir(hostenv.Path(sudoersDir))
+	if err != nil {
+		return files
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			files = append(files, filepath.Join(sudoersDir, e.Name()))
+		}
+	}
+	return files
+}
+
+func isSudoers(path string) bool {
+	return path == sudoersPath || strings.HasPrefix(path, sudoersDir+"/")
+}
+
+// parseAuthorizedKeys extracts key type, fingerprint and comment per line,
+// skipping any leading options (from=, command=, ...)
+func parseAuthorizedKeys(path string) (file, error) {
+	data, err := os.ReadFile(hostenv.Path(path))
+	if err != nil {
+		return file{}, err
+	}
+	f := file{Keys: make(map[string]sshKey)}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i := 0; i+1 < len(fields); i++ {
+			if !isKeyType(fields[i]) {
+				continue
+			}
+			blob, err := base64.StdEncoding.DecodeString(fields[i+1])
+			if err != nil {
+				break
+			}
+			sum := sha256.Sum256(blob)
+			fp := "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+			f.Keys[fp] = sshKey{Type: fields[i], Fingerprint: fp, Comment: strings.Join(fields[i+2:], " ")}
+			break
+		}
+	}
+	return f, nil
+}
+
+func isKeyType(s string) bool {
+	return strings.HasPrefix(s, "ssh-") || strings.HasPrefix(s, "ecdsa-sha2-") || strings.HasPrefix(s, "sk-")
+}
+
+// parseSudoers returns the non-comment lines with whitespace collapsed.
+// "#include"/"#includedir" are directives, not comments.
+func parseSudoers(path string) (file, error) {
+	data, err := os.ReadFile(hostenv.Path(path))
+	if err != nil {
+		return file{}, err
+	}
+	f := file{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" || (strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "#include")) {
+			continue
+		}
+		f.Rules = append(f.Rules, line)
+	}
+	return f, nil
+}
+
+// diff returns entries of a that are not in b
+func diff(a, b []string) []string {
+	seen := make(map[string]bool, len(b))
+	for _, s := range b {
+		seen[s] = true
+	}
+	var out []string
+	for _, s := range a {
+		if !seen[s] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// diffKeys returns fingerprints in a that are not in b, sorted
+func diffKeys(a, b map[string]sshKey) []string {
+	var out []string
+	for fp := range a {
+		if _, ok := b[fp]; !ok {
+			out = append(out, fp)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func sortedPaths(a, b map[string]file) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, m := range []map[string]file{a, b} {
+		for p := range m {
+			if !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,36 @@
+//go:build linux
+
+package logtail
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/yourusername/nodeguarder/hostenv"
+)
+
+// Journal returns journalctl lines since the given unix time, filtered by args
+// (e.g. "--unit=cron.service" or "_COMM=sshd")
+func Journal(args []string, since int64, match MatchFunc) ([]string, error) {
+	cmdArgs := append([]string{"--since=" + fmt.Sprintf("@%d", since), "--no-pager", "-o", "short-precise"}, args...)
+	if hostenv.Root() != "" {
+		// Containerized agent: read the host's journal files
+		cmdArgs = append(cmdArgs, "--directory="+hostenv.Path("/var/log/journal"))
+	}
+	output, err := exec.Command("journalctl", cmdArgs...).Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []string
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match == nil || match(line) {
+			entries = append(entries, line)
+		}
+	}
+	return entries, nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package logtail
+
+import "errors"
+
+// errNoJournal makes callers fall back to log files
+var errNoJournal = errors.New("journald is only available on Linux")
+
+// Journal is not available outside Linux
+func Journal(args []string, since int64, match MatchFunc) ([]string, error) {
+	return nil, errNoJournal
+}
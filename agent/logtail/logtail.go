@@ -0,0 +1,124 @@
+package logtail
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/yourusername/nodeguarder/hostenv"
+	"github.com/yourusername/nodeguarder/state"
+)
+
+// MatchFunc selects the log lines a caller is interested in
+type MatchFunc func(line string) bool
+
+// Reader reads new lines from log files, remembering its offset per file
+type Reader struct {
+	mu      sync.Mutex
+	offsets map[string]int64
+	fromEnd bool
+	state   *state.Namespace // persisted offsets, nil = memory only
+}
+
+// NewReader creates a reader. With fromEnd, the first read of a file starts at its
+// current end instead of replaying its whole history.
+func NewReader(fromEnd bool) *Reader {
+	return &Reader{
+		offsets: make(map[string]int64),
+		fromEnd: fromEnd,
+	}
+}
+
+// SetState persists the offsets as "offset:<path>" in the namespace, so after
+// a restart reading resumes where it stopped instead of replaying or skipping
+func (r *Reader) SetState(ns *state.Namespace) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = ns
+}
+
+// offset returns the cursor of a file, loading a persisted one on first use.
+// Called with r.mu held.
+func (r *Reader) offset(key string) (int64, bool) {
+	if offset, ok := r.offsets[key]; ok {
+		return offset, true
+	}
+	value, ok, err := r.state.Get("offset:" + key)
+	if err != nil || !ok {
+		return 0, false
+	}
+	offset, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	r.offsets[key] = offset
+	return offset, true
+}
+
+// setOffset moves the cursor of a file. Called with r.mu held.
+func (r *Reader) setOffset(key string, offset int64) {
+	if old, ok := r.offsets[key]; ok && old == offset {
+		return
+	}
+	r.offsets[key] = offset
+	r.state.Put("offset:"+key, strconv.FormatInt(offset, 10))
+}
+
+// File returns lines appended since the last call to the first readable path.
+// Log rotation (file shrinking) restarts from the beginning.
+func (r *Reader) File(paths []string, match MatchFunc) ([]string, error) {
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no log paths given")
+	}
+
+	var file *os.File
+	var err error
+	for _, p := range paths {
+		if file, err = os.Open(hostenv.Path(p)); err == nil {
+			break
+		}
+	}
+	if file == nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	fi, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// Offsets are keyed by the primary path so a fallback file keeps one cursor
+	key := paths[0]
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var startPos int64 = 0
+	if offset, ok := r.offset(key); ok {
+		if fi.Size() >= offset {
+			startPos = offset
+		}
+	} else if r.fromEnd {
+		r.setOffset(key, fi.Size())
+		return nil, nil
+	}
+
+	if _, err := file.Seek(startPos, 0); err != nil {
+		return nil, err
+	}
+
+	var entries []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match == nil || match(line) {
+			entries = append(entries, line)
+		}
+	}
+
+	r.setOffset(key, fi.Size())
+	return entries, nil
+}
@@ -0,0 +1,44 @@
+package logtail
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/nodeguarder/state"
+)
+
+func TestReaderResumesFromPersistedOffset(t *testing.T) {
+	dir := t.TempDir()
+	store, err := state.Open(filepath.Join(dir, state.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	logPath := filepath.Join(dir, "syslog")
+	if err := os.WriteFile(logPath, []byte("one\ntwo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReader(false)
+	r.SetState(store.Namespace("test"))
+	if lines, err := r.File([]string{logPath}, nil); err != nil || len(lines) != 2 {
+		t.Fatalf("first read = %v, %v", lines, err)
+	}
+
+	f, err := os.OpenFile(logPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("three\n")
+	f.Close()
+
+	// A new reader (agent restart) continues after the lines already read
+	r = NewReader(true)
+	r.SetState(store.Namespace("test"))
+	lines, err := r.File([]string{logPath}, nil)
+	if err != nil || len(lines) != 1 || lines[0] != "three" {
+		t.Errorf("read after restart = %v, %v; want [three]", lines, err)
+	}
+}
@@ -1,55 +1,170 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"errors"
 	"log"
+	"math/rand"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
 	"syscall"
 	"time"
 	"io"
 
+	"github.com/fsnotify/fsnotify"
 	"gopkg.in/natefinch/lumberjack.v2"
 
+	"github.com/yourusername/nodeguarder/accounts"
 	"github.com/yourusername/nodeguarder/api"
+	"github.com/yourusername/nodeguarder/authwatch"
 	"github.com/yourusername/nodeguarder/collector"
 	"github.com/yourusername/nodeguarder/config"
 	"github.com/yourusername/nodeguarder/cron"
+	"github.com/yourusername/nodeguarder/diag"
 	"github.com/yourusername/nodeguarder/drift"
+	"github.com/yourusername/nodeguarder/egress"
+	"github.com/yourusername/nodeguarder/fileaudit"
+	"github.com/yourusername/nodeguarder/hostenv"
+	"github.com/yourusername/nodeguarder/installer"
+	"github.com/yourusername/nodeguarder/integrity"
+	"github.com/yourusername/nodeguarder/mtls"
+	"github.com/yourusername/nodeguarder/packages"
+	"github.com/yourusername/nodeguarder/portcheck"
+	"github.com/yourusername/nodeguarder/proconn"
     "github.com/yourusername/nodeguarder/ebpf"
 	"github.com/yourusername/nodeguarder/queue"
+	"github.com/yourusername/nodeguarder/relay"
+	"github.com/yourusername/nodeguarder/remediate"
+	"github.com/yourusername/nodeguarder/scripts"
+	"github.com/yourusername/nodeguarder/state"
+	"github.com/yourusername/nodeguarder/status"
+	"github.com/yourusername/nodeguarder/terminal"
 	"github.com/yourusername/nodeguarder/updater"
 )
 
 var Version = "1.0.1"
 
+// hostEnv is the runtime environment detected at startup
+var hostEnv hostenv.Environment
+
+// agentState is the consolidated state database (nil if it could not be opened)
+var agentState *state.Store
+
+// resilienceQueue buffers pushes while the dashboard is unreachable (nil if it could not be opened)
+var resilienceQueue *queue.Queue
+
+// ebpfLoader is the zero-touch cron exit monitor (nil if eBPF is unavailable)
+var ebpfLoader *ebpf.Loader
+
+// ebpfUnavailable explains why ebpfLoader is nil (kernel release, missing features)
+var ebpfUnavailable ebpf.Stats
+
+// procConnector reports cron exit codes when eBPF is unavailable (nil otherwise)
+var procConnector *proconn.Listener
+
+// egressMonitor reports unexpected outbound connections (enabled from the dashboard)
+var egressMonitor = egress.New()
+
+// pendingSamples are taken every sample_interval and sent with the next push
+var pendingSamples []collector.Sample
+
+// maxPendingSamples bounds the batch while pushes are failing (oldest dropped)
+const maxPendingSamples = 720
+
+// binarySHA256 and configSHA256 attest the running binary and the loaded
+// config file at registration (empty if they could not be read)
+var binarySHA256, configSHA256 string
+
+// certStore holds the client certificate for mutual TLS (nil unless mtls is enabled)
+var certStore *mtls.Store
+
 func main() {
 	// Command line flags
 	var (
-		configPath   = flag.String("config", config.DefaultConfigPath, "Path to configuration file")
-		installFlag  = flag.Bool("install", false, "Install the agent as a systemd service")
-		dashboardURL = flag.String("dashboard-url", "", "Dashboard URL (required for install)")
+		configPath    = flag.String("config", config.DefaultConfigPath, "Path to configuration file")
+		installFlag   = flag.Bool("install", false, "Install the agent as a system service (systemd, launchd or rc.d)")
+		dashboardURL  = flag.String("dashboard-url", "", "Dashboard URL (required for install)")
+		regToken      = flag.String("token", "", "Registration token written to the config at install")
+		packageID     = flag.String("package-id", "", "Install package ID written to the config at install")
+		insecureFlag  = flag.Bool("insecure", false, "Skip TLS verification of the dashboard (written to the config at install)")
+		uninstallFlag = flag.Bool("uninstall", false, "Stop and remove the system service and the installed binary")
+		purgeFlag     = flag.Bool("purge", false, "With --uninstall, also remove the config and agent data")
+		wrapFlag      = flag.Bool("wrap", false, "Run a cron job (command after --) and keep its output if it fails")
+		checkFlag     = flag.Bool("check", false, "Run one collection, drift scan and cron log parse, print what would be sent and exit")
+		driftIgnore   patternList
 	)
+	flag.Var(&driftIgnore, "drift-ignore", "Drift ignore pattern to try with -check (repeatable)")
 	flag.Parse()
 
+	// Cron job wrapper: nodeguarder-agent -wrap -- /usr/local/bin/backup.sh
+	if *wrapFlag {
+		os.Exit(cron.RunWrapped(flag.Args()))
+	}
+
+	// Dry run: nodeguarder-agent -check [-drift-ignore '*.swp']
+	if *checkFlag {
+		os.Exit(runCheck(*configPath, driftIgnore))
+	}
+
+	// Local status of the running agent: nodeguarder-agent status [-json]
+	if flag.Arg(0) == "status" {
+		os.Exit(printStatus(*configPath, flag.Args()[1:]))
+	}
+
 	// Handle install command
 	if *installFlag {
-		if err := install(*dashboardURL, *configPath); err != nil {
+		err := install(installer.Options{
+			DashboardURL:      *dashboardURL,
+			ConfigPath:        *configPath,
+			RegistrationToken: *regToken,
+			PackageID:         *packageID,
+			Insecure:          *insecureFlag,
+		})
+		if err != nil {
 			log.Fatalf("Installation failed: %v", err)
 		}
-		fmt.Println("✅ Agent installed successfully!")
-		fmt.Println("Run: sudo systemctl start nodeguarder-agent")
+		fmt.Println("✅ Agent installed and running!")
+		if runtime.GOOS == "linux" {
+			fmt.Println("   Logs: journalctl -u nodeguarder-agent -f")
+		} else {
+			fmt.Println("   Logs: tail -f /var/log/nodeguarder/agent.log")
+		}
 		return
 	}
 
+	// Handle uninstall command
+	if *uninstallFlag {
+		if os.Geteuid() != 0 {
+			log.Fatal("Uninstallation must be run as root")
+		}
+		if err := installer.Uninstall(installer.Options{ConfigPath: *configPath}, *purgeFlag); err != nil {
+			log.Fatalf("Uninstallation failed: %v", err)
+		}
+		fmt.Println("✅ Agent uninstalled")
+		if !*purgeFlag {
+			fmt.Printf("   Configuration kept in %s (remove it with --uninstall --purge)\n", filepath.Dir(*configPath))
+		}
+		return
+	}
+
+	// Containers (DaemonSet) create their config on first start
+	if err := bootstrapContainerConfig(*configPath); err != nil {
+		log.Fatalf("Failed to create config: %v", err)
+	}
+
 	// Load configuration
 	cfg, err := config.Load(*configPath)
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	// Containerized agent: read the host's files from where they are mounted
+	hostenv.SetRoot(cfg.HostRoot)
 
 	// Configure logging
 	logFile := &lumberjack.Logger{
@@ -64,145 +179,548 @@ func main() {
 	mw := io.MultiWriter(os.Stdout, logFile)
 	log.SetOutput(mw)
 
-	log.Printf("Starting NodeGuarder Agent v%s", Version)
+	log.Printf("Starting NodeGuarder Agent v%s (%s build)", Version, buildProfile)
+	if binarySHA256, err = updater.BinaryChecksum(); err != nil {
+		log.Printf("Warning: Failed to hash agent binary: %v", err)
+	}
+	if configSHA256, err = updater.FileChecksum(*configPath); err != nil {
+		log.Printf("Warning: Failed to hash config file: %v", err)
+	}
 	log.Printf("Server ID: %s", cfg.ServerID)
 	log.Printf("Dashboard: %s", cfg.DashboardURL)
 
+	// Confirm or roll back a self-update (checked on every start, so a new
+	// version that keeps crashing is rolled back once its deadline passed)
+	updateMarkerPath := filepath.Join(filepath.Dir(*configPath), updater.MarkerFile)
+	pendingUpdate, rolledBackUpdate := checkPendingUpdate(updateMarkerPath, rollbackWindow(cfg))
+
 	// Create API client
 	apiClient := api.NewClient(cfg.DashboardURL, cfg.ServerID, cfg.APISecret, cfg.DisableSSLVerify)
+	if cfg.MTLS {
+		// Present the client certificate (enrolled after registration) on every connection
+		certStore = mtls.NewStore(cfg.MTLSCert, cfg.MTLSKey)
+		if err := certStore.Load(); err != nil {
+			log.Printf("Warning: Failed to load client certificate: %v", err)
+		}
+		apiClient.UseClientCertificate(certStore.Certificate)
+	}
+	if cfg.Transport == "grpc" {
+		if err := apiClient.UseGRPC(cfg.GRPCAddress, strings.HasPrefix(cfg.DashboardURL, "https://")); err != nil {
+			log.Fatalf("Failed to set up gRPC transport: %v", err)
+		}
+		defer apiClient.Close()
+		log.Printf("Transport: gRPC (%s)", cfg.GRPCAddress)
+	} else {
+		// Long-poll for config changes and commands between ticks
+		apiClient.WatchConfig()
+	}
+
+	// Open the consolidated state database (queue, drift baseline, cron state, log cursors)
+	stateDir := filepath.Dir(*configPath)
+	stateStore, err := state.Open(filepath.Join(stateDir, state.FileName))
+	if err != nil {
+		log.Printf("Warning: Failed to open state database: %v", err)
+	} else {
+		defer stateStore.Close()
+		agentState = stateStore
+	}
 
 	// Initialize resilience queue
-	queuePath := filepath.Join(filepath.Dir(*configPath), "queue.db")
-	q, err := queue.NewQueue(queuePath, 1000) // Max 1000 items
+	var q *queue.Queue
+	if stateStore != nil {
+		q, err = queue.NewQueueWithDB(stateStore.DB(), queueMaxItems(cfg))
+		if err == nil {
+			// Carry over items still queued by older agents in queue.db
+			if n, err := stateStore.ImportLegacy(filepath.Join(stateDir, "queue.db"), "queue", "type, payload, timestamp, retries, last_error, created_at"); err != nil {
+				log.Printf("⚠️  Legacy queue migration: %v", err)
+			} else if n > 0 {
+				log.Printf("✓ Migrated %d queued items from queue.db", n)
+				if err := q.Reprioritize(); err != nil {
+					log.Printf("⚠️  Legacy queue migration: %v", err)
+				}
+			}
+		}
+	} else {
+		q, err = queue.NewQueue(filepath.Join(stateDir, "queue.db"), queueMaxItems(cfg))
+	}
 	if err != nil {
 		log.Printf("Warning: Failed to initialize resilience queue: %v", err)
 		log.Println("Continuing without offline resilience...")
 	} else {
+		q.SetMaxMB(queueMaxMB(cfg))
+		q.SetMaxAge(time.Duration(cfg.QueueMaxAge) * time.Second)
+		if err := q.SetOverflow(cfg.QueueOverflow); err != nil {
+			log.Printf("⚠️  %v", err)
+		}
 		apiClient.SetQueue(q)
+		resilienceQueue = q
 		defer q.Close()
 		log.Println("✓ Resilience queue initialized")
 	}
 
+	// Start relay listener for agents on isolated segments (DMZ / air-gapped)
+	if cfg.RelayListen != "" {
+		relayServer := relay.New(cfg.RelayListen, apiClient, q)
+		relayServer.SetTLS(cfg.RelayTLSCert, cfg.RelayTLSKey)
+		relayServer.Start()
+		defer relayServer.Close()
+	}
+
 	// Register with dashboard
-	if err := registerAgent(apiClient, cfg.RegistrationToken); err != nil {
+	if err := registerAgent(apiClient, cfg.RegistrationToken, cfg.PackageID); err != nil {
 		log.Printf("Warning: Failed to register with dashboard: %v", err)
 		log.Printf("Will retry on next interval...")
 	} else {
 		log.Println("✅ Registered with dashboard")
 	}
+	enrollCertificate(apiClient, cfg.ServerID, false)
 
 	// Initialize drift detector
 	driftPaths := cfg.DriftPaths
 	if len(driftPaths) == 0 {
 		driftPaths = []string{"/etc"}
 	}
+	driftPaths = hostPaths(driftPaths)
 	driftDetector := drift.New(driftPaths)
+	driftDetector.SetThrottle(cfg.DriftThrottle)
+	if err := driftDetector.SetState(agentState.Namespace("drift")); err != nil {
+		log.Printf("⚠️  Ignoring saved drift baseline: %v", err)
+	}
+	networkDetector := drift.NewNetworkDetector()
+
+	// Attribute drift to the writing process (fanotify, needs root)
+	writeTracker, err := fileaudit.Start(driftPaths)
+	if err != nil {
+		log.Printf("⚠️  File write attribution disabled: %v", err)
+	} else {
+		defer writeTracker.Close()
+		driftDetector.SetAttributor(func(path string) (string, bool) {
+			w, ok := writeTracker.Writer(path)
+			return w.String(), ok
+		})
+	}
+
+	// Detect bare metal / VM / container so monitors and the dashboard can adapt
+	hostEnv = hostenv.Detect()
+	log.Printf("🖥️  Runtime environment: %s", hostEnv)
 
 	// Initialize cron monitor
 	cronMonitor := cron.New(cfg.CronLogPath)
+	cronMonitor.SetState(agentState.Namespace("cron"))
+	// With hostPID (DaemonSet) a container sees the host's PIDs like a host agent
+	cronMonitor.SetContainerized(hostEnv.Container && !hostEnv.HostPID)
+	cron.PrepareCaptureDir() // output of jobs run under -wrap
+
+	// Initialize port liveness checks
+	portChecker := portcheck.New(cfg.PortChecks)
+
+	// Initialize auth log watcher (failed SSH logins, sudo sessions)
+	authWatcher := authwatch.New(cfg.AuthWatch)
+	authWatcher.SetState(agentState.Namespace("authwatch"))
+	var accountWatcher *accounts.Watcher
+	if cfg.AccountWatch {
+		accountWatcher = accounts.New()
+	}
+	// SSH authorized_keys and sudoers are always watched, independent of drift settings
+	integrityWatcher := integrity.New()
+	var packageTracker *packages.Tracker
+	if cfg.PackageInventory {
+		packageTracker = packages.NewTracker()
+	}
+	inventorySynced := false
 
     // Initialize eBPF Monitor (Zero Touch)
     // We try to load the BPF program. If it fails (old kernel/permissions), we continue without it.
     // In that case, we rely on standard log parsing (no exit codes).
-    bpfLoader, err := ebpf.InitBPF()
+    // Exits from the eBPF probes or, without them, the proc connector.
+    // The exec'd argv names the job; PIDs (Global and Namespace) are the fallback
+    updateCronStatus := func(e ebpf.ProcessExitEvent) bool {
+        return cronMonitor.UpdateJobStatusByPID(int32(e.Pid), int32(e.ParentPid), int32(e.NsPid), int32(e.NsParentPid), cron.ExitStatus{
+            ExitCode:   int(e.ExitCode),
+            Signal:     int(e.Signal),
+            CoreDumped: e.CoreDumped,
+            OOMKilled:  e.OOMKilled,
+            Argv:          e.Argv,
+            ArgvTruncated: e.ArgvTruncated,
+            Source:        e.Source,
+        })
+    }
+
+    bpfLoader, err := ebpf.InitBPF(cfg.EBPFBufferPages, cfg.EBPFBTFPath)
     if err != nil {
         log.Printf("⚠️  eBPF Initialization Failed: %v", err)
-        log.Println("    (Zero Touch Cron Failure Detection disabled. Ensure standard cron logs are available)")
+        ebpfUnavailable = ebpf.Unavailable(cfg.EBPFBTFPath, err)
+
+        // Fall back to the proc connector, which still reports exit codes
+        conn, err := proconn.Start(updateCronStatus)
+        if err != nil {
+            log.Printf("⚠️  Proc connector unavailable: %v", err)
+            log.Println("    (Zero Touch Cron Failure Detection disabled. Ensure standard cron logs are available)")
+        } else {
+            log.Println("✅ Proc connector fallback active (cron exit codes without eBPF)")
+            defer conn.Close()
+            procConnector = conn
+        }
     } else {
         log.Println("✅ eBPF Monitor Loaded (Zero Touch Exit Code Detection Enabled)")
         defer bpfLoader.Close()
+        ebpfLoader = bpfLoader
         
         // Connect BPF events to Cron Monitor
         bpfLoader.SetEventHandler(func(e ebpf.ProcessExitEvent) {
-             // We prioritize matching by PID for accurate command linking
-             // We pass both Global PIDs and Namespace PIDs
-             cronMonitor.UpdateJobStatusByPID(int32(e.Pid), int32(e.ParentPid), int32(e.NsPid), int32(e.NsParentPid), int(e.ExitCode))
+             if !updateCronStatus(e) {
+                 bpfLoader.CountUnmatched()
+             }
         })
     }
 
-	// Start monitoring loop
-	ticker := time.NewTicker(time.Duration(cfg.Interval) * time.Second)
+	defer egressMonitor.Close()
+
+	// Start monitoring loop. The push, drift and queue flush tickers are
+	// re-armed with jitter after every tick so agents installed together
+	// spread out instead of hitting the dashboard in the same second.
+	ticker := time.NewTicker(jittered(time.Duration(cfg.Interval)*time.Second, cfg.Jitter))
 	defer ticker.Stop()
 
     // Drift check ticker
-    driftInterval := 5 * time.Minute
-    if cfg.DriftInterval > 0 {
-        driftInterval = time.Duration(cfg.DriftInterval) * time.Second
-    }
-    // Fallback Legacy Heuristic logic (if not set by dashboard yet)
-    if cfg.DriftInterval == 0 && cfg.Interval < 60 {
-        driftInterval = time.Duration(cfg.Interval) * time.Second
-    }
-
-    driftTicker := time.NewTicker(driftInterval)
+    driftTicker := time.NewTicker(jittered(driftInterval(cfg), cfg.Jitter))
     defer driftTicker.Stop()
 
+	// Sampling between pushes (sample_interval below interval)
+	var sampleTicker *time.Ticker
+	var sampleTick <-chan time.Time
+	setSampling := func() {
+		if sampleTicker != nil {
+			sampleTicker.Stop()
+			sampleTicker, sampleTick = nil, nil
+		}
+		if cfg.SampleInterval > 0 && cfg.SampleInterval < cfg.Interval {
+			sampleTicker = time.NewTicker(time.Duration(cfg.SampleInterval) * time.Second)
+			sampleTick = sampleTicker.C
+			log.Printf("Sampling every %ds, batched into each push", cfg.SampleInterval)
+		}
+	}
+	setSampling()
+	defer func() {
+		if sampleTicker != nil {
+			sampleTicker.Stop()
+		}
+	}()
+
+	// Local status for `nodeguarder-agent status`, refreshed after every push
+	statusPath := filepath.Join(stateDir, status.FileName)
+	startedAt := time.Now().Unix()
+	var lastPush int64
+	var lastPushErr string
+	var healthServer *status.Server
+	writeStatus := func() {
+		snap := status.Snapshot{
+			Version:       Version,
+			Build:         buildProfile,
+			PID:           os.Getpid(),
+			StartedAt:     startedAt,
+			UpdatedAt:     time.Now().Unix(),
+			Interval:      cfg.Interval,
+			ServerID:      cfg.ServerID,
+			Dashboard:     cfg.DashboardURL,
+			Transport:     "rest",
+			Connected:     lastPush > 0 && lastPushErr == "",
+			LastPush:      lastPush,
+			LastError:     lastPushErr,
+			EBPF:          ebpfUnavailable.Level,
+			EBPFError:     ebpfUnavailable.Error,
+			ProcConnector: procConnector != nil,
+		}
+		if cfg.Transport == "grpc" {
+			snap.Transport = "grpc"
+		}
+		if q != nil {
+			snap.QueueSize, _ = q.GetSize()
+		}
+		if ebpfLoader != nil {
+			snap.EBPF, snap.EBPFError = ebpfLoader.Stats().Level, ""
+		}
+		for _, job := range cronMonitor.GetTrackedJobs() {
+			snap.CronJobs = append(snap.CronJobs, status.CronJob{
+				Command:  job.Command,
+				User:     job.User,
+				Schedule: job.Schedule,
+				LastRun:  job.LastExecTime,
+				ExitCode: job.LastExitCode,
+				Running:  job.ActivePID > 0,
+				Failures: job.FailureCount,
+			})
+		}
+		if healthServer != nil {
+			healthServer.Update(snap)
+		}
+		if err := status.Write(statusPath, snap); err != nil {
+			log.Printf("Warning: Failed to write status file: %v", err)
+		}
+	}
+	if addr := healthListen(cfg); addr != "" {
+		if healthServer, err = status.Listen(addr); err != nil {
+			log.Printf("⚠️  Health endpoint disabled: %v", err)
+		} else {
+			defer healthServer.Close()
+			log.Printf("✅ Health endpoint on http://%s/healthz", healthServer.Addr())
+		}
+	}
+	writeStatus()
+	defer os.Remove(statusPath)
+
 	// Queue flush ticker (every 30 seconds)
-	queueFlushTicker := time.NewTicker(30 * time.Second)
+	queueFlushTicker := time.NewTicker(jittered(queueFlushInterval, cfg.Jitter))
 	defer queueFlushTicker.Stop()
 
+	// Opt-in debug mode: pprof on localhost and a periodic self-report
+	var selfReportTick <-chan time.Time
+	if cfg.Debug {
+		addr := cfg.DebugListen
+		if addr == "" {
+			addr = diag.DefaultListen
+		}
+		if pprofServer, err := diag.Listen(addr); err != nil {
+			log.Printf("⚠️  pprof disabled: %v", err)
+		} else {
+			defer pprofServer.Close()
+			log.Printf("🩺 Debug mode: pprof on http://%s/debug/pprof/", pprofServer.Addr())
+		}
+		selfReportTicker := time.NewTicker(selfReportInterval)
+		defer selfReportTicker.Stop()
+		selfReportTick = selfReportTicker.C
+	}
+
 	// Update check & Cleanup ticker (every 1 hour)
 	updateTicker := time.NewTicker(1 * time.Hour)
 	defer updateTicker.Stop()
 
+	// Update to the version assigned by a staged rollout, or else to the
+	// published one. Returns true when the agent must exit to restart.
+	selfUpdate := func() bool {
+		newVersion := cfg.TargetVersion
+		hasUpdate := newVersion != "" && newVersion != Version
+		if newVersion == "" {
+			log.Println("Checking for updates...")
+			if hasUpdate, newVersion, err = updater.CheckForUpdate(cfg.DashboardURL, Version); err != nil {
+				log.Printf("Failed to check for updates: %v", err)
+				return false
+			}
+		}
+		if !hasUpdate {
+			return false
+		}
+		if skipUpdate(updateMarkerPath, newVersion) {
+			log.Printf("⏭️  Skipping v%s: it was rolled back on this host", newVersion)
+			return false
+		}
+		log.Printf("🚀 New version available: %s. Upgrading...", newVersion)
+		if err := updater.ApplyUpdate(cfg.DashboardURL, Version, newVersion, updateMarkerPath); err != nil {
+			log.Printf("❌ Failed to apply update: %v", err)
+			return false
+		}
+		log.Println("✅ Update applied successfully! Exiting to restart...")
+		return true
+	}
+
+	// Deadline for the first push of an updated agent
+	var rollbackTimeout <-chan time.Time
+	if pendingUpdate != nil {
+		rollbackTimer := time.NewTimer(time.Until(pendingUpdate.Deadline(rollbackWindow(cfg))))
+		defer rollbackTimer.Stop()
+		rollbackTimeout = rollbackTimer.C
+	}
+
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	// Reload the config file on SIGHUP or when it changes
+	hupChan := make(chan os.Signal, 1)
+	signal.Notify(hupChan, syscall.SIGHUP)
+	configChanged, configWatcher, err := watchConfigFile(*configPath)
+	if err != nil {
+		log.Printf("⚠️  Config file watch disabled (reload with SIGHUP): %v", err)
+	} else {
+		defer configWatcher.Close()
+	}
+
 	// Initialize last alert times
 	lastAlertTime := make(map[string]time.Time)
     
     // Initialize sustain start times (for health alert debouncing)
     sustainStartTime := make(map[string]time.Time)
 
+	// Re-arm the tickers after the push or drift interval changed
+	retime := func(oldInterval int, oldDriftInterval time.Duration) {
+		if cfg.Interval != oldInterval {
+			ticker.Reset(jittered(time.Duration(cfg.Interval)*time.Second, cfg.Jitter))
+			setSampling()
+			log.Printf("Interval updated to %ds", cfg.Interval)
+		}
+		if newDriftInterval := driftInterval(cfg); newDriftInterval != oldDriftInterval {
+			driftTicker.Reset(jittered(newDriftInterval, cfg.Jitter))
+			log.Printf("Drift interval updated to %s", newDriftInterval)
+		}
+	}
+
+    // Refresh configuration from the dashboard
+    applyConfig := func() {
+            oldInterval, oldDriftInterval := cfg.Interval, driftInterval(cfg)
+			if err := refreshConfig(apiClient, driftDetector, networkDetector, cronMonitor, writeTracker, cfg, filepath.Dir(*configPath)); err != nil {
+				log.Printf("Warning: Failed to refresh config: %v", err)
+			} else {
+                retime(oldInterval, oldDriftInterval)
+            }
+    }
+
+	// Re-read the local config file and apply what can change at runtime
+	reloadConfig := func() {
+		next, err := config.Load(*configPath)
+		if err != nil {
+			log.Printf("⚠️  Config reload failed, keeping the current config: %v", err)
+			return
+		}
+		old, oldDriftInterval := *cfg, driftInterval(cfg)
+		for _, name := range cfg.Reload(next) {
+			log.Printf("⚠️  %s changed in %s: restart the agent to apply it", name, *configPath)
+		}
+
+		retime(old.Interval, oldDriftInterval)
+		if cfg.Interval == old.Interval && cfg.SampleInterval != old.SampleInterval {
+			setSampling()
+		}
+		if q != nil && cfg.QueueMaxItems != old.QueueMaxItems {
+			q.SetMaxItems(queueMaxItems(cfg))
+		}
+		if q != nil && cfg.QueueMaxMB != old.QueueMaxMB {
+			q.SetMaxMB(queueMaxMB(cfg))
+		}
+		if q != nil && cfg.QueueMaxAge != old.QueueMaxAge {
+			q.SetMaxAge(time.Duration(cfg.QueueMaxAge) * time.Second)
+		}
+		if q != nil && cfg.QueueOverflow != old.QueueOverflow {
+			if err := q.SetOverflow(cfg.QueueOverflow); err != nil {
+				log.Printf("⚠️  %v", err)
+			}
+		}
+		if cfg.DriftThrottle != old.DriftThrottle {
+			driftDetector.SetThrottle(cfg.DriftThrottle)
+		}
+		if !reflect.DeepEqual(cfg.PortChecks, old.PortChecks) {
+			portChecker = portcheck.New(cfg.PortChecks)
+		}
+		if !reflect.DeepEqual(cfg.AuthWatch, old.AuthWatch) {
+			authWatcher = authwatch.New(cfg.AuthWatch)
+			authWatcher.SetState(agentState.Namespace("authwatch"))
+		}
+		if cfg.AccountWatch != old.AccountWatch {
+			accountWatcher = nil
+			if cfg.AccountWatch {
+				accountWatcher = accounts.New()
+			}
+		}
+		if cfg.PackageInventory != old.PackageInventory {
+			packageTracker, inventorySynced = nil, false
+			if cfg.PackageInventory {
+				packageTracker = packages.NewTracker()
+			}
+		}
+		if sum, err := updater.FileChecksum(*configPath); err == nil {
+			configSHA256 = sum // attested at the next registration
+		}
+
+		// Dashboard-managed settings take precedence again
+		applyConfig()
+		log.Printf("🔄 Configuration reloaded from %s", *configPath)
+	}
+
 	log.Printf("Monitoring started (interval: %ds)", cfg.Interval)
 
 	for {
 		select {
+		case <-apiClient.ConfigUpdates():
+			// Signalled by the config stream (gRPC or REST long-poll), applied
+			// without waiting for the next tick
+			oldTarget := cfg.TargetVersion
+			applyConfig()
+			// A rollout reached this server: update now instead of on the hourly check
+			if cfg.TargetVersion != oldTarget && selfUpdate() {
+				return
+			}
+
+		case <-selfReportTick:
+			report := diag.Collect().String()
+			if agentState != nil {
+				report += fmt.Sprintf(" state.db=%.1fKB", float64(agentState.Stats().SizeBytes)/1024)
+			}
+			if q != nil {
+				if n, err := q.GetSize(); err == nil {
+					report += fmt.Sprintf(" queued=%d", n)
+				}
+			}
+			log.Printf("🩺 Self-report: %s", report)
+
+		case <-sampleTick:
+			pendingSamples = append(pendingSamples, collector.CollectSample())
+			if over := len(pendingSamples) - maxPendingSamples; over > 0 {
+				pendingSamples = pendingSamples[over:]
+			}
+
 		case <-ticker.C:
 			// Refresh configuration
-            oldDriftInterval := cfg.DriftInterval
-			if err := refreshConfig(apiClient, driftDetector, cronMonitor, cfg); err != nil {
-				log.Printf("Warning: Failed to refresh config: %v", err)
-			} else {
-                // Check if drift interval changed
-                if cfg.DriftInterval != oldDriftInterval {
-                     newDriftInterval := 5 * time.Minute
-                     if cfg.DriftInterval > 0 {
-                        newDriftInterval = time.Duration(cfg.DriftInterval) * time.Second
-                     } else if cfg.Interval < 60 {
-                         // Fallback logic for when config is reset to 0 but interval is short
-                         newDriftInterval = time.Duration(cfg.Interval) * time.Second
-                     }
-                     driftTicker.Reset(newDriftInterval)
-                     log.Printf("Drift interval updated to %s", newDriftInterval)
-                }
-            }
+			oldTarget := cfg.TargetVersion
+			applyConfig()
+			// A rollout reached this server: update now instead of on the hourly check
+			if cfg.TargetVersion != oldTarget && selfUpdate() {
+				return
+			}
+			ticker.Reset(jittered(time.Duration(cfg.Interval)*time.Second, cfg.Jitter))
 
             // NOTE: Drift check removed from here to reduce I/O load. 
             // It now runs on its own 5m ticker.
 
-			if err := collectAndSend(apiClient, driftDetector, cronMonitor, cfg, lastAlertTime, sustainStartTime, false); err != nil {
+			if err := collectAndSend(apiClient, driftDetector, networkDetector, cronMonitor, portChecker, authWatcher, accountWatcher, integrityWatcher, cfg, lastAlertTime, sustainStartTime, false); err != nil {
 				log.Printf("Error: %v", err)
+				lastPushErr = err.Error()
 
 				// Check if unauthorized (server deleted agent?)
 				if errors.Is(err, api.ErrUnauthorized) {
 					log.Println("⚠️  Server rejected credentials (node might be deleted). Attempting re-registration...")
-					if err := registerAgent(apiClient, cfg.RegistrationToken); err != nil {
+					enrollCertificate(apiClient, cfg.ServerID, true)
+					if err := registerAgent(apiClient, cfg.RegistrationToken, cfg.PackageID); err != nil {
 						log.Printf("❌ Re-registration failed: %v", err)
 					} else {
 						log.Println("✅ Re-registration successful! Resuming monitoring...")
+						enrollCertificate(apiClient, cfg.ServerID, false)
 					}
 				}
+			} else {
+				lastPush, lastPushErr = time.Now().Unix(), ""
+				if pendingUpdate != nil {
+					log.Printf("✅ Update to v%s confirmed by a successful push", pendingUpdate.ToVersion)
+					updater.ClearMarker(updateMarkerPath)
+					pendingUpdate, rollbackTimeout = nil, nil
+				}
+				if rolledBackUpdate != nil {
+					reportRollback(apiClient, updateMarkerPath, rolledBackUpdate)
+					rolledBackUpdate = nil
+				}
 			}
+			writeStatus()
 
         case <-driftTicker.C:
+            driftTicker.Reset(jittered(driftInterval(cfg), cfg.Jitter))
             // Run Drift Check separately
-			if err := collectAndSend(apiClient, driftDetector, cronMonitor, cfg, lastAlertTime, sustainStartTime, true); err != nil {
+			if err := collectAndSend(apiClient, driftDetector, networkDetector, cronMonitor, portChecker, authWatcher, accountWatcher, integrityWatcher, cfg, lastAlertTime, sustainStartTime, true); err != nil {
                  log.Printf("Error sending drift events: %v", err)
             }
+			if packageTracker != nil {
+				checkPackages(apiClient, packageTracker, &inventorySynced)
+			}
 
 		case <-queueFlushTicker.C:
+			queueFlushTicker.Reset(jittered(queueFlushInterval, cfg.Jitter))
 			// Try to flush queued items periodically
 			if q != nil && !q.IsConnected() {
 				sent, failed, err := apiClient.FlushQueue()
@@ -217,20 +735,26 @@ func main() {
             // Cleanup stale cron jobs
             cronMonitor.Cleanup()
 
+			// Renew the client certificate once two thirds of its validity passed
+			enrollCertificate(apiClient, cfg.ServerID, false)
+
 			// Check for updates
-			log.Println("Checking for updates...")
-			hasUpdate, newVersion, err := updater.CheckForUpdate(cfg.DashboardURL, Version)
-			if err != nil {
-				log.Printf("Failed to check for updates: %v", err)
-			} else if hasUpdate {
-				log.Printf("🚀 New version available: %s. Upgrading...", newVersion)
-				if err := updater.ApplyUpdate(cfg.DashboardURL, newVersion); err != nil {
-					log.Printf("❌ Failed to apply update: %v", err)
-				} else {
-					log.Println("✅ Update applied successfully! Exiting to restart...")
-					return
-				}
+			if selfUpdate() {
+				return
+			}
+
+		case <-rollbackTimeout:
+			if rollbackUpdate(updateMarkerPath, pendingUpdate, rollbackWindow(cfg)) {
+				return
 			}
+			pendingUpdate, rollbackTimeout = nil, nil
+
+		case <-hupChan:
+			log.Println("Received SIGHUP, reloading configuration...")
+			reloadConfig()
+
+		case <-configChanged:
+			reloadConfig()
 
 		case sig := <-sigChan:
 			log.Printf("Received signal %v, shutting down...", sig)
@@ -239,8 +763,81 @@ func main() {
 	}
 }
 
+// selfReportInterval is how often debug mode logs the agent's own usage
+const selfReportInterval = 5 * time.Minute
+
+// queueFlushInterval is how often queued items are retried while disconnected
+const queueFlushInterval = 30 * time.Second
+
+// jittered returns d moved by a random amount of up to ±percent of it
+func jittered(d time.Duration, percent int) time.Duration {
+	spread := int64(d) * int64(percent) / 100
+	if spread <= 0 {
+		return d
+	}
+	return d + time.Duration(rand.Int63n(2*spread+1)-spread)
+}
+
+// driftInterval is the drift check interval set from the dashboard, or 5
+// minutes; shorter push intervals are used as is while the dashboard has not
+// set one (legacy heuristic)
+func driftInterval(cfg *config.Config) time.Duration {
+	if cfg.DriftInterval > 0 {
+		return time.Duration(cfg.DriftInterval) * time.Second
+	}
+	if cfg.Interval < 60 {
+		return time.Duration(cfg.Interval) * time.Second
+	}
+	return 5 * time.Minute
+}
+
+// configReloadDelay coalesces the events of one config file save
+const configReloadDelay = 500 * time.Millisecond
+
+// watchConfigFile signals changes to the config file. Its directory is
+// watched, so editors that replace the file (write and rename) are noticed.
+func watchConfigFile(path string) (<-chan struct{}, io.Closer, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		w.Close()
+		return nil, nil, err
+	}
+
+	changed := make(chan struct{}, 1)
+	target := filepath.Clean(path)
+	go func() {
+		var settle <-chan time.Time
+		for {
+			select {
+			case ev, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(ev.Name) == target && ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+					settle = time.After(configReloadDelay)
+				}
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("⚠️  Config file watch: %v", err)
+			case <-settle:
+				settle = nil
+				select {
+				case changed <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return changed, w, nil
+}
+
 // refreshConfig fetches and applies dynamic configuration from the dashboard
-func refreshConfig(client *api.Client, driftDetector *drift.Detector, cronMonitor *cron.Monitor, cfg *config.Config) error {
+func refreshConfig(client *api.Client, driftDetector *drift.Detector, networkDetector *drift.NetworkDetector, cronMonitor *cron.Monitor, writeTracker *fileaudit.Tracker, cfg *config.Config, stateDir string) error {
 	newConfig, err := client.GetConfig()
 	if err != nil {
 		return err
@@ -253,14 +850,40 @@ func refreshConfig(client *api.Client, driftDetector *drift.Detector, cronMonito
 	cfg.Thresholds.Disk = int(newConfig.Thresholds.DiskCritical)
 
     // Update Drift Params
-	driftDetector.SetIgnore(newConfig.DriftIgnore)
-    driftDetector.SetPaths(newConfig.DriftPaths)
+	if err := driftDetector.SetIgnore(newConfig.DriftIgnore); err != nil {
+		log.Printf("⚠️  %v", err)
+	}
+    driftDetector.SetPaths(hostPaths(newConfig.DriftPaths))
+    if writeTracker != nil {
+        writeTracker.SetPaths(hostPaths(newConfig.DriftPaths))
+    }
     cfg.DriftInterval = newConfig.DriftInterval
+    cfg.SetDashboardInterval(newConfig.Interval)
+    if cfg.NetworkDriftEnabled != newConfig.NetworkDriftEnabled {
+        // Start from a fresh baseline when the mode is toggled
+        networkDetector.Reset()
+        cfg.NetworkDriftEnabled = newConfig.NetworkDriftEnabled
+    }
+
+    // Drift accepted on the dashboard: current state becomes the new baseline
+    if newConfig.DriftRebaseline {
+        if err := driftDetector.Rebaseline(); err != nil {
+            log.Printf("⚠️  Drift re-baseline failed: %v", err)
+        } else {
+            log.Println("✅ Drift baseline accepted from dashboard")
+        }
+        networkDetector.Reset()
+    }
     
     // Update Health Params
     cfg.HealthEnabled = newConfig.HealthEnabled
     cfg.HealthEnabled = newConfig.HealthEnabled
+    cfg.TargetVersion = newConfig.TargetVersion
     cfg.HealthSustainDuration = newConfig.HealthSustainDuration
+    collector.SetDiskExclusions(newConfig.DiskExcludeFSTypes, newConfig.DiskExcludePaths)
+    if err := egressMonitor.Configure(newConfig.EgressMonitorEnabled, newConfig.EgressAllow, cfg.EBPFBufferPages, cfg.EBPFBTFPath); err != nil {
+        log.Printf("⚠️  %v", err)
+    }
     
 	// Update Cron Monitor
     cfg.CronEnabled = newConfig.CronEnabled
@@ -274,6 +897,23 @@ func refreshConfig(client *api.Client, driftDetector *drift.Detector, cronMonito
     }
     cronMonitor.SetConfig(cronConfig)
 
+    // Apply cron pause/resume requests (crontab lines are commented out, with a backup)
+    for _, req := range newConfig.CronPauses {
+        result := cron.ApplyPause(req, filepath.Join(stateDir, "cron-backups"))
+        for _, file := range result.Files {
+            // Our own edit, not configuration drift
+            driftDetector.Acknowledge(file)
+        }
+        if result.Error != "" {
+            log.Printf("❌ Cron pause (%q, paused=%t) failed: %s", req.Command, req.Paused, result.Error)
+        } else {
+            log.Printf("⏸️  Cron job %q paused=%t (%d crontab(s) changed)", req.Command, req.Paused, len(result.Files))
+        }
+        if err := client.ReportCronPause(result); err != nil {
+            log.Printf("Failed to report cron pause result: %v", err)
+        }
+    }
+
     // Check for Log Collection Request
     if newConfig.CollectLogs {
         log.Println("📥 Received request to collect logs...")
@@ -294,6 +934,55 @@ func refreshConfig(client *api.Client, driftDetector *drift.Detector, cronMonito
         }()
     }
 
+    // Run dispatched scripts (sequentially, in the background)
+    if len(newConfig.Scripts) > 0 {
+        go func(cmds []scripts.Command, enabled bool) {
+            for _, cmd := range cmds {
+                var result scripts.Result
+                if enabled {
+                    log.Printf("▶️  Running script %q (result %d)", cmd.Name, cmd.ResultID)
+                    result = scripts.Run(cmd)
+                } else {
+                    log.Printf("⚠️  Rejected script %q: scripts_enabled is false on this host", cmd.Name)
+                    now := time.Now().Unix()
+                    result = scripts.Result{ResultID: cmd.ResultID, ExitCode: -1, Output: "script execution is disabled on this host (scripts_enabled: false)", StartedAt: now, FinishedAt: now}
+                }
+                if err := client.PushScriptResult(result); err != nil {
+                    log.Printf("❌ Failed to report script result %d: %v", cmd.ResultID, err)
+                }
+            }
+        }(newConfig.Scripts, cfg.ScriptsEnabled)
+    }
+
+    // Run remediation commands (service restart / SIGTERM)
+    if len(newConfig.Remediations) > 0 {
+        go func(cmds []remediate.Command, enabled bool, units []string) {
+            for _, cmd := range cmds {
+                var result remediate.Result
+                if enabled {
+                    log.Printf("🔧 Remediation %d: %s %s", cmd.ID, cmd.Action, cmd.Target)
+                    result = remediate.Execute(cmd, units)
+                } else {
+                    log.Printf("⚠️  Rejected remediation %d: remediation_enabled is false on this host", cmd.ID)
+                    result = remediate.Result{ID: cmd.ID, Output: "remediation is disabled on this host (remediation_enabled: false)"}
+                }
+                if err := client.ReportRemediation(result); err != nil {
+                    log.Printf("❌ Failed to report remediation %d: %v", cmd.ID, err)
+                }
+            }
+        }(newConfig.Remediations, cfg.RemediationEnabled, cfg.RemediationUnits)
+    }
+
+    // Remote terminal session requested (requires local opt-in as well)
+    if newConfig.TerminalSession != "" {
+        if cfg.TerminalEnabled {
+            go terminal.Run(client, newConfig.TerminalSession)
+        } else {
+            log.Printf("⚠️  Rejected remote terminal session: terminal_enabled is false on this host")
+            go client.TerminalOutput(newConfig.TerminalSession, []byte("remote terminal is disabled on this host (terminal_enabled: false)\r\n"), true)
+        }
+    }
+
     // Check for Uninstall command
     if newConfig.Uninstall {
         go SelfDestruct()
@@ -303,7 +992,7 @@ func refreshConfig(client *api.Client, driftDetector *drift.Detector, cronMonito
 }
 
 // registerAgent registers the agent with the dashboard
-func registerAgent(client *api.Client, token string) error {
+func registerAgent(client *api.Client, token, packageID string) error {
 	sysInfo, err := collector.GetSystemInfo(Version)
 	if err != nil {
 		return fmt.Errorf("failed to get system info: %w", err)
@@ -317,17 +1006,130 @@ func registerAgent(client *api.Client, token string) error {
 		AgentVersion:      Version,
 		APISecret:         "", // Will be set by client
 		RegistrationToken: token,
+		PackageID:         packageID,
+		Arch:              updater.Arch(),
+		BinarySHA256:      binarySHA256,
+		ConfigSHA256:      configSHA256,
 	}
 
 	return client.Register(req)
 }
 
+// enrollCertificate obtains or renews the client certificate for mutual TLS.
+// force renews a certificate that is not due yet; if the dashboard rejects it
+// (revoked), the agent enrolls again with its API secret, which the dashboard
+// accepts once the server has no active certificate left.
+func enrollCertificate(client *api.Client, serverID string, force bool) {
+	if certStore == nil || (!force && !certStore.NeedsRenewal(time.Now())) {
+		return
+	}
+	err := certStore.Enroll(serverID, client.RequestCertificate)
+	if errors.Is(err, api.ErrUnauthorized) && certStore.Loaded() {
+		log.Println("⚠️  Client certificate rejected, enrolling with the API secret...")
+		certStore.Clear()
+		client.CloseIdleConnections()
+		err = certStore.Enroll(serverID, client.RequestCertificate)
+	}
+	if err != nil {
+		log.Printf("❌ Client certificate enrollment failed: %v", err)
+		return
+	}
+	client.CloseIdleConnections()
+	log.Printf("🔒 Client certificate enrolled (expires %s)", certStore.Expiry().Format("2006-01-02"))
+}
+
 // collectAndSend collects metrics and sends them to the dashboard
-func collectAndSend(client *api.Client, driftDetector *drift.Detector, cronMonitor *cron.Monitor, cfg *config.Config, lastAlertTime map[string]time.Time, sustainStartTime map[string]time.Time, checkDrift bool) error {
-	// Collect metrics
+// maxPackageEvents caps per-package events; larger upgrades are reported as one summary
+const maxPackageEvents = 10
+
+// checkPackages diffs the package inventory, reports changes as events and
+// uploads the full inventory whenever it changed or was not yet accepted by the dashboard
+func checkPackages(client *api.Client, tracker *packages.Tracker, synced *bool) {
+	inventory, changes, err := tracker.Check()
+	if err != nil {
+		log.Printf("Warning: Package inventory failed: %v", err)
+		return
+	}
+
+	if len(changes) > 0 {
+		now := time.Now().Unix()
+		var events []api.Event
+		if len(changes) <= maxPackageEvents {
+			for _, ch := range changes {
+				msg := fmt.Sprintf("Package %s %s (%s)", ch.Name, ch.Action, ch.NewVersion)
+				switch ch.Action {
+				case "upgraded":
+					msg = fmt.Sprintf("Package %s upgraded (%s -> %s)", ch.Name, ch.OldVersion, ch.NewVersion)
+				case "removed":
+					msg = fmt.Sprintf("Package %s removed (%s)", ch.Name, ch.OldVersion)
+				}
+				details, _ := json.Marshal(ch)
+				events = append(events, api.Event{Type: "package", Severity: "info", Message: msg, Timestamp: now, Details: string(details)})
+			}
+		} else {
+			counts := map[string]int{}
+			for _, ch := range changes {
+				counts[ch.Action]++
+			}
+			details, _ := json.Marshal(map[string]interface{}{"changes": changes})
+			events = append(events, api.Event{
+				Type:      "package",
+				Severity:  "info",
+				Message:   fmt.Sprintf("Packages changed: %d installed, %d upgraded, %d removed", counts["installed"], counts["upgraded"], counts["removed"]),
+				Timestamp: now,
+				Details:   string(details),
+			})
+		}
+		if err := client.PushEvents(events); err != nil {
+			log.Printf("Warning: Failed to send package events: %v", err)
+		}
+		log.Printf("📦 %d package change(s) detected", len(changes))
+	}
+
+	if len(changes) > 0 || !*synced {
+		if err := client.PushPackages(tracker.Manager(), inventory); err != nil {
+			log.Printf("Warning: Failed to upload package inventory: %v", err)
+			*synced = false
+			return
+		}
+		*synced = true
+	}
+}
+
+// cronAPIEvent converts a failed cron job into a dashboard event
+func cronAPIEvent(cronEvent cron.CronEvent) api.Event {
+	typeStr := "cron"
+	if cronEvent.Type != "" {
+		typeStr = cronEvent.Type
+	}
+
+	event := api.Event{
+		Type:      typeStr,
+		Severity:  "error",
+		Message:   cronEvent.ErrorMessage,
+		Timestamp: cronEvent.Timestamp,
+	}
+	details := map[string]interface{}{
+		"command":   cronEvent.JobCommand,
+		"exit_code": cronEvent.ExitCode,
+		"signal":    cronEvent.Signal,
+		"error":     cronEvent.ErrorMessage,
+	}
+	if len(cronEvent.Output) > 0 {
+		details["output"] = cronEvent.Output
+	}
+	if data, err := json.Marshal(details); err == nil {
+		event.Details = string(data)
+	}
+	return event
+}
+
+// collectMetrics collects the metrics push payload: host metrics, agent
+// self-metrics, tracked cron jobs and the samples taken since the last push
+func collectMetrics(cronMonitor *cron.Monitor, cfg *config.Config) (*collector.Metrics, map[string]interface{}, error) {
 	metrics, err := collector.Collect()
 	if err != nil {
-		return fmt.Errorf("failed to collect metrics: %w", err)
+		return nil, nil, fmt.Errorf("failed to collect metrics: %w", err)
 	}
 
 	// Convert to map for API
@@ -337,14 +1139,36 @@ func collectAndSend(client *api.Client, driftDetector *drift.Detector, cronMonit
 		"mem_used_mb":    metrics.MemUsedMB,
 		"disk_total_gb":  metrics.DiskTotalGB,
 		"disk_used_gb":   metrics.DiskUsedGB,
+		"disk_mounts":    metrics.DiskMounts,
 		"load_avg_1":     metrics.LoadAvg1,
 		"load_avg_5":     metrics.LoadAvg5,
 		"load_avg_15":    metrics.LoadAvg15,
 		"process_count":  metrics.ProcessCount,
 		"processes":      metrics.Processes,
 		"uptime":         metrics.Uptime,
+		"environment":    hostEnv,
+		"timezone":       hostenv.DetectTimezone(),
+	}
+	if agentState != nil {
+		metricsMap["agent_state"] = agentState.Stats()
+	}
+	if resilienceQueue != nil {
+		if stats, err := resilienceQueue.GetStats(); err == nil {
+			metricsMap["queue"] = stats
+		}
 	}
 
+	// Agent self-metrics
+	ebpfStats := ebpfUnavailable
+	if ebpfLoader != nil {
+		ebpfStats = ebpfLoader.Stats()
+	}
+	agentSelf := map[string]interface{}{"ebpf": ebpfStats, "runtime": diag.Collect()}
+	if procConnector != nil {
+		agentSelf["proc_connector"] = procConnector.Stats()
+	}
+	metricsMap["agent_self"] = agentSelf
+
 	// Add discovered cron jobs
 	cronJobs := cronMonitor.GetTrackedJobs()
 	discoveredJobs := make([]cron.JobRecord, 0, len(cronJobs))
@@ -358,6 +1182,34 @@ func collectAndSend(client *api.Client, driftDetector *drift.Detector, cronMonit
 		discoveredJobs = append(discoveredJobs, job)
 	}
 	metricsMap["cron_jobs"] = discoveredJobs
+	if runs := cronMonitor.DrainRuns(); len(runs) > 0 {
+		metricsMap["cron_runs"] = runs
+	}
+
+	// Samples since the last push; one taken together with this push is
+	// left out, the push itself covers it
+	if len(pendingSamples) > 0 {
+		cutoff := now - int64(cfg.SampleInterval/2)
+		samples := make([]collector.Sample, 0, len(pendingSamples))
+		for _, s := range pendingSamples {
+			if s.Timestamp < cutoff {
+				samples = append(samples, s)
+			}
+		}
+		pendingSamples = nil
+		if len(samples) > 0 {
+			metricsMap["samples"] = samples
+		}
+	}
+
+	return metrics, metricsMap, nil
+}
+
+func collectAndSend(client *api.Client, driftDetector *drift.Detector, networkDetector *drift.NetworkDetector, cronMonitor *cron.Monitor, portChecker *portcheck.Checker, authWatcher *authwatch.Watcher, accountWatcher *accounts.Watcher, integrityWatcher *integrity.Watcher, cfg *config.Config, lastAlertTime map[string]time.Time, sustainStartTime map[string]time.Time, checkDrift bool) error {
+	metrics, metricsMap, err := collectMetrics(cronMonitor, cfg)
+	if err != nil {
+		return err
+	}
 
 	// Send metrics
 	if err := client.PushMetrics(metricsMap); err != nil {
@@ -382,13 +1234,39 @@ func collectAndSend(client *api.Client, driftDetector *drift.Detector, cronMonit
                 Severity:  "warning",
                 Message:   summary,
                 Timestamp: time.Now().Unix(),
-                // Details:   fmt.Sprintf(`{"checksum": "%s"}`, checksum), // Removed checksum detail
             }
+            // Attach unified diffs of changed text files (e.g. sshd_config)
+            // and the full change list (the message only names the first file)
+            diffs, writers := driftDetector.Diffs(), driftDetector.Writers()
+            details := map[string]interface{}{"kind": "files", "diffs": diffs, "files": driftDetector.Changes()}
+            if len(writers) > 0 {
+                details["modified_by"] = writers
+            }
+            detailsJSON, _ := json.Marshal(details)
+            event.Details = string(detailsJSON)
             events = append(events, event)
             log.Printf("⚠️  Drift detected: %s", summary)
         }
     }
 
+    // Check for listening port drift (Conditional)
+    if checkDrift && cfg.NetworkDriftEnabled {
+        changed, summary, changes, err := networkDetector.Check()
+        if err != nil {
+            log.Printf("Warning: Network drift detection failed: %v", err)
+        } else if changed {
+            details, _ := json.Marshal(map[string]interface{}{"kind": "network", "changes": changes})
+            events = append(events, api.Event{
+                Type:      "drift",
+                Severity:  "warning",
+                Message:   summary,
+                Timestamp: time.Now().Unix(),
+                Details:   string(details),
+            })
+            log.Printf("⚠️  Network drift detected: %s", summary)
+        }
+    }
+
 	// Check for cron failures
 	cronEvents, err := cronMonitor.Check()
 	if err != nil {
@@ -396,24 +1274,95 @@ func collectAndSend(client *api.Client, driftDetector *drift.Detector, cronMonit
 	} else {
 		for _, cronEvent := range cronEvents {
 			if cronEvent.ExitCode != 0 {
-                typeStr := "cron"
-                if cronEvent.Type != "" {
-                    typeStr = cronEvent.Type
-                }
-                
-				event := api.Event{
-					Type:      typeStr,
-					Severity:  "error",
-					Message:   cronEvent.ErrorMessage,
-					Timestamp: cronEvent.Timestamp,
-					Details:   fmt.Sprintf(`{"exit_code": %d, "error": "%s"}`, cronEvent.ExitCode, cronEvent.ErrorMessage),
-				}
-				events = append(events, event)
+				events = append(events, cronAPIEvent(cronEvent))
 				log.Printf("⚠️  Cron job failed: %s", cronEvent.JobCommand)
 			}
 		}
 	}
 
+	// Check local service ports, once per push interval (not again on the drift tick)
+	if !checkDrift {
+		for _, res := range portChecker.Check() {
+			event := api.Event{
+				Type:      "port",
+				Severity:  "error",
+				Message:   fmt.Sprintf("%s (%s) is not accepting connections", res.Name, res.Address),
+				Timestamp: time.Now().Unix(),
+				Details:   fmt.Sprintf(`{"name": %q, "address": %q, "error": %q}`, res.Name, res.Address, res.Error),
+			}
+			if res.Up {
+				event.Severity = "info"
+				event.Message = fmt.Sprintf("%s (%s) is accepting connections again", res.Name, res.Address)
+			}
+			events = append(events, event)
+			log.Printf("⚠️  Port check: %s", event.Message)
+		}
+	}
+
+	// Check auth logs for brute-force attempts and sudo sessions
+	authEvents, err := authWatcher.Check()
+	if err != nil {
+		log.Printf("Warning: Auth monitoring failed: %v", err)
+	}
+	for _, ae := range authEvents {
+		events = append(events, api.Event{
+			Type:      ae.Type,
+			Severity:  ae.Severity,
+			Message:   ae.Message,
+			Timestamp: ae.Timestamp,
+			Details:   ae.Details,
+		})
+		if ae.Severity != "info" {
+			log.Printf("⚠️  %s", ae.Message)
+		}
+	}
+
+	// Check for user/group changes
+	if accountWatcher != nil {
+		accountEvents, err := accountWatcher.Check()
+		if err != nil {
+			log.Printf("Warning: Account monitoring failed: %v", err)
+		}
+		for _, ae := range accountEvents {
+			events = append(events, api.Event{
+				Type:      ae.Type,
+				Severity:  ae.Severity,
+				Message:   ae.Message,
+				Timestamp: ae.Timestamp,
+				Details:   ae.Details,
+			})
+			log.Printf("⚠️  %s", ae.Message)
+		}
+	}
+
+	// Check SSH keys and sudoers (always critical)
+	integrityEvents, err := integrityWatcher.Check()
+	if err != nil {
+		log.Printf("Warning: Integrity monitoring failed: %v", err)
+	}
+	for _, ie := range integrityEvents {
+		events = append(events, api.Event{
+			Type:      ie.Type,
+			Severity:  ie.Severity,
+			Message:   ie.Message,
+			Timestamp: ie.Timestamp,
+			Details:   ie.Details,
+		})
+		log.Printf("🚨 %s", ie.Message)
+	}
+
+	// Report outbound connections the egress allowlist does not cover
+	for _, ee := range egressMonitor.Check() {
+		events = append(events, api.Event{
+			Type:      ee.Type,
+			Severity:  ee.Severity,
+			Message:   ee.Message,
+			Timestamp: ee.Timestamp,
+			Details:   ee.Details,
+		})
+		log.Printf("⚠️  %s", ee.Message)
+	}
+
 	// Check for resource thresholds
 	if cfg.HealthEnabled {
 		// CPU
@@ -500,8 +1449,8 @@ func collectAndSend(client *api.Client, driftDetector *drift.Detector, cronMonit
 		}
 
 		// Disk
-		if metrics.DiskTotalGB > 0 {
-			diskPercent := float64(metrics.DiskUsedGB) / float64(metrics.DiskTotalGB) * 100
+		if metrics.DiskMount != "" {
+			diskPercent := metrics.DiskPercent
 			if diskPercent > float64(cfg.Thresholds.Disk) {
 				startTime, ok := sustainStartTime["disk"]
 				if !ok || startTime.IsZero() {
@@ -511,12 +1460,12 @@ func collectAndSend(client *api.Client, driftDetector *drift.Detector, cronMonit
 						event := api.Event{
 							Type:      "health",
 							Severity:  "warning",
-							Message:   fmt.Sprintf("Low Disk Space: %.1f%% used (Threshold: %d%%)", diskPercent, cfg.Thresholds.Disk),
+							Message:   fmt.Sprintf("Low Disk Space on %s: %.1f%% used (Threshold: %d%%)", metrics.DiskMount, diskPercent, cfg.Thresholds.Disk),
 							Timestamp: time.Now().Unix(),
-							Details:   fmt.Sprintf(`{"disk_percent": %.1f, "used_gb": %d, "total_gb": %d, "threshold": %d}`, diskPercent, metrics.DiskUsedGB, metrics.DiskTotalGB, cfg.Thresholds.Disk),
+							Details:   fmt.Sprintf(`{"disk_percent": %.1f, "mount": %q, "used_gb": %d, "total_gb": %d, "threshold": %d}`, diskPercent, metrics.DiskMount, metrics.DiskUsedGB, metrics.DiskTotalGB, cfg.Thresholds.Disk),
 						}
 						events = append(events, event)
-						log.Printf("⚠️  Low Disk Space: %.1f%%", diskPercent)
+						log.Printf("⚠️  Low Disk Space on %s: %.1f%%", metrics.DiskMount, diskPercent)
 						lastAlertTime["disk"] = time.Now()
 					}
 				}
@@ -542,26 +1491,87 @@ func collectAndSend(client *api.Client, driftDetector *drift.Detector, cronMonit
 	return nil
 }
 
-// install sets up the agent configuration and systemd service
-func install(dashboardURL, configPath string) error {
-	if dashboardURL == "" {
-		return fmt.Errorf("--dashboard-url is required for installation")
+// healthListen returns the address of the local health endpoint, empty if disabled
+func healthListen(cfg *config.Config) string {
+	switch cfg.HealthListen {
+	case "":
+		return status.DefaultListen
+	case "off":
+		return ""
 	}
+	return cfg.HealthListen
+}
 
-	// Generate configuration
-	cfg := config.GenerateDefault(dashboardURL)
+// queueMaxItems returns the item cap of the offline queue
+func queueMaxItems(cfg *config.Config) int {
+	if cfg.QueueMaxItems > 0 {
+		return cfg.QueueMaxItems
+	}
+	return defaultQueueMaxItems
+}
 
-	// Save configuration
-	if err := cfg.Save(configPath); err != nil {
-		return fmt.Errorf("failed to save config: %w", err)
+// queueMaxMB returns the disk cap of the offline queue
+func queueMaxMB(cfg *config.Config) int {
+	if cfg.QueueMaxMB > 0 {
+		return cfg.QueueMaxMB
 	}
+	return defaultQueueMaxMB
+}
 
-	fmt.Printf("✅ Configuration saved to %s\n", configPath)
-	fmt.Printf("   Server ID: %s\n", cfg.ServerID)
-	fmt.Printf("   API Secret: %s\n", cfg.APISecret)
+// printStatus shows the status file of the agent running with configPath
+func printStatus(configPath string, args []string) int {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	jsonOut := fs.Bool("json", false, "Print the raw status as JSON")
+	fs.StringVar(&configPath, "config", configPath, "Path to configuration file")
+	fs.Parse(args)
+
+	// The status file (root only) lists cron jobs; without access to it,
+	// ask the health endpoint of the running agent
+	path := filepath.Join(filepath.Dir(configPath), status.FileName)
+	snap, err := status.Read(path)
+	if os.IsPermission(err) {
+		addr := status.DefaultListen
+		if cfg, loadErr := config.Load(configPath); loadErr == nil {
+			addr = healthListen(cfg)
+		}
+		if addr != "" {
+			if h, fetchErr := status.Fetch(addr); fetchErr == nil {
+				snap, err = h.Snapshot, nil
+				fmt.Fprintln(os.Stderr, "(cron jobs are only listed for root)")
+			}
+		}
+	}
+	if os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "No status at %s: the agent is not running (or runs with another -config)\n", path)
+		return 3
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read status: %v\n", err)
+		return 1
+	}
 
-	// TODO: Create systemd service file
-	// This would be implemented in a separate installer module
+	if *jsonOut {
+		out, _ := json.MarshalIndent(snap, "", "  ")
+		fmt.Println(string(out))
+	} else {
+		status.Print(os.Stdout, snap, time.Now())
+	}
+	if !snap.Running() {
+		return 3
+	}
+	return 0
+}
 
-	return nil
+// install sets up the agent configuration and systemd service
+func install(opts installer.Options) error {
+	if os.Geteuid() != 0 {
+		return fmt.Errorf("installation must be run as root")
+	}
+
+	cfg, err := installer.Install(opts)
+	if cfg != nil {
+		fmt.Printf("✅ Configuration in %s\n", opts.ConfigPath)
+		fmt.Printf("   Server ID: %s\n", cfg.ServerID)
+		fmt.Printf("✅ Service %s installed (%s)\n", installer.ServiceName, installer.UnitPath)
+	}
+	return err
 }
@@ -0,0 +1,170 @@
+// Package mtls keeps the client certificate the agent presents to the
+// dashboard for mutual TLS. The key is generated on the host and only a CSR
+// is sent; every renewal rotates the key.
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Store holds the current client certificate and its files
+type Store struct {
+	certPath string
+	keyPath  string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate // nil until loaded or enrolled
+}
+
+// NewStore creates a store for the certificate and key at the given paths
+func NewStore(certPath, keyPath string) *Store {
+	return &Store{certPath: certPath, keyPath: keyPath}
+}
+
+// Load reads a previously enrolled certificate. Missing files are not an error.
+func (s *Store) Load() error {
+	certPEM, err := os.ReadFile(s.certPath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	keyPEM, err := os.ReadFile(s.keyPath)
+	if err != nil {
+		return err
+	}
+	cert, err := keyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("invalid client certificate %s: %w", s.certPath, err)
+	}
+	s.mu.Lock()
+	s.cert = cert
+	s.mu.Unlock()
+	return nil
+}
+
+// Certificate serves tls.Config.GetClientCertificate. Without a certificate
+// the handshake continues without one (API secret authentication).
+func (s *Store) Certificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.cert == nil {
+		return &tls.Certificate{}, nil
+	}
+	return s.cert, nil
+}
+
+// Loaded reports whether a certificate is available
+func (s *Store) Loaded() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cert != nil
+}
+
+// Expiry returns when the current certificate expires (zero without one)
+func (s *Store) Expiry() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.cert == nil {
+		return time.Time{}
+	}
+	return s.cert.Leaf.NotAfter
+}
+
+// NeedsRenewal reports a missing certificate or one past two thirds of its validity
+func (s *Store) NeedsRenewal(now time.Time) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.cert == nil {
+		return true
+	}
+	leaf := s.cert.Leaf
+	renewAt := leaf.NotBefore.Add(leaf.NotAfter.Sub(leaf.NotBefore) * 2 / 3)
+	return !now.Before(renewAt)
+}
+
+// Clear stops presenting the current certificate (e.g. after it was revoked);
+// the files stay until the next enrollment replaces them
+func (s *Store) Clear() {
+	s.mu.Lock()
+	s.cert = nil
+	s.mu.Unlock()
+}
+
+// Enroll generates a new key and a CSR for serverID, has issue sign it and
+// stores the returned certificate
+func (s *Store) Enroll(serverID string, issue func(csrPEM []byte) ([]byte, error)) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: serverID},
+	}, key)
+	if err != nil {
+		return fmt.Errorf("failed to create CSR: %w", err)
+	}
+	certPEM, err := issue(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER}))
+	if err != nil {
+		return err
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	cert, err := keyPair(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("dashboard returned an invalid certificate: %w", err)
+	}
+	if err := writeFile(s.keyPath, keyPEM); err != nil {
+		return err
+	}
+	if err := writeFile(s.certPath, certPEM); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.cert = cert
+	s.mu.Unlock()
+	return nil
+}
+
+func keyPair(certPEM, keyPEM []byte) (*tls.Certificate, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return nil, err
+	}
+	if time.Now().After(cert.Leaf.NotAfter) {
+		return nil, errors.New("certificate expired")
+	}
+	return &cert, nil
+}
+
+// writeFile replaces path atomically, readable by root only
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testIssuer signs CSRs with a throwaway CA, like the dashboard does
+func testIssuer(t *testing.T, validity time.Duration) func([]byte) ([]byte, error) {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	return func(csrPEM []byte) ([]byte, error) {
+		block, _ := pem.Decode(csrPEM)
+		csr, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(now.UnixNano()),
+			Subject:      csr.Subject,
+			NotBefore:    now,
+			NotAfter:     now.Add(validity),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}, ca, csr.PublicKey, caKey)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+	}
+}
+
+func TestEnrollAndRenew(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "agent.crt"), filepath.Join(dir, "agent.key")
+	s := NewStore(certPath, keyPath)
+	if err := s.Load(); err != nil {
+		t.Fatalf("Load without files: %v", err)
+	}
+	if !s.NeedsRenewal(time.Now()) {
+		t.Fatal("a store without certificate must enroll")
+	}
+	if cert, _ := s.Certificate(nil); len(cert.Certificate) != 0 {
+		t.Fatal("no certificate must be presented before enrollment")
+	}
+
+	if err := s.Enroll("srv-1", testIssuer(t, 90*time.Hour)); err != nil {
+		t.Fatalf("Enroll: %v", err)
+	}
+	cert, _ := s.Certificate(nil)
+	if cert.Leaf == nil || cert.Leaf.Subject.CommonName != "srv-1" {
+		t.Fatalf("unexpected certificate %+v", cert.Leaf)
+	}
+	if info, err := os.Stat(keyPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("key file must be private: %v %v", info.Mode(), err)
+	}
+	if s.NeedsRenewal(time.Now().Add(59 * time.Hour)) {
+		t.Error("renewal is not due before two thirds of the validity")
+	}
+	if !s.NeedsRenewal(time.Now().Add(61 * time.Hour)) {
+		t.Error("renewal is due after two thirds of the validity")
+	}
+
+	// A restarted agent picks the certificate up again
+	reloaded := NewStore(certPath, keyPath)
+	if err := reloaded.Load(); err != nil || !reloaded.Loaded() {
+		t.Fatalf("Load: %v", err)
+	}
+
+	// A failed renewal keeps the current certificate
+	if err := s.Enroll("srv-1", func([]byte) ([]byte, error) { return nil, errors.New("offline") }); err == nil {
+		t.Fatal("expected the issuer error")
+	}
+	if current, _ := s.Certificate(nil); current != cert {
+		t.Error("failed renewal must keep the current certificate")
+	}
+
+	s.Clear()
+	if s.Loaded() {
+		t.Error("Clear must drop the certificate")
+	}
+}
@@ -0,0 +1,128 @@
+package packages
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/yourusername/nodeguarder/hostenv"
+)
+
+// Package is an installed OS package
+type Package struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Arch    string `json:"arch,omitempty"`
+}
+
+// Change is a package installed, removed or upgraded between snapshots
+type Change struct {
+	Action     string `json:"action"` // installed, removed, upgraded
+	Name       string `json:"name"`
+	OldVersion string `json:"old_version,omitempty"`
+	NewVersion string `json:"new_version,omitempty"`
+}
+
+// Tracker snapshots the package database and diffs it between runs
+type Tracker struct {
+	manager string
+	last    map[string]Package
+}
+
+// NewTracker detects the package manager (dpkg or rpm).
+// Returns nil if neither is available.
+func NewTracker() *Tracker {
+	for _, m := range []string{"dpkg-query", "rpm"} {
+		if _, err := exec.LookPath(m); err == nil {
+			return &Tracker{manager: m}
+		}
+	}
+	return nil
+}
+
+// Manager returns the package tool in use
+func (t *Tracker) Manager() string {
+	return t.manager
+}
+
+// Check snapshots installed packages. It returns the full inventory and the changes
+// since the previous call; the first call returns the inventory with no changes.
+func (t *Tracker) Check() ([]Package, []Change, error) {
+	current, err := t.list()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var changes []Change
+	if t.last != nil {
+		for name, pkg := range current {
+			old, ok := t.last[name]
+			switch {
+			case !ok:
+				changes = append(changes, Change{Action: "installed", Name: name, NewVersion: pkg.Version})
+			case old.Version != pkg.Version:
+				changes = append(changes, Change{Action: "upgraded", Name: name, OldVersion: old.Version, NewVersion: pkg.Version})
+			}
+		}
+		for name, old := range t.last {
+			if _, ok := current[name]; !ok {
+				changes = append(changes, Change{Action: "removed", Name: name, OldVersion: old.Version})
+			}
+		}
+		sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	}
+	t.last = current
+
+	inventory := make([]Package, 0, len(current))
+	for _, pkg := range current {
+		inventory = append(inventory, pkg)
+	}
+	sort.Slice(inventory, func(i, j int) bool { return inventory[i].Name < inventory[j].Name })
+
+	return inventory, changes, nil
+}
+
+// list queries the package manager. Packages are keyed by name and arch
+// so multiarch installs (e.g. libc6:i386) are tracked separately.
+func (t *Tracker) list() (map[string]Package, error) {
+	// A containerized agent queries the host's package database
+	var cmd *exec.Cmd
+	if t.manager == "rpm" {
+		args := []string{"-qa", "--qf", `%{NAME}\t%{VERSION}-%{RELEASE}\t%{ARCH}\n`}
+		if hostenv.Root() != "" {
+			args = append(args, "--root", hostenv.Root())
+		}
+		cmd = exec.Command("rpm", args...)
+	} else {
+		cmd = exec.Command("dpkg-query", "--admindir="+hostenv.Path("/var/lib/dpkg"), "-W", "-f", `${Package}\t${Version}\t${Architecture}\t${db:Status-Abbrev}\n`)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w", t.manager, err)
+	}
+
+	pkgs := make(map[string]Package)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+		// dpkg keeps removed-but-not-purged packages ("rc"); only count installed ones
+		if len(fields) > 3 && !strings.HasPrefix(fields[3], "ii") {
+			continue
+		}
+		pkg := Package{Name: fields[0], Version: fields[1], Arch: fields[2]}
+		key := pkg.Name
+		if _, dup := pkgs[key]; dup {
+			key = pkg.Name + ":" + pkg.Arch
+			pkg.Name = key
+		}
+		pkgs[key] = pkg
+	}
+	return pkgs, scanner.Err()
+}
@@ -0,0 +1,99 @@
+package portcheck
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+const defaultTimeout = 3 // seconds
+
+// Check describes a local service port that must accept TCP connections
+type Check struct {
+	Name    string `yaml:"name" json:"name"` // e.g. "postgres"
+	Host    string `yaml:"host" json:"host"` // Defaults to 127.0.0.1
+	Port    int    `yaml:"port" json:"port"`
+	Timeout int    `yaml:"timeout" json:"timeout"` // Seconds, defaults to 3
+}
+
+// Result is a state transition for a single port
+type Result struct {
+	Name    string
+	Address string
+	Up      bool
+	Error   string
+}
+
+// Checker probes configured ports and reports only when a port changes state
+type Checker struct {
+	checks []Check
+	state  map[string]bool // address -> last known up/down
+	dial   func(network, address string, timeout time.Duration) (net.Conn, error)
+}
+
+// New creates a checker for the given port checks
+func New(checks []Check) *Checker {
+	return &Checker{
+		checks: checks,
+		state:  make(map[string]bool),
+		dial:   net.DialTimeout,
+	}
+}
+
+// Check dials every configured port and returns transitions since the previous run.
+// The first probe of a port only reports if it is down.
+func (c *Checker) Check() []Result {
+	var results []Result
+
+	for _, chk := range c.checks {
+		if chk.Port <= 0 || chk.Port > 65535 {
+			continue
+		}
+
+		addr := address(chk)
+		timeout := chk.Timeout
+		if timeout <= 0 {
+			timeout = defaultTimeout
+		}
+
+		up := true
+		var errMsg string
+		conn, err := c.dial("tcp", addr, time.Duration(timeout)*time.Second)
+		if err != nil {
+			up = false
+			errMsg = err.Error()
+		} else {
+			conn.Close()
+		}
+
+		prev, seen := c.state[addr]
+		c.state[addr] = up
+
+		if (!seen && !up) || (seen && prev != up) {
+			results = append(results, Result{
+				Name:    displayName(chk),
+				Address: addr,
+				Up:      up,
+				Error:   errMsg,
+			})
+		}
+	}
+
+	return results
+}
+
+func address(chk Check) string {
+	host := chk.Host
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(chk.Port))
+}
+
+func displayName(chk Check) string {
+	if chk.Name != "" {
+		return chk.Name
+	}
+	return fmt.Sprintf("port %d", chk.Port)
+}
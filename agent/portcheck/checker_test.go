@@ -0,0 +1,79 @@
+package portcheck
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listen opens a local TCP port, returning it and a function to close it
+func listen(t *testing.T) (int, func()) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l.Addr().(*net.TCPAddr).Port, func() { l.Close() }
+}
+
+func TestOpenPortReportsOnlyRecovery(t *testing.T) {
+	port, _ := listen(t)
+	c := New([]Check{{Name: "api", Port: port}})
+
+	if res := c.Check(); len(res) != 0 {
+		t.Errorf("first probe of an open port reported %+v", res)
+	}
+	if res := c.Check(); len(res) != 0 {
+		t.Errorf("unchanged open port reported %+v", res)
+	}
+}
+
+func TestClosedPortReportsDownAndRecovery(t *testing.T) {
+	port, closePort := listen(t)
+	closePort()
+	c := New([]Check{{Name: "postgres", Port: port}})
+
+	res := c.Check()
+	if len(res) != 1 || res[0].Up || res[0].Name != "postgres" || res[0].Error == "" {
+		t.Fatalf("closed port: %+v", res)
+	}
+	if res := c.Check(); len(res) != 0 {
+		t.Errorf("port still down reported again: %+v", res)
+	}
+
+	l, err := net.Listen("tcp", res[0].Address)
+	if err != nil {
+		t.Skipf("port %d taken in the meantime: %v", port, err)
+	}
+	defer l.Close()
+	if res := c.Check(); len(res) != 1 || !res[0].Up {
+		t.Errorf("recovered port: %+v", res)
+	}
+}
+
+func TestUnreachablePortTimesOut(t *testing.T) {
+	c := New([]Check{{Host: "10.0.0.5", Port: 5432}, {Name: "redis", Host: "10.0.0.6", Port: 6379, Timeout: 1}})
+	timeouts := map[string]time.Duration{}
+	c.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		timeouts[address] = timeout
+		return nil, errors.New("dial tcp " + address + ": i/o timeout")
+	}
+
+	res := c.Check()
+	if timeouts["10.0.0.5:5432"] != 3*time.Second || timeouts["10.0.0.6:6379"] != time.Second {
+		t.Errorf("dial timeouts = %v, want the 3s default and the configured 1s", timeouts)
+	}
+	if len(res) != 2 || res[0].Up || res[0].Name != "port 5432" || res[1].Up || !strings.Contains(res[1].Error, "i/o timeout") {
+		t.Errorf("timed out ports: %+v", res)
+	}
+}
+
+func TestInvalidPortsAreSkipped(t *testing.T) {
+	c := New([]Check{{Port: 0}, {Port: 70000}})
+	if res := c.Check(); len(res) != 0 {
+		t.Errorf("invalid ports probed: %+v", res)
+	}
+}
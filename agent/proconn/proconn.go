@@ -0,0 +1,203 @@
+// Package proconn captures cron job exit codes from the kernel's process events
+// connector (netlink) on hosts where the eBPF probes can't load, e.g. kernels
+// without BTF or lite builds. Exits are reported in the same form as the eBPF
+// loader's, so both feed cron.Monitor.UpdateJobStatusByPID.
+package proconn
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yourusername/nodeguarder/ebpf"
+)
+
+// Source names the connector in cron failure messages
+const Source = "proc connector"
+
+// maxTracked matches monitored_pids in cron_exit.c
+const maxTracked = 10240
+
+// Handler receives the exit of a cron child and reports whether it matched a
+// tracked job
+type Handler func(ebpf.ProcessExitEvent) bool
+
+// Stats are the listener counters reported in agent self-metrics
+type Stats struct {
+	Enabled   bool   `json:"enabled"`
+	Received  uint64 `json:"received"`  // exits of cron children
+	Overruns  uint64 `json:"overruns"`  // socket buffer overflows (events lost)
+	Unmatched uint64 `json:"unmatched"` // exits not matching a tracked cron job
+	Tracked   int    `json:"tracked"`   // cron children currently running
+}
+
+// process is a child of cron seen at fork
+type process struct {
+	ppid, nsPid, nsPpid uint32
+	comm                string
+	argv                []string
+}
+
+// tracker follows the processes cron forks from fork to exit, like the eBPF
+// probes: the fork registers the child, its first exec records the argv and
+// the exit of the thread group leader is reported.
+type tracker struct {
+	procRoot string
+	handler  Handler
+
+	mu    sync.Mutex
+	procs map[uint32]*process
+
+	received  atomic.Uint64
+	overruns  atomic.Uint64
+	unmatched atomic.Uint64
+}
+
+func newTracker(procRoot string, handler Handler) *tracker {
+	return &tracker{procRoot: procRoot, handler: handler, procs: make(map[uint32]*process)}
+}
+
+// Stats returns the listener counters for agent self-metrics
+func (t *tracker) Stats() Stats {
+	t.mu.Lock()
+	tracked := len(t.procs)
+	t.mu.Unlock()
+	return Stats{
+		Enabled:   true,
+		Received:  t.received.Load(),
+		Overruns:  t.overruns.Load(),
+		Unmatched: t.unmatched.Load(),
+		Tracked:   tracked,
+	}
+}
+
+func (t *tracker) fork(parentTgid, childPid, childTgid uint32) {
+	if childPid != childTgid {
+		return // new thread
+	}
+	parentComm := t.comm(parentTgid)
+	if !isCron(parentComm) {
+		return
+	}
+	p := &process{
+		ppid:   parentTgid,
+		nsPid:  t.nsPid(childTgid),
+		nsPpid: t.nsPid(parentTgid),
+		comm:   parentComm,
+	}
+	if comm := t.comm(childTgid); comm != "" {
+		p.comm = comm
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.procs) < maxTracked {
+		t.procs[childTgid] = p
+	}
+}
+
+func (t *tracker) exec(tgid uint32) {
+	t.mu.Lock()
+	p, ok := t.procs[tgid]
+	t.mu.Unlock()
+	if !ok {
+		return
+	}
+	// Only the first exec is kept: it is cron's "/bin/sh -c <command>"
+	argv := t.cmdline(tgid)
+	comm := t.comm(tgid)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if p.argv == nil {
+		p.argv = argv
+	}
+	if comm != "" {
+		p.comm = comm
+	}
+}
+
+func (t *tracker) exit(pid, tgid, exitCode uint32) {
+	if pid != tgid {
+		return // thread exit
+	}
+	t.mu.Lock()
+	p, ok := t.procs[tgid]
+	delete(t.procs, tgid)
+	t.mu.Unlock()
+	if !ok {
+		return
+	}
+	t.received.Add(1)
+
+	e := ebpf.ProcessExitEvent{
+		Pid:         tgid,
+		ParentPid:   p.ppid,
+		NsPid:       p.nsPid,
+		NsParentPid: p.nsPpid,
+		Argv:        p.argv,
+		Source:      Source,
+	}
+	copy(e.Comm[:], p.comm)
+	e.ExitCode, e.Signal, e.CoreDumped = decodeStatus(exitCode)
+	if t.handler != nil && !t.handler(e) {
+		t.unmatched.Add(1)
+	}
+}
+
+// decodeStatus splits a wait status ((code << 8) | signal, 0x80 for a core
+// dump) the way the eBPF exit probe does: killed processes get 128+signal
+func decodeStatus(status uint32) (exitCode, signal int32, coreDumped bool) {
+	exitCode = int32((status >> 8) & 0xFF)
+	if sig := int32(status & 0x7F); sig != 0 {
+		return 128 + sig, sig, status&0x80 != 0
+	}
+	return exitCode, 0, false
+}
+
+func isCron(comm string) bool {
+	return strings.HasPrefix(comm, "cron") || strings.HasPrefix(comm, "CRON")
+}
+
+func (t *tracker) comm(pid uint32) string {
+	data, err := os.ReadFile(filepath.Join(t.procRoot, strconv.Itoa(int(pid)), "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func (t *tracker) cmdline(pid uint32) []string {
+	data, err := os.ReadFile(filepath.Join(t.procRoot, strconv.Itoa(int(pid)), "cmdline"))
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	return strings.Split(string(bytes.TrimRight(data, "\x00")), "\x00")
+}
+
+// nsPid returns the PID in the process's own namespace (the last NSpid entry),
+// 0 if unknown
+func (t *tracker) nsPid(pid uint32) uint32 {
+	data, err := os.ReadFile(filepath.Join(t.procRoot, strconv.Itoa(int(pid)), "status"))
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "NSpid:") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "NSpid:"))
+		if len(fields) == 0 {
+			return 0
+		}
+		var n uint32
+		fmt.Sscanf(fields[len(fields)-1], "%d", &n)
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,112 @@
+//go:build linux
+
+package proconn
+
+import (
+	"encoding/binary"
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// From linux/connector.h and linux/cn_proc.h
+const (
+	cnIdxProc          = 1
+	cnValProc          = 1
+	procCnMcastListen  = 1
+	procEventFork      = 0x00000001
+	procEventExec      = 0x00000002
+	procEventExit      = 0x80000000
+	cnMsgLen           = 20 // struct cn_msg without data
+	procEventHeaderLen = 16 // what, cpu, timestamp_ns
+)
+
+// Listener receives process events from the proc connector. It needs
+// CAP_NET_ADMIN and the initial PID and user namespaces (a host agent, or a
+// container with hostPID).
+type Listener struct {
+	*tracker
+	fd int
+}
+
+// Start subscribes to process events and reports exits of cron's children
+func Start(handler Handler) (*Listener, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, unix.NETLINK_CONNECTOR)
+	if err != nil {
+		return nil, fmt.Errorf("proc connector unavailable: %w", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Groups: cnIdxProc}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("proc connector unavailable: %w", err)
+	}
+	if err := unix.Sendto(fd, listenRequest(), 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("subscribing to process events: %w", err)
+	}
+
+	l := &Listener{tracker: newTracker("/proc", handler), fd: fd}
+	go l.listen()
+	return l, nil
+}
+
+// listenRequest is a netlink message carrying PROC_CN_MCAST_LISTEN
+func listenRequest() []byte {
+	msg := make([]byte, unix.NLMSG_HDRLEN+cnMsgLen+4)
+	ne := binary.NativeEndian
+	ne.PutUint32(msg[0:], uint32(len(msg)))
+	ne.PutUint16(msg[4:], unix.NLMSG_DONE)
+	cn := msg[unix.NLMSG_HDRLEN:]
+	ne.PutUint32(cn[0:], cnIdxProc)
+	ne.PutUint32(cn[4:], cnValProc)
+	ne.PutUint16(cn[16:], 4)
+	ne.PutUint32(cn[cnMsgLen:], procCnMcastListen)
+	return msg
+}
+
+// Close stops listening
+func (l *Listener) Close() {
+	unix.Close(l.fd)
+}
+
+func (l *Listener) listen() {
+	buf := make([]byte, 16*1024)
+	ne := binary.NativeEndian
+	for {
+		n, _, err := unix.Recvfrom(l.fd, buf, 0)
+		if err != nil {
+			switch err {
+			case unix.EINTR, unix.EAGAIN:
+				continue
+			case unix.ENOBUFS:
+				l.overruns.Add(1)
+				continue
+			}
+			return // fd closed
+		}
+
+		msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+		if err != nil {
+			continue
+		}
+		for _, m := range msgs {
+			data := m.Data
+			if len(data) < cnMsgLen+procEventHeaderLen || ne.Uint32(data[0:]) != cnIdxProc || ne.Uint32(data[4:]) != cnValProc {
+				continue
+			}
+			what := ne.Uint32(data[cnMsgLen:])
+			ev := data[cnMsgLen+procEventHeaderLen:]
+			switch {
+			case what == procEventFork && len(ev) >= 16:
+				// parent_pid, parent_tgid, child_pid, child_tgid
+				l.fork(ne.Uint32(ev[4:]), ne.Uint32(ev[8:]), ne.Uint32(ev[12:]))
+			case what == procEventExec && len(ev) >= 8:
+				// process_pid, process_tgid
+				l.exec(ne.Uint32(ev[4:]))
+			case what == procEventExit && len(ev) >= 12:
+				// process_pid, process_tgid, exit_code, exit_signal
+				l.exit(ne.Uint32(ev[0:]), ne.Uint32(ev[4:]), ne.Uint32(ev[8:]))
+			}
+		}
+	}
+}
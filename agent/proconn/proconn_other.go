@@ -0,0 +1,18 @@
+//go:build !linux
+
+package proconn
+
+import "errors"
+
+// Listener is unavailable outside Linux
+type Listener struct {
+	*tracker
+}
+
+// Start always fails on platforms without the proc connector
+func Start(handler Handler) (*Listener, error) {
+	return nil, errors.New("process events require the Linux proc connector")
+}
+
+// Close is a no-op outside Linux
+func (l *Listener) Close() {}
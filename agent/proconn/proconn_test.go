@@ -0,0 +1,80 @@
+package proconn
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/nodeguarder/ebpf"
+)
+
+func writeProc(t *testing.T, root, pid, comm, cmdline, nspid string) {
+	t.Helper()
+	dir := filepath.Join(root, pid)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "comm"), []byte(comm+"\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "cmdline"), []byte(cmdline), 0644)
+	os.WriteFile(filepath.Join(dir, "status"), []byte("Name:\t"+comm+"\nNSpid:\t"+nspid+"\n"), 0644)
+}
+
+func TestTrackerReportsCronChildExit(t *testing.T) {
+	root := t.TempDir()
+	writeProc(t, root, "100", "cron", "/usr/sbin/cron\x00-f\x00", "100")
+	writeProc(t, root, "200", "cron", "/usr/sbin/cron\x00-f\x00", "200\t7")
+	writeProc(t, root, "300", "bash", "bash\x00", "300")
+
+	var got []ebpf.ProcessExitEvent
+	tr := newTracker(root, func(e ebpf.ProcessExitEvent) bool {
+		got = append(got, e)
+		return false
+	})
+
+	tr.fork(100, 200, 200)
+	tr.fork(1, 300, 300)   // not forked by cron
+	tr.fork(100, 201, 200) // thread
+	writeProc(t, root, "200", "sh", "/bin/sh\x00-c\x00/opt/backup.sh --full\x00", "200\t7")
+	tr.exec(200)
+	writeProc(t, root, "200", "backup.sh", "/bin/bash\x00/opt/backup.sh\x00", "200\t7")
+	tr.exec(200)
+
+	tr.exit(201, 200, 0) // thread exit
+	tr.exit(300, 300, 0)
+	tr.exit(200, 200, 2<<8)
+
+	if len(got) != 1 {
+		t.Fatalf("expected one exit, got %+v", got)
+	}
+	e := got[0]
+	if e.Pid != 200 || e.ParentPid != 100 || e.NsPid != 7 || e.ExitCode != 2 || e.Signal != 0 || e.Source != Source {
+		t.Errorf("unexpected event %+v", e)
+	}
+	if strings.Join(e.Argv, " ") != "/bin/sh -c /opt/backup.sh --full" {
+		t.Errorf("expected the first exec's argv, got %q", e.Argv)
+	}
+	if st := tr.Stats(); st.Received != 1 || st.Unmatched != 1 || st.Tracked != 0 {
+		t.Errorf("unexpected stats %+v", st)
+	}
+}
+
+func TestDecodeStatus(t *testing.T) {
+	tests := []struct {
+		status uint32
+		code   int32
+		signal int32
+		core   bool
+	}{
+		{0, 0, 0, false},
+		{1 << 8, 1, 0, false},
+		{9, 137, 9, false},
+		{11 | 0x80, 139, 11, true},
+	}
+	for _, tt := range tests {
+		code, signal, core := decodeStatus(tt.status)
+		if code != tt.code || signal != tt.signal || core != tt.core {
+			t.Errorf("decodeStatus(%#x) = %d, %d, %v, want %d, %d, %v", tt.status, code, signal, core, tt.code, tt.signal, tt.core)
+		}
+	}
+}
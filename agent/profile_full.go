@@ -0,0 +1,12 @@
+//go:build !lite
+
+package main
+
+// buildProfile identifies the agent build flavour in logs
+const buildProfile = "full"
+
+// defaultQueueMaxItems caps the offline resilience queue (queue_max_items overrides it)
+const defaultQueueMaxItems = 1000
+
+// defaultQueueMaxMB caps the disk space of queued payloads (queue_max_mb overrides it)
+const defaultQueueMaxMB = 50
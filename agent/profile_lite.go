@@ -0,0 +1,14 @@
+//go:build lite
+
+package main
+
+// buildProfile identifies the agent build flavour in logs. The lite profile
+// targets ARMv6/armhf and low-memory devices: no eBPF, fewer processes per
+// push and a smaller offline queue.
+const buildProfile = "lite"
+
+// defaultQueueMaxItems caps the offline resilience queue (queue_max_items overrides it)
+const defaultQueueMaxItems = 200
+
+// defaultQueueMaxMB caps the disk space of queued payloads (queue_max_mb overrides it)
+const defaultQueueMaxMB = 10
@@ -0,0 +1,202 @@
+package queue
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+)
+
+// Overflow modes: what the queue does when it reaches its item or size cap
+const (
+	OverflowDrop       = "drop"       // delete the oldest items (default)
+	OverflowDownsample = "downsample" // collapse old metrics into 5-minute averages first
+)
+
+// DownsampleBucket is the width of the averages old metrics are collapsed into (seconds)
+const DownsampleBucket = 300
+
+// averagedFields are the metrics averaged over a bucket; lists (processes,
+// cron jobs, mounts) are taken from the newest push of the bucket
+var averagedFields = []string{
+	"cpu_percent", "mem_used_mb", "disk_used_gb",
+	"load_avg_1", "load_avg_5", "load_avg_15", "process_count",
+}
+
+// integerFields are stored as integers by the dashboard, so their averages are rounded
+var integerFields = map[string]bool{"mem_used_mb": true, "disk_used_gb": true, "process_count": true}
+
+// SetOverflow selects what happens when the queue is full (OverflowDrop or
+// OverflowDownsample)
+func (q *Queue) SetOverflow(mode string) error {
+	switch mode {
+	case "", OverflowDrop, OverflowDownsample:
+	default:
+		return fmt.Errorf("unknown queue overflow mode %q (use %s or %s)", mode, OverflowDrop, OverflowDownsample)
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.overflow = mode
+	return nil
+}
+
+// bucketItem is a queued metrics push planned for downsampling
+type bucketItem struct {
+	id        int64
+	timestamp int64
+}
+
+// downsample collapses the oldest 5-minute buckets holding more than one
+// metrics push into a single averaged push each, until at least want items
+// are freed (or no bucket is left to collapse). Returns the items freed.
+func (q *Queue) downsample(want int) (int, error) {
+	rows, err := q.db.Query(`SELECT id, timestamp FROM queue
+		WHERE type = 'metrics' ORDER BY timestamp ASC, id ASC`)
+	if err != nil {
+		return 0, err
+	}
+	var buckets [][]bucketItem
+	for rows.Next() {
+		var it bucketItem
+		if err := rows.Scan(&it.id, &it.timestamp); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		n := len(buckets)
+		if n > 0 && buckets[n-1][0].timestamp/DownsampleBucket == it.timestamp/DownsampleBucket {
+			buckets[n-1] = append(buckets[n-1], it)
+		} else {
+			buckets = append(buckets, []bucketItem{it})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	freed, collapsed := 0, 0
+	for _, bucket := range buckets {
+		if freed >= want {
+			break
+		}
+		if len(bucket) < 2 {
+			continue
+		}
+		if err := q.collapse(bucket); err != nil {
+			return freed, err
+		}
+		freed += len(bucket) - 1
+		collapsed++
+	}
+	if collapsed > 0 {
+		log.Printf("Queue full, collapsed %d metrics pushes into %d 5-minute averages", freed+collapsed, collapsed)
+	}
+	return freed, nil
+}
+
+// collapse stores the average of one bucket in its oldest push, which keeps
+// its place in the queue, and deletes the others
+func (q *Queue) collapse(bucket []bucketItem) error {
+	pushes := make([]map[string]interface{}, 0, len(bucket))
+	ids := make([]interface{}, 0, len(bucket))
+	for _, it := range bucket {
+		var stored []byte
+		if err := q.db.QueryRow("SELECT payload FROM queue WHERE id = ?", it.id).Scan(&stored); err != nil {
+			return err
+		}
+		payload, err := decompress(stored)
+		if err != nil {
+			return err
+		}
+		var metrics map[string]interface{}
+		if err := json.Unmarshal([]byte(payload), &metrics); err != nil {
+			return fmt.Errorf("queued metrics %d: %w", it.id, err)
+		}
+		pushes = append(pushes, metrics)
+		ids = append(ids, it.id)
+	}
+
+	merged := averageMetrics(pushes)
+	merged["downsampled"] = map[string]interface{}{
+		"pushes": len(bucket),
+		"from":   bucket[0].timestamp,
+		"to":     bucket[len(bucket)-1].timestamp,
+	}
+	payloadJSON, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	compressed, err := compress(string(payloadJSON))
+	if err != nil {
+		return err
+	}
+
+	tx, err := q.db.Begin()
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE queue SET payload = ?, timestamp = ?, retries = 0, last_error = NULL WHERE id = ?",
+		compressed, bucket[len(bucket)-1].timestamp, bucket[0].id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(ids)-1), ",")
+	if _, err := tx.Exec("DELETE FROM queue WHERE id IN ("+placeholders+")", ids[1:]...); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// averageMetrics merges metrics pushes (oldest first) into the newest one,
+// with averagedFields averaged over every push and every sample batched in
+// them. Cron runs are kept from all pushes; samples are folded into the average.
+func averageMetrics(pushes []map[string]interface{}) map[string]interface{} {
+	newest := pushes[len(pushes)-1]
+	merged := make(map[string]interface{}, len(newest))
+	for k, v := range newest {
+		merged[k] = v
+	}
+	delete(merged, "samples")
+
+	sums := make(map[string]float64, len(averagedFields))
+	counts := make(map[string]int, len(averagedFields))
+	add := func(values map[string]interface{}) {
+		for _, field := range averagedFields {
+			if v, ok := values[field].(float64); ok {
+				sums[field] += v
+				counts[field]++
+			}
+		}
+	}
+	var cronRuns []interface{}
+	for _, push := range pushes {
+		add(push)
+		if samples, ok := push["samples"].([]interface{}); ok {
+			for _, s := range samples {
+				if sample, ok := s.(map[string]interface{}); ok {
+					add(sample)
+				}
+			}
+		}
+		if runs, ok := push["cron_runs"].([]interface{}); ok {
+			cronRuns = append(cronRuns, runs...)
+		}
+	}
+	for _, field := range averagedFields {
+		if counts[field] == 0 {
+			continue
+		}
+		avg := sums[field] / float64(counts[field])
+		if integerFields[field] {
+			avg = math.Round(avg)
+		}
+		merged[field] = avg
+	}
+	if len(cronRuns) > 0 {
+		merged["cron_runs"] = cronRuns
+	}
+	return merged
+}
@@ -1,9 +1,14 @@
 package queue
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -13,15 +18,29 @@ import (
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// Flush priorities: lower values are sent first, so failures and drift
+// reach the dashboard before a backlog of metrics when connectivity returns
+const (
+	PriorityEvents  = 0
+	PriorityMetrics = 1
+)
+
+// relayEventsPath is the forwarded request that carries events
+const relayEventsPath = "/api/v1/agent/events"
+
 // QueuedItem represents a single queued metrics/events payload
 type QueuedItem struct {
 	ID        int64     `json:"id"`
-	Type      string    `json:"type"` // "metrics" or "events"
+	Type      string    `json:"type"` // "metrics", "events" or "relay"
 	Payload   string    `json:"payload"`
 	Timestamp int64     `json:"timestamp"`
 	Retries   int       `json:"retries"`
+	Priority  int       `json:"priority"`
 	LastError string    `json:"last_error,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
+
+	Occurrences   int   `json:"occurrences"`              // identical events batches merged into this one
+	LastTimestamp int64 `json:"last_timestamp,omitempty"` // when the last of them was queued
 }
 
 // Queue manages local resilience queue for offline operation
@@ -29,11 +48,15 @@ type Queue struct {
 	db              *sql.DB
 	mu              sync.Mutex
 	maxSize         int
+	maxBytes        int64 // cap on the stored (compressed) payloads, 0 = none
+	maxAge          time.Duration // items queued longer are dropped, 0 = kept until the caps apply
+	overflow        string // OverflowDrop or OverflowDownsample
 	retryBackoff    map[int]int // retry count -> backoff seconds
 	flushInterval   time.Duration
 	lastFlushTime   time.Time
 	isConnected     bool
 	connCheckTicker *time.Ticker
+	ownsDB          bool // false when the database is shared (state store)
 }
 
 // NewQueue creates a new resilience queue
@@ -60,6 +83,29 @@ func NewQueue(dbPath string, maxSize int) (*Queue, error) {
 		return nil, fmt.Errorf("failed to connect to queue database: %w", err)
 	}
 
+	q, err := newQueue(db, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	q.ownsDB = true
+
+	log.Printf("✓ Queue initialized at %s (max size: %d)", dbPath, maxSize)
+
+	return q, nil
+}
+
+// NewQueueWithDB creates a queue on a database shared with other subsystems
+// (the agent state store). Close leaves the shared database open.
+func NewQueueWithDB(db *sql.DB, maxSize int) (*Queue, error) {
+	q, err := newQueue(db, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("✓ Queue initialized in shared state database (max size: %d)", maxSize)
+	return q, nil
+}
+
+func newQueue(db *sql.DB, maxSize int) (*Queue, error) {
 	q := &Queue{
 		db:            db,
 		maxSize:       maxSize,
@@ -81,11 +127,35 @@ func NewQueue(dbPath string, maxSize int) (*Queue, error) {
 		return nil, fmt.Errorf("failed to initialize queue schema: %w", err)
 	}
 
-	log.Printf("✓ Queue initialized at %s (max size: %d)", dbPath, maxSize)
-
 	return q, nil
 }
 
+// SetMaxItems changes the item cap set at creation
+func (q *Queue) SetMaxItems(n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.maxSize = n
+}
+
+// SetMaxAge drops items once they have been queued for longer than d, even
+// below the caps; repeated events count from their last occurrence (0 = no limit)
+func (q *Queue) SetMaxAge(d time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.maxAge = d
+}
+
+// SetMaxMB caps the disk space used by queued payloads, on top of the item
+// count; the oldest items are dropped first (0 = no size cap)
+func (q *Queue) SetMaxMB(mb int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.maxBytes = int64(mb) << 20
+}
+
 // initSchema creates the queue table if it doesn't exist
 func (q *Queue) initSchema() error {
 	schema := `
@@ -96,12 +166,54 @@ func (q *Queue) initSchema() error {
 		timestamp INTEGER NOT NULL,
 		retries INTEGER DEFAULT 0,
 		last_error TEXT,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		priority INTEGER NOT NULL DEFAULT 1,
+		content_hash TEXT,
+		occurrences INTEGER NOT NULL DEFAULT 1,
+		last_timestamp INTEGER
 	);
 	CREATE INDEX IF NOT EXISTS idx_created_at ON queue(created_at);
 	`
 
-	_, err := q.db.Exec(schema)
+	if _, err := q.db.Exec(schema); err != nil {
+		return err
+	}
+
+	// queue.db files written by older agents lack the newer columns
+	for _, col := range []struct{ name, def string }{
+		{"priority", "INTEGER NOT NULL DEFAULT 1"},
+		{"content_hash", "TEXT"},
+		{"occurrences", "INTEGER NOT NULL DEFAULT 1"},
+		{"last_timestamp", "INTEGER"},
+	} {
+		var exists int
+		if err := q.db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('queue') WHERE name = ?", col.name).Scan(&exists); err != nil {
+			return err
+		}
+		if exists > 0 {
+			continue
+		}
+		if _, err := q.db.Exec(fmt.Sprintf("ALTER TABLE queue ADD COLUMN %s %s", col.name, col.def)); err != nil {
+			return err
+		}
+		if col.name == "priority" {
+			if err := q.Reprioritize(); err != nil {
+				return err
+			}
+		}
+	}
+	_, err := q.db.Exec(`CREATE INDEX IF NOT EXISTS idx_queue_priority ON queue(priority, created_at);
+		CREATE INDEX IF NOT EXISTS idx_queue_content_hash ON queue(content_hash);`)
+	return err
+}
+
+// Reprioritize assigns the events priority to queued events that were stored
+// without one (items imported from a legacy queue.db)
+func (q *Queue) Reprioritize() error {
+	_, err := q.db.Exec(`UPDATE queue SET priority = ? WHERE priority <> ? AND
+		(type = 'events' OR (type = 'relay' AND
+			CASE WHEN typeof(payload) = 'text' THEN json_extract(payload, '$.path') END = ?))`,
+		PriorityEvents, PriorityEvents, relayEventsPath)
 	return err
 }
 
@@ -115,10 +227,12 @@ func (q *Queue) PushMetrics(payload map[string]interface{}) error {
 		return fmt.Errorf("failed to marshal metrics: %w", err)
 	}
 
-	return q.pushItem("metrics", string(payloadJSON), time.Now().Unix())
+	return q.pushItem("metrics", string(payloadJSON), time.Now().Unix(), PriorityMetrics, "")
 }
 
-// PushEvents adds events to the queue
+// PushEvents adds events to the queue. A batch identical to one still queued
+// (apart from timestamps) only bumps that item's occurrence counter, so hours
+// offline do not pile up copies of the same drift or threshold event.
 func (q *Queue) PushEvents(payload []interface{}) error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
@@ -127,22 +241,86 @@ func (q *Queue) PushEvents(payload []interface{}) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal events: %w", err)
 	}
+	hash, err := eventsHash(payloadJSON)
+	if err != nil {
+		return fmt.Errorf("failed to hash events: %w", err)
+	}
+
+	now := time.Now().Unix()
+	res, err := q.db.Exec(`UPDATE queue SET occurrences = occurrences + 1, last_timestamp = ?
+		WHERE id = (SELECT MAX(id) FROM queue WHERE type = 'events' AND content_hash = ?)`, now, hash)
+	if err != nil {
+		return fmt.Errorf("failed to update queue item: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	return q.pushItem("events", string(payloadJSON), now, PriorityEvents, hash)
+}
+
+// eventsHash identifies a batch of events by content, ignoring their timestamps
+func eventsHash(payloadJSON []byte) (string, error) {
+	var events []interface{}
+	if err := json.Unmarshal(payloadJSON, &events); err != nil {
+		return "", err
+	}
+	for _, e := range events {
+		if fields, ok := e.(map[string]interface{}); ok {
+			delete(fields, "timestamp")
+		}
+	}
+	canonical, err := json.Marshal(events) // map keys are sorted
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
 
-	return q.pushItem("events", string(payloadJSON), time.Now().Unix())
+// RelayPayload is a raw agent request buffered by a relay on behalf of another agent
+type RelayPayload struct {
+	Path string `json:"path"`
+	Body string `json:"body"`
+}
+
+// PushRelay adds a forwarded request body to the queue, to be replayed as-is
+func (q *Queue) PushRelay(path string, body []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	payloadJSON, err := json.Marshal(RelayPayload{Path: path, Body: string(body)})
+	if err != nil {
+		return fmt.Errorf("failed to marshal relay payload: %w", err)
+	}
+
+	priority := PriorityMetrics
+	if path == relayEventsPath {
+		priority = PriorityEvents
+	}
+	return q.pushItem("relay", string(payloadJSON), time.Now().Unix(), priority, "")
 }
 
 // pushItem adds a single item to the queue
-func (q *Queue) pushItem(itemType, payload string, timestamp int64) error {
+func (q *Queue) pushItem(itemType, payload string, timestamp int64, priority int, contentHash string) error {
+	compressed, err := compress(payload)
+	if err != nil {
+		return fmt.Errorf("failed to compress queue item: %w", err)
+	}
+
 	// Check queue size and drop oldest if needed
 	if err := q.enforceMaxSize(); err != nil {
 		log.Printf("Warning: Failed to enforce max queue size: %v", err)
 	}
+	if err := q.enforceMaxBytes(int64(len(compressed))); err != nil {
+		log.Printf("Warning: Failed to enforce max queue disk size: %v", err)
+	}
 
 	// Insert new item
-	stmt := `INSERT INTO queue (type, payload, timestamp, retries, created_at)
-	         VALUES (?, ?, ?, 0, CURRENT_TIMESTAMP)`
+	stmt := `INSERT INTO queue (type, payload, timestamp, retries, priority, content_hash, created_at)
+	         VALUES (?, ?, ?, 0, ?, NULLIF(?, ''), CURRENT_TIMESTAMP)`
 
-	result, err := q.db.Exec(stmt, itemType, payload, timestamp)
+	result, err := q.db.Exec(stmt, itemType, compressed, timestamp, priority, contentHash)
 	if err != nil {
 		return fmt.Errorf("failed to insert queue item: %w", err)
 	}
@@ -153,8 +331,20 @@ func (q *Queue) pushItem(itemType, payload string, timestamp int64) error {
 	return nil
 }
 
-// enforceMaxSize removes oldest items if queue exceeds max size
+// enforceMaxSize removes items older than the max age, then the oldest items
+// if queue exceeds max size
 func (q *Queue) enforceMaxSize() error {
+	if q.maxAge > 0 {
+		cutoff := time.Now().Add(-q.maxAge).Unix()
+		res, err := q.db.Exec("DELETE FROM queue WHERE COALESCE(last_timestamp, timestamp) < ?", cutoff)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			log.Printf("Deleted %d queued items older than %s", n, q.maxAge)
+		}
+	}
+
 	// Get current queue size
 	var count int
 	err := q.db.QueryRow("SELECT COUNT(*) FROM queue").Scan(&count)
@@ -162,8 +352,21 @@ func (q *Queue) enforceMaxSize() error {
 		return err
 	}
 
-	if count > q.maxSize {
-		toDelete := count - q.maxSize
+	// Runs before insert, so make room for the incoming item. Downsampling
+	// frees a tenth of the queue at once so it does not run on every push.
+	if count >= q.maxSize && q.overflow == OverflowDownsample {
+		want := count - q.maxSize + 1
+		if want < q.maxSize/10 {
+			want = q.maxSize / 10
+		}
+		freed, err := q.downsample(want)
+		if err != nil {
+			log.Printf("Warning: Failed to downsample queued metrics: %v", err)
+		}
+		count -= freed
+	}
+	if count >= q.maxSize {
+		toDelete := count - q.maxSize + 1
 		log.Printf("Queue size (%d) exceeds max (%d), deleting %d oldest items",
 			count, q.maxSize, toDelete)
 
@@ -178,7 +381,96 @@ func (q *Queue) enforceMaxSize() error {
 	return nil
 }
 
-// GetPending returns queued items ready for retry
+// enforceMaxBytes removes oldest items until an incoming payload of the given
+// size fits under the disk size cap
+func (q *Queue) enforceMaxBytes(incoming int64) error {
+	if q.maxBytes <= 0 {
+		return nil
+	}
+	var total int64
+	if err := q.db.QueryRow("SELECT COALESCE(SUM(LENGTH(CAST(payload AS BLOB))), 0) FROM queue").Scan(&total); err != nil {
+		return err
+	}
+	excess := total + incoming - q.maxBytes
+	if excess <= 0 {
+		return nil
+	}
+	if q.overflow == OverflowDownsample {
+		if freed, err := q.downsample(q.maxSize/10 + 1); err != nil {
+			log.Printf("Warning: Failed to downsample queued metrics: %v", err)
+		} else if freed > 0 {
+			if err := q.db.QueryRow("SELECT COALESCE(SUM(LENGTH(CAST(payload AS BLOB))), 0) FROM queue").Scan(&total); err != nil {
+				return err
+			}
+			if excess = total + incoming - q.maxBytes; excess <= 0 {
+				return nil
+			}
+		}
+	}
+
+	rows, err := q.db.Query("SELECT id, LENGTH(CAST(payload AS BLOB)) FROM queue ORDER BY created_at ASC, id ASC")
+	if err != nil {
+		return err
+	}
+	var ids []int64
+	var freed int64
+	for freed < excess && rows.Next() {
+		var id, size int64
+		if err := rows.Scan(&id, &size); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+		freed += size
+	}
+	rows.Close()
+
+	log.Printf("Queue payloads (%.1fMB) exceed max (%.1fMB), deleting %d oldest items",
+		float64(total)/(1<<20), float64(q.maxBytes)/(1<<20), len(ids))
+	for _, id := range ids {
+		if _, err := q.db.Exec("DELETE FROM queue WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gzipMagic starts every compressed payload; JSON never does, so rows
+// written before compression are read back as-is
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// compress gzips a payload for storage
+func compress(payload string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(payload)); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress returns a stored payload as JSON text
+func decompress(stored []byte) (string, error) {
+	if !bytes.HasPrefix(stored, gzipMagic) {
+		return string(stored), nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(stored))
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GetPending returns queued items ready for retry: events first, then
+// metrics, oldest first within each priority
 func (q *Queue) GetPending() ([]QueuedItem, error) {
 	q.mu.Lock()
 	defer q.mu.Unlock()
@@ -188,9 +480,10 @@ func (q *Queue) GetPending() ([]QueuedItem, error) {
 
 	// Get items that are ready for retry based on backoff
 	rows, err := q.db.Query(`
-		SELECT id, type, payload, timestamp, retries, last_error, created_at
+		SELECT id, type, payload, timestamp, retries, priority, COALESCE(last_error, ''), created_at,
+			occurrences, COALESCE(last_timestamp, 0)
 		FROM queue
-		ORDER BY created_at ASC
+		ORDER BY priority ASC, created_at ASC, id ASC
 		LIMIT 100
 	`)
 	if err != nil {
@@ -201,12 +494,18 @@ func (q *Queue) GetPending() ([]QueuedItem, error) {
 	for rows.Next() {
 		var item QueuedItem
 		var createdAtStr string
+		var stored []byte
 
-		err := rows.Scan(&item.ID, &item.Type, &item.Payload, &item.Timestamp,
-			&item.Retries, &item.LastError, &createdAtStr)
+		err := rows.Scan(&item.ID, &item.Type, &stored, &item.Timestamp,
+			&item.Retries, &item.Priority, &item.LastError, &createdAtStr,
+			&item.Occurrences, &item.LastTimestamp)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan queue item: %w", err)
 		}
+		if item.Payload, err = decompress(stored); err != nil {
+			// Hand it to the caller anyway: the unmarshal error marks it failed
+			log.Printf("Warning: Failed to decompress queue item %d: %v", item.ID, err)
+		}
 
 		item.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAtStr)
 
@@ -281,6 +580,11 @@ func (q *Queue) GetStats() (map[string]interface{}, error) {
 	q.db.QueryRow("SELECT COUNT(*) FROM queue").Scan(&total)
 	stats["total"] = total
 
+	// Disk space of the stored (compressed) payloads
+	var size int64
+	q.db.QueryRow("SELECT COALESCE(SUM(LENGTH(CAST(payload AS BLOB))), 0) FROM queue").Scan(&size)
+	stats["bytes"] = size
+
 	// Items by type
 	rows, err := q.db.Query("SELECT type, COUNT(*) FROM queue GROUP BY type")
 	if err == nil {
@@ -347,10 +651,19 @@ func (q *Queue) IsConnected() bool {
 	return q.isConnected
 }
 
+// DB exposes the queue's database to subsystems that keep their state next
+// to the queue (the relay's credential and config cache)
+func (q *Queue) DB() *sql.DB {
+	return q.db
+}
+
 // Close closes the database connection
 func (q *Queue) Close() error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
+	if !q.ownsDB {
+		return nil
+	}
 	return q.db.Close()
 }
@@ -1,7 +1,15 @@
 package queue
 
 import (
+	"bytes"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -226,6 +234,36 @@ func TestMaxSizeEnforcement(t *testing.T) {
 	}
 }
 
+func TestMaxSizeMakesRoomForNewest(t *testing.T) {
+	q, err := NewQueue(filepath.Join(t.TempDir(), "queue.db"), 5)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	defer q.Close()
+
+	for i := 0; i < 8; i++ {
+		if err := q.PushMetrics(map[string]interface{}{"id": i}); err != nil {
+			t.Fatalf("Failed to push metrics: %v", err)
+		}
+	}
+
+	// A full queue drops its oldest item for each push, never the pushed one
+	pending, err := q.GetPending()
+	if err != nil {
+		t.Fatalf("Failed to get pending items: %v", err)
+	}
+	if len(pending) != 5 {
+		t.Fatalf("Expected 5 items, got %d", len(pending))
+	}
+	for i, item := range pending {
+		var m map[string]interface{}
+		json.Unmarshal([]byte(item.Payload), &m)
+		if id, _ := m["id"].(float64); int(id) != i+3 {
+			t.Errorf("Item %d has id %v, want %d", i, m["id"], i+3)
+		}
+	}
+}
+
 func TestQueueStats(t *testing.T) {
 	tempFile := "/tmp/test_queue_stats.db"
 	os.Remove(tempFile)
@@ -251,6 +289,9 @@ func TestQueueStats(t *testing.T) {
 	if stats["total"] != 3 {
 		t.Errorf("Expected total=3, got %v", stats["total"])
 	}
+	if size, _ := stats["bytes"].(int64); size <= 0 {
+		t.Errorf("Expected the payload size in bytes, got %v", stats["bytes"])
+	}
 
 	byType := stats["by_type"].(map[string]int)
 	if byType["metrics"] != 2 {
@@ -323,3 +364,258 @@ func TestGetPendingOrdering(t *testing.T) {
 		}
 	}
 }
+
+func TestGetPendingEventsFirst(t *testing.T) {
+	q, err := NewQueue(filepath.Join(t.TempDir(), "queue.db"), 100)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	defer q.Close()
+
+	q.PushMetrics(map[string]interface{}{"id": 1})
+	q.PushRelay("/api/v1/agent/metrics", []byte(`{}`))
+	q.PushEvents([]interface{}{"drift"})
+	q.PushMetrics(map[string]interface{}{"id": 2})
+	q.PushRelay("/api/v1/agent/events", []byte(`[]`))
+
+	items, err := q.GetPending()
+	if err != nil {
+		t.Fatalf("Failed to get pending: %v", err)
+	}
+	var got []int64
+	for _, item := range items {
+		got = append(got, item.ID)
+	}
+	// Events (ids 3, 5) oldest first, then metrics (1, 2, 4)
+	want := []int64{3, 5, 1, 2, 4}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Expected order %v, got %v", want, got)
+	}
+}
+
+func TestLegacyQueueGetsPriority(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		timestamp INTEGER NOT NULL,
+		retries INTEGER DEFAULT 0,
+		last_error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	INSERT INTO queue (type, payload, timestamp) VALUES ('metrics', '{}', 1), ('events', '[]', 2);`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := NewQueue(path, 100)
+	if err != nil {
+		t.Fatalf("Failed to open legacy queue: %v", err)
+	}
+	defer q.Close()
+
+	items, err := q.GetPending()
+	if err != nil {
+		t.Fatalf("Failed to get pending: %v", err)
+	}
+	if len(items) != 2 || items[0].Type != "events" || items[0].Priority != PriorityEvents {
+		t.Errorf("Expected the legacy event first, got %+v", items)
+	}
+}
+
+func TestPayloadsAreCompressed(t *testing.T) {
+	q, err := NewQueue(filepath.Join(t.TempDir(), "queue.db"), 100)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	defer q.Close()
+
+	procs := make([]interface{}, 200)
+	for i := range procs {
+		procs[i] = map[string]interface{}{"name": "nginx: worker process", "cpu": 0.1}
+	}
+	if err := q.PushMetrics(map[string]interface{}{"processes": procs}); err != nil {
+		t.Fatalf("Failed to push metrics: %v", err)
+	}
+	// Rows written before compression are read back unchanged
+	if _, err := q.db.Exec(`INSERT INTO queue (type, payload, timestamp, priority) VALUES ('events', '["legacy"]', 1, 0)`); err != nil {
+		t.Fatal(err)
+	}
+
+	var stored []byte
+	q.db.QueryRow("SELECT payload FROM queue WHERE type = 'metrics'").Scan(&stored)
+	if !bytes.HasPrefix(stored, gzipMagic) {
+		t.Fatalf("Expected a gzip payload, got %q", stored)
+	}
+
+	items, err := q.GetPending()
+	if err != nil {
+		t.Fatalf("Failed to get pending: %v", err)
+	}
+	if len(items) != 2 || items[0].Payload != `["legacy"]` {
+		t.Fatalf("Unexpected items: %+v", items)
+	}
+	if len(stored) >= len(items[1].Payload) {
+		t.Errorf("Compressed payload (%d B) not smaller than JSON (%d B)", len(stored), len(items[1].Payload))
+	}
+	var metrics map[string]interface{}
+	if err := json.Unmarshal([]byte(items[1].Payload), &metrics); err != nil || len(metrics["processes"].([]interface{})) != 200 {
+		t.Errorf("Payload did not round-trip: %v", err)
+	}
+}
+
+func TestMaxMBEnforcement(t *testing.T) {
+	q, err := NewQueue(filepath.Join(t.TempDir(), "queue.db"), 1000)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	defer q.Close()
+	q.SetMaxMB(1)
+
+	// Random hex barely compresses: each item stores ~100KB
+	for i := 0; i < 20; i++ {
+		blob := make([]byte, 100<<10)
+		rand.Read(blob)
+		if err := q.PushMetrics(map[string]interface{}{"id": i, "blob": hex.EncodeToString(blob)}); err != nil {
+			t.Fatalf("Failed to push metrics: %v", err)
+		}
+	}
+
+	var total int64
+	q.db.QueryRow("SELECT SUM(LENGTH(payload)) FROM queue").Scan(&total)
+	if total > 1<<20 {
+		t.Errorf("Queue payloads use %d bytes, cap is 1MB", total)
+	}
+	items, _ := q.GetPending()
+	if len(items) == 0 || !strings.Contains(items[len(items)-1].Payload, `"id":19`) {
+		t.Error("Expected the newest item to be kept")
+	}
+}
+
+func TestDownsampleWhenFull(t *testing.T) {
+	q, err := NewQueue(filepath.Join(t.TempDir(), "queue.db"), 20)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	defer q.Close()
+	if err := q.SetOverflow(OverflowDownsample); err != nil {
+		t.Fatal(err)
+	}
+
+	// One push a minute for 20 minutes: cpu 0..19, the first one with a cron run
+	base := int64(1700000100) // start of a 5-minute bucket
+	for i := 0; i < 20; i++ {
+		metrics := map[string]interface{}{"cpu_percent": float64(i), "process_count": 100 + i}
+		if i == 0 {
+			metrics["cron_runs"] = []interface{}{map[string]interface{}{"command": "backup.sh"}}
+		}
+		payload, _ := json.Marshal(metrics)
+		q.mu.Lock()
+		err := q.pushItem("metrics", string(payload), base+int64(i)*60, PriorityMetrics, "")
+		q.mu.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.PushEvents([]interface{}{"cron failed"})
+
+	items, err := q.GetPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The oldest bucket (5 pushes) became one average; nothing was dropped
+	if len(items) != 17 {
+		t.Fatalf("Expected 17 items, got %d", len(items))
+	}
+	var first map[string]interface{}
+	if err := json.Unmarshal([]byte(items[1].Payload), &first); err != nil {
+		t.Fatal(err)
+	}
+	if first["cpu_percent"] != 2.0 || first["process_count"] != 102.0 {
+		t.Errorf("Expected the average of the first 5 pushes, got %v", first)
+	}
+	if runs, _ := first["cron_runs"].([]interface{}); len(runs) != 1 {
+		t.Errorf("Expected cron runs to be kept, got %v", first["cron_runs"])
+	}
+	if items[1].Timestamp != base+4*60 {
+		t.Errorf("Expected the timestamp of the newest push in the bucket, got %d", items[1].Timestamp)
+	}
+	if !strings.Contains(items[2].Payload, `"cpu_percent":5`) {
+		t.Errorf("Expected newer pushes untouched, got %s", items[2].Payload)
+	}
+}
+
+func TestPushEventsDeduplicates(t *testing.T) {
+	q, err := NewQueue(filepath.Join(t.TempDir(), "queue.db"), 100)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	defer q.Close()
+
+	event := func(msg string, ts int64) []interface{} {
+		return []interface{}{map[string]interface{}{"type": "drift", "severity": "warning", "message": msg, "timestamp": ts}}
+	}
+	for i := int64(0); i < 5; i++ {
+		if err := q.PushEvents(event("File modified: /etc/hosts", 1000+i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	q.PushEvents(event("CPU above 90%", 2000))
+
+	items, err := q.GetPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("Expected 2 items, got %d", len(items))
+	}
+	if items[0].Occurrences != 5 || items[0].LastTimestamp == 0 {
+		t.Errorf("Expected 5 occurrences with a last timestamp, got %+v", items[0])
+	}
+	if !strings.Contains(items[0].Payload, `"timestamp":1000`) {
+		t.Errorf("Expected the first event to be kept, got %s", items[0].Payload)
+	}
+	if items[1].Occurrences != 1 {
+		t.Errorf("Expected a single occurrence, got %d", items[1].Occurrences)
+	}
+
+	// Once sent, the same event is queued anew
+	q.MarkSent(items[0].ID)
+	q.PushEvents(event("File modified: /etc/hosts", 3000))
+	if size, _ := q.GetSize(); size != 2 {
+		t.Errorf("Expected 2 items after resend, got %d", size)
+	}
+}
+
+func TestMaxAgeEnforcement(t *testing.T) {
+	q, err := NewQueue(filepath.Join(t.TempDir(), "queue.db"), 100)
+	if err != nil {
+		t.Fatalf("Failed to create queue: %v", err)
+	}
+	defer q.Close()
+	q.SetMaxAge(time.Hour)
+
+	old := time.Now().Add(-2 * time.Hour).Unix()
+	q.mu.Lock()
+	q.pushItem("metrics", `{"id":1}`, old, PriorityMetrics, "")
+	q.pushItem("events", `[{"message":"disk full"}]`, old, PriorityEvents, "h")
+	q.mu.Unlock()
+	// An event repeated recently is kept
+	q.db.Exec("UPDATE queue SET last_timestamp = ? WHERE type = 'events'", time.Now().Unix())
+
+	q.PushMetrics(map[string]interface{}{"id": 2})
+
+	items, err := q.GetPending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].Type != "events" || !strings.Contains(items[1].Payload, `"id":2`) {
+		t.Errorf("Expected the expired metrics to be dropped, got %+v", items)
+	}
+}
@@ -0,0 +1,405 @@
+package relay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yourusername/nodeguarder/api"
+	"github.com/yourusername/nodeguarder/queue"
+)
+
+// maxBodySize caps the payload accepted from downstream agents (log uploads included)
+var maxBodySize int64 = 64 << 20
+
+// queueablePaths are the push endpoints that can be buffered while upstream is unreachable
+var queueablePaths = map[string]bool{
+	"/api/v1/agent/metrics": true,
+	"/api/v1/agent/events":  true,
+}
+
+// forwardedHeaders are the request headers passed upstream, besides any X-Agent-* header
+var forwardedHeaders = []string{"Content-Type", "Content-Encoding", "Authorization"}
+
+// configPath is served from cache while upstream is unreachable
+const configPath = "/api/v1/agent/config"
+
+// cacheSchema keeps the accepted credentials and cached configs in the queue
+// database, so a restarted gateway can still serve agents during an outage
+const cacheSchema = `
+CREATE TABLE IF NOT EXISTS relay_agents (
+	server_id TEXT PRIMARY KEY,
+	secret_hash BLOB,
+	config BLOB,
+	updated_at INTEGER NOT NULL
+);`
+
+// Server accepts agent traffic on an isolated network segment and forwards it upstream
+type Server struct {
+	listenAddr      string
+	mode            string
+	certFile        string
+	keyFile         string
+	client          *api.Client
+	queue           *queue.Queue
+	httpServer      *http.Server
+	storeAndForward bool
+	db              *sql.DB // persistent copy of the caches below (nil = memory only)
+
+	cacheMu     sync.RWMutex
+	configCache map[string][]byte   // server ID -> last good config response
+	accepted    map[string][32]byte // server ID -> SHA-256 of the API secret the dashboard last accepted
+}
+
+// New creates a relay listening on listenAddr that forwards through client.
+// Pushes that cannot be delivered are stored in q (if not nil) and replayed by FlushQueue;
+// the credential and config caches are kept in the queue database as well.
+func New(listenAddr string, client *api.Client, q *queue.Queue) *Server {
+	s := &Server{
+		listenAddr:  listenAddr,
+		mode:        "relay",
+		client:      client,
+		queue:       q,
+		configCache: make(map[string][]byte),
+		accepted:    make(map[string][32]byte),
+	}
+	if q != nil {
+		if err := s.loadCache(q.DB()); err != nil {
+			log.Printf("⚠️  Relay: credential cache not persisted: %v", err)
+		} else {
+			s.db = q.DB()
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/agent/", s.handleAgent)
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok","mode":"` + s.mode + `"}`))
+	})
+
+	s.httpServer = &http.Server{
+		Addr:              listenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// SetTLS enables TLS on the local listener
+func (s *Server) SetTLS(certFile, keyFile string) {
+	s.certFile = certFile
+	s.keyFile = keyFile
+}
+
+// SetStoreAndForward makes the relay acknowledge metric/event pushes immediately and
+// buffer them to disk while the dashboard is unreachable, leaving delivery to FlushQueue
+// instead of trying upstream first. Intended for gateways on flaky WAN links.
+func (s *Server) SetStoreAndForward(enabled bool) {
+	s.storeAndForward = enabled
+	if enabled {
+		s.mode = "gateway"
+	}
+}
+
+// Start begins serving in the background
+func (s *Server) Start() {
+	go func() {
+		var err error
+		if s.certFile != "" && s.keyFile != "" {
+			err = s.httpServer.ListenAndServeTLS(s.certFile, s.keyFile)
+		} else {
+			err = s.httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("❌ Relay listener stopped: %v", err)
+		}
+	}()
+	log.Printf("✅ Relay listening on %s", s.listenAddr)
+}
+
+// Close shuts down the local listener
+func (s *Server) Close() error {
+	return s.httpServer.Close()
+}
+
+// handleAgent forwards a downstream agent request to the dashboard
+func (s *Server) handleAgent(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	endpoint := r.URL.Path
+	if r.URL.RawQuery != "" {
+		endpoint += "?" + r.URL.RawQuery
+	}
+
+	serverID, secret := agentCredentials(r, body)
+	queueable := r.Method == http.MethodPost && queueablePaths[r.URL.Path] && s.queue != nil
+
+	// Gateway mode: while the dashboard is known to be down, terminate pushes
+	// locally and let the flush loop sync them
+	if queueable && s.storeAndForward && !s.queue.IsConnected() {
+		s.enqueue(w, r.URL.Path, serverID, secret, body)
+		return
+	}
+
+	resp, err := s.client.Forward(r.Method, endpoint, upstreamHeader(r.Header), bytes.NewReader(body))
+	if err != nil {
+		// Upstream unreachable: buffer pushes, serve cached config, reject everything else
+		if queueable {
+			s.queue.SetConnected(false)
+			s.enqueue(w, r.URL.Path, serverID, secret, body)
+			return
+		}
+		if r.Method == http.MethodGet && r.URL.Path == configPath && s.checkCredentials(serverID, secret) == http.StatusOK {
+			if cached, ok := s.cachedConfig(serverID); ok {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(cached)
+				return
+			}
+		}
+		log.Printf("Relay: upstream request %s %s failed: %v", r.Method, r.URL.Path, err)
+		writeError(w, http.StatusBadGateway, "Upstream unavailable")
+		return
+	}
+	defer resp.Body.Close()
+
+	if s.queue != nil {
+		s.queue.SetConnected(true)
+	}
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		s.forgetCredentials(serverID)
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		s.rememberCredentials(serverID, secret)
+	}
+
+	if r.Method == http.MethodGet && r.URL.Path == configPath && resp.StatusCode == http.StatusOK {
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, "Failed to read upstream response")
+			return
+		}
+		s.storeConfig(serverID, respBody)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(respBody)
+		return
+	}
+
+	for _, h := range []string{"Content-Type", "Content-Disposition", "Content-Length"} {
+		if v := resp.Header.Get(h); v != "" {
+			w.Header().Set(h, v)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// enqueue buffers a push for later delivery and acknowledges it to the downstream agent.
+// Only pushes carrying credentials the dashboard accepted through this relay are buffered.
+func (s *Server) enqueue(w http.ResponseWriter, path, serverID, secret string, body []byte) {
+	switch s.checkCredentials(serverID, secret) {
+	case http.StatusUnauthorized:
+		writeError(w, http.StatusUnauthorized, "Authentication failed")
+		return
+	case http.StatusBadGateway:
+		// Unknown here, so the agent keeps the push in its own queue
+		writeError(w, http.StatusBadGateway, "Upstream unavailable")
+		return
+	}
+
+	if err := s.queue.PushRelay(path, body); err != nil {
+		log.Printf("Warning: Failed to queue relayed request: %v", err)
+		writeError(w, http.StatusBadGateway, "Upstream unavailable")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	// The dashboard's answer is not known yet; a full sync keeps the agent's
+	// process and cron job deltas from building on a push still in the queue
+	w.Write([]byte(`{"status":"queued","full_sync":true}`))
+}
+
+// upstreamHeader picks the downstream request headers the dashboard needs, so
+// hop-by-hop and client headers (cookies, proxies, encodings) stay local
+func upstreamHeader(in http.Header) http.Header {
+	out := make(http.Header)
+	for name, values := range in {
+		if strings.HasPrefix(http.CanonicalHeaderKey(name), "X-Agent-") {
+			out[name] = values
+		}
+	}
+	for _, name := range forwardedHeaders {
+		if v := in.Values(name); len(v) > 0 {
+			out[name] = v
+		}
+	}
+	return out
+}
+
+// agentCredentials returns the server ID and API secret of a downstream request:
+// the query string of GET requests, the JSON body of pushes
+func agentCredentials(r *http.Request, body []byte) (serverID, secret string) {
+	if r.Method == http.MethodGet {
+		return r.URL.Query().Get("server_id"), r.URL.Query().Get("api_secret")
+	}
+	var creds struct {
+		ServerID  string `json:"server_id"`
+		APISecret string `json:"api_secret"`
+	}
+	json.Unmarshal(body, &creds)
+	return creds.ServerID, creds.APISecret
+}
+
+// loadCache creates the cache table in db and reads back what an earlier run stored
+func (s *Server) loadCache(db *sql.DB) error {
+	if _, err := db.Exec(cacheSchema); err != nil {
+		return err
+	}
+	rows, err := db.Query("SELECT server_id, secret_hash, config FROM relay_agents")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	for rows.Next() {
+		var serverID string
+		var hash, config []byte
+		if err := rows.Scan(&serverID, &hash, &config); err != nil {
+			return err
+		}
+		if len(hash) == sha256.Size {
+			s.accepted[serverID] = [32]byte(hash)
+		}
+		if config != nil {
+			s.configCache[serverID] = config
+		}
+	}
+	return rows.Err()
+}
+
+// persist writes a change of the caches through to the database
+func (s *Server) persist(query string, args ...interface{}) {
+	if s.db == nil {
+		return
+	}
+	if _, err := s.db.Exec(query, args...); err != nil {
+		log.Printf("Warning: Relay failed to save credential cache: %v", err)
+	}
+}
+
+// rememberCredentials records the secret the dashboard accepted for a server
+func (s *Server) rememberCredentials(serverID, secret string) {
+	if serverID == "" || secret == "" {
+		return
+	}
+	hash := sha256.Sum256([]byte(secret))
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	if old, ok := s.accepted[serverID]; ok && old == hash {
+		return
+	}
+	s.accepted[serverID] = hash
+	s.persist(`INSERT INTO relay_agents (server_id, secret_hash, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(server_id) DO UPDATE SET secret_hash = excluded.secret_hash, updated_at = excluded.updated_at`,
+		serverID, hash[:], time.Now().Unix())
+}
+
+// forgetCredentials drops a server the dashboard rejected, with its cached config
+func (s *Server) forgetCredentials(serverID string) {
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	delete(s.accepted, serverID)
+	delete(s.configCache, serverID)
+	s.persist("DELETE FROM relay_agents WHERE server_id = ?", serverID)
+}
+
+// checkCredentials verifies a request against the secret the dashboard last accepted.
+// It returns http.StatusOK on a match, http.StatusUnauthorized for a wrong secret and
+// http.StatusBadGateway when the server (or a signed request) cannot be checked here.
+func (s *Server) checkCredentials(serverID, secret string) int {
+	s.cacheMu.RLock()
+	want, ok := s.accepted[serverID]
+	s.cacheMu.RUnlock()
+	if !ok || secret == "" {
+		return http.StatusBadGateway
+	}
+	got := sha256.Sum256([]byte(secret))
+	if subtle.ConstantTimeCompare(got[:], want[:]) != 1 {
+		return http.StatusUnauthorized
+	}
+	return http.StatusOK
+}
+
+// storeConfig remembers the last config served to an agent, keyed by its server ID
+func (s *Server) storeConfig(serverID string, body []byte) {
+	if serverID == "" {
+		return
+	}
+	s.cacheMu.Lock()
+	defer s.cacheMu.Unlock()
+	if bytes.Equal(s.configCache[serverID], body) {
+		return
+	}
+	s.configCache[serverID] = body
+	s.persist(`INSERT INTO relay_agents (server_id, config, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(server_id) DO UPDATE SET config = excluded.config, updated_at = excluded.updated_at`,
+		serverID, body, time.Now().Unix())
+}
+
+// cachedConfig returns the last known config with one-shot commands stripped,
+// so a stale log request, uninstall, script, terminal or remediation is never
+// replayed, and without the rollout target so no update starts from a stale one
+func (s *Server) cachedConfig(serverID string) ([]byte, bool) {
+	s.cacheMu.RLock()
+	body, ok := s.configCache[serverID]
+	s.cacheMu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+
+	var cfg map[string]interface{}
+	if err := json.Unmarshal(body, &cfg); err != nil {
+		return nil, false
+	}
+	cfg["collect_logs"] = false
+	cfg["uninstall"] = false
+	cfg["drift_rebaseline"] = false
+	delete(cfg, "scripts")
+	delete(cfg, "terminal_session")
+	delete(cfg, "cron_pauses")
+	delete(cfg, "remediations")
+	delete(cfg, "target_version")
+
+	out, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	payload, _ := json.Marshal(map[string]string{"error": msg})
+	w.Write(payload)
+}
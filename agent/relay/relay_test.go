@@ -0,0 +1,195 @@
+package relay
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/nodeguarder/api"
+	"github.com/yourusername/nodeguarder/queue"
+)
+
+func TestGatewayBuffersOnlyAuthenticatedPushes(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_secret") != "good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"interval":30,"collect_logs":true}`))
+	}))
+
+	q, err := queue.NewQueue(filepath.Join(t.TempDir(), "queue.db"), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+	s := New("127.0.0.1:0", api.NewClient(upstream.URL, "", "", false), q)
+	s.SetStoreAndForward(true)
+
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	// The dashboard accepts srv-1 through the gateway, then goes away
+	if rec := do("GET", configPath+"?server_id=srv-1&api_secret=good", ""); rec.Code != http.StatusOK {
+		t.Fatalf("config: status %d", rec.Code)
+	}
+	upstream.Close()
+
+	push := `{"server_id":"srv-1","api_secret":"good","metrics":{}}`
+	rec := do("POST", "/api/v1/agent/metrics", push)
+	var resp struct {
+		Status   string `json:"status"`
+		FullSync bool   `json:"full_sync"`
+	}
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if rec.Code != http.StatusAccepted || resp.Status != "queued" || !resp.FullSync {
+		t.Fatalf("push: status %d, %+v", rec.Code, resp)
+	}
+	if rec := do("POST", "/api/v1/agent/metrics", `{"server_id":"srv-1","api_secret":"bad"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong secret: status %d, want 401", rec.Code)
+	}
+	if rec := do("POST", "/api/v1/agent/events", `{"server_id":"srv-2","api_secret":"good"}`); rec.Code != http.StatusBadGateway {
+		t.Errorf("unknown server: status %d, want 502", rec.Code)
+	}
+	if size, _ := q.GetSize(); size != 1 {
+		t.Errorf("queue size %d, want 1", size)
+	}
+
+	// The cached config is served per server ID, without one-shot commands
+	rec = do("GET", configPath+"?api_secret=good&server_id=srv-1", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"collect_logs":false`) {
+		t.Errorf("cached config: status %d, %s", rec.Code, rec.Body.String())
+	}
+	if rec := do("GET", configPath+"?server_id=srv-1&api_secret=bad", ""); rec.Code == http.StatusOK {
+		t.Error("cached config served for a wrong secret")
+	}
+}
+
+func TestRelayForwardsAgentHeadersAndCapsBodies(t *testing.T) {
+	var got http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer upstream.Close()
+	s := New("127.0.0.1:0", api.NewClient(upstream.URL, "", "", false), nil)
+
+	req := httptest.NewRequest("POST", "/api/v1/agent/events", strings.NewReader(`{"server_id":"srv-1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Agent-Nonce", "0123456789abcdef")
+	req.Header.Set("X-Agent-Signature", "abc")
+	req.Header.Set("Cookie", "session=1")
+	req.Header.Set("X-Forwarded-For", "10.0.0.9")
+	rec := httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("forward: status %d", rec.Code)
+	}
+	if got.Get("X-Agent-Nonce") != "0123456789abcdef" || got.Get("X-Agent-Signature") != "abc" || got.Get("Content-Type") != "application/json" {
+		t.Errorf("agent headers not forwarded: %v", got)
+	}
+	if got.Get("Cookie") != "" || got.Get("X-Forwarded-For") != "" {
+		t.Errorf("client headers forwarded: %v", got)
+	}
+
+	defer func(max int64) { maxBodySize = max }(maxBodySize)
+	maxBodySize = 16
+	got = nil
+	rec = httptest.NewRecorder()
+	s.httpServer.Handler.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/agent/logs", strings.NewReader(strings.Repeat("x", 17))))
+	if rec.Code != http.StatusRequestEntityTooLarge || got != nil {
+		t.Errorf("oversized body: status %d, forwarded %v", rec.Code, got != nil)
+	}
+}
+
+func TestGatewayKeepsCredentialsAcrossRestart(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api_secret") != "good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"interval":30}`))
+	}))
+	path := filepath.Join(t.TempDir(), "queue.db")
+	start := func() (*Server, *queue.Queue) {
+		t.Helper()
+		q, err := queue.NewQueue(path, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := New("127.0.0.1:0", api.NewClient(upstream.URL, "", "", false), q)
+		s.SetStoreAndForward(true)
+		return s, q
+	}
+	do := func(s *Server, method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		s.httpServer.Handler.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	s, q := start()
+	do(s, "GET", configPath+"?server_id=srv-1&api_secret=good", "")
+	do(s, "GET", configPath+"?server_id=srv-2&api_secret=good", "")
+	// srv-2's secret was rotated out: the dashboard rejects it from now on
+	if rec := do(s, "GET", configPath+"?server_id=srv-2&api_secret=old", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("rejected secret: status %d", rec.Code)
+	}
+	q.Close()
+
+	// The gateway restarts while the WAN is down
+	upstream.Close()
+	s, q = start()
+	defer q.Close()
+	if rec := do(s, "POST", "/api/v1/agent/metrics", `{"server_id":"srv-1","api_secret":"good"}`); rec.Code != http.StatusAccepted {
+		t.Errorf("push after restart: status %d, want 202", rec.Code)
+	}
+	if rec := do(s, "POST", "/api/v1/agent/metrics", `{"server_id":"srv-1","api_secret":"bad"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("wrong secret after restart: status %d, want 401", rec.Code)
+	}
+	if rec := do(s, "GET", configPath+"?server_id=srv-1&api_secret=good", ""); rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"interval":30`) {
+		t.Errorf("cached config after restart: status %d, %s", rec.Code, rec.Body.String())
+	}
+	if rec := do(s, "POST", "/api/v1/agent/events", `{"server_id":"srv-2","api_secret":"good"}`); rec.Code != http.StatusBadGateway {
+		t.Errorf("forgotten server after restart: status %d, want 502", rec.Code)
+	}
+}
+
+func TestCachedConfigStripsOneShotCommands(t *testing.T) {
+	s := New("127.0.0.1:0", nil, nil)
+	s.storeConfig("srv-1", []byte(`{"interval":30,"collect_logs":true,"uninstall":true,"drift_rebaseline":true,
+		"scripts":[{"id":1}],"terminal_session":{"id":"t"},"cron_pauses":["backup"],"remediations":[{"id":2}],
+		"target_version":"1.2.0"}`))
+
+	body, ok := s.cachedConfig("srv-1")
+	if !ok {
+		t.Fatal("no cached config")
+	}
+	var cfg map[string]interface{}
+	if err := json.Unmarshal(body, &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg["interval"] != float64(30) {
+		t.Errorf("interval = %v, want it kept", cfg["interval"])
+	}
+	for _, key := range []string{"collect_logs", "uninstall", "drift_rebaseline"} {
+		if cfg[key] != false {
+			t.Errorf("%s = %v, want false", key, cfg[key])
+		}
+	}
+	for _, key := range []string{"scripts", "terminal_session", "cron_pauses", "remediations", "target_version"} {
+		if _, ok := cfg[key]; ok {
+			t.Errorf("%s served from cache", key)
+		}
+	}
+	if _, ok := s.cachedConfig("srv-2"); ok {
+		t.Error("config of another server served")
+	}
+}
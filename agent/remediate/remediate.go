@@ -0,0 +1,121 @@
+package remediate
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const (
+	ActionRestartUnit = "restart_unit"
+	ActionKillPID     = "kill_pid"
+)
+
+// unitPattern rejects anything that is not a plain systemd unit name
+// (starting with a letter or digit, so it cannot pass for an option)
+var unitPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9@._:-]*$`)
+
+// Command is a remediation requested from the dashboard
+type Command struct {
+	ID      int64  `json:"id"`
+	Action  string `json:"action"`  // restart_unit, kill_pid
+	Target  string `json:"target"`  // Unit name or PID
+	Process string `json:"process"` // kill_pid: expected process name (guards against PID reuse)
+}
+
+// Result is the outcome reported back to the dashboard
+type Result struct {
+	ID      int64  `json:"id"`
+	Success bool   `json:"success"`
+	Output  string `json:"output"`
+}
+
+// Execute runs a remediation command. allowedUnits, when non-empty, limits which
+// units may be restarted.
+func Execute(cmd Command, allowedUnits []string) Result {
+	res := Result{ID: cmd.ID}
+	var err error
+
+	switch cmd.Action {
+	case ActionRestartUnit:
+		res.Output, err = restartUnit(cmd.Target, allowedUnits)
+	case ActionKillPID:
+		res.Output, err = terminatePID(cmd.Target, cmd.Process)
+	default:
+		err = fmt.Errorf("unknown action %q", cmd.Action)
+	}
+
+	if err != nil {
+		res.Output = strings.TrimSpace(res.Output + "\n" + err.Error())
+		return res
+	}
+	res.Success = true
+	return res
+}
+
+func restartUnit(unit string, allowedUnits []string) (string, error) {
+	if !unitPattern.MatchString(unit) {
+		return "", fmt.Errorf("invalid unit name %q", unit)
+	}
+	if len(allowedUnits) > 0 && !unitAllowed(unit, allowedUnits) {
+		return "", fmt.Errorf("unit %q is not in remediation_units", unit)
+	}
+
+	out, err := exec.Command("systemctl", "restart", "--", unit).CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("systemctl restart failed: %w", err)
+	}
+
+	// Confirm the unit came back up
+	time.Sleep(2 * time.Second)
+	state, _ := exec.Command("systemctl", "is-active", "--", unit).Output()
+	active := strings.TrimSpace(string(state))
+	if active != "active" {
+		return string(out), fmt.Errorf("unit %s is %s after restart", unit, active)
+	}
+	return fmt.Sprintf("%s restarted and active", unit), nil
+}
+
+func terminatePID(target, expectedName string) (string, error) {
+	var pid int32
+	if _, err := fmt.Sscanf(target, "%d", &pid); err != nil || pid <= 1 {
+		return "", fmt.Errorf("invalid PID %q", target)
+	}
+
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return "", fmt.Errorf("process %d not found", pid)
+	}
+	name, _ := p.Name()
+	if expectedName != "" && name != expectedName {
+		return "", fmt.Errorf("PID %d is now %q, expected %q; refusing to signal", pid, name, expectedName)
+	}
+
+	if err := syscall.Kill(int(pid), syscall.SIGTERM); err != nil {
+		return "", fmt.Errorf("failed to send SIGTERM to %d: %w", pid, err)
+	}
+
+	// Give it a moment to exit so the confirmation is meaningful
+	for i := 0; i < 10; i++ {
+		time.Sleep(500 * time.Millisecond)
+		if exists, _ := process.PidExists(pid); !exists {
+			return fmt.Sprintf("SIGTERM sent to %d (%s); process exited", pid, name), nil
+		}
+	}
+	return fmt.Sprintf("SIGTERM sent to %d (%s); process still running", pid, name), nil
+}
+
+func unitAllowed(unit string, allowed []string) bool {
+	base := strings.TrimSuffix(unit, ".service")
+	for _, a := range allowed {
+		if a == unit || strings.TrimSuffix(a, ".service") == base {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package remediate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExecuteRejectsInvalidTargets(t *testing.T) {
+	for _, cmd := range []Command{
+		{Action: ActionRestartUnit, Target: "--no-block"},
+		{Action: ActionRestartUnit, Target: "-nginx.service"},
+		{Action: ActionRestartUnit, Target: "nginx service"},
+		{Action: ActionRestartUnit, Target: ""},
+		{Action: ActionKillPID, Target: "1"},
+		{Action: ActionKillPID, Target: "-5"},
+		{Action: ActionKillPID, Target: "abc"},
+	} {
+		res := Execute(cmd, nil)
+		if res.Success || !strings.Contains(res.Output, "invalid") {
+			t.Errorf("%s %q: %+v, want rejected as invalid", cmd.Action, cmd.Target, res)
+		}
+	}
+}
+
+func TestUnitPattern(t *testing.T) {
+	for _, unit := range []string{"nginx.service", "getty@tty1.service", "1password.service", "sys-kernel-debug.mount"} {
+		if !unitPattern.MatchString(unit) {
+			t.Errorf("%q rejected", unit)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package scripts
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+const (
+	defaultTimeout = 300       // seconds
+	maxOutputSize  = 64 * 1024 // bytes kept of combined stdout/stderr
+)
+
+// Command is a script execution dispatched by the dashboard
+type Command struct {
+	ResultID int64  `json:"result_id"`
+	Name     string `json:"name"`
+	Content  string `json:"content"`
+	SHA256   string `json:"sha256"`
+	Timeout  int    `json:"timeout"` // Seconds
+}
+
+// Result is the outcome of running a Command
+type Result struct {
+	ResultID   int64  `json:"result_id"`
+	ExitCode   int    `json:"exit_code"`
+	Output     string `json:"output"`
+	StartedAt  int64  `json:"started_at"`
+	FinishedAt int64  `json:"finished_at"`
+}
+
+// Run verifies the script checksum, executes it with /bin/sh and captures its output
+func Run(cmd Command) Result {
+	res := Result{ResultID: cmd.ResultID, StartedAt: time.Now().Unix()}
+
+	sum := sha256.Sum256([]byte(cmd.Content))
+	if hex.EncodeToString(sum[:]) != cmd.SHA256 {
+		res.ExitCode = -1
+		res.Output = "checksum mismatch, refusing to execute"
+		res.FinishedAt = time.Now().Unix()
+		return res
+	}
+
+	tmp, err := os.CreateTemp("", "nodeguarder-script-*.sh")
+	if err != nil {
+		return failed(res, fmt.Errorf("failed to create script file: %w", err))
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(cmd.Content); err != nil {
+		tmp.Close()
+		return failed(res, fmt.Errorf("failed to write script file: %w", err))
+	}
+	tmp.Close()
+
+	timeout := cmd.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Second)
+	defer cancel()
+
+	var out bytes.Buffer
+	c := exec.CommandContext(ctx, "/bin/sh", tmp.Name())
+	c.Stdout = &out
+	c.Stderr = &out
+
+	err = c.Run()
+	res.Output = truncate(out.String())
+
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		res.ExitCode = -1
+		res.Output += fmt.Sprintf("\n[timed out after %ds]", timeout)
+	case errors.As(err, &exitErr):
+		res.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return failed(res, err)
+	}
+
+	res.FinishedAt = time.Now().Unix()
+	return res
+}
+
+func failed(res Result, err error) Result {
+	res.ExitCode = -1
+	res.Output = err.Error()
+	res.FinishedAt = time.Now().Unix()
+	return res
+}
+
+func truncate(s string) string {
+	if len(s) <= maxOutputSize {
+		return s
+	}
+	return s[len(s)-maxOutputSize:]
+}
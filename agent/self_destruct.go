@@ -5,11 +5,16 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"time"
 )
 
 // SelfDestruct initiates the agent uninstallation process
 func SelfDestruct() {
+	if runtime.GOOS != "linux" {
+		log.Println("⚠️  Self-destruct is only supported on Linux, uninstall with: nodeguarder-agent --uninstall --purge")
+		return
+	}
 	log.Println("⚠️  RECEIVED SELF-DESTRUCT COMMAND. INITIATING UNINSTALLATION in 5 seconds...")
 
 	// Create a temporary uninstallation script
@@ -0,0 +1,322 @@
+package state
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// FileName is the default name of the agent state database
+const FileName = "state.db"
+
+// migrations are applied in order; PRAGMA user_version records how many ran.
+// Each subsystem keeps its own tables (queue, kv namespaces, ...) in the same file.
+// Never edit an existing entry, only append.
+var migrations = []string{
+	// 1. Offline resilience queue (same layout as the legacy queue.db)
+	`CREATE TABLE IF NOT EXISTS queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		timestamp INTEGER NOT NULL,
+		retries INTEGER DEFAULT 0,
+		last_error TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX IF NOT EXISTS idx_created_at ON queue(created_at);`,
+
+	// 2. Namespaced key/value state (drift baselines, cron state, log cursors)
+	`CREATE TABLE IF NOT EXISTS kv (
+		namespace TEXT NOT NULL,
+		key TEXT NOT NULL,
+		value TEXT NOT NULL,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (namespace, key)
+	);`,
+
+	// 3. Queue flush priority (events before metrics)
+	`ALTER TABLE queue ADD COLUMN priority INTEGER NOT NULL DEFAULT 1;
+	UPDATE queue SET priority = 0 WHERE type = 'events'
+		OR (type = 'relay' AND json_extract(payload, '$.path') = '/api/v1/agent/events');
+	CREATE INDEX IF NOT EXISTS idx_queue_priority ON queue(priority, created_at);`,
+
+	// 4. Queue dedup of repeated events (content hash + occurrence counter)
+	`ALTER TABLE queue ADD COLUMN content_hash TEXT;
+	ALTER TABLE queue ADD COLUMN occurrences INTEGER NOT NULL DEFAULT 1;
+	ALTER TABLE queue ADD COLUMN last_timestamp INTEGER;
+	CREATE INDEX IF NOT EXISTS idx_queue_content_hash ON queue(content_hash);`,
+}
+
+// Store is the single SQLite file holding all persistent agent state
+type Store struct {
+	db        *sql.DB
+	path      string
+	mu        sync.Mutex
+	recovered string // path of the quarantined corrupt file, if recovery happened
+	checkedAt time.Time
+	checkErr  string
+}
+
+// Stats describes the state database for self-monitoring
+type Stats struct {
+	Path          string `json:"path"`
+	SizeBytes     int64  `json:"size_bytes"`
+	SchemaVersion int    `json:"schema_version"`
+	Healthy       bool   `json:"healthy"`
+	Recovered     bool   `json:"recovered"` // a corrupt file was replaced at startup
+	Error         string `json:"error,omitempty"`
+}
+
+// Open opens (or creates) the state database, recovering from corruption by
+// moving the damaged file aside and starting fresh, then runs pending migrations.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	s := &Store{path: path}
+	db, err := openChecked(path)
+	if err != nil {
+		log.Printf("⚠️  State database %s is unusable (%v), starting fresh", path, err)
+		quarantine := fmt.Sprintf("%s.corrupt-%d", path, time.Now().Unix())
+		if renameErr := os.Rename(path, quarantine); renameErr != nil && !os.IsNotExist(renameErr) {
+			return nil, fmt.Errorf("failed to move corrupt state database: %w", renameErr)
+		}
+		os.Remove(path + "-wal")
+		os.Remove(path + "-shm")
+		s.recovered = quarantine
+
+		if db, err = openChecked(path); err != nil {
+			return nil, fmt.Errorf("failed to recreate state database: %w", err)
+		}
+	}
+	s.db = db
+	s.checkedAt = time.Now() // openChecked just verified it
+
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	log.Printf("✓ State database ready at %s (schema v%d)", path, len(migrations))
+	return s, nil
+}
+
+// openChecked opens the database and verifies it with a quick integrity check
+func openChecked(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000")
+	if err != nil {
+		return nil, err
+	}
+	// Single connection to avoid locks between subsystems
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+
+	var result string
+	if err := db.QueryRow("PRAGMA quick_check").Scan(&result); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if result != "ok" {
+		db.Close()
+		return nil, fmt.Errorf("integrity check failed: %s", result)
+	}
+	return db, nil
+}
+
+// migrate applies migrations newer than the stored user_version
+func (s *Store) migrate() error {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read state schema version: %w", err)
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("state database schema v%d is newer than this agent (v%d)", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("state migration %d failed: %w", i+1, err)
+		}
+		// PRAGMA does not accept bound parameters
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("state migration %d failed: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("state migration %d failed: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// DB exposes the shared connection for subsystems that own tables in the store
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// Get returns a value from a namespace
+func (s *Store) Get(namespace, key string) (string, bool, error) {
+	var value string
+	err := s.db.QueryRow("SELECT value FROM kv WHERE namespace = ? AND key = ?", namespace, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// Put stores a value in a namespace
+func (s *Store) Put(namespace, key, value string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO kv (namespace, key, value, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(namespace, key) DO UPDATE SET value=excluded.value, updated_at=excluded.updated_at
+	`, namespace, key, value, time.Now().Unix())
+	return err
+}
+
+// Delete removes a value from a namespace
+func (s *Store) Delete(namespace, key string) error {
+	_, err := s.db.Exec("DELETE FROM kv WHERE namespace = ? AND key = ?", namespace, key)
+	return err
+}
+
+// Namespace is the kv view of one subsystem. A nil Namespace (no state
+// database) stores nothing, so subsystems keep their state in memory only.
+type Namespace struct {
+	store *Store
+	name  string
+}
+
+// Namespace returns the kv view for a subsystem, nil if s is nil
+func (s *Store) Namespace(name string) *Namespace {
+	if s == nil {
+		return nil
+	}
+	return &Namespace{store: s, name: name}
+}
+
+// Get returns a value of the namespace
+func (n *Namespace) Get(key string) (string, bool, error) {
+	if n == nil {
+		return "", false, nil
+	}
+	return n.store.Get(n.name, key)
+}
+
+// Put stores a value in the namespace
+func (n *Namespace) Put(key, value string) error {
+	if n == nil {
+		return nil
+	}
+	return n.store.Put(n.name, key, value)
+}
+
+// Delete removes a value from the namespace
+func (n *Namespace) Delete(key string) error {
+	if n == nil {
+		return nil
+	}
+	return n.store.Delete(n.name, key)
+}
+
+// GetJSON decodes a value stored with PutJSON into v
+func (n *Namespace) GetJSON(key string, v interface{}) (bool, error) {
+	value, ok, err := n.Get(key)
+	if err != nil || !ok {
+		return false, err
+	}
+	if err := json.Unmarshal([]byte(value), v); err != nil {
+		return false, fmt.Errorf("invalid %s/%s state: %w", n.name, key, err)
+	}
+	return true, nil
+}
+
+// PutJSON stores v encoded as JSON
+func (n *Namespace) PutJSON(key string, v interface{}) error {
+	if n == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return n.Put(key, string(data))
+}
+
+// ImportLegacy copies the rows of a table from an older standalone database
+// (e.g. queue.db) into the store, then renames the old file to *.migrated.
+func (s *Store) ImportLegacy(legacyPath, table string, columns string) (int64, error) {
+	if _, err := os.Stat(legacyPath); err != nil {
+		return 0, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec("ATTACH DATABASE ? AS legacy", legacyPath); err != nil {
+		return 0, fmt.Errorf("failed to open legacy database: %w", err)
+	}
+	res, err := s.db.Exec(fmt.Sprintf("INSERT INTO main.%s (%s) SELECT %s FROM legacy.%s", table, columns, columns, table))
+	s.db.Exec("DETACH DATABASE legacy")
+	if err != nil {
+		return 0, fmt.Errorf("failed to import %s: %w", table, err)
+	}
+	n, _ := res.RowsAffected()
+
+	if err := os.Rename(legacyPath, legacyPath+".migrated"); err != nil {
+		return n, fmt.Errorf("imported %d rows but could not rename %s: %w", n, legacyPath, err)
+	}
+	return n, nil
+}
+
+// healthCheckInterval limits how often Stats re-runs the integrity check
+const healthCheckInterval = time.Hour
+
+// Stats returns the file size and health of the store
+func (s *Store) Stats() Stats {
+	st := Stats{Path: s.path, Recovered: s.recovered != ""}
+	for _, suffix := range []string{"", "-wal"} {
+		if info, err := os.Stat(s.path + suffix); err == nil {
+			st.SizeBytes += info.Size()
+		}
+	}
+	s.db.QueryRow("PRAGMA user_version").Scan(&st.SchemaVersion)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.checkedAt) > healthCheckInterval {
+		var result string
+		if err := s.db.QueryRow("PRAGMA quick_check").Scan(&result); err != nil {
+			s.checkErr = err.Error()
+		} else if result != "ok" {
+			s.checkErr = result
+		} else {
+			s.checkErr = ""
+		}
+		s.checkedAt = time.Now()
+	}
+	st.Healthy = s.checkErr == ""
+	st.Error = s.checkErr
+	return st
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,107 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenRunsMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer s.Close()
+
+	stats := s.Stats()
+	if stats.SchemaVersion != len(migrations) {
+		t.Errorf("Expected schema v%d, got v%d", len(migrations), stats.SchemaVersion)
+	}
+	if !stats.Healthy || stats.Recovered {
+		t.Errorf("Expected healthy, non-recovered store, got %+v", stats)
+	}
+}
+
+func TestKeyValueNamespaces(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer s.Close()
+
+	if err := s.Put("cron", "cursor", "42"); err != nil {
+		t.Fatalf("Failed to put: %v", err)
+	}
+	if err := s.Put("drift", "cursor", "7"); err != nil {
+		t.Fatalf("Failed to put: %v", err)
+	}
+
+	if v, ok, _ := s.Get("cron", "cursor"); !ok || v != "42" {
+		t.Errorf("Expected cron cursor 42, got %q (found=%v)", v, ok)
+	}
+	if v, ok, _ := s.Get("drift", "cursor"); !ok || v != "7" {
+		t.Errorf("Expected drift cursor 7, got %q (found=%v)", v, ok)
+	}
+
+	s.Delete("cron", "cursor")
+	if _, ok, _ := s.Get("cron", "cursor"); ok {
+		t.Error("Expected cron cursor to be deleted")
+	}
+}
+
+func TestNamespaceJSON(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), FileName))
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	defer s.Close()
+
+	type cursor struct{ Offset int64 }
+	ns := s.Namespace("logtail")
+	if err := ns.PutJSON("/var/log/syslog", cursor{Offset: 42}); err != nil {
+		t.Fatalf("Failed to put: %v", err)
+	}
+	var got cursor
+	if ok, err := ns.GetJSON("/var/log/syslog", &got); err != nil || !ok || got.Offset != 42 {
+		t.Errorf("Expected offset 42, got %+v (found=%v, err=%v)", got, ok, err)
+	}
+	if _, ok, _ := s.Get("logtail", "/var/log/syslog"); !ok {
+		t.Error("Expected the value in the logtail namespace")
+	}
+
+	// Without a store nothing is kept
+	var none *Store
+	if err := none.Namespace("logtail").PutJSON("k", cursor{}); err != nil {
+		t.Errorf("Put without a store: %v", err)
+	}
+	if ok, err := none.Namespace("logtail").GetJSON("k", &got); ok || err != nil {
+		t.Errorf("Get without a store: found=%v err=%v", ok, err)
+	}
+}
+
+func TestCorruptDatabaseIsRecovered(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	os.WriteFile(path, []byte(strings.Repeat("not a sqlite database ", 10)), 0600)
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Expected recovery, got error: %v", err)
+	}
+	defer s.Close()
+
+	if !s.Stats().Recovered {
+		t.Error("Expected store to report recovery")
+	}
+	if err := s.Put("cron", "k", "v"); err != nil {
+		t.Errorf("Recovered store not writable: %v", err)
+	}
+
+	matches, _ := filepath.Glob(path + ".corrupt-*")
+	if len(matches) != 1 {
+		t.Errorf("Expected corrupt file to be kept aside, found %v", matches)
+	}
+}
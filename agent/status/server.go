@@ -0,0 +1,121 @@
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultListen is the local health endpoint address ("off" disables it)
+const DefaultListen = "127.0.0.1:9810"
+
+// Health is served on GET /healthz: the latest snapshot plus derived fields.
+// Any local user can read it, so cron jobs are only counted (their commands
+// stay in the root-only status file).
+type Health struct {
+	Status        string `json:"status"` // ok, or stale when the main loop stopped updating
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	EBPFLoaded    bool   `json:"ebpf_loaded"`
+	CronJobCount  int    `json:"cron_job_count"`
+	Snapshot
+}
+
+// Server serves the latest snapshot on localhost for supervisors, k8s probes
+// and the status subcommand
+type Server struct {
+	listener   net.Listener
+	httpServer *http.Server
+
+	mu   sync.RWMutex
+	snap Snapshot
+}
+
+// Listen starts the health endpoint on addr
+func Listen(addr string) (*Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{listener: ln}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealth)
+	s.httpServer = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("❌ Health endpoint stopped: %v", err)
+		}
+	}()
+	return s, nil
+}
+
+// Addr returns the address the endpoint listens on
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Update replaces the snapshot served by the endpoint
+func (s *Server) Update(snap Snapshot) {
+	s.mu.Lock()
+	s.snap = snap
+	s.mu.Unlock()
+}
+
+// Close shuts down the endpoint
+func (s *Server) Close() error {
+	return s.httpServer.Close()
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.RLock()
+	snap := s.snap
+	s.mu.RUnlock()
+
+	now := time.Now()
+	h := Health{
+		Status:        "ok",
+		UptimeSeconds: now.Unix() - snap.StartedAt,
+		EBPFLoaded:    snap.EBPF == "full" || snap.EBPF == "basic",
+		CronJobCount:  len(snap.CronJobs),
+		Snapshot:      snap,
+	}
+	h.CronJobs = nil
+	code := http.StatusOK
+	if snap.UpdatedAt == 0 || snap.Stale(now) {
+		h.Status = "stale"
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(h)
+}
+
+// Fetch reads the health of the agent listening on addr
+func Fetch(addr string) (Health, error) {
+	var h Health
+	client := &http.Client{Timeout: 3 * time.Second}
+	resp, err := client.Get("http://" + addr + "/healthz")
+	if err != nil {
+		return h, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return h, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&h); err != nil {
+		return h, fmt.Errorf("invalid health response: %w", err)
+	}
+	return h, nil
+}
@@ -0,0 +1,40 @@
+package status
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestHealthEndpoint(t *testing.T) {
+	s, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	h, err := Fetch(s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Status != "stale" {
+		t.Errorf("before the first update the agent must be reported stale, got %s", h.Status)
+	}
+
+	now := time.Now()
+	s.Update(Snapshot{PID: os.Getpid(), StartedAt: now.Add(-time.Minute).Unix(), UpdatedAt: now.Unix(), Interval: 30, QueueSize: 2, EBPF: "basic"})
+	if h, err = Fetch(s.Addr()); err != nil {
+		t.Fatal(err)
+	}
+	if h.Status != "ok" || !h.EBPFLoaded || h.QueueSize != 2 || h.UptimeSeconds < 60 {
+		t.Errorf("unexpected health: %+v", h)
+	}
+
+	s.Update(Snapshot{StartedAt: now.Add(-time.Hour).Unix(), UpdatedAt: now.Add(-10 * time.Minute).Unix(), Interval: 30, EBPF: "none"})
+	if h, err = Fetch(s.Addr()); err != nil {
+		t.Fatal(err)
+	}
+	if h.Status != "stale" || h.EBPFLoaded {
+		t.Errorf("agent missing its updates must be stale: %+v", h)
+	}
+}
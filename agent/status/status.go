@@ -0,0 +1,175 @@
+// Package status shares the state of the running agent with the
+// `nodeguarder-agent status` subcommand, supervisors and probes, through a
+// local /healthz endpoint and a small JSON file next to the config, so a node
+// can be inspected over SSH without the dashboard.
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+// FileName is the status file written next to the agent config
+const FileName = "status.json"
+
+// Snapshot is the agent state written after every push
+type Snapshot struct {
+	Version   string `json:"version"`
+	Build     string `json:"build"`
+	PID       int    `json:"pid"`
+	StartedAt int64  `json:"started_at"`
+	UpdatedAt int64  `json:"updated_at"`
+	Interval  int    `json:"interval"` // push interval (seconds)
+
+	ServerID  string `json:"server_id"`
+	Dashboard string `json:"dashboard"`
+	Transport string `json:"transport"`
+	Connected bool   `json:"connected"`
+	LastPush  int64  `json:"last_push,omitempty"`  // last successful metrics push
+	LastError string `json:"last_error,omitempty"` // error of the last failed push
+	QueueSize int    `json:"queue_size"`           // items waiting for the dashboard
+
+	EBPF          string `json:"ebpf"` // full, basic or none
+	EBPFError     string `json:"ebpf_error,omitempty"`
+	ProcConnector bool   `json:"proc_connector"` // cron exit codes without eBPF
+
+	CronJobs []CronJob `json:"cron_jobs,omitempty"`
+}
+
+// CronJob is a tracked cron job as shown by the status subcommand
+type CronJob struct {
+	Command  string `json:"command"`
+	User     string `json:"user,omitempty"`
+	Schedule string `json:"schedule,omitempty"`
+	LastRun  int64  `json:"last_run,omitempty"`
+	ExitCode int    `json:"exit_code"`
+	Running  bool   `json:"running"`
+	Failures int    `json:"failures"`
+}
+
+// Write replaces the status file atomically, readable by root only
+// (cron commands may carry arguments worth keeping private)
+func Write(path string, s Snapshot) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// Read loads the status file written by the running agent
+func Read(path string) (Snapshot, error) {
+	var s Snapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("invalid status file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Running reports whether the process that wrote the snapshot is still alive
+func (s Snapshot) Running() bool {
+	if s.PID <= 0 {
+		return false
+	}
+	err := syscall.Kill(s.PID, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// Stale reports whether the agent has missed updates for more than two intervals
+func (s Snapshot) Stale(now time.Time) bool {
+	interval := int64(s.Interval)
+	if interval <= 0 {
+		interval = 60
+	}
+	return now.Unix()-s.UpdatedAt > 2*interval+30
+}
+
+// Print writes the snapshot for humans
+func Print(w io.Writer, s Snapshot, now time.Time) {
+	state := "running"
+	if !s.Running() {
+		state = "not running"
+	} else if s.Stale(now) {
+		state = "running, status not updated since " + ago(s.UpdatedAt, now)
+	}
+	fmt.Fprintf(w, "Agent:        v%s (%s build), pid %d, %s\n", s.Version, s.Build, s.PID, state)
+	fmt.Fprintf(w, "Started:      %s\n", ago(s.StartedAt, now))
+	fmt.Fprintf(w, "Server ID:    %s\n", s.ServerID)
+	fmt.Fprintf(w, "Dashboard:    %s (%s)\n", s.Dashboard, s.Transport)
+
+	connection := "connected"
+	if !s.Connected {
+		connection = "disconnected"
+	}
+	fmt.Fprintf(w, "Connection:   %s\n", connection)
+	fmt.Fprintf(w, "Last push:    %s\n", ago(s.LastPush, now))
+	if s.LastError != "" {
+		fmt.Fprintf(w, "Last error:   %s\n", s.LastError)
+	}
+	fmt.Fprintf(w, "Queue:        %d items\n", s.QueueSize)
+
+	ebpf := s.EBPF
+	if s.EBPFError != "" {
+		ebpf += " (" + s.EBPFError + ")"
+	}
+	if s.ProcConnector {
+		ebpf += ", proc connector fallback active"
+	}
+	fmt.Fprintf(w, "eBPF:         %s\n", ebpf)
+
+	fmt.Fprintf(w, "\nCron jobs (%d):\n", len(s.CronJobs))
+	if len(s.CronJobs) == 0 {
+		return
+	}
+	jobs := append([]CronJob(nil), s.CronJobs...)
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].Command < jobs[j].Command })
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  SCHEDULE\tUSER\tLAST RUN\tEXIT\tFAILURES\tCOMMAND")
+	for _, j := range jobs {
+		exit := fmt.Sprint(j.ExitCode)
+		if j.Running {
+			exit = "running"
+		} else if j.LastRun == 0 {
+			exit = "-"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%d\t%s\n", orDash(j.Schedule), orDash(j.User), ago(j.LastRun, now), exit, j.Failures, j.Command)
+	}
+	tw.Flush()
+}
+
+// ago formats a unix time relative to now
+func ago(ts int64, now time.Time) string {
+	if ts == 0 {
+		return "never"
+	}
+	d := now.Sub(time.Unix(ts, 0)).Truncate(time.Second)
+	if d < 0 {
+		d = 0
+	}
+	return d.String() + " ago"
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,62 @@
+package status
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteReadPrint(t *testing.T) {
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), FileName)
+	want := Snapshot{
+		Version:   "1.2.3",
+		Build:     "full",
+		PID:       os.Getpid(),
+		StartedAt: now.Add(-time.Hour).Unix(),
+		UpdatedAt: now.Unix(),
+		Interval:  30,
+		ServerID:  "srv-1",
+		Transport: "rest",
+		Connected: true,
+		LastPush:  now.Add(-10 * time.Second).Unix(),
+		QueueSize: 4,
+		EBPF:      "full",
+		CronJobs: []CronJob{
+			{Command: "/usr/local/bin/backup.sh", Schedule: "0 2 * * *", LastRun: now.Add(-time.Minute).Unix(), ExitCode: 1, Failures: 2},
+			{Command: "/usr/bin/true", Schedule: "* * * * *"},
+		},
+	}
+	if err := Write(path, want); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Fatalf("status file must be private: %v %v", info.Mode(), err)
+	}
+
+	got, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Running() || got.Stale(now) {
+		t.Error("snapshot of this process must be running and current")
+	}
+	if !got.Stale(now.Add(2 * time.Minute)) {
+		t.Error("snapshot not updated for two intervals must be stale")
+	}
+
+	var out bytes.Buffer
+	Print(&out, got, now)
+	for _, s := range []string{"v1.2.3 (full build)", "Queue:        4 items", "Last push:    10s ago", "Cron jobs (2)", "0 2 * * *"} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("output lacks %q:\n%s", s, out.String())
+		}
+	}
+	// Jobs are sorted by command
+	if i, j := strings.Index(out.String(), "/usr/bin/true"), strings.Index(out.String(), "backup.sh"); i > j {
+		t.Errorf("jobs must be sorted by command:\n%s", out.String())
+	}
+}
@@ -0,0 +1,129 @@
+package terminal
+
+import (
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Transport moves terminal data between the agent and the dashboard
+type Transport interface {
+	// TerminalInput long-polls for keystrokes; closed is true once the admin ends the session
+	TerminalInput(sessionID string) (data []byte, closed bool, err error)
+	// TerminalOutput sends shell output; closed signals the shell has exited
+	TerminalOutput(sessionID string, data []byte, closed bool) error
+}
+
+// IdleTimeout ends sessions with no keystrokes
+const IdleTimeout = 15 * time.Minute
+
+var (
+	activeMu sync.Mutex
+	active   = make(map[string]bool)
+)
+
+// Run opens an outbound shell session for the dashboard and blocks until it ends
+func Run(t Transport, sessionID string) {
+	activeMu.Lock()
+	if active[sessionID] {
+		activeMu.Unlock()
+		return
+	}
+	active[sessionID] = true
+	activeMu.Unlock()
+	defer func() {
+		activeMu.Lock()
+		delete(active, sessionID)
+		activeMu.Unlock()
+	}()
+
+	cmd := shellCommand()
+	cmd.Env = append(os.Environ(), "TERM=xterm")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		log.Printf("❌ Terminal %s: %v", sessionID, err)
+		return
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		log.Printf("❌ Terminal %s: %v", sessionID, err)
+		return
+	}
+	cmd.Stderr = cmd.Stdout
+
+	if err := cmd.Start(); err != nil {
+		log.Printf("❌ Terminal %s: failed to start shell: %v", sessionID, err)
+		t.TerminalOutput(sessionID, []byte("failed to start shell: "+err.Error()+"\r\n"), true)
+		return
+	}
+	log.Printf("🖥️  Remote terminal session %s opened", sessionID)
+
+	done := make(chan struct{})
+
+	// Shell output -> dashboard
+	go func() {
+		defer close(done)
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := stdout.Read(buf)
+			if n > 0 {
+				if sendErr := t.TerminalOutput(sessionID, buf[:n], false); sendErr != nil {
+					log.Printf("Terminal %s: failed to send output: %v", sessionID, sendErr)
+				}
+			}
+			if err != nil {
+				if err != io.EOF {
+					log.Printf("Terminal %s: read error: %v", sessionID, err)
+				}
+				return
+			}
+		}
+	}()
+
+	// Dashboard -> shell input
+	go func() {
+		lastInput := time.Now()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			data, closed, err := t.TerminalInput(sessionID)
+			if err != nil {
+				log.Printf("Terminal %s: input poll failed: %v", sessionID, err)
+				time.Sleep(2 * time.Second)
+			}
+			if closed || time.Since(lastInput) > IdleTimeout {
+				stdin.Close()
+				cmd.Process.Kill()
+				return
+			}
+			if len(data) > 0 {
+				lastInput = time.Now()
+				stdin.Write(data)
+			}
+		}
+	}()
+
+	<-done
+	cmd.Wait()
+	t.TerminalOutput(sessionID, nil, true)
+	log.Printf("🖥️  Remote terminal session %s closed", sessionID)
+}
+
+// shellCommand prefers util-linux `script` to give the shell a pseudo-terminal
+func shellCommand() *exec.Cmd {
+	shell := "/bin/bash"
+	if _, err := os.Stat(shell); err != nil {
+		shell = "/bin/sh"
+	}
+	if path, err := exec.LookPath("script"); err == nil {
+		return exec.Command(path, "-qfc", shell+" -l", "/dev/null")
+	}
+	return exec.Command(shell, "-i")
+}
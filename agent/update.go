@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/yourusername/nodeguarder/api"
+	"github.com/yourusername/nodeguarder/config"
+	"github.com/yourusername/nodeguarder/updater"
+)
+
+// rollbackWindow is how long an updated agent has to complete its first push
+func rollbackWindow(cfg *config.Config) time.Duration {
+	if cfg.UpdateRollbackMinutes > 0 {
+		return time.Duration(cfg.UpdateRollbackMinutes) * time.Minute
+	}
+	return updater.DefaultRollbackWindow
+}
+
+// checkPendingUpdate runs at startup, before anything that could crash. It
+// returns the update this version must confirm with a push, or the rollback
+// this (previous) version must report. A new version past its deadline, e.g.
+// one that keeps crashing, is rolled back here and the agent exits.
+func checkPendingUpdate(markerPath string, window time.Duration) (pending, rolledBack *updater.Marker) {
+	marker, err := updater.ReadMarker(markerPath)
+	if err != nil {
+		log.Printf("⚠️  %v", err)
+		updater.ClearMarker(markerPath)
+		return nil, nil
+	}
+	switch {
+	case marker == nil:
+		return nil, nil
+	case marker.RolledBack && marker.Reported:
+		return nil, nil
+	case marker.RolledBack:
+		return nil, marker
+	case marker.ToVersion != Version:
+		// The update never started (or was replaced by hand)
+		updater.ClearMarker(markerPath)
+		return nil, nil
+	case time.Now().After(marker.Deadline(window)):
+		if rollbackUpdate(markerPath, marker, window) {
+			os.Exit(1)
+		}
+		return nil, nil
+	}
+	log.Printf("🔄 Running updated agent v%s, rolling back to v%s unless a push succeeds by %s",
+		marker.ToVersion, marker.FromVersion, marker.Deadline(window).Format(time.RFC3339))
+	return marker, nil
+}
+
+// rollbackUpdate swaps the previous binary back. The caller then exits, so the
+// service manager restarts the previous version.
+func rollbackUpdate(markerPath string, marker *updater.Marker, window time.Duration) bool {
+	reason := fmt.Sprintf("v%s did not complete a push within %s of the update", marker.ToVersion, window)
+	if err := updater.Rollback(markerPath, marker, reason); err != nil {
+		log.Printf("❌ Update rollback failed: %v", err)
+		updater.ClearMarker(markerPath)
+		return false
+	}
+	log.Printf("⏪ %s, rolled back to v%s. Exiting to restart...", reason, marker.FromVersion)
+	return true
+}
+
+// skipUpdate reports whether version was rolled back on this host before. The
+// marker is replaced when another version is installed.
+func skipUpdate(markerPath, version string) bool {
+	marker, err := updater.ReadMarker(markerPath)
+	return err == nil && marker != nil && marker.RolledBack && marker.ToVersion == version
+}
+
+// reportRollback records the rollback as an event once the previous version
+// reaches the dashboard again
+func reportRollback(client *api.Client, markerPath string, marker *updater.Marker) {
+	event := api.Event{
+		Type:      "update",
+		Severity:  "warning",
+		Message:   fmt.Sprintf("Agent update rolled back: %s; running v%s again", marker.Reason, marker.FromVersion),
+		Timestamp: time.Now().Unix(),
+	}
+	if data, err := json.Marshal(marker); err == nil {
+		event.Details = string(data)
+	}
+	if err := client.PushEvents([]api.Event{event}); err != nil {
+		log.Printf("Warning: Failed to report update rollback: %v", err)
+	}
+	// Queued when the push failed, so it is reported either way
+	marker.Reported = true
+	if err := updater.WriteMarker(markerPath, marker); err != nil {
+		log.Printf("Warning: Failed to update the update marker: %v", err)
+	}
+}
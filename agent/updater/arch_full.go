@@ -0,0 +1,10 @@
+//go:build !lite
+
+package updater
+
+import "runtime"
+
+// downloadArch returns the architecture name used for update downloads
+func downloadArch() string {
+	return runtime.GOARCH
+}
@@ -0,0 +1,8 @@
+//go:build lite
+
+package updater
+
+// downloadArch keeps lite agents on the lite binary when they self-update
+func downloadArch() string {
+	return "armv6"
+}
@@ -0,0 +1,45 @@
+package updater
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"runtime"
+)
+
+// Arch returns the architecture name of this build in the dashboard's binary
+// manifest, prefixed with the OS outside Linux (e.g. "darwin-arm64")
+func Arch() string {
+	if runtime.GOOS != "linux" {
+		return runtime.GOOS + "-" + downloadArch()
+	}
+	return downloadArch()
+}
+
+// BinaryChecksum returns the SHA256 of the running agent binary. /proc/self/exe
+// still refers to the running image after an update has replaced the file on disk.
+func BinaryChecksum() (string, error) {
+	path := "/proc/self/exe"
+	if _, err := os.Stat(path); err != nil {
+		if path, err = os.Executable(); err != nil {
+			return "", err
+		}
+	}
+	return FileChecksum(path)
+}
+
+// FileChecksum returns the hex SHA256 of a file's content
+func FileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,116 @@
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MarkerFile records an update until the new version completed a push
+const MarkerFile = "update-pending.json"
+
+// BackupSuffix names the previous binary kept next to the running one
+const BackupSuffix = ".old"
+
+// DefaultRollbackWindow is how long a new version has to complete its first push
+const DefaultRollbackWindow = 10 * time.Minute
+
+// Marker is the post-update health marker written by ApplyUpdate
+type Marker struct {
+	FromVersion string `json:"from_version"`
+	ToVersion   string `json:"to_version"`
+	Binary      string `json:"binary"`
+	Backup      string `json:"backup"`
+	AppliedAt   int64  `json:"applied_at"`
+	RolledBack  bool   `json:"rolled_back,omitempty"` // swapped back, to be reported by the previous version
+	Reason      string `json:"reason,omitempty"`
+	Reported    bool   `json:"reported,omitempty"` // rollback reported; kept so the version is not installed again
+}
+
+// Deadline is when the new version must have completed a push
+func (m *Marker) Deadline(window time.Duration) time.Time {
+	return time.Unix(m.AppliedAt, 0).Add(window)
+}
+
+// ReadMarker returns the marker at path, nil if there is no update pending
+func ReadMarker(path string) (*Marker, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m Marker
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("corrupt update marker %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// WriteMarker stores the marker atomically
+func WriteMarker(path string, m *Marker) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ClearMarker removes the marker once the update was confirmed or its rollback reported
+func ClearMarker(path string) error {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Rollback swaps the kept previous binary back in place of the update and
+// records it in the marker, so the previous version reports it after restart
+func Rollback(markerPath string, m *Marker, reason string) error {
+	if _, err := os.Stat(m.Backup); err != nil {
+		return fmt.Errorf("no previous binary to roll back to: %w", err)
+	}
+	if err := os.Rename(m.Backup, m.Binary); err != nil {
+		return fmt.Errorf("failed to restore previous binary: %w", err)
+	}
+	m.RolledBack = true
+	m.Reason = reason
+	return WriteMarker(markerPath, m)
+}
+
+// backupBinary copies the running binary to exePath+BackupSuffix. It is copied
+// rather than moved so exePath exists at every moment of the update.
+func backupBinary(exePath string) (string, error) {
+	backup := exePath + BackupSuffix
+	src, err := os.Open(exePath)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(exePath), filepath.Base(backup)+".*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return "", err
+	}
+	return backup, os.Rename(tmp.Name(), backup)
+}
@@ -0,0 +1,49 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRollbackRestoresPreviousBinary(t *testing.T) {
+	dir := t.TempDir()
+	exePath := filepath.Join(dir, "nodeguarder-agent")
+	markerPath := filepath.Join(dir, MarkerFile)
+	os.WriteFile(exePath, []byte("v1"), 0755)
+
+	backup, err := backupBinary(exePath)
+	if err != nil {
+		t.Fatalf("backupBinary: %v", err)
+	}
+	if backup != exePath+BackupSuffix {
+		t.Fatalf("backup kept as %s", backup)
+	}
+	marker := &Marker{FromVersion: "1", ToVersion: "2", Binary: exePath, Backup: backup, AppliedAt: time.Now().Unix()}
+	if err := WriteMarker(markerPath, marker); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(exePath, []byte("v2"), 0755) // the update
+
+	if err := Rollback(markerPath, marker, "no push"); err != nil {
+		t.Fatalf("Rollback: %v", err)
+	}
+	if data, _ := os.ReadFile(exePath); string(data) != "v1" {
+		t.Fatalf("binary after rollback = %q, want v1", data)
+	}
+	stored, err := ReadMarker(markerPath)
+	if err != nil || stored == nil {
+		t.Fatalf("marker lost after rollback: %v", err)
+	}
+	if !stored.RolledBack || stored.Reason != "no push" || stored.ToVersion != "2" {
+		t.Fatalf("marker after rollback = %+v", stored)
+	}
+
+	if err := ClearMarker(markerPath); err != nil {
+		t.Fatal(err)
+	}
+	if m, err := ReadMarker(markerPath); m != nil || err != nil {
+		t.Fatalf("marker after clear = %+v, %v", m, err)
+	}
+}
@@ -0,0 +1,78 @@
+package updater
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// PublicKey is the Ed25519 key update binaries must be signed with: the
+// dashboard's license public.key, base64 encoded and set at build time with
+//
+//	-ldflags "-X github.com/yourusername/nodeguarder/updater.PublicKey=$(base64 -w0 public.key)"
+//
+// Agents built without it refuse every update.
+var PublicKey string
+
+// errNoPublicKey is returned when the agent has no key to verify updates with
+var errNoPublicKey = errors.New("agent was built without an update signing key, refusing unsigned update")
+
+// publicKey decodes PublicKey (base64 of a PEM "PUBLIC KEY" block)
+func publicKey() (ed25519.PublicKey, error) {
+	if PublicKey == "" {
+		return nil, errNoPublicKey
+	}
+	pemData, err := base64.StdEncoding.DecodeString(strings.TrimSpace(PublicKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid update signing key: %w", err)
+	}
+	block, _ := pem.Decode(pemData)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("invalid update signing key: no PEM public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid update signing key: %w", err)
+	}
+	ed25519Pub, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("update signing key is not an Ed25519 key")
+	}
+	return ed25519Pub, nil
+}
+
+// fetchSignature downloads the detached, base64 encoded signature of a binary
+func fetchSignature(client *http.Client, url string) (string, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", fmt.Errorf("failed to download signature: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("update is not signed (signature download returned status %d)", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", fmt.Errorf("failed to read signature: %w", err)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// verifySignature checks a detached signature of a downloaded binary
+func verifySignature(pub ed25519.PublicKey, binary []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("malformed update signature: %w", err)
+	}
+	if len(sig) != ed25519.SignatureSize || !ed25519.Verify(pub, binary, sig) {
+		return errors.New("update signature does not match, refusing binary")
+	}
+	return nil
+}
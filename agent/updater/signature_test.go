@@ -0,0 +1,42 @@
+package updater
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+)
+
+func TestVerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+
+	defer func(old string) { PublicKey = old }(PublicKey)
+	PublicKey = ""
+	if _, err := publicKey(); err != errNoPublicKey {
+		t.Fatalf("expected unsigned updates to be refused without a key, got %v", err)
+	}
+
+	PublicKey = base64.StdEncoding.EncodeToString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	key, err := publicKey()
+	if err != nil {
+		t.Fatalf("publicKey: %v", err)
+	}
+
+	binary := []byte("new agent binary")
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, binary))
+	if err := verifySignature(key, binary, signature); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := verifySignature(key, []byte("tampered agent binary"), signature); err == nil {
+		t.Fatal("signature accepted for a modified binary")
+	}
+	if err := verifySignature(key, binary, ""); err == nil {
+		t.Fatal("empty signature accepted")
+	}
+}
@@ -5,9 +5,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
-
 	"runtime"
+
 	"time"
 )
 
@@ -42,13 +43,21 @@ func CheckForUpdate(dashboardURL, currentVersion string) (bool, string, error) {
 	return false, "", nil
 }
 
-// ApplyUpdate downloads and applies the update
-func ApplyUpdate(dashboardURL, version string) error {
+// ApplyUpdate downloads and applies the update. The running binary is kept as
+// <binary>.old and a marker is written to markerPath, so the new version rolls
+// back if it does not complete a push in time (see Rollback).
+func ApplyUpdate(dashboardURL, currentVersion, version, markerPath string) error {
 	// Determine architecture
-	arch := runtime.GOARCH
-	// Map common archs if needed, though Go's runtime.GOARCH usually matches
+	arch := downloadArch()
 	
-	downloadURL := fmt.Sprintf("%s/api/v1/agent/download/linux/%s", dashboardURL, arch)
+	downloadURL := fmt.Sprintf("%s/api/v1/agent/download/%s/%s", dashboardURL, runtime.GOOS, arch)
+	query := "?version=" + url.QueryEscape(version)
+
+	// Only binaries signed with the embedded key are installed
+	pub, err := publicKey()
+	if err != nil {
+		return err
+	}
 	
 	// Get current executable path
 	exePath, err := os.Executable()
@@ -64,7 +73,7 @@ func ApplyUpdate(dashboardURL, version string) error {
 	defer os.Remove(tmpFile.Name())
 
 	// Download new binary
-	resp, err := http.Get(downloadURL)
+	resp, err := http.Get(downloadURL + query)
 	if err != nil {
 		return fmt.Errorf("failed to download update: %w", err)
 	}
@@ -79,13 +88,43 @@ func ApplyUpdate(dashboardURL, version string) error {
 	}
 	tmpFile.Close() // Close so we can execute/move it
 
+	// Verify the detached signature before the binary goes anywhere near exePath
+	signature, err := fetchSignature(&http.Client{Timeout: 30 * time.Second}, downloadURL+"/signature"+query)
+	if err != nil {
+		return err
+	}
+	binary, err := os.ReadFile(tmpFile.Name())
+	if err != nil {
+		return fmt.Errorf("failed to read update: %w", err)
+	}
+	if err := verifySignature(pub, binary, signature); err != nil {
+		return err
+	}
+
 	// Make executable
 	if err := os.Chmod(tmpFile.Name(), 0755); err != nil {
 		return fmt.Errorf("failed to chmod: %w", err)
 	}
 
+	// Keep the previous binary for rollback
+	backup, err := backupBinary(exePath)
+	if err != nil {
+		return fmt.Errorf("failed to keep previous binary: %w", err)
+	}
+	marker := &Marker{
+		FromVersion: currentVersion,
+		ToVersion:   version,
+		Binary:      exePath,
+		Backup:      backup,
+		AppliedAt:   time.Now().Unix(),
+	}
+	if err := WriteMarker(markerPath, marker); err != nil {
+		return fmt.Errorf("failed to write update marker: %w", err)
+	}
+
 	// Replace binary (atomic rename)
 	if err := os.Rename(tmpFile.Name(), exePath); err != nil {
+		ClearMarker(markerPath)
 		return fmt.Errorf("failed to replace binary: %w", err)
 	}
 
@@ -0,0 +1,218 @@
+// Package agentca issues and verifies the client certificates agents use for
+// mutual TLS. Agents generate their key locally and send a CSR, so private
+// keys never leave the host; the CA key is kept next to the database.
+package agentca
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// Validity of an issued agent certificate; agents renew after two thirds
+	Validity = 90 * 24 * time.Hour
+	// caValidity of the generated CA
+	caValidity = 10 * 365 * 24 * time.Hour
+	// CertKey is the request local holding a client certificate presented to a
+	// listener other than the main one (e.g. the gRPC server)
+	CertKey = "agent_client_cert"
+)
+
+var (
+	caCert    *x509.Certificate
+	caKey     *ecdsa.PrivateKey
+	caCertPEM []byte
+)
+
+// Init loads the agent CA from agent-ca.crt / agent-ca.key in dir, generating
+// them on first start
+func Init(dir string) error {
+	certPath := filepath.Join(dir, "agent-ca.crt")
+	keyPath := filepath.Join(dir, "agent-ca.key")
+
+	certPEM, certErr := os.ReadFile(certPath)
+	keyPEM, keyErr := os.ReadFile(keyPath)
+	if os.IsNotExist(certErr) && os.IsNotExist(keyErr) {
+		var err error
+		if certPEM, keyPEM, err = generateCA(); err != nil {
+			return err
+		}
+		if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", keyPath, err)
+		}
+		if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", certPath, err)
+		}
+		log.Printf("🔑 Generated agent CA at %s (back it up with the database)", certPath)
+	} else if certErr != nil {
+		return fmt.Errorf("failed to read %s: %w", certPath, certErr)
+	} else if keyErr != nil {
+		return fmt.Errorf("failed to read %s: %w", keyPath, keyErr)
+	}
+
+	cert, err := parseCertPEM(certPEM)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", certPath, err)
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return fmt.Errorf("invalid %s", keyPath)
+	}
+	key, err := x509.ParseECPrivateKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", keyPath, err)
+	}
+	caCert, caKey, caCertPEM = cert, key, certPEM
+	return nil
+}
+
+func generateCA() ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "NodeGuarder Agent CA"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// CertPEM returns the CA certificate, for proxies that verify agent certificates
+func CertPEM() []byte {
+	return caCertPEM
+}
+
+// Pool returns the CA as a pool for tls.Config.ClientCAs
+func Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	if caCert != nil {
+		pool.AddCert(caCert)
+	}
+	return pool
+}
+
+// Issue signs a client certificate for serverID from a PEM encoded CSR whose
+// common name must be the server ID
+func Issue(serverID string, csrPEM []byte) ([]byte, *x509.Certificate, error) {
+	if caCert == nil {
+		return nil, nil, errors.New("agent CA not initialized")
+	}
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, nil, errors.New("csr must be a PEM encoded certificate request")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid csr: %w", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, nil, fmt.Errorf("invalid csr signature: %w", err)
+	}
+	if csr.Subject.CommonName != serverID {
+		return nil, nil, errors.New("csr common name must be the server ID")
+	}
+
+	serial, err := randomSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: serverID, Organization: []string{"NodeGuarder Agent"}},
+		NotBefore:    now.Add(-5 * time.Minute),
+		NotAfter:     now.Add(Validity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, csr.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), cert, nil
+}
+
+// Verify checks that cert was issued by the agent CA for client auth and is
+// currently valid, and returns the server ID it was issued to. Revocation is
+// checked by the caller against the issued certificates.
+func Verify(cert *x509.Certificate) (string, error) {
+	if caCert == nil {
+		return "", errors.New("agent CA not initialized")
+	}
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:     Pool(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return "", err
+	}
+	return cert.Subject.CommonName, nil
+}
+
+// ParseHeader decodes a client certificate forwarded by a TLS-terminating
+// proxy as URL-escaped PEM (nginx $ssl_client_escaped_cert)
+func ParseHeader(value string) (*x509.Certificate, error) {
+	unescaped, err := url.QueryUnescape(value)
+	if err != nil {
+		return nil, err
+	}
+	return parseCertPEM([]byte(unescaped))
+}
+
+// Serial formats a certificate serial number as stored
+func Serial(cert *x509.Certificate) string {
+	return fmt.Sprintf("%x", cert.SerialNumber)
+}
+
+func parseCertPEM(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("no PEM certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func randomSerial() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial: %w", err)
+	}
+	return serial, nil
+}
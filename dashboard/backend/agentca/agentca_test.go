@@ -0,0 +1,66 @@
+package agentca
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net/url"
+	"testing"
+)
+
+func newCSR(t *testing.T, cn string) []byte {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: cn}}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	if err := Init(dir); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	first := CertPEM()
+	if err := Init(dir); err != nil {
+		t.Fatalf("Init reload: %v", err)
+	}
+	if string(CertPEM()) != string(first) {
+		t.Fatal("reloading must keep the generated CA")
+	}
+
+	if _, _, err := Issue("srv-1", newCSR(t, "srv-2")); err == nil {
+		t.Error("expected a CSR for another server to be rejected")
+	}
+
+	certPEM, cert, err := Issue("srv-1", newCSR(t, "srv-1"))
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	id, err := Verify(cert)
+	if err != nil || id != "srv-1" {
+		t.Fatalf("Verify = %q, %v", id, err)
+	}
+
+	// Forwarded by a proxy as URL-escaped PEM
+	parsed, err := ParseHeader(url.QueryEscape(string(certPEM)))
+	if err != nil || Serial(parsed) != Serial(cert) {
+		t.Fatalf("ParseHeader: %v", err)
+	}
+
+	// A certificate from another CA must not verify
+	if err := Init(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Verify(cert); err == nil {
+		t.Error("expected a certificate from another CA to be rejected")
+	}
+}
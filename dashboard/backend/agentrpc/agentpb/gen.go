@@ -0,0 +1,4 @@
+package agentpb
+
+// Generated from the agent's service definition, which is the source of truth
+//go:generate protoc -I ../../../../agent/api/agentpb --go_out=. --go_opt=paths=source_relative,Magent.proto=github.com/yourusername/health-dashboard-backend/agentrpc/agentpb --go-grpc_out=. --go-grpc_opt=paths=source_relative,Magent.proto=github.com/yourusername/health-dashboard-backend/agentrpc/agentpb agent.proto
@@ -0,0 +1,260 @@
+// Package agentrpc serves the agent API over gRPC. Each call is translated to
+// the matching REST endpoint and dispatched in-process through the Fiber app,
+// so both transports share authentication, validation and storage.
+package agentrpc
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/yourusername/health-dashboard-backend/agentca"
+	"github.com/yourusername/health-dashboard-backend/agentrpc/agentpb"
+	"github.com/yourusername/health-dashboard-backend/push"
+)
+
+// ConfigPollInterval is how often an open config stream re-reads the agent
+// configuration when no change was signalled; a changed config is pushed
+// right away
+var ConfigPollInterval = 10 * time.Second
+
+// Server implements agentpb.AgentServiceServer on top of the REST handlers
+type Server struct {
+	agentpb.UnimplementedAgentServiceServer
+	handler fasthttp.RequestHandler
+}
+
+// NewServer wraps the Fiber app; call it after all routes are registered
+func NewServer(app *fiber.App) *Server {
+	return &Server{handler: app.Handler()}
+}
+
+// Serve registers the service on a new gRPC server and serves lis until it fails
+func Serve(lis net.Listener, app *fiber.App, opts ...grpc.ServerOption) error {
+	opts = append(opts, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: 30 * time.Second, PermitWithoutStream: true}))
+	srv := grpc.NewServer(opts...)
+	agentpb.RegisterAgentServiceServer(srv, NewServer(app))
+	return srv.Serve(lis)
+}
+
+// ListenAndServe serves the agent API on addr in the background, with TLS when
+// certFile and keyFile are set (plaintext suits a TLS-terminating proxy).
+// With TLS, agents may authenticate with a client certificate.
+func ListenAndServe(addr string, app *fiber.App, certFile, keyFile string) error {
+	var opts []grpc.ServerOption
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("loading gRPC TLS certificate: %w", err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(&tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   tls.VerifyClientCertIfGiven,
+			ClientCAs:    agentca.Pool(),
+			MinVersion:   tls.VersionTLS12,
+		})))
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := Serve(lis, app, opts...); err != nil {
+			log.Printf("⚠️  gRPC server stopped: %v", err)
+		}
+	}()
+	return nil
+}
+
+// agentCredentials reads the agent's "server-id" and "api-secret" metadata
+func agentCredentials(ctx context.Context) (string, string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	get := func(key string) string {
+		if v := md.Get(key); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	return get("server-id"), get("api-secret")
+}
+
+// call dispatches a request to the REST handlers and maps the HTTP status
+func (s *Server) call(ctx context.Context, method, uri string, payload interface{}) ([]byte, error) {
+	var req fasthttp.Request
+	req.Header.SetMethod(method)
+	req.SetRequestURI(uri)
+	req.Header.Set("User-Agent", "nodeguarder-agent/1.0 (grpc)")
+	if payload != nil {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		req.Header.SetContentType("application/json")
+		req.SetBody(body)
+	}
+
+	var remote net.Addr = &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remote = p.Addr
+	}
+	var rc fasthttp.RequestCtx
+	rc.Init(&req, remote, nil)
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.PeerCertificates) > 0 {
+			rc.SetUserValue(agentca.CertKey, info.State.PeerCertificates[0])
+		}
+	}
+	s.handler(&rc)
+
+	body := append([]byte(nil), rc.Response.Body()...)
+	code := rc.Response.StatusCode()
+	if code >= 200 && code < 300 {
+		return body, nil
+	}
+	var apiErr struct {
+		Error string `json:"error"`
+	}
+	msg := string(body)
+	if json.Unmarshal(body, &apiErr) == nil && apiErr.Error != "" {
+		msg = apiErr.Error
+	}
+	return nil, status.Error(httpCode(code), msg)
+}
+
+// httpCode maps an HTTP status to the closest gRPC code
+func httpCode(code int) codes.Code {
+	switch code {
+	case 400, 413:
+		return codes.InvalidArgument
+	case 401:
+		return codes.Unauthenticated
+	case 403:
+		return codes.PermissionDenied
+	case 404:
+		return codes.NotFound
+	case 409:
+		return codes.AlreadyExists
+	case 429:
+		return codes.ResourceExhausted
+	case 503:
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
+
+// Register mirrors POST /api/v1/agent/register
+func (s *Server) Register(ctx context.Context, req *agentpb.RegisterRequest) (*agentpb.Ack, error) {
+	serverID, apiSecret := agentCredentials(ctx)
+	_, err := s.call(ctx, "POST", "/api/v1/agent/register", map[string]interface{}{
+		"server_id":          serverID,
+		"api_secret":         apiSecret,
+		"hostname":           req.Hostname,
+		"os_name":            req.OsName,
+		"os_version":         req.OsVersion,
+		"agent_version":      req.AgentVersion,
+		"registration_token": req.RegistrationToken,
+		"package_id":         req.PackageId,
+		"arch":               req.Arch,
+		"binary_sha256":      req.BinarySha256,
+		"config_sha256":      req.ConfigSha256,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &agentpb.Ack{}, nil
+}
+
+// PushMetrics mirrors POST /api/v1/agent/metrics
+func (s *Server) PushMetrics(ctx context.Context, req *agentpb.MetricsRequest) (*agentpb.Ack, error) {
+	if !json.Valid(req.MetricsJson) {
+		return nil, status.Error(codes.InvalidArgument, "metrics_json is not valid JSON")
+	}
+	serverID, apiSecret := agentCredentials(ctx)
+	body, err := s.call(ctx, "POST", "/api/v1/agent/metrics", map[string]interface{}{
+		"server_id":  serverID,
+		"api_secret": apiSecret,
+		"timestamp":  req.Timestamp,
+		"metrics":    json.RawMessage(req.MetricsJson),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		FullSync bool `json:"full_sync"`
+	}
+	json.Unmarshal(body, &resp)
+	return &agentpb.Ack{FullSync: resp.FullSync}, nil
+}
+
+// PushEvents mirrors POST /api/v1/agent/events
+func (s *Server) PushEvents(ctx context.Context, req *agentpb.EventsRequest) (*agentpb.Ack, error) {
+	type event struct {
+		Type      string `json:"type"`
+		Severity  string `json:"severity"`
+		Message   string `json:"message"`
+		Timestamp int64  `json:"timestamp"`
+		Details   string `json:"details,omitempty"`
+	}
+	events := make([]event, len(req.Events))
+	for i, e := range req.Events {
+		events[i] = event{Type: e.Type, Severity: e.Severity, Message: e.Message, Timestamp: e.Timestamp, Details: e.Details}
+	}
+	serverID, apiSecret := agentCredentials(ctx)
+	_, err := s.call(ctx, "POST", "/api/v1/agent/events", map[string]interface{}{
+		"server_id":  serverID,
+		"api_secret": apiSecret,
+		"events":     events,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &agentpb.Ack{}, nil
+}
+
+// StreamConfig sends the configuration from GET /api/v1/agent/config on
+// connect and whenever it changes, until the agent disconnects
+func (s *Server) StreamConfig(_ *agentpb.ConfigRequest, stream agentpb.AgentService_StreamConfigServer) error {
+	ctx := stream.Context()
+	serverID, apiSecret := agentCredentials(ctx)
+	uri := fmt.Sprintf("/api/v1/agent/config?server_id=%s&api_secret=%s", url.QueryEscape(serverID), url.QueryEscape(apiSecret))
+
+	seq := push.Seq()
+	var last []byte
+	for {
+		cfg, err := s.call(ctx, "GET", uri, nil)
+		if err != nil {
+			if status.Code(err) != codes.Unauthenticated {
+				log.Printf("⚠️  gRPC config stream for %s: %v", serverID, err)
+			}
+			return err
+		}
+		if string(cfg) != string(last) {
+			if err := stream.Send(&agentpb.AgentConfig{ConfigJson: cfg}); err != nil {
+				return err
+			}
+			last = cfg
+		}
+
+		// Woken early by dashboard changes; polling still picks up the rest
+		// (e.g. scheduled script runs becoming due)
+		seq, _ = push.Wait(serverID, seq, ConfigPollInterval, ctx.Done())
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
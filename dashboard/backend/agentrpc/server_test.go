@@ -0,0 +1,105 @@
+package agentrpc
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/yourusername/health-dashboard-backend/agentrpc/agentpb"
+)
+
+func testClient(t *testing.T, app *fiber.App) agentpb.AgentServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	go Serve(lis, app)
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close(); lis.Close() })
+	return agentpb.NewAgentServiceClient(conn)
+}
+
+func TestPushMetricsUsesRESTHandler(t *testing.T) {
+	app := fiber.New()
+	var got struct {
+		ServerID  string                 `json:"server_id"`
+		APISecret string                 `json:"api_secret"`
+		Metrics   map[string]interface{} `json:"metrics"`
+	}
+	app.Post("/api/v1/agent/metrics", func(c *fiber.Ctx) error {
+		if err := c.BodyParser(&got); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+		if got.APISecret != "secret" {
+			return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
+		}
+		return c.JSON(fiber.Map{"status": "ok", "full_sync": true})
+	})
+	client := testClient(t, app)
+
+	ctx := metadata.NewOutgoingContext(context.Background(), metadata.Pairs("server-id", "srv-1", "api-secret", "secret"))
+	ack, err := client.PushMetrics(ctx, &agentpb.MetricsRequest{Timestamp: 1, MetricsJson: []byte(`{"cpu_usage":12.5}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ack.FullSync {
+		t.Error("full_sync from the REST handler must be passed on")
+	}
+	if got.ServerID != "srv-1" || got.Metrics["cpu_usage"] != 12.5 {
+		t.Errorf("handler got %+v", got)
+	}
+
+	ctx = metadata.NewOutgoingContext(context.Background(), metadata.Pairs("server-id", "srv-1", "api-secret", "wrong"))
+	_, err = client.PushMetrics(ctx, &agentpb.MetricsRequest{MetricsJson: []byte(`{}`)})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated, got %v", err)
+	}
+}
+
+func TestStreamConfigSendsChanges(t *testing.T) {
+	defer func(d time.Duration) { ConfigPollInterval = d }(ConfigPollInterval)
+	ConfigPollInterval = 10 * time.Millisecond
+
+	app := fiber.New()
+	var polls atomic.Int32
+	app.Get("/api/v1/agent/config", func(c *fiber.Ctx) error {
+		if c.Query("api_secret") != "secret" {
+			return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
+		}
+		if polls.Add(1) < 5 {
+			return c.JSON(fiber.Map{"drift_interval": 300})
+		}
+		return c.JSON(fiber.Map{"drift_interval": 60})
+	})
+	client := testClient(t, app)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs("server-id", "srv-1", "api-secret", "secret"))
+	stream, err := client.StreamConfig(ctx, &agentpb.ConfigRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`{"drift_interval":300}`, `{"drift_interval":60}`} {
+		msg, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(msg.ConfigJson) != want {
+			t.Errorf("got %s, want %s (unchanged configs must not be resent)", msg.ConfigJson, want)
+		}
+	}
+}
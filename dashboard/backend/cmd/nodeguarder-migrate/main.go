@@ -0,0 +1,76 @@
+// Command nodeguarder-migrate inspects and moves the dashboard database
+// between schema versions. The dashboard applies pending migrations itself on
+// startup; this is for checking an upgrade and for rolling one back before
+// starting an older release. Stop the dashboard first.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+func main() {
+	dbPath := flag.String("db", os.Getenv("DB_PATH"), "SQLite database (default: $DB_PATH or ./data/health.db)")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: nodeguarder-migrate [-db health.db] status | up | down <version>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *dbPath == "" {
+		*dbPath = "./data/health.db"
+	}
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := database.Open(*dbPath); err != nil {
+		fail(err)
+	}
+	defer database.Close()
+
+	switch flag.Arg(0) {
+	case "status":
+		migrations, err := database.MigrationStatus()
+		if err != nil {
+			fail(err)
+		}
+		for _, m := range migrations {
+			applied := "pending"
+			if m.AppliedAt != 0 {
+				applied = "applied " + time.Unix(m.AppliedAt, 0).Format(time.RFC3339)
+			}
+			fmt.Printf("%04d  %-32s %s\n", m.Version, m.Name, applied)
+		}
+	case "up":
+		if err := database.Migrate(); err != nil {
+			fail(err)
+		}
+	case "down":
+		if flag.NArg() != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		version, err := strconv.Atoi(flag.Arg(1))
+		if err != nil || version < 0 {
+			fail(fmt.Errorf("invalid version %q", flag.Arg(1)))
+		}
+		if err := database.MigrateDown(version); err != nil {
+			fail(err)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,45 @@
+// Command nodeguarder-sign writes the detached Ed25519 signature agents check
+// before installing an update. It signs with the license private key and stores
+// <binary>.sig next to each binary, where the dashboard serves it from.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/yourusername/health-dashboard-backend/license"
+)
+
+func main() {
+	keyPath := flag.String("key", "private.key", "Ed25519 private key (PEM, PKCS#8)")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: nodeguarder-sign [-key private.key] <binary>...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	priv, err := license.LoadPrivateKey(*keyPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+
+	for _, path := range flag.Args() {
+		signature, err := license.SignFile(priv, path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(path+".sig", []byte(signature+"\n"), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("signed %s\n", path)
+	}
+}
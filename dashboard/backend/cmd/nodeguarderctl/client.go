@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// session is what login stores between invocations
+type session struct {
+	URL      string `json:"url"`
+	Token    string `json:"token"`
+	Username string `json:"username"`
+	Insecure bool   `json:"insecure,omitempty"`
+}
+
+// sessionPath is ~/.config/nodeguarder/ctl.json (or the platform equivalent)
+func sessionPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "nodeguarder", "ctl.json"), nil
+}
+
+func loadSession() session {
+	var s session
+	if path, err := sessionPath(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			json.Unmarshal(data, &s)
+		}
+	}
+	return s
+}
+
+func saveSession(s session) error {
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, _ := json.MarshalIndent(s, "", "  ")
+	// The token grants dashboard access, keep it private
+	return os.WriteFile(path, data, 0600)
+}
+
+func removeSession() error {
+	path, err := sessionPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Client talks to the dashboard API as a logged-in user
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func NewClient(baseURL, token string, insecure bool) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if insecure {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Timeout: 60 * time.Second, Transport: transport},
+	}
+}
+
+// APIError is a non-2xx response; the dashboard reports {"error": "..."}
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Status == http.StatusUnauthorized {
+		return "not logged in or session expired (run: nodeguarderctl login)"
+	}
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.Status)
+}
+
+// raw performs a request and returns the response body of a successful call
+func (c *Client) raw(method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		if json.Unmarshal(data, &apiErr) != nil || apiErr.Error == "" {
+			apiErr.Error = strings.TrimSpace(string(data))
+		}
+		return nil, &APIError{Status: resp.StatusCode, Message: apiErr.Error}
+	}
+	return resp, nil
+}
+
+// do performs a JSON request and decodes the response into out (if not nil)
+func (c *Client) do(method, path string, body, out interface{}) error {
+	resp, err := c.raw(method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Login exchanges credentials for a token
+func (c *Client) Login(username, password string) (models.LoginResponse, error) {
+	var resp models.LoginResponse
+	err := c.do("POST", "/api/v1/auth/login", models.LoginRequest{Username: username, Password: password}, &resp)
+	if err == nil {
+		c.token = resp.Token
+	}
+	return resp, err
+}
+
+func (c *Client) Servers() ([]models.Server, error) {
+	var servers []models.Server
+	return servers, c.do("GET", "/api/v1/servers", nil, &servers)
+}
+
+func (c *Client) Server(id string) (models.Server, error) {
+	var server models.Server
+	return server, c.do("GET", "/api/v1/servers/"+id, nil, &server)
+}
+
+// Events returns the most recent events of all visible servers, newest first
+func (c *Client) Events() ([]models.Event, error) {
+	var events []models.Event
+	return events, c.do("GET", "/api/v1/events", nil, &events)
+}
+
+// ServerEvents returns the most recent events of one server, newest first
+func (c *Client) ServerEvents(id string) ([]models.Event, error) {
+	var events []models.Event
+	return events, c.do("GET", "/api/v1/servers/"+id+"/events", nil, &events)
+}
+
+// AcceptDrift accepts a server's current state as its drift baseline and
+// acknowledges its outstanding drift alerts
+func (c *Client) AcceptDrift(id string) (int64, error) {
+	var resp struct {
+		EventsAcknowledged int64 `json:"events_acknowledged"`
+	}
+	err := c.do("POST", "/api/v1/servers/"+id+"/drift/accept", nil, &resp)
+	return resp.EventsAcknowledged, err
+}
+
+// Config returns the global agent configuration as raw JSON fields, so fields
+// this build does not know about survive an edit
+func (c *Client) Config() (map[string]interface{}, error) {
+	var cfg map[string]interface{}
+	return cfg, c.do("GET", "/api/v1/config", nil, &cfg)
+}
+
+func (c *Client) SaveConfig(cfg map[string]interface{}) error {
+	return c.do("POST", "/api/v1/config", cfg, nil)
+}
+
+func (c *Client) RequestLogs(id string) error {
+	return c.do("POST", "/api/v1/servers/"+id+"/logs/request", nil, nil)
+}
+
+// DownloadLogs streams the last collected log bundle into w
+func (c *Client) DownloadLogs(id string, w io.Writer) error {
+	resp, err := c.raw("GET", "/api/v1/servers/"+id+"/logs/download", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// ResolveServer accepts a server ID or a (unique) hostname
+func (c *Client) ResolveServer(ref string) (models.Server, error) {
+	servers, err := c.Servers()
+	if err != nil {
+		return models.Server{}, err
+	}
+	return findServer(servers, ref)
+}
+
+func findServer(servers []models.Server, ref string) (models.Server, error) {
+	var matches []models.Server
+	for _, s := range servers {
+		if s.ID == ref {
+			return s, nil
+		}
+		if strings.EqualFold(s.Hostname, ref) {
+			matches = append(matches, s)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return models.Server{}, fmt.Errorf("no server with ID or hostname %q", ref)
+	case 1:
+		return matches[0], nil
+	}
+	return models.Server{}, fmt.Errorf("hostname %q matches %d servers, use the server ID", ref, len(matches))
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+func TestClientAuthAndErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/auth/login":
+			var req models.LoginRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.Password != "secret" {
+				w.WriteHeader(401)
+				w.Write([]byte(`{"error":"Invalid credentials"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(models.LoginResponse{Token: "tok", User: models.User{Username: req.Username}})
+		case "/api/v1/servers":
+			if r.Header.Get("Authorization") != "Bearer tok" {
+				w.WriteHeader(401)
+				return
+			}
+			json.NewEncoder(w).Encode([]models.Server{{ID: "a1", Hostname: "web-1"}, {ID: "b2", Hostname: "db"}, {ID: "c3", Hostname: "DB"}})
+		default:
+			w.WriteHeader(403)
+			w.Write([]byte(`{"error":"Insufficient permissions"}`))
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL+"/", "", false)
+	if _, err := client.Servers(); err == nil || err.(*APIError).Status != 401 {
+		t.Fatalf("expected 401 before login, got %v", err)
+	}
+	if _, err := client.Login("admin", "wrong"); err == nil {
+		t.Fatal("expected a failed login")
+	}
+	if _, err := client.Login("admin", "secret"); err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	if s, err := client.ResolveServer("web-1"); err != nil || s.ID != "a1" {
+		t.Errorf("resolve by hostname: %v %v", s, err)
+	}
+	if s, err := client.ResolveServer("c3"); err != nil || s.Hostname != "DB" {
+		t.Errorf("resolve by ID: %v %v", s, err)
+	}
+	if _, err := client.ResolveServer("db"); err == nil {
+		t.Error("an ambiguous hostname should be rejected")
+	}
+
+	err := client.SaveConfig(map[string]interface{}{})
+	if apiErr, ok := err.(*APIError); !ok || apiErr.Message != "Insufficient permissions" {
+		t.Errorf("expected the dashboard's error message, got %v", err)
+	}
+}
+
+func TestConfigKeys(t *testing.T) {
+	cfg := map[string]interface{}{
+		"drift_interval": float64(300),
+		"thresholds":     map[string]interface{}{"cpu_warning": float64(80)},
+	}
+	if err := setKey(cfg, "thresholds.cpu_warning", parseValue("70")); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := lookupKey(cfg, "thresholds.cpu_warning"); !ok || v != float64(70) {
+		t.Errorf("thresholds.cpu_warning = %v", v)
+	}
+	if err := setKey(cfg, "drift_interval.x", 1); err == nil {
+		t.Error("setting below a scalar should fail")
+	}
+	if parseValue("/etc,/opt") != "/etc,/opt" || parseValue("true") != true {
+		t.Error("values should parse as JSON, else as strings")
+	}
+
+	cfg["drift_interval"] = "ten"
+	if validateConfig(cfg) == nil {
+		t.Error("a string drift_interval should not validate against the config model")
+	}
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+const timeFormat = "2006-01-02 15:04:05"
+
+func cmdLogin(sess session, args []string) error {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	username := fs.String("u", "admin", "Username")
+	passwordStdin := fs.Bool("password-stdin", false, "Read the password from stdin (for scripts)")
+	fs.Parse(args)
+
+	if sess.URL == "" {
+		return fmt.Errorf("no dashboard URL (use: nodeguarderctl -url https://dashboard login)")
+	}
+
+	var password string
+	var err error
+	if *passwordStdin {
+		password, err = readLine(os.Stdin)
+	} else {
+		password, err = promptPassword()
+	}
+	if err != nil {
+		return err
+	}
+
+	client := NewClient(sess.URL, "", sess.Insecure)
+	resp, err := client.Login(*username, password)
+	if err != nil {
+		return err
+	}
+	sess.Token, sess.Username = resp.Token, resp.User.Username
+	if err := saveSession(sess); err != nil {
+		return fmt.Errorf("logged in but failed to save the session: %v", err)
+	}
+	fmt.Printf("Logged in to %s as %s (%s)\n", sess.URL, resp.User.Username, resp.User.Role)
+	return nil
+}
+
+// promptPassword reads a password from the terminal with echo turned off
+func promptPassword() (string, error) {
+	fmt.Fprint(os.Stderr, "Password: ")
+	stty := func(arg string) {
+		cmd := exec.Command("stty", arg)
+		cmd.Stdin = os.Stdin
+		cmd.Run()
+	}
+	stty("-echo")
+	defer func() {
+		stty("echo")
+		fmt.Fprintln(os.Stderr)
+	}()
+	return readLine(os.Stdin)
+}
+
+func readLine(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read password: %v", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func cmdServers(client *Client, args []string) error {
+	fs := flag.NewFlagSet("servers", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print raw JSON")
+	fs.Parse(args)
+
+	servers, err := client.Servers()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(servers)
+	}
+
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Hostname < servers[j].Hostname })
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HOSTNAME\tID\tHEALTH\tOS\tAGENT\tLAST SEEN\tTAGS")
+	for _, s := range servers {
+		health := s.HealthStatus
+		if s.DriftChanged {
+			health += " (drift)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s %s\t%s\t%s\t%s\n", s.Hostname, s.ID, health, s.OSName, s.OSVersion,
+			s.AgentVersion, ago(s.LastSeen), strings.Join(s.Tags, ","))
+	}
+	return w.Flush()
+}
+
+// ago renders a unix timestamp relative to now
+func ago(ts int64) string {
+	if ts == 0 {
+		return "never"
+	}
+	d := time.Since(time.Unix(ts, 0)).Round(time.Second)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
+
+func cmdEvents(client *Client, args []string) error {
+	fs := flag.NewFlagSet("events", flag.ExitOnError)
+	serverRef := fs.String("server", "", "Only events of this server (ID or hostname)")
+	eventType := fs.String("type", "", "Only this event type (e.g. drift, cron, health)")
+	follow := fs.Bool("f", false, "Keep polling and print new events as they arrive")
+	interval := fs.Duration("interval", 5*time.Second, "Poll interval with -f")
+	asJSON := fs.Bool("json", false, "Print one JSON object per event")
+	fs.Parse(args)
+
+	servers, err := client.Servers()
+	if err != nil {
+		return err
+	}
+	hostnames := make(map[string]string, len(servers))
+	for _, s := range servers {
+		hostnames[s.ID] = s.Hostname
+	}
+
+	fetch := client.Events
+	if *serverRef != "" {
+		server, err := findServer(servers, *serverRef)
+		if err != nil {
+			return err
+		}
+		fetch = func() ([]models.Event, error) { return client.ServerEvents(server.ID) }
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if !*asJSON {
+		fmt.Fprintln(w, "ID\tTIME\tHOSTNAME\tSEVERITY\tTYPE\tMESSAGE")
+	}
+	var lastID int64
+	for {
+		events, err := fetch()
+		if err != nil {
+			if !*follow {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, "Error:", err)
+		}
+		// Oldest first, so a follow reads like a log
+		for i := len(events) - 1; i >= 0; i-- {
+			e := events[i]
+			if e.ID <= lastID || (*eventType != "" && e.EventType != *eventType) {
+				continue
+			}
+			if *asJSON {
+				json.NewEncoder(os.Stdout).Encode(e)
+				continue
+			}
+			ack := ""
+			if e.Acknowledged {
+				ack = " [acked]"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s%s\n", e.ID, time.Unix(e.Timestamp, 0).Format(timeFormat),
+				hostnames[e.ServerID], e.Severity, e.EventType, e.Message, ack)
+		}
+		for _, e := range events {
+			if e.ID > lastID {
+				lastID = e.ID
+			}
+		}
+		w.Flush()
+
+		if !*follow {
+			return nil
+		}
+		time.Sleep(*interval)
+	}
+}
+
+func cmdAck(client *Client, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: nodeguarderctl ack <server>")
+	}
+	server, err := client.ResolveServer(args[0])
+	if err != nil {
+		return err
+	}
+	acked, err := client.AcceptDrift(server.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Accepted drift on %s: %d event(s) acknowledged, the agent re-baselines on its next config poll\n", server.Hostname, acked)
+	return nil
+}
+
+func cmdConfig(client *Client, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: nodeguarderctl config get [key] | set key=value... | edit")
+	}
+	cfg, err := client.Config()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "get":
+		if len(args) == 1 {
+			return printJSON(cfg)
+		}
+		val, ok := lookupKey(cfg, args[1])
+		if !ok {
+			return fmt.Errorf("unknown config key %q", args[1])
+		}
+		return printJSON(val)
+
+	case "set":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: nodeguarderctl config set key=value...")
+		}
+		for _, kv := range args[1:] {
+			key, raw, ok := strings.Cut(kv, "=")
+			if !ok {
+				return fmt.Errorf("expected key=value, got %q", kv)
+			}
+			if err := setKey(cfg, key, parseValue(raw)); err != nil {
+				return err
+			}
+		}
+		if err := validateConfig(cfg); err != nil {
+			return err
+		}
+		if err := client.SaveConfig(cfg); err != nil {
+			return err
+		}
+		fmt.Println("Configuration saved, agents pick it up on their next config poll")
+		return nil
+
+	case "edit":
+		edited, changed, err := editJSON(cfg)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			fmt.Println("No changes")
+			return nil
+		}
+		if err := validateConfig(edited); err != nil {
+			return err
+		}
+		if err := client.SaveConfig(edited); err != nil {
+			return err
+		}
+		fmt.Println("Configuration saved, agents pick it up on their next config poll")
+		return nil
+	}
+	return fmt.Errorf("unknown config subcommand %q", args[0])
+}
+
+// parseValue reads a value as JSON (numbers, booleans, lists, objects), falling
+// back to a plain string
+func parseValue(raw string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return raw
+	}
+	return v
+}
+
+// lookupKey resolves a dotted path such as thresholds.cpu_warning
+func lookupKey(cfg map[string]interface{}, key string) (interface{}, bool) {
+	var cur interface{} = cfg
+	for _, part := range strings.Split(key, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// setKey sets a dotted path, creating intermediate objects as needed
+func setKey(cfg map[string]interface{}, key string, val interface{}) error {
+	parts := strings.Split(key, ".")
+	m := cfg
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]interface{})
+		if !ok {
+			if m[part] != nil {
+				return fmt.Errorf("config key %q is not an object", part)
+			}
+			next = map[string]interface{}{}
+			m[part] = next
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = val
+	return nil
+}
+
+// validateConfig catches type mistakes (e.g. a string for a number) against the
+// API model before the dashboard sees them
+func validateConfig(cfg map[string]interface{}) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	var typed models.AgentConfig
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return fmt.Errorf("invalid configuration: %v", err)
+	}
+	return nil
+}
+
+// editJSON opens the value in $VISUAL/$EDITOR and returns the edited copy
+func editJSON(cfg map[string]interface{}) (map[string]interface{}, bool, error) {
+	original, _ := json.MarshalIndent(cfg, "", "  ")
+	f, err := os.CreateTemp("", "nodeguarder-config-*.json")
+	if err != nil {
+		return nil, false, err
+	}
+	defer os.Remove(f.Name())
+	f.Write(append(original, '\n'))
+	f.Close()
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// The editor may carry arguments (e.g. "code --wait")
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], f.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, false, fmt.Errorf("editor failed: %v", err)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		return nil, false, err
+	}
+	if bytes.Equal(bytes.TrimSpace(data), bytes.TrimSpace(original)) {
+		return cfg, false, nil
+	}
+	var edited map[string]interface{}
+	if err := json.Unmarshal(data, &edited); err != nil {
+		return nil, false, fmt.Errorf("edited configuration is not valid JSON: %v", err)
+	}
+	return edited, true, nil
+}
+
+func cmdLogs(client *Client, args []string) error {
+	fs := flag.NewFlagSet("logs", flag.ExitOnError)
+	output := fs.String("o", "", "Output file (default <hostname>_logs.zip)")
+	timeout := fs.Duration("timeout", 5*time.Minute, "How long to wait for the agent to upload")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: nodeguarderctl logs [-o file] <server>")
+	}
+
+	server, err := client.ResolveServer(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := client.RequestLogs(server.ID); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Requested logs from %s, waiting for the agent's next config poll...\n", server.Hostname)
+
+	// The agent uploads on its next poll, which clears the pending flag
+	deadline := time.Now().Add(*timeout)
+	for {
+		time.Sleep(3 * time.Second)
+		s, err := client.Server(server.ID)
+		if err != nil {
+			return err
+		}
+		if !s.LogRequestPending {
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("the agent did not upload logs within %s (is it online?)", *timeout)
+		}
+	}
+
+	path := *output
+	if path == "" {
+		path = server.Hostname + "_logs.zip"
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := client.DownloadLogs(server.ID, f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Println("Saved", path)
+	return nil
+}
@@ -0,0 +1,95 @@
+// Command nodeguarderctl is a terminal client for the dashboard API: list
+// servers, tail events, acknowledge drift alerts, edit the global agent
+// configuration and fetch agent logs without a browser.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var Version = "1.0.0"
+
+const usage = `Usage: nodeguarderctl [-url URL] [-insecure] <command> [flags] [args]
+
+Commands:
+  login                    Log in and store a session token
+  logout                   Forget the stored session
+  servers                  List servers and their health
+  events                   Show recent events (-f to follow)
+  ack <server>             Accept drift on a server and acknowledge its drift alerts
+  config get [key]         Print the global agent configuration (or one key)
+  config set key=value...  Change configuration keys (dotted paths, JSON values)
+  config edit              Edit the configuration in $EDITOR
+  logs <server>            Request and download an agent's log bundle
+  version                  Print the CLI version
+
+Servers are given by ID or hostname. The dashboard URL and token can also be
+set with NODEGUARDER_URL and NODEGUARDER_TOKEN.
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	urlFlag := flag.String("url", "", "Dashboard URL (default: from login or NODEGUARDER_URL)")
+	insecure := flag.Bool("insecure", false, "Skip TLS certificate verification")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	sess := loadSession()
+	if v := os.Getenv("NODEGUARDER_URL"); v != "" {
+		sess.URL = v
+	}
+	if v := os.Getenv("NODEGUARDER_TOKEN"); v != "" {
+		sess.Token = v
+	}
+	if *urlFlag != "" {
+		sess.URL = *urlFlag
+	}
+	if *insecure {
+		sess.Insecure = true
+	}
+
+	cmd, args := flag.Arg(0), flag.Args()[1:]
+	var err error
+	switch cmd {
+	case "login":
+		err = cmdLogin(sess, args)
+	case "logout":
+		err = removeSession()
+	case "version":
+		fmt.Println("nodeguarderctl", Version)
+	case "servers", "events", "ack", "config", "logs":
+		if sess.URL == "" {
+			err = fmt.Errorf("no dashboard URL (run: nodeguarderctl -url https://dashboard login)")
+			break
+		}
+		client := NewClient(sess.URL, sess.Token, sess.Insecure)
+		switch cmd {
+		case "servers":
+			err = cmdServers(client, args)
+		case "events":
+			err = cmdEvents(client, args)
+		case "ack":
+			err = cmdAck(client, args)
+		case "config":
+			err = cmdConfig(client, args)
+		case "logs":
+			err = cmdLogs(client, args)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", cmd)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", strings.TrimSpace(err.Error()))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,276 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Schema changes are numbered SQL files in migrations/: NNNN_name.up.sql
+// applies a change, NNNN_name.down.sql reverts it. Applied versions are
+// recorded in schema_migrations, so an upgrade across several releases runs
+// exactly the missing migrations, in order, each in its own transaction.
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationFilePattern matches migrations/NNNN_name.(up|down).sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one numbered schema change
+type Migration struct {
+	Version   int
+	Name      string
+	AppliedAt int64 // 0 = pending
+	up, down  string
+}
+
+// loadMigrations returns the embedded migrations ordered by version
+func loadMigrations() ([]*Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := migrationFiles.ReadFile(path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.up = string(body)
+		} else {
+			m.down = string(body)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// ensureMigrationsTable creates schema_migrations. It reports whether the
+// database predates it but already holds the dashboard's tables.
+func ensureMigrationsTable() (legacy bool, err error) {
+	var tracked int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).Scan(&tracked); err != nil {
+		return false, err
+	}
+	if tracked == 0 {
+		var servers int
+		if err := DB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'servers'`).Scan(&servers); err != nil {
+			return false, err
+		}
+		legacy = servers > 0
+	}
+
+	_, err = DB.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at INTEGER NOT NULL
+	)`)
+	return legacy, err
+}
+
+// MigrationStatus returns every known migration with when it was applied
+func MigrationStatus() ([]*Migration, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := ensureMigrationsTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := DB.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := map[int]int64{}
+	for rows.Next() {
+		var version int
+		var at int64
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, m := range migrations {
+		m.AppliedAt = applied[m.Version]
+	}
+	return migrations, nil
+}
+
+// Migrate applies all pending migrations in order
+func Migrate() error {
+	legacy, err := ensureMigrationsTable()
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	if legacy {
+		if err := adoptLegacySchema(); err != nil {
+			return fmt.Errorf("failed to adopt existing schema: %w", err)
+		}
+	}
+
+	migrations, err := MigrationStatus()
+	if err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if m.AppliedAt != 0 {
+			continue
+		}
+		if err := runMigration(m, m.up, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`, m.Version, m.Name, time.Now().Unix()); err != nil {
+			return fmt.Errorf("migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+		log.Printf("✅ Applied migration %04d_%s", m.Version, m.Name)
+	}
+	return nil
+}
+
+// MigrateDown reverts applied migrations newer than version, newest first
+func MigrateDown(version int) error {
+	migrations, err := MigrationStatus()
+	if err != nil {
+		return err
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version <= version || m.AppliedAt == 0 {
+			continue
+		}
+		if err := runMigration(m, m.down, `DELETE FROM schema_migrations WHERE version = ?`, m.Version); err != nil {
+			return fmt.Errorf("reverting migration %04d_%s failed: %w", m.Version, m.Name, err)
+		}
+		log.Printf("↩️  Reverted migration %04d_%s", m.Version, m.Name)
+	}
+	return nil
+}
+
+// runMigration executes a migration script and its bookkeeping in one transaction
+func runMigration(m *Migration, script, record string, args ...interface{}) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	script, err = skipExistingColumns(tx, script)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(script); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// adoptLegacySchema brings a database from before versioned migrations up to
+// the baseline's tables and records it as migration 1; the columns it may
+// lack are added by migration 0011_legacy_columns
+func adoptLegacySchema() error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+	if len(migrations) == 0 || migrations[0].Version != 1 {
+		return fmt.Errorf("baseline migration 0001 is missing")
+	}
+	baseline := migrations[0]
+
+	// The baseline only uses CREATE ... IF NOT EXISTS
+	if _, err := DB.Exec(baseline.up); err != nil {
+		return err
+	}
+
+	_, err = DB.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		baseline.Version, baseline.Name, time.Now().Unix())
+	if err == nil {
+		log.Printf("✅ Adopted existing database as migration %04d_%s", baseline.Version, baseline.Name)
+	}
+	return err
+}
+
+// addColumnPattern matches an ALTER TABLE ... ADD COLUMN statement of a migration
+var addColumnPattern = regexp.MustCompile(`(?im)^ALTER TABLE (\w+) ADD COLUMN (\w+)[^;]*;[ \t]*\n?`)
+
+// skipExistingColumns drops the ADD COLUMN statements for columns the
+// database already has, which SQLite would otherwise reject: databases
+// adopted from before versioned migrations have any mix of the columns
+// later releases added
+func skipExistingColumns(tx *sql.Tx, script string) (string, error) {
+	var err error
+	script = addColumnPattern.ReplaceAllStringFunc(script, func(stmt string) string {
+		if err != nil {
+			return stmt
+		}
+		match := addColumnPattern.FindStringSubmatch(stmt)
+		var exists bool
+		if exists, err = columnExists(tx, match[1], match[2]); exists {
+			return ""
+		}
+		return stmt
+	})
+	return script, err
+}
+
+// querier is a *sql.DB or *sql.Tx
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// columnExists reports whether a table has a column
+func columnExists(q querier, table, column string) (bool, error) {
+	rows, err := q.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
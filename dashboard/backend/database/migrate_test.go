@@ -0,0 +1,121 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func openTestDB(t *testing.T) {
+	t.Helper()
+	if err := Open(filepath.Join(t.TempDir(), "health.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { Close() })
+}
+
+func appliedVersions(t *testing.T) []int {
+	t.Helper()
+	migrations, err := MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var versions []int
+	for _, m := range migrations {
+		if m.AppliedAt != 0 {
+			versions = append(versions, m.Version)
+		}
+	}
+	return versions
+}
+
+func TestMigrateUpAndDown(t *testing.T) {
+	openTestDB(t)
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if got := appliedVersions(t); len(got) != len(migrations) {
+		t.Fatalf("applied %v, want all %d migrations", got, len(migrations))
+	}
+	// Re-running is a no-op
+	if err := Migrate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MigrateDown(0); err != nil {
+		t.Fatal(err)
+	}
+	if got := appliedVersions(t); len(got) != 0 {
+		t.Fatalf("still applied after down: %v", got)
+	}
+	var tables int
+	DB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT IN ('schema_migrations', 'sqlite_sequence')`).Scan(&tables)
+	if tables != 0 {
+		t.Errorf("%d tables left after reverting every migration", tables)
+	}
+
+	if err := Migrate(); err != nil {
+		t.Fatalf("migrating up again: %v", err)
+	}
+}
+
+func TestMigrateAdoptsLegacySchema(t *testing.T) {
+	openTestDB(t)
+	// A database from before versioned migrations, with some of the later
+	// columns but not all of them
+	if _, err := DB.Exec(`CREATE TABLE servers (
+		id TEXT PRIMARY KEY,
+		hostname TEXT NOT NULL,
+		api_secret_hash TEXT NOT NULL,
+		first_seen INTEGER NOT NULL,
+		last_seen INTEGER NOT NULL,
+		timezone TEXT
+	)`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	for _, column := range []string{"target_version", "mtls_required", "timezone"} {
+		if ok, err := columnExists(DB, "servers", column); err != nil || !ok {
+			t.Errorf("servers.%s missing after adoption (%v)", column, err)
+		}
+	}
+	if got := appliedVersions(t); len(got) == 0 || got[0] != 1 {
+		t.Errorf("applied = %v, want the baseline recorded", got)
+	}
+}
+
+func TestMigrateMovesRollupStateOutOfSettings(t *testing.T) {
+	openTestDB(t)
+	if err := Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := MigrateDown(11); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DB.Exec(`INSERT OR REPLACE INTO settings (key, value, updated_at) VALUES ('metrics_rollup_state', '{"last_id":42,"rolled_until":3600}', 0)`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	var lastID, rolledUntil int64
+	if err := DB.QueryRow(`SELECT last_id, rolled_until FROM metrics_rollup_state WHERE id = 1`).Scan(&lastID, &rolledUntil); err != nil {
+		t.Fatal(err)
+	}
+	if lastID != 42 || rolledUntil != 3600 {
+		t.Errorf("state = %d/%d, want 42/3600", lastID, rolledUntil)
+	}
+	var left int
+	DB.QueryRow(`SELECT COUNT(*) FROM settings WHERE key = 'metrics_rollup_state'`).Scan(&left)
+	if left != 0 {
+		t.Error("rollup state left in settings")
+	}
+}
@@ -0,0 +1,28 @@
+-- Drops everything the baseline created (children before their parents)
+DROP TABLE IF EXISTS agent_rollout_servers;
+DROP TABLE IF EXISTS agent_rollouts;
+DROP TABLE IF EXISTS agent_certificates;
+DROP TABLE IF EXISTS heartbeats;
+DROP TABLE IF EXISTS tickets;
+DROP TABLE IF EXISTS ticket_integrations;
+DROP TABLE IF EXISTS cron_failure_streaks;
+DROP TABLE IF EXISTS notification_status;
+DROP TABLE IF EXISTS cron_runs;
+DROP TABLE IF EXISTS threshold_profile_tags;
+DROP TABLE IF EXISTS threshold_profiles;
+DROP TABLE IF EXISTS agent_packages;
+DROP TABLE IF EXISTS server_tags;
+DROP TABLE IF EXISTS server_packages;
+DROP TABLE IF EXISTS remediations;
+DROP TABLE IF EXISTS cron_pauses;
+DROP TABLE IF EXISTS terminal_sessions;
+DROP TABLE IF EXISTS audit_log;
+DROP TABLE IF EXISTS script_results;
+DROP TABLE IF EXISTS script_runs;
+DROP TABLE IF EXISTS scripts;
+DROP TABLE IF EXISTS alert_settings;
+DROP TABLE IF EXISTS settings;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS metrics;
+DROP TABLE IF EXISTS servers;
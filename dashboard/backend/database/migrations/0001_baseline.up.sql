@@ -0,0 +1,381 @@
+-- Baseline schema. Tables are created IF NOT EXISTS so databases from before
+-- versioned migrations can be adopted (see adoptLegacySchema).
+
+-- Create servers table
+CREATE TABLE IF NOT EXISTS servers (
+    id TEXT PRIMARY KEY,
+    hostname TEXT NOT NULL,
+    os_name TEXT,
+    os_version TEXT,
+    agent_version TEXT,
+    api_secret_hash TEXT NOT NULL,
+    first_seen INTEGER NOT NULL,
+    last_seen INTEGER NOT NULL,
+    health_status TEXT DEFAULT 'unknown',
+    drift_checksum TEXT,
+    drift_changed INTEGER DEFAULT 0,
+    seen_cron_jobs TEXT,
+    log_request_pending BOOLEAN DEFAULT 0,
+    log_file_path TEXT,
+    log_file_time INTEGER,
+    pending_uninstall BOOLEAN DEFAULT 0,
+    terminal_enabled BOOLEAN DEFAULT 0,
+    package_manager TEXT,
+    packages_updated_at INTEGER,
+    drift_rebaseline_pending BOOLEAN DEFAULT 0,
+    configuration TEXT,
+    environment TEXT,
+    agent_state TEXT,
+    agent_self TEXT,
+    package_id TEXT,
+    timezone TEXT,
+    log_request_time INTEGER,
+    last_status_change INTEGER,
+    health_message TEXT,
+    attestation TEXT,
+    disk_mounts TEXT,
+    ebpf_level TEXT,
+    mtls_required BOOLEAN DEFAULT 0, -- set once the agent authenticated with a client certificate
+    signing_key TEXT,
+    agent_queue TEXT,
+    target_version TEXT -- assigned by a staged rollout (NULL = follow the published version)
+);
+
+-- Create metrics table
+CREATE TABLE IF NOT EXISTS metrics (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    server_id TEXT NOT NULL,
+    timestamp INTEGER NOT NULL,
+    cpu_percent REAL,
+    mem_total_mb INTEGER,
+    mem_used_mb INTEGER,
+    disk_total_gb INTEGER,
+    disk_used_gb INTEGER,
+    load_avg_1 REAL,
+    load_avg_5 REAL,
+    load_avg_15 REAL,
+    process_count INTEGER,
+    processes TEXT,
+    uptime INTEGER,
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_metrics_server_time ON metrics(server_id, timestamp DESC);
+
+-- Create events table
+CREATE TABLE IF NOT EXISTS events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    server_id TEXT NOT NULL,
+    timestamp INTEGER NOT NULL,
+    event_type TEXT NOT NULL,
+    severity TEXT DEFAULT 'info',
+    message TEXT NOT NULL,
+    details TEXT,
+    acknowledged BOOLEAN DEFAULT 0,
+    acked_by TEXT,
+    acked_at INTEGER,
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_server_time ON events(server_id, timestamp DESC);
+
+-- Create users table
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT UNIQUE NOT NULL,
+    password_hash TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    password_changed BOOLEAN DEFAULT 0,
+    role TEXT DEFAULT 'admin', -- admin | operator | viewer
+    allowed_tags TEXT -- Non-admins only see servers with one of these tags (comma-separated, empty = all)
+);
+
+-- Default admin user is now managed by the application at startup via ADMIN_PASSWORD env var
+
+-- Create settings table for global configuration
+CREATE TABLE IF NOT EXISTS settings (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+-- CONFIG: Alert Settings (Single Row, ID=1)
+CREATE TABLE IF NOT EXISTS alert_settings (
+    id INTEGER PRIMARY KEY CHECK (id = 1), -- Ensure singleton
+    slack_webhook_url TEXT,
+    teams_webhook_url TEXT,
+    discord_webhook_url TEXT,
+    email_recipients TEXT,
+    smtp_server TEXT,
+    smtp_port INTEGER,
+    smtp_user TEXT,
+    smtp_password TEXT,
+    alerts_enabled BOOLEAN DEFAULT 0,
+    notify_on_warning BOOLEAN DEFAULT 0
+);
+
+-- Script Runner: allow-listed scripts uploaded by admins
+CREATE TABLE IF NOT EXISTS scripts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT UNIQUE NOT NULL,
+    description TEXT,
+    content TEXT NOT NULL,
+    sha256 TEXT NOT NULL,
+    timeout INTEGER DEFAULT 300,
+    created_by TEXT NOT NULL,
+    created_at INTEGER NOT NULL
+);
+
+-- Script Runner: a scheduled execution of a script against a set of servers
+CREATE TABLE IF NOT EXISTS script_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    script_id INTEGER NOT NULL,
+    scheduled_at INTEGER NOT NULL,
+    created_by TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    FOREIGN KEY (script_id) REFERENCES scripts(id) ON DELETE CASCADE
+);
+
+-- Script Runner: per-host outcome (pending -> dispatched -> completed)
+CREATE TABLE IF NOT EXISTS script_results (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    run_id INTEGER NOT NULL,
+    server_id TEXT NOT NULL,
+    status TEXT DEFAULT 'pending',
+    exit_code INTEGER,
+    output TEXT,
+    dispatched_at INTEGER,
+    started_at INTEGER,
+    finished_at INTEGER,
+    FOREIGN KEY (run_id) REFERENCES script_runs(id) ON DELETE CASCADE,
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_script_results_server_status ON script_results(server_id, status);
+
+-- Audit log for privileged actions
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp INTEGER NOT NULL,
+    username TEXT NOT NULL,
+    action TEXT NOT NULL,
+    target TEXT,
+    details TEXT,
+    ip_address TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_time ON audit_log(timestamp DESC);
+
+-- Remote terminal sessions (recordings stored on disk)
+CREATE TABLE IF NOT EXISTS terminal_sessions (
+    id TEXT PRIMARY KEY,
+    server_id TEXT NOT NULL,
+    username TEXT NOT NULL,
+    started_at INTEGER NOT NULL,
+    ended_at INTEGER,
+    recording_path TEXT NOT NULL,
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+-- Cron pause control: desired pause state per server/job, applied by the agent
+CREATE TABLE IF NOT EXISTS cron_pauses (
+    server_id TEXT NOT NULL,
+    command TEXT NOT NULL,
+    paused BOOLEAN NOT NULL,
+    status TEXT DEFAULT 'pending', -- pending, applied, failed
+    error TEXT,
+    files TEXT, -- JSON array of modified crontabs
+    requested_by TEXT NOT NULL,
+    requested_at INTEGER NOT NULL,
+    applied_at INTEGER,
+    PRIMARY KEY (server_id, command),
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+-- Remediation commands (service restart / SIGTERM) executed by the agent
+CREATE TABLE IF NOT EXISTS remediations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    server_id TEXT NOT NULL,
+    action TEXT NOT NULL, -- restart_unit, kill_pid
+    target TEXT NOT NULL,
+    process TEXT, -- kill_pid: process name at request time
+    event_id INTEGER, -- alert the remediation was triggered from
+    status TEXT DEFAULT 'pending', -- pending, dispatched, succeeded, failed
+    output TEXT,
+    requested_by TEXT NOT NULL,
+    requested_at INTEGER NOT NULL,
+    completed_at INTEGER,
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_remediations_server_status ON remediations(server_id, status);
+
+-- Installed package inventory (latest snapshot per server)
+CREATE TABLE IF NOT EXISTS server_packages (
+    server_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    version TEXT NOT NULL,
+    arch TEXT,
+    PRIMARY KEY (server_id, name),
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_packages_name ON server_packages(name);
+
+-- Server tags (used for role-scoped visibility)
+CREATE TABLE IF NOT EXISTS server_tags (
+    server_id TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (server_id, tag),
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_tags_tag ON server_tags(tag);
+
+-- Generated install packages (registration source tracking)
+CREATE TABLE IF NOT EXISTS agent_packages (
+    id TEXT PRIMARY KEY,
+    label TEXT,
+    created_at INTEGER NOT NULL,
+    created_ip TEXT,
+    revoked_at INTEGER DEFAULT 0,
+    revoked_by TEXT
+);
+
+-- Named threshold profiles, assigned per server (servers.configuration) or per tag
+CREATE TABLE IF NOT EXISTS threshold_profiles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT,
+    thresholds TEXT NOT NULL,
+    created_at INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS threshold_profile_tags (
+    tag TEXT PRIMARY KEY,
+    profile_id INTEGER NOT NULL,
+    FOREIGN KEY (profile_id) REFERENCES threshold_profiles(id) ON DELETE CASCADE
+);
+
+-- Cron run history reported by agents (one row per finished run)
+CREATE TABLE IF NOT EXISTS cron_runs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    server_id TEXT NOT NULL,
+    command TEXT NOT NULL,
+    run_user TEXT,
+    started_at INTEGER NOT NULL,
+    duration INTEGER NOT NULL DEFAULT 0,
+    exit_code INTEGER NOT NULL DEFAULT 0,
+    signal INTEGER NOT NULL DEFAULT 0,
+    UNIQUE (server_id, command, started_at),
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+-- Delivery health per notification provider (slack, teams, discord, email)
+CREATE TABLE IF NOT EXISTS notification_status (
+    provider TEXT PRIMARY KEY,
+    last_success INTEGER,
+    last_failure INTEGER,
+    last_error TEXT,
+    failing_since INTEGER, -- first failure since the last success
+    alerted_at INTEGER     -- when the failure was reported via other channels
+);
+
+-- Consecutive failures per cron job, so a flapping job raises one event
+CREATE TABLE IF NOT EXISTS cron_failure_streaks (
+    server_id TEXT NOT NULL,
+    command TEXT NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    first_failure INTEGER NOT NULL,
+    last_failure INTEGER NOT NULL,
+    event_id INTEGER,          -- escalated event, updated on further failures
+    PRIMARY KEY (server_id, command),
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+-- Issue tracker integrations (Jira, GitLab, GitHub); credentials are AES-GCM encrypted
+CREATE TABLE IF NOT EXISTS ticket_integrations (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    base_url TEXT,
+    default_project TEXT,
+    project_map TEXT, -- JSON: tag -> project
+    credentials TEXT, -- encrypted JSON
+    enabled BOOLEAN DEFAULT 1,
+    created_at INTEGER NOT NULL
+);
+
+-- Issues opened per incident; an incident has at most one open ticket per integration
+CREATE TABLE IF NOT EXISTS tickets (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    integration_id INTEGER NOT NULL,
+    server_id TEXT NOT NULL,
+    incident_key TEXT NOT NULL,
+    project TEXT NOT NULL,
+    external_id TEXT NOT NULL,
+    url TEXT,
+    status TEXT NOT NULL DEFAULT 'open',
+    error TEXT, -- last failed update/close
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL,
+    FOREIGN KEY (integration_id) REFERENCES ticket_integrations(id) ON DELETE CASCADE,
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_tickets_incident ON tickets(server_id, incident_key, status);
+
+-- Heartbeat (deadman) checks: a job pings /api/v1/heartbeats/ping/<token> and is
+-- overdue once no ping arrived for interval + grace seconds
+CREATE TABLE IF NOT EXISTS heartbeats (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    token TEXT NOT NULL UNIQUE,
+    server_id TEXT, -- optional: events are logged on this server
+    interval_seconds INTEGER NOT NULL,
+    grace_seconds INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'new', -- new (never pinged), up, down
+    last_ping INTEGER,
+    down_since INTEGER,
+    created_at INTEGER NOT NULL,
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE SET NULL
+);
+
+-- Client certificates issued to agents for mutual TLS (serial in hex); once a
+-- server authenticated with one, servers.mtls_required rejects its API secret
+CREATE TABLE IF NOT EXISTS agent_certificates (
+    serial TEXT PRIMARY KEY,
+    server_id TEXT NOT NULL,
+    issued_at INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL,
+    revoked_at INTEGER,
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_agent_certificates_server ON agent_certificates(server_id);
+
+-- Staged agent rollouts: servers get servers.target_version in rollout order
+-- (group_order = JSON list of tags) until percentage of them is reached
+CREATE TABLE IF NOT EXISTS agent_rollouts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    version TEXT NOT NULL,
+    percentage INTEGER NOT NULL,
+    group_order TEXT NOT NULL DEFAULT '[]',
+    max_failures INTEGER NOT NULL DEFAULT 1,
+    status TEXT NOT NULL DEFAULT 'active', -- active, paused, failed, completed, cancelled
+    created_by TEXT,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS agent_rollout_servers (
+    rollout_id INTEGER NOT NULL,
+    server_id TEXT NOT NULL,
+    group_tag TEXT,
+    status TEXT NOT NULL DEFAULT 'pending', -- pending, updated, failed
+    targeted_at INTEGER NOT NULL,
+    updated_at INTEGER,
+    error TEXT,
+    PRIMARY KEY (rollout_id, server_id),
+    FOREIGN KEY (rollout_id) REFERENCES agent_rollouts(id) ON DELETE CASCADE,
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_agent_rollout_servers_server ON agent_rollout_servers(server_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_metrics_timestamp;
+DROP TABLE IF EXISTS metrics_1h;
+DROP TABLE IF EXISTS metrics_5m;
+DELETE FROM settings WHERE key = 'metrics_rollup_state';
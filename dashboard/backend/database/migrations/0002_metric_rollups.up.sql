@@ -0,0 +1,46 @@
+-- Metrics rolled up into 5-minute and hourly buckets (bucket = start, unix
+-- seconds) by the rollup worker, so long chart ranges read a few hundred rows
+CREATE TABLE metrics_5m (
+    server_id TEXT NOT NULL,
+    bucket INTEGER NOT NULL,
+    samples INTEGER NOT NULL,
+    cpu_percent REAL,
+    cpu_percent_max REAL,
+    mem_total_mb INTEGER,
+    mem_used_mb INTEGER,
+    mem_used_mb_max INTEGER,
+    disk_total_gb INTEGER,
+    disk_used_gb INTEGER,
+    load_avg_1 REAL,
+    load_avg_1_max REAL,
+    load_avg_5 REAL,
+    load_avg_15 REAL,
+    process_count INTEGER,
+    uptime INTEGER,
+    PRIMARY KEY (server_id, bucket),
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+CREATE TABLE metrics_1h (
+    server_id TEXT NOT NULL,
+    bucket INTEGER NOT NULL,
+    samples INTEGER NOT NULL,
+    cpu_percent REAL,
+    cpu_percent_max REAL,
+    mem_total_mb INTEGER,
+    mem_used_mb INTEGER,
+    mem_used_mb_max INTEGER,
+    disk_total_gb INTEGER,
+    disk_used_gb INTEGER,
+    load_avg_1 REAL,
+    load_avg_1_max REAL,
+    load_avg_5 REAL,
+    load_avg_15 REAL,
+    process_count INTEGER,
+    uptime INTEGER,
+    PRIMARY KEY (server_id, bucket),
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE
+);
+
+-- The rollup worker and the janitor select raw rows by age
+CREATE INDEX IF NOT EXISTS idx_metrics_timestamp ON metrics(timestamp);
@@ -0,0 +1,2 @@
+-- Paged event listings across all servers are ordered by time
+CREATE INDEX IF NOT EXISTS idx_events_timestamp ON events(timestamp DESC);
@@ -0,0 +1 @@
+ALTER TABLE agent_packages DROP COLUMN tags;
@@ -0,0 +1,2 @@
+-- Tags given to the servers an install package onboards (comma separated)
+ALTER TABLE agent_packages ADD COLUMN tags TEXT;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS server_group_members;
+DROP TABLE IF EXISTS server_groups;
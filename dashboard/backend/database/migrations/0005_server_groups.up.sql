@@ -0,0 +1,18 @@
+-- Named groups of servers with configuration overrides (JSON models.ConfigOverrides)
+CREATE TABLE IF NOT EXISTS server_groups (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT,
+    config TEXT NOT NULL DEFAULT '{}',
+    created_at INTEGER NOT NULL
+);
+
+-- A server belongs to at most one group
+CREATE TABLE IF NOT EXISTS server_group_members (
+    server_id TEXT PRIMARY KEY,
+    group_id INTEGER NOT NULL,
+    FOREIGN KEY (server_id) REFERENCES servers(id) ON DELETE CASCADE,
+    FOREIGN KEY (group_id) REFERENCES server_groups(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_group_members_group ON server_group_members(group_id);
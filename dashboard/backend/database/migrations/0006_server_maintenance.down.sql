@@ -0,0 +1,2 @@
+ALTER TABLE servers DROP COLUMN maintenance_reason;
+ALTER TABLE servers DROP COLUMN maintenance_until;
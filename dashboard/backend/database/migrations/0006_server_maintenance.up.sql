@@ -0,0 +1,3 @@
+-- Maintenance mode: alerts about the server are held back until maintenance_until
+ALTER TABLE servers ADD COLUMN maintenance_until INTEGER;
+ALTER TABLE servers ADD COLUMN maintenance_reason TEXT;
@@ -0,0 +1,14 @@
+-- Silences mute the notifications matching all of their set (non-NULL) matchers until expires_at
+CREATE TABLE IF NOT EXISTS silences (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    server_id TEXT,
+    tag TEXT,
+    event_type TEXT,
+    severity TEXT,
+    comment TEXT,
+    created_by TEXT,
+    created_at INTEGER NOT NULL,
+    expires_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_silences_expires ON silences(expires_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_events_open_incidents;
+ALTER TABLE events DROP COLUMN resolved_at;
+ALTER TABLE events DROP COLUMN incident_key;
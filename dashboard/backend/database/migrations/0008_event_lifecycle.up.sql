@@ -0,0 +1,6 @@
+-- Events reporting a problem are incidents: open (resolved_at NULL) until the problem clears.
+-- incident_key is the key of the problem, as used for tickets ("cron:<command>", "port:<name>", "health:cpu")
+ALTER TABLE events ADD COLUMN incident_key TEXT;
+ALTER TABLE events ADD COLUMN resolved_at INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_events_open_incidents ON events(server_id, incident_key) WHERE incident_key IS NOT NULL AND resolved_at IS NULL;
@@ -0,0 +1,11 @@
+-- Freeform comments on events (incident journal)
+CREATE TABLE IF NOT EXISTS event_comments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    event_id INTEGER NOT NULL,
+    username TEXT,
+    body TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    FOREIGN KEY (event_id) REFERENCES events(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_event_comments_event ON event_comments(event_id, created_at);
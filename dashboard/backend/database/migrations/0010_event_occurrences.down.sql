@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_events_repeats;
+ALTER TABLE events DROP COLUMN last_seen;
+ALTER TABLE events DROP COLUMN first_seen;
+ALTER TABLE events DROP COLUMN occurrences;
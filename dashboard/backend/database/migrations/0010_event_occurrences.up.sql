@@ -0,0 +1,6 @@
+-- Repeats of an identical event are counted on it instead of stored as new rows
+ALTER TABLE events ADD COLUMN occurrences INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE events ADD COLUMN first_seen INTEGER;
+ALTER TABLE events ADD COLUMN last_seen INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_events_repeats ON events(server_id, event_type, message);
@@ -0,0 +1,2 @@
+-- The columns are part of the 0001 baseline schema, so there is nothing to
+-- revert: reverting the baseline drops their tables.
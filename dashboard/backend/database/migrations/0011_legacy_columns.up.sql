@@ -0,0 +1,36 @@
+-- Columns older releases added with ALTER TABLE on startup, before migrations
+-- were versioned. New databases get them from the baseline and a database
+-- adopted from such a release may lack any of them; columns that already
+-- exist are skipped (see skipExistingColumns).
+ALTER TABLE alert_settings ADD COLUMN teams_webhook_url TEXT;
+ALTER TABLE alert_settings ADD COLUMN discord_webhook_url TEXT;
+ALTER TABLE metrics ADD COLUMN processes TEXT;
+ALTER TABLE servers ADD COLUMN configuration TEXT;
+ALTER TABLE servers ADD COLUMN log_request_pending BOOLEAN DEFAULT 0;
+ALTER TABLE servers ADD COLUMN log_request_time INTEGER;
+ALTER TABLE servers ADD COLUMN log_file_path TEXT;
+ALTER TABLE servers ADD COLUMN log_file_time INTEGER;
+ALTER TABLE servers ADD COLUMN last_status_change INTEGER;
+ALTER TABLE servers ADD COLUMN health_message TEXT;
+ALTER TABLE users ADD COLUMN password_changed BOOLEAN DEFAULT 0;
+ALTER TABLE users ADD COLUMN role TEXT DEFAULT 'admin';
+ALTER TABLE servers ADD COLUMN terminal_enabled BOOLEAN DEFAULT 0;
+ALTER TABLE servers ADD COLUMN package_manager TEXT;
+ALTER TABLE servers ADD COLUMN packages_updated_at INTEGER;
+ALTER TABLE servers ADD COLUMN drift_rebaseline_pending BOOLEAN DEFAULT 0;
+ALTER TABLE events ADD COLUMN acknowledged BOOLEAN DEFAULT 0;
+ALTER TABLE events ADD COLUMN acked_by TEXT;
+ALTER TABLE events ADD COLUMN acked_at INTEGER;
+ALTER TABLE users ADD COLUMN allowed_tags TEXT;
+ALTER TABLE servers ADD COLUMN environment TEXT;
+ALTER TABLE servers ADD COLUMN agent_state TEXT;
+ALTER TABLE servers ADD COLUMN agent_self TEXT;
+ALTER TABLE servers ADD COLUMN package_id TEXT;
+ALTER TABLE servers ADD COLUMN timezone TEXT;
+ALTER TABLE servers ADD COLUMN attestation TEXT;
+ALTER TABLE servers ADD COLUMN disk_mounts TEXT;
+ALTER TABLE servers ADD COLUMN ebpf_level TEXT;
+ALTER TABLE servers ADD COLUMN mtls_required BOOLEAN DEFAULT 0;
+ALTER TABLE servers ADD COLUMN signing_key TEXT;
+ALTER TABLE servers ADD COLUMN agent_queue TEXT;
+ALTER TABLE servers ADD COLUMN target_version TEXT;
@@ -0,0 +1,5 @@
+INSERT OR REPLACE INTO settings (key, value, updated_at)
+SELECT 'metrics_rollup_state', json_object('last_id', last_id, 'rolled_until', rolled_until), strftime('%s', 'now')
+FROM metrics_rollup_state;
+
+DROP TABLE IF EXISTS metrics_rollup_state;
@@ -0,0 +1,13 @@
+-- Where the rollup worker stopped, kept out of settings so its hourly runs
+-- neither show up as configuration changes nor flush the settings cache
+CREATE TABLE IF NOT EXISTS metrics_rollup_state (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    last_id INTEGER NOT NULL DEFAULT 0,
+    rolled_until INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT OR IGNORE INTO metrics_rollup_state (id, last_id, rolled_until)
+SELECT 1, COALESCE(json_extract(value, '$.last_id'), 0), COALESCE(json_extract(value, '$.rolled_until'), 0)
+FROM settings WHERE key = 'metrics_rollup_state' AND json_valid(value);
+
+DELETE FROM settings WHERE key = 'metrics_rollup_state';
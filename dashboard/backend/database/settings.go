@@ -0,0 +1,80 @@
+package database
+
+import (
+	"database/sql"
+	"sync"
+)
+
+// Global settings are read on every agent config poll and metrics push, so
+// the settings table is kept in memory: the first read loads all rows, and
+// any write through the API invalidates the copy.
+
+var settingsCache struct {
+	sync.RWMutex
+	values     map[string]string // nil = not loaded
+	generation int               // bumped by InvalidateSettings
+}
+
+// GetSetting reads a setting into dest like Scan: sql.ErrNoRows if it is not set
+func GetSetting(key string, dest *string) error {
+	settingsCache.RLock()
+	values := settingsCache.values
+	settingsCache.RUnlock()
+
+	if values == nil {
+		var err error
+		if values, err = loadSettings(); err != nil {
+			return err
+		}
+	}
+	val, ok := values[key]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	*dest = val
+	return nil
+}
+
+// loadSettings reads the settings table and caches it unless it was
+// invalidated while loading
+func loadSettings() (map[string]string, error) {
+	settingsCache.RLock()
+	generation := settingsCache.generation
+	settingsCache.RUnlock()
+
+	rows, err := DB.Query("SELECT key, value FROM settings")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	values := map[string]string{}
+	for rows.Next() {
+		var key string
+		var val sql.NullString
+		if err := rows.Scan(&key, &val); err != nil {
+			return nil, err
+		}
+		if val.Valid {
+			values[key] = val.String
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	settingsCache.Lock()
+	if settingsCache.generation == generation {
+		settingsCache.values = values
+	}
+	settingsCache.Unlock()
+	return values, nil
+}
+
+// InvalidateSettings drops the cached settings; call it after writing the
+// settings table
+func InvalidateSettings() {
+	settingsCache.Lock()
+	settingsCache.values = nil
+	settingsCache.generation++
+	settingsCache.Unlock()
+}
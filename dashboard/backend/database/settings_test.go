@@ -0,0 +1,34 @@
+package database
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestSettingsCache(t *testing.T) {
+	openTestDB(t)
+	if err := Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	DB.Exec(`INSERT INTO settings (key, value, updated_at) VALUES ('offline_timeout', '120', 0)`)
+
+	var val string
+	if err := GetSetting("offline_timeout", &val); err != nil || val != "120" {
+		t.Fatalf("GetSetting = %q, %v", val, err)
+	}
+	if err := GetSetting("missing", &val); err != sql.ErrNoRows {
+		t.Fatalf("missing setting: err = %v, want sql.ErrNoRows", err)
+	}
+
+	// Served from memory until invalidated
+	DB.Exec(`UPDATE settings SET value = '300' WHERE key = 'offline_timeout'`)
+	GetSetting("offline_timeout", &val)
+	if val != "120" {
+		t.Fatalf("cached value = %q, want 120", val)
+	}
+	InvalidateSettings()
+	GetSetting("offline_timeout", &val)
+	if val != "300" {
+		t.Fatalf("after invalidation = %q, want 300", val)
+	}
+}
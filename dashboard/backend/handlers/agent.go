@@ -18,8 +18,13 @@ import (
 	"github.com/yourusername/health-dashboard-backend/database"
 	"github.com/yourusername/health-dashboard-backend/health"
 	"github.com/yourusername/health-dashboard-backend/license"
+	"github.com/yourusername/health-dashboard-backend/live"
 	"github.com/yourusername/health-dashboard-backend/models"
 	"github.com/yourusername/health-dashboard-backend/notifications"
+	"github.com/yourusername/health-dashboard-backend/push"
+	"github.com/yourusername/health-dashboard-backend/schedule"
+	"github.com/yourusername/health-dashboard-backend/terminal"
+	"github.com/yourusername/health-dashboard-backend/tickets"
 	"golang.org/x/crypto/bcrypt"
 	"gopkg.in/yaml.v2"
 )
@@ -35,6 +40,10 @@ func AgentRegister(c *fiber.Ctx) error {
 		AgentVersion      string `json:"agent_version"`
 		APISecret         string `json:"api_secret"`
 		RegistrationToken string `json:"registration_token"`
+		PackageID         string `json:"package_id"` // install package that onboarded this host
+		Arch              string `json:"arch"`
+		BinarySHA256      string `json:"binary_sha256"`
+		ConfigSHA256      string `json:"config_sha256"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -64,6 +73,18 @@ func AgentRegister(c *fiber.Ctx) error {
 			log.Printf("❌ Registration failed: Invalid token from %s", req.Hostname)
 			return c.Status(403).JSON(fiber.Map{"error": "Invalid registration token"})
 		}
+		if req.PackageID != "" {
+			if err := checkInstallPackage(req.PackageID); err != nil {
+				log.Printf("❌ Registration failed: %v (%s)", err, req.Hostname)
+				return c.Status(403).JSON(fiber.Map{"error": err.Error()})
+			}
+		}
+	} else if serverPackageRevoked(req.ServerID) {
+		log.Printf("❌ Re-registration refused: install package of %s (%s) is revoked", req.Hostname, req.ServerID)
+		return c.Status(403).JSON(fiber.Map{"error": "Install package revoked"})
+	} else if !certAuthenticated(c, req.ServerID) && requiresMTLS(req.ServerID) {
+		log.Printf("❌ Re-registration refused: %s (%s) must present its client certificate", req.Hostname, req.ServerID)
+		return c.Status(401).JSON(fiber.Map{"error": "Client certificate required"})
 	}
 
 	// CHECK LICENSE BEFORE REGISTRATION
@@ -106,15 +127,21 @@ func AgentRegister(c *fiber.Ctx) error {
 	if isNewServer {
 		// New server - insert
 		_, err = database.DB.Exec(`
-			INSERT INTO servers (id, hostname, os_name, os_version, agent_version, api_secret_hash, first_seen, last_seen, health_status)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-		`, req.ServerID, req.Hostname, req.OSName, req.OSVersion, req.AgentVersion, string(secretHash), now, now, "healthy")
+			INSERT INTO servers (id, hostname, os_name, os_version, agent_version, api_secret_hash, first_seen, last_seen, health_status, package_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''))
+		`, req.ServerID, req.Hostname, req.OSName, req.OSVersion, req.AgentVersion, string(secretHash), now, now, "healthy", req.PackageID)
 
 		if err != nil {
 			log.Printf("Failed to insert server: %v", err)
 			return c.Status(500).JSON(fiber.Map{"error": "Failed to register server"})
 		}
 
+		if req.PackageID != "" {
+			if err := applyInstallPackageTags(req.ServerID, req.PackageID); err != nil {
+				log.Printf("Failed to tag server %s: %v", req.ServerID, err)
+			}
+		}
+
 		log.Printf("✅ New server registered: %s (%s)", req.Hostname, req.ServerID)
 	} else if err == nil {
 		// Existing server - update
@@ -134,6 +161,14 @@ func AgentRegister(c *fiber.Ctx) error {
 		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
 	}
 
+	attestAgent(req.ServerID, req.Hostname, req.AgentVersion, req.Arch, req.BinarySHA256, req.ConfigSHA256)
+	rolloutServerRegistered(req.ServerID, req.AgentVersion)
+
+	// Further requests may be signed instead of carrying the secret
+	if storeSigningKey(req.ServerID, req.APISecret) {
+		c.Set("X-Agent-Signing", signingScheme)
+	}
+
 	return c.JSON(fiber.Map{"status": "registered"})
 }
 
@@ -151,9 +186,13 @@ func AgentPushMetrics(c *fiber.Ctx) error {
 	}
 
 	// Authenticate agent
-	if !authenticateAgent(req.ServerID, req.APISecret) {
+	if !authenticateAgent(c, req.ServerID, req.APISecret) {
 		return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
 	}
+
+	// Changed-only transmission: the process list may be left out and cron
+	// jobs sent as a delta (see metrics_sync.go)
+	applyCronJobs, fullSync := checkMetricsSync(req.ServerID, req.Metrics["sync"])
 	
 	var processesJSON string
 	if procs, ok := req.Metrics["processes"]; ok && procs != nil {
@@ -163,7 +202,7 @@ func AgentPushMetrics(c *fiber.Ctx) error {
 	}
 
     // Handle Discovered Cron Jobs
-	if cronJobs, ok := req.Metrics["cron_jobs"]; ok && cronJobs != nil {
+	if cronJobs, ok := req.Metrics["cron_jobs"]; ok && cronJobs != nil && applyCronJobs {
         // We now support both []string (old) and []JobRecord (new, comes as []interface{})
         // Since we just store it as JSON in the DB, we can marshal whatever we get
         // as long as it is a slice.
@@ -174,11 +213,67 @@ func AgentPushMetrics(c *fiber.Ctx) error {
         }
 	}
 
-	// Insert metrics
-	_, err := database.DB.Exec(`
-		INSERT INTO metrics (server_id, timestamp, cpu_percent, mem_total_mb, mem_used_mb, disk_total_gb, disk_used_gb, load_avg_1, load_avg_5, load_avg_15, process_count, processes, uptime)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`,
+	if delta, ok := req.Metrics["cron_jobs_delta"]; ok && delta != nil && applyCronJobs {
+		if err := applyCronJobsDelta(req.ServerID, delta); err != nil {
+			log.Printf("Failed to apply cron job changes: %v", err)
+		}
+	}
+
+	// Finished cron runs since the last push (run history)
+	if runs, ok := req.Metrics["cron_runs"]; ok && runs != nil {
+		saveCronRuns(req.ServerID, runs)
+	}
+
+	// Runtime environment (bare metal / VM / container)
+	if env, ok := req.Metrics["environment"]; ok && env != nil {
+		if bytes, err := json.Marshal(env); err == nil {
+			database.DB.Exec("UPDATE servers SET environment = ? WHERE id = ?", string(bytes), req.ServerID)
+		}
+	}
+
+	// Time zone, for schedules in server local time (ignored if unknown to the tz database)
+	if tz, ok := req.Metrics["timezone"].(string); ok && tz != "" && schedule.ValidTimezone(tz) {
+		database.DB.Exec("UPDATE servers SET timezone = ? WHERE id = ?", tz, req.ServerID)
+	}
+
+	// Agent state database size/health
+	if st, ok := req.Metrics["agent_state"]; ok && st != nil {
+		if bytes, err := json.Marshal(st); err == nil {
+			database.DB.Exec("UPDATE servers SET agent_state = ? WHERE id = ?", string(bytes), req.ServerID)
+		}
+	}
+
+	// Agent self-metrics (eBPF listener counters and capability level)
+	if self, ok := req.Metrics["agent_self"]; ok && self != nil {
+		if bytes, err := json.Marshal(self); err == nil {
+			var selfMetrics struct {
+				EBPF struct {
+					Level string `json:"level"`
+				} `json:"ebpf"`
+			}
+			json.Unmarshal(bytes, &selfMetrics)
+			database.DB.Exec("UPDATE servers SET agent_self = ?, ebpf_level = NULLIF(?, '') WHERE id = ?", string(bytes), selfMetrics.EBPF.Level, req.ServerID)
+		}
+	}
+
+	// Offline queue depth and age, to notice agents stuck in offline mode
+	if q, ok := req.Metrics["queue"]; ok && q != nil {
+		updateAgentQueue(req.ServerID, req.Timestamp, q)
+	}
+
+	// Per-filesystem disk usage (null for agents that only report the root partition)
+	var diskMounts interface{}
+	if mounts, ok := req.Metrics["disk_mounts"]; ok && mounts != nil {
+		if bytes, err := json.Marshal(mounts); err == nil {
+			diskMounts = string(bytes)
+		}
+	}
+	database.DB.Exec("UPDATE servers SET disk_mounts = ? WHERE id = ?", diskMounts, req.ServerID)
+
+	// Insert metrics, with the samples taken between pushes (agents with
+	// sample_interval set)
+	batch := &ingestBatch{}
+	batch.addMetric(
 		req.ServerID,
 		req.Timestamp,
 		req.Metrics["cpu_percent"],
@@ -193,14 +288,32 @@ func AgentPushMetrics(c *fiber.Ctx) error {
 		processesJSON,
 		req.Metrics["uptime"],
 	)
+	if samples, ok := req.Metrics["samples"]; ok && samples != nil {
+		if err := addMetricSamples(batch, req.ServerID, req.Timestamp, samples); err != nil {
+			log.Printf("Failed to read metric samples: %v", err)
+		}
+	}
 
-	if err != nil {
+	if err := ingest(batch); err != nil {
 		log.Printf("Failed to insert metrics: %v", err)
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to store metrics"})
 	}
 
 	// Update last_seen
 	database.DB.Exec("UPDATE servers SET last_seen = ? WHERE id = ?", time.Now().Unix(), req.ServerID)
+	live.Publish(live.Update{Type: live.TypeMetric, ServerID: req.ServerID, Data: map[string]interface{}{
+		"server_id":     req.ServerID,
+		"timestamp":     req.Timestamp,
+		"cpu_percent":   req.Metrics["cpu_percent"],
+		"mem_total_mb":  req.Metrics["mem_total_mb"],
+		"mem_used_mb":   req.Metrics["mem_used_mb"],
+		"disk_total_gb": req.Metrics["disk_total_gb"],
+		"disk_used_gb":  req.Metrics["disk_used_gb"],
+		"load_avg_1":    req.Metrics["load_avg_1"],
+	}})
+
+	// Health events whose resource is back within its threshold are resolved
+	resolveHealthIncidents(req.ServerID, req.Timestamp)
 
 	// Calculate and update health status based on new metrics
 	newStatus, oldStatus, reason, oldReason, err := health.UpdateServerHealth(req.ServerID)
@@ -218,11 +331,16 @@ func AgentPushMetrics(c *fiber.Ctx) error {
 				go func(hname, sid, status, reason string) {
 					if Notifier == nil { return }
 					Notifier.Notify(notifications.Notification{
-						Subject: fmt.Sprintf("[%s] Server Alert: %s is %s", strings.ToUpper(status), hname, status),
-						Message: fmt.Sprintf("Server %s (%s) has entered %s state. Reason: %s", hname, sid, status, reason),
-						Type:    notifications.TypeCritical,
+						Subject:   fmt.Sprintf("[%s] Server Alert: %s is %s", strings.ToUpper(status), hname, status),
+						Message:   fmt.Sprintf("Server %s (%s) has entered %s state. Reason: %s", hname, sid, status, reason),
+						Type:      notifications.TypeCritical,
+						ServerID:  sid,
+						EventType: "health",
 					})
 				}(hostname, req.ServerID, newStatus, reason)
+				go tickets.Open(req.ServerID, "health",
+					fmt.Sprintf("%s is %s", hostname, newStatus),
+					fmt.Sprintf("Server %s (%s) entered %s state at %s.\n\nReason: %s", hostname, req.ServerID, newStatus, time.Now().UTC().Format(time.RFC1123), reason))
 			} else if newStatus == "healthy" && (oldStatus == "recovering" || oldStatus == "offline" || oldStatus == "critical") {
                 // RECOVERY NOTIFICATION
                 go func(hname, sid, oldStat, oldReas string) {
@@ -245,16 +363,62 @@ func AgentPushMetrics(c *fiber.Ctx) error {
                     }
 
                     Notifier.Notify(notifications.Notification{
-						Subject: fmt.Sprintf("[RESOLVED] Server %s Recovered", hname),
-						Message: msg,
-						Type:    notifications.TypeSuccess,
+						Subject:   fmt.Sprintf("[RESOLVED] Server %s Recovered", hname),
+						Message:   msg,
+						Type:      notifications.TypeSuccess,
+						ServerID:  sid,
+						EventType: "health",
 					})
                 }(hostname, req.ServerID, oldStatus, oldReason)
+            }
+            if newStatus == "healthy" {
+                go tickets.Resolve(req.ServerID, "health", fmt.Sprintf("Server %s is healthy again (was %s).", hostname, oldStatus))
             }
 		}
 	}
 
-	return c.JSON(fiber.Map{"status": "ok"})
+	return c.JSON(fiber.Map{"status": "ok", "full_sync": fullSync})
+}
+
+// maxMetricSamples caps the samples accepted with one push (a day at 2 minutes,
+// or 2 hours at 10 seconds)
+const maxMetricSamples = 720
+
+// addMetricSamples adds the batched samples of a push as regular metric rows
+// (without process list). Samples not older than the push are ignored.
+func addMetricSamples(batch *ingestBatch, serverID string, pushedAt int64, raw interface{}) error {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	var samples []struct {
+		Timestamp    int64   `json:"timestamp"`
+		CPUPercent   float64 `json:"cpu_percent"`
+		MemTotalMB   uint64  `json:"mem_total_mb"`
+		MemUsedMB    uint64  `json:"mem_used_mb"`
+		DiskTotalGB  uint64  `json:"disk_total_gb"`
+		DiskUsedGB   uint64  `json:"disk_used_gb"`
+		LoadAvg1     float64 `json:"load_avg_1"`
+		LoadAvg5     float64 `json:"load_avg_5"`
+		LoadAvg15    float64 `json:"load_avg_15"`
+		ProcessCount int     `json:"process_count"`
+		Uptime       uint64  `json:"uptime"`
+	}
+	if err := json.Unmarshal(data, &samples); err != nil {
+		return err
+	}
+	if len(samples) > maxMetricSamples {
+		samples = samples[len(samples)-maxMetricSamples:]
+	}
+
+	for _, m := range samples {
+		if m.Timestamp <= 0 || m.Timestamp >= pushedAt {
+			continue
+		}
+		batch.addMetric(serverID, m.Timestamp, m.CPUPercent, m.MemTotalMB, m.MemUsedMB, m.DiskTotalGB, m.DiskUsedGB,
+			m.LoadAvg1, m.LoadAvg5, m.LoadAvg15, m.ProcessCount, "", m.Uptime)
+	}
+	return nil
 }
 
 // AgentPushEvents handles events ingestion
@@ -268,6 +432,9 @@ func AgentPushEvents(c *fiber.Ctx) error {
 			Message   string `json:"message"`
 			Timestamp int64  `json:"timestamp"`
 			Details   string `json:"details"`
+
+			Occurrences   int   `json:"occurrences"`    // queued repeatedly while the agent was offline
+			LastTimestamp int64 `json:"last_timestamp"` // time of the last repetition
 		} `json:"events"`
 	}
 
@@ -276,22 +443,44 @@ func AgentPushEvents(c *fiber.Ctx) error {
 	}
 
 	// Authenticate agent
-	if !authenticateAgent(req.ServerID, req.APISecret) {
+	if !authenticateAgent(c, req.ServerID, req.APISecret) {
 		return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
 	}
 
     // Resolve hostname for notifications
     hostname := getHostname(req.ServerID)
 
-	// Insert events
-	for _, event := range req.Events {
-		_, err := database.DB.Exec(`
-			INSERT INTO events (server_id, timestamp, event_type, severity, message, details)
-			VALUES (?, ?, ?, ?, ?, ?)
-		`, req.ServerID, event.Timestamp, event.Type, event.Severity, event.Message, event.Details)
+	// Insert events in one batch; nothing is stored or notified if it fails,
+	// so the agent can safely send them again
+	batch := &ingestBatch{}
+	rows := make([]*ingestEvent, len(req.Events))
+	for i, event := range req.Events {
+		if groupedCronFailure(event.Type, event.Severity, event.Details) != "" {
+			continue
+		}
+		rows[i] = batch.addEvent(req.ServerID, event.Timestamp, event.Type, event.Severity, event.Message, event.Details)
+		if event.Occurrences > 1 && event.LastTimestamp >= event.Timestamp {
+			rows[i].occurrences, rows[i].lastSeen = event.Occurrences, event.LastTimestamp
+		}
+	}
+	if err := ingest(batch); err != nil {
+		log.Printf("Failed to insert events: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to store events"})
+	}
+
+	for i, event := range req.Events {
+		// Repeats counted on an earlier identical event were notified about with it
+		if rows[i] != nil && rows[i].merged {
+			continue
+		}
+		if event.Occurrences > 1 {
+			event.Message += fmt.Sprintf(" (repeated %d times while the agent was offline, last at %s)",
+				event.Occurrences, time.Unix(event.LastTimestamp, 0).UTC().Format("2006-01-02 15:04 UTC"))
+		}
 
-		if err != nil {
-			log.Printf("Failed to insert event: %v", err)
+		// Cron failures are grouped per job (escalation threshold, "still failing" updates)
+		if command := groupedCronFailure(event.Type, event.Severity, event.Details); command != "" {
+			recordCronFailure(req.ServerID, hostname, command, event.Type, event.Severity, event.Message, event.Timestamp, event.Details)
 			continue
 		}
 
@@ -303,9 +492,11 @@ func AgentPushEvents(c *fiber.Ctx) error {
 			go func(hname, msg string) {
 				if Notifier == nil { return }
 				Notifier.Notify(notifications.Notification{
-					Subject: fmt.Sprintf("[WARNING] Drift Detected on %s", hname),
-					Message: msg, // Use the actual event message
-					Type:    notifications.TypeWarning,
+					Subject:   fmt.Sprintf("[WARNING] Drift Detected on %s", hname),
+					Message:   msg, // Use the actual event message
+					Type:      notifications.TypeWarning,
+					ServerID:  req.ServerID,
+					EventType: "drift",
 				})
 			}(hostname, event.Message)
 
@@ -328,9 +519,85 @@ func AgentPushEvents(c *fiber.Ctx) error {
 					notifType = notifications.TypeCritical
 				}
 				Notifier.Notify(notifications.Notification{
-					Subject: fmt.Sprintf("[%s] Health Alert on %s", strings.ToUpper(severity), hname),
-					Message: msg,
-					Type:    notifType,
+					Subject:   fmt.Sprintf("[%s] Health Alert on %s", strings.ToUpper(severity), hname),
+					Message:   msg,
+					Type:      notifType,
+					ServerID:  req.ServerID,
+					EventType: "health",
+				})
+			}(hostname, event.Message, event.Severity)
+		}
+
+		// Notify when an agent rolled back a self-update
+		if event.Type == "update" && event.Severity != "info" {
+			var update struct {
+				ToVersion string `json:"to_version"`
+			}
+			json.Unmarshal([]byte(event.Details), &update)
+			if update.ToVersion != "" {
+				rolloutServerFailed(req.ServerID, hostname, update.ToVersion, event.Message)
+			}
+			go func(hname, msg string) {
+				if Notifier == nil { return }
+				Notifier.Notify(notifications.Notification{
+					Subject:   fmt.Sprintf("[WARNING] Agent Update Rolled Back on %s", hname),
+					Message:   msg,
+					Type:      notifications.TypeWarning,
+					ServerID:  req.ServerID,
+					EventType: "update",
+				})
+			}(hostname, event.Message)
+		}
+
+		// Port failures open a ticket per port, closed when it accepts connections again
+		if event.Type == "port" {
+			var port struct {
+				Name string `json:"name"`
+			}
+			json.Unmarshal([]byte(event.Details), &port)
+			if port.Name != "" && event.Severity != "info" {
+				go tickets.Open(req.ServerID, "port:"+port.Name, fmt.Sprintf("%s: %s is down", hostname, port.Name), event.Message)
+			} else if port.Name != "" {
+				resolveIncident(req.ServerID, "port:"+port.Name, event.Timestamp)
+				go tickets.Resolve(req.ServerID, "port:"+port.Name, event.Message)
+			}
+		}
+
+		// Critical security events share one ticket per server until it is closed in the tracker
+		if event.Type == "security" && event.Severity == "critical" {
+			go tickets.Open(req.ServerID, "security", fmt.Sprintf("%s: security alert", hostname), event.Message)
+		}
+
+		// Notify on Port Check failures (service stopped listening)
+		if event.Type == "port" && event.Severity != "info" {
+			go func(hname, msg string) {
+				if Notifier == nil { return }
+				Notifier.Notify(notifications.Notification{
+					Subject:   fmt.Sprintf("[CRITICAL] Service Port Down on %s", hname),
+					Message:   msg,
+					Type:      notifications.TypeCritical,
+					ServerID:  req.ServerID,
+					EventType: "port",
+				})
+			}(hostname, event.Message)
+		}
+
+		// Notify on Security Events (SSH brute-force, SSH keys/sudoers); sudo sessions are informational
+		if event.Type == "security" && event.Severity != "info" {
+			go func(hname, msg, severity string) {
+				if Notifier == nil { return }
+				subject := fmt.Sprintf("[WARNING] Security Alert on %s", hname)
+				notifType := notifications.TypeWarning
+				if severity == "critical" {
+					subject = fmt.Sprintf("[CRITICAL] Security Alert on %s", hname)
+					notifType = notifications.TypeCritical
+				}
+				Notifier.Notify(notifications.Notification{
+					Subject:   subject,
+					Message:   msg,
+					Type:      notifType,
+					ServerID:  req.ServerID,
+					EventType: "security",
 				})
 			}(hostname, event.Message, event.Severity)
 		}
@@ -357,9 +624,11 @@ func AgentPushEvents(c *fiber.Ctx) error {
 					}
 
 					Notifier.Notify(notifications.Notification{
-						Subject: subject,
-						Message: msg,
-						Type:    notifType,
+						Subject:   subject,
+						Message:   msg,
+						Type:      notifType,
+						ServerID:  req.ServerID,
+						EventType: evtType,
 					})
 				}(hostname, event.Message, event.Type)
 			}
@@ -369,13 +638,40 @@ func AgentPushEvents(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"status": "ok"})
 }
 
-// authenticateAgent verifies the agent's credentials
-func authenticateAgent(serverID, apiSecret string) bool {
+// groupedCronFailure returns the job of a cron failure event, which is
+// recorded per job instead of as a plain event ("" for other events)
+func groupedCronFailure(eventType, severity, details string) string {
+	if (eventType == "cron" || eventType == "cron_error") && severity != "info" {
+		return cronEventCommand(details)
+	}
+	return ""
+}
+
+// authenticateAgent verifies the agent's credentials: a verified client
+// certificate for the server, or its request signature or API secret unless
+// it requires mTLS. A certificate or signature of another server (a relay
+// forwarding pushes) is ignored.
+func authenticateAgent(c *fiber.Ctx, serverID, apiSecret string) bool {
 	var secretHash string
-	err := database.DB.QueryRow("SELECT api_secret_hash FROM servers WHERE id = ?", serverID).Scan(&secretHash)
+	var mtlsRequired bool
+	// Agents onboarded by a revoked install package are locked out
+	err := database.DB.QueryRow(`
+		SELECT s.api_secret_hash, `+mtlsRequiredSQL+` FROM servers s
+		LEFT JOIN agent_packages p ON p.id = s.package_id
+		WHERE s.id = ? AND COALESCE(p.revoked_at, 0) = 0
+	`, time.Now().Unix(), serverID).Scan(&secretHash, &mtlsRequired)
 	if err != nil {
 		return false
 	}
+	if certAuthenticated(c, serverID) {
+		return true
+	}
+	if mtlsRequired {
+		return false
+	}
+	if signedBy(c, serverID) {
+		return true
+	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(secretHash), []byte(apiSecret))
 	return err == nil
@@ -393,11 +689,15 @@ func GetLicenseStatus(c *fiber.Ctx) error {
 	return c.JSON(status)
 }
 
-// GenerateAgentPackage generates an install script for the agent
+// GenerateAgentPackage generates an install script, a .deb/.rpm package, an
+// air-gapped bundle or provisioning snippet (Ansible tasks, cloud-init
+// user-data, Kubernetes DaemonSet) for the agent
 func GenerateAgentPackage(c *fiber.Ctx) error {
 	format := c.Params("format")
-	if format != "bash" {
-		return c.Status(400).JSON(fiber.Map{"error": "Only bash format is currently supported"})
+	switch format {
+	case "bash", "deb", "rpm", "bundle", "ansible", "cloud-init", "kubernetes":
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "Supported formats: bash, deb, rpm, bundle, ansible, cloud-init, kubernetes"})
 	}
 
 	// Verify Admin Token for generating the package
@@ -421,6 +721,19 @@ func GenerateAgentPackage(c *fiber.Ctx) error {
 	// Generate server ID
 	serverID := generateServerID()
 
+	// Tags given to every host the package onboards (?tags=prod,web)
+	tags, err := parseTagList(c.Query("tags"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Record the package so hosts it onboards can be traced (and revoked) as a batch
+	packageID, err := createInstallPackage(c.Query("label"), c.IP(), tags)
+	if err != nil {
+		log.Printf("Failed to record install package: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to generate install script"})
+	}
+
 	// Determine if we should use insecure flags (dev mode or local network)
 	insecure := strings.Contains(dashboardURL, "localhost") || 
                 strings.Contains(dashboardURL, "127.0.0.1") ||
@@ -428,8 +741,18 @@ func GenerateAgentPackage(c *fiber.Ctx) error {
                 strings.Contains(dashboardURL, "10.") ||
                 (strings.Contains(dashboardURL, "172.") && isPrivateIP(dashboardURL))
 
+	if format == "deb" || format == "rpm" {
+		return sendNativePackage(c, format, dashboardURL, packageID, insecure)
+	}
+	if format == "bundle" {
+		return sendAirGappedBundle(c, dashboardURL, packageID, insecure)
+	}
+	if format == "ansible" || format == "cloud-init" || format == "kubernetes" {
+		return sendProvisioningSnippet(c, format, dashboardURL, packageID, insecure)
+	}
+
 	// Generate bash script
-	script, err := generateBashInstallScript(dashboardURL, serverID, apiSecret, RegistrationToken, insecure)
+	script, err := generateBashInstallScript(dashboardURL, serverID, apiSecret, RegistrationToken, packageID, insecure)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to generate install script"})
 	}
@@ -441,6 +764,41 @@ func GenerateAgentPackage(c *fiber.Ctx) error {
 	return c.Send([]byte(script))
 }
 
+// sendNativePackage builds a .deb or .rpm for the architecture in ?arch=
+// (default amd64) around the published agent binary
+func sendNativePackage(c *fiber.Ctx, format, dashboardURL, packageID string, insecure bool) error {
+	binaryPath, filename, err := agentBinaryPath("linux", c.Query("arch", "amd64"), "")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	agentBinary, err := os.ReadFile(binaryPath)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Agent binary not found for this architecture"})
+	}
+	archs := nativeArchs[strings.TrimPrefix(filename, "nodeguarder-agent-linux-")]
+	skeleton := nativeConfigSkeleton(dashboardURL, RegistrationToken, packageID, insecure)
+
+	var pkg []byte
+	if format == "deb" {
+		p := newNativePackage(publishedAgentVersion(), archs.deb, "/lib/systemd/system", agentBinary, skeleton)
+		pkg, err = buildDeb(p)
+		filename = fmt.Sprintf("%s_%s_%s.deb", nativePackageName, p.Version, p.Arch)
+		c.Set("Content-Type", "application/vnd.debian.binary-package")
+	} else {
+		p := newNativePackage(publishedAgentVersion(), archs.rpm, "/usr/lib/systemd/system", agentBinary, skeleton)
+		pkg, err = buildRPM(p)
+		filename = fmt.Sprintf("%s-%s-1.%s.rpm", nativePackageName, p.Version, p.Arch)
+		c.Set("Content-Type", "application/x-rpm")
+	}
+	if err != nil {
+		log.Printf("Failed to build %s package: %v", format, err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to build package"})
+	}
+
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.Send(pkg)
+}
+
 // UploadLicense handles license file upload (admin only)
 func UploadLicense(c *fiber.Ctx) error {
 	file, err := c.FormFile("license")
@@ -530,7 +888,7 @@ func generateServerID() string {
 }
 
 // generateBashInstallScript generates the bash install script
-func generateBashInstallScript(dashboardURL, serverID, apiSecret, regToken string, insecure bool) (string, error) {
+func generateBashInstallScript(dashboardURL, serverID, apiSecret, regToken, packageID string, insecure bool) (string, error) {
 	scriptTemplate := `#!/bin/bash
 set -e
 
@@ -555,6 +913,7 @@ done
 # Server ID is fixed for this unique script download
 SERVER_ID="{{ .ServerID }}"
 API_SECRET="{{ .APISecret }}"
+PACKAGE_ID="{{ .PackageID }}"
 
 AGENT_BIN="nodeguarder-agent"
 INSTALL_DIR="/opt/nodeguarder-agent"
@@ -576,6 +935,42 @@ if [[ $EUID -ne 0 ]]; then
    exit 1
 fi
 
+# FreeBSD and macOS: the agent installs its own rc.d script or launchd daemon
+case "$(uname -s)" in
+    Darwin|FreeBSD)
+        PLATFORM=$(uname -s | tr '[:upper:]' '[:lower:]')
+        case "$(uname -m)" in
+            arm64|aarch64) ARCH="arm64" ;;
+            *) ARCH="amd64" ;;
+        esac
+        echo -e "${YELLOW}📋 Detected OS: $PLATFORM, Architecture: $ARCH${NC}"
+
+        mkdir -p "$INSTALL_DIR"
+        AGENT_URL="${DASHBOARD_URL}/api/v1/agent/download/${PLATFORM}/${ARCH}"
+        echo -e "${YELLOW}📥 Downloading agent binary from $AGENT_URL...${NC}"
+        if ! curl {{ if .Insecure }}-k{{ end }} -fsSL "$AGENT_URL" -o "$INSTALL_DIR/$AGENT_BIN"; then
+            echo -e "${RED}❌ Failed to download agent binary!${NC}"
+            exit 1
+        fi
+        chmod +x "$INSTALL_DIR/$AGENT_BIN"
+
+        cat > "$CONFIG_FILE" <<EOF
+server_id: $SERVER_ID
+api_secret: $API_SECRET
+dashboard_url: $DASHBOARD_URL
+registration_token: $REGISTRATION_TOKEN
+package_id: $PACKAGE_ID
+interval: 10
+disable_ssl_verify: {{ .Insecure }}
+EOF
+        chmod 600 "$CONFIG_FILE"
+
+        "$INSTALL_DIR/$AGENT_BIN" --install --config "$CONFIG_FILE"
+        echo -e "${GREEN}✅ Installation complete! Logs: tail -f /var/log/nodeguarder/agent.log${NC}"
+        exit 0
+        ;;
+esac
+
 # Detect OS and architecture
 OS="unknown"
 ARCH="amd64"
@@ -595,14 +990,15 @@ elif command -v apk &> /dev/null; then
 elif command -v systemctl &> /dev/null; then
     OS="linux"
 else
-    echo -e "${RED}❌ Unsupported OS. Supported: Debian, RHEL, SUSE, Arch, Alpine, or any Generic Linux with systemd${NC}"
+    echo -e "${RED}❌ Unsupported OS. Supported: Debian, RHEL, SUSE, Arch, Alpine, any Generic Linux with systemd, FreeBSD or macOS${NC}"
     exit 1
 fi
 
 if [[ $(uname -m) == "aarch64" ]]; then
     ARCH="arm64"
-elif [[ $(uname -m) == "armv7l" ]]; then
-    ARCH="arm"
+elif [[ $(uname -m) == "armv6l" || $(uname -m) == "armv7l" ]]; then
+    # 32-bit ARM (older Raspberry Pis, OpenWrt-class boxes) gets the lite build
+    ARCH="armv6"
 elif [[ $(uname -m) == "i686" ]]; then
     ARCH="386"
 fi
@@ -638,6 +1034,7 @@ server_id: $SERVER_ID
 api_secret: $API_SECRET
 dashboard_url: $DASHBOARD_URL
 registration_token: $REGISTRATION_TOKEN
+package_id: $PACKAGE_ID
 interval: 10
 disable_ssl_verify: {{ .Insecure }}
 EOF
@@ -656,6 +1053,7 @@ Wants=network-online.target
 Type=simple
 User=root
 ExecStart=/opt/nodeguarder-agent/nodeguarder-agent --config $CONFIG_FILE
+ExecReload=/bin/kill -HUP \$MAINPID
 Restart=always
 RestartSec=10
 StandardOutput=journal
@@ -750,12 +1148,14 @@ fi
 		ServerID     string
 		APISecret    string
 		RegistrationToken string
+		PackageID    string
 		Insecure     bool
 	}{
 		DashboardURL: dashboardURL,
-		ServerID:     serverID,
+		ServerID: serverID,
 		APISecret:    apiSecret,
 		RegistrationToken: regToken,
+		PackageID:    packageID,
 		Insecure:     insecure,
 	}
 
@@ -795,58 +1195,79 @@ func getHostname(serverID string) string {
 
 // DownloadAgent serves the agent binary
 func DownloadAgent(c *fiber.Ctx) error {
-	osName := c.Params("os")
-	arch := c.Params("arch")
-
-	if osName != "linux" {
-		return c.Status(400).JSON(fiber.Map{"error": "Only linux is supported"})
+	fullPath, filename, err := agentBinaryPath(c.Params("os"), c.Params("arch"), c.Query("version"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Sanitize architecture
-	validArchs := map[string]bool{"amd64": true, "arm64": true, "arm": true, "386": true}
-	if !validArchs[arch] {
-		return c.Status(400).JSON(fiber.Map{"error": "Unsupported architecture"})
+	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+		return c.Status(404).JSON(fiber.Map{"error": "Agent binary not found for this architecture"})
 	}
 
-	// Path to binaries (configurable via env, default to ./agent-binaries)
-	binaryPath := os.Getenv("AGENT_BINARY_PATH")
-	if binaryPath == "" {
-		binaryPath = "./agent-binaries"
-	}
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	return c.SendFile(fullPath)
+}
 
-	filename := fmt.Sprintf("nodeguarder-agent-%s-%s", osName, arch)
-	fullPath := fmt.Sprintf("%s/%s", binaryPath, filename)
+// DownloadAgentSignature serves the detached Ed25519 signature agents verify
+// before installing an update
+func DownloadAgentSignature(c *fiber.Ctx) error {
+	fullPath, _, err := agentBinaryPath(c.Params("os"), c.Params("arch"), c.Query("version"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 
 	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
 		return c.Status(404).JSON(fiber.Map{"error": "Agent binary not found for this architecture"})
 	}
 
-	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
-	return c.SendFile(fullPath)
+	signature, err := agentBinarySignature(fullPath)
+	if err != nil {
+		log.Printf("⚠️  No valid signature for %s: %v", fullPath, err)
+		return c.Status(404).JSON(fiber.Map{"error": "Agent binary is not signed"})
+	}
+	return c.SendString(signature)
+}
+
+// agentBinaryPath resolves the binary for an OS, architecture and version
+// (empty = the published version)
+func agentBinaryPath(osName, arch, version string) (string, string, error) {
+	// Sanitize OS and architecture. FreeBSD and macOS agents report core metrics
+	// (no eBPF, journald or systemd).
+	validArchs := map[string]map[string]bool{
+		"linux":   {"amd64": true, "arm64": true, "arm": true, "armv6": true, "386": true},
+		"freebsd": {"amd64": true, "arm64": true},
+		"darwin":  {"amd64": true, "arm64": true},
+	}
+	if validArchs[osName] == nil {
+		return "", "", fmt.Errorf("Unsupported operating system")
+	}
+	// 32-bit ARM aliases resolve to the lite (ARMv6) build
+	if osName == "linux" && (arch == "armhf" || arch == "armv6l" || arch == "armv7l") {
+		arch = "armv6"
+	}
+	if !validArchs[osName][arch] {
+		return "", "", fmt.Errorf("Unsupported architecture")
+	}
+
+	if version != "" && !versionPattern.MatchString(version) {
+		return "", "", fmt.Errorf("Invalid version")
+	}
+
+	// Path to binaries (configurable via env, default to ./agent-binaries)
+	filename := fmt.Sprintf("nodeguarder-agent-%s-%s", osName, arch)
+	return fmt.Sprintf("%s/%s", versionBinaryDir(version), filename), filename, nil
 }
 
 // GetAgentVersion returns the latest available agent version
 func GetAgentVersion(c *fiber.Ctx) error {
-	// Version is injected at build time into the container env
-	version := os.Getenv("AGENT_VERSION")
-	if version == "" {
-		version = "1.0.1" // Fallback
-	}
 	return c.JSON(fiber.Map{
-		"version": version,
+		"version": publishedAgentVersion(),
 		"latest": true,
 	})
 }
-// AgentGetConfig returns the configuration for the agent
-func AgentGetConfig(c *fiber.Ctx) error {
-	serverID := c.Query("server_id")
-	apiSecret := c.Query("api_secret")
-
-	// Authenticate
-	if !authenticateAgent(serverID, apiSecret) {
-		return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
-	}
-
+// resolveAgentConfig builds a server's settings from the global config, its
+// group and its own overrides, noting in Source which layer each came from
+func resolveAgentConfig(serverID string) models.AgentConfig {
 	// Fetch Global Configuration
 	// Defaults
 	config := models.AgentConfig{
@@ -868,22 +1289,22 @@ func AgentGetConfig(c *fiber.Ctx) error {
 
 	// Load stored settings
 	var driftIgnoreJSON string
-	if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'drift_ignore'").Scan(&driftIgnoreJSON); err == nil {
+	if err := database.GetSetting("drift_ignore", &driftIgnoreJSON); err == nil {
 		json.Unmarshal([]byte(driftIgnoreJSON), &config.DriftIgnore)
 	}
 
 	var cronIgnoreJSON string
-	if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'cron_ignore'").Scan(&cronIgnoreJSON); err == nil {
+	if err := database.GetSetting("cron_ignore", &cronIgnoreJSON); err == nil {
 		json.Unmarshal([]byte(cronIgnoreJSON), &config.CronIgnore)
 	}
 
     var cronTimeoutsJSON string
-	if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'cron_timeouts'").Scan(&cronTimeoutsJSON); err == nil {
+	if err := database.GetSetting("cron_timeouts", &cronTimeoutsJSON); err == nil {
 		json.Unmarshal([]byte(cronTimeoutsJSON), &config.CronTimeouts)
 	}
 
     var cronGlobalTimeoutVal string
-	if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'cron_global_timeout'").Scan(&cronGlobalTimeoutVal); err == nil {
+	if err := database.GetSetting("cron_global_timeout", &cronGlobalTimeoutVal); err == nil {
 		fmt.Sscanf(cronGlobalTimeoutVal, "%d", &config.CronGlobalTimeout)
 	}
 
@@ -892,19 +1313,22 @@ func AgentGetConfig(c *fiber.Ctx) error {
 	// Let's try individual for granularity if we had a flat table, 
 	// but storing the whole struct as JSON is easier for "Config UI" saving.
 	var thresholdsJSON string
-	if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'thresholds'").Scan(&thresholdsJSON); err == nil {
+	if err := database.GetSetting("thresholds", &thresholdsJSON); err == nil {
 		json.Unmarshal([]byte(thresholdsJSON), &config.Thresholds)
 	}
+	// Threshold profile assigned to the server or one of its tags
+	config.Thresholds, config.ThresholdProfile = health.ResolveThresholds(serverID, config.Thresholds)
+	config.DiskExcludeFSTypes, config.DiskExcludePaths = health.LoadDiskExclusions()
 	
 	// Offline Timeout
 	var timeoutVal string
-	if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'offline_timeout'").Scan(&timeoutVal); err == nil {
+	if err := database.GetSetting("offline_timeout", &timeoutVal); err == nil {
 		fmt.Sscanf(timeoutVal, "%d", &config.OfflineTimeout)
 	}
 
     // Cron Enabled
     var cronEnabledVal string
-    if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'cron_enabled'").Scan(&cronEnabledVal); err == nil {
+    if err := database.GetSetting("cron_enabled", &cronEnabledVal); err == nil {
         config.CronEnabled = cronEnabledVal == "true"
     } else {
         config.CronEnabled = true // Default to true
@@ -913,7 +1337,7 @@ func AgentGetConfig(c *fiber.Ctx) error {
     // Cron Auto Discover
     var cronAutoDiscoverVal string
     config.CronAutoDiscover = true // Default to true
-    if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'cron_auto_discover'").Scan(&cronAutoDiscoverVal); err == nil {
+    if err := database.GetSetting("cron_auto_discover", &cronAutoDiscoverVal); err == nil {
         if cronAutoDiscoverVal == "false" || cronAutoDiscoverVal == "0" {
             config.CronAutoDiscover = false
         }
@@ -921,15 +1345,46 @@ func AgentGetConfig(c *fiber.Ctx) error {
 
 	// Drift Paths
 	var driftPathsJSON string
-	if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'drift_paths'").Scan(&driftPathsJSON); err == nil {
+	if err := database.GetSetting("drift_paths", &driftPathsJSON); err == nil {
 		if err := json.Unmarshal([]byte(driftPathsJSON), &config.DriftPaths); err != nil {
         }
 	}
+
+	// Overrides of the server's group, then of the server itself (thresholds
+	// were already resolved by ResolveThresholds, shared with health evaluation)
+	config.Source = map[string]string{"interval": "agent"}
+	for _, key := range overridableSettings {
+		config.Source[key] = "global"
+	}
+	groupThresholds := false
+	if name, groupCfg, ok := health.ServerGroupConfig(serverID); ok {
+		applyConfigOverrides(&config, groupCfg, "group")
+		config.Group = name
+		groupThresholds = groupCfg.Thresholds != nil
+	}
+	serverCfg, _ := loadServerConfiguration(serverID)
+	if serverCfg.Overrides != nil {
+		applyConfigOverrides(&config, *serverCfg.Overrides, "server")
+	}
+	// Drift override (merged into or replacing the group/global lists)
+	applyConfigOverrides(&config, models.ConfigOverrides{Drift: serverCfg.Drift}, "server")
+	if serverCfg.Interval > 0 {
+		config.Interval = serverCfg.Interval
+		config.Source["interval"] = "server"
+	}
+	switch {
+	case serverCfg.Overrides != nil && serverCfg.Overrides.Thresholds != nil:
+		config.Source["thresholds"] = "server"
+	case config.ThresholdProfile != "":
+		config.Source["thresholds"] = "profile"
+	case groupThresholds:
+		config.Source["thresholds"] = "group"
+	}
     
     // Health Enabled
     var healthEnabledVal string
     // Default to true if not found (matching frontend load logic, though zero value is false, explicit load is better)
-    if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'health_enabled'").Scan(&healthEnabledVal); err == nil {
+    if err := database.GetSetting("health_enabled", &healthEnabledVal); err == nil {
          config.HealthEnabled = healthEnabledVal == "true"
     } else {
          config.HealthEnabled = true // Default true
@@ -938,17 +1393,39 @@ func AgentGetConfig(c *fiber.Ctx) error {
     // Health Sustain Duration
     var healthSustainVal string
     config.HealthSustainDuration = 30 // Default
-    if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'health_sustain_duration'").Scan(&healthSustainVal); err == nil {
+    if err := database.GetSetting("health_sustain_duration", &healthSustainVal); err == nil {
         fmt.Sscanf(healthSustainVal, "%d", &config.HealthSustainDuration)
     }
 
     // Drift Interval
     var driftIntervalVal string
     config.DriftInterval = 300 // Default to 5m
-    if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'drift_interval'").Scan(&driftIntervalVal); err == nil {
+    if err := database.GetSetting("drift_interval", &driftIntervalVal); err == nil {
         fmt.Sscanf(driftIntervalVal, "%d", &config.DriftInterval)
     }
 
+    // Network Drift (listening ports)
+    var networkDriftVal string
+    if err := database.GetSetting("network_drift_enabled", &networkDriftVal); err == nil {
+        config.NetworkDriftEnabled = networkDriftVal == "true"
+    }
+    config.EgressMonitorEnabled, config.EgressAllow = loadEgressSettings()
+
+	return config
+}
+
+// AgentGetConfig returns the configuration for the agent
+func AgentGetConfig(c *fiber.Ctx) error {
+	serverID := c.Query("server_id")
+	apiSecret := c.Query("api_secret")
+
+	// Authenticate
+	if !authenticateAgent(c, serverID, apiSecret) {
+		return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
+	}
+
+	config := resolveAgentConfig(serverID)
+
     // Check for pending log request
     var logRequestPending bool
     if err := database.DB.QueryRow("SELECT log_request_pending FROM servers WHERE id = ?", serverID).Scan(&logRequestPending); err == nil {
@@ -961,16 +1438,60 @@ func AgentGetConfig(c *fiber.Ctx) error {
         config.Uninstall = pendingUninstall
     }
 
+    // Dispatch due script runs
+    config.Scripts = pendingScripts(serverID)
+    config.CronPauses = pendingCronPauses(serverID)
+    config.Remediations = pendingRemediations(serverID)
+
+    // One-shot drift re-baseline after the user accepted the changes
+    if res, err := database.DB.Exec("UPDATE servers SET drift_rebaseline_pending = 0 WHERE id = ? AND drift_rebaseline_pending = 1", serverID); err == nil {
+        if n, _ := res.RowsAffected(); n == 1 {
+            config.DriftRebaseline = true
+        }
+    }
+
+    // Hand over a waiting remote terminal session (server must be opted in)
+    var terminalEnabled bool
+    if err := database.DB.QueryRow("SELECT COALESCE(terminal_enabled, 0) FROM servers WHERE id = ?", serverID).Scan(&terminalEnabled); err == nil && terminalEnabled {
+        if sess, ok := terminal.PendingFor(serverID); ok {
+            config.TerminalSession = sess.ID
+        }
+    }
+
+	// Agent version assigned by a staged rollout
+	config.TargetVersion = serverTargetVersion(serverID)
+
 	return c.JSON(config)
 }
 
+// agentStreamWait is how long a config stream request is held open; it must
+// stay below the agent's 30s HTTP timeout
+const agentStreamWait = 25 * time.Second
+
+// AgentStream long-polls until the agent's configuration or pending commands
+// change, so it can fetch its config right away instead of on the next poll.
+// The agent passes back the returned seq as since.
+func AgentStream(c *fiber.Ctx) error {
+	serverID := c.Query("server_id")
+	if !authenticateAgent(c, serverID, c.Query("api_secret")) {
+		return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
+	}
+
+	since := push.Seq() // first call: only changes from now on
+	if c.Query("since") != "" {
+		since = int64(c.QueryInt("since"))
+	}
+	seq, changed := push.Wait(serverID, since, agentStreamWait, nil)
+	return c.JSON(fiber.Map{"seq": seq, "changed": changed})
+}
+
 // AgentUploadLogs handles log file upload from agent
 func AgentUploadLogs(c *fiber.Ctx) error {
     serverID := c.FormValue("server_id")
     apiSecret := c.FormValue("api_secret")
 
     // Authenticate
-    if !authenticateAgent(serverID, apiSecret) {
+    if !authenticateAgent(c, serverID, apiSecret) {
         return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
     }
 
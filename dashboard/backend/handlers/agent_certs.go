@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"crypto/x509"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/agentca"
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+// Agents can authenticate with a client certificate issued by the dashboard's
+// agent CA instead of their API secret. The agent sends a CSR (authenticated
+// with its API secret) and uses the returned certificate on its connections;
+// once a server authenticated with a certificate, its API secret alone is no
+// longer accepted while it holds an active one. Revoking the last active
+// certificate lifts that again, so the agent can enroll anew.
+
+// agentCertLocal holds the server ID of a verified agent client certificate
+const agentCertLocal = "agent_cert_server"
+
+// clientCertHeader is the header a TLS-terminating proxy forwards the agent's
+// client certificate in (nginx: $ssl_client_escaped_cert). It must only be
+// enabled behind a proxy that always sets it, or clients could supply their own.
+var clientCertHeader = os.Getenv("MTLS_CLIENT_CERT_HEADER")
+
+// AgentCertificate is a client certificate issued to an agent
+type AgentCertificate struct {
+	Serial    string `json:"serial"`
+	IssuedAt  int64  `json:"issued_at"`
+	ExpiresAt int64  `json:"expires_at"`
+	RevokedAt int64  `json:"revoked_at,omitempty"`
+}
+
+// presentedCert returns the client certificate of the request, if any
+func presentedCert(c *fiber.Ctx) *x509.Certificate {
+	if cert, ok := c.Locals(agentca.CertKey).(*x509.Certificate); ok {
+		return cert // gRPC transport
+	}
+	if state := c.Context().TLSConnectionState(); state != nil && len(state.PeerCertificates) > 0 {
+		return state.PeerCertificates[0]
+	}
+	if clientCertHeader != "" {
+		if value := c.Get(clientCertHeader); value != "" {
+			cert, err := agentca.ParseHeader(value)
+			if err != nil {
+				log.Printf("⚠️  Ignoring malformed %s header from %s: %v", clientCertHeader, c.IP(), err)
+				return nil
+			}
+			return cert
+		}
+	}
+	return nil
+}
+
+// AgentClientCert verifies the client certificate of an agent request against
+// the agent CA and the issued certificates. Requests without one continue to
+// authenticate with their API secret.
+func AgentClientCert(c *fiber.Ctx) error {
+	cert := presentedCert(c)
+	if cert == nil {
+		return c.Next()
+	}
+	serverID, err := agentca.Verify(cert)
+	if err != nil {
+		log.Printf("❌ Rejected agent certificate from %s: %v", c.IP(), err)
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid client certificate"})
+	}
+
+	var revokedAt int64
+	var required bool
+	err = database.DB.QueryRow(`
+		SELECT COALESCE(ac.revoked_at, 0), COALESCE(s.mtls_required, 0)
+		FROM agent_certificates ac
+		JOIN servers s ON s.id = ac.server_id
+		WHERE ac.serial = ? AND ac.server_id = ?
+	`, agentca.Serial(cert), serverID).Scan(&revokedAt, &required)
+	if err != nil || revokedAt != 0 {
+		log.Printf("❌ Rejected revoked or unknown certificate %s of %s", agentca.Serial(cert), serverID)
+		return c.Status(401).JSON(fiber.Map{"error": "Client certificate revoked"})
+	}
+	if !required {
+		database.DB.Exec("UPDATE servers SET mtls_required = 1 WHERE id = ?", serverID)
+		log.Printf("🔒 %s authenticated with a client certificate; API secret no longer accepted", serverID)
+	}
+
+	c.Locals(agentCertLocal, serverID)
+	return c.Next()
+}
+
+// certAuthenticated reports whether the request carried a verified client
+// certificate for serverID
+func certAuthenticated(c *fiber.Ctx, serverID string) bool {
+	certID, _ := c.Locals(agentCertLocal).(string)
+	return certID != "" && certID == serverID
+}
+
+// mtlsRequiredSQL selects (for servers s) whether the API secret is refused:
+// the agent switched to its certificate and still holds an active one, so an
+// agent whose certificates all expired or were revoked can enroll again.
+// Takes the current time as parameter.
+const mtlsRequiredSQL = `COALESCE(s.mtls_required, 0) AND EXISTS (
+	SELECT 1 FROM agent_certificates ac
+	WHERE ac.server_id = s.id AND ac.revoked_at IS NULL AND ac.expires_at > ?)`
+
+// requiresMTLS reports whether the server only accepts its client certificate
+func requiresMTLS(serverID string) bool {
+	var required bool
+	database.DB.QueryRow("SELECT "+mtlsRequiredSQL+" FROM servers s WHERE s.id = ?", time.Now().Unix(), serverID).Scan(&required)
+	return required
+}
+
+// AgentRequestCertificate issues a client certificate for the agent's CSR.
+// The same endpoint renews it: agents that already use mTLS authenticate with
+// their current certificate.
+func AgentRequestCertificate(c *fiber.Ctx) error {
+	var req struct {
+		ServerID  string `json:"server_id"`
+		APISecret string `json:"api_secret"`
+		CSR       string `json:"csr"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if !authenticateAgent(c, req.ServerID, req.APISecret) {
+		return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
+	}
+
+	certPEM, cert, err := agentca.Issue(req.ServerID, []byte(req.CSR))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	_, err = database.DB.Exec(`
+		INSERT INTO agent_certificates (serial, server_id, issued_at, expires_at)
+		VALUES (?, ?, ?, ?)
+	`, agentca.Serial(cert), req.ServerID, time.Now().Unix(), cert.NotAfter.Unix())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to record certificate"})
+	}
+
+	log.Printf("🔑 Issued client certificate %s to %s (expires %s)", agentca.Serial(cert), req.ServerID, cert.NotAfter.Format("2006-01-02"))
+	return c.JSON(fiber.Map{
+		"certificate": string(certPEM),
+		"expires_at":  cert.NotAfter.Unix(),
+	})
+}
+
+// GetAgentCertificates lists the client certificates issued to a server
+func GetAgentCertificates(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+	rows, err := database.DB.Query(`
+		SELECT serial, issued_at, expires_at, COALESCE(revoked_at, 0)
+		FROM agent_certificates
+		WHERE server_id = ?
+		ORDER BY issued_at DESC
+	`, serverID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	certs := []AgentCertificate{}
+	for rows.Next() {
+		var ac AgentCertificate
+		if err := rows.Scan(&ac.Serial, &ac.IssuedAt, &ac.ExpiresAt, &ac.RevokedAt); err != nil {
+			continue
+		}
+		certs = append(certs, ac)
+	}
+
+	return c.JSON(fiber.Map{"mtls_required": requiresMTLS(serverID), "certificates": certs})
+}
+
+// RevokeAgentCertificate revokes a client certificate. Once a server has no
+// active certificate left, its agent may enroll again with its API secret.
+func RevokeAgentCertificate(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+	serial := c.Params("serial")
+	now := time.Now().Unix()
+
+	res, err := database.DB.Exec(`
+		UPDATE agent_certificates SET revoked_at = ?
+		WHERE serial = ? AND server_id = ? AND revoked_at IS NULL
+	`, now, serial, serverID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Certificate not found or already revoked"})
+	}
+
+	recordAudit(c, "agent_certificate.revoke", serverID, serial)
+	return c.JSON(fiber.Map{"status": "revoked", "mtls_required": requiresMTLS(serverID)})
+}
+
+// GetAgentCA returns the agent CA certificate, for proxies verifying agent
+// client certificates (nginx ssl_client_certificate)
+func GetAgentCA(c *fiber.Ctx) error {
+	pem := agentca.CertPEM()
+	if pem == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Agent CA unavailable"})
+	}
+	c.Set("Content-Type", "application/x-pem-file")
+	c.Set("Content-Disposition", `attachment; filename="agent-ca.crt"`)
+	return c.Send(pem)
+}
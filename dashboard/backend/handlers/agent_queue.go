@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/notifications"
+)
+
+// queueStuckAge is how old the oldest item of an agent's offline queue may
+// get while the agent itself is pushing: older means the queue is not draining
+// (e.g. replays rejected by the dashboard) and the backlog will never arrive
+const queueStuckAge = 15 * 60
+
+// updateAgentQueue stores the offline queue statistics of a metrics push and
+// alerts when the agent is online but its queue stops draining
+func updateAgentQueue(serverID string, reportedAt int64, raw interface{}) {
+	bytes, err := json.Marshal(raw)
+	if err != nil {
+		return
+	}
+	var stats models.AgentQueueStats
+	if err := json.Unmarshal(bytes, &stats); err != nil {
+		return
+	}
+	stats.ReportedAt = reportedAt
+
+	previous := loadAgentQueue(serverID)
+	// Pushes replayed from the queue carry the stats of when they were queued
+	if previous != nil && previous.ReportedAt > reportedAt {
+		return
+	}
+	stored, _ := json.Marshal(stats)
+	if _, err := database.DB.Exec("UPDATE servers SET agent_queue = ? WHERE id = ?", string(stored), serverID); err != nil {
+		log.Printf("Failed to store queue stats for %s: %v", serverID, err)
+		return
+	}
+
+	wasStuck := previous != nil && previous.Stuck(queueStuckAge)
+	isStuck := stats.Stuck(queueStuckAge)
+	if wasStuck == isStuck {
+		return
+	}
+
+	hostname := getHostname(serverID)
+	severity, msg := "info", "Agent offline queue is draining again"
+	var alertType notifications.NotificationType
+	if isStuck {
+		severity, alertType = "warning", notifications.TypeWarning
+		msg = fmt.Sprintf("Agent offline queue is not draining: %d items, oldest queued %s ago",
+			stats.Total, time.Duration(stats.OldestAgeSeconds)*time.Second)
+	}
+	if _, err := database.DB.Exec(`
+		INSERT INTO events (server_id, timestamp, event_type, severity, message, details)
+		VALUES (?, ?, 'agent', ?, ?, ?)
+	`, serverID, time.Now().Unix(), severity, msg, string(stored)); err != nil {
+		log.Printf("Failed to insert queue event: %v", err)
+	}
+	log.Printf("📦 %s (%s): %s", hostname, serverID, msg)
+
+	if alertType != "" && Notifier != nil {
+		go Notifier.Notify(notifications.Notification{
+			Subject:   fmt.Sprintf("[%s] Agent Queue: %s", strings.ToUpper(severity), hostname),
+			Message:   fmt.Sprintf("Server %s (%s): %s", hostname, serverID, msg),
+			Type:      alertType,
+			ServerID:  serverID,
+			EventType: "agent",
+		})
+	}
+}
+
+// loadAgentQueue returns the last reported queue statistics (nil if none)
+func loadAgentQueue(serverID string) *models.AgentQueueStats {
+	var raw string
+	if database.DB.QueryRow("SELECT COALESCE(agent_queue, '') FROM servers WHERE id = ?", serverID).Scan(&raw) != nil {
+		return nil
+	}
+	return parseAgentQueue(raw)
+}
+
+// parseAgentQueue decodes stored queue statistics (nil until reported)
+func parseAgentQueue(raw string) *models.AgentQueueStats {
+	if raw == "" {
+		return nil
+	}
+	var stats models.AgentQueueStats
+	if err := json.Unmarshal([]byte(raw), &stats); err != nil {
+		return nil
+	}
+	return &stats
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/secrets"
+)
+
+// Agents send their API secret only at registration. The registration
+// response announces signing support (X-Agent-Signing), and later requests
+// carry no secret but an HMAC-SHA256 signature instead:
+//
+//	X-Agent-ID:        server ID
+//	X-Agent-Timestamp: unix seconds, within signatureMaxSkew of the dashboard clock
+//	X-Agent-Nonce:     random, accepted once
+//	X-Agent-Signature: hex HMAC(signing key, method \n request URI \n timestamp \n nonce \n hex SHA-256(body))
+//
+// The signing key is derived from the API secret and stored encrypted with
+// the secrets key. Relays do not pass the X-Agent-Signing header on, so agents
+// behind a relay keep sending their secret.
+
+const (
+	// signingScheme is announced in the X-Agent-Signing registration header
+	signingScheme = "hmac-sha256"
+	// signatureMaxSkew bounds the age of a signed request (and how long nonces are kept)
+	signatureMaxSkew = 5 * time.Minute
+)
+
+// agentSignedLocal holds the server ID of a request with a valid signature
+const agentSignedLocal = "agent_signed_server"
+
+// maxNonces caps the nonces kept for replay detection. Only requests with a
+// valid signature record one; past the cap, signed requests are turned away
+// until older nonces expire rather than forgetting nonces still in use.
+var maxNonces = 200000
+
+var (
+	nonceMu   sync.Mutex
+	seenNonce = make(map[string]int64) // server ID + nonce -> expiry (unix)
+	lastPrune int64
+
+	errNonceReplayed = errors.New("nonce already used")
+	errTooManyNonces = errors.New("too many signed requests")
+)
+
+// signingKey derives the request signing key from an agent's API secret
+func signingKey(apiSecret string) []byte {
+	mac := hmac.New(sha256.New, []byte(apiSecret))
+	mac.Write([]byte("nodeguarder-request-signing"))
+	return mac.Sum(nil)
+}
+
+// requestSignature computes the signature of a request
+func requestSignature(key []byte, method, uri, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s\n%s\n%s\n%s\n%s", method, uri, timestamp, nonce, hex.EncodeToString(bodyHash[:]))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// storeSigningKey keeps the signing key for the agent's secret at registration.
+// It reports whether signed requests can be verified for this server.
+func storeSigningKey(serverID, apiSecret string) bool {
+	sealed, err := secrets.Seal(signingKey(apiSecret))
+	if err != nil {
+		log.Printf("⚠️  Request signing unavailable for %s: %v", serverID, err)
+		return false
+	}
+	_, err = database.DB.Exec("UPDATE servers SET signing_key = ? WHERE id = ?", sealed, serverID)
+	return err == nil
+}
+
+// useNonce records a nonce; it fails for a nonce already used and when
+// maxNonces are kept
+func useNonce(serverID, nonce string, now time.Time) error {
+	nonceMu.Lock()
+	defer nonceMu.Unlock()
+	if now.Unix()-lastPrune > int64(signatureMaxSkew.Seconds()) || len(seenNonce) >= maxNonces {
+		for k, expiry := range seenNonce {
+			if expiry < now.Unix() {
+				delete(seenNonce, k)
+			}
+		}
+		lastPrune = now.Unix()
+	}
+	key := serverID + ":" + nonce
+	if _, seen := seenNonce[key]; seen {
+		return errNonceReplayed
+	}
+	if len(seenNonce) >= maxNonces {
+		return errTooManyNonces
+	}
+	// Kept until the timestamp could no longer pass the skew check
+	seenNonce[key] = now.Add(2 * signatureMaxSkew).Unix()
+	return nil
+}
+
+// AgentSignature verifies signed agent requests. Unsigned requests continue
+// to authenticate with their API secret.
+func AgentSignature(c *fiber.Ctx) error {
+	signature := c.Get("X-Agent-Signature")
+	if signature == "" {
+		return c.Next()
+	}
+	serverID := c.Get("X-Agent-ID")
+	timestamp := c.Get("X-Agent-Timestamp")
+	nonce := c.Get("X-Agent-Nonce")
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	now := time.Now()
+	if err != nil || serverID == "" || len(nonce) < 16 || len(nonce) > 64 {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid request signature"})
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > signatureMaxSkew || skew < -signatureMaxSkew {
+		return c.Status(401).JSON(fiber.Map{"error": "Request timestamp out of range (check the agent clock)"})
+	}
+
+	var sealed string
+	database.DB.QueryRow("SELECT COALESCE(signing_key, '') FROM servers WHERE id = ?", serverID).Scan(&sealed)
+	if sealed == "" {
+		return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
+	}
+	key, err := secrets.Unseal(sealed)
+	if err != nil {
+		log.Printf("⚠️  Cannot verify signed request of %s: %v", serverID, err)
+		return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
+	}
+
+	expected := requestSignature(key, c.Method(), c.OriginalURL(), timestamp, nonce, c.Body())
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid request signature"})
+	}
+	switch err := useNonce(serverID, nonce, now); err {
+	case errNonceReplayed:
+		log.Printf("❌ Replayed request from %s (%s %s)", serverID, c.Method(), c.Path())
+		return c.Status(401).JSON(fiber.Map{"error": "Request replayed"})
+	case errTooManyNonces:
+		log.Printf("⚠️  Nonce cache full, turning away signed request from %s", serverID)
+		return c.Status(503).JSON(fiber.Map{"error": "Too many signed requests, retry later"})
+	}
+
+	c.Locals(agentSignedLocal, serverID)
+	return c.Next()
+}
+
+// signedBy reports whether the request carried a valid signature of serverID
+func signedBy(c *fiber.Ctx, serverID string) bool {
+	signer, _ := c.Locals(agentSignedLocal).(string)
+	return signer != "" && signer == serverID
+}
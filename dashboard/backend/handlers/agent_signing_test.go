@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRequestSignature(t *testing.T) {
+	// Same vector as the agent's signing test, so both sides stay in sync
+	got := requestSignature(signingKey("secret"), "POST", "/api/v1/agent/metrics", "1700000000", "0123456789abcdef", []byte(`{"server_id":"srv-1"}`))
+	if want := "e74f742da20106918d637a127ee9bef30aa6e086a6ec2b128bd5a9d3b6d8ff49"; got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+}
+
+func TestUseNonce(t *testing.T) {
+	now := time.Now()
+	if useNonce("srv-n", "nonce-1", now) != nil {
+		t.Fatal("a fresh nonce must be accepted")
+	}
+	if useNonce("srv-n", "nonce-1", now.Add(time.Minute)) != errNonceReplayed {
+		t.Error("a replayed nonce must be rejected")
+	}
+	if useNonce("srv-other", "nonce-1", now) != nil {
+		t.Error("nonces are tracked per server")
+	}
+	// Expired nonces are pruned once their timestamps can no longer pass the skew check
+	if useNonce("srv-n", "nonce-1", now.Add(3*signatureMaxSkew)) != nil {
+		t.Error("expired nonce must have been pruned")
+	}
+}
+
+func TestUseNonceCap(t *testing.T) {
+	defer func(max int) { maxNonces = max }(maxNonces)
+	nonceMu.Lock()
+	seenNonce = make(map[string]int64)
+	nonceMu.Unlock()
+	maxNonces = 2
+
+	now := time.Now()
+	useNonce("srv-c", "nonce-1", now)
+	useNonce("srv-c", "nonce-2", now)
+	if err := useNonce("srv-c", "nonce-3", now); err != errTooManyNonces {
+		t.Fatalf("nonce past the cap: %v, want errTooManyNonces", err)
+	}
+	if err := useNonce("srv-c", "nonce-1", now); err != errNonceReplayed {
+		t.Errorf("replay at the cap: %v, want errNonceReplayed", err)
+	}
+	// Room again once the kept nonces expired
+	if err := useNonce("srv-c", "nonce-3", now.Add(3*signatureMaxSkew)); err != nil {
+		t.Errorf("nonce after expiry: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/maintenance"
+)
+
+// seedMetricsArchive enables metrics archiving and writes the archive of the
+// month holding timestamp with the given raw samples (server ID, timestamp,
+// CPU, process list) and their hourly buckets
+func seedMetricsArchive(t *testing.T, timestamp int64, samples ...[4]interface{}) {
+	t.Helper()
+	t.Setenv("METRICS_ARCHIVE_DIR", filepath.Join(t.TempDir(), "archive"))
+	mustExec(t, `INSERT OR REPLACE INTO settings (key, value, updated_at) VALUES ('metrics_archive_enabled', 'true', 0)`)
+	database.InvalidateSettings()
+
+	if err := os.MkdirAll(maintenance.MetricsArchiveDir(), 0750); err != nil {
+		t.Fatal(err)
+	}
+	month := time.Unix(timestamp, 0).UTC().Format("2006-01")
+	db, err := sql.Open("sqlite3", filepath.Join(maintenance.MetricsArchiveDir(), "metrics-"+month+".db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`CREATE TABLE metrics (id INTEGER PRIMARY KEY, server_id TEXT NOT NULL, timestamp INTEGER NOT NULL,
+			cpu_percent REAL, mem_total_mb INTEGER, mem_used_mb INTEGER, disk_total_gb INTEGER, disk_used_gb INTEGER,
+			load_avg_1 REAL, load_avg_5 REAL, load_avg_15 REAL, process_count INTEGER, processes TEXT, uptime INTEGER)`,
+		`CREATE TABLE metrics_1h (server_id TEXT NOT NULL, bucket INTEGER NOT NULL, samples INTEGER NOT NULL,
+			cpu_percent REAL, PRIMARY KEY (server_id, bucket))`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, sm := range samples {
+		ts := sm[1].(int64)
+		if _, err := db.Exec(`INSERT INTO metrics (server_id, timestamp, cpu_percent, processes) VALUES (?, ?, ?, ?)`, sm[0], ts, sm[2], sm[3]); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`INSERT OR IGNORE INTO metrics_1h (server_id, bucket, samples, cpu_percent) VALUES (?, ?, 1, ?)`, sm[0], ts-ts%3600, sm[2]); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// archiveRows counts the rows of an archive table matching where
+func archiveRows(t *testing.T, timestamp int64, table, where string) int {
+	t.Helper()
+	n := 0
+	if err := maintenance.EachMetricsArchive(timestamp, timestamp+1, func(db *sql.DB) error {
+		return db.QueryRow("SELECT COUNT(*) FROM " + table + " WHERE " + where).Scan(&n)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/license"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/notifications"
+)
+
+// agentBinaryPrefix starts every published binary name (nodeguarder-agent-<os>-<arch>)
+const agentBinaryPrefix = "nodeguarder-agent-"
+
+// manifestArch is the manifest key of a published binary: its arch for Linux
+// ("amd64"), OS and arch elsewhere ("darwin-arm64"), as agents report it
+func manifestArch(name string) (string, bool) {
+	platform := strings.TrimPrefix(name, agentBinaryPrefix)
+	if platform == name || strings.HasSuffix(name, agentSignatureSuffix) {
+		return "", false
+	}
+	return strings.TrimPrefix(platform, "linux-"), true
+}
+
+// agentSignatureSuffix names the detached signature published next to a binary
+const agentSignatureSuffix = ".sig"
+
+// agentBinaryDir is where the published agent binaries live (AGENT_BINARY_PATH)
+func agentBinaryDir() string {
+	if dir := os.Getenv("AGENT_BINARY_PATH"); dir != "" {
+		return dir
+	}
+	return "./agent-binaries"
+}
+
+// publishedAgentVersion is the agent version offered for updates
+func publishedAgentVersion() string {
+	// Version is injected at build time into the container env
+	if version := os.Getenv("AGENT_VERSION"); version != "" {
+		return version
+	}
+	return "1.0.1" // Fallback
+}
+
+// manifestCache holds binary hashes keyed by arch, with the size and mtime they were computed for
+var manifestCache = struct {
+	sync.Mutex
+	entries map[string]manifestEntry
+}{entries: map[string]manifestEntry{}}
+
+type manifestEntry struct {
+	size    int64
+	modTime time.Time
+	sha256  string
+}
+
+// agentManifest returns the SHA256 of each published binary by arch. A SHA256SUMS
+// file (sha256sum format) in the binary directory takes precedence; otherwise the
+// binaries are hashed, and re-hashed only when they change.
+func agentManifest() map[string]string {
+	dir := agentBinaryDir()
+	manifest := map[string]string{}
+
+	if f, err := os.Open(filepath.Join(dir, "SHA256SUMS")); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) != 2 {
+				continue
+			}
+			name := strings.TrimPrefix(filepath.Base(fields[1]), "*")
+			if arch, ok := manifestArch(name); ok {
+				manifest[arch] = strings.ToLower(fields[0])
+			}
+		}
+		return manifest
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(dir, agentBinaryPrefix+"*"))
+	manifestCache.Lock()
+	defer manifestCache.Unlock()
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() || strings.HasSuffix(path, agentSignatureSuffix) {
+			continue
+		}
+		arch, _ := manifestArch(filepath.Base(path))
+		entry, ok := manifestCache.entries[arch]
+		if !ok || entry.size != info.Size() || !entry.modTime.Equal(info.ModTime()) {
+			sum, err := fileSHA256(path)
+			if err != nil {
+				log.Printf("Failed to hash agent binary %s: %v", path, err)
+				continue
+			}
+			entry = manifestEntry{size: info.Size(), modTime: info.ModTime(), sha256: sum}
+			manifestCache.entries[arch] = entry
+		}
+		manifest[arch] = entry.sha256
+	}
+	return manifest
+}
+
+// agentBinarySignature returns the detached signature of a published binary.
+// Release images ship it as <binary>.sig, made with the license signing key;
+// developer setups holding that key sign missing or stale binaries on demand.
+func agentBinarySignature(path string) (string, error) {
+	sigPath := path + agentSignatureSuffix
+	data, err := os.ReadFile(sigPath)
+	if err == nil {
+		signature := strings.TrimSpace(string(data))
+		pub, keyErr := license.LoadPublicKey(license.PublicKeyPath())
+		if keyErr != nil {
+			return signature, nil // agents verify it against their embedded key
+		}
+		if err = license.VerifyFile(pub, path, signature); err == nil {
+			return signature, nil
+		}
+	}
+
+	priv, keyErr := license.LoadPrivateKey(license.PrivateKeyPath())
+	if keyErr != nil {
+		return "", err
+	}
+	signature, err := license.SignFile(priv, path)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(sigPath, []byte(signature+"\n"), 0644); err != nil {
+		log.Printf("⚠️  Could not store signature %s: %v", sigPath, err)
+	}
+	log.Printf("🔏 Signed agent binary %s", filepath.Base(path))
+	return signature, nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// GetAgentManifest returns the published agent version and the SHA256 of each binary
+func GetAgentManifest(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"version":  publishedAgentVersion(),
+		"binaries": agentManifest(),
+	})
+}
+
+// attestAgent compares the binary an agent reported at registration with the
+// manifest and stores the result. Status changes are recorded as security
+// events; a binary that claims the published version but has another hash is
+// critical, an older version (stale binary) a warning.
+func attestAgent(serverID, hostname, version, arch, binarySum, configSum string) {
+	att := models.AgentAttestation{
+		Arch:         arch,
+		BinarySHA256: strings.ToLower(binarySum),
+		ConfigSHA256: strings.ToLower(configSum),
+		CheckedAt:    time.Now().Unix(),
+	}
+	expected, published := agentManifest()[arch]
+	switch {
+	case binarySum == "":
+		att.Status = "unreported" // agents before attestation
+	case !published:
+		att.Status = "unknown" // no published binary for this arch
+	case att.BinarySHA256 == expected:
+		att.Status = "verified"
+	case version != publishedAgentVersion():
+		att.Status = "outdated"
+	default:
+		att.Status = "mismatch"
+	}
+	if published && att.Status != "verified" {
+		att.ExpectedSHA256 = expected
+	}
+
+	var previous models.AgentAttestation
+	var raw string
+	if database.DB.QueryRow("SELECT COALESCE(attestation, '') FROM servers WHERE id = ?", serverID).Scan(&raw) == nil && raw != "" {
+		json.Unmarshal([]byte(raw), &previous)
+	}
+	stored, _ := json.Marshal(att)
+	if _, err := database.DB.Exec("UPDATE servers SET attestation = ? WHERE id = ?", string(stored), serverID); err != nil {
+		log.Printf("Failed to store attestation for %s: %v", serverID, err)
+		return
+	}
+
+	if previous.ConfigSHA256 != "" && att.ConfigSHA256 != "" && previous.ConfigSHA256 != att.ConfigSHA256 {
+		recordAttestationEvent(serverID, "info",
+			fmt.Sprintf("Agent configuration changed (config sha256 %.12s -> %.12s)", previous.ConfigSHA256, att.ConfigSHA256), att)
+	}
+	if att.Status == previous.Status && att.BinarySHA256 == previous.BinarySHA256 {
+		return
+	}
+
+	var severity, msg string
+	var alertType notifications.NotificationType
+	switch att.Status {
+	case "mismatch":
+		severity, alertType = "critical", notifications.TypeCritical
+		msg = fmt.Sprintf("Agent binary does not match the published v%s build for %s (sha256 %.12s, expected %.12s) - possible tampering", version, arch, att.BinarySHA256, expected)
+	case "outdated":
+		severity, alertType = "warning", notifications.TypeWarning
+		msg = fmt.Sprintf("Agent runs v%s, not the published v%s; its binary cannot be verified", version, publishedAgentVersion())
+	case "verified":
+		if previous.Status != "mismatch" && previous.Status != "outdated" {
+			return
+		}
+		severity = "info"
+		msg = fmt.Sprintf("Agent binary verified against the published v%s build for %s", version, arch)
+	default:
+		return
+	}
+	recordAttestationEvent(serverID, severity, msg, att)
+	log.Printf("🔏 %s (%s): %s", hostname, serverID, msg)
+
+	if alertType != "" && Notifier != nil {
+		go Notifier.Notify(notifications.Notification{
+			Subject:   fmt.Sprintf("[%s] Agent Attestation: %s", strings.ToUpper(severity), hostname),
+			Message:   fmt.Sprintf("Server %s (%s): %s", hostname, serverID, msg),
+			Type:      alertType,
+			ServerID:  serverID,
+			EventType: "security",
+		})
+	}
+}
+
+func recordAttestationEvent(serverID, severity, msg string, att models.AgentAttestation) {
+	details, _ := json.Marshal(att)
+	if _, err := database.DB.Exec(`
+		INSERT INTO events (server_id, timestamp, event_type, severity, message, details)
+		VALUES (?, ?, 'security', ?, ?, ?)
+	`, serverID, att.CheckedAt, severity, msg, string(details)); err != nil {
+		log.Printf("Failed to insert attestation event: %v", err)
+	}
+}
+
+// parseAttestation decodes the stored attestation (nil until the agent registered)
+func parseAttestation(raw string) *models.AgentAttestation {
+	if raw == "" {
+		return nil
+	}
+	var att models.AgentAttestation
+	if err := json.Unmarshal([]byte(raw), &att); err != nil {
+		return nil
+	}
+	return &att
+}
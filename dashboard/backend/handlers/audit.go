@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// recordAudit writes a privileged action to the audit log
+func recordAudit(c *fiber.Ctx, action, target, details string) {
+	username, _ := c.Locals("username").(string)
+	_, err := database.DB.Exec(`
+		INSERT INTO audit_log (timestamp, username, action, target, details, ip_address)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, time.Now().Unix(), username, action, target, details, c.IP())
+	if err != nil {
+		log.Printf("❌ Failed to write audit log (%s by %s): %v", action, username, err)
+	}
+}
+
+// GetAuditLog returns the most recent audit entries
+func GetAuditLog(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 200)
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id, timestamp, username, action, COALESCE(target, ''), COALESCE(details, ''), COALESCE(ip_address, '')
+		FROM audit_log
+		ORDER BY timestamp DESC, id DESC
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var e models.AuditEntry
+		if err := rows.Scan(&e.ID, &e.Timestamp, &e.Username, &e.Action, &e.Target, &e.Details, &e.IPAddress); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	return c.JSON(entries)
+}
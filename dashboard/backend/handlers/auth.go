@@ -38,6 +38,7 @@ func InitJWTSecret() error {
 			"INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)", 
 			"jwt_secret", secretHex, time.Now().Unix(),
 		)
+		database.InvalidateSettings()
 		if err != nil {
 			return fmt.Errorf("failed to save JWT secret: %v", err)
 		}
@@ -85,6 +86,7 @@ func InitRegistrationToken() error {
 			"INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)", 
 			"registration_token", token, time.Now().Unix(),
 		)
+		database.InvalidateSettings()
 		if err != nil {
 			return fmt.Errorf("failed to save registration token: %v", err)
 		}
@@ -136,7 +138,7 @@ func EnsureAdminUser() error {
 	if err == sql.ErrNoRows {
 		// Create new admin
 		_, err = database.DB.Exec(
-			"INSERT INTO users (username, password_hash, created_at, password_changed) VALUES (?, ?, ?, 0)",
+			"INSERT INTO users (username, password_hash, created_at, password_changed, role) VALUES (?, ?, ?, 0, 'admin')",
 			"admin", string(hash), time.Now().Unix(),
 		)
 		if err != nil {
@@ -175,10 +177,10 @@ func Login(c *fiber.Ctx) error {
 	// Get user from database
 	var user models.User
 	err := database.DB.QueryRow(`
-		SELECT id, username, password_hash, created_at, COALESCE(password_changed, 0)
+		SELECT id, username, password_hash, created_at, COALESCE(password_changed, 0), COALESCE(role, 'admin')
 		FROM users 
 		WHERE username = ?
-	`, req.Username).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt, &user.PasswordChanged)
+	`, req.Username).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.CreatedAt, &user.PasswordChanged, &user.Role)
 
 	if err == sql.ErrNoRows {
 		log.Printf("❌ User not found: %s", req.Username)
@@ -204,6 +206,7 @@ func Login(c *fiber.Ctx) error {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"user_id":  user.ID,
 		"username": user.Username,
+		"role":     user.Role,
 		"exp":      time.Now().Add(24 * time.Hour).Unix(),
 	})
 
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Air-gapped bundles enroll hosts that cannot download anything at install
+// time: one tarball holds the agent binary (and its signature), the config
+// skeleton, the systemd unit and an offline install script. Like the native
+// packages a bundle is shared by many hosts, so install.sh gives each host its
+// own server_id and api_secret; the agent only needs to reach the dashboard.
+
+// bundleInstallScript installs the bundle it is unpacked from
+const bundleInstallScript = `#!/bin/sh
+# NodeGuarder agent offline installer: sudo ./install.sh
+set -e
+cd "$(dirname "$0")"
+
+if [ "$(id -u)" -ne 0 ]; then
+    echo "❌ This script must be run as root (use: sudo ./install.sh)"
+    exit 1
+fi
+
+INSTALL_DIR=/opt/nodeguarder-agent
+CONFIG_DIR=/etc/nodeguarder-agent
+CONFIG_FILE=$CONFIG_DIR/config.yaml
+
+mkdir -p "$INSTALL_DIR" "$CONFIG_DIR"
+# Replace the binary of a running agent without "text file busy"
+cp nodeguarder-agent "$INSTALL_DIR/nodeguarder-agent.new"
+chmod 755 "$INSTALL_DIR/nodeguarder-agent.new"
+mv "$INSTALL_DIR/nodeguarder-agent.new" "$INSTALL_DIR/nodeguarder-agent"
+install -m 600 config.yaml.dist "$CONFIG_DIR/config.yaml.dist"
+
+# First install: give this host its own identity, it registers on start
+if [ ! -f "$CONFIG_FILE" ]; then
+    SERVER_ID=$(cat /proc/sys/kernel/random/uuid)
+    API_SECRET=$(od -An -N32 -tx1 /dev/urandom | tr -d ' \n')
+    (
+        umask 077
+        {
+            echo "server_id: $SERVER_ID"
+            echo "api_secret: $API_SECRET"
+            cat "$CONFIG_DIR/config.yaml.dist"
+        } > "$CONFIG_FILE"
+    )
+    echo "✓ Created $CONFIG_FILE (server ID $SERVER_ID)"
+else
+    echo "✓ Keeping existing $CONFIG_FILE"
+fi
+
+if ! command -v systemctl >/dev/null 2>&1; then
+    echo "⚠️  systemd not found, start the agent with: $INSTALL_DIR/nodeguarder-agent --config $CONFIG_FILE"
+    exit 0
+fi
+install -m 644 nodeguarder-agent.service /etc/systemd/system/nodeguarder-agent.service
+systemctl daemon-reload
+systemctl enable nodeguarder-agent.service
+systemctl restart nodeguarder-agent.service
+echo "✅ NodeGuarder agent installed. Logs: journalctl -u nodeguarder-agent -f"
+`
+
+// buildAirGappedBundle writes the bundle as a gzipped tarball with a single
+// top-level directory
+func buildAirGappedBundle(dir string, agentBinary []byte, signature string, skeleton []byte) ([]byte, error) {
+	files := []nativeFile{
+		{Path: dir, Mode: 0755, Dir: true},
+		{Path: dir + "/install.sh", Mode: 0755, Body: []byte(bundleInstallScript)},
+		{Path: dir + "/nodeguarder-agent", Mode: 0755, Body: agentBinary},
+		{Path: dir + "/config.yaml.dist", Mode: 0600, Body: skeleton},
+		{Path: dir + "/nodeguarder-agent.service", Mode: 0644, Body: []byte(nativeUnitFile)},
+	}
+	if signature != "" {
+		files = append(files, nativeFile{Path: dir + "/nodeguarder-agent" + agentSignatureSuffix, Mode: 0644, Body: []byte(signature + "\n")})
+	}
+	return tarGz(time.Now(), files)
+}
+
+// sendAirGappedBundle serves the bundle for the architecture in ?arch=
+// (default amd64) around the published agent binary
+func sendAirGappedBundle(c *fiber.Ctx, dashboardURL, packageID string, insecure bool) error {
+	binaryPath, filename, err := agentBinaryPath("linux", c.Query("arch", "amd64"), "")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	agentBinary, err := os.ReadFile(binaryPath)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Agent binary not found for this architecture"})
+	}
+	// Unsigned binaries still install, they just cannot be verified offline
+	signature, err := agentBinarySignature(binaryPath)
+	if err != nil {
+		log.Printf("⚠️  Bundling unsigned agent binary %s: %v", binaryPath, err)
+	}
+
+	dir := fmt.Sprintf("%s-%s", filename, strings.TrimPrefix(publishedAgentVersion(), "v"))
+	skeleton := nativeConfigSkeleton(dashboardURL, RegistrationToken, packageID, insecure)
+	bundle, err := buildAirGappedBundle(dir, agentBinary, signature, skeleton)
+	if err != nil {
+		log.Printf("Failed to build air-gapped bundle: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to build package"})
+	}
+
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.tar.gz"`, dir))
+	c.Set("Content-Type", "application/gzip")
+	return c.Send(bundle)
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildAirGappedBundle(t *testing.T) {
+	skeleton := nativeConfigSkeleton("https://dash.example", "reg-token", "pkg-1", false)
+	bundle, err := buildAirGappedBundle("nodeguarder-agent-linux-amd64-1.2.0", []byte("agent binary"), "c2lnbmF0dXJl", skeleton)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := readTarGz(t, bundle)
+	dir := "./nodeguarder-agent-linux-amd64-1.2.0/"
+	if files[dir+"nodeguarder-agent"] != "agent binary" {
+		t.Error("agent binary missing")
+	}
+	if files[dir+"nodeguarder-agent.sig"] != "c2lnbmF0dXJl\n" {
+		t.Errorf("signature = %q", files[dir+"nodeguarder-agent.sig"])
+	}
+	if !strings.Contains(files[dir+"config.yaml.dist"], "package_id: pkg-1\n") {
+		t.Errorf("skeleton = %q", files[dir+"config.yaml.dist"])
+	}
+	if files[dir+"nodeguarder-agent.service"] != nativeUnitFile {
+		t.Error("systemd unit missing")
+	}
+	if files[dir+"install.sh"] != bundleInstallScript {
+		t.Error("install script missing")
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+)
+
+// Capacity recommendation thresholds (percent). Downsizing is judged on peaks
+// so a host that is idle on average but busy at month end is not flagged.
+const (
+	underutilizedPeakCPU = 20
+	underutilizedPeakMem = 40
+	saturatedAvgCPU      = 75
+	saturatedAvgMem      = 85
+	saturatedPeakDisk    = 90
+)
+
+// capacityRow is one server's utilization over the report period
+type capacityRow struct {
+	ServerID       string  `json:"server_id"`
+	Hostname       string  `json:"hostname"`
+	Samples        int     `json:"samples"`
+	AvgCPU         float64 `json:"avg_cpu_percent"`
+	PeakCPU        float64 `json:"peak_cpu_percent"`
+	AvgMem         float64 `json:"avg_mem_percent"`
+	PeakMem        float64 `json:"peak_mem_percent"`
+	AvgDisk        float64 `json:"avg_disk_percent"`
+	PeakDisk       float64 `json:"peak_disk_percent"`
+	AvgLoad        float64 `json:"avg_load_1"`
+	PeakLoad       float64 `json:"peak_load_1"`
+	MemTotalMB     int64   `json:"mem_total_mb"`
+	DiskTotalGB    int64   `json:"disk_total_gb"`
+	Recommendation string  `json:"recommendation"` // "downsize", "upgrade" or "" (right-sized)
+	Reason         string  `json:"reason,omitempty"`
+}
+
+// recommend flags saturated hosts (upgrade) before under-utilized ones (downsize)
+func (r *capacityRow) recommend() {
+	var reasons []string
+	if r.AvgCPU >= saturatedAvgCPU {
+		reasons = append(reasons, fmt.Sprintf("average CPU %.0f%%", r.AvgCPU))
+	}
+	if r.AvgMem >= saturatedAvgMem {
+		reasons = append(reasons, fmt.Sprintf("average memory %.0f%%", r.AvgMem))
+	}
+	if r.PeakDisk >= saturatedPeakDisk {
+		reasons = append(reasons, fmt.Sprintf("peak disk %.0f%%", r.PeakDisk))
+	}
+	if len(reasons) > 0 {
+		r.Recommendation = "upgrade"
+		r.Reason = strings.Join(reasons, ", ")
+		return
+	}
+	if r.PeakCPU < underutilizedPeakCPU && r.PeakMem < underutilizedPeakMem {
+		r.Recommendation = "downsize"
+		r.Reason = fmt.Sprintf("peak CPU %.0f%%, peak memory %.0f%%", r.PeakCPU, r.PeakMem)
+	}
+}
+
+// GetCapacityReport aggregates average and peak utilization per server.
+// Query: days (default 30, max 365), format=csv for a spreadsheet export
+func GetCapacityReport(c *fiber.Ctx) error {
+	days := c.QueryInt("days", 30)
+	if days < 1 || days > 365 {
+		return c.Status(400).JSON(fiber.Map{"error": "Days must be between 1 and 365"})
+	}
+	since := time.Now().AddDate(0, 0, -days).Unix()
+
+	visible, visibleArgs := middleware.ServerVisibilityClause(c, "s.id")
+	args := append([]interface{}{since}, visibleArgs...)
+	rows, err := database.DB.Query(`
+		SELECT s.id, s.hostname, COUNT(*),
+			AVG(m.cpu_percent), MAX(m.cpu_percent),
+			AVG(CASE WHEN m.mem_total_mb > 0 THEN 100.0 * m.mem_used_mb / m.mem_total_mb END),
+			MAX(CASE WHEN m.mem_total_mb > 0 THEN 100.0 * m.mem_used_mb / m.mem_total_mb END),
+			AVG(CASE WHEN m.disk_total_gb > 0 THEN 100.0 * m.disk_used_gb / m.disk_total_gb END),
+			MAX(CASE WHEN m.disk_total_gb > 0 THEN 100.0 * m.disk_used_gb / m.disk_total_gb END),
+			AVG(m.load_avg_1), MAX(m.load_avg_1),
+			MAX(COALESCE(m.mem_total_mb, 0)), MAX(COALESCE(m.disk_total_gb, 0))
+		FROM servers s
+		JOIN metrics m ON m.server_id = s.id
+		WHERE m.timestamp >= ? AND `+visible+`
+		GROUP BY s.id
+		ORDER BY s.hostname
+	`, args...)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	report := []capacityRow{}
+	for rows.Next() {
+		var r capacityRow
+		var stats [8]*float64
+		if err := rows.Scan(&r.ServerID, &r.Hostname, &r.Samples,
+			&stats[0], &stats[1], &stats[2], &stats[3], &stats[4], &stats[5], &stats[6], &stats[7],
+			&r.MemTotalMB, &r.DiskTotalGB); err != nil {
+			continue
+		}
+		for i, dst := range []*float64{&r.AvgCPU, &r.PeakCPU, &r.AvgMem, &r.PeakMem, &r.AvgDisk, &r.PeakDisk, &r.AvgLoad, &r.PeakLoad} {
+			if stats[i] != nil {
+				*dst = math.Round(*stats[i]*10) / 10
+			}
+		}
+		r.recommend()
+		report = append(report, r)
+	}
+
+	if c.Query("format") == "csv" {
+		return sendCapacityCSV(c, report, days)
+	}
+	return c.JSON(fiber.Map{
+		"days":    days,
+		"since":   since,
+		"servers": report,
+	})
+}
+
+func sendCapacityCSV(c *fiber.Ctx, report []capacityRow, days int) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{
+		"server_id", "hostname", "samples",
+		"avg_cpu_percent", "peak_cpu_percent", "avg_mem_percent", "peak_mem_percent",
+		"avg_disk_percent", "peak_disk_percent", "avg_load_1", "peak_load_1",
+		"mem_total_mb", "disk_total_gb", "recommendation", "reason",
+	})
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) }
+	for _, r := range report {
+		w.Write([]string{
+			r.ServerID, r.Hostname, strconv.Itoa(r.Samples),
+			f(r.AvgCPU), f(r.PeakCPU), f(r.AvgMem), f(r.PeakMem),
+			f(r.AvgDisk), f(r.PeakDisk), f(r.AvgLoad), f(r.PeakLoad),
+			strconv.FormatInt(r.MemTotalMB, 10), strconv.FormatInt(r.DiskTotalGB, 10), r.Recommendation, r.Reason,
+		})
+	}
+	w.Flush()
+
+	c.Set("Content-Type", "text/csv")
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="capacity-report-%dd-%s.csv"`, days, time.Now().Format("2006-01-02")))
+	return c.Send(buf.Bytes())
+}
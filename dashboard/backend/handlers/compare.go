@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// metricSummary holds averaged metrics over a comparison window
+type metricSummary struct {
+	Samples      int     `json:"samples"`
+	CPUPercent   float64 `json:"cpu_percent"`
+	MemPercent   float64 `json:"mem_percent"`
+	DiskPercent  float64 `json:"disk_percent"`
+	LoadAvg1     float64 `json:"load_avg_1"`
+	ProcessCount float64 `json:"process_count"`
+}
+
+// CompareServerPeriods diffs the window before an incident time against the window after it.
+// Query: at (unix seconds, default now), window (seconds, default 3600)
+func CompareServerPeriods(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+
+	var exists int
+	if err := database.DB.QueryRow("SELECT 1 FROM servers WHERE id = ?", serverID).Scan(&exists); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
+
+	at := time.Now().Unix()
+	if v := c.Query("at"); v != "" {
+		parsed, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid 'at' timestamp"})
+		}
+		at = parsed
+	}
+	window := int64(c.QueryInt("window", 3600))
+	if window < 60 || window > 7*24*3600 {
+		return c.Status(400).JSON(fiber.Map{"error": "Window must be between 60 seconds and 7 days"})
+	}
+
+	beforeStart, duringEnd := at-window, at+window
+
+	before, beforeProcs := summarizeMetrics(serverID, beforeStart, at)
+	during, duringProcs := summarizeMetrics(serverID, at, duringEnd)
+
+	// Processes seen during the incident that were not running before
+	newProcesses := []string{}
+	for name := range duringProcs {
+		if !beforeProcs[name] {
+			newProcesses = append(newProcesses, name)
+		}
+	}
+	sort.Strings(newProcesses)
+
+	// Events whose type+message did not occur in the healthy window
+	seen := make(map[string]bool)
+	for _, e := range eventsBetween(serverID, beforeStart, at) {
+		seen[e.EventType+"|"+e.Message] = true
+	}
+	newEvents := []models.Event{}
+	driftChanges := []models.Event{}
+	for _, e := range eventsBetween(serverID, at, duringEnd) {
+		if e.EventType == "drift" || e.EventType == "package" {
+			driftChanges = append(driftChanges, e)
+			continue
+		}
+		if !seen[e.EventType+"|"+e.Message] {
+			newEvents = append(newEvents, e)
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"server_id": serverID,
+		"at":        at,
+		"window":    window,
+		"metrics": fiber.Map{
+			"before": before,
+			"during": during,
+			"delta": metricSummary{
+				CPUPercent:   during.CPUPercent - before.CPUPercent,
+				MemPercent:   during.MemPercent - before.MemPercent,
+				DiskPercent:  during.DiskPercent - before.DiskPercent,
+				LoadAvg1:     during.LoadAvg1 - before.LoadAvg1,
+				ProcessCount: during.ProcessCount - before.ProcessCount,
+			},
+		},
+		"new_events":     newEvents,
+		"drift_changes":  driftChanges,
+		"new_processes":  newProcesses,
+		"config_changes": configChangesBetween(serverID, beforeStart, duringEnd),
+	})
+}
+
+// summarizeMetrics averages metrics in [from, to) and collects the process names seen
+func summarizeMetrics(serverID string, from, to int64) (metricSummary, map[string]bool) {
+	var s metricSummary
+	procs := make(map[string]bool)
+
+	rows, err := database.DB.Query(`
+		SELECT COALESCE(cpu_percent, 0), COALESCE(mem_total_mb, 0), COALESCE(mem_used_mb, 0),
+			COALESCE(disk_total_gb, 0), COALESCE(disk_used_gb, 0), COALESCE(load_avg_1, 0),
+			COALESCE(process_count, 0), COALESCE(processes, '')
+		FROM metrics
+		WHERE server_id = ? AND timestamp >= ? AND timestamp < ?
+	`, serverID, from, to)
+	if err != nil {
+		return s, procs
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cpu, load1 float64
+		var memTotal, memUsed, diskTotal, diskUsed, procCount int64
+		var processesJSON string
+		if err := rows.Scan(&cpu, &memTotal, &memUsed, &diskTotal, &diskUsed, &load1, &procCount, &processesJSON); err != nil {
+			continue
+		}
+		s.Samples++
+		s.CPUPercent += cpu
+		if memTotal > 0 {
+			s.MemPercent += float64(memUsed) / float64(memTotal) * 100
+		}
+		if diskTotal > 0 {
+			s.DiskPercent += float64(diskUsed) / float64(diskTotal) * 100
+		}
+		s.LoadAvg1 += load1
+		s.ProcessCount += float64(procCount)
+
+		var list []struct {
+			Name string `json:"name"`
+		}
+		if processesJSON != "" && json.Unmarshal([]byte(processesJSON), &list) == nil {
+			for _, p := range list {
+				procs[p.Name] = true
+			}
+		}
+	}
+
+	if s.Samples > 0 {
+		n := float64(s.Samples)
+		s.CPUPercent /= n
+		s.MemPercent /= n
+		s.DiskPercent /= n
+		s.LoadAvg1 /= n
+		s.ProcessCount /= n
+	}
+	return s, procs
+}
+
+// eventsBetween returns a server's events in [from, to), oldest first
+func eventsBetween(serverID string, from, to int64) []models.Event {
+	rows, err := database.DB.Query(`
+		SELECT id, server_id, timestamp, event_type, severity, message, details
+		FROM events
+		WHERE server_id = ? AND timestamp >= ? AND timestamp < ?
+		ORDER BY timestamp ASC
+		LIMIT 1000
+	`, serverID, from, to)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var events []models.Event
+	for rows.Next() {
+		var e models.Event
+		if err := rows.Scan(&e.ID, &e.ServerID, &e.Timestamp, &e.EventType, &e.Severity, &e.Message, &e.Details); err == nil {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// configChangesBetween lists global settings updates and audited actions on the server in [from, to)
+func configChangesBetween(serverID string, from, to int64) []fiber.Map {
+	changes := []fiber.Map{}
+
+	rows, err := database.DB.Query(`
+		SELECT key, updated_at FROM settings WHERE updated_at >= ? AND updated_at < ? ORDER BY updated_at
+	`, from, to)
+	if err == nil {
+		for rows.Next() {
+			var key string
+			var ts int64
+			if rows.Scan(&key, &ts) == nil {
+				changes = append(changes, fiber.Map{"source": "settings", "timestamp": ts, "key": key})
+			}
+		}
+		rows.Close()
+	}
+
+	rows, err = database.DB.Query(`
+		SELECT timestamp, username, action, COALESCE(details, '') FROM audit_log
+		WHERE target = ? AND timestamp >= ? AND timestamp < ? ORDER BY timestamp
+	`, serverID, from, to)
+	if err == nil {
+		for rows.Next() {
+			var ts int64
+			var username, action, details string
+			if rows.Scan(&ts, &username, &action, &details) == nil {
+				changes = append(changes, fiber.Map{"source": "audit", "timestamp": ts, "user": username, "action": action, "details": details})
+			}
+		}
+		rows.Close()
+	}
+
+	return changes
+}
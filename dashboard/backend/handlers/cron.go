@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/push"
+)
+
+// GetCronPauses lists paused (or pending) cron jobs for a server
+func GetCronPauses(c *fiber.Ctx) error {
+	rows, err := database.DB.Query(`
+		SELECT server_id, command, paused, COALESCE(status, 'pending'), COALESCE(error, ''), COALESCE(files, '[]'),
+		       requested_by, requested_at, COALESCE(applied_at, 0)
+		FROM cron_pauses
+		WHERE server_id = ?
+		ORDER BY command
+	`, c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	pauses := []models.CronPause{}
+	for rows.Next() {
+		var p models.CronPause
+		var filesJSON string
+		if err := rows.Scan(&p.ServerID, &p.Command, &p.Paused, &p.Status, &p.Error, &filesJSON,
+			&p.RequestedBy, &p.RequestedAt, &p.AppliedAt); err != nil {
+			continue
+		}
+		json.Unmarshal([]byte(filesJSON), &p.Files)
+		pauses = append(pauses, p)
+	}
+
+	return c.JSON(pauses)
+}
+
+// PauseCronJob requests the agent to comment out a cron job
+func PauseCronJob(c *fiber.Ctx) error {
+	return setCronPause(c, true)
+}
+
+// ResumeCronJob requests the agent to restore a paused cron job
+func ResumeCronJob(c *fiber.Ctx) error {
+	return setCronPause(c, false)
+}
+
+func setCronPause(c *fiber.Ctx, paused bool) error {
+	serverID := c.Params("id")
+
+	var req struct {
+		Command string `json:"command"`
+	}
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Command) == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Command is required"})
+	}
+
+	var exists int
+	if err := database.DB.QueryRow("SELECT 1 FROM servers WHERE id = ?", serverID).Scan(&exists); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
+
+	username, _ := c.Locals("username").(string)
+	_, err := database.DB.Exec(`
+		INSERT INTO cron_pauses (server_id, command, paused, status, requested_by, requested_at)
+		VALUES (?, ?, ?, 'pending', ?, ?)
+		ON CONFLICT(server_id, command) DO UPDATE SET
+			paused = excluded.paused, status = 'pending', error = NULL,
+			requested_by = excluded.requested_by, requested_at = excluded.requested_at
+	`, serverID, req.Command, paused, username, time.Now().Unix())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save request"})
+	}
+	push.Notify(serverID)
+
+	action := "cron.resume"
+	if paused {
+		action = "cron.pause"
+	}
+	recordAudit(c, action, serverID, req.Command)
+	return c.JSON(fiber.Map{"status": "pending"})
+}
+
+// pendingCronPauses returns pause/resume requests not yet applied by the agent.
+// They are resent on every poll until reported; applying them is idempotent.
+func pendingCronPauses(serverID string) []models.AgentCronPause {
+	rows, err := database.DB.Query("SELECT command, paused FROM cron_pauses WHERE server_id = ? AND status = 'pending'", serverID)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var pauses []models.AgentCronPause
+	for rows.Next() {
+		var p models.AgentCronPause
+		if err := rows.Scan(&p.Command, &p.Paused); err == nil {
+			pauses = append(pauses, p)
+		}
+	}
+	return pauses
+}
+
+// AgentCronPauseResult records the outcome of a pause/resume on the host
+func AgentCronPauseResult(c *fiber.Ctx) error {
+	var req struct {
+		ServerID  string   `json:"server_id"`
+		APISecret string   `json:"api_secret"`
+		Command   string   `json:"command"`
+		Paused    bool     `json:"paused"`
+		Files     []string `json:"files"`
+		Backups   []string `json:"backups"`
+		Error     string   `json:"error"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if !authenticateAgent(c, req.ServerID, req.APISecret) {
+		return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
+	}
+
+	now := time.Now().Unix()
+	filesJSON, _ := json.Marshal(req.Files)
+
+	// Ignore results for a state that has since been changed again
+	var status string
+	if req.Error != "" {
+		status = "failed"
+	} else {
+		status = "applied"
+	}
+	res, err := database.DB.Exec(`
+		UPDATE cron_pauses SET status = ?, error = ?, files = ?, applied_at = ?
+		WHERE server_id = ? AND command = ? AND paused = ? AND status = 'pending'
+	`, status, req.Error, string(filesJSON), now, req.ServerID, req.Command, req.Paused)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to store result"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.JSON(fiber.Map{"status": "stale"})
+	}
+
+	// A successfully resumed job no longer needs tracking
+	if !req.Paused && status == "applied" {
+		database.DB.Exec("DELETE FROM cron_pauses WHERE server_id = ? AND command = ? AND paused = 0", req.ServerID, req.Command)
+	}
+
+	// Record on the server timeline
+	verb := "resumed"
+	if req.Paused {
+		verb = "paused"
+	}
+	severity := "info"
+	msg := fmt.Sprintf("Cron job %s: %s", verb, req.Command)
+	if req.Error != "" {
+		severity = "warning"
+		msg = fmt.Sprintf("Failed to %s cron job %s: %s", strings.TrimSuffix(verb, "d"), req.Command, req.Error)
+	}
+	details, _ := json.Marshal(fiber.Map{"command": req.Command, "paused": req.Paused, "files": req.Files, "backups": req.Backups, "error": req.Error})
+	if _, err := database.DB.Exec(`
+		INSERT INTO events (server_id, timestamp, event_type, severity, message, details)
+		VALUES (?, ?, 'cron_pause', ?, ?, ?)
+	`, req.ServerID, now, severity, msg, string(details)); err != nil {
+		log.Printf("Failed to insert cron pause event: %v", err)
+	}
+
+	return c.JSON(fiber.Map{"status": "ok"})
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/notifications"
+)
+
+const (
+	defaultCronAnomalyFactor = 3.0
+	cronBaselineRuns         = 20 // rolling window for the median
+	cronBaselineMinRuns      = 5  // no baseline (and no alerts) until a job has run this often
+	cronAnomalyMinSlack      = 60 // seconds; a 2s job taking 6s is not worth a warning
+)
+
+// loadCronAnomalyFactor returns the configured slowdown factor (0 = detection off)
+func loadCronAnomalyFactor() float64 {
+	factor := defaultCronAnomalyFactor
+	var val string
+	if err := database.GetSetting("cron_anomaly_factor", &val); err == nil {
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			factor = f
+		}
+	}
+	return factor
+}
+
+// cronTimeoutFor returns the hard timeout the agent applies to a job (0 = none)
+func cronTimeoutFor(command string) int {
+	timeout := 300
+	var val string
+	if err := database.GetSetting("cron_global_timeout", &val); err == nil {
+		fmt.Sscanf(val, "%d", &timeout)
+	}
+	if err := database.GetSetting("cron_timeouts", &val); err == nil {
+		var overrides map[string]int
+		if json.Unmarshal([]byte(val), &overrides) == nil {
+			keys := make([]string, 0, len(overrides))
+			for k := range overrides {
+				keys = append(keys, k)
+			}
+			if key, ok := matchCronKey(command, keys); ok {
+				timeout = overrides[key]
+			}
+		}
+	}
+	return timeout
+}
+
+// checkCronDuration raises a warning when a successful run takes factor times
+// the median of the job's previous successful runs. Runs past the hard timeout
+// are skipped, since the agent already reported them as long running.
+func checkCronDuration(serverID string, run models.CronRun, factor float64) {
+	if run.ExitCode != 0 || run.Duration <= 0 {
+		return
+	}
+	if timeout := cronTimeoutFor(run.Command); timeout > 0 && run.Duration > int64(timeout) {
+		return
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT duration FROM cron_runs
+		WHERE server_id = ? AND command = ? AND started_at < ? AND exit_code = 0 AND duration > 0
+		ORDER BY started_at DESC
+		LIMIT ?
+	`, serverID, run.Command, run.StartedAt, cronBaselineRuns)
+	if err != nil {
+		return
+	}
+	var durations []int64
+	for rows.Next() {
+		var d int64
+		if rows.Scan(&d) == nil {
+			durations = append(durations, d)
+		}
+	}
+	rows.Close()
+	if len(durations) < cronBaselineMinRuns {
+		return
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	median := float64(durations[len(durations)/2])
+	if len(durations)%2 == 0 {
+		median = float64(durations[len(durations)/2-1]+durations[len(durations)/2]) / 2
+	}
+	if float64(run.Duration) < factor*median || float64(run.Duration)-median < cronAnomalyMinSlack {
+		return
+	}
+
+	ratio := float64(run.Duration) / median
+	msg := fmt.Sprintf("Cron job slower than usual: %s ran for %ds, %.1fx its median of %.0fs (last %d runs)",
+		run.Command, run.Duration, ratio, median, len(durations))
+	details, _ := json.Marshal(map[string]interface{}{
+		"kind":          "duration_anomaly",
+		"command":       run.Command,
+		"duration":      run.Duration,
+		"median":        median,
+		"ratio":         ratio,
+		"factor":        factor,
+		"baseline_runs": len(durations),
+	})
+	if _, err := database.DB.Exec(`
+		INSERT INTO events (server_id, timestamp, event_type, severity, message, details)
+		VALUES (?, ?, 'long_running', 'warning', ?, ?)
+	`, serverID, run.StartedAt+run.Duration, msg, string(details)); err != nil {
+		log.Printf("Failed to insert cron anomaly event: %v", err)
+		return
+	}
+
+	go func(hname string) {
+		if Notifier == nil {
+			return
+		}
+		Notifier.Notify(notifications.Notification{
+			Subject:   fmt.Sprintf("[WARNING] Slow Cron Job on %s", hname),
+			Message:   msg,
+			Type:      notifications.TypeWarning,
+			ServerID:  serverID,
+			EventType: "long_running",
+		})
+	}(getHostname(serverID))
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Cron ignore and timeout keys are matched the way the agent does (agent/cron/match.go):
+// an exact (whitespace-normalized) command wins, then "re:<regexp>" and "*" glob
+// keys in key order.
+
+func normalizeCronCommand(cmd string) string {
+	return strings.Join(strings.Fields(cmd), " ")
+}
+
+// compileCronKey turns a glob or "re:" key into a regexp; plain keys return nil
+func compileCronKey(key string) (*regexp.Regexp, error) {
+	if strings.HasPrefix(key, "re:") {
+		return regexp.Compile(strings.TrimPrefix(key, "re:"))
+	}
+	key = normalizeCronCommand(key)
+	if !strings.Contains(key, "*") {
+		return nil, nil
+	}
+	parts := strings.Split(key, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return regexp.Compile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// matchCronKey returns the key covering a command
+func matchCronKey(command string, keys []string) (string, bool) {
+	command = normalizeCronCommand(command)
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !strings.HasPrefix(key, "re:") && normalizeCronCommand(key) == command {
+			return key, true
+		}
+	}
+	for _, key := range keys {
+		if re, err := compileCronKey(key); err == nil && re != nil && re.MatchString(command) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// validateCronKeys rejects empty keys and invalid "re:" patterns
+func validateCronKeys(keys []string) error {
+	for _, key := range keys {
+		if strings.TrimSpace(strings.TrimPrefix(key, "re:")) == "" {
+			return fmt.Errorf("cron job pattern must not be empty")
+		}
+		if _, err := compileCronKey(key); err != nil {
+			return fmt.Errorf("invalid cron job pattern %q: %v", key, err)
+		}
+	}
+	return nil
+}
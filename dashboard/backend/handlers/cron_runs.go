@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// saveCronRuns stores the finished runs from an agent metrics push. A run
+// reported twice (log and eBPF) has the same start and is merged.
+func saveCronRuns(serverID string, raw interface{}) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return
+	}
+	var runs []models.CronRun
+	if err := json.Unmarshal(data, &runs); err != nil || len(runs) == 0 {
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		log.Printf("Warning: Failed to save cron runs: %v", err)
+		return
+	}
+	defer tx.Rollback()
+
+	var added []models.CronRun
+	for _, r := range runs {
+		if r.Command == "" || r.StartedAt <= 0 {
+			continue
+		}
+		var exists int
+		if tx.QueryRow("SELECT 1 FROM cron_runs WHERE server_id = ? AND command = ? AND started_at = ?", serverID, r.Command, r.StartedAt).Scan(&exists) != nil {
+			added = append(added, r)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO cron_runs (server_id, command, run_user, started_at, duration, exit_code, signal)
+			VALUES (?, ?, NULLIF(?, ''), ?, ?, ?, ?)
+			ON CONFLICT(server_id, command, started_at) DO UPDATE SET
+				duration = MAX(duration, excluded.duration),
+				exit_code = excluded.exit_code,
+				signal = MAX(signal, excluded.signal)
+		`, serverID, r.Command, r.User, r.StartedAt, r.Duration, r.ExitCode, r.Signal); err != nil {
+			log.Printf("Warning: Failed to save cron run: %v", err)
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Warning: Failed to save cron runs: %v", err)
+		return
+	}
+
+	for _, r := range added {
+		if r.ExitCode == 0 {
+			resolveCronFailureStreak(serverID, r.Command, r.StartedAt+r.Duration)
+		}
+	}
+	if factor := loadCronAnomalyFactor(); factor > 0 {
+		for _, r := range added {
+			checkCronDuration(serverID, r, factor)
+		}
+	}
+}
+
+// cronRunsSince parses the days query parameter (default 7, max 90) into a cutoff
+func cronRunsSince(c *fiber.Ctx) (int64, bool) {
+	days := c.QueryInt("days", 7)
+	if days < 1 || days > 90 {
+		return 0, false
+	}
+	return time.Now().AddDate(0, 0, -days).Unix(), true
+}
+
+// GetCronRunStats returns success rate and duration per job of a server.
+// Query: days (default 7, max 90)
+func GetCronRunStats(c *fiber.Ctx) error {
+	since, ok := cronRunsSince(c)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Days must be between 1 and 90"})
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT command, COUNT(*), SUM(CASE WHEN exit_code != 0 THEN 1 ELSE 0 END),
+			AVG(duration), MAX(duration), MAX(started_at),
+			(SELECT r2.exit_code FROM cron_runs r2 WHERE r2.server_id = r.server_id AND r2.command = r.command ORDER BY r2.started_at DESC LIMIT 1)
+		FROM cron_runs r
+		WHERE server_id = ? AND started_at >= ?
+		GROUP BY command
+		ORDER BY command
+	`, c.Params("id"), since)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	stats := []models.CronRunStats{}
+	for rows.Next() {
+		var s models.CronRunStats
+		if err := rows.Scan(&s.Command, &s.Runs, &s.Failures, &s.AvgDuration, &s.MaxDuration, &s.LastRun, &s.LastExit); err != nil {
+			continue
+		}
+		s.SuccessRate = float64(s.Runs-s.Failures) * 100 / float64(s.Runs)
+		stats = append(stats, s)
+	}
+	return c.JSON(stats)
+}
+
+// GetCronJobRuns returns the run history of one job (the URL-encoded command) with its stats.
+// Query: days (default 7, max 90), limit (default 100, max 1000)
+func GetCronJobRuns(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+	command, err := url.PathUnescape(c.Params("job"))
+	if err != nil || command == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid job"})
+	}
+	since, ok := cronRunsSince(c)
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Days must be between 1 and 90"})
+	}
+	limit := c.QueryInt("limit", 100)
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT command, COALESCE(run_user, ''), started_at, duration, exit_code, signal
+		FROM cron_runs
+		WHERE server_id = ? AND command = ? AND started_at >= ?
+		ORDER BY started_at DESC
+	`, serverID, command, since)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	// Stats cover the whole period; only the latest runs are listed
+	runs := []models.CronRun{}
+	stats := models.CronRunStats{Command: command}
+	var totalDuration int64
+	for rows.Next() {
+		var r models.CronRun
+		if err := rows.Scan(&r.Command, &r.User, &r.StartedAt, &r.Duration, &r.ExitCode, &r.Signal); err != nil {
+			continue
+		}
+		if stats.Runs == 0 {
+			stats.LastRun, stats.LastExit = r.StartedAt, r.ExitCode
+		}
+		stats.Runs++
+		if r.ExitCode != 0 {
+			stats.Failures++
+		}
+		totalDuration += r.Duration
+		if r.Duration > stats.MaxDuration {
+			stats.MaxDuration = r.Duration
+		}
+		if len(runs) < limit {
+			runs = append(runs, r)
+		}
+	}
+	if stats.Runs > 0 {
+		stats.SuccessRate = float64(stats.Runs-stats.Failures) * 100 / float64(stats.Runs)
+		stats.AvgDuration = float64(totalDuration) / float64(stats.Runs)
+	}
+
+	return c.JSON(fiber.Map{
+		"stats": stats,
+		"runs":  runs,
+	})
+}
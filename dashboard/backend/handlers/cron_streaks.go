@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/notifications"
+	"github.com/yourusername/health-dashboard-backend/tickets"
+)
+
+// defaultCronFailureThreshold alerts on the first failure, as before streaks existed
+const defaultCronFailureThreshold = 1
+
+// loadCronFailureThreshold returns how many consecutive failures raise an event
+func loadCronFailureThreshold() int {
+	threshold := defaultCronFailureThreshold
+	var val string
+	if err := database.GetSetting("cron_failure_threshold", &val); err == nil {
+		fmt.Sscanf(val, "%d", &threshold)
+	}
+	if threshold < 1 {
+		threshold = 1
+	}
+	return threshold
+}
+
+// cronEventCommand returns the job command of a cron failure event ("" for older agents)
+func cronEventCommand(details string) string {
+	var d struct {
+		Command string `json:"command"`
+	}
+	json.Unmarshal([]byte(details), &d)
+	return d.Command
+}
+
+// ordinal formats 1, 2, 3, 11 as "1st", "2nd", "3rd", "11th"
+func ordinal(n int) string {
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// recordCronFailure groups consecutive failures of a job. Failures below the
+// threshold are only counted; reaching it stores the event and alerts. Further
+// failures update that event in place (occurrences is the streak's length) and
+// send a single "still failing" alert. A failure re-sent by the agent (not
+// after the last one counted) is ignored.
+func recordCronFailure(serverID, hostname, command, eventType, severity, message string, timestamp int64, details string) {
+	var failures int
+	var firstFailure, lastFailure int64
+	var eventID sql.NullInt64
+	err := database.DB.QueryRow(`
+		SELECT failures, first_failure, last_failure, event_id FROM cron_failure_streaks WHERE server_id = ? AND command = ?
+	`, serverID, command).Scan(&failures, &firstFailure, &lastFailure, &eventID)
+	if err == sql.ErrNoRows {
+		firstFailure = timestamp
+	} else if err != nil {
+		log.Printf("Failed to load cron failure streak: %v", err)
+		return
+	} else if timestamp <= lastFailure {
+		return
+	}
+	failures++
+	threshold := loadCronFailureThreshold()
+
+	var d map[string]interface{}
+	json.Unmarshal([]byte(details), &d)
+	if d == nil {
+		d = map[string]interface{}{}
+	}
+	d["consecutive_failures"] = failures
+	d["first_failure"] = firstFailure
+	data, _ := json.Marshal(d)
+
+	msg := message
+	subject := fmt.Sprintf("[CRITICAL] Cron Job Failure on %s", hostname)
+	notify := false
+	switch {
+	case failures < threshold:
+		// Counted only; one failure of a flaky job is not worth an event
+	case failures == threshold || !eventID.Valid:
+		if threshold > 1 {
+			msg = fmt.Sprintf("%s (%d consecutive failures)", message, failures)
+		}
+		eventID, notify = insertCronEvent(serverID, timestamp, eventType, severity, msg, string(data)), true
+		if failures > 1 && eventID.Valid {
+			database.DB.Exec("UPDATE events SET occurrences = ?, first_seen = ?, last_seen = ? WHERE id = ?",
+				failures, firstFailure, timestamp, eventID.Int64)
+		}
+	default:
+		msg = fmt.Sprintf("%s - still failing (%s occurrence)", message, ordinal(failures))
+		res, err := database.DB.Exec(`
+			UPDATE events SET timestamp = ?, message = ?, details = ?, occurrences = ?, first_seen = ?, last_seen = ?
+			WHERE id = ? AND server_id = ?
+		`, timestamp, msg, string(data), failures, firstFailure, timestamp, eventID.Int64, serverID)
+		if err != nil {
+			log.Printf("Failed to update cron failure event: %v", err)
+		} else if n, _ := res.RowsAffected(); n == 0 {
+			eventID = insertCronEvent(serverID, timestamp, eventType, severity, msg, string(data)) // event was deleted
+		}
+		if failures == threshold+1 {
+			subject = fmt.Sprintf("[CRITICAL] Cron Job Still Failing on %s", hostname)
+			notify = true
+		}
+	}
+
+	if _, err := database.DB.Exec(`
+		INSERT INTO cron_failure_streaks (server_id, command, failures, first_failure, last_failure, event_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(server_id, command) DO UPDATE SET
+			failures = excluded.failures,
+			last_failure = excluded.last_failure,
+			event_id = excluded.event_id
+	`, serverID, command, failures, firstFailure, timestamp, eventID); err != nil {
+		log.Printf("Failed to save cron failure streak: %v", err)
+	}
+
+	if notify && severity != "info" {
+		go tickets.Open(serverID, "cron:"+command, fmt.Sprintf("%s: cron job failing: %s", hostname, command), msg)
+		go func() {
+			if Notifier == nil {
+				return
+			}
+			Notifier.Notify(notifications.Notification{
+				Subject:   subject,
+				Message:   msg,
+				Type:      notifications.TypeCritical,
+				ServerID:  serverID,
+				EventType: eventType,
+			})
+		}()
+	}
+}
+
+func insertCronEvent(serverID string, timestamp int64, eventType, severity, message, details string) sql.NullInt64 {
+	res, err := database.DB.Exec(`
+		INSERT INTO events (server_id, timestamp, event_type, severity, message, details, incident_key)
+		VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''))
+	`, serverID, timestamp, eventType, severity, message, details, eventIncidentKey(eventType, severity, details))
+	if err != nil {
+		log.Printf("Failed to insert event: %v", err)
+		return sql.NullInt64{}
+	}
+	id, _ := res.LastInsertId()
+	return sql.NullInt64{Int64: id, Valid: true}
+}
+
+// resolveCronFailureStreak ends a job's failure streak when a run finishing after
+// the last failure succeeded, resolving its failure event, with a recovery event
+// if the streak had alerted
+func resolveCronFailureStreak(serverID, command string, finishedAt int64) {
+	var failures int
+	var firstFailure, lastFailure int64
+	var eventID sql.NullInt64
+	if err := database.DB.QueryRow(`
+		SELECT failures, first_failure, last_failure, event_id FROM cron_failure_streaks WHERE server_id = ? AND command = ?
+	`, serverID, command).Scan(&failures, &firstFailure, &lastFailure, &eventID); err != nil {
+		return
+	}
+	if finishedAt <= lastFailure {
+		return // success reported late, from before the failures
+	}
+	database.DB.Exec("DELETE FROM cron_failure_streaks WHERE server_id = ? AND command = ?", serverID, command)
+	resolveIncident(serverID, "cron:"+command, finishedAt)
+	if !eventID.Valid {
+		return
+	}
+
+	msg := fmt.Sprintf("Cron job recovered: %s succeeded after %d consecutive failures", command, failures)
+	details, _ := json.Marshal(map[string]interface{}{
+		"command":       command,
+		"failures":      failures,
+		"first_failure": firstFailure,
+		"event_id":      eventID.Int64,
+	})
+	insertCronEvent(serverID, finishedAt, "cron", "info", msg, string(details))
+	go tickets.Resolve(serverID, "cron:"+command, msg)
+
+	go func(hname string) {
+		if Notifier == nil {
+			return
+		}
+		Notifier.Notify(notifications.Notification{
+			Subject:   fmt.Sprintf("[RESOLVED] Cron Job Recovered on %s", hname),
+			Message:   msg,
+			Type:      notifications.TypeSuccess,
+			ServerID:  serverID,
+			EventType: "cron",
+		})
+	}(getHostname(serverID))
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+	"github.com/yourusername/health-dashboard-backend/push"
+)
+
+// AcceptDrift accepts the current state of a server as its new drift baseline.
+// Outstanding drift events are acknowledged, drift_changed is cleared and the agent
+// re-baselines on its next config poll.
+func AcceptDrift(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+	username, _ := c.Locals("username").(string)
+
+	res, err := database.DB.Exec(`
+		UPDATE servers SET drift_changed = 0, drift_rebaseline_pending = 1 WHERE id = ?
+	`, serverID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update server"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
+
+	res, err = database.DB.Exec(`
+		UPDATE events SET acknowledged = 1, acked_by = ?, acked_at = ?
+		WHERE server_id = ? AND event_type = 'drift' AND COALESCE(acknowledged, 0) = 0
+	`, username, time.Now().Unix(), serverID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to acknowledge drift events"})
+	}
+	acked, _ := res.RowsAffected()
+	push.Notify(serverID)
+
+	recordAudit(c, "drift.accept", serverID, fmt.Sprintf("events_acknowledged=%d", acked))
+	return c.JSON(fiber.Map{"status": "accepted", "events_acknowledged": acked})
+}
+
+// GetEventDiff returns the unified diffs captured with a drift event.
+// ?format=text returns a plain patch (renderable by any diff viewer).
+func GetEventDiff(c *fiber.Ctx) error {
+	var serverID, eventType, details string
+	err := database.DB.QueryRow(`
+		SELECT server_id, event_type, COALESCE(details, '') FROM events WHERE id = ?
+	`, c.Params("id")).Scan(&serverID, &eventType, &details)
+	if err != nil || !middleware.CanSeeServer(c, serverID) {
+		return c.Status(404).JSON(fiber.Map{"error": "Event not found"})
+	}
+	if eventType != "drift" {
+		return c.Status(400).JSON(fiber.Map{"error": "Not a drift event"})
+	}
+
+	var parsed struct {
+		Diffs map[string]string `json:"diffs"`
+	}
+	json.Unmarshal([]byte(details), &parsed)
+
+	paths := make([]string, 0, len(parsed.Diffs))
+	for path := range parsed.Diffs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	if c.Query("format") == "text" {
+		var sb strings.Builder
+		for _, path := range paths {
+			sb.WriteString(parsed.Diffs[path])
+		}
+		c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+		return c.SendString(sb.String())
+	}
+
+	diffs := []fiber.Map{}
+	for _, path := range paths {
+		diffs = append(diffs, fiber.Map{"path": path, "diff": parsed.Diffs[path]})
+	}
+	return c.JSON(fiber.Map{"event_id": c.Params("id"), "server_id": serverID, "diffs": diffs})
+}
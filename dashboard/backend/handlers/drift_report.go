@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+	"github.com/yourusername/health-dashboard-backend/pdf"
+)
+
+// driftChange is one line of the configuration change log: a file or listening
+// port that changed, expanded from a drift event
+type driftChange struct {
+	Timestamp int64  `json:"timestamp"`
+	ServerID  string `json:"server_id"`
+	Hostname  string `json:"hostname"`
+	Change    string `json:"change"` // created, modified, deleted, changed (older agents), port_opened, port_closed, unlisted
+	Target    string `json:"target"` // file path or socket
+	Detail    string `json:"detail,omitempty"`
+	ChangedBy string `json:"changed_by,omitempty"`
+	EventID   int64  `json:"event_id"`
+	AckedBy   string `json:"acknowledged_by,omitempty"`
+	AckedAt   int64  `json:"acknowledged_at,omitempty"`
+}
+
+// Drift summaries as written by the agent ("File modified: /etc/x (Content changed) by vim and 2 others")
+var (
+	driftFileMessage = regexp.MustCompile(`^File (created|modified|deleted): (\S+)(?: \((.*?)\))?(?: by (.+?))?(?: and (\d+) others)?$`)
+	driftPortMessage = regexp.MustCompile(`^Port (opened|closed): (.+?)(?: and (\d+) others)?$`)
+)
+
+// expandDriftEvent lists the individual changes behind a drift event. Agents
+// include every changed file in the details; events from older agents only name
+// the first file in the message, plus the files that have a diff or a writer.
+func expandDriftEvent(message, details string) []driftChange {
+	var parsed struct {
+		Kind  string `json:"kind"`
+		Files []struct {
+			Path   string `json:"path"`
+			Change string `json:"change"`
+			Detail string `json:"detail"`
+			By     string `json:"by"`
+		} `json:"files"`
+		Changes    []string          `json:"changes"`
+		Diffs      map[string]string `json:"diffs"`
+		ModifiedBy map[string]string `json:"modified_by"`
+	}
+	json.Unmarshal([]byte(details), &parsed)
+
+	var out []driftChange
+	if parsed.Kind == "network" || driftPortMessage.MatchString(message) {
+		lines := parsed.Changes
+		if len(lines) == 0 {
+			lines = []string{message}
+		}
+		for _, line := range lines {
+			if m := driftPortMessage.FindStringSubmatch(line); m != nil {
+				out = append(out, driftChange{Change: "port_" + m[1], Target: m[2]})
+			}
+		}
+		return out
+	}
+
+	if len(parsed.Files) > 0 {
+		for _, f := range parsed.Files {
+			out = append(out, driftChange{Change: f.Change, Target: f.Path, Detail: f.Detail, ChangedBy: f.By})
+		}
+		return out
+	}
+
+	m := driftFileMessage.FindStringSubmatch(message)
+	if m == nil {
+		return []driftChange{{Change: "unlisted", Detail: message}}
+	}
+	out = append(out, driftChange{Change: m[1], Target: m[2], Detail: m[3], ChangedBy: m[4]})
+	listed := map[string]bool{m[2]: true}
+	var extra []string
+	for path := range parsed.Diffs {
+		extra = append(extra, path)
+	}
+	for path := range parsed.ModifiedBy {
+		extra = append(extra, path)
+	}
+	sort.Strings(extra)
+	for _, path := range extra {
+		if listed[path] {
+			continue
+		}
+		listed[path] = true
+		out = append(out, driftChange{Change: "changed", Target: path, ChangedBy: parsed.ModifiedBy[path]})
+	}
+	if others, _ := strconv.Atoi(m[5]); others > len(out)-1 {
+		out = append(out, driftChange{
+			Change: "unlisted",
+			Detail: fmt.Sprintf("%d more files changed (not itemized by this agent version)", others-(len(out)-1)),
+		})
+	}
+	return out
+}
+
+// GetDriftReport returns the configuration change log: every drift event of a
+// calendar month (UTC) expanded into the individual file and port changes.
+// Query: month=YYYY-MM (default current), server_id, format=csv|pdf for an export
+func GetDriftReport(c *fiber.Ctx) error {
+	month := c.Query("month", time.Now().UTC().Format("2006-01"))
+	from, err := time.Parse("2006-01", month)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Month must be YYYY-MM"})
+	}
+	to := from.AddDate(0, 1, 0)
+
+	serverID := c.Query("server_id")
+	if serverID != "" && !middleware.CanSeeServer(c, serverID) {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
+
+	visible, visibleArgs := middleware.ServerVisibilityClause(c, "e.server_id")
+	query := `
+		SELECT e.id, e.server_id, s.hostname, e.timestamp, COALESCE(e.message, ''), COALESCE(e.details, ''),
+			COALESCE(e.acked_by, ''), COALESCE(e.acked_at, 0)
+		FROM events e
+		JOIN servers s ON s.id = e.server_id
+		WHERE e.event_type = 'drift' AND e.timestamp >= ? AND e.timestamp < ? AND ` + visible
+	args := append([]interface{}{from.Unix(), to.Unix()}, visibleArgs...)
+	if serverID != "" {
+		query += " AND e.server_id = ?"
+		args = append(args, serverID)
+	}
+	rows, err := database.DB.Query(query+" ORDER BY s.hostname, e.timestamp, e.id", args...)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	entries := []driftChange{}
+	for rows.Next() {
+		var id, ts, ackedAt int64
+		var sid, hostname, message, details, ackedBy string
+		if err := rows.Scan(&id, &sid, &hostname, &ts, &message, &details, &ackedBy, &ackedAt); err != nil {
+			continue
+		}
+		for _, ch := range expandDriftEvent(message, details) {
+			ch.Timestamp, ch.ServerID, ch.Hostname, ch.EventID = ts, sid, hostname, id
+			ch.AckedBy, ch.AckedAt = ackedBy, ackedAt
+			entries = append(entries, ch)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+
+	switch c.Query("format") {
+	case "csv":
+		return sendDriftCSV(c, entries, month)
+	case "pdf":
+		return sendDriftPDF(c, entries, month, from, to)
+	}
+
+	type serverSummary struct {
+		ServerID string `json:"server_id"`
+		Hostname string `json:"hostname"`
+		Changes  int    `json:"changes"`
+	}
+	servers := []serverSummary{}
+	for _, e := range entries {
+		if n := len(servers); n > 0 && servers[n-1].ServerID == e.ServerID {
+			servers[n-1].Changes++
+			continue
+		}
+		servers = append(servers, serverSummary{ServerID: e.ServerID, Hostname: e.Hostname, Changes: 1})
+	}
+
+	return c.JSON(fiber.Map{
+		"month":   month,
+		"from":    from.Unix(),
+		"to":      to.Unix(),
+		"servers": servers,
+		"entries": entries,
+	})
+}
+
+func sendDriftCSV(c *fiber.Ctx, entries []driftChange, month string) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{
+		"timestamp", "hostname", "server_id", "change", "target", "detail", "changed_by",
+		"event_id", "acknowledged_by", "acknowledged_at",
+	})
+	for _, e := range entries {
+		ackedAt := ""
+		if e.AckedAt > 0 {
+			ackedAt = time.Unix(e.AckedAt, 0).UTC().Format(time.RFC3339)
+		}
+		w.Write([]string{
+			time.Unix(e.Timestamp, 0).UTC().Format(time.RFC3339), e.Hostname, e.ServerID, e.Change, e.Target, e.Detail, e.ChangedBy,
+			strconv.FormatInt(e.EventID, 10), e.AckedBy, ackedAt,
+		})
+	}
+	w.Flush()
+
+	c.Set("Content-Type", "text/csv")
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="drift-report-%s.csv"`, month))
+	return c.Send(buf.Bytes())
+}
+
+func sendDriftPDF(c *fiber.Ctx, entries []driftChange, month string, from, to time.Time) error {
+	username, _ := c.Locals("username").(string)
+	doc := pdf.New("Configuration Change Log - " + month)
+	doc.Lines(
+		fmt.Sprintf("Period:    %s to %s (UTC)", from.Format("2006-01-02"), to.AddDate(0, 0, -1).Format("2006-01-02")),
+		fmt.Sprintf("Generated: %s UTC by %s", time.Now().UTC().Format("2006-01-02 15:04:05"), username),
+		fmt.Sprintf("Changes:   %d", len(entries)),
+		"",
+	)
+	if len(entries) == 0 {
+		doc.Line("No configuration changes were recorded in this period.")
+	}
+
+	for i, e := range entries {
+		if i == 0 || entries[i-1].ServerID != e.ServerID {
+			if i > 0 {
+				doc.Line("")
+			}
+			doc.Lines(
+				fmt.Sprintf("== %s (%s) ==", e.Hostname, e.ServerID),
+				fmt.Sprintf("%-19s  %-11s  %s", "Timestamp (UTC)", "Change", "Target / detail / changed by / acknowledged"),
+			)
+		}
+		line := fmt.Sprintf("%-19s  %-11s  %s", time.Unix(e.Timestamp, 0).UTC().Format("2006-01-02 15:04:05"), e.Change, e.Target)
+		var extra []string
+		if e.Detail != "" {
+			extra = append(extra, e.Detail)
+		}
+		if e.ChangedBy != "" {
+			extra = append(extra, "by "+e.ChangedBy)
+		}
+		if e.AckedBy != "" {
+			extra = append(extra, fmt.Sprintf("ack %s %s", e.AckedBy, time.Unix(e.AckedAt, 0).UTC().Format("2006-01-02")))
+		}
+		if len(extra) > 0 {
+			line += " - " + strings.Join(extra, "; ")
+		}
+		doc.Line(line)
+	}
+
+	c.Set("Content-Type", "application/pdf")
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="drift-report-%s.pdf"`, month))
+	return c.Send(doc.Bytes())
+}
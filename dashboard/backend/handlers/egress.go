@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+// loadEgressSettings returns whether outbound connection monitoring is on and
+// its allowlist (default off, empty)
+func loadEgressSettings() (bool, []string) {
+	enabled := false
+	allow := []string{}
+	var val string
+	if err := database.GetSetting("egress_monitor_enabled", &val); err == nil {
+		enabled = val == "true"
+	}
+	if err := database.GetSetting("egress_allow", &val); err == nil {
+		json.Unmarshal([]byte(val), &allow)
+	}
+	return enabled, allow
+}
+
+// validateEgressAllow checks allowlist entries the way the agent parses them:
+// [process@]address[:port], with an IP, CIDR or "*" as address and IPv6 with a
+// port in brackets
+func validateEgressAllow(rules []string) error {
+	for _, rule := range rules {
+		s := strings.TrimSpace(rule)
+		if proc, rest, ok := strings.Cut(s, "@"); ok {
+			if _, err := filepath.Match(proc, ""); proc == "" || err != nil {
+				return fmt.Errorf("invalid egress rule %q: bad process name", rule)
+			}
+			s = rest
+		}
+
+		addr, port := s, ""
+		switch {
+		case strings.HasPrefix(s, "["):
+			end := strings.Index(s, "]")
+			if end < 0 || (end+1 < len(s) && s[end+1] != ':') {
+				return fmt.Errorf("invalid egress rule %q", rule)
+			}
+			addr = s[1:end]
+			if end+1 < len(s) {
+				port = s[end+2:]
+			}
+		case strings.Count(s, ":") == 1:
+			addr, port, _ = strings.Cut(s, ":")
+		}
+
+		if port != "" && port != "*" {
+			if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+				return fmt.Errorf("invalid egress rule %q: bad port", rule)
+			}
+		}
+		switch {
+		case addr == "*" || addr == "":
+			if s == "" && !strings.Contains(rule, "@") {
+				return fmt.Errorf("egress rule must not be empty")
+			}
+		case strings.Contains(addr, "/"):
+			if _, _, err := net.ParseCIDR(addr); err != nil {
+				return fmt.Errorf("invalid egress rule %q: %v", rule, err)
+			}
+		default:
+			if net.ParseIP(addr) == nil {
+				return fmt.Errorf("invalid egress rule %q: bad address", rule)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+)
+
+// AckEvent marks an event as handled. Acknowledged events are hidden from the
+// event listings (unless ?acknowledged=all or true) and no longer count as
+// outstanding drift; once a server's last drift event is acknowledged, its
+// drift flag is cleared. Acknowledging again keeps the first acknowledgment.
+func AckEvent(c *fiber.Ctx) error {
+	eventID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid event ID"})
+	}
+	visible, args := middleware.ServerVisibilityClause(c, "server_id")
+
+	var serverID, eventType string
+	var acked bool
+	err = database.DB.QueryRow(`SELECT server_id, event_type, COALESCE(acknowledged, 0) FROM events WHERE id = ? AND `+visible,
+		append([]interface{}{eventID}, args...)...).Scan(&serverID, &eventType, &acked)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Event not found"})
+	}
+
+	if !acked {
+		username, _ := c.Locals("username").(string)
+		_, err := database.DB.Exec(`
+			UPDATE events SET acknowledged = 1, acked_by = NULLIF(?, ''), acked_at = ?
+			WHERE id = ? AND COALESCE(acknowledged, 0) = 0
+		`, username, time.Now().Unix(), eventID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to acknowledge event"})
+		}
+		if eventType == "drift" {
+			database.DB.Exec(`
+				UPDATE servers SET drift_changed = 0
+				WHERE id = ? AND NOT EXISTS (
+					SELECT 1 FROM events WHERE server_id = ? AND event_type = 'drift' AND COALESCE(acknowledged, 0) = 0
+				)
+			`, serverID, serverID)
+		}
+		recordAudit(c, "event.ack", serverID, eventType)
+	}
+
+	var ackedBy string
+	var ackedAt int64
+	database.DB.QueryRow("SELECT COALESCE(acked_by, ''), COALESCE(acked_at, 0) FROM events WHERE id = ?", eventID).Scan(&ackedBy, &ackedAt)
+	return c.JSON(fiber.Map{"status": "acknowledged", "acked_by": ackedBy, "acked_at": ackedAt})
+}
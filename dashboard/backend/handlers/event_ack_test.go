@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+func TestAckEvent(t *testing.T) {
+	testDB(t)
+	mustExec(t, `INSERT INTO servers (id, hostname, api_secret_hash, first_seen, last_seen, drift_changed) VALUES ('s1', 'web-1', 'x', 0, 0, 1)`)
+	mustExec(t, `INSERT INTO events (id, server_id, timestamp, event_type, severity, message) VALUES
+		(1, 's1', 1000, 'drift', 'warning', 'sshd_config changed'),
+		(2, 's1', 1001, 'drift', 'warning', 'nginx.conf changed'),
+		(3, 's1', 1002, 'health', 'critical', 'CPU critical')`)
+
+	app := fiber.New()
+	app.Post("/events/:id/ack", AckEvent)
+	app.Get("/servers/:id/events", GetServerEvents)
+	ack := func(id string) int {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("POST", "/events/"+id+"/ack", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+	list := func(url string) []models.Event {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", url, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var events []models.Event
+		json.NewDecoder(resp.Body).Decode(&events)
+		return events
+	}
+	driftChanged := func() bool {
+		var changed bool
+		database.DB.QueryRow("SELECT drift_changed FROM servers WHERE id = 's1'").Scan(&changed)
+		return changed
+	}
+
+	if status := ack("99"); status != 404 {
+		t.Errorf("unknown event: status %d, want 404", status)
+	}
+	if status := ack("1"); status != 200 {
+		t.Fatalf("ack: status %d", status)
+	}
+	if !driftChanged() {
+		t.Error("drift cleared while a drift event is still outstanding")
+	}
+
+	// Acknowledged events are hidden by default
+	if events := list("/servers/s1/events"); len(events) != 2 || events[1].ID != 2 {
+		t.Errorf("default listing = %+v, want events 3 and 2", events)
+	}
+	if events := list("/servers/s1/events?acknowledged=true"); len(events) != 1 || !events[0].Acknowledged || events[0].AckedAt == 0 {
+		t.Errorf("acknowledged listing = %+v", events)
+	}
+	if events := list("/servers/s1/events?acknowledged=all"); len(events) != 3 {
+		t.Errorf("all: %d events, want 3", len(events))
+	}
+
+	if status := ack("2"); status != 200 {
+		t.Fatalf("ack: status %d", status)
+	}
+	if driftChanged() {
+		t.Error("drift still flagged after acknowledging every drift event")
+	}
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// maxCommentLength caps the text of one event comment
+const maxCommentLength = 4000
+
+// loadVisibleEvent reads an event of a server the user may see
+func loadVisibleEvent(c *fiber.Ctx, eventID int) (models.Event, error) {
+	visible, args := middleware.ServerVisibilityClause(c, "server_id")
+	var e models.Event
+	err := database.DB.QueryRow(`
+		SELECT id, server_id, timestamp, event_type, severity, message, COALESCE(details, ''), COALESCE(acknowledged, 0),
+			COALESCE(acked_by, ''), COALESCE(acked_at, 0), COALESCE(incident_key, ''), COALESCE(resolved_at, 0),
+			occurrences, COALESCE(first_seen, timestamp), COALESCE(last_seen, timestamp)
+		FROM events WHERE id = ? AND `+visible,
+		append([]interface{}{eventID}, args...)...).Scan(&e.ID, &e.ServerID, &e.Timestamp, &e.EventType, &e.Severity, &e.Message,
+		&e.Details, &e.Acknowledged, &e.AckedBy, &e.AckedAt, &e.IncidentKey, &e.ResolvedAt,
+		&e.Occurrences, &e.FirstSeen, &e.LastSeen)
+	setIncidentState(&e)
+	return e, err
+}
+
+// eventComments returns the comments of an event, oldest first
+func eventComments(eventID int64) ([]models.EventComment, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, event_id, COALESCE(username, ''), body, created_at
+		FROM event_comments WHERE event_id = ? ORDER BY created_at, id
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []models.EventComment{}
+	for rows.Next() {
+		var cm models.EventComment
+		if err := rows.Scan(&cm.ID, &cm.EventID, &cm.Username, &cm.Body, &cm.CreatedAt); err == nil {
+			comments = append(comments, cm)
+		}
+	}
+	return comments, nil
+}
+
+// GetEvent returns one event with its comments
+func GetEvent(c *fiber.Ctx) error {
+	eventID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid event ID"})
+	}
+	e, err := loadVisibleEvent(c, eventID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Event not found"})
+	}
+	if e.Comments, err = eventComments(e.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	return c.JSON(e)
+}
+
+// AddEventComment attaches a comment to an event ({"body": "..."}), recording
+// who wrote it and when. Comments are deleted with their event.
+func AddEventComment(c *fiber.Ctx) error {
+	eventID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid event ID"})
+	}
+	var req struct {
+		Body string `json:"body"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if req.Body == "" || len(req.Body) > maxCommentLength {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("Comment body is required (max %d characters)", maxCommentLength)})
+	}
+	e, err := loadVisibleEvent(c, eventID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Event not found"})
+	}
+
+	cm := models.EventComment{EventID: e.ID, Body: req.Body, CreatedAt: time.Now().Unix()}
+	cm.Username, _ = c.Locals("username").(string)
+	res, err := database.DB.Exec(`
+		INSERT INTO event_comments (event_id, username, body, created_at) VALUES (?, NULLIF(?, ''), ?, ?)
+	`, cm.EventID, cm.Username, cm.Body, cm.CreatedAt)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save comment"})
+	}
+	cm.ID, _ = res.LastInsertId()
+
+	recordAudit(c, "event.comment", e.ServerID, fmt.Sprintf("event %d", e.ID))
+	return c.Status(201).JSON(cm)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+func TestEventComments(t *testing.T) {
+	testDB(t)
+	seedServer(t, "s1", "web-1")
+	mustExec(t, `INSERT INTO events (id, server_id, timestamp, event_type, severity, message) VALUES (1, 's1', 1000, 'port', 'critical', 'nginx is down')`)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("username", "alice")
+		return c.Next()
+	})
+	app.Get("/events/:id", GetEvent)
+	app.Post("/events/:id/comments", AddEventComment)
+	app.Delete("/events/:id", DeleteEvent)
+	comment := func(id, body string) int {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/events/"+id+"/comments", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := comment("1", `{"body": "  "}`); status != 400 {
+		t.Errorf("empty comment: status %d, want 400", status)
+	}
+	if status := comment("2", `{"body": "x"}`); status != 404 {
+		t.Errorf("unknown event: status %d, want 404", status)
+	}
+	for _, body := range []string{`{"body": "Restarted nginx"}`, `{"body": "Root cause: full disk"}`} {
+		if status := comment("1", body); status != 201 {
+			t.Fatalf("comment: status %d", status)
+		}
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/events/1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e models.Event
+	json.NewDecoder(resp.Body).Decode(&e)
+	if len(e.Comments) != 2 || e.Comments[0].Body != "Restarted nginx" || e.Comments[1].Username != "alice" {
+		t.Fatalf("event detail = %+v", e)
+	}
+
+	// Comments go with their event
+	app.Test(httptest.NewRequest("DELETE", "/events/1", nil))
+	var n int
+	database.DB.QueryRow("SELECT COUNT(*) FROM event_comments").Scan(&n)
+	if n != 0 {
+		t.Errorf("%d comments left after deleting the event", n)
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// maxEventsPage caps the events returned by one request
+const maxEventsPage = 1000
+
+// eventFilter is the filtering and paging of an events listing, parsed from
+// page, limit, since, until (unix seconds), severity, type, server_id, tag
+// (comma separated), acknowledged (false by default, true or all) and state
+// (open or resolved)
+type eventFilter struct {
+	where []string
+	args  []interface{}
+	page  int
+	limit int
+}
+
+// parseEventFilter reads the query parameters; scope is the visibility clause
+// and its arguments the listing is restricted to, defaultAck the acknowledged
+// filter without one
+func parseEventFilter(c *fiber.Ctx, defaultLimit int, defaultAck, scope string, scopeArgs []interface{}) (*eventFilter, error) {
+	f := &eventFilter{
+		where: []string{scope},
+		args:  append([]interface{}{}, scopeArgs...),
+		page:  c.QueryInt("page", 1),
+		limit: c.QueryInt("limit", defaultLimit),
+	}
+	if f.page < 1 {
+		return nil, fmt.Errorf("page must be 1 or more")
+	}
+	if f.limit < 1 || f.limit > maxEventsPage {
+		return nil, fmt.Errorf("limit must be between 1 and %d", maxEventsPage)
+	}
+
+	for _, bound := range []struct{ param, cond string }{{"since", "timestamp >= ?"}, {"until", "timestamp <= ?"}} {
+		raw := c.Query(bound.param)
+		if raw == "" {
+			continue
+		}
+		ts, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || ts < 0 {
+			return nil, fmt.Errorf("%s must be a unix timestamp", bound.param)
+		}
+		f.where = append(f.where, bound.cond)
+		f.args = append(f.args, ts)
+	}
+
+	f.addList(c.Query("severity"), "severity")
+	f.addList(c.Query("type"), "event_type")
+	f.addList(c.Query("server_id"), "server_id")
+
+	switch c.Query("acknowledged", defaultAck) {
+	case "false":
+		f.where = append(f.where, "COALESCE(acknowledged, 0) = 0")
+	case "true":
+		f.where = append(f.where, "COALESCE(acknowledged, 0) = 1")
+	case "all":
+	default:
+		return nil, fmt.Errorf("acknowledged must be false, true or all")
+	}
+
+	switch c.Query("state") {
+	case "":
+	case "open":
+		f.where = append(f.where, "incident_key IS NOT NULL AND resolved_at IS NULL")
+	case "resolved":
+		f.where = append(f.where, "resolved_at IS NOT NULL")
+	default:
+		return nil, fmt.Errorf("state must be open or resolved")
+	}
+
+	tagged, tagArgs, err := tagFilterClause(c.Query("tag"), "server_id")
+	if err != nil {
+		return nil, err
+	}
+	f.where = append(f.where, tagged)
+	f.args = append(f.args, tagArgs...)
+	return f, nil
+}
+
+// addList restricts column to the comma separated values, if any
+func (f *eventFilter) addList(raw, column string) {
+	var values []interface{}
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+	f.where = append(f.where, column+" IN ("+strings.TrimSuffix(strings.Repeat("?,", len(values)), ",")+")")
+	f.args = append(f.args, values...)
+}
+
+// sendEvents answers with one page of matching events, newest first. The
+// body stays a plain list; the paging is in X-Total-Count, X-Page and X-Limit.
+func sendEvents(c *fiber.Ctx, f *eventFilter) error {
+	where := strings.Join(f.where, " AND ")
+
+	var total int
+	if err := database.DB.QueryRow(`SELECT COUNT(*) FROM events WHERE `+where, f.args...).Scan(&total); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id, server_id, timestamp, event_type, severity, message, COALESCE(details, ''), COALESCE(acknowledged, 0),
+			COALESCE(acked_by, ''), COALESCE(acked_at, 0), COALESCE(incident_key, ''), COALESCE(resolved_at, 0),
+			occurrences, COALESCE(first_seen, timestamp), COALESCE(last_seen, timestamp)
+		FROM events
+		WHERE `+where+`
+		ORDER BY timestamp DESC, id DESC
+		LIMIT ? OFFSET ?
+	`, append(f.args, f.limit, (f.page-1)*f.limit)...)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	events := []models.Event{}
+	for rows.Next() {
+		var e models.Event
+		err := rows.Scan(&e.ID, &e.ServerID, &e.Timestamp, &e.EventType, &e.Severity, &e.Message, &e.Details, &e.Acknowledged, &e.AckedBy, &e.AckedAt, &e.IncidentKey, &e.ResolvedAt,
+			&e.Occurrences, &e.FirstSeen, &e.LastSeen)
+		if err != nil {
+			continue
+		}
+		setIncidentState(&e)
+		events = append(events, e)
+	}
+
+	c.Set("X-Total-Count", strconv.Itoa(total))
+	c.Set("X-Page", strconv.Itoa(f.page))
+	c.Set("X-Limit", strconv.Itoa(f.limit))
+	return c.JSON(events)
+}
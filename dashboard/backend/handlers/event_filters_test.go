@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+func TestEventFilters(t *testing.T) {
+	testDB(t)
+	seedServer(t, "s1", "web-1")
+	seedServer(t, "s2", "web-2")
+	for i := 1; i <= 30; i++ {
+		severity, server := "info", "s1"
+		if i%3 == 0 {
+			severity = "critical"
+		}
+		if i > 20 {
+			server = "s2"
+		}
+		mustExec(t, `INSERT INTO events (server_id, timestamp, event_type, severity, message) VALUES (?, ?, 'health', ?, 'm')`, server, 1000+i, severity)
+	}
+
+	app := fiber.New()
+	app.Get("/events", GetAllEvents)
+	app.Get("/servers/:id/events", GetServerEvents)
+
+	get := func(url string) (int, string, []models.Event) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", url, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var events []models.Event
+		if resp.StatusCode == 200 {
+			json.NewDecoder(resp.Body).Decode(&events)
+		}
+		return resp.StatusCode, resp.Header.Get("X-Total-Count"), events
+	}
+
+	// Second page of 10, newest first
+	if status, total, events := get("/events?page=2&limit=10"); status != 200 || total != "30" || len(events) != 10 || events[0].Timestamp != 1020 {
+		t.Fatalf("page 2: status %d, total %s, %d events", status, total, len(events))
+	}
+	if _, total, events := get("/events?severity=critical&since=1010&until=1024"); total != "5" || len(events) != 5 {
+		t.Fatalf("severity and range: total %s, %d events", total, len(events))
+	}
+	if _, total, _ := get("/events?server_id=s2&type=health,drift"); total != "10" {
+		t.Fatalf("server_id and type: total %s", total)
+	}
+	if _, total, events := get("/servers/s1/events?limit=5"); total != "20" || len(events) != 5 {
+		t.Fatalf("server events: total %s, %d events", total, len(events))
+	}
+	for _, url := range []string{"/events?page=0", "/events?limit=5000", "/events?since=yesterday"} {
+		if status, _, _ := get(url); status != 400 {
+			t.Errorf("%s: status %d, want 400", url, status)
+		}
+	}
+}
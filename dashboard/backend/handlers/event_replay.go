@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+	"github.com/yourusername/health-dashboard-backend/notifications"
+)
+
+// ReplayEvent re-sends a stored event through the notification providers so
+// message templates can be checked without waiting for a real incident. The
+// replay is marked as a test and bypasses the alerts-enabled/severity filters.
+// Body: {"provider": "slack|teams|discord|email"} (empty = all configured)
+func ReplayEvent(c *fiber.Ctx) error {
+	var req struct {
+		Provider string `json:"provider"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	var serverID, eventType, severity, message string
+	var timestamp int64
+	err := database.DB.QueryRow(`
+		SELECT server_id, timestamp, event_type, severity, message FROM events WHERE id = ?
+	`, c.Params("id")).Scan(&serverID, &timestamp, &eventType, &severity, &message)
+	if err != nil || !middleware.CanSeeServer(c, serverID) {
+		return c.Status(404).JSON(fiber.Map{"error": "Event not found"})
+	}
+
+	if Notifier == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Notifications not initialized"})
+	}
+	providers := Notifier.Providers()
+	keys := notifications.ProviderKeys
+	if req.Provider != "" {
+		if _, ok := providers[req.Provider]; !ok {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("Provider '%s' is not configured", req.Provider)})
+		}
+		keys = []string{req.Provider}
+	} else if len(providers) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "No notification providers configured"})
+	}
+
+	notifType := notifications.TypeInfo
+	switch severity {
+	case "critical":
+		notifType = notifications.TypeCritical
+	case "warning":
+		notifType = notifications.TypeWarning
+	}
+	label := strings.ReplaceAll(eventType, "_", " ")
+	if label != "" {
+		label = strings.ToUpper(label[:1]) + label[1:]
+	}
+	n := notifications.Notification{
+		Subject: fmt.Sprintf("[TEST] [%s] %s Alert on %s", strings.ToUpper(severity), label, getHostname(serverID)),
+		Message: fmt.Sprintf("%s\n\n(Replay of event #%s from %s - test mode, no action required)",
+			message, c.Params("id"), time.Unix(timestamp, 0).UTC().Format("2006-01-02 15:04:05 UTC")),
+		Type: notifType,
+	}
+
+	results := []fiber.Map{}
+	failed := 0
+	for _, key := range keys {
+		p, ok := providers[key]
+		if !ok {
+			continue
+		}
+		result := fiber.Map{"provider": key, "name": p.Name(), "status": "sent"}
+		err := p.Send(n)
+		notifications.RecordDelivery(key, err)
+		if err != nil {
+			log.Printf("❌ Replay of event %s via %s failed: %v", c.Params("id"), key, err)
+			result["status"] = "failed"
+			result["error"] = err.Error()
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	target := req.Provider
+	if target == "" {
+		target = "all"
+	}
+	recordAudit(c, "event.replay", c.Params("id"), target)
+
+	status := 200
+	if failed == len(results) {
+		status = 502
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"event_id": c.Params("id"),
+		"test":     true,
+		"results":  results,
+	})
+}
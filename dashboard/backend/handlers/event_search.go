@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+)
+
+// maxSearchTerms caps the terms of one event search
+const maxSearchTerms = 10
+
+// searchTerms splits a search query into words and "quoted phrases"
+func searchTerms(q string) []string {
+	var terms []string
+	for i, part := range strings.Split(q, `"`) {
+		if i%2 == 1 {
+			if phrase := strings.TrimSpace(part); phrase != "" {
+				terms = append(terms, phrase)
+			}
+			continue
+		}
+		terms = append(terms, strings.Fields(part)...)
+	}
+	return terms
+}
+
+// likePattern matches text containing s, with LIKE wildcards in s escaped
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
+
+// SearchEvents finds the events whose message or details contain every term
+// of q (words or "quoted phrases", case-insensitive for ASCII), newest first.
+// It takes the same filters and paging as GET /events (since, until,
+// server_id, tag, type, severity, state), but includes acknowledged events
+// unless ?acknowledged=false.
+func SearchEvents(c *fiber.Ctx) error {
+	q := c.Query("q")
+	terms := searchTerms(q)
+	if len(terms) == 0 || len(q) > 200 {
+		return c.Status(400).JSON(fiber.Map{"error": "q is required (max 200 characters)"})
+	}
+	if len(terms) > maxSearchTerms {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("At most %d search terms", maxSearchTerms)})
+	}
+
+	visible, args := middleware.ServerVisibilityClause(c, "server_id")
+	filter, err := parseEventFilter(c, 50, "all", visible, args)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	for _, term := range terms {
+		pattern := likePattern(term)
+		filter.where = append(filter.where, `(message LIKE ? ESCAPE '\' OR details LIKE ? ESCAPE '\')`)
+		filter.args = append(filter.args, pattern, pattern)
+	}
+	return sendEvents(c, filter)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+func TestSearchEvents(t *testing.T) {
+	testDB(t)
+	seedServer(t, "s1", "db-1")
+	seedServer(t, "s2", "db-2")
+	mustExec(t, `INSERT INTO events (server_id, timestamp, event_type, severity, message, details, acknowledged) VALUES
+		('s1', 1000, 'port', 'critical', 'postgres is down', '{"name": "postgres"}', 1),
+		('s1', 2000, 'cron', 'critical', 'Cron job failed: pg_dump', '{"command": "pg_dump -U PostgreS app"}', 0),
+		('s2', 1500, 'port', 'critical', 'PostgreSQL replica lagging', '', 0),
+		('s2', 1600, 'health', 'warning', 'Disk at 95% on /var', '', 0),
+		('s1', 1700, 'health', 'warning', 'Disk at 95 percent', '', 0)`)
+
+	app := fiber.New()
+	app.Get("/events/search", SearchEvents)
+	search := func(query string) (int, []models.Event) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", "/events/search?"+query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var events []models.Event
+		if resp.StatusCode == 200 {
+			json.NewDecoder(resp.Body).Decode(&events)
+		}
+		return resp.StatusCode, events
+	}
+
+	if status, _ := search("q=" + url.QueryEscape(`""`)); status != 400 {
+		t.Errorf("empty query: status %d, want 400", status)
+	}
+	// Message or details, any case, acknowledged events included
+	if _, events := search("q=postgres"); len(events) != 3 {
+		t.Errorf("postgres: %d events, want 3", len(events))
+	}
+	if _, events := search("q=postgres&server_id=s1&since=900&until=1200"); len(events) != 1 || events[0].Timestamp != 1000 {
+		t.Errorf("postgres on s1 in range: %+v", events)
+	}
+	if _, events := search("q=postgres&acknowledged=false"); len(events) != 2 {
+		t.Errorf("unacknowledged postgres: %d events, want 2", len(events))
+	}
+	// Every term must match; phrases match as a whole
+	if _, events := search("q=" + url.QueryEscape(`postgres "replica lagging"`)); len(events) != 1 || events[0].ServerID != "s2" {
+		t.Errorf("phrase: %+v", events)
+	}
+	// LIKE wildcards in the query are literal
+	if _, events := search("q=" + url.QueryEscape("95%")); len(events) != 1 || events[0].Message != "Disk at 95% on /var" {
+		t.Errorf("95%%: %+v", events)
+	}
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/notifications"
+	"github.com/yourusername/health-dashboard-backend/tickets"
+)
+
+const (
+	minHeartbeatInterval = 60              // the watcher checks every 30s
+	maxHeartbeatInterval = 366 * 24 * 3600 // yearly jobs
+)
+
+// GetHeartbeats lists the heartbeat checks. Ping tokens are only shown to admins;
+// users restricted by tags only see heartbeats attached to their servers.
+func GetHeartbeats(c *fiber.Ctx) error {
+	visible, args := middleware.ServerVisibilityClause(c, "h.server_id")
+	rows, err := database.DB.Query(`
+		SELECT h.id, h.name, h.token, COALESCE(h.server_id, ''), COALESCE(s.hostname, ''), h.interval_seconds, h.grace_seconds,
+			h.status, COALESCE(h.last_ping, 0), COALESCE(h.down_since, 0), h.created_at
+		FROM heartbeats h
+		LEFT JOIN servers s ON s.id = h.server_id
+		WHERE `+visible+`
+		ORDER BY h.name
+	`, args...)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	isAdmin := c.Locals("role") == "admin"
+	list := []models.Heartbeat{}
+	for rows.Next() {
+		var h models.Heartbeat
+		if err := rows.Scan(&h.ID, &h.Name, &h.Token, &h.ServerID, &h.Hostname, &h.Interval, &h.Grace,
+			&h.Status, &h.LastPing, &h.DownSince, &h.CreatedAt); err != nil {
+			continue
+		}
+		if !isAdmin {
+			h.Token = ""
+		}
+		list = append(list, h)
+	}
+	return c.JSON(list)
+}
+
+// parseHeartbeat reads and validates a heartbeat from the request body
+func parseHeartbeat(c *fiber.Ctx) (models.Heartbeat, error) {
+	var h models.Heartbeat
+	if err := c.BodyParser(&h); err != nil {
+		return h, fmt.Errorf("Invalid request body")
+	}
+	h.Name = strings.TrimSpace(h.Name)
+	if h.Name == "" {
+		return h, fmt.Errorf("Name is required")
+	}
+	if h.Interval < minHeartbeatInterval || h.Interval > maxHeartbeatInterval {
+		return h, fmt.Errorf("Interval must be between %d seconds and 366 days", minHeartbeatInterval)
+	}
+	if h.Grace < 0 || h.Grace > maxHeartbeatInterval {
+		return h, fmt.Errorf("Grace must be between 0 seconds and 366 days")
+	}
+	if h.ServerID != "" {
+		var exists int
+		if err := database.DB.QueryRow("SELECT 1 FROM servers WHERE id = ?", h.ServerID).Scan(&exists); err != nil {
+			return h, fmt.Errorf("Server not found")
+		}
+	}
+	return h, nil
+}
+
+// CreateHeartbeat adds a heartbeat check with a new ping token
+func CreateHeartbeat(c *fiber.Ctx) error {
+	h, err := parseHeartbeat(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	h.Token = generateRandomToken(16)
+	h.Status = "new"
+	h.CreatedAt = time.Now().Unix()
+	res, err := database.DB.Exec(`
+		INSERT INTO heartbeats (name, token, server_id, interval_seconds, grace_seconds, status, created_at)
+		VALUES (?, ?, NULLIF(?, ''), ?, ?, 'new', ?)
+	`, h.Name, h.Token, h.ServerID, h.Interval, h.Grace, h.CreatedAt)
+	if err != nil {
+		log.Printf("❌ Failed to create heartbeat: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create heartbeat"})
+	}
+	h.ID, _ = res.LastInsertId()
+	recordAudit(c, "heartbeat.create", fmt.Sprintf("%d", h.ID), fmt.Sprintf("%s (every %ds)", h.Name, h.Interval))
+	return c.Status(201).JSON(h)
+}
+
+// UpdateHeartbeat changes a heartbeat's name, server, interval or grace; the token is kept
+func UpdateHeartbeat(c *fiber.Ctx) error {
+	h, err := parseHeartbeat(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	res, err := database.DB.Exec(`
+		UPDATE heartbeats SET name = ?, server_id = NULLIF(?, ''), interval_seconds = ?, grace_seconds = ?
+		WHERE id = ?
+	`, h.Name, h.ServerID, h.Interval, h.Grace, c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Heartbeat not found"})
+	}
+	recordAudit(c, "heartbeat.update", c.Params("id"), fmt.Sprintf("%s (every %ds)", h.Name, h.Interval))
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// DeleteHeartbeat removes a heartbeat check
+func DeleteHeartbeat(c *fiber.Ctx) error {
+	res, err := database.DB.Exec("DELETE FROM heartbeats WHERE id = ?", c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Heartbeat not found"})
+	}
+	recordAudit(c, "heartbeat.delete", c.Params("id"), "")
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// PingHeartbeat records a ping (public, authenticated by the token in the URL).
+// A ping on an overdue heartbeat resolves it.
+func PingHeartbeat(c *fiber.Ctx) error {
+	var id int64
+	var name, serverID, status string
+	var downSince int64
+	err := database.DB.QueryRow(`
+		SELECT id, name, COALESCE(server_id, ''), status, COALESCE(down_since, 0) FROM heartbeats WHERE token = ?
+	`, c.Params("token")).Scan(&id, &name, &serverID, &status, &downSince)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "Heartbeat not found"})
+	} else if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+
+	now := time.Now().Unix()
+	if _, err := database.DB.Exec("UPDATE heartbeats SET last_ping = ?, status = 'up', down_since = NULL WHERE id = ?", now, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+
+	if status == "down" {
+		msg := fmt.Sprintf("Heartbeat %s recovered after %s overdue", name, time.Duration(now-downSince)*time.Second)
+		where := ""
+		if serverID != "" {
+			hostname := getHostname(serverID)
+			where = " on " + hostname
+			details, _ := json.Marshal(fiber.Map{"heartbeat_id": id, "down_since": downSince})
+			if _, err := database.DB.Exec(`
+				INSERT INTO events (server_id, timestamp, event_type, severity, message, details)
+				VALUES (?, ?, 'heartbeat', 'info', ?, ?)
+			`, serverID, now, msg, string(details)); err != nil {
+				log.Printf("Failed to insert heartbeat event: %v", err)
+			}
+			go tickets.Resolve(serverID, fmt.Sprintf("heartbeat:%d", id), msg)
+		}
+		log.Printf("💓 %s", msg)
+		if Notifier != nil {
+			go Notifier.Notify(notifications.Notification{
+				Subject:   fmt.Sprintf("[RESOLVED] Heartbeat %s%s", name, where),
+				Message:   msg + ".",
+				Type:      notifications.TypeSuccess,
+				ServerID:  serverID,
+				EventType: "heartbeat",
+			})
+		}
+	}
+	return c.JSON(fiber.Map{"status": "ok"})
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/health"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// Events reporting a problem are incidents. They carry the key of the problem,
+// the same key its ticket uses ("cron:<command>", "port:<name>", "health:cpu"),
+// and stay open until the problem clears: a successful run of the job, the port
+// accepting connections again, the resource back within its threshold. The
+// push showing the recovery resolves them (resolved_at).
+
+// eventIncidentKey returns the incident key of an event, "" if it does not
+// report a problem that can clear
+func eventIncidentKey(eventType, severity, details string) string {
+	if severity == "info" {
+		return ""
+	}
+	switch eventType {
+	case "health":
+		var d struct {
+			CPU   *float64 `json:"cpu_percent"`
+			Mem   *float64 `json:"mem_percent"`
+			Disk  *float64 `json:"disk_percent"`
+			Mount string   `json:"mount"`
+		}
+		json.Unmarshal([]byte(details), &d)
+		switch {
+		case d.CPU != nil:
+			return "health:cpu"
+		case d.Mem != nil:
+			return "health:memory"
+		case d.Disk != nil && d.Mount != "":
+			return "health:disk:" + d.Mount
+		case d.Disk != nil:
+			return "health:disk"
+		}
+	case "port":
+		var d struct {
+			Name string `json:"name"`
+		}
+		json.Unmarshal([]byte(details), &d)
+		if d.Name != "" {
+			return "port:" + d.Name
+		}
+	case "cron", "cron_error":
+		if command := cronEventCommand(details); command != "" {
+			return "cron:" + command
+		}
+	}
+	return ""
+}
+
+// setIncidentState fills in whether an incident event is open or resolved
+func setIncidentState(e *models.Event) {
+	if e.IncidentKey == "" {
+		return
+	}
+	e.State = "open"
+	if e.ResolvedAt > 0 {
+		e.State = "resolved"
+	}
+}
+
+// resolveIncident resolves the server's open events with the key that were
+// reported before the recovery at
+func resolveIncident(serverID, key string, at int64) int64 {
+	res, err := database.DB.Exec(`
+		UPDATE events SET resolved_at = ?
+		WHERE server_id = ? AND incident_key = ? AND resolved_at IS NULL AND timestamp <= ?
+	`, at, serverID, key, at)
+	if err != nil {
+		log.Printf("Failed to resolve %s events: %v", key, err)
+		return 0
+	}
+	n, _ := res.RowsAffected()
+	return n
+}
+
+// resolveHealthIncidents resolves the server's open health events whose
+// resource is back within the threshold the agent reported it against
+func resolveHealthIncidents(serverID string, at int64) {
+	rows, err := database.DB.Query(`
+		SELECT incident_key, COALESCE(details, '') FROM events
+		WHERE server_id = ? AND event_type = 'health' AND incident_key IS NOT NULL AND resolved_at IS NULL
+	`, serverID)
+	if err != nil {
+		return
+	}
+	thresholds := make(map[string]float64)
+	for rows.Next() {
+		var key, details string
+		var d struct {
+			Threshold *float64 `json:"threshold"`
+		}
+		if rows.Scan(&key, &details) == nil && json.Unmarshal([]byte(details), &d) == nil && d.Threshold != nil {
+			thresholds[key] = *d.Threshold
+		}
+	}
+	rows.Close()
+	if len(thresholds) == 0 {
+		return
+	}
+
+	metrics, err := health.GetHealthMetricsForServer(serverID)
+	if err != nil || metrics.IsOffline {
+		return
+	}
+	mounts := health.ServerDiskMounts(serverID)
+	for key, threshold := range thresholds {
+		var value float64
+		switch {
+		case key == "health:cpu":
+			value = metrics.CPUPercent
+		case key == "health:memory":
+			value = metrics.MemoryPercent
+		case key == "health:disk":
+			value = metrics.DiskPercent
+		case strings.HasPrefix(key, "health:disk:"):
+			mount, found := strings.TrimPrefix(key, "health:disk:"), false
+			for _, m := range mounts {
+				if m.Mount == mount {
+					value, found = m.Percent, true
+				}
+			}
+			if !found {
+				continue // not reported anymore; it cannot be told whether it cleared
+			}
+		default:
+			continue
+		}
+		if value <= threshold {
+			resolveIncident(serverID, key, at)
+		}
+	}
+}
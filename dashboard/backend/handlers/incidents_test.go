@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+func TestIncidentResolution(t *testing.T) {
+	testDB(t)
+	seedServer(t, "s1", "web-1")
+
+	b := &ingestBatch{}
+	b.addEvent("s1", 1000, "health", "warning", "High CPU usage", `{"cpu_percent": 95.0, "threshold": 80}`)
+	b.addEvent("s1", 1000, "health", "warning", "High Memory usage", `{"mem_percent": 92.0, "threshold": 85}`)
+	b.addEvent("s1", 1000, "port", "critical", "nginx is down", `{"name": "nginx"}`)
+	b.addEvent("s1", 1000, "security", "info", "sudo session", "")
+	if err := ingest(b); err != nil {
+		t.Fatal(err)
+	}
+	insertCronEvent("s1", 1000, "cron", "critical", "backup failed", `{"command": "/usr/local/bin/backup"}`)
+
+	// CPU is back below 80%, memory is still above 85%
+	mustExec(t, `INSERT INTO metrics (server_id, timestamp, cpu_percent, mem_total_mb, mem_used_mb, disk_total_gb, disk_used_gb) VALUES ('s1', strftime('%s', 'now'), 40, 1000, 900, 100, 10)`)
+	resolveHealthIncidents("s1", 1100)
+	if n := resolveIncident("s1", "port:nginx", 1100); n != 1 {
+		t.Errorf("resolved %d port events, want 1", n)
+	}
+	resolveIncident("s1", "cron:/usr/local/bin/backup", 1100)
+	// A recovery reported before the failure does not resolve it
+	if n := resolveIncident("s1", "health:memory", 900); n != 0 {
+		t.Errorf("resolved %d memory events with an older recovery", n)
+	}
+
+	app := fiber.New()
+	app.Get("/servers/:id/events", GetServerEvents)
+	list := func(url string) map[string]models.Event {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", url, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var events []models.Event
+		json.NewDecoder(resp.Body).Decode(&events)
+		byMessage := make(map[string]models.Event)
+		for _, e := range events {
+			byMessage[e.Message] = e
+		}
+		return byMessage
+	}
+
+	events := list("/servers/s1/events")
+	want := map[string]string{
+		"High CPU usage":    "resolved",
+		"High Memory usage": "open",
+		"nginx is down":     "resolved",
+		"backup failed":     "resolved",
+		"sudo session":      "",
+	}
+	for msg, state := range want {
+		if events[msg].State != state {
+			t.Errorf("%s: state %q, want %q", msg, events[msg].State, state)
+		}
+	}
+	if e := events["nginx is down"]; e.ResolvedAt != 1100 || e.IncidentKey != "port:nginx" {
+		t.Errorf("port event = %+v", e)
+	}
+	if open := list("/servers/s1/events?state=open"); len(open) != 1 {
+		t.Errorf("open events = %v, want the memory event", open)
+	}
+}
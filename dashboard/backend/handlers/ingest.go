@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"database/sql"
+	"sync"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+// Metric and event rows pushed by agents are written by a single ingest
+// writer with group commit: it takes the first waiting push and whatever
+// else queued up behind it, and stores them in one transaction with prepared
+// statements. A lone push is written right away; a reconnect flood of queued
+// agents becomes a few large transactions instead of thousands of small
+// ones. Pushes wait for their commit, so health evaluation that follows
+// sees the new rows.
+//
+// Drift and cron failure events identical to an unacknowledged event of the
+// last hour (a re-sent queue, a change reported over and over) are not stored
+// again: they are counted on that event (occurrences, first_seen, last_seen)
+// and marked merged, so they are not notified about either.
+
+// maxIngestGroup caps the pushes committed together
+const maxIngestGroup = 256
+
+const insertMetricSQL = `
+	INSERT INTO metrics (server_id, timestamp, cpu_percent, mem_total_mb, mem_used_mb, disk_total_gb, disk_used_gb, load_avg_1, load_avg_5, load_avg_15, process_count, processes, uptime)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+const insertEventSQL = `
+	INSERT INTO events (server_id, timestamp, event_type, severity, message, details, incident_key, occurrences, first_seen, last_seen)
+	VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''), ?, ?, ?)`
+
+// mergeEventSQL counts an event on the latest identical one within the window
+const mergeEventSQL = `
+	UPDATE events SET occurrences = occurrences + ?,
+		first_seen = MIN(COALESCE(first_seen, timestamp), ?),
+		last_seen = MAX(COALESCE(last_seen, timestamp), ?)
+	WHERE id = (
+		SELECT id FROM events
+		WHERE server_id = ? AND event_type = ? AND severity = ? AND message = ? AND COALESCE(details, '') = ?
+			AND COALESCE(acknowledged, 0) = 0 AND resolved_at IS NULL
+			AND ? BETWEEN COALESCE(first_seen, timestamp) - ? AND COALESCE(last_seen, timestamp) + ?
+		ORDER BY id DESC LIMIT 1
+	)`
+
+// repeatWindow is how far apart repeats of an event are merged
+const repeatWindow = 3600
+
+// mergedEventTypes are the event types whose repeats are merged
+var mergedEventTypes = map[string]bool{"drift": true, "cron": true, "cron_error": true}
+
+// ingestBatch is the rows of one push, stored together or not at all
+type ingestBatch struct {
+	metrics [][]interface{} // insertMetricSQL arguments
+	events  []*ingestEvent
+	done    chan error
+}
+
+// ingestEvent is an event row of a push
+type ingestEvent struct {
+	serverID    string
+	timestamp   int64
+	eventType   string
+	severity    string
+	message     interface{}
+	details     string
+	occurrences int   // Times it happened (repeats the agent queued while offline)
+	lastSeen    int64 // Time of the last of them
+	merged      bool  // Set by the writer: counted on an earlier identical event
+}
+
+func (b *ingestBatch) addMetric(args ...interface{}) {
+	b.metrics = append(b.metrics, args)
+}
+
+// addEvent queues an event that happened once; events reporting a problem open
+// an incident
+func (b *ingestBatch) addEvent(serverID string, timestamp int64, eventType, severity string, message interface{}, details string) *ingestEvent {
+	e := &ingestEvent{serverID: serverID, timestamp: timestamp, eventType: eventType, severity: severity,
+		message: message, details: details, occurrences: 1, lastSeen: timestamp}
+	b.events = append(b.events, e)
+	return e
+}
+
+var (
+	ingestQueue     = make(chan *ingestBatch, maxIngestGroup)
+	ingestWriterRun sync.Once
+)
+
+// ingest stores a push's rows and waits for the commit
+func ingest(b *ingestBatch) error {
+	if len(b.metrics) == 0 && len(b.events) == 0 {
+		return nil
+	}
+	ingestWriterRun.Do(func() { go runIngestWriter() })
+	b.done = make(chan error, 1)
+	ingestQueue <- b
+	return <-b.done
+}
+
+func runIngestWriter() {
+	for first := range ingestQueue {
+		group := []*ingestBatch{first}
+	drain:
+		for len(group) < maxIngestGroup {
+			select {
+			case b := <-ingestQueue:
+				group = append(group, b)
+			default:
+				break drain
+			}
+		}
+
+		if err := writeIngestGroup(group); err == nil || len(group) == 1 {
+			for _, b := range group {
+				b.done <- err
+			}
+			continue
+		}
+		// One bad push must not fail the others: retry them one by one
+		for _, b := range group {
+			b.done <- writeIngestGroup([]*ingestBatch{b})
+		}
+	}
+}
+
+// writeIngestGroup stores the rows of several pushes in one transaction
+func writeIngestGroup(group []*ingestBatch) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var metricStmt, eventStmt, mergeStmt *sql.Stmt
+	for _, b := range group {
+		for _, args := range b.metrics {
+			if metricStmt == nil {
+				if metricStmt, err = tx.Prepare(insertMetricSQL); err != nil {
+					return err
+				}
+				defer metricStmt.Close()
+			}
+			if _, err := metricStmt.Exec(args...); err != nil {
+				return err
+			}
+		}
+		for _, e := range b.events {
+			e.merged = false
+			if mergedEventTypes[e.eventType] && e.severity != "info" {
+				if mergeStmt == nil {
+					if mergeStmt, err = tx.Prepare(mergeEventSQL); err != nil {
+						return err
+					}
+					defer mergeStmt.Close()
+				}
+				res, err := mergeStmt.Exec(e.occurrences, e.timestamp, e.lastSeen,
+					e.serverID, e.eventType, e.severity, e.message, e.details, e.timestamp, repeatWindow, repeatWindow)
+				if err != nil {
+					return err
+				}
+				if n, _ := res.RowsAffected(); n > 0 {
+					e.merged = true
+					continue
+				}
+			}
+
+			if eventStmt == nil {
+				if eventStmt, err = tx.Prepare(insertEventSQL); err != nil {
+					return err
+				}
+				defer eventStmt.Close()
+			}
+			_, err := eventStmt.Exec(e.serverID, e.timestamp, e.eventType, e.severity, e.message, e.details,
+				eventIncidentKey(e.eventType, e.severity, e.details), e.occurrences, e.timestamp, e.lastSeen)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
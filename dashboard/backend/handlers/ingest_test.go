@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+func TestIngestGroupCommit(t *testing.T) {
+	testDB(t)
+	seedServer(t, "s1", "web-1")
+
+	const pushes = 50
+	errs := make([]error, pushes+1)
+	var wg sync.WaitGroup
+	for i := 0; i < pushes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			b := &ingestBatch{}
+			b.addMetric("s1", int64(1000+i), 12.5, 1024, 512, 100, 40, 0.5, 0.4, 0.3, 120, "", 3600)
+			b.addEvent("s1", int64(1000+i), "health", "info", "ok", "")
+			errs[i] = ingest(b)
+		}(i)
+	}
+	// A push with an invalid row (events.message is NOT NULL) fails alone
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		b := &ingestBatch{}
+		b.addMetric("s1", int64(5000), 1.0, 1, 1, 1, 1, 0.0, 0.0, 0.0, 1, "", 1)
+		b.addEvent("s1", int64(5000), "health", "info", nil, "")
+		errs[pushes] = ingest(b)
+	}()
+	wg.Wait()
+
+	for i, err := range errs[:pushes] {
+		if err != nil {
+			t.Errorf("push %d: %v", i, err)
+		}
+	}
+	if errs[pushes] == nil {
+		t.Error("invalid push was stored")
+	}
+
+	var metrics, events int
+	database.DB.QueryRow(`SELECT COUNT(*) FROM metrics`).Scan(&metrics)
+	database.DB.QueryRow(`SELECT COUNT(*) FROM events`).Scan(&events)
+	if metrics != pushes || events != pushes {
+		t.Errorf("stored %d metrics and %d events, want %d of each", metrics, events, pushes)
+	}
+}
+
+func TestIngestMergesRepeats(t *testing.T) {
+	testDB(t)
+	seedServer(t, "s1", "web-1")
+
+	push := func(events func(b *ingestBatch)) *ingestBatch {
+		t.Helper()
+		b := &ingestBatch{}
+		events(b)
+		if err := ingest(b); err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	push(func(b *ingestBatch) {
+		b.addEvent("s1", 1000, "drift", "warning", "/etc/ssh/sshd_config modified", "{}")
+		b.addEvent("s1", 1000, "health", "info", "ok", "")
+	})
+	// A re-sent queue: the same drift twice (once queued repeatedly), and unrelated events
+	b := push(func(b *ingestBatch) {
+		b.addEvent("s1", 1000, "drift", "warning", "/etc/ssh/sshd_config modified", "{}")
+		repeated := b.addEvent("s1", 1200, "drift", "warning", "/etc/ssh/sshd_config modified", "{}")
+		repeated.occurrences, repeated.lastSeen = 3, 1900
+		b.addEvent("s1", 1000, "health", "info", "ok", "")
+		b.addEvent("s1", 1300, "drift", "warning", "/etc/hosts modified", "{}")
+	})
+	if merged := []bool{b.events[0].merged, b.events[1].merged, b.events[2].merged, b.events[3].merged}; !merged[0] || !merged[1] || merged[2] || merged[3] {
+		t.Errorf("merged = %v, want the repeated drift only", merged)
+	}
+
+	var occurrences int
+	var firstSeen, lastSeen int64
+	database.DB.QueryRow(`SELECT occurrences, first_seen, last_seen FROM events WHERE message = '/etc/ssh/sshd_config modified'`).Scan(&occurrences, &firstSeen, &lastSeen)
+	if occurrences != 5 || firstSeen != 1000 || lastSeen != 1900 {
+		t.Errorf("occurrences %d from %d to %d, want 5 from 1000 to 1900", occurrences, firstSeen, lastSeen)
+	}
+
+	// Acknowledged or outside the window, it is a new event
+	mustExec(t, `UPDATE events SET acknowledged = 1 WHERE message = '/etc/hosts modified'`)
+	push(func(b *ingestBatch) {
+		b.addEvent("s1", 1400, "drift", "warning", "/etc/hosts modified", "{}")
+		b.addEvent("s1", 1900+repeatWindow+1, "drift", "warning", "/etc/ssh/sshd_config modified", "{}")
+	})
+	var rows int
+	database.DB.QueryRow(`SELECT COUNT(*) FROM events WHERE event_type = 'drift'`).Scan(&rows)
+	if rows != 4 {
+		t.Errorf("%d drift events, want 4", rows)
+	}
+}
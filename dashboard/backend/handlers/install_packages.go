@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+// InstallPackage is a generated install script and the hosts it onboarded
+type InstallPackage struct {
+	ID        string               `json:"id"`
+	Label     string               `json:"label"`
+	Tags      []string             `json:"tags"`
+	CreatedAt int64                `json:"created_at"`
+	CreatedIP string               `json:"created_ip"`
+	RevokedAt int64                `json:"revoked_at,omitempty"`
+	RevokedBy string               `json:"revoked_by,omitempty"`
+	Servers   []InstallPackageHost `json:"servers"`
+}
+
+// InstallPackageHost is a server registered through an install package
+type InstallPackageHost struct {
+	ID        string `json:"id"`
+	Hostname  string `json:"hostname"`
+	FirstSeen int64  `json:"first_seen"`
+}
+
+// createInstallPackage records a generated install script and returns its ID.
+// Servers it onboards get its tags.
+func createInstallPackage(label, ip string, tags []string) (string, error) {
+	id := fmt.Sprintf("pkg-%d", time.Now().UnixNano())
+	if len(label) > 128 {
+		label = label[:128]
+	}
+	_, err := database.DB.Exec(`
+		INSERT INTO agent_packages (id, label, created_at, created_ip, tags)
+		VALUES (?, ?, ?, ?, NULLIF(?, ''))
+	`, id, label, time.Now().Unix(), ip, strings.Join(tags, ","))
+	return id, err
+}
+
+// applyInstallPackageTags gives a newly registered server its package's tags
+func applyInstallPackageTags(serverID, packageID string) error {
+	var raw string
+	if err := database.DB.QueryRow("SELECT COALESCE(tags, '') FROM agent_packages WHERE id = ?", packageID).Scan(&raw); err != nil || raw == "" {
+		return err
+	}
+	return addTags(serverID, strings.Split(raw, ","))
+}
+
+// checkInstallPackage rejects unknown and revoked package IDs at registration
+func checkInstallPackage(id string) error {
+	var revokedAt int64
+	err := database.DB.QueryRow("SELECT COALESCE(revoked_at, 0) FROM agent_packages WHERE id = ?", id).Scan(&revokedAt)
+	if err == sql.ErrNoRows {
+		return errors.New("Unknown install package")
+	} else if err != nil {
+		return errors.New("Failed to verify install package")
+	}
+	if revokedAt != 0 {
+		return errors.New("Install package revoked")
+	}
+	return nil
+}
+
+// serverPackageRevoked reports whether the server was onboarded by a revoked package
+func serverPackageRevoked(serverID string) bool {
+	var revoked int
+	err := database.DB.QueryRow(`
+		SELECT 1 FROM servers s
+		JOIN agent_packages p ON p.id = s.package_id
+		WHERE s.id = ? AND COALESCE(p.revoked_at, 0) != 0
+	`, serverID).Scan(&revoked)
+	return err == nil
+}
+
+// GetInstallPackages lists generated install packages with the hosts each onboarded
+func GetInstallPackages(c *fiber.Ctx) error {
+	rows, err := database.DB.Query(`
+		SELECT id, COALESCE(label, ''), COALESCE(tags, ''), created_at, COALESCE(created_ip, ''), COALESCE(revoked_at, 0), COALESCE(revoked_by, '')
+		FROM agent_packages
+		ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	packages := []InstallPackage{}
+	index := make(map[string]int)
+	for rows.Next() {
+		var p InstallPackage
+		var tags string
+		if err := rows.Scan(&p.ID, &p.Label, &tags, &p.CreatedAt, &p.CreatedIP, &p.RevokedAt, &p.RevokedBy); err != nil {
+			continue
+		}
+		p.Tags = []string{}
+		if tags != "" {
+			p.Tags = strings.Split(tags, ",")
+		}
+		p.Servers = []InstallPackageHost{}
+		index[p.ID] = len(packages)
+		packages = append(packages, p)
+	}
+
+	hosts, err := database.DB.Query(`
+		SELECT package_id, id, hostname, first_seen
+		FROM servers
+		WHERE package_id IS NOT NULL
+		ORDER BY first_seen
+	`)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer hosts.Close()
+	for hosts.Next() {
+		var packageID string
+		var h InstallPackageHost
+		if err := hosts.Scan(&packageID, &h.ID, &h.Hostname, &h.FirstSeen); err != nil {
+			continue
+		}
+		if i, ok := index[packageID]; ok {
+			packages[i].Servers = append(packages[i].Servers, h)
+		}
+	}
+
+	return c.JSON(packages)
+}
+
+// RevokeInstallPackage blocks new registrations from a package and locks out
+// every agent it onboarded (their pushes are rejected until deleted and reinstalled)
+func RevokeInstallPackage(c *fiber.Ctx) error {
+	id := c.Params("id")
+	username, _ := c.Locals("username").(string)
+
+	res, err := database.DB.Exec(`
+		UPDATE agent_packages SET revoked_at = ?, revoked_by = ?
+		WHERE id = ? AND COALESCE(revoked_at, 0) = 0
+	`, time.Now().Unix(), username, id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Install package not found or already revoked"})
+	}
+
+	var affected int
+	database.DB.QueryRow("SELECT COUNT(*) FROM servers WHERE package_id = ?", id).Scan(&affected)
+
+	recordAudit(c, "install_package.revoke", id, fmt.Sprintf("%d server(s) locked out", affected))
+	return c.JSON(fiber.Map{"status": "revoked", "servers": affected})
+}
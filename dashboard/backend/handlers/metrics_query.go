@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"database/sql"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/maintenance"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// maxQueryPoints caps the buckets one metrics query may return
+const maxQueryPoints = 10000
+
+// defaultQueryPoints is roughly how many buckets a query without ?step= gets
+const defaultQueryPoints = 500
+
+// metricAggregations maps ?aggregation= to the SQL aggregate; p95 is
+// computed here since SQLite has no percentile function
+var metricAggregations = map[string]string{
+	"avg": "AVG",
+	"max": "MAX",
+	"min": "MIN",
+	"p95": "",
+}
+
+// metricQueryColumns are the aggregated columns, in models.MetricPoint order
+var metricQueryColumns = []string{
+	"cpu_percent", "mem_total_mb", "mem_used_mb", "disk_total_gb", "disk_used_gb",
+	"load_avg_1", "load_avg_5", "load_avg_15", "process_count",
+}
+
+// QueryServerMetrics aggregates a server's raw metrics into fixed buckets,
+// reading samples past the metrics retention from the metrics archives. Query: start, end (unix seconds, default the last 24 hours), step (seconds,
+// default range/500 rounded up to a minute) and aggregation (avg, max, min,
+// p95; default avg). Buckets start at start; empty buckets are left out.
+func QueryServerMetrics(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+	end := int64(c.QueryInt("end", int(time.Now().Unix())))
+	start := int64(c.QueryInt("start", int(end-24*3600)))
+	if start < 0 || end <= start {
+		return c.Status(400).JSON(fiber.Map{"error": "start must be before end"})
+	}
+
+	step := int64(c.QueryInt("step", 0))
+	if step == 0 {
+		step = (end - start + defaultQueryPoints - 1) / defaultQueryPoints
+		step = (step + 59) / 60 * 60
+	}
+	if step < 10 {
+		return c.Status(400).JSON(fiber.Map{"error": "step must be at least 10 seconds"})
+	}
+	if (end-start)/step >= maxQueryPoints {
+		return c.Status(400).JSON(fiber.Map{"error": "Too many points: use a larger step or a shorter range"})
+	}
+
+	aggregation := c.Query("aggregation", "avg")
+	sqlAggregate, ok := metricAggregations[aggregation]
+	if !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "aggregation must be avg, max, min or p95"})
+	}
+
+	var points []models.MetricPoint
+	var err error
+	if sqlAggregate != "" {
+		points, err = aggregateMetrics(serverID, start, end, step, sqlAggregate)
+	} else {
+		points, err = percentileMetrics(serverID, start, end, step, 0.95)
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+
+	return c.JSON(fiber.Map{
+		"server_id":   serverID,
+		"start":       start,
+		"end":         end,
+		"step":        step,
+		"aggregation": aggregation,
+		"points":      points,
+	})
+}
+
+// pointFields returns the addresses of a point's aggregated values, in
+// metricQueryColumns order
+func pointFields(p *models.MetricPoint) []*float64 {
+	return []*float64{&p.CPUPercent, &p.MemTotalMB, &p.MemUsedMB, &p.DiskTotalGB, &p.DiskUsedGB,
+		&p.LoadAvg1, &p.LoadAvg5, &p.LoadAvg15, &p.ProcessCount}
+}
+
+// eachMetricSource calls fn with the databases holding a server's raw
+// metrics in [start, end), oldest first, and the part of the range to read
+// from each: with archiving enabled, the monthly archives up to the oldest
+// sample still in the database, then the database itself. The parts do not
+// overlap, so rows ordered by time within each are ordered overall.
+func eachMetricSource(serverID string, start, end int64, fn func(db *sql.DB, from, to int64) error) error {
+	liveFrom := start
+	if maintenance.MetricsArchiveEnabled() {
+		var oldest sql.NullInt64
+		if err := database.DB.QueryRow("SELECT MIN(timestamp) FROM metrics WHERE server_id = ?", serverID).Scan(&oldest); err != nil {
+			return err
+		}
+		liveFrom = end
+		if oldest.Valid && oldest.Int64 < end {
+			liveFrom = max(oldest.Int64, start)
+		}
+		if liveFrom > start {
+			if err := maintenance.EachMetricsArchive(start, liveFrom, func(db *sql.DB) error {
+				return fn(db, start, liveFrom)
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return fn(database.DB, liveFrom, end)
+}
+
+// aggregateMetrics computes an SQL aggregate per bucket of [start, end). Each
+// source returns partial aggregates (sums and counts for AVG) which are
+// merged, since a bucket can span an archive and the database.
+func aggregateMetrics(serverID string, start, end, step int64, aggregate string) ([]models.MetricPoint, error) {
+	partial := aggregate
+	if aggregate == "AVG" {
+		partial = "SUM"
+	}
+	query := `SELECT ?1 + (timestamp - ?1) / ?3 * ?3, COUNT(*)`
+	for _, col := range metricQueryColumns {
+		query += `, ` + partial + `(` + col + `), COUNT(` + col + `)`
+	}
+	query += `
+		FROM metrics
+		WHERE server_id = ?4 AND timestamp >= ?5 AND timestamp < ?6
+		GROUP BY 1`
+
+	type bucket struct {
+		point  models.MetricPoint
+		values []float64
+		counts []int64
+	}
+	buckets := map[int64]*bucket{}
+	err := eachMetricSource(serverID, start, end, func(db *sql.DB, from, to int64) error {
+		rows, err := db.Query(query, start, end, step, serverID, from, to)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		values := make([]sql.NullFloat64, len(metricQueryColumns))
+		counts := make([]int64, len(metricQueryColumns))
+		for rows.Next() {
+			var timestamp int64
+			var samples int
+			dest := []interface{}{&timestamp, &samples}
+			for i := range values {
+				dest = append(dest, &values[i], &counts[i])
+			}
+			if err := rows.Scan(dest...); err != nil {
+				return err
+			}
+
+			b := buckets[timestamp]
+			if b == nil {
+				b = &bucket{
+					point:  models.MetricPoint{Timestamp: timestamp},
+					values: make([]float64, len(metricQueryColumns)),
+					counts: make([]int64, len(metricQueryColumns)),
+				}
+				buckets[timestamp] = b
+			}
+			b.point.Samples += samples
+			for i, v := range values {
+				if counts[i] == 0 {
+					continue
+				}
+				switch {
+				case b.counts[i] == 0, aggregate == "AVG":
+					b.values[i] += v.Float64
+				case aggregate == "MIN":
+					b.values[i] = math.Min(b.values[i], v.Float64)
+				case aggregate == "MAX":
+					b.values[i] = math.Max(b.values[i], v.Float64)
+				}
+				b.counts[i] += counts[i]
+			}
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	points := make([]models.MetricPoint, 0, len(buckets))
+	for _, b := range buckets {
+		for i, field := range pointFields(&b.point) {
+			*field = b.values[i]
+			if aggregate == "AVG" && b.counts[i] > 0 {
+				*field /= float64(b.counts[i])
+			}
+		}
+		points = append(points, b.point)
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Timestamp < points[j].Timestamp })
+	return points, nil
+}
+
+// percentileMetrics computes the nearest-rank percentile per bucket of [start, end)
+func percentileMetrics(serverID string, start, end, step int64, percentile float64) ([]models.MetricPoint, error) {
+	query := `SELECT ?1 + (timestamp - ?1) / ?3 * ?3`
+	for _, col := range metricQueryColumns {
+		query += `, ` + col
+	}
+	query += `
+		FROM metrics
+		WHERE server_id = ?4 AND timestamp >= ?5 AND timestamp < ?6
+		ORDER BY timestamp`
+
+	points := []models.MetricPoint{}
+	samples := make([][]float64, len(metricQueryColumns))
+	flush := func() {
+		if len(points) == 0 {
+			return
+		}
+		for i, field := range pointFields(&points[len(points)-1]) {
+			*field = nearestRank(samples[i], percentile)
+			samples[i] = samples[i][:0]
+		}
+	}
+
+	values := make([]*float64, len(metricQueryColumns))
+	err := eachMetricSource(serverID, start, end, func(db *sql.DB, from, to int64) error {
+		rows, err := db.Query(query, start, end, step, serverID, from, to)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var bucket int64
+			dest := []interface{}{&bucket}
+			for i := range values {
+				dest = append(dest, &values[i])
+			}
+			if err := rows.Scan(dest...); err != nil {
+				return err
+			}
+			if len(points) == 0 || points[len(points)-1].Timestamp != bucket {
+				flush()
+				points = append(points, models.MetricPoint{Timestamp: bucket})
+			}
+			points[len(points)-1].Samples++
+			for i, v := range values {
+				if v != nil {
+					samples[i] = append(samples[i], *v)
+				}
+			}
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	flush()
+	return points, nil
+}
+
+// nearestRank returns the p-th percentile (0-1) of values, 0 if there are none
+func nearestRank(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	rank := int(math.Ceil(p*float64(len(values)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return values[rank]
+}
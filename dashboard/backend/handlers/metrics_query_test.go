@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+func TestQueryServerMetrics(t *testing.T) {
+	testDB(t)
+	seedServer(t, "s1", "web-1")
+	// Two buckets of 100s: CPU 1..20 and 21..40, one sample every 5s
+	for i := 0; i < 40; i++ {
+		mustExec(t, `INSERT INTO metrics (server_id, timestamp, cpu_percent, mem_used_mb) VALUES ('s1', ?, ?, 512)`, 1000+int64(i)*5, float64(i+1))
+	}
+
+	app := fiber.New()
+	app.Get("/servers/:id/metrics/query", QueryServerMetrics)
+
+	query := func(url string) (int, []models.MetricPoint) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", url, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Points []models.MetricPoint `json:"points"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body.Points
+	}
+
+	for aggregation, want := range map[string][2]float64{
+		"avg": {10.5, 30.5},
+		"max": {20, 40},
+		"min": {1, 21},
+		"p95": {19, 39},
+	} {
+		status, points := query("/servers/s1/metrics/query?start=1000&end=1200&step=100&aggregation=" + aggregation)
+		if status != 200 || len(points) != 2 {
+			t.Fatalf("%s: status %d, %d points", aggregation, status, len(points))
+		}
+		for i, p := range points {
+			if p.Timestamp != 1000+int64(i)*100 || p.Samples != 20 || p.CPUPercent != want[i] || p.MemUsedMB != 512 {
+				t.Errorf("%s: point %d = %+v, want cpu %v", aggregation, i, p, want[i])
+			}
+		}
+	}
+
+	for _, url := range []string{
+		"/servers/s1/metrics/query?start=2000&end=1000",
+		"/servers/s1/metrics/query?start=0&end=1000000&step=10",
+		"/servers/s1/metrics/query?aggregation=median",
+	} {
+		if status, _ := query(url); status != 400 {
+			t.Errorf("%s: status %d, want 400", url, status)
+		}
+	}
+}
+
+func TestQueryServerMetricsReadsArchives(t *testing.T) {
+	testDB(t)
+	seedServer(t, "s1", "web-1")
+	// Past the retention: 10 and 20 in the archive, 30 and 40 still in the database
+	start := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC).Unix()
+	seedMetricsArchive(t, start,
+		[4]interface{}{"s1", start, 10.0, ""},
+		[4]interface{}{"s1", start + 60, 20.0, ""},
+	)
+	for i, cpu := range []float64{30, 40} {
+		mustExec(t, `INSERT INTO metrics (server_id, timestamp, cpu_percent) VALUES ('s1', ?, ?)`, start+120+int64(i)*60, cpu)
+	}
+
+	app := fiber.New()
+	app.Get("/servers/:id/metrics/query", QueryServerMetrics)
+	for aggregation, want := range map[string]float64{"avg": 25, "max": 40, "min": 10, "p95": 40} {
+		url := fmt.Sprintf("/servers/s1/metrics/query?start=%d&end=%d&step=3600&aggregation=%s", start, start+3600, aggregation)
+		resp, err := app.Test(httptest.NewRequest("GET", url, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Points []models.MetricPoint `json:"points"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		if len(body.Points) != 1 || body.Points[0].Samples != 4 || body.Points[0].CPUPercent != want {
+			t.Errorf("%s: points = %+v, want one bucket of 4 samples with cpu %v", aggregation, body.Points, want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+// Agents leave the process list out of a push when it has not changed and
+// send cron jobs as changes against the previous push. Every push carries a
+// sequence number within the agent's session (its start time); a full sync
+// is sent periodically, after failed pushes and whenever the dashboard asks
+// for one because it missed a push.
+
+// metricsSync is the "sync" block of a metrics push
+type metricsSync struct {
+	Session int64 `json:"session"`
+	Seq     int64 `json:"seq"`
+	Full    bool  `json:"full"`
+}
+
+// cronJobsDelta is the "cron_jobs_delta" block of a metrics push
+type cronJobsDelta struct {
+	Upsert  []json.RawMessage `json:"upsert"`
+	Removed []string          `json:"removed"`
+}
+
+var (
+	syncMu    sync.Mutex
+	syncState = make(map[string]metricsSync) // last accepted push per server
+)
+
+// checkMetricsSync decides whether the cron job state of a push is applied
+// (apply) and whether the agent must send a full sync next (fullSync).
+// Pushes without a sync block come from older agents and are always full.
+// Stale pushes (queued and replayed late) must not overwrite newer state.
+func checkMetricsSync(serverID string, raw interface{}) (apply, fullSync bool) {
+	if raw == nil {
+		return true, false
+	}
+	var s metricsSync
+	data, _ := json.Marshal(raw)
+	if err := json.Unmarshal(data, &s); err != nil || s.Session == 0 {
+		return true, false
+	}
+
+	syncMu.Lock()
+	defer syncMu.Unlock()
+	last, known := syncState[serverID]
+	sameSession := known && last.Session == s.Session
+	switch {
+	case sameSession && s.Seq <= last.Seq:
+		return false, false // stale
+	case s.Full, sameSession && s.Seq == last.Seq+1:
+		syncState[serverID] = s
+		return true, false
+	default:
+		return false, true // missed a push (or the dashboard restarted)
+	}
+}
+
+// applyCronJobsDelta updates the stored cron job list with the jobs added,
+// changed or removed since the previous push
+func applyCronJobsDelta(serverID string, raw interface{}) error {
+	var delta cronJobsDelta
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &delta); err != nil {
+		return err
+	}
+	if len(delta.Upsert) == 0 && len(delta.Removed) == 0 {
+		return nil
+	}
+
+	var stored string
+	database.DB.QueryRow("SELECT COALESCE(seen_cron_jobs, '') FROM servers WHERE id = ?", serverID).Scan(&stored)
+	var jobs []json.RawMessage
+	if stored != "" {
+		json.Unmarshal([]byte(stored), &jobs)
+	}
+
+	removed := make(map[string]bool, len(delta.Removed)+len(delta.Upsert))
+	for _, cmd := range delta.Removed {
+		removed[cmd] = true
+	}
+	for _, job := range delta.Upsert {
+		removed[jobCommand(job)] = true // replaced below
+	}
+	merged := make([]json.RawMessage, 0, len(jobs)+len(delta.Upsert))
+	for _, job := range jobs {
+		if !removed[jobCommand(job)] {
+			merged = append(merged, job)
+		}
+	}
+	merged = append(merged, delta.Upsert...)
+
+	out, err := json.Marshal(merged)
+	if err != nil {
+		return err
+	}
+	_, err = database.DB.Exec("UPDATE servers SET seen_cron_jobs = ? WHERE id = ?", string(out), serverID)
+	return err
+}
+
+// jobCommand returns the command identifying a cron job record
+func jobCommand(job json.RawMessage) string {
+	var r struct {
+		Command string
+	}
+	json.Unmarshal(job, &r)
+	return r.Command
+}
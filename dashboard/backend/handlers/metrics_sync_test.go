@@ -0,0 +1,29 @@
+package handlers
+
+import "testing"
+
+func TestCheckMetricsSync(t *testing.T) {
+	sync := func(session, seq int64, full bool) interface{} {
+		return map[string]interface{}{"session": session, "seq": seq, "full": full}
+	}
+	steps := []struct {
+		name            string
+		raw             interface{}
+		apply, fullSync bool
+	}{
+		{"older agent", nil, true, false},
+		{"delta before any full sync", sync(100, 3, false), false, true},
+		{"full sync", sync(100, 4, true), true, false},
+		{"next delta", sync(100, 5, false), true, false},
+		{"gap", sync(100, 7, false), false, true},
+		{"stale replay", sync(100, 4, true), false, false},
+		{"agent restart", sync(200, 1, true), true, false},
+		{"delta after restart", sync(200, 2, false), true, false},
+	}
+	for _, s := range steps {
+		apply, fullSync := checkMetricsSync("srv-sync", s.raw)
+		if apply != s.apply || fullSync != s.fullSync {
+			t.Errorf("%s: got apply=%v fullSync=%v, want %v %v", s.name, apply, fullSync, s.apply, s.fullSync)
+		}
+	}
+}
@@ -0,0 +1,534 @@
+package handlers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Native (.deb/.rpm) agent packages. Unlike the install script they are meant
+// for apt/yum repositories and are shared by many hosts, so they carry no
+// server identity: the config skeleton only holds the dashboard settings and
+// the postinst script gives each host its own server_id and api_secret before
+// starting the service, which then registers with the registration token.
+
+const (
+	nativePackageName = "nodeguarder-agent"
+	nativeConfigDir   = "/etc/nodeguarder-agent"
+	nativeSkeleton    = nativeConfigDir + "/config.yaml.dist"
+)
+
+// nativeArchs maps agent build architectures to Debian and RPM names
+var nativeArchs = map[string]struct{ deb, rpm string }{
+	"amd64": {"amd64", "x86_64"},
+	"arm64": {"arm64", "aarch64"},
+	"arm":   {"armhf", "armv7hl"},
+	"armv6": {"armhf", "armv6hl"},
+	"386":   {"i386", "i686"},
+}
+
+// nativeFile is a file or directory installed by a package
+type nativeFile struct {
+	Path string
+	Mode int64 // permission bits
+	Dir  bool
+	Conf bool // config file, kept on upgrade if edited
+	Body []byte
+}
+
+// nativePackage is the content of a .deb or .rpm package
+type nativePackage struct {
+	Version  string
+	Arch     string // Debian or RPM architecture name
+	Files    []nativeFile
+	PostInst string
+	PreRm    string
+	PostRm   string
+}
+
+// nativeConfigSkeleton is installed as config.yaml.dist and completed by postinst
+func nativeConfigSkeleton(dashboardURL, regToken, packageID string, insecure bool) []byte {
+	return []byte(fmt.Sprintf(`# Copied to config.yaml with a per-host server_id and api_secret on first install
+dashboard_url: %s
+registration_token: %s
+package_id: %s
+interval: 10
+disable_ssl_verify: %t
+`, dashboardURL, regToken, packageID, insecure))
+}
+
+// nativeUnitFile is the systemd unit shipped in the packages
+const nativeUnitFile = `[Unit]
+Description=NodeGuarder Agent Monitoring Service
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=simple
+User=root
+ExecStart=/opt/nodeguarder-agent/nodeguarder-agent --config /etc/nodeguarder-agent/config.yaml
+ExecReload=/bin/kill -HUP $MAINPID
+Restart=always
+RestartSec=10
+StandardOutput=journal
+StandardError=journal
+SyslogIdentifier=nodeguarder-agent
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// Maintainer scripts, shared by both formats: dpkg passes "configure",
+// "remove" or "purge", rpm the number of installed instances (0 on erase)
+const nativePostInst = `#!/bin/sh
+set -e
+CONFIG_FILE=/etc/nodeguarder-agent/config.yaml
+
+# First install: give this host its own identity, it registers on start
+if [ ! -f "$CONFIG_FILE" ]; then
+    SERVER_ID=$(cat /proc/sys/kernel/random/uuid)
+    API_SECRET=$(od -An -N32 -tx1 /dev/urandom | tr -d ' \n')
+    (
+        umask 077
+        {
+            echo "server_id: $SERVER_ID"
+            echo "api_secret: $API_SECRET"
+            cat /etc/nodeguarder-agent/config.yaml.dist
+        } > "$CONFIG_FILE"
+    )
+fi
+
+if command -v systemctl >/dev/null 2>&1; then
+    systemctl daemon-reload
+    systemctl enable nodeguarder-agent.service
+    systemctl restart nodeguarder-agent.service
+fi
+`
+
+const nativePreRm = `#!/bin/sh
+# Stop the service on removal, not on upgrade
+if [ "$1" = "remove" ] || [ "$1" = "0" ]; then
+    if command -v systemctl >/dev/null 2>&1; then
+        systemctl disable --now nodeguarder-agent.service >/dev/null 2>&1 || true
+    fi
+fi
+`
+
+const nativePostRm = `#!/bin/sh
+if command -v systemctl >/dev/null 2>&1; then
+    systemctl daemon-reload || true
+fi
+# dpkg --purge also removes the host's identity and agent data
+if [ "$1" = "purge" ]; then
+    rm -rf /etc/nodeguarder-agent /var/lib/nodeguarder-agent
+fi
+`
+
+// newNativePackage assembles the package content around an agent binary.
+// unitDir is /lib/systemd/system for Debian and /usr/lib/systemd/system for RPM.
+func newNativePackage(version, arch, unitDir string, agentBinary, skeleton []byte) *nativePackage {
+	return &nativePackage{
+		// Package versions may not contain dashes (Debian revision, RPM release)
+		Version: strings.ReplaceAll(strings.TrimPrefix(version, "v"), "-", "~"),
+		Arch:    arch,
+		Files: []nativeFile{
+			{Path: "/opt/nodeguarder-agent", Mode: 0755, Dir: true},
+			{Path: "/opt/nodeguarder-agent/nodeguarder-agent", Mode: 0755, Body: agentBinary},
+			{Path: nativeConfigDir, Mode: 0755, Dir: true},
+			{Path: nativeSkeleton, Mode: 0600, Conf: true, Body: skeleton},
+			{Path: unitDir + "/nodeguarder-agent.service", Mode: 0644, Body: []byte(nativeUnitFile)},
+		},
+		PostInst: nativePostInst,
+		PreRm:    nativePreRm,
+		PostRm:   nativePostRm,
+	}
+}
+
+// installedSize is the size of the package's files in bytes
+func (p *nativePackage) installedSize() int64 {
+	var size int64
+	for _, f := range p.Files {
+		size += int64(len(f.Body))
+	}
+	return size
+}
+
+// buildDeb writes the package as a Debian archive: an ar archive holding
+// debian-binary, control.tar.gz and data.tar.gz
+func buildDeb(p *nativePackage) ([]byte, error) {
+	now := time.Now()
+
+	var conffiles strings.Builder
+	for _, f := range p.Files {
+		if f.Conf {
+			conffiles.WriteString(f.Path + "\n")
+		}
+	}
+	control := fmt.Sprintf(`Package: %s
+Version: %s
+Architecture: %s
+Maintainer: NodeGuarder
+Installed-Size: %d
+Section: admin
+Priority: optional
+Description: NodeGuarder monitoring agent
+ Reports metrics, cron jobs, drift and security events to a NodeGuarder dashboard.
+`, nativePackageName, p.Version, p.Arch, (p.installedSize()+1023)/1024)
+
+	controlTar, err := tarGz(now, []nativeFile{
+		{Path: "control", Mode: 0644, Body: []byte(control)},
+		{Path: "conffiles", Mode: 0644, Body: []byte(conffiles.String())},
+		{Path: "postinst", Mode: 0755, Body: []byte(p.PostInst)},
+		{Path: "prerm", Mode: 0755, Body: []byte(p.PreRm)},
+		{Path: "postrm", Mode: 0755, Body: []byte(p.PostRm)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// dpkg expects every parent directory in the data archive
+	var data []nativeFile
+	seen := map[string]bool{}
+	var addDirs func(dir string)
+	addDirs = func(dir string) {
+		if dir == "/" || seen[dir] {
+			return
+		}
+		addDirs(path.Dir(dir))
+		seen[dir] = true
+		data = append(data, nativeFile{Path: dir, Mode: 0755, Dir: true})
+	}
+	for _, f := range p.Files {
+		if f.Dir {
+			addDirs(f.Path)
+			continue
+		}
+		addDirs(path.Dir(f.Path))
+		data = append(data, f)
+	}
+	dataTar, err := tarGz(now, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("!<arch>\n")
+	for _, member := range []struct {
+		name string
+		body []byte
+	}{
+		{"debian-binary", []byte("2.0\n")},
+		{"control.tar.gz", controlTar},
+		{"data.tar.gz", dataTar},
+	} {
+		fmt.Fprintf(&buf, "%-16s%-12d%-6d%-6d%-8s%-10d`\n", member.name, now.Unix(), 0, 0, "100644", len(member.body))
+		buf.Write(member.body)
+		if len(member.body)%2 == 1 {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// tarGz writes files as a gzipped tar with "./"-relative names owned by root
+func tarGz(mtime time.Time, files []nativeFile) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		hdr := &tar.Header{
+			Name:    "./" + strings.TrimPrefix(f.Path, "/"),
+			Mode:    f.Mode,
+			Size:    int64(len(f.Body)),
+			ModTime: mtime,
+			Uname:   "root",
+			Gname:   "root",
+			Format:  tar.FormatGNU,
+		}
+		if f.Dir {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Name += "/"
+		} else {
+			hdr.Typeflag = tar.TypeReg
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(f.Body); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RPM header data types and tags (see rpm's rpmtag.h)
+const (
+	rpmInt16       = 3
+	rpmInt32       = 4
+	rpmString      = 6
+	rpmBin         = 7
+	rpmStringArray = 8
+	rpmI18NString  = 9
+
+	rpmTagHeaderSignatures = 62
+	rpmTagHeaderImmutable  = 63
+)
+
+// rpmHeader is an RPM header structure (used for the signature and the main header)
+type rpmHeader struct {
+	entries []rpmEntry
+}
+
+type rpmEntry struct {
+	tag, typ, count int32
+	data            []byte
+}
+
+func (h *rpmHeader) add(tag, typ, count int32, data []byte) {
+	h.entries = append(h.entries, rpmEntry{tag, typ, count, data})
+}
+
+func (h *rpmHeader) addString(tag int32, s string) {
+	h.add(tag, rpmString, 1, append([]byte(s), 0))
+}
+
+// addI18N adds a translatable string (only the "C" locale is provided)
+func (h *rpmHeader) addI18N(tag int32, s string) {
+	h.add(tag, rpmI18NString, 1, append([]byte(s), 0))
+}
+
+func (h *rpmHeader) addStrings(tag int32, values []string) {
+	var data []byte
+	for _, s := range values {
+		data = append(append(data, s...), 0)
+	}
+	h.add(tag, rpmStringArray, int32(len(values)), data)
+}
+
+func (h *rpmHeader) addInt32(tag int32, values ...int32) {
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint32(data[4*i:], uint32(v))
+	}
+	h.add(tag, rpmInt32, int32(len(values)), data)
+}
+
+func (h *rpmHeader) addInt16(tag int32, values ...int16) {
+	data := make([]byte, 2*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint16(data[2*i:], uint16(v))
+	}
+	h.add(tag, rpmInt16, int32(len(values)), data)
+}
+
+// marshal encodes the header with its region tag first, as rpm writes
+// immutable headers, entries sorted by tag and values aligned to their type
+func (h *rpmHeader) marshal(regionTag int32) []byte {
+	sort.SliceStable(h.entries, func(i, j int) bool { return h.entries[i].tag < h.entries[j].tag })
+
+	var index, store bytes.Buffer
+	writeEntry := func(tag, typ, offset, count int32) {
+		binary.Write(&index, binary.BigEndian, []int32{tag, typ, offset, count})
+	}
+	for _, e := range h.entries {
+		align := 1
+		switch e.typ {
+		case rpmInt16:
+			align = 2
+		case rpmInt32:
+			align = 4
+		}
+		for store.Len()%align != 0 {
+			store.WriteByte(0)
+		}
+		writeEntry(e.tag, e.typ, int32(store.Len()), e.count)
+		store.Write(e.data)
+	}
+
+	// The region trailer points back over every index entry, itself included
+	count := int32(len(h.entries) + 1)
+	var region bytes.Buffer
+	binary.Write(&region, binary.BigEndian, []int32{regionTag, rpmBin, int32(store.Len()), 16})
+	binary.Write(&store, binary.BigEndian, []int32{regionTag, rpmBin, -count * 16, 16})
+
+	var buf bytes.Buffer
+	buf.Write([]byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0})
+	binary.Write(&buf, binary.BigEndian, []int32{count, int32(store.Len())})
+	buf.Write(region.Bytes())
+	buf.Write(index.Bytes())
+	buf.Write(store.Bytes())
+	return buf.Bytes()
+}
+
+// buildRPM writes the package as an RPM: lead, signature header, header and
+// a gzipped cpio payload
+func buildRPM(p *nativePackage) ([]byte, error) {
+	const release = "1"
+	now := int32(time.Now().Unix())
+
+	// Payload: newc cpio archive with "./"-relative names
+	var cpio bytes.Buffer
+	writeCPIO := func(name string, ino int, mode int64, body []byte) {
+		fmt.Fprintf(&cpio, "070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+			ino, mode, 0, 0, 1, now, len(body), 0, 0, 0, 0, len(name)+1, 0)
+		cpio.WriteString(name)
+		cpio.WriteByte(0)
+		for cpio.Len()%4 != 0 {
+			cpio.WriteByte(0)
+		}
+		cpio.Write(body)
+		for cpio.Len()%4 != 0 {
+			cpio.WriteByte(0)
+		}
+	}
+
+	var (
+		sizes, mtimes, flags, dirIndexes, devices, inodes []int32
+		modes, rdevs                                      []int16
+		digests, linkTos, users, groups, langs, basenames []string
+		dirnames                                          []string
+	)
+	dirIndex := map[string]int32{}
+	for i, f := range p.Files {
+		mode := f.Mode | 0100000
+		digest := ""
+		if f.Dir {
+			mode = f.Mode | 040000
+		} else {
+			sum := sha256.Sum256(f.Body)
+			digest = hex.EncodeToString(sum[:])
+		}
+		var flag int32
+		if f.Conf {
+			flag = 1 | 1<<4 // RPMFILE_CONFIG | RPMFILE_NOREPLACE
+		}
+		dir := path.Dir(f.Path) + "/"
+		if _, ok := dirIndex[dir]; !ok {
+			dirIndex[dir] = int32(len(dirnames))
+			dirnames = append(dirnames, dir)
+		}
+
+		sizes = append(sizes, int32(len(f.Body)))
+		modes = append(modes, int16(mode))
+		rdevs = append(rdevs, 0)
+		mtimes = append(mtimes, now)
+		digests = append(digests, digest)
+		linkTos = append(linkTos, "")
+		flags = append(flags, flag)
+		users = append(users, "root")
+		groups = append(groups, "root")
+		devices = append(devices, 1)
+		inodes = append(inodes, int32(i+1))
+		langs = append(langs, "")
+		dirIndexes = append(dirIndexes, dirIndex[dir])
+		basenames = append(basenames, path.Base(f.Path))
+
+		writeCPIO("."+f.Path, i+1, mode, f.Body)
+	}
+	writeCPIO("TRAILER!!!", 0, 0, nil)
+
+	var payload bytes.Buffer
+	gz, _ := gzip.NewWriterLevel(&payload, gzip.BestCompression)
+	if _, err := gz.Write(cpio.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	payloadSum := sha256.Sum256(payload.Bytes())
+
+	h := &rpmHeader{}
+	h.addStrings(100, []string{"C"}) // HEADERI18NTABLE
+	h.addString(1000, nativePackageName)
+	h.addString(1001, p.Version)
+	h.addString(1002, release)
+	h.addI18N(1004, "NodeGuarder monitoring agent")
+	h.addI18N(1005, "Reports metrics, cron jobs, drift and security events to a NodeGuarder dashboard.")
+	h.addInt32(1006, now) // BUILDTIME
+	h.addInt32(1009, int32(p.installedSize()))
+	h.addString(1014, "Proprietary")
+	h.addI18N(1016, "System Environment/Daemons")
+	h.addString(1021, "linux")
+	h.addString(1022, p.Arch)
+	h.addString(1024, p.PostInst)
+	h.addString(1025, p.PreRm)
+	h.addString(1026, p.PostRm)
+	h.addInt32(1028, sizes...)
+	h.addInt16(1030, modes...)
+	h.addInt16(1033, rdevs...)
+	h.addInt32(1034, mtimes...)
+	h.addStrings(1035, digests)
+	h.addStrings(1036, linkTos)
+	h.addInt32(1037, flags...)
+	h.addStrings(1039, users)
+	h.addStrings(1040, groups)
+	// Binary packages are told apart from source packages by SOURCERPM
+	h.addString(1044, fmt.Sprintf("%s-%s-%s.src.rpm", nativePackageName, p.Version, release))
+	h.addInt32(1048, 1<<24|8|2, 1<<24|8|2) // REQUIREFLAGS: RPMLIB, EQUAL, LESS
+	h.addStrings(1049, []string{"rpmlib(CompressedFileNames)", "rpmlib(PayloadFilesHavePrefix)"})
+	h.addStrings(1050, []string{"3.0.4-1", "4.0-1"})
+	h.addString(1086, "/bin/sh") // POSTINPROG
+	h.addString(1087, "/bin/sh") // PREUNPROG
+	h.addString(1088, "/bin/sh") // POSTUNPROG
+	h.addInt32(1095, devices...)
+	h.addInt32(1096, inodes...)
+	h.addStrings(1097, langs)
+	h.addInt32(1116, dirIndexes...)
+	h.addStrings(1117, basenames)
+	h.addStrings(1118, dirnames)
+	h.addString(1124, "cpio")
+	h.addString(1125, "gzip")
+	h.addString(1126, "9")
+	h.addInt32(5011, 8) // FILEDIGESTALGO: SHA256
+	h.addStrings(5092, []string{hex.EncodeToString(payloadSum[:])})
+	h.addInt32(5093, 8) // PAYLOADDIGESTALGO: SHA256
+	header := h.marshal(rpmTagHeaderImmutable)
+
+	headerSHA1 := sha1.Sum(header)
+	headerSHA256 := sha256.Sum256(header)
+	md5sum := md5.New()
+	md5sum.Write(header)
+	md5sum.Write(payload.Bytes())
+
+	sig := &rpmHeader{}
+	sig.addString(269, hex.EncodeToString(headerSHA1[:]))
+	sig.addString(273, hex.EncodeToString(headerSHA256[:]))
+	sig.addInt32(1000, int32(len(header)+payload.Len())) // SIZE
+	sig.add(1004, rpmBin, 16, md5sum.Sum(nil))
+	sig.addInt32(1007, int32(cpio.Len())) // PAYLOADSIZE
+	signature := sig.marshal(rpmTagHeaderSignatures)
+
+	var buf bytes.Buffer
+	// Lead: magic, format 3.0, binary package, arch 1, name, os 1, header-style signature
+	buf.Write([]byte{0xed, 0xab, 0xee, 0xdb, 3, 0})
+	binary.Write(&buf, binary.BigEndian, []int16{0, 1})
+	name := make([]byte, 66)
+	copy(name[:65], fmt.Sprintf("%s-%s-%s", nativePackageName, p.Version, release))
+	buf.Write(name)
+	binary.Write(&buf, binary.BigEndian, []int16{1, 5})
+	buf.Write(make([]byte, 16))
+
+	buf.Write(signature)
+	for buf.Len()%8 != 0 {
+		buf.WriteByte(0)
+	}
+	buf.Write(header)
+	buf.Write(payload.Bytes())
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func testNativePackage(unitDir string) *nativePackage {
+	skeleton := nativeConfigSkeleton("https://dash.example", "reg-token", "pkg-1", false)
+	return newNativePackage("v1.2.0-rc1", "amd64", unitDir, []byte("agent binary"), skeleton)
+}
+
+// readTarGz returns the regular files of a gzipped tar by name
+func readTarGz(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(tr)
+		files[hdr.Name] = string(body)
+	}
+}
+
+func TestBuildDeb(t *testing.T) {
+	deb, err := buildDeb(testNativePackage("/lib/systemd/system"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(deb, []byte("!<arch>\n")) {
+		t.Fatal("not an ar archive")
+	}
+
+	members := map[string][]byte{}
+	var order []string
+	for rest := deb[8:]; len(rest) >= 60; {
+		name := strings.TrimSpace(string(rest[:16]))
+		size, err := strconv.Atoi(strings.TrimSpace(string(rest[48:58])))
+		if err != nil {
+			t.Fatalf("bad member size for %s: %v", name, err)
+		}
+		members[name] = rest[60 : 60+size]
+		order = append(order, name)
+		rest = rest[60+size+size%2:]
+	}
+	if strings.Join(order, ",") != "debian-binary,control.tar.gz,data.tar.gz" {
+		t.Fatalf("members = %v", order)
+	}
+
+	control := readTarGz(t, members["control.tar.gz"])
+	if !strings.Contains(control["./control"], "Version: 1.2.0~rc1\n") || !strings.Contains(control["./control"], "Architecture: amd64\n") {
+		t.Errorf("control = %q", control["./control"])
+	}
+	if control["./conffiles"] != nativeSkeleton+"\n" {
+		t.Errorf("conffiles = %q", control["./conffiles"])
+	}
+	if control["./postinst"] != nativePostInst {
+		t.Error("postinst missing")
+	}
+
+	data := readTarGz(t, members["data.tar.gz"])
+	if data["./opt/nodeguarder-agent/nodeguarder-agent"] != "agent binary" {
+		t.Error("agent binary missing")
+	}
+	if !strings.Contains(data["./etc/nodeguarder-agent/config.yaml.dist"], "package_id: pkg-1\n") {
+		t.Errorf("skeleton = %q", data["./etc/nodeguarder-agent/config.yaml.dist"])
+	}
+	if _, ok := data["./lib/systemd/system/nodeguarder-agent.service"]; !ok {
+		t.Error("systemd unit missing")
+	}
+}
+
+// readRPMHeader parses a header structure and returns its values by tag and its length
+func readRPMHeader(t *testing.T, data []byte) (map[int32][]byte, int) {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte{0x8e, 0xad, 0xe8, 0x01}) {
+		t.Fatal("bad header magic")
+	}
+	count := int(binary.BigEndian.Uint32(data[8:]))
+	storeLen := int(binary.BigEndian.Uint32(data[12:]))
+	store := data[16+16*count : 16+16*count+storeLen]
+
+	entries := map[int32][]byte{}
+	for i := 0; i < count; i++ {
+		e := data[16+16*i:]
+		tag := int32(binary.BigEndian.Uint32(e))
+		offset := int(binary.BigEndian.Uint32(e[8:]))
+		entries[tag] = store[offset:]
+	}
+	return entries, 16 + 16*count + storeLen
+}
+
+func TestBuildRPM(t *testing.T) {
+	rpm, err := buildRPM(testNativePackage("/usr/lib/systemd/system"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(rpm, []byte{0xed, 0xab, 0xee, 0xdb, 3, 0}) || len(rpm) < 96 {
+		t.Fatal("bad lead")
+	}
+
+	sig, sigLen := readRPMHeader(t, rpm[96:])
+	// Region trailer points back over all index entries
+	if trailer := sig[rpmTagHeaderSignatures]; int32(binary.BigEndian.Uint32(trailer[8:])) != -int32(len(sig))*16 {
+		t.Errorf("bad signature region trailer")
+	}
+	start := 96 + sigLen
+	for start%8 != 0 {
+		start++
+	}
+
+	header, headerLen := readRPMHeader(t, rpm[start:])
+	cstring := func(b []byte) string { return string(b[:bytes.IndexByte(b, 0)]) }
+	if got := cstring(header[1001]); got != "1.2.0~rc1" {
+		t.Errorf("version = %q", got)
+	}
+	if got := cstring(header[1024]); got != nativePostInst {
+		t.Errorf("postin = %q", got)
+	}
+	if size := binary.BigEndian.Uint32(sig[1000]); int(size) != len(rpm)-start {
+		t.Errorf("signature size = %d, want %d", size, len(rpm)-start)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(rpm[start+headerLen:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := io.ReadAll(gz)
+	if !bytes.HasPrefix(payload, []byte("070701")) {
+		t.Fatal("payload is not a newc cpio archive")
+	}
+	for _, want := range []string{"./opt/nodeguarder-agent/nodeguarder-agent\x00", "agent binary", "./usr/lib/systemd/system/nodeguarder-agent.service\x00", "TRAILER!!!\x00"} {
+		if !bytes.Contains(payload, []byte(want)) {
+			t.Errorf("payload is missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// AgentPushPackages replaces a server's package inventory with the agent's latest snapshot
+func AgentPushPackages(c *fiber.Ctx) error {
+	var req struct {
+		ServerID  string           `json:"server_id"`
+		APISecret string           `json:"api_secret"`
+		Manager   string           `json:"manager"`
+		Packages  []models.Package `json:"packages"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if !authenticateAgent(c, req.ServerID, req.APISecret) {
+		return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM server_packages WHERE server_id = ?", req.ServerID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	stmt, err := tx.Prepare("INSERT OR REPLACE INTO server_packages (server_id, name, version, arch) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer stmt.Close()
+	for _, p := range req.Packages {
+		if p.Name == "" {
+			continue
+		}
+		if _, err := stmt.Exec(req.ServerID, p.Name, p.Version, p.Arch); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to store packages"})
+		}
+	}
+	if _, err := tx.Exec("UPDATE servers SET package_manager = ?, packages_updated_at = ? WHERE id = ?", req.Manager, time.Now().Unix(), req.ServerID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to store packages"})
+	}
+
+	log.Printf("📦 Stored %d packages for %s", len(req.Packages), req.ServerID)
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// GetServerPackages returns a server's installed packages, optionally filtered by name (?q=)
+func GetServerPackages(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+
+	var manager string
+	var updatedAt int64
+	err := database.DB.QueryRow(`
+		SELECT COALESCE(package_manager, ''), COALESCE(packages_updated_at, 0) FROM servers WHERE id = ?
+	`, serverID).Scan(&manager, &updatedAt)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT name, version, COALESCE(arch, '') FROM server_packages
+		WHERE server_id = ? AND name LIKE ?
+		ORDER BY name
+	`, serverID, "%"+c.Query("q")+"%")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	pkgs := []models.Package{}
+	for rows.Next() {
+		var p models.Package
+		if err := rows.Scan(&p.Name, &p.Version, &p.Arch); err != nil {
+			continue
+		}
+		pkgs = append(pkgs, p)
+	}
+
+	return c.JSON(fiber.Map{
+		"manager":    manager,
+		"updated_at": updatedAt,
+		"packages":   pkgs,
+	})
+}
+
+// SearchPackages finds which servers have a package installed (?name=, exact match)
+func SearchPackages(c *fiber.Ctx) error {
+	name := c.Query("name")
+	if name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Query parameter 'name' is required"})
+	}
+
+	visible, args := middleware.ServerVisibilityClause(c, "p.server_id")
+	rows, err := database.DB.Query(`
+		SELECT p.server_id, s.hostname, p.name, p.version, COALESCE(p.arch, '')
+		FROM server_packages p
+		JOIN servers s ON s.id = p.server_id
+		WHERE p.name = ? AND `+visible+`
+		ORDER BY s.hostname
+	`, append([]interface{}{name}, args...)...)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	pkgs := []models.Package{}
+	for rows.Next() {
+		var p models.Package
+		if err := rows.Scan(&p.ServerID, &p.Hostname, &p.Name, &p.Version, &p.Arch); err != nil {
+			continue
+		}
+		pkgs = append(pkgs, p)
+	}
+
+	return c.JSON(pkgs)
+}
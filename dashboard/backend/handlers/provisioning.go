@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"log"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Provisioning snippets for configuration management. Like the native
+// packages they are shared by many hosts and carry no server identity: they
+// download the published agent binary and let the agent generate the host's
+// server_id and api_secret (its own installer, nodeguarder-agent --install,
+// or the container bootstrap from the environment), then register with the
+// registration token. They are idempotent, an installed agent (existing
+// config.yaml) is left alone.
+
+// ansibleTemplate is a role tasks file (roles/nodeguarder_agent/tasks/main.yml).
+// It uses [[ ]] delimiters so Jinja expressions pass through untouched.
+const ansibleTemplate = `---
+# NodeGuarder agent role: save as roles/nodeguarder_agent/tasks/main.yml
+# Install package: [[ .PackageID ]]
+#
+# Override from inventory or vault:
+#   nodeguarder_dashboard_url, nodeguarder_registration_token
+- name: Resolve NodeGuarder agent settings
+  ansible.builtin.set_fact:
+    nodeguarder_url: "{{ nodeguarder_dashboard_url | default('[[ .DashboardURL ]]') }}"
+    nodeguarder_token: "{{ nodeguarder_registration_token | default('[[ .RegistrationToken ]]') }}"
+    nodeguarder_arch: "{{ {'x86_64': 'amd64', 'aarch64': 'arm64', 'armv6l': 'armv6', 'armv7l': 'armv6', 'i386': '386', 'i686': '386'}[ansible_architecture] | default('amd64') }}"
+  no_log: true
+
+- name: Create NodeGuarder agent directory
+  ansible.builtin.file:
+    path: /opt/nodeguarder-agent
+    state: directory
+    mode: "0755"
+  become: true
+
+- name: Download NodeGuarder agent binary
+  ansible.builtin.get_url:
+    url: "{{ nodeguarder_url }}/api/v1/agent/download/linux/{{ nodeguarder_arch }}"
+    dest: /opt/nodeguarder-agent/nodeguarder-agent
+    mode: "0755"
+    validate_certs: [[ not .Insecure ]]
+  become: true
+
+- name: Install and register NodeGuarder agent
+  ansible.builtin.command:
+    argv:
+      - /opt/nodeguarder-agent/nodeguarder-agent
+      - --install
+      - --dashboard-url
+      - "{{ nodeguarder_url }}"
+      - --token
+      - "{{ nodeguarder_token }}"
+      - --package-id
+      - "[[ .PackageID ]]"
+[[- if .Insecure ]]
+      - --insecure
+[[- end ]]
+    creates: /etc/nodeguarder-agent/config.yaml
+  become: true
+  no_log: true
+`
+
+// cloudInitTemplate is #cloud-config user-data installing the agent on first boot
+const cloudInitTemplate = `#cloud-config
+# NodeGuarder agent, install package [[ .PackageID ]]
+runcmd:
+  - |
+    set -e
+    if [ -f /etc/nodeguarder-agent/config.yaml ]; then exit 0; fi
+    case "$(uname -m)" in
+      aarch64) ARCH=arm64 ;;
+      armv6l|armv7l) ARCH=armv6 ;;
+      i386|i686) ARCH=386 ;;
+      *) ARCH=amd64 ;;
+    esac
+    mkdir -p /opt/nodeguarder-agent
+    curl -fsSL[[ if .Insecure ]] -k[[ end ]] "[[ .DashboardURL ]]/api/v1/agent/download/linux/$ARCH" -o /opt/nodeguarder-agent/nodeguarder-agent
+    chmod 755 /opt/nodeguarder-agent/nodeguarder-agent
+    /opt/nodeguarder-agent/nodeguarder-agent --install --dashboard-url '[[ .DashboardURL ]]' --token '[[ .RegistrationToken ]]' --package-id '[[ .PackageID ]]'[[ if .Insecure ]] --insecure[[ end ]]
+`
+
+// kubernetesTemplate runs the agent on every node as a DaemonSet. The pod
+// shares the node's PID namespace and network, sees its filesystem under
+// /host and keeps config (identity) and binary on the node, so restarts and
+// self-updates survive. The agent creates its config from the environment.
+const kubernetesTemplate = `# NodeGuarder agent DaemonSet, install package [[ .PackageID ]]
+# kubectl apply -f nodeguarder-agent-daemonset.yaml
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: [[ .Namespace ]]
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: nodeguarder-agent
+  namespace: [[ .Namespace ]]
+stringData:
+  registration-token: "[[ .RegistrationToken ]]"
+---
+apiVersion: apps/v1
+kind: DaemonSet
+metadata:
+  name: nodeguarder-agent
+  namespace: [[ .Namespace ]]
+  labels:
+    app: nodeguarder-agent
+spec:
+  selector:
+    matchLabels:
+      app: nodeguarder-agent
+  template:
+    metadata:
+      labels:
+        app: nodeguarder-agent
+    spec:
+      hostPID: true
+      hostNetwork: true
+      dnsPolicy: ClusterFirstWithHostNet
+      tolerations:
+        - operator: Exists
+      containers:
+        - name: agent
+          image: [[ .Image ]]
+          command: ["/bin/sh", "-c"]
+          args:
+            - |
+              BIN=/var/lib/nodeguarder-agent/nodeguarder-agent
+              if [ ! -x "$BIN" ]; then
+                case "$(uname -m)" in
+                  aarch64) ARCH=arm64 ;;
+                  armv6l|armv7l) ARCH=armv6 ;;
+                  i386|i686) ARCH=386 ;;
+                  *) ARCH=amd64 ;;
+                esac
+                wget -q[[ if .Insecure ]] --no-check-certificate[[ end ]] -O "$BIN.tmp" "$NODEGUARDER_DASHBOARD_URL/api/v1/agent/download/linux/$ARCH"
+                chmod 755 "$BIN.tmp" && mv "$BIN.tmp" "$BIN"
+              fi
+              exec "$BIN" --config /etc/nodeguarder-agent/config.yaml
+          env:
+            - name: NODEGUARDER_DASHBOARD_URL
+              value: "[[ .DashboardURL ]]"
+            - name: NODEGUARDER_REGISTRATION_TOKEN
+              valueFrom:
+                secretKeyRef:
+                  name: nodeguarder-agent
+                  key: registration-token
+            - name: NODEGUARDER_PACKAGE_ID
+              value: "[[ .PackageID ]]"
+            - name: NODEGUARDER_HOST_ROOT
+              value: /host
+            - name: NODEGUARDER_INSECURE
+              value: "[[ .Insecure ]]"
+          securityContext:
+            privileged: true # eBPF, fanotify and the proc connector
+          volumeMounts:
+            - name: host
+              mountPath: /host
+              readOnly: true
+              mountPropagation: HostToContainer
+            - name: config
+              mountPath: /etc/nodeguarder-agent
+            - name: data
+              mountPath: /var/lib/nodeguarder-agent
+      volumes:
+        - name: host
+          hostPath:
+            path: /
+        - name: config
+          hostPath:
+            path: /etc/nodeguarder-agent
+            type: DirectoryOrCreate
+        - name: data
+          hostPath:
+            path: /var/lib/nodeguarder-agent
+            type: DirectoryOrCreate
+`
+
+// Kubernetes manifest options (?namespace=, ?image=)
+var (
+	namespacePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]{0,61}[a-z0-9])?$`)
+	imagePattern     = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._/:@-]{0,254}$`)
+)
+
+// generateProvisioningSnippet renders the Ansible tasks, cloud-init user-data or DaemonSet manifest
+func generateProvisioningSnippet(format, dashboardURL, regToken, packageID, namespace, image string, insecure bool) (string, error) {
+	text := ansibleTemplate
+	switch format {
+	case "cloud-init":
+		text = cloudInitTemplate
+	case "kubernetes":
+		text = kubernetesTemplate
+	}
+	tmpl, err := template.New(format).Delims("[[", "]]").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	data := struct {
+		DashboardURL      string
+		RegistrationToken string
+		PackageID         string
+		Namespace         string
+		Image             string
+		Insecure          bool
+	}{
+		DashboardURL:      strings.TrimSuffix(dashboardURL, "/"),
+		RegistrationToken: regToken,
+		PackageID:         packageID,
+		Namespace:         namespace,
+		Image:             image,
+		Insecure:          insecure,
+	}
+
+	var result strings.Builder
+	if err := tmpl.Execute(&result, data); err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+// sendProvisioningSnippet serves the Ansible tasks file, cloud-init user-data
+// or Kubernetes DaemonSet manifest
+func sendProvisioningSnippet(c *fiber.Ctx, format, dashboardURL, packageID string, insecure bool) error {
+	namespace := c.Query("namespace", "nodeguarder")
+	image := c.Query("image", "alpine:3.19")
+	if !namespacePattern.MatchString(namespace) {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid namespace"})
+	}
+	if !imagePattern.MatchString(image) {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid image"})
+	}
+
+	snippet, err := generateProvisioningSnippet(format, dashboardURL, RegistrationToken, packageID, namespace, image, insecure)
+	if err != nil {
+		log.Printf("Failed to generate %s snippet: %v", format, err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to generate install script"})
+	}
+
+	filename := "nodeguarder-agent-ansible.yml"
+	switch format {
+	case "cloud-init":
+		filename = "nodeguarder-agent-cloud-init.yml"
+	case "kubernetes":
+		filename = "nodeguarder-agent-daemonset.yaml"
+	}
+	c.Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Set("Content-Type", "application/x-yaml")
+	return c.SendString(snippet)
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/push"
+)
+
+// unitNamePattern matches plain systemd unit names; the first character
+// cannot be "-", so a name is never taken as a systemctl option
+var unitNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9@._:-]*$`)
+
+// RequestRemediation queues a service restart or process termination for a server
+func RequestRemediation(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+
+	var req struct {
+		Action  string `json:"action"` // restart_unit, kill_pid
+		Target  string `json:"target"`
+		EventID *int64 `json:"event_id"` // Optional: alert this was triggered from
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	var exists int
+	if err := database.DB.QueryRow("SELECT 1 FROM servers WHERE id = ?", serverID).Scan(&exists); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
+
+	var processName string
+	switch req.Action {
+	case "restart_unit":
+		if !unitNamePattern.MatchString(req.Target) {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid unit name"})
+		}
+	case "kill_pid":
+		pid, err := strconv.Atoi(req.Target)
+		if err != nil || pid <= 1 {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid PID"})
+		}
+		// Only PIDs from the latest reported process list may be targeted
+		name, ok := latestProcessName(serverID, int32(pid))
+		if !ok {
+			return c.Status(400).JSON(fiber.Map{"error": "PID not found in the latest process list"})
+		}
+		processName = name
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "Unsupported action"})
+	}
+
+	username, _ := c.Locals("username").(string)
+	res, err := database.DB.Exec(`
+		INSERT INTO remediations (server_id, action, target, process, event_id, status, requested_by, requested_at)
+		VALUES (?, ?, ?, ?, ?, 'pending', ?, ?)
+	`, serverID, req.Action, req.Target, processName, req.EventID, username, time.Now().Unix())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to queue remediation"})
+	}
+	id, _ := res.LastInsertId()
+	push.Notify(serverID)
+
+	recordAudit(c, "remediation."+req.Action, serverID, fmt.Sprintf("id=%d target=%s %s", id, req.Target, processName))
+	return c.JSON(fiber.Map{"id": id, "status": "pending"})
+}
+
+// GetRemediations lists recent remediations for a server
+func GetRemediations(c *fiber.Ctx) error {
+	rows, err := database.DB.Query(`
+		SELECT id, server_id, action, target, COALESCE(process, ''), event_id, status, COALESCE(output, ''),
+		       requested_by, requested_at, COALESCE(completed_at, 0)
+		FROM remediations
+		WHERE server_id = ?
+		ORDER BY requested_at DESC
+		LIMIT 100
+	`, c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	list := []models.Remediation{}
+	for rows.Next() {
+		var r models.Remediation
+		if err := rows.Scan(&r.ID, &r.ServerID, &r.Action, &r.Target, &r.Process, &r.EventID, &r.Status, &r.Output,
+			&r.RequestedBy, &r.RequestedAt, &r.CompletedAt); err != nil {
+			continue
+		}
+		list = append(list, r)
+	}
+
+	return c.JSON(list)
+}
+
+// latestProcessName looks up a PID in the server's most recent process list
+// (pushes and samples without one carry over the previous list)
+func latestProcessName(serverID string, pid int32) (string, bool) {
+	var processesJSON string
+	err := database.DB.QueryRow(`
+		SELECT processes FROM metrics
+		WHERE server_id = ? AND processes IS NOT NULL AND processes != ''
+		ORDER BY timestamp DESC LIMIT 1
+	`, serverID).Scan(&processesJSON)
+	if err != nil || processesJSON == "" {
+		return "", false
+	}
+
+	var procs []struct {
+		PID  int32  `json:"pid"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(processesJSON), &procs); err != nil {
+		return "", false
+	}
+	for _, p := range procs {
+		if p.PID == pid {
+			return p.Name, true
+		}
+	}
+	return "", false
+}
+
+// pendingRemediations returns queued remediations for a server and marks them dispatched (at-most-once)
+func pendingRemediations(serverID string) []models.AgentRemediation {
+	rows, err := database.DB.Query(`
+		SELECT id, action, target, COALESCE(process, '') FROM remediations
+		WHERE server_id = ? AND status = 'pending'
+		ORDER BY id
+	`, serverID)
+	if err != nil {
+		return nil
+	}
+
+	var cmds []models.AgentRemediation
+	for rows.Next() {
+		var cmd models.AgentRemediation
+		if err := rows.Scan(&cmd.ID, &cmd.Action, &cmd.Target, &cmd.Process); err == nil {
+			cmds = append(cmds, cmd)
+		}
+	}
+	rows.Close()
+
+	dispatched := cmds[:0]
+	for _, cmd := range cmds {
+		res, err := database.DB.Exec("UPDATE remediations SET status = 'dispatched' WHERE id = ? AND status = 'pending'", cmd.ID)
+		if err != nil {
+			continue
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			dispatched = append(dispatched, cmd)
+		}
+	}
+	return dispatched
+}
+
+// AgentRemediationResult records the outcome and posts a confirmation event
+func AgentRemediationResult(c *fiber.Ctx) error {
+	var req struct {
+		ServerID  string `json:"server_id"`
+		APISecret string `json:"api_secret"`
+		ID        int64  `json:"id"`
+		Success   bool   `json:"success"`
+		Output    string `json:"output"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if !authenticateAgent(c, req.ServerID, req.APISecret) {
+		return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
+	}
+
+	var action, target, requestedBy string
+	err := database.DB.QueryRow(`
+		SELECT action, target, requested_by FROM remediations WHERE id = ? AND server_id = ? AND status = 'dispatched'
+	`, req.ID, req.ServerID).Scan(&action, &target, &requestedBy)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Unknown or already completed remediation"})
+	}
+
+	status := "failed"
+	severity := "warning"
+	if req.Success {
+		status = "succeeded"
+		severity = "info"
+	}
+	now := time.Now().Unix()
+	database.DB.Exec("UPDATE remediations SET status = ?, output = ?, completed_at = ? WHERE id = ?", status, req.Output, now, req.ID)
+
+	label := map[string]string{"restart_unit": "Restart of unit", "kill_pid": "SIGTERM to PID"}[action]
+	msg := fmt.Sprintf("%s %s %s (requested by %s)", label, target, status, requestedBy)
+	details, _ := json.Marshal(fiber.Map{"remediation_id": req.ID, "action": action, "target": target, "output": req.Output})
+	if _, err := database.DB.Exec(`
+		INSERT INTO events (server_id, timestamp, event_type, severity, message, details)
+		VALUES (?, ?, 'remediation', ?, ?, ?)
+	`, req.ServerID, now, severity, msg, string(details)); err != nil {
+		log.Printf("Failed to insert remediation event: %v", err)
+	}
+
+	return c.JSON(fiber.Map{"status": "ok"})
+}
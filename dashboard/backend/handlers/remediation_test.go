@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+func TestRequestRemediationValidatesTargets(t *testing.T) {
+	testDB(t)
+	seedServer(t, "s1", "web-1")
+	mustExec(t, `INSERT INTO metrics (server_id, timestamp, processes) VALUES ('s1', 1000, '[{"pid": 4242, "name": "worker"}]')`)
+
+	app := fiber.New()
+	app.Post("/servers/:id/remediate", RequestRemediation)
+	remediate := func(server, action, target string) int {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/servers/"+server+"/remediate",
+			strings.NewReader(`{"action": "`+action+`", "target": "`+target+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	for _, unit := range []string{"", "-nginx", "--no-block", "nginx service", "nginx;reboot", "../nginx"} {
+		if got := remediate("s1", "restart_unit", unit); got != 400 {
+			t.Errorf("unit %q: status %d, want 400", unit, got)
+		}
+	}
+	// PIDs must be plain numbers above 1 from the latest process list
+	for _, pid := range []string{"", "0", "1", "-5", "42x", "4243"} {
+		if got := remediate("s1", "kill_pid", pid); got != 400 {
+			t.Errorf("PID %q: status %d, want 400", pid, got)
+		}
+	}
+	if got := remediate("s1", "reboot", "now"); got != 400 {
+		t.Errorf("unknown action: status %d, want 400", got)
+	}
+	if got := remediate("s9", "restart_unit", "nginx.service"); got != 404 {
+		t.Errorf("unknown server: status %d, want 404", got)
+	}
+	var n int
+	database.DB.QueryRow("SELECT COUNT(*) FROM remediations").Scan(&n)
+	if n != 0 {
+		t.Fatalf("%d remediations queued from invalid requests", n)
+	}
+
+	if got := remediate("s1", "restart_unit", "getty@tty1.service"); got != 200 {
+		t.Errorf("valid unit: status %d", got)
+	}
+	if got := remediate("s1", "kill_pid", "4242"); got != 200 {
+		t.Errorf("listed PID: status %d", got)
+	}
+	var process string
+	database.DB.QueryRow("SELECT COALESCE(process, '') FROM remediations WHERE action = 'kill_pid'").Scan(&process)
+	if process != "worker" {
+		t.Errorf("recorded process %q, want worker", process)
+	}
+}
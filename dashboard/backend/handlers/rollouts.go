@@ -0,0 +1,556 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/notifications"
+	"github.com/yourusername/health-dashboard-backend/push"
+)
+
+var versionPattern = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z._+-]{0,63}$`)
+
+// versionBinaryDir holds the binaries of an agent version: the published
+// version lives in AGENT_BINARY_PATH, others in AGENT_BINARY_PATH/<version>
+func versionBinaryDir(version string) string {
+	if version == "" || version == publishedAgentVersion() {
+		return agentBinaryDir()
+	}
+	return filepath.Join(agentBinaryDir(), version)
+}
+
+// agentVersionAvailable reports whether binaries of a version can be downloaded
+func agentVersionAvailable(version string) bool {
+	paths, _ := filepath.Glob(filepath.Join(versionBinaryDir(version), agentBinaryPrefix+"*"))
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() && !strings.HasSuffix(path, agentSignatureSuffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// rolloutInProgress reports whether a rollout is underway. Servers it has not
+// reached yet are held on their current version meanwhile.
+func rolloutInProgress() bool {
+	var n int
+	database.DB.QueryRow("SELECT COUNT(*) FROM agent_rollouts WHERE status IN ('active', 'paused', 'failed')").Scan(&n)
+	return n > 0
+}
+
+// serverTargetVersion is the version AgentGetConfig asks an agent to run
+// (empty = follow the published version)
+func serverTargetVersion(serverID string) string {
+	var target, current string
+	database.DB.QueryRow("SELECT COALESCE(target_version, ''), COALESCE(agent_version, '') FROM servers WHERE id = ?", serverID).Scan(&target, &current)
+	if target == "" && rolloutInProgress() {
+		return current
+	}
+	return target
+}
+
+// rolloutCandidate is a server in the order a rollout reaches it
+type rolloutCandidate struct {
+	id, group string
+	stage     int
+	rank      string
+}
+
+// rolloutOrder lists every server in rollout order: by the first group tag it
+// carries (untagged servers last), then in a stable pseudo-random order per
+// rollout, so each stage starts with an unbiased sample
+func rolloutOrder(rolloutID int64, groups []string) ([]rolloutCandidate, error) {
+	rows, err := database.DB.Query("SELECT id FROM servers")
+	if err != nil {
+		return nil, err
+	}
+	var candidates []rolloutCandidate
+	for rows.Next() {
+		var id string
+		if rows.Scan(&id) == nil {
+			sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s", rolloutID, id)))
+			candidates = append(candidates, rolloutCandidate{id: id, stage: len(groups), rank: fmt.Sprintf("%x", sum)})
+		}
+	}
+	rows.Close()
+
+	tags := serverTags()
+	for i := range candidates {
+		for stage, group := range groups {
+			if containsString(tags[candidates[i].id], group) {
+				candidates[i].stage, candidates[i].group = stage, group
+				break
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].stage != candidates[j].stage {
+			return candidates[i].stage < candidates[j].stage
+		}
+		return candidates[i].rank < candidates[j].rank
+	})
+	return candidates, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// loadRollout reads a rollout with its progress
+func loadRollout(id int64) (*models.AgentRollout, error) {
+	var r models.AgentRollout
+	var groups string
+	err := database.DB.QueryRow(`
+		SELECT id, version, percentage, group_order, max_failures, status, COALESCE(created_by, ''), created_at, updated_at
+		FROM agent_rollouts WHERE id = ?
+	`, id).Scan(&r.ID, &r.Version, &r.Percentage, &groups, &r.MaxFailures, &r.Status, &r.CreatedBy, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	r.Groups = []string{}
+	json.Unmarshal([]byte(groups), &r.Groups)
+
+	database.DB.QueryRow("SELECT COUNT(*) FROM servers").Scan(&r.Progress.Total)
+	rows, err := database.DB.Query("SELECT status, COUNT(*) FROM agent_rollout_servers WHERE rollout_id = ? GROUP BY status", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var n int
+		if rows.Scan(&status, &n) != nil {
+			continue
+		}
+		r.Progress.Targeted += n
+		switch status {
+		case "pending":
+			r.Progress.Pending = n
+		case "updated":
+			r.Progress.Updated = n
+		case "failed":
+			r.Progress.Failed = n
+		}
+	}
+	return &r, nil
+}
+
+// expandRollout gives the target version to the next servers in rollout order
+// until the rollout's percentage of the fleet is targeted
+func expandRollout(r *models.AgentRollout) error {
+	candidates, err := rolloutOrder(r.ID, r.Groups)
+	if err != nil {
+		return err
+	}
+	want := (len(candidates)*r.Percentage + 99) / 100
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	targeted := map[string]bool{}
+	rows, err := tx.Query("SELECT server_id FROM agent_rollout_servers WHERE rollout_id = ?", r.ID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id string
+		if rows.Scan(&id) == nil {
+			targeted[id] = true
+		}
+	}
+	rows.Close()
+
+	now := time.Now().Unix()
+	var notify []string
+	for _, cand := range candidates {
+		if len(targeted) >= want {
+			break
+		}
+		if targeted[cand.id] {
+			continue
+		}
+		var current string
+		tx.QueryRow("SELECT COALESCE(agent_version, '') FROM servers WHERE id = ?", cand.id).Scan(&current)
+		status, updatedAt := "pending", sql.NullInt64{}
+		if current == r.Version {
+			status, updatedAt = "updated", sql.NullInt64{Int64: now, Valid: true}
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO agent_rollout_servers (rollout_id, server_id, group_tag, status, targeted_at, updated_at)
+			VALUES (?, ?, NULLIF(?, ''), ?, ?, ?)
+		`, r.ID, cand.id, cand.group, status, now, updatedAt); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("UPDATE servers SET target_version = ? WHERE id = ?", r.Version, cand.id); err != nil {
+			return err
+		}
+		targeted[cand.id] = true
+		notify = append(notify, cand.id)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	for _, id := range notify {
+		push.Notify(id)
+	}
+	if len(notify) > 0 {
+		log.Printf("🚀 Rollout %d: v%s targeted at %d more servers (%d%%)", r.ID, r.Version, len(notify), r.Percentage)
+	}
+	return checkRolloutComplete(r.ID)
+}
+
+// checkRolloutComplete completes an active rollout at 100% once no targeted
+// server is still pending
+func checkRolloutComplete(id int64) error {
+	r, err := loadRollout(id)
+	if err != nil {
+		return err
+	}
+	if r.Status != "active" || r.Percentage < 100 || r.Progress.Pending > 0 {
+		return nil
+	}
+	_, err = database.DB.Exec("UPDATE agent_rollouts SET status = 'completed', updated_at = ? WHERE id = ?", time.Now().Unix(), id)
+	if err == nil {
+		log.Printf("✅ Rollout %d of agent v%s completed (%d updated, %d failed)", id, r.Version, r.Progress.Updated, r.Progress.Failed)
+	}
+	return err
+}
+
+// rolloutServerRegistered records that a server registered with an agent version
+func rolloutServerRegistered(serverID, version string) {
+	rows, err := database.DB.Query(`
+		SELECT rs.rollout_id FROM agent_rollout_servers rs
+		JOIN agent_rollouts r ON r.id = rs.rollout_id
+		WHERE rs.server_id = ? AND rs.status = 'pending' AND r.version = ?
+	`, serverID, version)
+	if err != nil {
+		return
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		database.DB.Exec("UPDATE agent_rollout_servers SET status = 'updated', updated_at = ? WHERE rollout_id = ? AND server_id = ?",
+			time.Now().Unix(), id, serverID)
+		if err := checkRolloutComplete(id); err != nil {
+			log.Printf("Failed to check rollout %d: %v", id, err)
+		}
+	}
+}
+
+// rolloutServerFailed records a rolled back update and halts the rollout once
+// it reached its failure budget
+func rolloutServerFailed(serverID, hostname, version, message string) {
+	var id int64
+	var maxFailures int
+	err := database.DB.QueryRow(`
+		SELECT r.id, r.max_failures FROM agent_rollout_servers rs
+		JOIN agent_rollouts r ON r.id = rs.rollout_id
+		WHERE rs.server_id = ? AND rs.status != 'failed' AND r.version = ? AND r.status IN ('active', 'paused')
+	`, serverID, version).Scan(&id, &maxFailures)
+	if err != nil {
+		return
+	}
+	now := time.Now().Unix()
+	database.DB.Exec("UPDATE agent_rollout_servers SET status = 'failed', updated_at = ?, error = ? WHERE rollout_id = ? AND server_id = ?",
+		now, message, id, serverID)
+
+	var failed int
+	database.DB.QueryRow("SELECT COUNT(*) FROM agent_rollout_servers WHERE rollout_id = ? AND status = 'failed'", id).Scan(&failed)
+	if maxFailures <= 0 || failed < maxFailures {
+		return
+	}
+	res, err := database.DB.Exec("UPDATE agent_rollouts SET status = 'failed', updated_at = ? WHERE id = ? AND status = 'active'", now, id)
+	if err != nil {
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return
+	}
+
+	msg := fmt.Sprintf("Rollout of agent v%s halted after %d failed updates (last on %s: %s)", version, failed, hostname, message)
+	log.Printf("❌ %s", msg)
+	database.DB.Exec(`
+		INSERT INTO events (server_id, timestamp, type, severity, message)
+		VALUES (?, ?, 'update', 'critical', ?)
+	`, serverID, now, msg)
+	go func() {
+		if Notifier == nil {
+			return
+		}
+		Notifier.Notify(notifications.Notification{
+			Subject: fmt.Sprintf("[CRITICAL] Agent Rollout v%s Halted", version),
+			Message: msg,
+			Type:    notifications.TypeCritical,
+		})
+	}()
+}
+
+// parseRolloutGroups validates the group order (tags, kept in the given order)
+func parseRolloutGroups(groups []string) ([]string, error) {
+	out := []string{}
+	for _, group := range groups {
+		group = strings.ToLower(strings.TrimSpace(group))
+		if group == "" || containsString(out, group) {
+			continue
+		}
+		if !tagPattern.MatchString(group) {
+			return nil, fmt.Errorf("invalid group tag: %s", group)
+		}
+		out = append(out, group)
+	}
+	return out, nil
+}
+
+// GetRollouts lists rollouts, newest first
+func GetRollouts(c *fiber.Ctx) error {
+	rows, err := database.DB.Query("SELECT id FROM agent_rollouts ORDER BY id DESC LIMIT 50")
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	rollouts := []models.AgentRollout{}
+	for _, id := range ids {
+		if r, err := loadRollout(id); err == nil {
+			rollouts = append(rollouts, *r)
+		}
+	}
+	return c.JSON(rollouts)
+}
+
+// GetRollout returns a rollout with the state of every targeted server
+func GetRollout(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid rollout ID"})
+	}
+	r, err := loadRollout(int64(id))
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "Rollout not found"})
+	} else if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT rs.server_id, COALESCE(s.hostname, rs.server_id), COALESCE(rs.group_tag, ''), COALESCE(s.agent_version, ''),
+			rs.status, rs.targeted_at, COALESCE(rs.updated_at, 0), COALESCE(rs.error, '')
+		FROM agent_rollout_servers rs
+		LEFT JOIN servers s ON s.id = rs.server_id
+		WHERE rs.rollout_id = ?
+		ORDER BY rs.targeted_at, s.hostname
+	`, r.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+	r.Servers = []models.RolloutServer{}
+	for rows.Next() {
+		var s models.RolloutServer
+		if err := rows.Scan(&s.ServerID, &s.Hostname, &s.Group, &s.AgentVersion, &s.Status, &s.TargetedAt, &s.UpdatedAt, &s.Error); err != nil {
+			continue
+		}
+		r.Servers = append(r.Servers, s)
+	}
+	return c.JSON(r)
+}
+
+// CreateRollout starts rolling out an agent version
+func CreateRollout(c *fiber.Ctx) error {
+	var req struct {
+		Version     string   `json:"version"`
+		Percentage  int      `json:"percentage"`
+		Groups      []string `json:"groups"`
+		MaxFailures *int     `json:"max_failures"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	req.Version = strings.TrimPrefix(strings.TrimSpace(req.Version), "v")
+	if !versionPattern.MatchString(req.Version) {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid version"})
+	}
+	if !agentVersionAvailable(req.Version) {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("No agent binaries for v%s in %s", req.Version, versionBinaryDir(req.Version))})
+	}
+	if req.Percentage < 1 || req.Percentage > 100 {
+		return c.Status(400).JSON(fiber.Map{"error": "Percentage must be between 1 and 100"})
+	}
+	groups, err := parseRolloutGroups(req.Groups)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	maxFailures := 1
+	if req.MaxFailures != nil {
+		if *req.MaxFailures < 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "max_failures must not be negative"})
+		}
+		maxFailures = *req.MaxFailures
+	}
+	if rolloutInProgress() {
+		return c.Status(409).JSON(fiber.Map{"error": "Another rollout is in progress; complete or cancel it first"})
+	}
+
+	username, _ := c.Locals("username").(string)
+	groupsJSON, _ := json.Marshal(groups)
+	now := time.Now().Unix()
+	res, err := database.DB.Exec(`
+		INSERT INTO agent_rollouts (version, percentage, group_order, max_failures, status, created_by, created_at, updated_at)
+		VALUES (?, ?, ?, ?, 'active', ?, ?, ?)
+	`, req.Version, req.Percentage, string(groupsJSON), maxFailures, username, now, now)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create rollout"})
+	}
+	id, _ := res.LastInsertId()
+
+	r, err := loadRollout(id)
+	if err == nil {
+		err = expandRollout(r)
+	}
+	if err != nil {
+		log.Printf("Failed to start rollout %d: %v", id, err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to start rollout"})
+	}
+	recordAudit(c, "rollout.create", req.Version, fmt.Sprintf("%d%% groups=%s", req.Percentage, strings.Join(groups, ",")))
+
+	r, _ = loadRollout(id)
+	return c.Status(201).JSON(r)
+}
+
+// UpdateRollout widens a rollout (percentage) or changes its failure budget
+func UpdateRollout(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid rollout ID"})
+	}
+	var req struct {
+		Percentage  *int `json:"percentage"`
+		MaxFailures *int `json:"max_failures"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	r, err := loadRollout(int64(id))
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "Rollout not found"})
+	} else if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	if r.Status == "completed" || r.Status == "cancelled" {
+		return c.Status(409).JSON(fiber.Map{"error": fmt.Sprintf("Rollout is %s", r.Status)})
+	}
+
+	if req.Percentage != nil {
+		if *req.Percentage < r.Percentage || *req.Percentage > 100 {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("Percentage must be between %d and 100 (cancel the rollout to stop it)", r.Percentage)})
+		}
+		r.Percentage = *req.Percentage
+	}
+	if req.MaxFailures != nil {
+		if *req.MaxFailures < 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "max_failures must not be negative"})
+		}
+		r.MaxFailures = *req.MaxFailures
+	}
+	if _, err := database.DB.Exec("UPDATE agent_rollouts SET percentage = ?, max_failures = ?, updated_at = ? WHERE id = ?",
+		r.Percentage, r.MaxFailures, time.Now().Unix(), r.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update rollout"})
+	}
+	// Paused and halted rollouts widen once resumed
+	if r.Status == "active" {
+		if err := expandRollout(r); err != nil {
+			log.Printf("Failed to expand rollout %d: %v", r.ID, err)
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to expand rollout"})
+		}
+	}
+	recordAudit(c, "rollout.update", r.Version, fmt.Sprintf("%d%% max_failures=%d", r.Percentage, r.MaxFailures))
+
+	r, _ = loadRollout(r.ID)
+	return c.JSON(r)
+}
+
+// RolloutAction pauses, resumes or cancels a rollout. Cancelling clears the
+// target version of its servers, which return to the published version.
+func RolloutAction(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid rollout ID"})
+	}
+	r, err := loadRollout(int64(id))
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "Rollout not found"})
+	} else if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+
+	action := c.Params("action")
+	var from []string
+	var to string
+	switch action {
+	case "pause":
+		from, to = []string{"active"}, "paused"
+	case "resume":
+		from, to = []string{"paused", "failed"}, "active"
+	case "cancel":
+		from, to = []string{"active", "paused", "failed"}, "cancelled"
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "Unknown action (use pause, resume or cancel)"})
+	}
+	if !containsString(from, r.Status) {
+		return c.Status(409).JSON(fiber.Map{"error": fmt.Sprintf("Cannot %s a rollout that is %s", action, r.Status)})
+	}
+
+	if _, err := database.DB.Exec("UPDATE agent_rollouts SET status = ?, updated_at = ? WHERE id = ?", to, time.Now().Unix(), r.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	r.Status = to
+	switch action {
+	case "resume":
+		if err := expandRollout(r); err != nil {
+			log.Printf("Failed to expand rollout %d: %v", r.ID, err)
+		}
+	case "cancel":
+		database.DB.Exec(`
+			UPDATE servers SET target_version = NULL
+			WHERE target_version = ? AND id IN (SELECT server_id FROM agent_rollout_servers WHERE rollout_id = ?)
+		`, r.Version, r.ID)
+		push.NotifyAll()
+	}
+	recordAudit(c, "rollout."+action, r.Version, fmt.Sprintf("rollout %d", r.ID))
+
+	r, _ = loadRollout(r.ID)
+	return c.JSON(r)
+}
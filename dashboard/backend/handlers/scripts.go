@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/push"
+)
+
+// maxScriptOutput caps the output stored per host
+const maxScriptOutput = 64 * 1024
+
+// GetScripts returns the script allow-list
+func GetScripts(c *fiber.Ctx) error {
+	rows, err := database.DB.Query(`
+		SELECT id, name, COALESCE(description, ''), content, sha256, COALESCE(timeout, 300), created_by, created_at
+		FROM scripts
+		ORDER BY name
+	`)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	scripts := []models.Script{}
+	for rows.Next() {
+		var s models.Script
+		if err := rows.Scan(&s.ID, &s.Name, &s.Description, &s.Content, &s.SHA256, &s.Timeout, &s.CreatedBy, &s.CreatedAt); err != nil {
+			continue
+		}
+		scripts = append(scripts, s)
+	}
+
+	return c.JSON(scripts)
+}
+
+// CreateScript adds a script to the allow-list
+func CreateScript(c *fiber.Ctx) error {
+	var req struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Content     string `json:"content"`
+		Timeout     int    `json:"timeout"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || strings.TrimSpace(req.Content) == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Name and content are required"})
+	}
+	if req.Timeout <= 0 {
+		req.Timeout = 300
+	}
+
+	sum := sha256.Sum256([]byte(req.Content))
+	checksum := hex.EncodeToString(sum[:])
+	username, _ := c.Locals("username").(string)
+
+	res, err := database.DB.Exec(`
+		INSERT INTO scripts (name, description, content, sha256, timeout, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, req.Name, req.Description, req.Content, checksum, req.Timeout, username, time.Now().Unix())
+	if err != nil {
+		return c.Status(409).JSON(fiber.Map{"error": "A script with this name already exists"})
+	}
+	id, _ := res.LastInsertId()
+
+	recordAudit(c, "script.create", req.Name, fmt.Sprintf("sha256=%s", checksum))
+	return c.JSON(fiber.Map{"id": id, "sha256": checksum})
+}
+
+// DeleteScript removes a script from the allow-list (and its run history)
+func DeleteScript(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var name string
+	if err := database.DB.QueryRow("SELECT name FROM scripts WHERE id = ?", id).Scan(&name); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Script not found"})
+	}
+
+	if _, err := database.DB.Exec("DELETE FROM scripts WHERE id = ?", id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete script"})
+	}
+
+	recordAudit(c, "script.delete", name, "")
+	return c.JSON(fiber.Map{"status": "deleted"})
+}
+
+// RunScript schedules an allow-listed script on the targeted servers
+func RunScript(c *fiber.Ctx) error {
+	scriptID := c.Params("id")
+
+	var req struct {
+		ServerIDs   []string `json:"server_ids"`
+		Tags        []string `json:"tags"` // servers carrying any of the tags
+		All         bool     `json:"all"`
+		ScheduledAt int64    `json:"scheduled_at"` // Unix seconds, 0 = now
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var name string
+	if err := database.DB.QueryRow("SELECT name FROM scripts WHERE id = ?", scriptID).Scan(&name); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Script not found"})
+	}
+
+	// Resolve targets: the listed servers plus those carrying a tag, or all
+	query, args := "", []interface{}{}
+	switch {
+	case req.All:
+		query = "SELECT id FROM servers"
+	case len(tags) > 0:
+		query = "SELECT DISTINCT server_id FROM server_tags WHERE tag IN (" + strings.TrimSuffix(strings.Repeat("?,", len(tags)), ",") + ")"
+		for _, tag := range tags {
+			args = append(args, tag)
+		}
+	}
+	targets := req.ServerIDs
+	if req.All {
+		targets = nil
+	}
+	if query != "" {
+		rows, err := database.DB.Query(query, args...)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+		}
+		for rows.Next() {
+			var id string
+			if rows.Scan(&id) == nil {
+				targets = append(targets, id)
+			}
+		}
+		rows.Close()
+	}
+	// Users limited to tagged servers can only target those
+	seen := make(map[string]bool)
+	var visibleTargets []string
+	for _, id := range targets {
+		if !seen[id] && middleware.CanSeeServer(c, id) {
+			visibleTargets = append(visibleTargets, id)
+		}
+		seen[id] = true
+	}
+	targets = visibleTargets
+	if len(targets) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "No target servers"})
+	}
+
+	now := time.Now().Unix()
+	if req.ScheduledAt <= 0 {
+		req.ScheduledAt = now
+	}
+	username, _ := c.Locals("username").(string)
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		INSERT INTO script_runs (script_id, scheduled_at, created_by, created_at)
+		VALUES (?, ?, ?, ?)
+	`, scriptID, req.ScheduledAt, username, now)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create run"})
+	}
+	runID, _ := res.LastInsertId()
+
+	var queued []string
+	for _, serverID := range targets {
+		var exists int
+		if tx.QueryRow("SELECT 1 FROM servers WHERE id = ?", serverID).Scan(&exists) != nil {
+			continue
+		}
+		if _, err := tx.Exec("INSERT INTO script_results (run_id, server_id, status) VALUES (?, ?, 'pending')", runID, serverID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to queue run"})
+		}
+		queued = append(queued, serverID)
+	}
+	if len(queued) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "No valid target servers"})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create run"})
+	}
+	for _, serverID := range queued {
+		push.Notify(serverID)
+	}
+
+	recordAudit(c, "script.run", name, fmt.Sprintf("run_id=%d servers=%d scheduled_at=%d", runID, len(queued), req.ScheduledAt))
+	return c.JSON(fiber.Map{"run_id": runID, "servers": len(queued)})
+}
+
+// GetScriptRuns returns recent script runs with progress counters
+func GetScriptRuns(c *fiber.Ctx) error {
+	rows, err := database.DB.Query(`
+		SELECT r.id, r.script_id, s.name, r.scheduled_at, r.created_by, r.created_at,
+		       COUNT(res.id),
+		       SUM(CASE WHEN res.status = 'completed' THEN 1 ELSE 0 END),
+		       SUM(CASE WHEN res.status = 'completed' AND res.exit_code != 0 THEN 1 ELSE 0 END)
+		FROM script_runs r
+		JOIN scripts s ON s.id = r.script_id
+		LEFT JOIN script_results res ON res.run_id = r.id
+		GROUP BY r.id
+		ORDER BY r.created_at DESC
+		LIMIT 100
+	`)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	runs := []models.ScriptRun{}
+	for rows.Next() {
+		var r models.ScriptRun
+		if err := rows.Scan(&r.ID, &r.ScriptID, &r.ScriptName, &r.ScheduledAt, &r.CreatedBy, &r.CreatedAt, &r.Total, &r.Completed, &r.Failed); err != nil {
+			continue
+		}
+		runs = append(runs, r)
+	}
+
+	return c.JSON(runs)
+}
+
+// GetScriptRun returns a single run with per-host exit codes and output
+func GetScriptRun(c *fiber.Ctx) error {
+	runID := c.Params("id")
+
+	var r models.ScriptRun
+	err := database.DB.QueryRow(`
+		SELECT r.id, r.script_id, s.name, r.scheduled_at, r.created_by, r.created_at
+		FROM script_runs r
+		JOIN scripts s ON s.id = r.script_id
+		WHERE r.id = ?
+	`, runID).Scan(&r.ID, &r.ScriptID, &r.ScriptName, &r.ScheduledAt, &r.CreatedBy, &r.CreatedAt)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Run not found"})
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT res.id, res.run_id, res.server_id, COALESCE(sv.hostname, ''), res.status, res.exit_code,
+		       COALESCE(res.output, ''), res.dispatched_at, res.started_at, res.finished_at
+		FROM script_results res
+		LEFT JOIN servers sv ON sv.id = res.server_id
+		WHERE res.run_id = ?
+		ORDER BY sv.hostname
+	`, runID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	r.Results = []models.ScriptResult{}
+	for rows.Next() {
+		var res models.ScriptResult
+		if err := rows.Scan(&res.ID, &res.RunID, &res.ServerID, &res.Hostname, &res.Status, &res.ExitCode,
+			&res.Output, &res.DispatchedAt, &res.StartedAt, &res.FinishedAt); err != nil {
+			continue
+		}
+		if !middleware.CanSeeServer(c, res.ServerID) {
+			continue
+		}
+		r.Total++
+		if res.Status == "completed" {
+			r.Completed++
+			if res.ExitCode != nil && *res.ExitCode != 0 {
+				r.Failed++
+			}
+		}
+		r.Results = append(r.Results, res)
+	}
+
+	return c.JSON(r)
+}
+
+// pendingScripts returns due script executions for a server and marks them dispatched.
+// Delivery is at-most-once: a dispatched script is never resent, even if the agent
+// never reports back.
+func pendingScripts(serverID string) []models.AgentScript {
+	now := time.Now().Unix()
+	rows, err := database.DB.Query(`
+		SELECT res.id, s.name, s.content, s.sha256, COALESCE(s.timeout, 300)
+		FROM script_results res
+		JOIN script_runs r ON r.id = res.run_id
+		JOIN scripts s ON s.id = r.script_id
+		WHERE res.server_id = ? AND res.status = 'pending' AND r.scheduled_at <= ?
+		ORDER BY r.scheduled_at, res.id
+	`, serverID, now)
+	if err != nil {
+		log.Printf("Failed to load pending scripts for %s: %v", serverID, err)
+		return nil
+	}
+
+	var cmds []models.AgentScript
+	for rows.Next() {
+		var cmd models.AgentScript
+		if err := rows.Scan(&cmd.ResultID, &cmd.Name, &cmd.Content, &cmd.SHA256, &cmd.Timeout); err != nil {
+			continue
+		}
+		cmds = append(cmds, cmd)
+	}
+	rows.Close()
+
+	dispatched := cmds[:0]
+	for _, cmd := range cmds {
+		res, err := database.DB.Exec("UPDATE script_results SET status = 'dispatched', dispatched_at = ? WHERE id = ? AND status = 'pending'", now, cmd.ResultID)
+		if err != nil {
+			continue
+		}
+		if n, _ := res.RowsAffected(); n == 1 {
+			dispatched = append(dispatched, cmd)
+		}
+	}
+
+	return dispatched
+}
+
+// AgentScriptResult receives the outcome of a script execution from an agent
+func AgentScriptResult(c *fiber.Ctx) error {
+	var req struct {
+		ServerID   string `json:"server_id"`
+		APISecret  string `json:"api_secret"`
+		ResultID   int64  `json:"result_id"`
+		ExitCode   int    `json:"exit_code"`
+		Output     string `json:"output"`
+		StartedAt  int64  `json:"started_at"`
+		FinishedAt int64  `json:"finished_at"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	if !authenticateAgent(c, req.ServerID, req.APISecret) {
+		return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
+	}
+
+	if len(req.Output) > maxScriptOutput {
+		req.Output = req.Output[len(req.Output)-maxScriptOutput:]
+	}
+
+	// Scoped to the reporting server so agents cannot overwrite each other's results
+	res, err := database.DB.Exec(`
+		UPDATE script_results
+		SET status = 'completed', exit_code = ?, output = ?, started_at = ?, finished_at = ?
+		WHERE id = ? AND server_id = ? AND status = 'dispatched'
+	`, req.ExitCode, req.Output, req.StartedAt, req.FinishedAt, req.ResultID, req.ServerID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to store result"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Unknown or already completed result"})
+	}
+
+	return c.JSON(fiber.Map{"status": "ok"})
+}
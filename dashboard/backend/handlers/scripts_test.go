@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+func TestRunScriptTargetsTagsWithinScope(t *testing.T) {
+	testDB(t)
+	seedServer(t, "s1", "web-1", "web")
+	seedServer(t, "s2", "web-2", "web", "eu")
+	seedServer(t, "s3", "db-1", "db", "eu")
+	mustExec(t, `INSERT INTO scripts (id, name, content, sha256, created_by, created_at) VALUES (1, 'uptime', 'uptime', 'x', 'admin', 0)`)
+	mustExec(t, `INSERT INTO users (id, username, password_hash, created_at, role, allowed_tags) VALUES (7, 'bob', 'x', 0, 'operator', 'web')`)
+
+	run := func(userID int64, body string) (int, []string) {
+		t.Helper()
+		app := fiber.New()
+		app.Use(func(c *fiber.Ctx) error {
+			c.Locals("user_id", userID)
+			c.Locals("role", "operator")
+			return c.Next()
+		})
+		app.Post("/scripts/:id/run", RunScript)
+		req := httptest.NewRequest("POST", "/scripts/1/run", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var servers []string
+		rows, err := database.DB.Query(`SELECT server_id FROM script_results WHERE run_id = (SELECT MAX(id) FROM script_runs)`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id string
+			rows.Scan(&id)
+			servers = append(servers, id)
+		}
+		sort.Strings(servers)
+		mustExec(t, `DELETE FROM script_results`)
+		mustExec(t, `DELETE FROM script_runs`)
+		return resp.StatusCode, servers
+	}
+
+	// An unrestricted user (no allowed_tags row) reaches every tagged server, once
+	if code, got := run(0, `{"tags": ["EU"], "server_ids": ["s2"]}`); code != 200 || strings.Join(got, ",") != "s2,s3" {
+		t.Errorf("tags=eu: status %d, servers %v; want s2,s3", code, got)
+	}
+	// A user limited to "web" only gets the tagged servers it can see
+	if code, got := run(7, `{"tags": ["eu"]}`); code != 200 || strings.Join(got, ",") != "s2" {
+		t.Errorf("scoped tags=eu: status %d, servers %v; want s2", code, got)
+	}
+	if code, _ := run(7, `{"tags": ["db"]}`); code != 400 {
+		t.Errorf("scoped tags=db: status %d, want 400", code)
+	}
+	if code, _ := run(0, `{"tags": ["not a tag"]}`); code != 400 {
+		t.Errorf("invalid tag: status %d, want 400", code)
+	}
+}
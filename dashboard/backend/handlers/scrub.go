@@ -0,0 +1,584 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/maintenance"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+const redacted = "[REDACTED]"
+
+// Locations scanned for personal data outside the database
+var (
+	backendLogGlobs = []string{"/data/backend.log", "/data/backend-*.log", "/data/backend-*.log.gz"}
+	agentLogDir     = "/data/logs"
+)
+
+// scrubber applies one scrub request; counts are collected into the report
+type scrubber struct {
+	serverID string
+	pattern  *regexp.Regexp
+	purge    bool
+	dryRun   bool
+	report   *models.ScrubReport
+}
+
+// ScrubData purges or anonymizes data for a server and/or matching a pattern, and returns a deletion report.
+// Body: {"server_id": "...", "pattern": "regex", "mode": "purge"|"anonymize", "dry_run": true}
+func ScrubData(c *fiber.Ctx) error {
+	var req struct {
+		ServerID string `json:"server_id"`
+		Pattern  string `json:"pattern"`
+		Mode     string `json:"mode"`
+		DryRun   bool   `json:"dry_run"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.Mode != "purge" && req.Mode != "anonymize" {
+		return c.Status(400).JSON(fiber.Map{"error": "Mode must be 'purge' or 'anonymize'"})
+	}
+	if req.ServerID == "" && req.Pattern == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "server_id or pattern is required"})
+	}
+	if req.Mode == "anonymize" && req.Pattern == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Anonymize requires a pattern"})
+	}
+
+	s := &scrubber{
+		serverID: req.ServerID,
+		purge:    req.Mode == "purge",
+		dryRun:   req.DryRun,
+		report: &models.ScrubReport{
+			Mode:     req.Mode,
+			DryRun:   req.DryRun,
+			ServerID: req.ServerID,
+			Pattern:  req.Pattern,
+			Tables:   map[string]int64{},
+			Files:    []models.ScrubFile{},
+		},
+	}
+	if req.Pattern != "" {
+		re, err := regexp.Compile(req.Pattern)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid pattern: " + err.Error()})
+		}
+		s.pattern = re
+	}
+	if req.ServerID != "" {
+		var exists int
+		if err := database.DB.QueryRow("SELECT 1 FROM servers WHERE id = ?", req.ServerID).Scan(&exists); err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+		}
+	}
+
+	if s.pattern == nil {
+		if err := s.purgeServerRows(); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to purge server data: " + err.Error()})
+		}
+	} else {
+		if err := s.scrubEvents(); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to scrub events: " + err.Error()})
+		}
+		if err := s.scrubEventComments(); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to scrub event comments: " + err.Error()})
+		}
+		if err := s.scrubMetrics(); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to scrub metrics: " + err.Error()})
+		}
+	}
+	s.scrubFiles()
+
+	recordAudit(c, "data.scrub", req.ServerID, fmt.Sprintf("mode=%s pattern=%q dry_run=%t", req.Mode, req.Pattern, req.DryRun))
+	return c.JSON(s.report)
+}
+
+// purgedTables are the tables purgeServerRows clears, with the condition
+// selecting a server's rows. Comments go before their events (which would
+// cascade them uncounted).
+var purgedTables = []struct{ table, where string }{
+	{"metrics", "server_id = ?"},
+	{maintenance.Rollup5m.Table, "server_id = ?"},
+	{maintenance.Rollup1h.Table, "server_id = ?"},
+	{"event_comments", "event_id IN (SELECT id FROM events WHERE server_id = ?)"},
+	{"events", "server_id = ?"},
+	{"remediations", "server_id = ?"},
+	{"cron_pauses", "server_id = ?"},
+	{"cron_runs", "server_id = ?"},
+	{"cron_failure_streaks", "server_id = ?"},
+	{"script_results", "server_id = ?"},
+	{"server_packages", "server_id = ?"},
+	{"terminal_sessions", "server_id = ?"},
+}
+
+// purgeServerRows deletes all history for a server, including the metrics
+// archives; the server registration itself is kept
+func (s *scrubber) purgeServerRows() error {
+	for _, t := range purgedTables {
+		table := t.table
+		var n int64
+		if s.dryRun {
+			if err := database.DB.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE "+t.where, s.serverID).Scan(&n); err != nil {
+				return err
+			}
+		} else {
+			// terminal_sessions rows are needed to locate recordings; they are removed after the files
+			if table == "terminal_sessions" {
+				continue
+			}
+			res, err := database.DB.Exec("DELETE FROM "+table+" WHERE "+t.where, s.serverID)
+			if err != nil {
+				return err
+			}
+			n, _ = res.RowsAffected()
+		}
+		s.report.Tables[table] = n
+	}
+
+	return s.eachArchive(func(db *sql.DB) error {
+		for _, table := range []string{"metrics", maintenance.Rollup1h.Table} {
+			var n int64
+			if s.dryRun {
+				if err := db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE server_id = ?", s.serverID).Scan(&n); err != nil {
+					return err
+				}
+			} else {
+				res, err := db.Exec("DELETE FROM "+table+" WHERE server_id = ?", s.serverID)
+				if err != nil {
+					return err
+				}
+				n, _ = res.RowsAffected()
+			}
+			s.report.Tables["archive."+table] += n
+		}
+		return nil
+	})
+}
+
+// eachArchive calls fn with every metrics archive, opened for changes. Old
+// archives are scrubbed even if archiving has been switched off since.
+func (s *scrubber) eachArchive(fn func(db *sql.DB) error) error {
+	for _, archive := range maintenance.ListMetricsArchives() {
+		db, err := maintenance.OpenMetricsArchive(archive.File)
+		if err != nil {
+			return err
+		}
+		err = fn(db)
+		db.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", archive.File, err)
+		}
+	}
+	return nil
+}
+
+// scrubEvents deletes or redacts events whose message or details match the pattern
+func (s *scrubber) scrubEvents() error {
+	rows, err := database.DB.Query("SELECT id, message, COALESCE(details, '') FROM events WHERE ? = '' OR server_id = ?", s.serverID, s.serverID)
+	if err != nil {
+		return err
+	}
+	type change struct {
+		id               int64
+		message, details string
+	}
+	var changes []change
+	for rows.Next() {
+		var ch change
+		if err := rows.Scan(&ch.id, &ch.message, &ch.details); err != nil {
+			continue
+		}
+		if !s.pattern.MatchString(ch.message) && !s.pattern.MatchString(ch.details) {
+			continue
+		}
+		ch.message = s.pattern.ReplaceAllString(ch.message, redacted)
+		ch.details = s.redactLine(ch.details)
+		changes = append(changes, ch)
+	}
+	rows.Close()
+
+	s.report.Tables["events"] = int64(len(changes))
+	if s.dryRun {
+		return nil
+	}
+	for _, ch := range changes {
+		if s.purge {
+			// The event's comments go with it
+			var res sql.Result
+			if res, err = database.DB.Exec("DELETE FROM event_comments WHERE event_id = ?", ch.id); err != nil {
+				return err
+			}
+			n, _ := res.RowsAffected()
+			s.report.Tables["event_comments"] += n
+			_, err = database.DB.Exec("DELETE FROM events WHERE id = ?", ch.id)
+		} else {
+			_, err = database.DB.Exec("UPDATE events SET message = ?, details = ? WHERE id = ?", ch.message, ch.details, ch.id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scrubEventComments deletes or redacts event comments whose body matches
+func (s *scrubber) scrubEventComments() error {
+	rows, err := database.DB.Query(`
+		SELECT c.id, c.body FROM event_comments c JOIN events e ON e.id = c.event_id
+		WHERE ? = '' OR e.server_id = ?
+	`, s.serverID, s.serverID)
+	if err != nil {
+		return err
+	}
+	changes := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var body string
+		if err := rows.Scan(&id, &body); err != nil {
+			continue
+		}
+		if s.pattern.MatchString(body) {
+			changes[id] = s.pattern.ReplaceAllString(body, redacted)
+		}
+	}
+	rows.Close()
+
+	s.report.Tables["event_comments"] += int64(len(changes))
+	if s.dryRun {
+		return nil
+	}
+	for id, body := range changes {
+		if s.purge {
+			_, err = database.DB.Exec("DELETE FROM event_comments WHERE id = ?", id)
+		} else {
+			_, err = database.DB.Exec("UPDATE event_comments SET body = ? WHERE id = ?", body, id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scrubMetrics deletes or redacts metric rows whose process list matches
+// (usernames, process names), in the database and the metrics archives. The
+// rollup tables hold no process lists, so anonymizing leaves them as they
+// are; purging also drops the rollup buckets the purged samples were
+// aggregated into.
+func (s *scrubber) scrubMetrics() error {
+	if err := s.scrubMetricRows(database.DB, "", []maintenance.MetricRollup{maintenance.Rollup5m, maintenance.Rollup1h}); err != nil {
+		return err
+	}
+	return s.eachArchive(func(db *sql.DB) error {
+		return s.scrubMetricRows(db, "archive.", []maintenance.MetricRollup{maintenance.Rollup1h})
+	})
+}
+
+// scrubMetricRows scrubs the metrics of one database; report keys get prefix
+func (s *scrubber) scrubMetricRows(db *sql.DB, prefix string, rollups []maintenance.MetricRollup) error {
+	rows, err := db.Query(`
+		SELECT id, server_id, timestamp, processes FROM metrics
+		WHERE processes IS NOT NULL AND processes != '' AND (? = '' OR server_id = ?)
+	`, s.serverID, s.serverID)
+	if err != nil {
+		return err
+	}
+	type sample struct {
+		serverID  string
+		timestamp int64
+	}
+	changes := map[int64]string{}
+	samples := map[int64]sample{}
+	for rows.Next() {
+		var id int64
+		var sm sample
+		var processes string
+		if err := rows.Scan(&id, &sm.serverID, &sm.timestamp, &processes); err != nil {
+			continue
+		}
+		if s.pattern.MatchString(processes) {
+			changes[id] = s.redactLine(processes)
+			samples[id] = sm
+		}
+	}
+	rows.Close()
+
+	s.report.Tables[prefix+"metrics"] += int64(len(changes))
+	if s.purge {
+		// Buckets holding purged samples, per rollup table
+		buckets := map[string]map[sample]bool{}
+		for _, r := range rollups {
+			buckets[r.Table] = map[sample]bool{}
+			for _, sm := range samples {
+				buckets[r.Table][sample{sm.serverID, sm.timestamp - sm.timestamp%r.Step}] = true
+			}
+		}
+		for _, r := range rollups {
+			for b := range buckets[r.Table] {
+				var n int64
+				if s.dryRun {
+					err = db.QueryRow("SELECT COUNT(*) FROM "+r.Table+" WHERE server_id = ? AND bucket = ?", b.serverID, b.timestamp).Scan(&n)
+				} else {
+					var res sql.Result
+					if res, err = db.Exec("DELETE FROM "+r.Table+" WHERE server_id = ? AND bucket = ?", b.serverID, b.timestamp); err == nil {
+						n, _ = res.RowsAffected()
+					}
+				}
+				if err != nil {
+					return err
+				}
+				s.report.Tables[prefix+r.Table] += n
+			}
+		}
+	}
+	if s.dryRun {
+		return nil
+	}
+	for id, processes := range changes {
+		if s.purge {
+			_, err = db.Exec("DELETE FROM metrics WHERE id = ?", id)
+		} else {
+			_, err = db.Exec("UPDATE metrics SET processes = ? WHERE id = ?", processes, id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scrubFiles handles backend logs (including rotated backups), agent log bundles and terminal recordings
+func (s *scrubber) scrubFiles() {
+	// Backend logs are shared by all servers: without a pattern, lines mentioning the server are dropped
+	for _, glob := range backendLogGlobs {
+		paths, _ := filepath.Glob(glob)
+		for _, path := range paths {
+			s.rewriteFile(path, s.lineMatcher())
+		}
+	}
+
+	bundleGlob := filepath.Join(agentLogDir, "*_logs.zip")
+	if s.serverID != "" {
+		bundleGlob = filepath.Join(agentLogDir, s.serverID+"_*_logs.zip")
+	}
+	bundles, _ := filepath.Glob(bundleGlob)
+	for _, path := range bundles {
+		if s.pattern == nil {
+			s.removeFile(path)
+		} else {
+			s.rewriteFile(path, s.pattern.MatchString)
+		}
+	}
+
+	var rows *sql.Rows
+	var err error
+	if s.serverID != "" {
+		rows, err = database.DB.Query("SELECT recording_path FROM terminal_sessions WHERE server_id = ?", s.serverID)
+	} else {
+		rows, err = database.DB.Query("SELECT recording_path FROM terminal_sessions")
+	}
+	if err == nil {
+		var recordings []string
+		for rows.Next() {
+			var path string
+			if rows.Scan(&path) == nil {
+				recordings = append(recordings, path)
+			}
+		}
+		rows.Close()
+		for _, path := range recordings {
+			if s.pattern == nil {
+				s.removeFile(path)
+			} else {
+				s.rewriteFile(path, s.pattern.MatchString)
+			}
+		}
+	}
+
+	if s.pattern == nil && !s.dryRun {
+		res, err := database.DB.Exec("DELETE FROM terminal_sessions WHERE server_id = ?", s.serverID)
+		if err == nil {
+			s.report.Tables["terminal_sessions"], _ = res.RowsAffected()
+		}
+		database.DB.Exec("UPDATE servers SET log_file_path = NULL, log_file_time = NULL WHERE id = ?", s.serverID)
+	}
+}
+
+// lineMatcher selects log lines by pattern, or by server ID for a whole-server purge
+func (s *scrubber) lineMatcher() func(string) bool {
+	if s.pattern != nil {
+		return s.pattern.MatchString
+	}
+	return func(line string) bool { return strings.Contains(line, s.serverID) }
+}
+
+// redactLine replaces matches in a line, preserving JSON structure when the line is JSON
+func (s *scrubber) redactLine(line string) string {
+	if s.pattern == nil {
+		return line
+	}
+	var v interface{}
+	if json.Unmarshal([]byte(line), &v) == nil {
+		if out, err := json.Marshal(s.redactValue(v)); err == nil {
+			return string(out)
+		}
+	}
+	return s.pattern.ReplaceAllString(line, redacted)
+}
+
+func (s *scrubber) redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return s.pattern.ReplaceAllString(t, redacted)
+	case []interface{}:
+		for i := range t {
+			t[i] = s.redactValue(t[i])
+		}
+	case map[string]interface{}:
+		for k := range t {
+			t[k] = s.redactValue(t[k])
+		}
+	}
+	return v
+}
+
+// scrubText drops (purge) or redacts (anonymize) matching lines and returns the match count
+func (s *scrubber) scrubText(data []byte, match func(string) bool) ([]byte, int) {
+	lines := strings.SplitAfter(string(data), "\n")
+	var out strings.Builder
+	hits := 0
+	for _, line := range lines {
+		if !match(line) {
+			out.WriteString(line)
+			continue
+		}
+		hits++
+		if s.purge {
+			continue
+		}
+		body := strings.TrimSuffix(line, "\n")
+		out.WriteString(s.redactLine(body))
+		if strings.HasSuffix(line, "\n") {
+			out.WriteString("\n")
+		}
+	}
+	return []byte(out.String()), hits
+}
+
+// rewriteFile scrubs a plain, gzip or zip file in place
+func (s *scrubber) rewriteFile(path string, match func(string) bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+
+	var out []byte
+	hits := 0
+	switch {
+	case strings.HasSuffix(path, ".gz"):
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			s.report.Files = append(s.report.Files, models.ScrubFile{Path: path, Action: "skipped", Error: err.Error()})
+			return
+		}
+		plain, err := io.ReadAll(zr)
+		if err != nil {
+			s.report.Files = append(s.report.Files, models.ScrubFile{Path: path, Action: "skipped", Error: err.Error()})
+			return
+		}
+		var text []byte
+		text, hits = s.scrubText(plain, match)
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(text)
+		zw.Close()
+		out = buf.Bytes()
+	case strings.HasSuffix(path, ".zip"):
+		out, hits, err = s.scrubZip(data, match)
+		if err != nil {
+			s.report.Files = append(s.report.Files, models.ScrubFile{Path: path, Action: "skipped", Error: err.Error()})
+			return
+		}
+	default:
+		out, hits = s.scrubText(data, match)
+	}
+
+	if hits == 0 {
+		return
+	}
+	action := "redacted"
+	if s.purge {
+		action = "lines_removed"
+	}
+	entry := models.ScrubFile{Path: path, Action: action, Matches: hits}
+	if !s.dryRun {
+		// Written in place (not renamed) so the open backend log keeps its inode
+		if err := os.WriteFile(path, out, 0644); err != nil {
+			entry.Error = err.Error()
+		}
+	}
+	s.report.Files = append(s.report.Files, entry)
+}
+
+// scrubZip scrubs every text entry of a log bundle
+func (s *scrubber) scrubZip(data []byte, match func(string) bool) ([]byte, int, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	hits := 0
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, 0, err
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		scrubbed, n := s.scrubText(content, match)
+		hits += n
+
+		header := f.FileHeader
+		w, err := zw.CreateHeader(&header)
+		if err != nil {
+			return nil, 0, err
+		}
+		w.Write(scrubbed)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), hits, nil
+}
+
+func (s *scrubber) removeFile(path string) {
+	if _, err := os.Stat(path); err != nil {
+		return
+	}
+	entry := models.ScrubFile{Path: path, Action: "deleted"}
+	if !s.dryRun {
+		if err := os.Remove(path); err != nil {
+			entry.Error = err.Error()
+		}
+	}
+	s.report.Files = append(s.report.Files, entry)
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// scrubFixture seeds two servers with history in every scrubbed table and
+// points the file locations at a temporary directory
+func scrubFixture(t *testing.T) (dir string) {
+	t.Helper()
+	testDB(t)
+	dir = t.TempDir()
+	oldGlobs, oldLogDir := backendLogGlobs, agentLogDir
+	backendLogGlobs = []string{filepath.Join(dir, "backend.log"), filepath.Join(dir, "backend-*.log.gz")}
+	agentLogDir = dir
+	t.Cleanup(func() { backendLogGlobs, agentLogDir = oldGlobs, oldLogDir })
+
+	for _, id := range []string{"s1", "s2"} {
+		seedServer(t, id, "host-"+id)
+		mustExec(t, `INSERT INTO metrics (server_id, timestamp, processes) VALUES (?, 3600, '[{"name":"sshd","user":"alice"}]'), (?, 7200, '[{"name":"nginx","user":"www"}]')`, id, id)
+		mustExec(t, `INSERT INTO metrics_5m (server_id, bucket, samples) VALUES (?, 3600, 1), (?, 7200, 1)`, id, id)
+		mustExec(t, `INSERT INTO metrics_1h (server_id, bucket, samples) VALUES (?, 3600, 1), (?, 7200, 1)`, id, id)
+		mustExec(t, `INSERT INTO events (server_id, timestamp, event_type, severity, message) VALUES (?, 1000, 'auth', 'warning', 'failed login for alice')`, id)
+		mustExec(t, `INSERT INTO event_comments (event_id, username, body, created_at) VALUES (last_insert_rowid(), 'admin', 'asked alice about it', 0)`)
+		recording := filepath.Join(dir, id+".cast")
+		writeFile(t, recording, "alice typed ls\n")
+		mustExec(t, `INSERT INTO terminal_sessions (id, server_id, username, started_at, recording_path) VALUES (?, ?, 'admin', 0, ?)`, "t-"+id, id, recording)
+		writeFile(t, filepath.Join(dir, id+"_1000_logs.zip"), "")
+	}
+	writeFile(t, filepath.Join(dir, "backend.log"), "agent s1 pushed metrics\nagent s2 pushed metrics\nlogin alice\n")
+	writeGzip(t, filepath.Join(dir, "backend-2026-01-01.log.gz"), "agent s1 registered\nlogin alice\n")
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeGzip(t *testing.T, path, content string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(content))
+	zw.Close()
+	writeFile(t, path, buf.String())
+}
+
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _ := io.ReadAll(zr)
+	return string(plain)
+}
+
+func scrub(t *testing.T, body string) models.ScrubReport {
+	t.Helper()
+	app := fiber.New()
+	app.Post("/admin/scrub", ScrubData)
+	req := httptest.NewRequest("POST", "/admin/scrub", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("scrub %s: status %d", body, resp.StatusCode)
+	}
+	var report models.ScrubReport
+	json.NewDecoder(resp.Body).Decode(&report)
+	return report
+}
+
+func countRows(t *testing.T, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	if err := database.DB.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestScrubPurgesServer(t *testing.T) {
+	dir := scrubFixture(t)
+	tables := []string{"metrics", "metrics_5m", "metrics_1h", "events", "terminal_sessions"}
+
+	dry := scrub(t, `{"server_id": "s1", "mode": "purge", "dry_run": true}`)
+	for _, table := range append(tables, "event_comments") {
+		if dry.Tables[table] == 0 {
+			t.Errorf("dry run reports no %s rows", table)
+		}
+	}
+	if n := countRows(t, `SELECT COUNT(*) FROM metrics_1h WHERE server_id = 's1'`); n != 2 {
+		t.Fatalf("dry run deleted rollups (%d left)", n)
+	}
+
+	report := scrub(t, `{"server_id": "s1", "mode": "purge"}`)
+	for _, table := range tables {
+		if n := countRows(t, `SELECT COUNT(*) FROM `+table+` WHERE server_id = 's1'`); n != 0 {
+			t.Errorf("%s: %d rows of s1 left", table, n)
+		}
+		if n := countRows(t, `SELECT COUNT(*) FROM `+table+` WHERE server_id = 's2'`); n == 0 {
+			t.Errorf("%s: s2's rows deleted", table)
+		}
+	}
+	if n := countRows(t, `SELECT COUNT(*) FROM event_comments`); n != 1 || report.Tables["event_comments"] != 1 {
+		t.Errorf("event comments left = %d, reported = %d; want 1 and 1", n, report.Tables["event_comments"])
+	}
+
+	for _, name := range []string{"s1.cast", "s1_1000_logs.zip"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s not deleted", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "s2.cast")); err != nil {
+		t.Errorf("s2's recording deleted: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "backend.log")); strings.Contains(string(data), "s1") || !strings.Contains(string(data), "s2") {
+		t.Errorf("backend.log after purge:\n%s", data)
+	}
+	if text := readGzip(t, filepath.Join(dir, "backend-2026-01-01.log.gz")); strings.Contains(text, "s1") {
+		t.Errorf("rotated backend log still mentions s1:\n%s", text)
+	}
+}
+
+func TestScrubAnonymizesPattern(t *testing.T) {
+	dir := scrubFixture(t)
+
+	report := scrub(t, `{"pattern": "alice", "mode": "anonymize"}`)
+	if report.Tables["metrics"] != 2 || report.Tables["events"] != 2 || report.Tables["event_comments"] != 2 {
+		t.Errorf("report tables = %v", report.Tables)
+	}
+	for _, query := range []string{
+		`SELECT COUNT(*) FROM metrics WHERE processes LIKE '%alice%'`,
+		`SELECT COUNT(*) FROM events WHERE message LIKE '%alice%'`,
+		`SELECT COUNT(*) FROM event_comments WHERE body LIKE '%alice%'`,
+	} {
+		if n := countRows(t, query); n != 0 {
+			t.Errorf("%s = %d, want 0", query, n)
+		}
+	}
+	// Nothing is deleted, rollups included
+	if n := countRows(t, `SELECT COUNT(*) FROM metrics_5m`); n != 4 {
+		t.Errorf("metrics_5m rows = %d, want 4", n)
+	}
+	if n := countRows(t, `SELECT COUNT(*) FROM metrics WHERE processes LIKE '%[REDACTED]%'`); n != 2 {
+		t.Errorf("redacted process lists = %d, want 2", n)
+	}
+
+	if text := readGzip(t, filepath.Join(dir, "backend-2026-01-01.log.gz")); strings.Contains(text, "alice") || !strings.Contains(text, "agent s1 registered") {
+		t.Errorf("rotated backend log after anonymize:\n%s", text)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "s1.cast")); strings.Contains(string(data), "alice") {
+		t.Errorf("recording not redacted:\n%s", data)
+	}
+}
+
+func TestScrubPurgesPatternWithRollups(t *testing.T) {
+	scrubFixture(t)
+
+	report := scrub(t, `{"server_id": "s1", "pattern": "alice", "mode": "purge"}`)
+	if n := countRows(t, `SELECT COUNT(*) FROM metrics WHERE server_id = 's1'`); n != 1 {
+		t.Errorf("s1 metrics left = %d, want the unmatched one", n)
+	}
+	for _, table := range []string{"metrics_5m", "metrics_1h"} {
+		// Only the bucket of the purged sample goes
+		if n := countRows(t, `SELECT COUNT(*) FROM `+table+` WHERE server_id = 's1' AND bucket = 3600`); n != 0 {
+			t.Errorf("%s: purged sample's bucket kept", table)
+		}
+		if n := countRows(t, `SELECT COUNT(*) FROM `+table+` WHERE bucket = 7200 OR server_id = 's2'`); n != 3 {
+			t.Errorf("%s: %d other buckets left, want 3", table, n)
+		}
+		if report.Tables[table] != 1 {
+			t.Errorf("%s reported %d rows, want 1", table, report.Tables[table])
+		}
+	}
+	if n := countRows(t, `SELECT COUNT(*) FROM events e JOIN event_comments c ON c.event_id = e.id WHERE e.server_id = 's1'`); n != 0 {
+		t.Errorf("purged event's comments kept")
+	}
+	if n := countRows(t, `SELECT COUNT(*) FROM events WHERE server_id = 's2'`); n != 1 {
+		t.Errorf("s2's events touched")
+	}
+}
+
+func TestScrubReachesMetricsArchives(t *testing.T) {
+	scrubFixture(t)
+	archived := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC).Unix()
+	seedMetricsArchive(t, archived,
+		[4]interface{}{"s1", archived, 10.0, `[{"name":"sshd","user":"alice"}]`},
+		[4]interface{}{"s1", archived + 7200, 20.0, `[{"name":"nginx","user":"www"}]`},
+		[4]interface{}{"s2", archived, 30.0, `[{"name":"sshd","user":"alice"}]`},
+	)
+
+	report := scrub(t, `{"pattern": "alice", "mode": "anonymize"}`)
+	if report.Tables["archive.metrics"] != 2 {
+		t.Errorf("archive.metrics reported %d rows, want 2", report.Tables["archive.metrics"])
+	}
+	if n := archiveRows(t, archived, "metrics", "processes LIKE '%alice%'"); n != 0 {
+		t.Errorf("%d archived process lists still name alice", n)
+	}
+
+	report = scrub(t, `{"server_id": "s1", "mode": "purge"}`)
+	if report.Tables["archive.metrics"] != 2 || report.Tables["archive.metrics_1h"] != 2 {
+		t.Errorf("report tables = %v", report.Tables)
+	}
+	for _, table := range []string{"metrics", "metrics_1h"} {
+		if n := archiveRows(t, archived, table, "server_id = 's1'"); n != 0 {
+			t.Errorf("archive %s: %d rows of s1 left", table, n)
+		}
+		if n := archiveRows(t, archived, table, "server_id = 's2'"); n != 1 {
+			t.Errorf("archive %s: s2's rows deleted", table)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/push"
+)
+
+// loadServerConfiguration reads the per-server overrides (empty when unset)
+func loadServerConfiguration(serverID string) (models.ServerConfiguration, error) {
+	var cfg models.ServerConfiguration
+	var raw sql.NullString
+	if err := database.DB.QueryRow("SELECT configuration FROM servers WHERE id = ?", serverID).Scan(&raw); err != nil {
+		return cfg, err
+	}
+	if raw.Valid && raw.String != "" {
+		if err := json.Unmarshal([]byte(raw.String), &cfg); err != nil {
+			return cfg, fmt.Errorf("invalid server configuration: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// saveServerConfiguration stores the per-server overrides and wakes the agent
+func saveServerConfiguration(serverID string, cfg models.ServerConfiguration) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if _, err := database.DB.Exec("UPDATE servers SET configuration = ? WHERE id = ?", string(data), serverID); err != nil {
+		return err
+	}
+	push.Notify(serverID)
+	return nil
+}
+
+// normalizeDriftOverride validates paths/patterns and fills in the default mode
+func normalizeDriftOverride(o *models.DriftOverride) error {
+	switch o.Mode {
+	case "":
+		o.Mode = "merge"
+	case "merge", "replace":
+	default:
+		return fmt.Errorf("mode must be merge or replace")
+	}
+
+	paths := []string{}
+	for _, p := range o.Paths {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !filepath.IsAbs(p) {
+			return fmt.Errorf("drift path must be absolute: %s", p)
+		}
+		paths = append(paths, filepath.Clean(p))
+	}
+	if o.Mode == "replace" && len(paths) == 0 {
+		return fmt.Errorf("replace mode requires at least one path")
+	}
+
+	ignore := []string{}
+	for _, p := range o.Ignore {
+		if p = strings.TrimSpace(p); p != "" {
+			ignore = append(ignore, p)
+		}
+	}
+	if err := validateDriftIgnore(ignore); err != nil {
+		return err
+	}
+	o.Paths = uniqueStrings(paths)
+	o.Ignore = uniqueStrings(ignore)
+	return nil
+}
+
+// globalDriftLists loads the fleet-wide drift paths and ignore patterns
+func globalDriftLists() ([]string, []string) {
+	paths := []string{"/etc"}
+	ignore := []string{}
+	var raw string
+	if err := database.GetSetting("drift_paths", &raw); err == nil {
+		json.Unmarshal([]byte(raw), &paths)
+	}
+	if err := database.GetSetting("drift_ignore", &raw); err == nil {
+		json.Unmarshal([]byte(raw), &ignore)
+	}
+	return paths, ignore
+}
+
+// mergeDriftOverride applies a server override to the global drift lists
+func mergeDriftOverride(paths, ignore []string, o *models.DriftOverride) ([]string, []string) {
+	if o == nil {
+		return paths, ignore
+	}
+	if o.Mode == "replace" {
+		return o.Paths, o.Ignore
+	}
+	return uniqueStrings(append(append([]string{}, paths...), o.Paths...)),
+		uniqueStrings(append(append([]string{}, ignore...), o.Ignore...))
+}
+
+// uniqueStrings removes duplicates while keeping the original order
+func uniqueStrings(in []string) []string {
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, s := range in {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// GetServerConfig returns the overrides set on a server and the config its
+// agent receives, whose source field names the layer of each setting
+func GetServerConfig(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+	cfg, err := loadServerConfiguration(serverID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	} else if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	overrides := models.ConfigOverrides{}
+	if cfg.Overrides != nil {
+		overrides = *cfg.Overrides
+	}
+	overrides.Drift = cfg.Drift
+	return c.JSON(fiber.Map{
+		"overrides": overrides,
+		"effective": resolveAgentConfig(serverID),
+	})
+}
+
+// SetServerConfig replaces the overrides of a server (including its drift
+// override); an empty object returns it to its group and global settings
+func SetServerConfig(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+
+	var req models.ConfigOverrides
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if err := validateConfigOverrides(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	cfg, err := loadServerConfiguration(serverID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	} else if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	details, _ := json.Marshal(req)
+	cfg.Drift = req.Drift
+	req.Drift = nil
+	cfg.Overrides = &req
+	if err := saveServerConfiguration(serverID, cfg); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save configuration"})
+	}
+
+	recordAudit(c, "server.config", serverID, string(details))
+	return GetServerConfig(c)
+}
+
+// GetServerDriftConfig returns the drift override of a server and the effective lists
+func GetServerDriftConfig(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+	cfg, err := loadServerConfiguration(serverID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	} else if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	paths, ignore := globalDriftLists()
+	paths, ignore = mergeDriftOverride(paths, ignore, cfg.Drift)
+	return c.JSON(fiber.Map{
+		"override":         cfg.Drift,
+		"effective_paths":  paths,
+		"effective_ignore": ignore,
+	})
+}
+
+// SetServerDriftConfig creates or replaces the drift override of a server
+func SetServerDriftConfig(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+
+	var req models.DriftOverride
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if err := normalizeDriftOverride(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	cfg, err := loadServerConfiguration(serverID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	} else if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	cfg.Drift = &req
+	if err := saveServerConfiguration(serverID, cfg); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save configuration"})
+	}
+
+	details, _ := json.Marshal(req)
+	recordAudit(c, "server.drift_config", serverID, string(details))
+	return c.JSON(req)
+}
+
+// DeleteServerDriftConfig removes the drift override so the global settings apply again
+func DeleteServerDriftConfig(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+	cfg, err := loadServerConfiguration(serverID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	} else if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	cfg.Drift = nil
+	if err := saveServerConfiguration(serverID, cfg); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save configuration"})
+	}
+
+	recordAudit(c, "server.drift_config", serverID, "removed")
+	return c.JSON(fiber.Map{"message": "Drift override removed"})
+}
+
+// Bounds of a per-server push interval (seconds)
+const (
+	minServerInterval = 10
+	maxServerInterval = 3600
+)
+
+// GetServerInterval returns the push interval set for a server (0 = the agent's own)
+func GetServerInterval(c *fiber.Ctx) error {
+	cfg, err := loadServerConfiguration(c.Params("id"))
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	} else if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"interval": cfg.Interval})
+}
+
+// SetServerInterval sets the push interval of a server; 0 returns it to the
+// interval in the agent's config.yaml
+func SetServerInterval(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+
+	var req struct {
+		Interval int `json:"interval"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if req.Interval != 0 && (req.Interval < minServerInterval || req.Interval > maxServerInterval) {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("interval must be between %d and %d seconds (0 to use the agent's own)", minServerInterval, maxServerInterval)})
+	}
+	// A server pushing less often than the offline timeout would flap offline
+	offlineTimeout := 120
+	var val string
+	if err := database.GetSetting("offline_timeout", &val); err == nil {
+		fmt.Sscanf(val, "%d", &offlineTimeout)
+	}
+	if req.Interval >= offlineTimeout {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("interval must be below the offline timeout (%ds)", offlineTimeout)})
+	}
+
+	cfg, err := loadServerConfiguration(serverID)
+	if err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	} else if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	cfg.Interval = req.Interval
+	if err := saveServerConfiguration(serverID, cfg); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save configuration"})
+	}
+
+	recordAudit(c, "server.interval", serverID, fmt.Sprintf("%d", req.Interval))
+	return c.JSON(fiber.Map{"status": "saved", "interval": req.Interval})
+}
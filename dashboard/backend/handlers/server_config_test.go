@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/health"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+func TestServerConfigOverrides(t *testing.T) {
+	testDB(t)
+	seedServer(t, "s1", "db-1")
+	mustExec(t, `INSERT INTO server_groups (id, name, config, created_at) VALUES (1, 'db-servers', '{"cron_enabled": false, "cron_global_timeout": 600}', 0)`)
+	mustExec(t, `INSERT INTO server_group_members (server_id, group_id) VALUES ('s1', 1)`)
+
+	app := fiber.New()
+	app.Get("/servers/:id/config", GetServerConfig)
+	app.Put("/servers/:id/config", SetServerConfig)
+	put := func(body string) int {
+		t.Helper()
+		req := httptest.NewRequest("PUT", "/servers/s1/config", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := put(`{"drift": {"mode": "replace"}}`); status != 400 {
+		t.Errorf("replace without paths: status %d, want 400", status)
+	}
+	if status := put(`{
+		"thresholds": {"cpu_warning": 97, "cpu_critical": 99, "memory_warning": 80, "memory_critical": 95, "disk_warning": 80, "disk_critical": 95},
+		"cron_global_timeout": 1800,
+		"drift": {"paths": ["/srv"]}
+	}`); status != 200 {
+		t.Fatalf("save overrides: status %d", status)
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/servers/s1/config", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		Overrides models.ConfigOverrides `json:"overrides"`
+		Effective models.AgentConfig     `json:"effective"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if body.Overrides.Drift == nil || body.Overrides.CronGlobalTimeout == nil {
+		t.Fatalf("overrides = %+v", body.Overrides)
+	}
+
+	cfg := body.Effective
+	if cfg.CronGlobalTimeout != 1800 || cfg.CronEnabled || cfg.Thresholds.CPUWarning != 97 {
+		t.Errorf("effective config = %+v", cfg)
+	}
+	want := map[string]string{
+		"thresholds":          "server",
+		"cron_global_timeout": "server",
+		"cron_enabled":        "group",
+		"drift_paths":         "server",
+		"cron_timeouts":       "global",
+		"interval":            "agent",
+	}
+	for key, source := range want {
+		if cfg.Source[key] != source {
+			t.Errorf("source[%s] = %q, want %q", key, cfg.Source[key], source)
+		}
+	}
+
+	// The dashboard's own health evaluation sees the server's thresholds too
+	if thresholds, _ := health.ResolveThresholds("s1", models.ResourceThresholds{}); thresholds.CPUWarning != 97 {
+		t.Errorf("resolved cpu warning = %v, want 97", thresholds.CPUWarning)
+	}
+}
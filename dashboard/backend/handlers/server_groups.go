@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/push"
+)
+
+// validateConfigOverrides checks the overrides and normalizes the drift lists
+func validateConfigOverrides(o *models.ConfigOverrides) error {
+	if o.Thresholds != nil {
+		if err := validateThresholds(*o.Thresholds); err != nil {
+			return err
+		}
+	}
+	if o.Drift != nil {
+		if err := normalizeDriftOverride(o.Drift); err != nil {
+			return err
+		}
+	}
+
+	cronKeys := []string{}
+	for k := range o.CronIgnore {
+		cronKeys = append(cronKeys, k)
+	}
+	for k, timeout := range o.CronTimeouts {
+		if timeout < 0 {
+			return fmt.Errorf("cron timeout must not be negative: %s", k)
+		}
+		cronKeys = append(cronKeys, k)
+	}
+	if err := validateCronKeys(cronKeys); err != nil {
+		return err
+	}
+	if o.CronGlobalTimeout != nil && *o.CronGlobalTimeout < 0 {
+		return fmt.Errorf("cron global timeout must not be negative")
+	}
+	return nil
+}
+
+// overridableSettings are the AgentConfig keys groups and servers can override
+var overridableSettings = []string{
+	"thresholds", "drift_paths", "drift_ignore", "cron_enabled", "cron_auto_discover",
+	"cron_ignore", "cron_timeouts", "cron_global_timeout",
+}
+
+// applyConfigOverrides layers overrides onto an agent config and records the
+// layer in config.Source. Thresholds are resolved separately by
+// health.ResolveThresholds, which the dashboard's own evaluation shares.
+func applyConfigOverrides(config *models.AgentConfig, o models.ConfigOverrides, layer string) {
+	if config.Source == nil {
+		config.Source = make(map[string]string)
+	}
+	if o.Drift != nil {
+		config.DriftPaths, config.DriftIgnore = mergeDriftOverride(config.DriftPaths, config.DriftIgnore, o.Drift)
+		config.Source["drift_paths"] = layer
+		config.Source["drift_ignore"] = layer
+	}
+	if o.CronEnabled != nil {
+		config.CronEnabled = *o.CronEnabled
+		config.Source["cron_enabled"] = layer
+	}
+	if o.CronAutoDiscover != nil {
+		config.CronAutoDiscover = *o.CronAutoDiscover
+		config.Source["cron_auto_discover"] = layer
+	}
+	if len(o.CronIgnore) > 0 {
+		merged := make(map[string][]int, len(config.CronIgnore)+len(o.CronIgnore))
+		for k, v := range config.CronIgnore {
+			merged[k] = v
+		}
+		for k, v := range o.CronIgnore {
+			merged[k] = v
+		}
+		config.CronIgnore = merged
+		config.Source["cron_ignore"] = layer
+	}
+	if len(o.CronTimeouts) > 0 {
+		merged := make(map[string]int, len(config.CronTimeouts)+len(o.CronTimeouts))
+		for k, v := range config.CronTimeouts {
+			merged[k] = v
+		}
+		for k, v := range o.CronTimeouts {
+			merged[k] = v
+		}
+		config.CronTimeouts = merged
+		config.Source["cron_timeouts"] = layer
+	}
+	if o.CronGlobalTimeout != nil {
+		config.CronGlobalTimeout = *o.CronGlobalTimeout
+		config.Source["cron_global_timeout"] = layer
+	}
+}
+
+// loadServerGroups returns all groups with their member servers
+func loadServerGroups() ([]models.ServerGroup, error) {
+	rows, err := database.DB.Query("SELECT id, name, COALESCE(description, ''), config, created_at FROM server_groups ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := []models.ServerGroup{}
+	index := make(map[int64]int)
+	for rows.Next() {
+		var g models.ServerGroup
+		var raw string
+		if err := rows.Scan(&g.ID, &g.Name, &g.Description, &raw, &g.CreatedAt); err != nil {
+			continue
+		}
+		json.Unmarshal([]byte(raw), &g.Config)
+		g.Servers = []string{}
+		index[g.ID] = len(groups)
+		groups = append(groups, g)
+	}
+
+	memberRows, err := database.DB.Query("SELECT group_id, server_id FROM server_group_members ORDER BY server_id")
+	if err != nil {
+		return nil, err
+	}
+	defer memberRows.Close()
+	for memberRows.Next() {
+		var id int64
+		var serverID string
+		if memberRows.Scan(&id, &serverID) == nil {
+			if i, ok := index[id]; ok {
+				groups[i].Servers = append(groups[i].Servers, serverID)
+			}
+		}
+	}
+	return groups, nil
+}
+
+// parseServerGroup reads and validates a group from the request body
+func parseServerGroup(c *fiber.Ctx) (models.ServerGroup, error) {
+	var g models.ServerGroup
+	if err := c.BodyParser(&g); err != nil {
+		return g, fmt.Errorf("Invalid request")
+	}
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" || len(g.Name) > 64 {
+		return g, fmt.Errorf("Group name is required (max 64 characters)")
+	}
+	if err := validateConfigOverrides(&g.Config); err != nil {
+		return g, err
+	}
+	return g, nil
+}
+
+// notifyGroupMembers wakes the agents of a group so they fetch the new config
+func notifyGroupMembers(groupID int64) {
+	rows, err := database.DB.Query("SELECT server_id FROM server_group_members WHERE group_id = ?", groupID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var serverID string
+		if rows.Scan(&serverID) == nil {
+			push.Notify(serverID)
+		}
+	}
+}
+
+// GetServerGroups lists server groups
+func GetServerGroups(c *fiber.Ctx) error {
+	groups, err := loadServerGroups()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	return c.JSON(groups)
+}
+
+// CreateServerGroup adds a named server group
+func CreateServerGroup(c *fiber.Ctx) error {
+	g, err := parseServerGroup(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	config, _ := json.Marshal(g.Config)
+
+	g.CreatedAt = time.Now().Unix()
+	res, err := database.DB.Exec(`
+		INSERT INTO server_groups (name, description, config, created_at)
+		VALUES (?, ?, ?, ?)
+	`, g.Name, g.Description, string(config), g.CreatedAt)
+	if err != nil {
+		return c.Status(409).JSON(fiber.Map{"error": "A group with this name already exists"})
+	}
+	g.ID, _ = res.LastInsertId()
+	g.Servers = []string{}
+
+	recordAudit(c, "server_group.create", g.Name, string(config))
+	return c.Status(201).JSON(g)
+}
+
+// UpdateServerGroup replaces a group's name, description and overrides
+func UpdateServerGroup(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid group ID"})
+	}
+	g, err := parseServerGroup(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	g.ID = id
+	config, _ := json.Marshal(g.Config)
+
+	res, err := database.DB.Exec(`
+		UPDATE server_groups SET name = ?, description = ?, config = ?
+		WHERE id = ?
+	`, g.Name, g.Description, string(config), id)
+	if err != nil {
+		return c.Status(409).JSON(fiber.Map{"error": "A group with this name already exists"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Group not found"})
+	}
+
+	notifyGroupMembers(id)
+	recordAudit(c, "server_group.update", g.Name, string(config))
+	return c.JSON(g)
+}
+
+// DeleteServerGroup removes a group; its servers fall back to the global config
+func DeleteServerGroup(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid group ID"})
+	}
+
+	var name string
+	if err := database.DB.QueryRow("SELECT name FROM server_groups WHERE id = ?", id).Scan(&name); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Group not found"})
+	}
+	notifyGroupMembers(id)
+	database.DB.Exec("DELETE FROM server_group_members WHERE group_id = ?", id)
+	if _, err := database.DB.Exec("DELETE FROM server_groups WHERE id = ?", id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+
+	recordAudit(c, "server_group.delete", name, "")
+	return c.JSON(fiber.Map{"status": "deleted"})
+}
+
+// SetServerGroup moves a server into a group (group_id 0 removes it from its group)
+func SetServerGroup(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+
+	var req struct {
+		GroupID int64 `json:"group_id"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	var exists int
+	if err := database.DB.QueryRow("SELECT 1 FROM servers WHERE id = ?", serverID).Scan(&exists); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
+
+	name := "none"
+	if req.GroupID == 0 {
+		if _, err := database.DB.Exec("DELETE FROM server_group_members WHERE server_id = ?", serverID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+		}
+	} else {
+		if err := database.DB.QueryRow("SELECT name FROM server_groups WHERE id = ?", req.GroupID).Scan(&name); err == sql.ErrNoRows {
+			return c.Status(400).JSON(fiber.Map{"error": "Group not found"})
+		} else if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+		}
+		if _, err := database.DB.Exec(`
+			INSERT INTO server_group_members (server_id, group_id) VALUES (?, ?)
+			ON CONFLICT(server_id) DO UPDATE SET group_id = excluded.group_id
+		`, serverID, req.GroupID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+		}
+	}
+
+	push.Notify(serverID)
+	recordAudit(c, "server.group", serverID, name)
+	return c.JSON(fiber.Map{"status": "saved", "group_id": req.GroupID})
+}
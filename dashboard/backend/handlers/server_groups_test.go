@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestServerGroupConfigLayering(t *testing.T) {
+	testDB(t)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	mustExec(t, `INSERT INTO servers (id, hostname, api_secret_hash, first_seen, last_seen) VALUES
+		('s1', 'db-1', ?, 0, 0), ('s2', 'web-1', ?, 0, 0)`, string(hash), string(hash))
+	mustExec(t, `INSERT INTO settings (key, value, updated_at) VALUES ('drift_paths', '["/etc"]', 0), ('cron_timeouts', '{"backup.sh": 60}', 0)`)
+	database.InvalidateSettings()
+
+	app := fiber.New()
+	app.Post("/server-groups", CreateServerGroup)
+	app.Put("/servers/:id/group", SetServerGroup)
+	app.Put("/servers/:id/config/drift", SetServerDriftConfig)
+	app.Get("/agent/config", AgentGetConfig)
+	send := func(method, url, body string, out interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest(method, url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != nil {
+			json.NewDecoder(resp.Body).Decode(out)
+		}
+		return resp.StatusCode
+	}
+
+	if status := send("POST", "/server-groups", `{"name": "db-servers", "config": {"thresholds": {"cpu_warning": 90, "cpu_critical": 80}}}`, nil); status != 400 {
+		t.Errorf("invalid thresholds: status %d, want 400", status)
+	}
+	var group models.ServerGroup
+	status := send("POST", "/server-groups", `{"name": "db-servers", "config": {
+		"thresholds": {"cpu_warning": 90, "cpu_critical": 98, "memory_warning": 85, "memory_critical": 95, "disk_warning": 80, "disk_critical": 90},
+		"drift": {"paths": ["/var/lib/postgresql/"]},
+		"cron_enabled": false,
+		"cron_timeouts": {"vacuum.sh": 3600}
+	}}`, &group)
+	if status != 201 {
+		t.Fatalf("create group: status %d", status)
+	}
+	if status := send("PUT", "/servers/s1/group", `{"group_id": `+strconv.FormatInt(group.ID, 10)+`}`, nil); status != 200 {
+		t.Fatalf("assign group: status %d", status)
+	}
+	// The server's own drift override goes on top of the group's
+	send("PUT", "/servers/s1/config/drift", `{"paths": ["/opt/db"]}`, nil)
+
+	var config models.AgentConfig
+	send("GET", "/agent/config?server_id=s1&api_secret=secret", "", &config)
+	if config.Group != "db-servers" || config.Thresholds.CPUWarning != 90 || config.CronEnabled {
+		t.Errorf("group overrides not applied: %+v", config)
+	}
+	if strings.Join(config.DriftPaths, ",") != "/etc,/var/lib/postgresql,/opt/db" {
+		t.Errorf("drift paths = %v", config.DriftPaths)
+	}
+	if config.CronTimeouts["backup.sh"] != 60 || config.CronTimeouts["vacuum.sh"] != 3600 {
+		t.Errorf("cron timeouts = %v", config.CronTimeouts)
+	}
+
+	// Servers outside the group keep the global config
+	config = models.AgentConfig{}
+	send("GET", "/agent/config?server_id=s2&api_secret=secret", "", &config)
+	if config.Group != "" || config.Thresholds.CPUWarning != 80 || !config.CronEnabled || len(config.DriftPaths) != 1 {
+		t.Errorf("global config changed for s2: %+v", config)
+	}
+}
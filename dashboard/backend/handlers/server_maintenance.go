@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/health"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/schedule"
+)
+
+// maxMaintenanceMinutes bounds a maintenance period (7 days)
+const maxMaintenanceMinutes = 7 * 24 * 60
+
+// StartServerMaintenance puts a server into maintenance for duration_minutes.
+// Its data is still stored, but health transitions, notifications, tickets and
+// offline alerts are held back and its status shows "maintenance". Calling it
+// again replaces the end time.
+func StartServerMaintenance(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+
+	var req struct {
+		DurationMinutes int    `json:"duration_minutes"`
+		Reason          string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	if req.DurationMinutes <= 0 || req.DurationMinutes > maxMaintenanceMinutes {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("duration_minutes must be between 1 and %d", maxMaintenanceMinutes)})
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if len(req.Reason) > 200 {
+		return c.Status(400).JSON(fiber.Map{"error": "Reason is too long (max 200 characters)"})
+	}
+
+	m := models.Maintenance{
+		Until:  time.Now().Add(time.Duration(req.DurationMinutes) * time.Minute).Unix(),
+		Reason: req.Reason,
+	}
+	if err := health.StartMaintenance(serverID, m); err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	} else if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	recordAudit(c, "server.maintenance", serverID, fmt.Sprintf("%d minutes %s", req.DurationMinutes, req.Reason))
+	return c.JSON(m)
+}
+
+// EndServerMaintenance ends a server's maintenance early
+func EndServerMaintenance(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+
+	var exists int
+	if err := database.DB.QueryRow("SELECT 1 FROM servers WHERE id = ?", serverID).Scan(&exists); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
+	if err := health.EndMaintenance(serverID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+
+	recordAudit(c, "server.maintenance", serverID, "ended")
+	return c.JSON(fiber.Map{"status": "ended"})
+}
+
+// validateMaintenanceWindow checks a window and normalizes its scope
+func validateMaintenanceWindow(w *models.MaintenanceWindow) error {
+	w.Name = strings.TrimSpace(w.Name)
+	if w.Name == "" || len(w.Name) > 64 {
+		return fmt.Errorf("window name is required (max 64 characters)")
+	}
+	w.Schedule = strings.TrimSpace(w.Schedule)
+	if w.Schedule == "@reboot" {
+		return fmt.Errorf("%s: @reboot is not a schedule", w.Name)
+	}
+	if _, err := schedule.ParseCron(w.Schedule); err != nil {
+		return fmt.Errorf("%s: %v", w.Name, err)
+	}
+	if w.DurationMinutes <= 0 || w.DurationMinutes > maxMaintenanceMinutes {
+		return fmt.Errorf("%s: duration_minutes must be between 1 and %d", w.Name, maxMaintenanceMinutes)
+	}
+	if w.Timezone != "" && !schedule.ValidTimezone(w.Timezone) {
+		return fmt.Errorf("%s: invalid timezone %q", w.Name, w.Timezone)
+	}
+	tags, err := normalizeTags(w.Tags)
+	if err != nil {
+		return fmt.Errorf("%s: %v", w.Name, err)
+	}
+	w.Tags = tags
+	w.Servers = uniqueStrings(w.Servers)
+	return nil
+}
+
+// GetMaintenanceWindows returns the recurring maintenance windows
+func GetMaintenanceWindows(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"windows": health.LoadMaintenanceWindows()})
+}
+
+// SaveMaintenanceWindows replaces the recurring maintenance windows. While a
+// window runs, the servers in its scope are treated as in maintenance.
+func SaveMaintenanceWindows(c *fiber.Ctx) error {
+	var req struct {
+		Windows []models.MaintenanceWindow `json:"windows"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.Windows == nil {
+		req.Windows = []models.MaintenanceWindow{}
+	}
+	names := make(map[string]bool)
+	for i := range req.Windows {
+		if err := validateMaintenanceWindow(&req.Windows[i]); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		if names[req.Windows[i].Name] {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("Duplicate window name: %s", req.Windows[i].Name)})
+		}
+		names[req.Windows[i].Name] = true
+	}
+
+	bytes, _ := json.Marshal(req.Windows)
+	_, err := database.DB.Exec(`
+		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value=excluded.value, updated_at=excluded.updated_at
+	`, "maintenance_windows", string(bytes), time.Now().Unix())
+	database.InvalidateSettings()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save maintenance windows"})
+	}
+
+	recordAudit(c, "settings.maintenance_windows", "", string(bytes))
+	return c.JSON(fiber.Map{"status": "ok", "windows": req.Windows})
+}
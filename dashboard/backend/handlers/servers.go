@@ -2,39 +2,58 @@ package handlers
 
 import (
 	"database/sql"
+	"encoding/json"
     "fmt"
+    "log"
     "os"
     "path/filepath"
     "time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/push"
+	"github.com/yourusername/health-dashboard-backend/middleware"
 	"github.com/yourusername/health-dashboard-backend/health"
+	"github.com/yourusername/health-dashboard-backend/maintenance"
 	"github.com/yourusername/health-dashboard-backend/models"
 )
 
-// GetServers returns all servers
+// GetServers returns all servers, or those carrying any of ?tag=prod,web
 func GetServers(c *fiber.Ctx) error {
+	visible, args := middleware.ServerVisibilityClause(c, "id")
+	tagged, tagArgs, err := tagFilterClause(c.Query("tag"), "id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 	rows, err := database.DB.Query(`
-		SELECT id, hostname, COALESCE(os_name, ''), COALESCE(os_version, ''), COALESCE(agent_version, ''), first_seen, last_seen, COALESCE(health_status, 'unknown'), COALESCE(drift_checksum, ''), drift_changed
+		SELECT id, hostname, COALESCE(os_name, ''), COALESCE(os_version, ''), COALESCE(agent_version, ''), first_seen, last_seen, COALESCE(health_status, 'unknown'), COALESCE(drift_checksum, ''), drift_changed, COALESCE(environment, ''), COALESCE(package_id, ''), COALESCE(timezone, ''), COALESCE(attestation, ''), COALESCE(ebpf_level, '')
 		FROM servers
+		WHERE `+visible+` AND `+tagged+`
 		ORDER BY hostname
-	`)
+	`, append(args, tagArgs...)...)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
 	}
 	defer rows.Close()
 
+	tags := serverTags()
 	servers := []models.Server{}
 	for rows.Next() {
 		var s models.Server
 		var driftChanged int
+		var environment, attestation string
 		err := rows.Scan(&s.ID, &s.Hostname, &s.OSName, &s.OSVersion, &s.AgentVersion, 
-			&s.FirstSeen, &s.LastSeen, &s.HealthStatus, &s.DriftChecksum, &driftChanged)
+			&s.FirstSeen, &s.LastSeen, &s.HealthStatus, &s.DriftChecksum, &driftChanged, &environment, &s.PackageID, &s.Timezone, &attestation, &s.EBPFLevel)
 		if err != nil {
 			continue
 		}
 		s.DriftChanged = driftChanged == 1
+		s.Environment = parseEnvironment(environment)
+		s.Attestation = parseAttestation(attestation)
+		s.Tags = tags[s.ID]
+		if s.Tags == nil {
+			s.Tags = []string{}
+		}
 		servers = append(servers, s)
 	}
 
@@ -47,12 +66,13 @@ func GetServer(c *fiber.Ctx) error {
 
 	var s models.Server
 	var driftChanged int
+	var environment, agentState, agentSelf, attestation, agentQueue string
 	err := database.DB.QueryRow(`
-		SELECT id, hostname, COALESCE(os_name, ''), COALESCE(os_version, ''), COALESCE(agent_version, ''), first_seen, last_seen, COALESCE(health_status, 'unknown'), COALESCE(drift_checksum, ''), drift_changed, log_request_pending, COALESCE(log_request_time, 0), COALESCE(log_file_path, ''), COALESCE(log_file_time, 0)
+		SELECT id, hostname, COALESCE(os_name, ''), COALESCE(os_version, ''), COALESCE(agent_version, ''), first_seen, last_seen, COALESCE(health_status, 'unknown'), COALESCE(drift_checksum, ''), drift_changed, log_request_pending, COALESCE(log_request_time, 0), COALESCE(log_file_path, ''), COALESCE(log_file_time, 0), COALESCE(environment, ''), COALESCE(agent_state, ''), COALESCE(agent_self, ''), COALESCE(package_id, ''), COALESCE(timezone, ''), COALESCE(attestation, ''), COALESCE(ebpf_level, ''), COALESCE(agent_queue, '')
 		FROM servers
 		WHERE id = ?
 	`, serverID).Scan(&s.ID, &s.Hostname, &s.OSName, &s.OSVersion, &s.AgentVersion,
-		&s.FirstSeen, &s.LastSeen, &s.HealthStatus, &s.DriftChecksum, &driftChanged, &s.LogRequestPending, &s.LogRequestTime, &s.LogFilePath, &s.LogFileTime)
+		&s.FirstSeen, &s.LastSeen, &s.HealthStatus, &s.DriftChecksum, &driftChanged, &s.LogRequestPending, &s.LogRequestTime, &s.LogFilePath, &s.LogFileTime, &environment, &agentState, &agentSelf, &s.PackageID, &s.Timezone, &attestation, &s.EBPFLevel, &agentQueue)
 
 	if err == sql.ErrNoRows {
 		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
@@ -61,36 +81,78 @@ func GetServer(c *fiber.Ctx) error {
 	}
 
 	s.DriftChanged = driftChanged == 1
+	s.Environment = parseEnvironment(environment)
+	s.Attestation = parseAttestation(attestation)
+	s.AgentQueue = parseAgentQueue(agentQueue)
+	s.DiskMounts = health.ServerDiskMounts(s.ID)
+	if m, ok := health.ActiveMaintenance(s.ID); ok {
+		s.Maintenance = &m
+	}
+	if agentState != "" {
+		var st models.AgentStateStats
+		if json.Unmarshal([]byte(agentState), &st) == nil {
+			s.AgentState = &st
+		}
+	}
+	if agentSelf != "" {
+		s.AgentSelf = json.RawMessage(agentSelf)
+	}
 	return c.JSON(s)
 }
 
-// DeleteServer removes a server and all its data
+// parseEnvironment decodes the stored runtime environment (nil until reported)
+func parseEnvironment(raw string) *models.HostEnvironment {
+	if raw == "" {
+		return nil
+	}
+	var env models.HostEnvironment
+	if err := json.Unmarshal([]byte(raw), &env); err != nil {
+		return nil
+	}
+	return &env
+}
+
+// serverDataTables hold rows per server (server_id). Their foreign keys
+// cascade too, but they are cleared explicitly so that rows orphaned while
+// foreign keys were not enforced on every connection are cleaned up as well.
+var serverDataTables = []string{
+	"events", "metrics", maintenance.Rollup5m.Table, maintenance.Rollup1h.Table,
+	"script_results", "terminal_sessions", "remediations", "server_packages", "server_tags",
+	"server_group_members", "cron_pauses", "cron_runs", "cron_failure_streaks", "tickets",
+	"agent_certificates", "agent_rollout_servers", "silences",
+}
+
+// DeleteServer removes a server and all its data. Heartbeats logging on it
+// are kept, without a server.
 func DeleteServer(c *fiber.Ctx) error {
 	serverID := c.Params("id")
 
-	// Start a transaction would be ideal, but for sqlite we can just do sequential deletes
-	// Delete associated events
-	_, err := database.DB.Exec("DELETE FROM events WHERE server_id = ?", serverID)
+	tx, err := database.DB.Begin()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete events"})
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
 	}
-
-	// Delete associated metrics
-	_, err = database.DB.Exec("DELETE FROM metrics WHERE server_id = ?", serverID)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete metrics"})
+	defer tx.Rollback()
+	for _, table := range serverDataTables {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE server_id = ?", serverID); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to delete " + table})
+		}
+	}
+	if _, err := tx.Exec("UPDATE heartbeats SET server_id = NULL WHERE server_id = ?", serverID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to detach heartbeats"})
 	}
 
 	// Delete the server itself
-	result, err := database.DB.Exec("DELETE FROM servers WHERE id = ?", serverID)
+	result, err := tx.Exec("DELETE FROM servers WHERE id = ?", serverID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
 	}
-
-	rows, _ := result.RowsAffected()
-	if rows == 0 {
+	if rows, _ := result.RowsAffected(); rows == 0 {
 		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
 	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	push.Forget(serverID)
 
 	return c.JSON(fiber.Map{"status": "deleted"})
 }
@@ -98,6 +160,9 @@ func DeleteServer(c *fiber.Ctx) error {
 // DeleteServerEvents removes all events for a server
 func DeleteServerEvents(c *fiber.Ctx) error {
 	serverID := c.Params("id")
+	if !middleware.CanSeeServer(c, serverID) {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
 
 	_, err := database.DB.Exec("DELETE FROM events WHERE server_id = ?", serverID)
 	if err != nil {
@@ -107,10 +172,20 @@ func DeleteServerEvents(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"status": "events deleted"})
 }
 
-// DeleteEvent removes a single event by ID
+// DeleteEvent removes a single event by ID. Events of servers hidden from
+// the user answer 404, like unknown ones.
 func DeleteEvent(c *fiber.Ctx) error {
 	eventID := c.Params("id")
 
+	var serverID string
+	err := database.DB.QueryRow("SELECT server_id FROM events WHERE id = ?", eventID).Scan(&serverID)
+	if err == sql.ErrNoRows || (err == nil && !middleware.CanSeeServer(c, serverID)) {
+		return c.Status(404).JSON(fiber.Map{"error": "Event not found"})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+
 	result, err := database.DB.Exec("DELETE FROM events WHERE id = ?", eventID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to delete event"})
@@ -124,41 +199,95 @@ func DeleteEvent(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"status": "event deleted"})
 }
 
-// CleanupDatabase removes orphaned data
-func CleanupDatabase(c *fiber.Ctx) error {
-	// Clean orphaned events
-	res1, err := database.DB.Exec("DELETE FROM events WHERE server_id NOT IN (SELECT id FROM servers)")
+// CleanupOrphanedData removes the rows left behind by deleted servers (and
+// the comments of deleted events), returning the count per table
+func CleanupOrphanedData() (map[string]int64, error) {
+	deleted := make(map[string]int64)
+	for _, table := range serverDataTables {
+		res, err := database.DB.Exec("DELETE FROM " + table + " WHERE server_id NOT IN (SELECT id FROM servers)")
+		if err != nil {
+			return deleted, fmt.Errorf("failed to clean %s: %w", table, err)
+		}
+		deleted[table], _ = res.RowsAffected()
+	}
+	if _, err := database.DB.Exec("UPDATE heartbeats SET server_id = NULL WHERE server_id NOT IN (SELECT id FROM servers)"); err != nil {
+		return deleted, fmt.Errorf("failed to clean heartbeats: %w", err)
+	}
+	res, err := database.DB.Exec("DELETE FROM event_comments WHERE event_id NOT IN (SELECT id FROM events)")
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to clean events"})
+		return deleted, fmt.Errorf("failed to clean event_comments: %w", err)
 	}
-	eventsDeleted, _ := res1.RowsAffected()
+	deleted["event_comments"], _ = res.RowsAffected()
+	return deleted, nil
+}
 
-	// Clean orphaned metrics
-	res2, err := database.DB.Exec("DELETE FROM metrics WHERE server_id NOT IN (SELECT id FROM servers)")
+// CleanupDatabase removes orphaned data
+func CleanupDatabase(c *fiber.Ctx) error {
+	deleted, err := CleanupOrphanedData()
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Failed to clean metrics"})
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
-	metricsDeleted, _ := res2.RowsAffected()
 
 	return c.JSON(fiber.Map{
 		"status": "cleanup_complete",
-		"events_deleted": eventsDeleted,
-		"metrics_deleted": metricsDeleted,
+		"events_deleted": deleted["events"],
+		"metrics_deleted": deleted["metrics"],
+		"rows_deleted": deleted,
 	})
 }
 
-// GetServerMetrics returns metrics for a server
+// GetServerMetrics returns metrics for a server over the last ?hours= (default
+// 24, max 10 years). Up to 48 hours are raw samples; longer ranges come from
+// the 5-minute (up to 14 days) or hourly rollups, plus the not yet rolled up
+// recent samples aggregated into the same buckets. Hourly ranges reaching past
+// the metrics retention continue from the metrics archives.
 func GetServerMetrics(c *fiber.Ctx) error {
 	serverID := c.Params("id")
-	
-	// Get metrics for the last 24 hours
-	rows, err := database.DB.Query(`
-		SELECT id, server_id, timestamp, cpu_percent, mem_total_mb, mem_used_mb, 
-			disk_total_gb, disk_used_gb, load_avg_1, load_avg_5, load_avg_15, process_count, uptime
-		FROM metrics
-		WHERE server_id = ? AND timestamp > strftime('%s', 'now', '-24 hours')
-		ORDER BY timestamp DESC
-	`, serverID)
+	hours := c.QueryInt("hours", 24)
+	if hours < 1 || hours > 3650*24 {
+		return c.Status(400).JSON(fiber.Map{"error": "Hours must be between 1 and 87600"})
+	}
+	since := time.Now().Add(-time.Duration(hours) * time.Hour).Unix()
+
+	var rows *sql.Rows
+	var err error
+	hourly := false
+	if time.Duration(hours)*time.Hour <= maintenance.RawMetricsWindow {
+		rows, err = database.DB.Query(`
+			SELECT id, timestamp, cpu_percent, mem_total_mb, mem_used_mb,
+				disk_total_gb, disk_used_gb, load_avg_1, load_avg_5, load_avg_15, process_count, uptime
+			FROM metrics
+			WHERE server_id = ? AND timestamp > ?
+			ORDER BY timestamp DESC
+		`, serverID, since)
+	} else {
+		rollup := maintenance.Rollup5m
+		if hours > 14*24 {
+			rollup = maintenance.Rollup1h
+			hourly = true
+		}
+		rolledUntil := maintenance.MetricsRolledUntil()
+		recentSince := since
+		if rolledUntil > recentSince {
+			recentSince = rolledUntil
+		}
+		rows, err = database.DB.Query(`
+			SELECT 0, bucket, COALESCE(cpu_percent, 0), COALESCE(mem_total_mb, 0), COALESCE(mem_used_mb, 0),
+				COALESCE(disk_total_gb, 0), COALESCE(disk_used_gb, 0), COALESCE(load_avg_1, 0), COALESCE(load_avg_5, 0),
+				COALESCE(load_avg_15, 0), COALESCE(process_count, 0), COALESCE(uptime, 0)
+			FROM `+rollup.Table+`
+			WHERE server_id = ?1 AND bucket >= ?2 AND bucket < ?3
+			UNION ALL
+			SELECT 0, timestamp / ?4 * ?4, COALESCE(AVG(cpu_percent), 0), COALESCE(MAX(mem_total_mb), 0), CAST(COALESCE(AVG(mem_used_mb), 0) AS INTEGER),
+				COALESCE(MAX(disk_total_gb), 0), COALESCE(MAX(disk_used_gb), 0), COALESCE(AVG(load_avg_1), 0), COALESCE(AVG(load_avg_5), 0),
+				COALESCE(AVG(load_avg_15), 0), CAST(COALESCE(AVG(process_count), 0) AS INTEGER), COALESCE(MAX(uptime), 0)
+			FROM metrics
+			WHERE server_id = ?1 AND timestamp >= ?5
+			GROUP BY timestamp / ?4
+			ORDER BY 2 DESC
+		`, serverID, since, rolledUntil, rollup.Step, recentSince)
+		c.Set("X-Metrics-Resolution", fmt.Sprintf("%ds", rollup.Step))
+	}
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
 	}
@@ -166,8 +295,8 @@ func GetServerMetrics(c *fiber.Ctx) error {
 
 	metrics := []models.Metric{}
 	for rows.Next() {
-		var m models.Metric
-		err := rows.Scan(&m.ID, &m.ServerID, &m.Timestamp, &m.CPUPercent, &m.MemTotalMB,
+		m := models.Metric{ServerID: serverID}
+		err := rows.Scan(&m.ID, &m.Timestamp, &m.CPUPercent, &m.MemTotalMB,
 			&m.MemUsedMB, &m.DiskTotalGB, &m.DiskUsedGB, &m.LoadAvg1, &m.LoadAvg5,
 			&m.LoadAvg15, &m.ProcessCount, &m.Uptime)
 		if err != nil {
@@ -176,63 +305,43 @@ func GetServerMetrics(c *fiber.Ctx) error {
 		metrics = append(metrics, m)
 	}
 
+	// Older than anything still in the database
+	if hourly && maintenance.MetricsArchiveEnabled() {
+		until := time.Now().Unix()
+		if len(metrics) > 0 {
+			until = metrics[len(metrics)-1].Timestamp
+		}
+		if until > since {
+			archived, err := maintenance.ArchivedHourlyMetrics(serverID, since, until)
+			if err != nil {
+				log.Printf("Failed to read metrics archives: %v", err)
+			}
+			metrics = append(metrics, archived...)
+		}
+	}
+
 	return c.JSON(metrics)
 }
 
-// GetServerEvents returns events for a server
+// GetServerEvents returns events for a server, newest first.
+// Query: page, limit (default 100, max 1000), since, until, severity, type, acknowledged, state
 func GetServerEvents(c *fiber.Ctx) error {
-	serverID := c.Params("id")
-
-	rows, err := database.DB.Query(`
-		SELECT id, server_id, timestamp, event_type, severity, message, details
-		FROM events
-		WHERE server_id = ?
-		ORDER BY timestamp DESC
-		LIMIT 100
-	`, serverID)
+	filter, err := parseEventFilter(c, 100, "false", "server_id = ?", []interface{}{c.Params("id")})
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
-	defer rows.Close()
-
-	events := []models.Event{}
-	for rows.Next() {
-		var e models.Event
-		err := rows.Scan(&e.ID, &e.ServerID, &e.Timestamp, &e.EventType, &e.Severity, &e.Message, &e.Details)
-		if err != nil {
-			continue
-		}
-		events = append(events, e)
-	}
-
-	return c.JSON(events)
+	return sendEvents(c, filter)
 }
 
-// GetAllEvents returns recent events across all servers
+// GetAllEvents returns events across all visible servers, newest first.
+// Query: page, limit (default 50, max 1000), since, until, severity, type, server_id, tag, acknowledged, state
 func GetAllEvents(c *fiber.Ctx) error {
-	// Get last 50 events from all servers, ordered by timestamp
-	rows, err := database.DB.Query(`
-		SELECT id, server_id, timestamp, event_type, severity, message, details
-		FROM events
-		ORDER BY timestamp DESC
-		LIMIT 50
-	`)
+	visible, args := middleware.ServerVisibilityClause(c, "server_id")
+	filter, err := parseEventFilter(c, 50, "false", visible, args)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
-	defer rows.Close()
-
-	events := []models.Event{}
-	for rows.Next() {
-		var e models.Event
-		err := rows.Scan(&e.ID, &e.ServerID, &e.Timestamp, &e.EventType, &e.Severity, &e.Message, &e.Details)
-		if err != nil {
-			continue
-		}
-		events = append(events, e)
-	}
-
-	return c.JSON(events)
+	return sendEvents(c, filter)
 }
 
 // GetServerHealth returns detailed health metrics for a server
@@ -256,6 +365,7 @@ func RequestLogs(c *fiber.Ctx) error {
     if err != nil {
         return c.Status(500).JSON(fiber.Map{"error": "Failed to update server"})
     }
+    push.Notify(serverID)
 
     return c.JSON(fiber.Map{"status": "request_sent"})
 }
@@ -296,6 +406,7 @@ func UninstallAgent(c *fiber.Ctx) error {
     if err != nil {
         return c.Status(500).JSON(fiber.Map{"error": "Database error"})
     }
+    push.Notify(serverID)
 
     return c.JSON(fiber.Map{"status": "ok", "message": "Uninstall scheduled"})
 }
@@ -4,12 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/health"
+	"github.com/yourusername/health-dashboard-backend/maintenance"
 	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/push"
 	"github.com/yourusername/health-dashboard-backend/notifications"
 )
 
@@ -23,12 +28,17 @@ func GetAlertSettings(c *fiber.Ctx) error {
 
 	if err != nil {
 		// Return empty default settings if not passed
-		return c.JSON(models.AlertSettings{ID: 1})
+		s = models.AlertSettings{ID: 1}
 	}
     
     // Mask password
     s.SMTPPassword = "" 
 
+	s.ProviderFailureMinutes = notifications.FailureMinutes()
+	if Notifier != nil {
+		s.ProviderStatus = notifications.ProviderStatuses(Notifier)
+	}
+
 	return c.JSON(s)
 }
 
@@ -53,6 +63,18 @@ func SaveAlertSettings(c *fiber.Ctx) error {
         }
     }
 
+	// Clients that predate provider health checks don't send the threshold; keep the stored one
+	var present struct {
+		ProviderFailureMinutes *int `json:"provider_failure_minutes"`
+	}
+	json.Unmarshal(c.Body(), &present)
+	if present.ProviderFailureMinutes == nil {
+		req.ProviderFailureMinutes = notifications.FailureMinutes()
+	}
+	if req.ProviderFailureMinutes < 0 || req.ProviderFailureMinutes > 1440 {
+		return c.Status(400).JSON(fiber.Map{"error": "Provider failure minutes must be between 0 and 1440"})
+	}
+
 	// Upsert (since ID=1)
 	_, err := database.DB.Exec(`
 		INSERT INTO alert_settings (id, slack_webhook_url, teams_webhook_url, discord_webhook_url, email_recipients, smtp_server, smtp_port, smtp_user, smtp_password, alerts_enabled, notify_on_warning)
@@ -73,6 +95,13 @@ func SaveAlertSettings(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": "Failed to save settings"})
 	}
+	if _, err := database.DB.Exec(`
+		INSERT INTO settings (key, value, updated_at) VALUES ('provider_failure_minutes', ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value=excluded.value, updated_at=excluded.updated_at
+	`, fmt.Sprintf("%d", req.ProviderFailureMinutes), time.Now().Unix()); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save settings"})
+	}
+	database.InvalidateSettings()
 
 	// Update the live service
     recipients := []string{}
@@ -139,11 +168,13 @@ func GetConfig(c *fiber.Ctx) error {
 		OfflineTimeout: 60,
         CronGlobalTimeout: 300,
         CronTimeouts: make(map[string]int),
+        CronAnomalyFactor: defaultCronAnomalyFactor,
+        CronFailureThreshold: defaultCronFailureThreshold,
 	}
 
 	loadJSON := func(key string, target interface{}) {
 		var val string
-		if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&val); err == nil {
+		if err := database.GetSetting(key, &val); err == nil {
 			json.Unmarshal([]byte(val), target)
 		}
 	}
@@ -158,34 +189,43 @@ func GetConfig(c *fiber.Ctx) error {
 	loadJSON("drift_paths", &config.DriftPaths)
 
 	var val string
-	if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'offline_timeout'").Scan(&val); err == nil {
+	if err := database.GetSetting("offline_timeout", &val); err == nil {
 		fmt.Sscanf(val, "%d", &config.OfflineTimeout)
 	}
-    if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'cron_global_timeout'").Scan(&val); err == nil {
+    if err := database.GetSetting("cron_global_timeout", &val); err == nil {
 		fmt.Sscanf(val, "%d", &config.CronGlobalTimeout)
 	}
+    config.CronAnomalyFactor = loadCronAnomalyFactor()
+    config.CronFailureThreshold = loadCronFailureThreshold()
+    config.DiskExcludeFSTypes, config.DiskExcludePaths = health.LoadDiskExclusions()
+    config.EgressMonitorEnabled, config.EgressAllow = loadEgressSettings()
     
     // Load drift_interval
     config.DriftInterval = 300 // Default 5 mins
-    if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'drift_interval'").Scan(&val); err == nil {
+    if err := database.GetSetting("drift_interval", &val); err == nil {
         fmt.Sscanf(val, "%d", &config.DriftInterval)
     }
 
     // Load health settings
     config.HealthEnabled = true // Default
-    if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'health_enabled'").Scan(&val); err == nil {
+    if err := database.GetSetting("health_enabled", &val); err == nil {
          if val == "false" || val == "0" {
             config.HealthEnabled = false
         }
     }
 
     config.HealthSustainDuration = 30 // Default
-    if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'health_sustain_duration'").Scan(&val); err == nil {
+    if err := database.GetSetting("health_sustain_duration", &val); err == nil {
         fmt.Sscanf(val, "%d", &config.HealthSustainDuration)
     }
 
+    // Load network drift (listening ports), default off
+    if err := database.GetSetting("network_drift_enabled", &val); err == nil {
+        config.NetworkDriftEnabled = val == "true"
+    }
+
     config.StabilityWindow = 120 // Default 2 mins
-    if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'stability_window'").Scan(&val); err == nil {
+    if err := database.GetSetting("stability_window", &val); err == nil {
         fmt.Sscanf(val, "%d", &config.StabilityWindow)
     }
 
@@ -233,7 +273,7 @@ func GetConfig(c *fiber.Ctx) error {
     var cronEnabledVal string
     // Default to true if not found
     config.CronEnabled = true
-    if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'cron_enabled'").Scan(&cronEnabledVal); err == nil {
+    if err := database.GetSetting("cron_enabled", &cronEnabledVal); err == nil {
         if cronEnabledVal == "false" || cronEnabledVal == "0" {
             config.CronEnabled = false
         }
@@ -242,7 +282,7 @@ func GetConfig(c *fiber.Ctx) error {
     // Load cron_auto_discover
     var cronAutoDiscoverVal string
     config.CronAutoDiscover = true // Default to true
-    if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'cron_auto_discover'").Scan(&cronAutoDiscoverVal); err == nil {
+    if err := database.GetSetting("cron_auto_discover", &cronAutoDiscoverVal); err == nil {
          if cronAutoDiscoverVal == "false" || cronAutoDiscoverVal == "0" {
             config.CronAutoDiscover = false
         }
@@ -253,6 +293,9 @@ func GetConfig(c *fiber.Ctx) error {
         "drift_ignore": config.DriftIgnore,
         "drift_paths": config.DriftPaths,
         "drift_interval": config.DriftInterval,
+        "network_drift_enabled": config.NetworkDriftEnabled,
+        "egress_monitor_enabled": config.EgressMonitorEnabled,
+        "egress_allow": config.EgressAllow,
         "health_enabled": config.HealthEnabled,
         "health_sustain_duration": config.HealthSustainDuration,
         "cron_enabled": config.CronEnabled,
@@ -260,19 +303,109 @@ func GetConfig(c *fiber.Ctx) error {
         "cron_ignore": config.CronIgnore,
         "cron_global_timeout": config.CronGlobalTimeout,
         "cron_timeouts": config.CronTimeouts,
+        "cron_anomaly_factor": config.CronAnomalyFactor,
+        "cron_failure_threshold": config.CronFailureThreshold,
         "thresholds": config.Thresholds,
+        "disk_exclude_fstypes": config.DiskExcludeFSTypes,
+        "disk_exclude_paths": config.DiskExcludePaths,
         "offline_timeout": config.OfflineTimeout,
         "stability_window": config.StabilityWindow,
+        "retention_metrics_days": maintenance.LoadMetricsRetentionDays(),
+        "retention_events_days": maintenance.LoadEventsRetentionDays(),
+        "retention_event_types": maintenance.LoadEventTypeRetention(),
+        "metrics_archive_enabled": maintenance.MetricsArchiveEnabled(),
         "discovered_cron_jobs": discoveredJobs,
     })
 }
 
+// validateDriftIgnore checks drift ignore patterns the way the agent parses them:
+// "re:" regexes, "dir/" prefixes and filepath.Match globs
+func validateDriftIgnore(patterns []string) error {
+	for _, p := range patterns {
+		switch {
+		case strings.HasPrefix(p, "re:"):
+			if _, err := regexp.Compile(strings.TrimPrefix(p, "re:")); err != nil {
+				return fmt.Errorf("invalid drift ignore regex %q: %v", p, err)
+			}
+		case strings.HasSuffix(p, "/"):
+			if dir := filepath.Clean(p); dir == "/" || dir == "." {
+				return fmt.Errorf("drift ignore %q would ignore everything", p)
+			}
+		default:
+			if _, err := filepath.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid drift ignore pattern %q: %v", p, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateDiskExcludePaths checks disk exclusion paths: absolute mount points
+// (excluding everything below them) or filepath.Match globs
+func validateDiskExcludePaths(paths []string) error {
+	for _, p := range paths {
+		if !strings.HasPrefix(p, "/") {
+			return fmt.Errorf("disk exclusion %q must be an absolute path", p)
+		}
+		if filepath.Clean(p) == "/" {
+			return fmt.Errorf("disk exclusion %q would exclude every filesystem", p)
+		}
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid disk exclusion pattern %q: %v", p, err)
+		}
+	}
+	return nil
+}
+
 // SaveConfig updates the global configuration settings
 func SaveConfig(c *fiber.Ctx) error {
 	var req models.AgentConfig
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
 	}
+	if err := validateDriftIgnore(req.DriftIgnore); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	cronKeys := []string{}
+	for k := range req.CronIgnore {
+		cronKeys = append(cronKeys, k)
+	}
+	for k := range req.CronTimeouts {
+		cronKeys = append(cronKeys, k)
+	}
+	if err := validateCronKeys(cronKeys); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if req.CronAnomalyFactor != 0 && req.CronAnomalyFactor < 1.5 {
+		return c.Status(400).JSON(fiber.Map{"error": "Cron anomaly factor must be 0 (off) or at least 1.5"})
+	}
+	if err := validateDiskExcludePaths(req.DiskExcludePaths); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := validateEgressAllow(req.EgressAllow); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if req.CronFailureThreshold == 0 {
+		req.CronFailureThreshold = defaultCronFailureThreshold
+	}
+	if req.CronFailureThreshold < 1 || req.CronFailureThreshold > 100 {
+		return c.Status(400).JSON(fiber.Map{"error": "Cron failure threshold must be between 1 and 100"})
+	}
+	// Retention lives next to the agent settings; omitted keys keep their value
+	var retention struct {
+		MetricsDays *int           `json:"retention_metrics_days"`
+		EventsDays  *int           `json:"retention_events_days"`
+		EventTypes  map[string]int `json:"retention_event_types"`
+		Archive     *bool          `json:"metrics_archive_enabled"`
+	}
+	if strings.HasPrefix(c.Get("Content-Type"), fiber.MIMEApplicationJSON) {
+		if err := json.Unmarshal(c.Body(), &retention); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid retention settings"})
+		}
+	}
+	if err := validateRetention(retention.MetricsDays, retention.EventsDays, retention.EventTypes); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 
 	saveJSON := func(key string, val interface{}) {
 		bytes, _ := json.Marshal(val)
@@ -290,6 +423,28 @@ func SaveConfig(c *fiber.Ctx) error {
 	saveJSON("cron_ignore", req.CronIgnore)
 	saveJSON("cron_timeouts", req.CronTimeouts)
 	saveJSON("thresholds", req.Thresholds)
+	// Omitted by older clients: keep the stored exclusions
+	if req.DiskExcludeFSTypes != nil {
+		saveJSON("disk_exclude_fstypes", req.DiskExcludeFSTypes)
+	}
+	if req.DiskExcludePaths != nil {
+		saveJSON("disk_exclude_paths", req.DiskExcludePaths)
+	}
+	if req.EgressAllow != nil {
+		saveJSON("egress_allow", req.EgressAllow)
+	}
+	if retention.MetricsDays != nil {
+		saveJSON("retention_metrics_days", *retention.MetricsDays)
+	}
+	if retention.EventsDays != nil {
+		saveJSON("retention_events_days", *retention.EventsDays)
+	}
+	if retention.EventTypes != nil {
+		saveJSON("retention_event_types", retention.EventTypes)
+	}
+	if retention.Archive != nil {
+		saveJSON("metrics_archive_enabled", *retention.Archive)
+	}
 	
 	database.DB.Exec(`
 		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
@@ -301,6 +456,16 @@ func SaveConfig(c *fiber.Ctx) error {
 		ON CONFLICT(key) DO UPDATE SET value=excluded.value, updated_at=excluded.updated_at
 	`, "cron_global_timeout", fmt.Sprintf("%d", req.CronGlobalTimeout), time.Now().Unix())
 
+    database.DB.Exec(`
+		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value=excluded.value, updated_at=excluded.updated_at
+	`, "cron_anomaly_factor", fmt.Sprintf("%g", req.CronAnomalyFactor), time.Now().Unix())
+
+    database.DB.Exec(`
+		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value=excluded.value, updated_at=excluded.updated_at
+	`, "cron_failure_threshold", fmt.Sprintf("%d", req.CronFailureThreshold), time.Now().Unix())
+
     database.DB.Exec(`
 		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
 		ON CONFLICT(key) DO UPDATE SET value=excluded.value, updated_at=excluded.updated_at
@@ -331,5 +496,95 @@ func SaveConfig(c *fiber.Ctx) error {
 		ON CONFLICT(key) DO UPDATE SET value=excluded.value, updated_at=excluded.updated_at
 	`, "stability_window", fmt.Sprintf("%d", req.StabilityWindow), time.Now().Unix())
 
+    database.DB.Exec(`
+		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value=excluded.value, updated_at=excluded.updated_at
+	`, "network_drift_enabled", fmt.Sprintf("%t", req.NetworkDriftEnabled), time.Now().Unix())
+
+    database.DB.Exec(`
+		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value=excluded.value, updated_at=excluded.updated_at
+	`, "egress_monitor_enabled", fmt.Sprintf("%t", req.EgressMonitorEnabled), time.Now().Unix())
+	database.InvalidateSettings()
+	push.NotifyAll()
+
 	return c.JSON(fiber.Map{"status": "ok"})
 }
+
+// validateRetention checks retention settings (days): metrics 1-3650, events
+// 0 (per severity) to 3650, event type overrides 1-3650
+func validateRetention(metricsDays, eventsDays *int, eventTypes map[string]int) error {
+	if metricsDays != nil && (*metricsDays < 1 || *metricsDays > 3650) {
+		return fmt.Errorf("Metrics retention must be between 1 and 3650 days")
+	}
+	if eventsDays != nil && (*eventsDays < 0 || *eventsDays > 3650) {
+		return fmt.Errorf("Events retention must be between 0 (per severity) and 3650 days")
+	}
+	for eventType, days := range eventTypes {
+		if eventType == "" || len(eventType) > 64 {
+			return fmt.Errorf("Invalid event type: %q", eventType)
+		}
+		if days < 1 || days > 3650 {
+			return fmt.Errorf("Retention for %s events must be between 1 and 3650 days", eventType)
+		}
+	}
+	return nil
+}
+
+// GetRetentionSettings returns the metrics and event retention (days)
+func GetRetentionSettings(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"event_retention":         maintenance.LoadEventRetention(),
+		"retention_metrics_days":  maintenance.LoadMetricsRetentionDays(),
+		"retention_events_days":   maintenance.LoadEventsRetentionDays(),
+		"retention_event_types":   maintenance.LoadEventTypeRetention(),
+		"metrics_archive_enabled": maintenance.MetricsArchiveEnabled(),
+	})
+}
+
+// GetMetricsArchives lists the monthly metrics archive files
+func GetMetricsArchives(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"enabled":   maintenance.MetricsArchiveEnabled(),
+		"directory": maintenance.MetricsArchiveDir(),
+		"archives":  maintenance.ListMetricsArchives(),
+	})
+}
+
+// SaveRetentionSettings updates the per-severity event retention (days)
+func SaveRetentionSettings(c *fiber.Ctx) error {
+	var req struct {
+		EventRetention maintenance.EventRetention `json:"event_retention"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	for key, days := range req.EventRetention {
+		if _, known := maintenance.DefaultEventRetention[key]; !known {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("Unknown retention key: %s", key)})
+		}
+		if days < 1 || days > 3650 {
+			return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("Retention for %s must be between 1 and 3650 days", key)})
+		}
+	}
+
+	// Merge onto current values so partial updates keep the rest
+	retention := maintenance.LoadEventRetention()
+	for key, days := range req.EventRetention {
+		retention[key] = days
+	}
+
+	bytes, _ := json.Marshal(retention)
+	_, err := database.DB.Exec(`
+		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value=excluded.value, updated_at=excluded.updated_at
+	`, "event_retention", string(bytes), time.Now().Unix())
+	database.InvalidateSettings()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save retention settings"})
+	}
+
+	recordAudit(c, "settings.retention", "", string(bytes))
+	return c.JSON(fiber.Map{"status": "ok", "event_retention": retention})
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// maxSilenceMinutes bounds a silence (30 days), so none is forgotten forever
+const maxSilenceMinutes = 30 * 24 * 60
+
+// GetSilences lists the active silences, or with ?all=true also the last
+// expired ones
+func GetSilences(c *fiber.Ctx) error {
+	query := `
+		SELECT id, COALESCE(server_id, ''), COALESCE(tag, ''), COALESCE(event_type, ''), COALESCE(severity, ''),
+			COALESCE(comment, ''), COALESCE(created_by, ''), created_at, expires_at
+		FROM silences`
+	args := []interface{}{}
+	if c.Query("all") != "true" {
+		query += " WHERE expires_at > ?"
+		args = append(args, time.Now().Unix())
+	}
+	query += " ORDER BY expires_at DESC LIMIT 200"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	silences := []models.Silence{}
+	for rows.Next() {
+		var s models.Silence
+		if err := rows.Scan(&s.ID, &s.ServerID, &s.Tag, &s.EventType, &s.Severity, &s.Comment, &s.CreatedBy, &s.CreatedAt, &s.ExpiresAt); err == nil {
+			silences = append(silences, s)
+		}
+	}
+	return c.JSON(silences)
+}
+
+// CreateSilence mutes the notifications matching server_id, tag, event_type
+// and severity (each optional, at least one required) for duration_minutes.
+// Events are still stored; only the notifications are held back.
+func CreateSilence(c *fiber.Ctx) error {
+	var req struct {
+		models.Silence
+		DurationMinutes int `json:"duration_minutes"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	s := req.Silence
+	s.ServerID = strings.TrimSpace(s.ServerID)
+	s.Tag = strings.ToLower(strings.TrimSpace(s.Tag))
+	s.EventType = strings.TrimSpace(s.EventType)
+	s.Severity = strings.ToLower(strings.TrimSpace(s.Severity))
+	s.Comment = strings.TrimSpace(s.Comment)
+
+	if s.ServerID == "" && s.Tag == "" && s.EventType == "" && s.Severity == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "At least one of server_id, tag, event_type or severity is required"})
+	}
+	if s.ServerID != "" {
+		var exists int
+		if err := database.DB.QueryRow("SELECT 1 FROM servers WHERE id = ?", s.ServerID).Scan(&exists); err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+		}
+	}
+	if s.Tag != "" && !tagPattern.MatchString(s.Tag) {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("invalid tag: %s", s.Tag)})
+	}
+	if len(s.EventType) > 64 {
+		return c.Status(400).JSON(fiber.Map{"error": "event_type is too long"})
+	}
+	switch s.Severity {
+	case "", "critical", "warning", "info":
+	default:
+		return c.Status(400).JSON(fiber.Map{"error": "severity must be critical, warning or info"})
+	}
+	if len(s.Comment) > 200 {
+		return c.Status(400).JSON(fiber.Map{"error": "Comment is too long (max 200 characters)"})
+	}
+	if req.DurationMinutes <= 0 || req.DurationMinutes > maxSilenceMinutes {
+		return c.Status(400).JSON(fiber.Map{"error": fmt.Sprintf("duration_minutes must be between 1 and %d", maxSilenceMinutes)})
+	}
+
+	now := time.Now()
+	s.CreatedBy, _ = c.Locals("username").(string)
+	s.CreatedAt = now.Unix()
+	s.ExpiresAt = now.Add(time.Duration(req.DurationMinutes) * time.Minute).Unix()
+	res, err := database.DB.Exec(`
+		INSERT INTO silences (server_id, tag, event_type, severity, comment, created_by, created_at, expires_at)
+		VALUES (NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?, ?)
+	`, s.ServerID, s.Tag, s.EventType, s.Severity, s.Comment, s.CreatedBy, s.CreatedAt, s.ExpiresAt)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to create silence"})
+	}
+	s.ID, _ = res.LastInsertId()
+
+	recordAudit(c, "silence.create", fmt.Sprintf("%d", s.ID), fmt.Sprintf("server=%s tag=%s type=%s severity=%s for %d minutes %s",
+		s.ServerID, s.Tag, s.EventType, s.Severity, req.DurationMinutes, s.Comment))
+	return c.Status(201).JSON(s)
+}
+
+// DeleteSilence expires a silence early; it stays listed with ?all=true
+func DeleteSilence(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid silence ID"})
+	}
+	now := time.Now().Unix()
+	res, err := database.DB.Exec("UPDATE silences SET expires_at = ? WHERE id = ? AND expires_at > ?", now, id, now)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Silence not found or already expired"})
+	}
+
+	recordAudit(c, "silence.expire", fmt.Sprintf("%d", id), "")
+	return c.JSON(fiber.Map{"status": "expired"})
+}
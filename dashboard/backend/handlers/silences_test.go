@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/health"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+func TestSilences(t *testing.T) {
+	testDB(t)
+	seedServer(t, "s1", "db-1", "db")
+	seedServer(t, "s2", "web-1")
+
+	app := fiber.New()
+	app.Get("/silences", GetSilences)
+	app.Post("/silences", CreateSilence)
+	app.Delete("/silences/:id", DeleteSilence)
+	create := func(body string) (int, models.Silence) {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/silences", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s models.Silence
+		json.NewDecoder(resp.Body).Decode(&s)
+		return resp.StatusCode, s
+	}
+
+	if status, _ := create(`{"duration_minutes": 60}`); status != 400 {
+		t.Errorf("silence without matchers: status %d, want 400", status)
+	}
+	if status, _ := create(`{"severity": "fatal", "duration_minutes": 60}`); status != 400 {
+		t.Errorf("unknown severity: status %d, want 400", status)
+	}
+	status, s := create(`{"tag": "db", "event_type": "cron", "duration_minutes": 60, "comment": "noisy backup job"}`)
+	if status != 201 || s.ID == 0 {
+		t.Fatalf("create silence: status %d, %+v", status, s)
+	}
+
+	cases := []struct {
+		serverID, eventType string
+		want                bool
+	}{
+		{"s1", "cron", true},
+		{"s1", "health", false},
+		{"s1", "", false},
+		{"s2", "cron", false},
+	}
+	for _, tc := range cases {
+		if _, got := health.Silenced(tc.serverID, tc.eventType, "critical"); got != tc.want {
+			t.Errorf("Silenced(%s, %q) = %v, want %v", tc.serverID, tc.eventType, got, tc.want)
+		}
+	}
+
+	// Expiring it lets the notifications through again
+	resp, _ := app.Test(httptest.NewRequest("DELETE", fmt.Sprintf("/silences/%d", s.ID), nil))
+	if resp.StatusCode != 200 {
+		t.Fatalf("expire silence: status %d", resp.StatusCode)
+	}
+	if _, ok := health.Silenced("s1", "cron", "critical"); ok {
+		t.Error("expired silence still matches")
+	}
+	resp, _ = app.Test(httptest.NewRequest("GET", "/silences", nil))
+	var active []models.Silence
+	json.NewDecoder(resp.Body).Decode(&active)
+	if len(active) != 0 {
+		t.Errorf("active silences = %+v, want none", active)
+	}
+}
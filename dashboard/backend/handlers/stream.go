@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/live"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// streamKeepAlive is how often an idle stream sends a comment, which also
+// notices dashboards that went away
+const streamKeepAlive = 25 * time.Second
+
+// eventTailInterval is how often new events are picked up for the stream
+const eventTailInterval = time.Second
+
+// LiveStream sends server status changes, new events and metric ticks to a
+// dashboard as Server-Sent Events, limited to the servers the user may see.
+// EventSource cannot set headers, so the token may be passed as ?token=.
+// New events reach the stream through StartEventTail.
+func LiveStream(c *fiber.Ctx) error {
+	// Decided now: the request context is gone once streaming starts
+	clause, scope := middleware.ServerVisibilityClause(c, "?")
+	visible := map[string]bool{}
+	canSee := func(serverID string) bool {
+		if len(scope) == 0 {
+			return true
+		}
+		if seen, ok := visible[serverID]; ok {
+			return seen
+		}
+		var one int
+		err := database.DB.QueryRow("SELECT 1 WHERE "+clause, append([]interface{}{serverID}, scope...)...).Scan(&one)
+		visible[serverID] = err == nil
+		return err == nil
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	updates, unsubscribe := live.Subscribe()
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+
+		fmt.Fprint(w, "retry: 3000\n\n")
+		if w.Flush() != nil {
+			return
+		}
+		for {
+			select {
+			case u, open := <-updates:
+				if !open {
+					// Fell behind: the dashboard reconnects and reloads
+					return
+				}
+				if !canSee(u.ServerID) {
+					continue
+				}
+				data, err := json.Marshal(u.Data)
+				if err != nil {
+					continue
+				}
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", u.Type, data)
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+			if w.Flush() != nil {
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// StartEventTail publishes events to the live stream as they are stored,
+// whichever code path stored them. Nothing is read while no dashboard is
+// connected. The returned func stops it and waits until it has.
+func StartEventTail() (stop func()) {
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		tailEvents(done)
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// tailEvents polls for new events until done is closed
+func tailEvents(done <-chan struct{}) {
+	var lastID int64
+	database.DB.QueryRow("SELECT COALESCE(MAX(id), 0) FROM events").Scan(&lastID)
+
+	ticker := time.NewTicker(eventTailInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		if live.Subscribers() == 0 {
+			database.DB.QueryRow("SELECT COALESCE(MAX(id), ?) FROM events", lastID).Scan(&lastID)
+			continue
+		}
+		var err error
+		if lastID, err = publishNewEvents(lastID); err != nil {
+			log.Printf("Live stream: failed to read events: %v", err)
+		}
+	}
+}
+
+// publishNewEvents publishes the events stored after lastID and returns the
+// new last ID
+func publishNewEvents(lastID int64) (int64, error) {
+	rows, err := database.DB.Query(`
+		SELECT id, server_id, timestamp, event_type, severity, message, COALESCE(details, ''), COALESCE(acknowledged, 0)
+		FROM events
+		WHERE id > ?
+		ORDER BY id
+		LIMIT 500
+	`, lastID)
+	if err != nil {
+		return lastID, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e models.Event
+		if err := rows.Scan(&e.ID, &e.ServerID, &e.Timestamp, &e.EventType, &e.Severity, &e.Message, &e.Details, &e.Acknowledged); err != nil {
+			return lastID, err
+		}
+		lastID = e.ID
+		live.Publish(live.Update{Type: live.TypeEvent, ServerID: e.ServerID, Data: e})
+	}
+	return lastID, rows.Err()
+}
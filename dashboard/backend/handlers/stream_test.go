@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/live"
+)
+
+func TestLiveStream(t *testing.T) {
+	testDB(t)
+	stop := StartEventTail()
+	defer stop()
+
+	app := fiber.New()
+	app.Get("/stream", LiveStream)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go app.Listener(ln)
+	defer ln.Close()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	next := func() string {
+		t.Helper()
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					t.Fatal("stream closed")
+				}
+				if strings.HasPrefix(line, "event:") || strings.HasPrefix(line, "data:") {
+					return line
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("no update received")
+			}
+		}
+	}
+
+	// Wait for the subscription before publishing
+	for live.Subscribers() == 0 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	live.Publish(live.Update{Type: live.TypeStatus, ServerID: "s1", Data: map[string]string{"status": "offline"}})
+	if event, data := next(), next(); event != "event: status" || data != `data: {"status":"offline"}` {
+		t.Fatalf("got %q / %q", event, data)
+	}
+
+	// Events are picked up from the table, whoever stored them
+	seedServer(t, "s1", "web-1")
+	mustExec(t, `INSERT INTO events (server_id, timestamp, event_type, severity, message) VALUES ('s1', 1000, 'drift', 'warning', 'changed')`)
+	if event, data := next(), next(); event != "event: event" || !strings.Contains(data, `"message":"changed"`) {
+		t.Fatalf("got %q / %q", event, data)
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/license"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+)
+
+// GetFleetSummary returns the fleet at a glance in one call: visible servers
+// by health status, how many are offline, their events of the last 24 hours
+// by severity, and the license utilization
+func GetFleetSummary(c *fiber.Ctx) error {
+	now := time.Now().Unix()
+	offlineTimeout := 120
+	var val string
+	if err := database.GetSetting("offline_timeout", &val); err == nil {
+		fmt.Sscanf(val, "%d", &offlineTimeout)
+	}
+
+	visible, args := middleware.ServerVisibilityClause(c, "id")
+	byStatus := map[string]int{}
+	total, offline := 0, 0
+	rows, err := database.DB.Query(`
+		SELECT COALESCE(NULLIF(health_status, ''), 'unknown'), COUNT(*), COALESCE(SUM(last_seen < ?), 0)
+		FROM servers
+		WHERE `+visible+`
+		GROUP BY 1
+	`, append([]interface{}{now - int64(offlineTimeout)}, args...)...)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	for rows.Next() {
+		var status string
+		var count, stale int
+		if err := rows.Scan(&status, &count, &stale); err != nil {
+			continue
+		}
+		byStatus[status] = count
+		total += count
+		offline += stale
+	}
+	rows.Close()
+
+	visible, args = middleware.ServerVisibilityClause(c, "server_id")
+	bySeverity := map[string]int{}
+	events := 0
+	rows, err = database.DB.Query(`
+		SELECT severity, COUNT(*)
+		FROM events
+		WHERE timestamp >= ? AND `+visible+`
+		GROUP BY severity
+	`, append([]interface{}{now - 24*3600}, args...)...)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	for rows.Next() {
+		var severity string
+		var count int
+		if err := rows.Scan(&severity, &count); err != nil {
+			continue
+		}
+		bySeverity[severity] = count
+		events += count
+	}
+	rows.Close()
+
+	// Licensed slots are used by every server, visible or not
+	var licensed int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM servers").Scan(&licensed); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	status := license.GetStatus(licensed)
+	utilization := 0.0
+	if status.MaxServers > 0 {
+		utilization = float64(status.CurrentServers) * 100 / float64(status.MaxServers)
+	}
+
+	return c.JSON(fiber.Map{
+		"servers": fiber.Map{
+			"total":     total,
+			"offline":   offline,
+			"by_status": byStatus,
+		},
+		"events_24h": fiber.Map{
+			"total":       events,
+			"by_severity": bySeverity,
+		},
+		"license": fiber.Map{
+			"status":              status,
+			"utilization_percent": utilization,
+		},
+		"generated_at": now,
+	})
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGetFleetSummary(t *testing.T) {
+	testDB(t)
+	now := time.Now().Unix()
+	mustExec(t, `INSERT INTO servers (id, hostname, api_secret_hash, first_seen, last_seen, health_status) VALUES
+		('s1', 'web-1', 'x', 0, ?1, 'healthy'), ('s2', 'web-2', 'x', 0, ?1, 'healthy'),
+		('s3', 'db-1', 'x', 0, ?1, 'critical'), ('s4', 'db-2', 'x', 0, ?2, 'offline')`, now, now-3600)
+	mustExec(t, `INSERT INTO events (server_id, timestamp, event_type, severity, message) VALUES
+		('s1', ?1, 'health', 'warning', 'm'), ('s3', ?1, 'health', 'critical', 'm'),
+		('s3', ?1, 'health', 'critical', 'm'), ('s3', ?2, 'health', 'critical', 'old')`, now-60, now-2*24*3600)
+
+	app := fiber.New()
+	app.Get("/summary", GetFleetSummary)
+	resp, err := app.Test(httptest.NewRequest("GET", "/summary", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var summary struct {
+		Servers struct {
+			Total    int            `json:"total"`
+			Offline  int            `json:"offline"`
+			ByStatus map[string]int `json:"by_status"`
+		} `json:"servers"`
+		Events struct {
+			Total      int            `json:"total"`
+			BySeverity map[string]int `json:"by_severity"`
+		} `json:"events_24h"`
+	}
+	json.NewDecoder(resp.Body).Decode(&summary)
+
+	if summary.Servers.Total != 4 || summary.Servers.Offline != 1 || summary.Servers.ByStatus["healthy"] != 2 || summary.Servers.ByStatus["critical"] != 1 {
+		t.Errorf("servers = %+v", summary.Servers)
+	}
+	if summary.Events.Total != 3 || summary.Events.BySeverity["critical"] != 2 || summary.Events.BySeverity["warning"] != 1 {
+		t.Errorf("events = %+v", summary.Events)
+	}
+}
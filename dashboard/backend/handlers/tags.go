@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+	"github.com/yourusername/health-dashboard-backend/push"
+)
+
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]{1,64}$`)
+
+// normalizeTags trims, validates and de-duplicates tags
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool)
+	out := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag: %s", tag)
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// parseTagList normalizes a comma separated list of tags (?tags=prod,web)
+func parseTagList(raw string) ([]string, error) {
+	return normalizeTags(strings.Split(raw, ","))
+}
+
+// tagFilterClause restricts column (a server ID) to servers carrying any of
+// the comma separated tags; "1=1" if there are none
+func tagFilterClause(raw, column string) (string, []interface{}, error) {
+	tags, err := parseTagList(raw)
+	if err != nil || len(tags) == 0 {
+		return "1=1", nil, err
+	}
+	args := make([]interface{}, len(tags))
+	for i, tag := range tags {
+		args[i] = tag
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(tags)), ",")
+	return column + " IN (SELECT server_id FROM server_tags WHERE tag IN (" + placeholders + "))", args, nil
+}
+
+// serverTags returns tags per server ID
+func serverTags() map[string][]string {
+	tags := make(map[string][]string)
+	rows, err := database.DB.Query("SELECT server_id, tag FROM server_tags ORDER BY tag")
+	if err != nil {
+		return tags
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, tag string
+		if rows.Scan(&id, &tag) == nil {
+			tags[id] = append(tags[id], tag)
+		}
+	}
+	return tags
+}
+
+// GetServerTags returns the tags of a server
+func GetServerTags(c *fiber.Ctx) error {
+	tags := serverTags()[c.Params("id")]
+	if tags == nil {
+		tags = []string{}
+	}
+	return c.JSON(fiber.Map{"tags": tags})
+}
+
+// SetServerTags replaces the tags of a server
+func SetServerTags(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+
+	var req struct {
+		Tags []string `json:"tags"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var exists int
+	if err := database.DB.QueryRow("SELECT 1 FROM servers WHERE id = ?", serverID).Scan(&exists); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM server_tags WHERE server_id = ?", serverID); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT INTO server_tags (server_id, tag) VALUES (?, ?)", serverID, tag); err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "Failed to save tags"})
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save tags"})
+	}
+	push.Notify(serverID) // tags select the threshold profile
+
+	recordAudit(c, "server.tags", serverID, strings.Join(tags, ","))
+	return c.JSON(fiber.Map{"tags": tags})
+}
+
+// AddServerTags adds tags to a server, keeping its others
+func AddServerTags(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+
+	var req struct {
+		Tags []string `json:"tags"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(tags) == 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "No tags given"})
+	}
+
+	var exists int
+	if err := database.DB.QueryRow("SELECT 1 FROM servers WHERE id = ?", serverID).Scan(&exists); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
+	if err := addTags(serverID, tags); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save tags"})
+	}
+	push.Notify(serverID)
+
+	recordAudit(c, "server.tags.add", serverID, strings.Join(tags, ","))
+	return GetServerTags(c)
+}
+
+// RemoveServerTag removes one tag from a server
+func RemoveServerTag(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+	tag := strings.ToLower(c.Params("tag"))
+
+	res, err := database.DB.Exec("DELETE FROM server_tags WHERE server_id = ? AND tag = ?", serverID, tag)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Tag not found on server"})
+	}
+	push.Notify(serverID)
+
+	recordAudit(c, "server.tags.remove", serverID, tag)
+	return GetServerTags(c)
+}
+
+// addTags adds tags to a server, ignoring those it already has
+func addTags(serverID string, tags []string) error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, tag := range tags {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO server_tags (server_id, tag) VALUES (?, ?)", serverID, tag); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// TagCount is a tag and how many visible servers carry it
+type TagCount struct {
+	Tag     string `json:"tag"`
+	Servers int    `json:"servers"`
+}
+
+// GetTags lists the tags in use on the servers the user may see
+func GetTags(c *fiber.Ctx) error {
+	visible, args := middleware.ServerVisibilityClause(c, "server_id")
+	rows, err := database.DB.Query(`
+		SELECT tag, COUNT(*) FROM server_tags
+		WHERE `+visible+`
+		GROUP BY tag
+		ORDER BY tag
+	`, args...)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	tags := []TagCount{}
+	for rows.Next() {
+		var t TagCount
+		if rows.Scan(&t.Tag, &t.Servers) == nil {
+			tags = append(tags, t)
+		}
+	}
+	return c.JSON(tags)
+}
+
+// DeleteTag removes a tag from every server
+func DeleteTag(c *fiber.Ctx) error {
+	tag := strings.ToLower(c.Params("tag"))
+
+	rows, err := database.DB.Query("SELECT server_id FROM server_tags WHERE tag = ?", tag)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	var servers []string
+	for rows.Next() {
+		var id string
+		if rows.Scan(&id) == nil {
+			servers = append(servers, id)
+		}
+	}
+	rows.Close()
+	if len(servers) == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Tag not found"})
+	}
+
+	if _, err := database.DB.Exec("DELETE FROM server_tags WHERE tag = ?", tag); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	for _, id := range servers {
+		push.Notify(id)
+	}
+
+	recordAudit(c, "tag.delete", tag, fmt.Sprintf("removed from %d server(s)", len(servers)))
+	return c.JSON(fiber.Map{"status": "deleted", "servers": len(servers)})
+}
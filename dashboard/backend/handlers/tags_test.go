@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+func TestTagFilters(t *testing.T) {
+	testDB(t)
+	mustExec(t, `INSERT INTO servers (id, hostname, api_secret_hash, first_seen, last_seen) VALUES
+		('s1', 'web-1', 'x', 0, 0), ('s2', 'db-1', 'x', 0, 0), ('s3', 'batch-1', 'x', 0, 0)`)
+	mustExec(t, `INSERT INTO events (server_id, timestamp, event_type, severity, message) VALUES
+		('s1', 1, 'health', 'info', 'm'), ('s2', 2, 'health', 'info', 'm'), ('s3', 3, 'health', 'info', 'm')`)
+
+	// Servers onboarded by a tagged install package get its tags
+	tags, err := parseTagList(" Prod, web ,prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	packageID, err := createInstallPackage("web fleet", "127.0.0.1", tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := applyInstallPackageTags("s1", packageID); err != nil {
+		t.Fatal(err)
+	}
+	addTags("s2", []string{"prod", "db"})
+
+	app := fiber.New()
+	app.Get("/servers", GetServers)
+	app.Get("/events", GetAllEvents)
+	get := func(url string, out interface{}) int {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", url, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		json.NewDecoder(resp.Body).Decode(out)
+		return resp.StatusCode
+	}
+
+	var servers []models.Server
+	get("/servers?tag=web", &servers)
+	if len(servers) != 1 || servers[0].ID != "s1" || len(servers[0].Tags) != 2 {
+		t.Fatalf("tag=web: %+v", servers)
+	}
+	servers = nil
+	get("/servers?tag=db,web", &servers)
+	if len(servers) != 2 {
+		t.Fatalf("tag=db,web: %d servers, want 2", len(servers))
+	}
+
+	var events []models.Event
+	get("/events?tag=prod", &events)
+	if len(events) != 2 {
+		t.Fatalf("events tag=prod: %d, want 2", len(events))
+	}
+	if status := get("/servers?tag=bad%20tag", &servers); status != 400 {
+		t.Errorf("invalid tag: status %d, want 400", status)
+	}
+}
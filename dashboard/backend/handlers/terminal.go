@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/push"
+	"github.com/yourusername/health-dashboard-backend/terminal"
+)
+
+// terminalPollWait is how long long-poll requests are held open
+const terminalPollWait = 20 * time.Second
+
+// SetTerminalEnabled toggles remote terminal access for a server
+func SetTerminalEnabled(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+
+	var req struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	res, err := database.DB.Exec("UPDATE servers SET terminal_enabled = ? WHERE id = ?", req.Enabled, serverID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update server"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
+
+	recordAudit(c, "terminal.enable", serverID, fmt.Sprintf("enabled=%t", req.Enabled))
+	return c.JSON(fiber.Map{"status": "updated", "enabled": req.Enabled})
+}
+
+// OpenTerminal starts a remote terminal session; the agent connects on its next config poll
+func OpenTerminal(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+
+	var enabled bool
+	if err := database.DB.QueryRow("SELECT COALESCE(terminal_enabled, 0) FROM servers WHERE id = ?", serverID).Scan(&enabled); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
+	if !enabled {
+		return c.Status(403).JSON(fiber.Map{"error": "Remote terminal is not enabled for this server"})
+	}
+
+	username, _ := c.Locals("username").(string)
+	sess, err := terminal.Open(serverID, username)
+	if err != nil {
+		log.Printf("❌ Failed to open terminal session: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to open session"})
+	}
+
+	_, err = database.DB.Exec(`
+		INSERT INTO terminal_sessions (id, server_id, username, started_at, recording_path)
+		VALUES (?, ?, ?, ?, ?)
+	`, sess.ID, serverID, username, sess.CreatedAt.Unix(), terminal.RecordingPath(sess.ID))
+	if err != nil {
+		sess.Close()
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to record session"})
+	}
+
+	push.Notify(serverID)
+
+	recordAudit(c, "terminal.open", serverID, fmt.Sprintf("session=%s", sess.ID))
+	return c.JSON(fiber.Map{"session_id": sess.ID})
+}
+
+// TerminalInput sends keystrokes to the remote shell
+func TerminalInput(c *fiber.Ctx) error {
+	sess, ok := ownedSession(c)
+	if !ok {
+		return nil
+	}
+
+	var req struct {
+		Data []byte `json:"data"` // base64
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	if err := sess.WriteInput(req.Data); err != nil {
+		return c.Status(410).JSON(fiber.Map{"error": "Session closed"})
+	}
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// TerminalOutput long-polls shell output from the given offset
+func TerminalOutput(c *fiber.Ctx) error {
+	sess, ok := ownedSession(c)
+	if !ok {
+		return nil
+	}
+
+	data, next, closed := sess.ReadOutput(c.QueryInt("offset", 0), terminalPollWait)
+	return c.JSON(fiber.Map{"data": data, "offset": next, "closed": closed})
+}
+
+// CloseTerminal ends a session
+func CloseTerminal(c *fiber.Ctx) error {
+	sess, ok := ownedSession(c)
+	if !ok {
+		return nil
+	}
+
+	sess.Close()
+	markTerminalEnded(sess.ID)
+	recordAudit(c, "terminal.close", sess.ServerID, fmt.Sprintf("session=%s", sess.ID))
+	return c.JSON(fiber.Map{"status": "closed"})
+}
+
+// GetTerminalSessions lists recorded sessions for a server
+func GetTerminalSessions(c *fiber.Ctx) error {
+	rows, err := database.DB.Query(`
+		SELECT id, server_id, username, started_at, COALESCE(ended_at, 0)
+		FROM terminal_sessions
+		WHERE server_id = ?
+		ORDER BY started_at DESC
+		LIMIT 100
+	`, c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	sessions := []models.TerminalSession{}
+	for rows.Next() {
+		var s models.TerminalSession
+		if err := rows.Scan(&s.ID, &s.ServerID, &s.Username, &s.StartedAt, &s.EndedAt); err != nil {
+			continue
+		}
+		sessions = append(sessions, s)
+	}
+
+	return c.JSON(sessions)
+}
+
+// DownloadTerminalRecording serves a session recording (asciicast v2)
+func DownloadTerminalRecording(c *fiber.Ctx) error {
+	var path string
+	if err := database.DB.QueryRow("SELECT recording_path FROM terminal_sessions WHERE id = ?", c.Params("sid")).Scan(&path); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Session not found"})
+	}
+	if _, err := os.Stat(path); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Recording not found"})
+	}
+
+	recordAudit(c, "terminal.recording", c.Params("sid"), "")
+	return c.Download(path, c.Params("sid")+".cast")
+}
+
+// AgentTerminalInput long-polls keystrokes for the agent's shell
+func AgentTerminalInput(c *fiber.Ctx) error {
+	sess, ok := agentSession(c)
+	if !ok {
+		return nil
+	}
+
+	data, closed := sess.ReadInput(terminalPollWait)
+	return c.JSON(fiber.Map{"data": data, "closed": closed})
+}
+
+// AgentTerminalOutput receives shell output from the agent
+func AgentTerminalOutput(c *fiber.Ctx) error {
+	var req struct {
+		ServerID  string `json:"server_id"`
+		APISecret string `json:"api_secret"`
+		Data      []byte `json:"data"`
+		Closed    bool   `json:"closed"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if !authenticateAgent(c, req.ServerID, req.APISecret) {
+		return c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
+	}
+
+	sess, ok := terminal.Get(c.Params("sid"))
+	if !ok || sess.ServerID != req.ServerID {
+		return c.Status(404).JSON(fiber.Map{"error": "Session not found"})
+	}
+
+	if len(req.Data) > 0 {
+		sess.WriteOutput(req.Data)
+	}
+	if req.Closed {
+		sess.Close()
+		markTerminalEnded(sess.ID)
+	}
+	if sess.Closed() {
+		return c.Status(410).JSON(fiber.Map{"error": "Session closed"})
+	}
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// StartTerminalReaper periodically closes idle terminal sessions
+func StartTerminalReaper() {
+	go func() {
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			for _, id := range terminal.Reap() {
+				log.Printf("🧹 Closed idle terminal session %s", id)
+				markTerminalEnded(id)
+			}
+		}
+	}()
+}
+
+// ownedSession resolves the :sid param to a session opened by the current user.
+// On failure the error response has already been written.
+func ownedSession(c *fiber.Ctx) (*terminal.Session, bool) {
+	sess, ok := terminal.Get(c.Params("sid"))
+	if !ok {
+		c.Status(404).JSON(fiber.Map{"error": "Session not found"})
+		return nil, false
+	}
+	username, _ := c.Locals("username").(string)
+	if sess.Username != username {
+		c.Status(403).JSON(fiber.Map{"error": "Session belongs to another user"})
+		return nil, false
+	}
+	return sess, true
+}
+
+// agentSession authenticates an agent request and resolves its session.
+// On failure the error response has already been written.
+func agentSession(c *fiber.Ctx) (*terminal.Session, bool) {
+	var req struct {
+		ServerID  string `json:"server_id"`
+		APISecret string `json:"api_secret"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+		return nil, false
+	}
+	if !authenticateAgent(c, req.ServerID, req.APISecret) {
+		c.Status(401).JSON(fiber.Map{"error": "Authentication failed"})
+		return nil, false
+	}
+
+	sess, ok := terminal.Get(c.Params("sid"))
+	if !ok || sess.ServerID != req.ServerID {
+		c.Status(404).JSON(fiber.Map{"error": "Session not found"})
+		return nil, false
+	}
+	return sess, true
+}
+
+func markTerminalEnded(id string) {
+	database.DB.Exec("UPDATE terminal_sessions SET ended_at = ? WHERE id = ? AND ended_at IS NULL", time.Now().Unix(), id)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/terminal"
+)
+
+func TestTerminalSessions(t *testing.T) {
+	testDB(t)
+	terminal.RecordingDir = t.TempDir()
+	seedServer(t, "s1", "web-1")
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("username", c.Get("X-User"))
+		return c.Next()
+	})
+	app.Put("/servers/:id/terminal", SetTerminalEnabled)
+	app.Post("/servers/:id/terminal", OpenTerminal)
+	app.Post("/terminal/:sid/input", TerminalInput)
+	app.Delete("/terminal/:sid", CloseTerminal)
+	app.Post("/agent/terminal/:sid/output", AgentTerminalOutput)
+	do := func(method, path, user, body string) (int, map[string]interface{}) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", user)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	// Terminals are opt-in per server
+	if status, _ := do("POST", "/servers/s1/terminal", "alice", ""); status != 403 {
+		t.Errorf("terminal not enabled: status %d, want 403", status)
+	}
+	if status, _ := do("POST", "/servers/s9/terminal", "alice", ""); status != 404 {
+		t.Errorf("unknown server: status %d, want 404", status)
+	}
+	if status, _ := do("PUT", "/servers/s1/terminal", "alice", `{"enabled": true}`); status != 200 {
+		t.Fatalf("enable: status %d", status)
+	}
+	status, out := do("POST", "/servers/s1/terminal", "alice", "")
+	sid, _ := out["session_id"].(string)
+	if status != 200 || sid == "" {
+		t.Fatalf("open: status %d, %v", status, out)
+	}
+
+	// Sessions belong to the admin who opened them, and agents must authenticate
+	if status, _ := do("POST", "/terminal/"+sid+"/input", "bob", `{"data": "bHMK"}`); status != 403 {
+		t.Errorf("input by another user: status %d, want 403", status)
+	}
+	if status, _ := do("POST", "/terminal/unknown/input", "alice", `{"data": "bHMK"}`); status != 404 {
+		t.Errorf("unknown session: status %d, want 404", status)
+	}
+	if status, _ := do("POST", "/agent/terminal/"+sid+"/output", "", `{"server_id": "s1", "api_secret": "wrong"}`); status != 401 {
+		t.Errorf("agent with a wrong secret: status %d, want 401", status)
+	}
+	if status, _ := do("POST", "/terminal/"+sid+"/input", "alice", `{"data": "bHMK"}`); status != 200 {
+		t.Errorf("input: status %d", status)
+	}
+
+	if status, _ := do("DELETE", "/terminal/"+sid, "alice", ""); status != 200 {
+		t.Fatalf("close: status %d", status)
+	}
+	var ended int64
+	database.DB.QueryRow("SELECT COALESCE(ended_at, 0) FROM terminal_sessions WHERE id = ?", sid).Scan(&ended)
+	if ended == 0 {
+		t.Error("closed session not marked ended")
+	}
+	if status, _ := do("POST", "/terminal/"+sid+"/input", "alice", `{"data": "bHMK"}`); status != 410 {
+		t.Errorf("input after close: status %d", status)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+// testDB opens a fresh database for the test, closed when it ends
+func testDB(t *testing.T) {
+	t.Helper()
+	if err := database.Init(filepath.Join(t.TempDir(), "health.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.Close() })
+}
+
+// seedServer adds a server (last seen at the epoch) carrying the given tags
+func seedServer(t *testing.T, id, hostname string, tags ...string) {
+	t.Helper()
+	mustExec(t, `INSERT INTO servers (id, hostname, api_secret_hash, first_seen, last_seen) VALUES (?, ?, 'x', 0, 0)`, id, hostname)
+	for _, tag := range tags {
+		mustExec(t, `INSERT INTO server_tags (server_id, tag) VALUES (?, ?)`, id, tag)
+	}
+}
+
+// mustExec runs a fixture statement, failing the test if it does not apply
+func mustExec(t *testing.T, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := database.DB.Exec(query, args...); err != nil {
+		t.Fatalf("fixture %q: %v", query, err)
+	}
+}
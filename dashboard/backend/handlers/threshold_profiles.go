@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/health"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/push"
+)
+
+// validateThresholds checks percentages are within 0-100 and warning <= critical (0 disables a level)
+func validateThresholds(t models.ResourceThresholds) error {
+	pairs := []struct {
+		name              string
+		warning, critical float64
+	}{
+		{"cpu", t.CPUWarning, t.CPUCritical},
+		{"memory", t.MemoryWarning, t.MemoryCritical},
+		{"disk", t.DiskWarning, t.DiskCritical},
+	}
+	for _, p := range pairs {
+		if p.warning < 0 || p.warning > 100 || p.critical < 0 || p.critical > 100 {
+			return fmt.Errorf("%s thresholds must be between 0 and 100", p.name)
+		}
+		if p.warning > 0 && p.critical > 0 && p.warning > p.critical {
+			return fmt.Errorf("%s warning threshold must not exceed critical", p.name)
+		}
+	}
+	return nil
+}
+
+// loadThresholdProfiles returns all profiles with their assigned tags
+func loadThresholdProfiles() ([]models.ThresholdProfile, error) {
+	rows, err := database.DB.Query("SELECT id, name, COALESCE(description, ''), thresholds, created_at FROM threshold_profiles ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := []models.ThresholdProfile{}
+	index := make(map[int64]int)
+	for rows.Next() {
+		var p models.ThresholdProfile
+		var raw string
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &raw, &p.CreatedAt); err != nil {
+			continue
+		}
+		json.Unmarshal([]byte(raw), &p.Thresholds)
+		p.Tags = []string{}
+		index[p.ID] = len(profiles)
+		profiles = append(profiles, p)
+	}
+
+	tagRows, err := database.DB.Query("SELECT profile_id, tag FROM threshold_profile_tags ORDER BY tag")
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+	for tagRows.Next() {
+		var id int64
+		var tag string
+		if tagRows.Scan(&id, &tag) == nil {
+			if i, ok := index[id]; ok {
+				profiles[i].Tags = append(profiles[i].Tags, tag)
+			}
+		}
+	}
+	return profiles, nil
+}
+
+// parseThresholdProfile reads and validates a profile from the request body
+func parseThresholdProfile(c *fiber.Ctx) (models.ThresholdProfile, error) {
+	var p models.ThresholdProfile
+	if err := c.BodyParser(&p); err != nil {
+		return p, fmt.Errorf("Invalid request")
+	}
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" || len(p.Name) > 64 {
+		return p, fmt.Errorf("Profile name is required (max 64 characters)")
+	}
+	if err := validateThresholds(p.Thresholds); err != nil {
+		return p, err
+	}
+	tags, err := normalizeTags(p.Tags)
+	if err != nil {
+		return p, err
+	}
+	p.Tags = tags
+	return p, nil
+}
+
+// saveThresholdProfileTags replaces the tags of a profile; a tag moves to this
+// profile if another one had it, since each tag resolves to a single profile
+func saveThresholdProfileTags(tx *sql.Tx, id int64, tags []string) error {
+	if _, err := tx.Exec("DELETE FROM threshold_profile_tags WHERE profile_id = ?", id); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec(`
+			INSERT INTO threshold_profile_tags (tag, profile_id) VALUES (?, ?)
+			ON CONFLICT(tag) DO UPDATE SET profile_id = excluded.profile_id
+		`, tag, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetThresholdProfiles lists threshold profiles
+func GetThresholdProfiles(c *fiber.Ctx) error {
+	profiles, err := loadThresholdProfiles()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	return c.JSON(profiles)
+}
+
+// CreateThresholdProfile adds a named threshold profile
+func CreateThresholdProfile(c *fiber.Ctx) error {
+	p, err := parseThresholdProfile(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	thresholds, _ := json.Marshal(p.Thresholds)
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer tx.Rollback()
+
+	p.CreatedAt = time.Now().Unix()
+	res, err := tx.Exec(`
+		INSERT INTO threshold_profiles (name, description, thresholds, created_at)
+		VALUES (?, ?, ?, ?)
+	`, p.Name, p.Description, string(thresholds), p.CreatedAt)
+	if err != nil {
+		return c.Status(409).JSON(fiber.Map{"error": "A profile with this name already exists"})
+	}
+	p.ID, _ = res.LastInsertId()
+	if err := saveThresholdProfileTags(tx, p.ID, p.Tags); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save profile tags"})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+
+	push.NotifyAll()
+	recordAudit(c, "threshold_profile.create", p.Name, strings.Join(p.Tags, ","))
+	return c.Status(201).JSON(p)
+}
+
+// UpdateThresholdProfile replaces a profile's thresholds, description and tags
+func UpdateThresholdProfile(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid profile ID"})
+	}
+	p, err := parseThresholdProfile(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	p.ID = id
+	thresholds, _ := json.Marshal(p.Thresholds)
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		UPDATE threshold_profiles SET name = ?, description = ?, thresholds = ?
+		WHERE id = ?
+	`, p.Name, p.Description, string(thresholds), id)
+	if err != nil {
+		return c.Status(409).JSON(fiber.Map{"error": "A profile with this name already exists"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Profile not found"})
+	}
+	if err := saveThresholdProfileTags(tx, id, p.Tags); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save profile tags"})
+	}
+	if err := tx.Commit(); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+
+	push.NotifyAll()
+	recordAudit(c, "threshold_profile.update", p.Name, strings.Join(p.Tags, ","))
+	return c.JSON(p)
+}
+
+// DeleteThresholdProfile removes a profile; servers using it fall back to tag or global thresholds
+func DeleteThresholdProfile(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var name string
+	if err := database.DB.QueryRow("SELECT name FROM threshold_profiles WHERE id = ?", id).Scan(&name); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Profile not found"})
+	}
+	database.DB.Exec("DELETE FROM threshold_profile_tags WHERE profile_id = ?", id)
+	if _, err := database.DB.Exec("DELETE FROM threshold_profiles WHERE id = ?", id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+
+	push.NotifyAll()
+	recordAudit(c, "threshold_profile.delete", name, "")
+	return c.JSON(fiber.Map{"status": "deleted"})
+}
+
+// GetServerThresholds returns the effective thresholds of a server and where they come from
+func GetServerThresholds(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+	cfg, err := loadServerConfiguration(serverID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
+
+	global := models.ResourceThresholds{
+		CPUWarning:     80,
+		CPUCritical:    95,
+		MemoryWarning:  80,
+		MemoryCritical: 95,
+		DiskWarning:    80,
+		DiskCritical:   95,
+	}
+	var raw string
+	if err := database.GetSetting("thresholds", &raw); err == nil {
+		json.Unmarshal([]byte(raw), &global)
+	}
+	thresholds, profile := health.ResolveThresholds(serverID, global)
+	group, _, _ := health.ServerGroupConfig(serverID)
+
+	return c.JSON(fiber.Map{
+		"profile_id": cfg.ThresholdProfile, // directly assigned profile (0 = none)
+		"profile":    profile,              // profile in effect (direct or via tag), "" = group or global
+		"group":      group,                // server group, "" = none
+		"thresholds": thresholds,
+	})
+}
+
+// SetServerThresholdProfile assigns a profile to a server (profile_id 0 clears it)
+func SetServerThresholdProfile(c *fiber.Ctx) error {
+	serverID := c.Params("id")
+
+	var req struct {
+		ProfileID int64 `json:"profile_id"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	cfg, err := loadServerConfiguration(serverID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
+
+	name := "none"
+	if req.ProfileID != 0 {
+		if err := database.DB.QueryRow("SELECT name FROM threshold_profiles WHERE id = ?", req.ProfileID).Scan(&name); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Profile not found"})
+		}
+	}
+
+	cfg.ThresholdProfile = req.ProfileID
+	if err := saveServerConfiguration(serverID, cfg); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save configuration"})
+	}
+
+	recordAudit(c, "server.threshold_profile", serverID, name)
+	return c.JSON(fiber.Map{"status": "saved", "profile_id": req.ProfileID})
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/tickets"
+)
+
+// GetTicketIntegrations lists the issue tracker integrations (without tokens)
+func GetTicketIntegrations(c *fiber.Ctx) error {
+	list, err := tickets.List()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	return c.JSON(list)
+}
+
+// parseTicketIntegration reads and validates an integration from the request body
+func parseTicketIntegration(c *fiber.Ctx) (models.TicketIntegration, error) {
+	var in models.TicketIntegration
+	if err := c.BodyParser(&in); err != nil {
+		return in, fmt.Errorf("Invalid request body")
+	}
+	if in.ProjectMap == nil {
+		in.ProjectMap = map[string]string{}
+	}
+	normalized := make(map[string]string, len(in.ProjectMap))
+	for tag, project := range in.ProjectMap {
+		tags, err := normalizeTags([]string{tag})
+		if err != nil {
+			return in, err
+		}
+		if len(tags) == 1 && project != "" {
+			normalized[tags[0]] = project
+		}
+	}
+	in.ProjectMap = normalized
+	return in, tickets.Validate(in)
+}
+
+// CreateTicketIntegration adds an integration
+func CreateTicketIntegration(c *fiber.Ctx) error {
+	in, err := parseTicketIntegration(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if in.Token == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Token is required"})
+	}
+	in.ID = 0
+	if err := tickets.Save(&in); err != nil {
+		log.Printf("❌ Failed to save ticket integration: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save integration"})
+	}
+	recordAudit(c, "ticket_integration.create", fmt.Sprintf("%d", in.ID), fmt.Sprintf("%s (%s)", in.Name, in.Kind))
+	return c.Status(201).JSON(in)
+}
+
+// UpdateTicketIntegration replaces an integration; an empty token keeps the stored one
+func UpdateTicketIntegration(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid integration ID"})
+	}
+	in, err := parseTicketIntegration(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	in.ID = int64(id)
+	if err := tickets.Save(&in); err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "Integration not found"})
+	} else if err != nil {
+		log.Printf("❌ Failed to save ticket integration: %v", err)
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to save integration"})
+	}
+	recordAudit(c, "ticket_integration.update", c.Params("id"), fmt.Sprintf("%s (%s)", in.Name, in.Kind))
+	return c.JSON(in)
+}
+
+// DeleteTicketIntegration removes an integration and its ticket records; the issues stay in the tracker
+func DeleteTicketIntegration(c *fiber.Ctx) error {
+	res, err := database.DB.Exec("DELETE FROM ticket_integrations WHERE id = ?", c.Params("id"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "Integration not found"})
+	}
+	recordAudit(c, "ticket_integration.delete", c.Params("id"), "")
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// TestTicketIntegration checks the stored credentials against the tracker
+func TestTicketIntegration(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid integration ID"})
+	}
+	if err := tickets.Test(int64(id)); err == sql.ErrNoRows {
+		return c.Status(404).JSON(fiber.Map{"error": "Integration not found"})
+	} else if err != nil {
+		return c.Status(502).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"status": "ok"})
+}
+
+// GetTickets lists the tickets opened for incidents, newest first.
+// Query: status (open, closed), limit (default 100, max 1000)
+func GetTickets(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 100)
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+	visible, args := middleware.ServerVisibilityClause(c, "t.server_id")
+	query := `
+		SELECT t.id, t.integration_id, t.server_id, COALESCE(s.hostname, ''), t.incident_key, t.project,
+			t.external_id, COALESCE(t.url, ''), t.status, COALESCE(t.error, ''), t.created_at, t.updated_at
+		FROM tickets t
+		LEFT JOIN servers s ON s.id = t.server_id
+		WHERE ` + visible
+	if status := c.Query("status"); status != "" {
+		query += " AND t.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY t.updated_at DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+	list := []models.Ticket{}
+	for rows.Next() {
+		var t models.Ticket
+		if err := rows.Scan(&t.ID, &t.IntegrationID, &t.ServerID, &t.Hostname, &t.IncidentKey, &t.Project,
+			&t.ExternalID, &t.URL, &t.Status, &t.Error, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			continue
+		}
+		list = append(list, t)
+	}
+	return c.JSON(list)
+}
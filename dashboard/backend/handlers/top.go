@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/health"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// TopServer is a server's latest usage of the ranked resource
+type TopServer struct {
+	ServerID     string  `json:"server_id"`
+	Hostname     string  `json:"hostname"`
+	HealthStatus string  `json:"health_status"`
+	Percent      float64 `json:"percent"`
+	Mount        string  `json:"mount,omitempty"` // disk: the fullest filesystem
+	Timestamp    int64   `json:"timestamp"`
+}
+
+// GetTopServers ranks the visible online servers by their latest CPU, memory
+// or disk usage. Query: metric (cpu, mem, disk; default cpu), n (default 10,
+// max 100). Disk is the fullest non-excluded filesystem, as for health.
+func GetTopServers(c *fiber.Ctx) error {
+	metric := c.Query("metric", "cpu")
+	if metric != "cpu" && metric != "mem" && metric != "disk" {
+		return c.Status(400).JSON(fiber.Map{"error": "metric must be cpu, mem or disk"})
+	}
+	n := c.QueryInt("n", 10)
+	if n < 1 || n > 100 {
+		return c.Status(400).JSON(fiber.Map{"error": "n must be between 1 and 100"})
+	}
+
+	offlineTimeout := 120
+	var val string
+	if err := database.GetSetting("offline_timeout", &val); err == nil {
+		fmt.Sscanf(val, "%d", &offlineTimeout)
+	}
+
+	// The latest sample of each server, through idx_metrics_server_time
+	visible, args := middleware.ServerVisibilityClause(c, "s.id")
+	rows, err := database.DB.Query(`
+		SELECT s.id, s.hostname, COALESCE(s.health_status, 'unknown'), COALESCE(s.disk_mounts, ''),
+			m.timestamp, COALESCE(m.cpu_percent, 0), COALESCE(m.mem_total_mb, 0), COALESCE(m.mem_used_mb, 0),
+			COALESCE(m.disk_total_gb, 0), COALESCE(m.disk_used_gb, 0)
+		FROM servers s
+		JOIN metrics m ON m.id = (SELECT id FROM metrics WHERE server_id = s.id ORDER BY timestamp DESC LIMIT 1)
+		WHERE s.last_seen >= ? AND `+visible,
+		append([]interface{}{time.Now().Unix() - int64(offlineTimeout)}, args...)...)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	fstypes, paths := health.LoadDiskExclusions()
+	top := []TopServer{}
+	for rows.Next() {
+		var t TopServer
+		var mountsJSON string
+		var m models.Metric
+		if err := rows.Scan(&t.ServerID, &t.Hostname, &t.HealthStatus, &mountsJSON, &t.Timestamp, &m.CPUPercent,
+			&m.MemTotalMB, &m.MemUsedMB, &m.DiskTotalGB, &m.DiskUsedGB); err != nil {
+			continue
+		}
+
+		switch metric {
+		case "cpu":
+			t.Percent = m.CPUPercent
+		case "mem":
+			if m.MemTotalMB > 0 {
+				t.Percent = float64(m.MemUsedMB) * 100 / float64(m.MemTotalMB)
+			}
+		case "disk":
+			if m.DiskTotalGB > 0 {
+				t.Percent = float64(m.DiskUsedGB) * 100 / float64(m.DiskTotalGB)
+			}
+			var mounts []models.DiskMount
+			if json.Unmarshal([]byte(mountsJSON), &mounts) == nil {
+				health.MarkExcludedMounts(mounts, fstypes, paths)
+				if fullest, ok := health.FullestDiskMount(mounts); ok {
+					t.Percent, t.Mount = fullest.Percent, fullest.Mount
+				}
+			}
+		}
+		top = append(top, t)
+	}
+
+	sort.SliceStable(top, func(i, j int) bool { return top[i].Percent > top[j].Percent })
+	if len(top) > n {
+		top = top[:n]
+	}
+	return c.JSON(fiber.Map{"metric": metric, "servers": top})
+}
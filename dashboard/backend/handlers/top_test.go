@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGetTopServers(t *testing.T) {
+	testDB(t)
+	now := time.Now().Unix()
+	mustExec(t, `INSERT INTO servers (id, hostname, api_secret_hash, first_seen, last_seen, disk_mounts) VALUES
+		('s1', 'web-1', 'x', 0, ?1, ''), ('s2', 'web-2', 'x', 0, ?1, ?3), ('s3', 'web-3', 'x', 0, ?1, ''), ('s4', 'gone', 'x', 0, ?2, '')`,
+		now, now-3600, `[{"mount":"/","fstype":"ext4","total_mb":100,"used_mb":50,"percent":50},{"mount":"/data","fstype":"xfs","total_mb":100,"used_mb":95,"percent":95},{"mount":"/snap/core","fstype":"squashfs","total_mb":10,"used_mb":10,"percent":100}]`)
+	for _, m := range []struct {
+		server   string
+		ts       int64
+		cpu      float64
+		diskUsed int
+	}{{"s1", now - 120, 99, 10}, {"s1", now, 20, 10}, {"s2", now, 60, 50}, {"s3", now, 80, 70}, {"s4", now - 3600, 100, 100}} {
+		mustExec(t, `INSERT INTO metrics (server_id, timestamp, cpu_percent, mem_total_mb, mem_used_mb, disk_total_gb, disk_used_gb) VALUES (?, ?, ?, 100, 50, 100, ?)`,
+			m.server, m.ts, m.cpu, m.diskUsed)
+	}
+
+	app := fiber.New()
+	app.Get("/servers/top", GetTopServers)
+	top := func(url string) []TopServer {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest("GET", url, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Servers []TopServer `json:"servers"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return body.Servers
+	}
+
+	// Latest sample only, offline servers left out
+	if got := top("/servers/top?metric=cpu&n=2"); len(got) != 2 || got[0].ServerID != "s3" || got[1].ServerID != "s2" {
+		t.Fatalf("cpu = %+v", got)
+	}
+	// The fullest kept filesystem beats the total
+	if got := top("/servers/top?metric=disk"); len(got) != 3 || got[0].ServerID != "s2" || got[0].Mount != "/data" || got[0].Percent != 95 {
+		t.Fatalf("disk = %+v", got)
+	}
+}
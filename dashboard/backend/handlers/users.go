@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// validRoles lists the roles a user can hold
+var validRoles = map[string]bool{
+	"admin":    true, // Everything, including script allow-list and user management
+	"operator": true, // Can run allow-listed scripts
+	"viewer":   true, // Read-only for privileged features
+}
+
+// GetUsers returns all dashboard users
+func GetUsers(c *fiber.Ctx) error {
+	rows, err := database.DB.Query(`
+		SELECT id, username, created_at, COALESCE(password_changed, 0), COALESCE(role, 'admin'), COALESCE(allowed_tags, '')
+		FROM users
+		ORDER BY username
+	`)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Database error"})
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var u models.User
+		var allowedTags string
+		if err := rows.Scan(&u.ID, &u.Username, &u.CreatedAt, &u.PasswordChanged, &u.Role, &allowedTags); err != nil {
+			continue
+		}
+		u.AllowedTags = []string{}
+		if allowedTags != "" {
+			u.AllowedTags = strings.Split(allowedTags, ",")
+		}
+		users = append(users, u)
+	}
+
+	return c.JSON(users)
+}
+
+// CreateUser adds a dashboard user with the given role
+func CreateUser(c *fiber.Ctx) error {
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Role     string `json:"role"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	if req.Username == "" || len(req.Password) < 8 {
+		return c.Status(400).JSON(fiber.Map{"error": "Username and a password of at least 8 characters are required"})
+	}
+	if req.Role == "" {
+		req.Role = "viewer"
+	}
+	if !validRoles[req.Role] {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid role"})
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to hash password"})
+	}
+
+	res, err := database.DB.Exec(
+		"INSERT INTO users (username, password_hash, created_at, password_changed, role) VALUES (?, ?, ?, 0, ?)",
+		req.Username, string(hash), time.Now().Unix(), req.Role,
+	)
+	if err != nil {
+		return c.Status(409).JSON(fiber.Map{"error": "User already exists"})
+	}
+	id, _ := res.LastInsertId()
+
+	recordAudit(c, "user.create", req.Username, fmt.Sprintf("role=%s", req.Role))
+	return c.JSON(fiber.Map{"id": id, "username": req.Username, "role": req.Role})
+}
+
+// UpdateUserRole changes a user's role
+func UpdateUserRole(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := c.BodyParser(&req); err != nil || !validRoles[req.Role] {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid role"})
+	}
+
+	// Prevent admins from locking themselves out
+	if currentID, _ := c.Locals("user_id").(int64); currentID == int64(id) && req.Role != "admin" {
+		return c.Status(400).JSON(fiber.Map{"error": "You cannot remove your own admin role"})
+	}
+
+	var username string
+	if err := database.DB.QueryRow("SELECT username FROM users WHERE id = ?", id).Scan(&username); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "User not found"})
+	}
+
+	if _, err := database.DB.Exec("UPDATE users SET role = ? WHERE id = ?", req.Role, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update role"})
+	}
+
+	recordAudit(c, "user.role", username, fmt.Sprintf("role=%s", req.Role))
+	return c.JSON(fiber.Map{"status": "updated"})
+}
+
+// UpdateUserTags limits which servers a non-admin user can see (by server tag).
+// An empty list gives access to all servers.
+func UpdateUserTags(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid user ID"})
+	}
+
+	var req struct {
+		Tags []string `json:"tags"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request"})
+	}
+	tags, err := normalizeTags(req.Tags)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var username string
+	if err := database.DB.QueryRow("SELECT username FROM users WHERE id = ?", id).Scan(&username); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "User not found"})
+	}
+
+	if _, err := database.DB.Exec("UPDATE users SET allowed_tags = ? WHERE id = ?", strings.Join(tags, ","), id); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "Failed to update tags"})
+	}
+
+	recordAudit(c, "user.tags", username, strings.Join(tags, ","))
+	return c.JSON(fiber.Map{"status": "updated", "allowed_tags": tags})
+}
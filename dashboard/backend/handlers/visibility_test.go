@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+func TestScopedUserCannotReachHiddenServers(t *testing.T) {
+	testDB(t)
+	seedServer(t, "s1", "web-1", "web")
+	seedServer(t, "s2", "db-1", "db")
+	mustExec(t, `INSERT INTO users (id, username, password_hash, created_at, role, allowed_tags) VALUES (7, 'bob', 'x', 0, 'operator', 'web')`)
+	mustExec(t, `INSERT INTO events (id, server_id, timestamp, event_type, severity, message) VALUES
+		(1, 's1', 1000, 'port', 'critical', 'nginx is down'),
+		(2, 's2', 1000, 'port', 'critical', 'postgres is down')`)
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_id", int64(7))
+		c.Locals("role", "operator")
+		return c.Next()
+	})
+	app.All("/servers/:id", middleware.RequireServerVisible)
+	app.All("/servers/:id/*", middleware.RequireServerVisible)
+	app.Get("/servers/:id", GetServer)
+	app.Get("/events", GetAllEvents)
+	app.Get("/events/:id", GetEvent)
+	app.Delete("/events/:id", DeleteEvent)
+	// Without the route middleware: the handler checks on its own
+	app.Delete("/unguarded/servers/:id/events", DeleteServerEvents)
+	status := func(method, path string) int {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(method, path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	for _, req := range []struct{ method, path string }{
+		{"GET", "/servers/s2"},
+		{"GET", "/events/2"},
+		{"DELETE", "/events/2"},
+		{"DELETE", "/unguarded/servers/s2/events"},
+	} {
+		if got := status(req.method, req.path); got != 404 {
+			t.Errorf("%s %s: status %d, want 404", req.method, req.path, got)
+		}
+	}
+	var n int
+	database.DB.QueryRow("SELECT COUNT(*) FROM events WHERE server_id = 's2'").Scan(&n)
+	if n != 1 {
+		t.Fatalf("hidden server's events deleted (%d left)", n)
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/events", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []models.Event
+	json.NewDecoder(resp.Body).Decode(&events)
+	if len(events) != 1 || events[0].ServerID != "s1" {
+		t.Errorf("event feed = %+v, want only s1's event", events)
+	}
+
+	if got := status("GET", "/servers/s1"); got != 200 {
+		t.Errorf("visible server: status %d", got)
+	}
+	if got := status("DELETE", "/events/1"); got != 200 {
+		t.Errorf("visible event: status %d", got)
+	}
+}
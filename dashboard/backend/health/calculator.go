@@ -8,17 +8,19 @@ import (
 	"time"
 
 	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/live"
 	"github.com/yourusername/health-dashboard-backend/models"
 )
 
 // Health status constants
 const (
-	StatusHealthy    = "healthy"
-	StatusWarning    = "warning"
-	StatusCritical   = "critical"
-	StatusOffline    = "offline"
-	StatusUnknown    = "unknown"
-	StatusRecovering = "recovering"
+	StatusHealthy     = "healthy"
+	StatusWarning     = "warning"
+	StatusCritical    = "critical"
+	StatusOffline     = "offline"
+	StatusUnknown     = "unknown"
+	StatusRecovering  = "recovering"
+	StatusMaintenance = "maintenance"
 )
 
 // Default metric interval in seconds (agent reports every 60 seconds by default)
@@ -45,14 +47,14 @@ func CalculateHealth(serverID string) (string, string, error) {
 		return StatusOffline, "Server is offline", nil
 	}
 
-	config := getAgentConfig()
+	config := getAgentConfig(serverID)
 
 	// Evaluate metrics
 	status, reason := evaluateMetrics(metrics.CPUPercent, metrics.MemoryPercent, metrics.DiskPercent, config)
 	return status, reason, nil
 }
 
-func getAgentConfig() models.AgentConfig {
+func getAgentConfig(serverID string) models.AgentConfig {
 	config := models.AgentConfig{
 		HealthEnabled: true,
 		Thresholds: models.ResourceThresholds{
@@ -66,11 +68,12 @@ func getAgentConfig() models.AgentConfig {
 	}
 
 	var val string
-	if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'thresholds'").Scan(&val); err == nil {
+	if err := database.GetSetting("thresholds", &val); err == nil {
 		json.Unmarshal([]byte(val), &config.Thresholds)
 	}
+	config.Thresholds, config.ThresholdProfile = ResolveThresholds(serverID, config.Thresholds)
 	
-	if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'health_enabled'").Scan(&val); err == nil {
+	if err := database.GetSetting("health_enabled", &val); err == nil {
 		if val == "false" || val == "0" {
 			config.HealthEnabled = false
 		}
@@ -130,12 +133,15 @@ func UpdateServerHealth(serverID string) (string, string, string, string, error)
 		return "", "", "", "", err
 	}
 
-	// Stability Window Logic
-	if newStatus == StatusHealthy {
+	// In maintenance the status is held (metrics are still stored)
+	if m, ok := ActiveMaintenance(serverID); ok {
+		newStatus, reason = StatusMaintenance, maintenanceMessage(m)
+	} else if newStatus == StatusHealthy {
+		// Stability Window Logic
 		// Fetch settings
 		stabilityWindow := int64(120) // Default
 		var val string
-		if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'stability_window'").Scan(&val); err == nil {
+		if err := database.GetSetting("stability_window", &val); err == nil {
 			var sVal int64
 			if _, err := fmt.Sscanf(val, "%d", &sVal); err == nil && sVal >= 0 {
 				stabilityWindow = sVal
@@ -188,10 +194,24 @@ func UpdateServerHealth(serverID string) (string, string, string, string, error)
 		log.Printf("Failed to update health status for %s: %v", serverID, err)
 		return "", "", "", "", err
 	}
+	if newStatus != oldStatus {
+		PublishStatus(serverID, newStatus, oldStatus, reason)
+	}
 
 	return newStatus, oldStatus, reason, oldReason, nil
 }
 
+// PublishStatus sends a status transition to the connected dashboards
+func PublishStatus(serverID, status, previous, reason string) {
+	live.Publish(live.Update{Type: live.TypeStatus, ServerID: serverID, Data: map[string]interface{}{
+		"server_id": serverID,
+		"status":    status,
+		"previous":  previous,
+		"reason":    reason,
+		"timestamp": time.Now().Unix(),
+	}})
+}
+
 // GetServerHealth returns the current health status for a server
 func GetServerHealth(serverID string) (string, error) {
 	var status string
@@ -211,6 +231,7 @@ type HealthMetrics struct {
 	CPUPercent      float64 `json:"cpu_percent"`
 	MemoryPercent   float64 `json:"memory_percent"`
 	DiskPercent     float64 `json:"disk_percent"`
+	DiskMount       string  `json:"disk_mount,omitempty"` // Filesystem the disk percent refers to
 	IsOffline       bool    `json:"is_offline"`
 	HasDriftEvent   bool    `json:"has_drift_event"`
 	HealthStatus    string  `json:"health_status"`
@@ -250,12 +271,19 @@ func GetHealthMetricsForServer(serverID string) (*HealthMetrics, error) {
 	if metric.DiskTotalGB > 0 {
 		diskPercent = (float64(metric.DiskUsedGB) / float64(metric.DiskTotalGB)) * 100.0
 	}
+	// Agents that report per-filesystem usage: the fullest mount the current
+	// exclusions keep, as the agent alerts on
+	diskMount := ""
+	if fullest, ok := FullestDiskMount(ServerDiskMounts(serverID)); ok {
+		diskPercent = fullest.Percent
+		diskMount = fullest.Mount
+	}
 
 	// Check if offline
 	now := time.Now().Unix()
 	maxStaleSeconds := int64(DefaultMetricIntervalSeconds * 2) // default
 	var timeoutVal string
-	if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'offline_timeout'").Scan(&timeoutVal); err == nil {
+	if err := database.GetSetting("offline_timeout", &timeoutVal); err == nil {
 		var val int64
 		if _, err := fmt.Sscanf(timeoutVal, "%d", &val); err == nil {
 			maxStaleSeconds = val
@@ -273,6 +301,7 @@ func GetHealthMetricsForServer(serverID string) (*HealthMetrics, error) {
 		CPUPercent:     metric.CPUPercent,
 		MemoryPercent:  memPercent,
 		DiskPercent:    diskPercent,
+		DiskMount:      diskMount,
 		IsOffline:      isOffline,
 		HasDriftEvent:  hasDrift,
 		HealthStatus:   status,
@@ -282,8 +311,8 @@ func GetHealthMetricsForServer(serverID string) (*HealthMetrics, error) {
 
 func hasDriftEvent(serverID string) bool {
 	var count int
-	// Check for unresolved drift events in the last hour?
-	err := database.DB.QueryRow("SELECT COUNT(*) FROM events WHERE server_id = ? AND event_type = 'drift' AND created_at > ?", serverID, time.Now().Add(-1*time.Hour).Unix()).Scan(&count)
+	// Unacknowledged drift events in the last hour
+	err := database.DB.QueryRow("SELECT COUNT(*) FROM events WHERE server_id = ? AND event_type = 'drift' AND COALESCE(acknowledged, 0) = 0 AND timestamp > ?", serverID, time.Now().Add(-1*time.Hour).Unix()).Scan(&count)
 	if err != nil {
 		return false
 	}
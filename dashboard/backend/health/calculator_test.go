@@ -144,6 +144,9 @@ func TestThresholdConstants(t *testing.T) {
 			if tt.value == CPUWarningThreshold-0.1 && isWarning {
 				t.Errorf("Threshold check failed: %v >= %v should be false", tt.value, CPUWarningThreshold)
 			}
+			if tt.value == CPUCriticalThreshold && !isCritical {
+				t.Errorf("Threshold check failed: %v >= %v should be true", tt.value, CPUCriticalThreshold)
+			}
 		})
 	}
 }
@@ -180,6 +183,8 @@ func TestMetricIntervalConstant(t *testing.T) {
 // Test time-based calculations (offline detection)
 func TestOfflineDetectionLogic(t *testing.T) {
 	now := time.Now()
+	// A server is offline once its latest metric is more than two intervals old
+	threshold := time.Duration(DefaultMetricIntervalSeconds*2) * time.Second
 	tests := []struct {
 		name          string
 		timeDiff      time.Duration
@@ -192,17 +197,17 @@ func TestOfflineDetectionLogic(t *testing.T) {
 		},
 		{
 			name:          "Stale metric (offline)",
-			timeDiff:      30 * time.Second,
+			timeDiff:      threshold + 30*time.Second,
 			expectOffline: true,
 		},
 		{
-			name:          "At threshold (offline)",
-			timeDiff:      20 * time.Second,
+			name:          "Just past threshold (offline)",
+			timeDiff:      threshold + time.Second,
 			expectOffline: true,
 		},
 		{
-			name:          "Just before threshold (online)",
-			timeDiff:      19 * time.Second,
+			name:          "At threshold (online)",
+			timeDiff:      threshold,
 			expectOffline: false,
 		},
 	}
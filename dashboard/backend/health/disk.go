@@ -0,0 +1,102 @@
+package health
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// Default disk exclusions (kept in sync with the agent's collector defaults)
+var (
+	DefaultDiskExcludeFSTypes = []string{"squashfs", "overlay", "tmpfs", "devtmpfs", "nsfs", "autofs"}
+	DefaultDiskExcludePaths   = []string{"/snap", "/var/lib/docker", "/var/lib/containers", "/run"}
+)
+
+// LoadDiskExclusions returns the configured filesystem types and mount paths
+// left out of disk metrics, or the defaults when they were never saved
+func LoadDiskExclusions() ([]string, []string) {
+	fstypes := append([]string(nil), DefaultDiskExcludeFSTypes...)
+	paths := append([]string(nil), DefaultDiskExcludePaths...)
+
+	var val string
+	if err := database.GetSetting("disk_exclude_fstypes", &val); err == nil {
+		json.Unmarshal([]byte(val), &fstypes)
+	}
+	if err := database.GetSetting("disk_exclude_paths", &val); err == nil {
+		json.Unmarshal([]byte(val), &paths)
+	}
+	return fstypes, paths
+}
+
+// DiskExcluded reports whether a mount is left out of disk metrics: its fstype is
+// listed, or its mount point is a listed path, lies below one, or matches a glob.
+// This is the agent collector's rule.
+func DiskExcluded(mount, fstype string, fstypes, paths []string) bool {
+	for _, t := range fstypes {
+		if strings.EqualFold(t, fstype) {
+			return true
+		}
+	}
+	for _, p := range paths {
+		if strings.ContainsAny(p, "*?[") {
+			if ok, _ := filepath.Match(p, mount); ok {
+				return true
+			}
+			continue
+		}
+		p = filepath.Clean(p)
+		if mount == p || strings.HasPrefix(mount, strings.TrimSuffix(p, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkExcludedMounts flags the mounts the current exclusions leave out. Agents
+// apply the exclusions from their last config fetch, so this catches up with a
+// change before every agent has picked it up.
+func MarkExcludedMounts(mounts []models.DiskMount, fstypes, paths []string) {
+	for i := range mounts {
+		mounts[i].Excluded = DiskExcluded(mounts[i].Mount, mounts[i].FSType, fstypes, paths)
+	}
+}
+
+// FullestDiskMount returns the non-excluded mount closest to full
+func FullestDiskMount(mounts []models.DiskMount) (models.DiskMount, bool) {
+	var fullest models.DiskMount
+	found := false
+	for _, m := range mounts {
+		if m.Excluded || m.TotalMB <= 0 {
+			continue
+		}
+		if !found || m.Percent > fullest.Percent {
+			fullest, found = m, true
+		}
+	}
+	return fullest, found
+}
+
+// loadDiskMounts reads the per-filesystem usage from the server's last metrics push
+func loadDiskMounts(serverID string) []models.DiskMount {
+	var raw string
+	if err := database.DB.QueryRow("SELECT COALESCE(disk_mounts, '') FROM servers WHERE id = ?", serverID).Scan(&raw); err != nil || raw == "" {
+		return nil
+	}
+	var mounts []models.DiskMount
+	if err := json.Unmarshal([]byte(raw), &mounts); err != nil {
+		return nil
+	}
+	return mounts
+}
+
+// ServerDiskMounts returns the server's reported filesystems with the current
+// exclusions marked
+func ServerDiskMounts(serverID string) []models.DiskMount {
+	mounts := loadDiskMounts(serverID)
+	fstypes, paths := LoadDiskExclusions()
+	MarkExcludedMounts(mounts, fstypes, paths)
+	return mounts
+}
@@ -0,0 +1,57 @@
+package health
+
+import (
+	"testing"
+
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// Test the mount exclusion rule shared with the agent collector
+func TestDiskExcluded(t *testing.T) {
+	fstypes := []string{"squashfs", "overlay"}
+	paths := []string{"/snap", "/var/lib/docker/", "/mnt/backup-*"}
+
+	tests := []struct {
+		mount    string
+		fstype   string
+		expected bool
+	}{
+		{"/", "ext4", false},
+		{"/home", "xfs", false},
+		{"/snap/core/123", "ext4", true},
+		{"/snapshots", "ext4", false},
+		{"/var/lib/docker", "ext4", true},
+		{"/var/lib/docker/overlay2/abc/merged", "ext4", true},
+		{"/data", "SquashFS", true},
+		{"/mnt/backup-1", "nfs", true},
+		{"/mnt/backup-1/sub", "nfs", false},
+	}
+
+	for _, tt := range tests {
+		if got := DiskExcluded(tt.mount, tt.fstype, fstypes, paths); got != tt.expected {
+			t.Errorf("DiskExcluded(%q, %q) = %v, expected %v", tt.mount, tt.fstype, got, tt.expected)
+		}
+	}
+}
+
+// Test that the fullest kept mount drives the disk percent
+func TestFullestDiskMount(t *testing.T) {
+	mounts := []models.DiskMount{
+		{Mount: "/", FSType: "ext4", TotalMB: 50000, UsedMB: 20000, Percent: 40},
+		{Mount: "/var", FSType: "xfs", TotalMB: 100000, UsedMB: 85000, Percent: 85},
+		{Mount: "/snap/core/123", FSType: "squashfs", TotalMB: 60, UsedMB: 60, Percent: 100},
+	}
+	MarkExcludedMounts(mounts, []string{"squashfs"}, nil)
+
+	fullest, ok := FullestDiskMount(mounts)
+	if !ok || fullest.Mount != "/var" {
+		t.Fatalf("Expected /var as the fullest mount, got %q (found: %v)", fullest.Mount, ok)
+	}
+	if !mounts[2].Excluded {
+		t.Error("Expected the squashfs mount to be marked excluded")
+	}
+
+	if _, ok := FullestDiskMount(nil); ok {
+		t.Error("Expected no mount without per-filesystem data")
+	}
+}
@@ -0,0 +1,163 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+	"github.com/yourusername/health-dashboard-backend/schedule"
+)
+
+// ActiveMaintenance returns the server's maintenance period if one is running:
+// maintenance started for the server itself, or a maintenance window covering it
+func ActiveMaintenance(serverID string) (models.Maintenance, bool) {
+	var m models.Maintenance
+	if database.DB == nil || serverID == "" {
+		return m, false
+	}
+	now := time.Now()
+	err := database.DB.QueryRow(`
+		SELECT COALESCE(maintenance_until, 0), COALESCE(maintenance_reason, '')
+		FROM servers WHERE id = ?
+	`, serverID).Scan(&m.Until, &m.Reason)
+	if err != nil {
+		return models.Maintenance{}, false
+	}
+	if m.Until > now.Unix() {
+		return m, true
+	}
+	return activeWindow(serverID, now)
+}
+
+// LoadMaintenanceWindows returns the recurring maintenance windows
+func LoadMaintenanceWindows() []models.MaintenanceWindow {
+	windows := []models.MaintenanceWindow{}
+	var raw string
+	if err := database.GetSetting("maintenance_windows", &raw); err == nil {
+		json.Unmarshal([]byte(raw), &windows)
+	}
+	return windows
+}
+
+// activeWindow finds a maintenance window covering the server at now
+func activeWindow(serverID string, now time.Time) (models.Maintenance, bool) {
+	var serverLoc *time.Location
+	var tags map[string]bool
+	for _, w := range LoadMaintenanceWindows() {
+		cron, err := schedule.ParseCron(w.Schedule)
+		if err != nil {
+			continue
+		}
+		loc, err := schedule.LoadLocation(w.Timezone)
+		if w.Timezone == "" || err != nil {
+			if serverLoc == nil {
+				serverLoc = schedule.ServerLocation(serverID)
+			}
+			loc = serverLoc
+		}
+		dur := time.Duration(w.DurationMinutes) * time.Minute
+		start, ok := cron.Covering(now, dur, loc)
+		if !ok {
+			continue
+		}
+		if tags == nil {
+			tags = serverTags(serverID)
+		}
+		if windowCovers(w, serverID, tags) {
+			return models.Maintenance{Until: start.Add(dur).Unix(), Reason: "maintenance window " + w.Name}, true
+		}
+	}
+	return models.Maintenance{}, false
+}
+
+// windowCovers reports whether the server is in the window's scope
+func windowCovers(w models.MaintenanceWindow, serverID string, tags map[string]bool) bool {
+	if len(w.Servers) == 0 && len(w.Tags) == 0 {
+		return true
+	}
+	for _, id := range w.Servers {
+		if id == serverID {
+			return true
+		}
+	}
+	for _, tag := range w.Tags {
+		if tags[tag] {
+			return true
+		}
+	}
+	return false
+}
+
+// serverTags returns the tags of a server as a set
+func serverTags(serverID string) map[string]bool {
+	tags := make(map[string]bool)
+	rows, err := database.DB.Query("SELECT tag FROM server_tags WHERE server_id = ?", serverID)
+	if err != nil {
+		return tags
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tag string
+		if rows.Scan(&tag) == nil {
+			tags[tag] = true
+		}
+	}
+	return tags
+}
+
+// InMaintenance reports whether alerts about the server are held back
+func InMaintenance(serverID string) bool {
+	_, ok := ActiveMaintenance(serverID)
+	return ok
+}
+
+// maintenanceMessage is the health message shown while the server is in maintenance
+func maintenanceMessage(m models.Maintenance) string {
+	msg := "In maintenance until " + time.Unix(m.Until, 0).UTC().Format("2006-01-02 15:04 UTC")
+	if m.Reason != "" {
+		msg += ": " + m.Reason
+	}
+	return msg
+}
+
+// StartMaintenance puts a server into maintenance until the given time. Its
+// status shows "maintenance" and no transitions happen until it ends.
+func StartMaintenance(serverID string, m models.Maintenance) error {
+	var oldStatus string
+	if err := database.DB.QueryRow("SELECT COALESCE(health_status, 'unknown') FROM servers WHERE id = ?", serverID).Scan(&oldStatus); err != nil {
+		return err
+	}
+	_, err := database.DB.Exec(`
+		UPDATE servers SET maintenance_until = ?, maintenance_reason = NULLIF(?, ''),
+			health_status = ?, health_message = ?, last_status_change = ?
+		WHERE id = ?
+	`, m.Until, m.Reason, StatusMaintenance, maintenanceMessage(m), time.Now().Unix(), serverID)
+	if err != nil {
+		return fmt.Errorf("failed to start maintenance: %w", err)
+	}
+	if oldStatus != StatusMaintenance {
+		PublishStatus(serverID, StatusMaintenance, oldStatus, maintenanceMessage(m))
+	}
+	return nil
+}
+
+// EndMaintenance ends a server's maintenance early. Its status is unknown
+// until the next metrics push (or the watchdog, if it stays silent).
+func EndMaintenance(serverID string) error {
+	res, err := database.DB.Exec(`
+		UPDATE servers SET maintenance_until = NULL, maintenance_reason = NULL,
+			health_status = ?, health_message = 'Maintenance ended', last_status_change = ?
+		WHERE id = ? AND health_status = ?
+	`, StatusUnknown, time.Now().Unix(), serverID, StatusMaintenance)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		PublishStatus(serverID, StatusUnknown, StatusMaintenance, "Maintenance ended")
+	} else {
+		database.DB.Exec("UPDATE servers SET maintenance_until = NULL, maintenance_reason = NULL WHERE id = ?", serverID)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package health
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+func TestMaintenanceHoldsStatus(t *testing.T) {
+	if err := database.Init(filepath.Join(t.TempDir(), "health.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	now := time.Now().Unix()
+	database.DB.Exec(`INSERT INTO servers (id, hostname, api_secret_hash, first_seen, last_seen, health_status) VALUES ('s1', 'db-1', 'x', ?, ?, 'healthy')`, now, now)
+	database.DB.Exec(`INSERT INTO metrics (server_id, timestamp, cpu_percent, mem_total_mb, mem_used_mb, disk_total_gb, disk_used_gb) VALUES ('s1', ?, 99, 1000, 100, 100, 10)`, now)
+
+	if err := StartMaintenance("s1", models.Maintenance{Until: now + 3600, Reason: "kernel upgrade"}); err != nil {
+		t.Fatal(err)
+	}
+	if !InMaintenance("s1") {
+		t.Fatal("server not in maintenance")
+	}
+	newStatus, oldStatus, reason, _, err := UpdateServerHealth("s1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newStatus != StatusMaintenance || oldStatus != StatusMaintenance {
+		t.Errorf("critical metrics during maintenance: %s -> %s (%s)", oldStatus, newStatus, reason)
+	}
+
+	// Once it ends, the next evaluation sees the real state
+	if err := EndMaintenance("s1"); err != nil {
+		t.Fatal(err)
+	}
+	if newStatus, _, _, _, _ := UpdateServerHealth("s1"); newStatus != StatusCritical {
+		t.Errorf("status after maintenance = %s, want critical", newStatus)
+	}
+
+	// An expired period no longer counts
+	database.DB.Exec("UPDATE servers SET maintenance_until = ? WHERE id = 's1'", now-1)
+	if InMaintenance("s1") {
+		t.Error("expired maintenance still active")
+	}
+}
+
+func TestMaintenanceWindowScope(t *testing.T) {
+	if err := database.Init(filepath.Join(t.TempDir(), "health.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	database.DB.Exec(`INSERT INTO servers (id, hostname, api_secret_hash, first_seen, last_seen) VALUES ('s1', 'db-1', 'x', 0, 0), ('s2', 'web-1', 'x', 0, 0)`)
+	database.DB.Exec(`INSERT INTO server_tags (server_id, tag) VALUES ('s1', 'db')`)
+
+	// A window that started this minute (every minute, for 10 minutes) on db servers
+	windows, _ := json.Marshal([]models.MaintenanceWindow{
+		{Name: "patching", Schedule: "* * * * *", DurationMinutes: 10, Timezone: "UTC", Tags: []string{"db"}},
+		{Name: "never", Schedule: "0 0 30 2 *", DurationMinutes: 60},
+	})
+	database.DB.Exec(`INSERT INTO settings (key, value, updated_at) VALUES ('maintenance_windows', ?, 0)`, string(windows))
+	database.InvalidateSettings()
+
+	m, ok := ActiveMaintenance("s1")
+	if !ok || m.Reason != "maintenance window patching" || m.Until <= time.Now().Unix() {
+		t.Errorf("s1: %+v, %v; expected the patching window", m, ok)
+	}
+	if InMaintenance("s2") {
+		t.Error("s2 is outside the window's scope")
+	}
+}
@@ -0,0 +1,71 @@
+package health
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// ServerGroupConfig returns the group a server belongs to and its overrides
+// (ok is false when the server has no group)
+func ServerGroupConfig(serverID string) (name string, cfg models.ConfigOverrides, ok bool) {
+	var raw string
+	err := database.DB.QueryRow(`
+		SELECT g.name, g.config
+		FROM server_group_members m
+		JOIN server_groups g ON g.id = m.group_id
+		WHERE m.server_id = ?
+	`, serverID).Scan(&name, &raw)
+	if err != nil {
+		return "", cfg, false
+	}
+	json.Unmarshal([]byte(raw), &cfg)
+	return name, cfg, true
+}
+
+// ResolveThresholds returns the thresholds that apply to a server and the name of
+// the profile they came from. Thresholds set on the server itself win, then a
+// profile assigned to the server, then one assigned to its tags (first tag
+// alphabetically); otherwise the thresholds of the server's group apply, then global.
+func ResolveThresholds(serverID string, global models.ResourceThresholds) (models.ResourceThresholds, string) {
+	if _, group, ok := ServerGroupConfig(serverID); ok && group.Thresholds != nil {
+		global = *group.Thresholds
+	}
+
+	var name, raw string
+	err := sql.ErrNoRows
+
+	var cfgJSON sql.NullString
+	if database.DB.QueryRow("SELECT configuration FROM servers WHERE id = ?", serverID).Scan(&cfgJSON) == nil && cfgJSON.Valid {
+		var cfg models.ServerConfiguration
+		if json.Unmarshal([]byte(cfgJSON.String), &cfg) == nil && cfg.Overrides != nil && cfg.Overrides.Thresholds != nil {
+			return *cfg.Overrides.Thresholds, ""
+		}
+		if cfg.ThresholdProfile > 0 {
+			err = database.DB.QueryRow("SELECT name, thresholds FROM threshold_profiles WHERE id = ?", cfg.ThresholdProfile).Scan(&name, &raw)
+		}
+	}
+
+	if err != nil {
+		err = database.DB.QueryRow(`
+			SELECT p.name, p.thresholds
+			FROM server_tags st
+			JOIN threshold_profile_tags pt ON pt.tag = st.tag
+			JOIN threshold_profiles p ON p.id = pt.profile_id
+			WHERE st.server_id = ?
+			ORDER BY st.tag
+			LIMIT 1
+		`, serverID).Scan(&name, &raw)
+	}
+	if err != nil {
+		return global, ""
+	}
+
+	thresholds := global
+	if json.Unmarshal([]byte(raw), &thresholds) != nil {
+		return global, ""
+	}
+	return thresholds, name
+}
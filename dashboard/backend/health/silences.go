@@ -0,0 +1,27 @@
+package health
+
+import (
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+// Silenced reports whether an active silence matches an alert about the
+// server, returning the silence's ID. A silence matches when each of its set
+// matchers does; alerts without an event type only match silences without one.
+func Silenced(serverID, eventType, severity string) (int64, bool) {
+	if database.DB == nil || serverID == "" {
+		return 0, false
+	}
+	var id int64
+	err := database.DB.QueryRow(`
+		SELECT id FROM silences
+		WHERE expires_at > ?
+		  AND (server_id IS NULL OR server_id = ?)
+		  AND (event_type IS NULL OR event_type = ?)
+		  AND (severity IS NULL OR severity = ?)
+		  AND (tag IS NULL OR tag IN (SELECT tag FROM server_tags WHERE server_id = ?))
+		ORDER BY id LIMIT 1
+	`, time.Now().Unix(), serverID, eventType, severity, serverID).Scan(&id)
+	return id, err == nil
+}
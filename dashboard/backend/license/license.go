@@ -52,11 +52,7 @@ func LoadLicense(licensePath string) error {
 
 	// 3. Verify Signature
 	// We look for public.key in the same directory as license.yaml or /app/public.key
-	publicKeyPath := "/app/public.key"
-	if _, err := os.Stat(publicKeyPath); os.IsNotExist(err) {
-		// Try local directory (for dev/testing)
-		publicKeyPath = "public.key"
-	}
+	publicKeyPath := PublicKeyPath()
 
 	// Only verify if we have a public key. 
 	// If we don't have a public key, we can't verify, so we should fail or warn.
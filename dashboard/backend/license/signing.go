@@ -0,0 +1,103 @@
+package license
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// LoadPublicKey reads the PEM encoded Ed25519 public key used to verify licenses
+// and agent binaries
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public key from %s: %v", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("failed to decode PEM block containing public key")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %v", err)
+	}
+
+	ed25519Pub, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is not an Ed25519 key")
+	}
+	return ed25519Pub, nil
+}
+
+// LoadPrivateKey reads the PEM encoded (PKCS#8) Ed25519 private key that signs
+// licenses and agent binaries
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key from %s: %v", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PRIVATE KEY" {
+		return nil, fmt.Errorf("failed to decode PEM block containing private key")
+	}
+
+	priv, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+
+	ed25519Priv, ok := priv.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not an Ed25519 key")
+	}
+	return ed25519Priv, nil
+}
+
+// SignFile returns the detached, base64 encoded Ed25519 signature of a file's content
+func SignFile(priv ed25519.PrivateKey, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data)), nil
+}
+
+// VerifyFile checks a detached, base64 encoded signature made by SignFile
+func VerifyFile(pub ed25519.PublicKey, path, signature string) error {
+	sigBytes, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("failed to decode signature: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, data, sigBytes) {
+		return fmt.Errorf("invalid signature for %s", path)
+	}
+	return nil
+}
+
+// PublicKeyPath returns the public key shipped with the image, or public.key
+// in the working directory (dev/testing)
+func PublicKeyPath() string {
+	if _, err := os.Stat("/app/public.key"); err == nil {
+		return "/app/public.key"
+	}
+	return "public.key"
+}
+
+// PrivateKeyPath returns the signing key mounted in developer setups
+// (SIGNING_KEY_PATH, default /app/private.key). Customer images do not have one.
+func PrivateKeyPath() string {
+	if path := os.Getenv("SIGNING_KEY_PATH"); path != "" {
+		return path
+	}
+	return "/app/private.key"
+}
@@ -0,0 +1,52 @@
+package license
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignAndVerifyFile(t *testing.T) {
+	dir := t.TempDir()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Round-trip the keys through PEM files as the image ships them
+	pubDER, _ := x509.MarshalPKIXPublicKey(pub)
+	privDER, _ := x509.MarshalPKCS8PrivateKey(priv)
+	pubPath := filepath.Join(dir, "public.key")
+	privPath := filepath.Join(dir, "private.key")
+	os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0644)
+	os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600)
+
+	loadedPub, err := LoadPublicKey(pubPath)
+	if err != nil {
+		t.Fatalf("LoadPublicKey: %v", err)
+	}
+	loadedPriv, err := LoadPrivateKey(privPath)
+	if err != nil {
+		t.Fatalf("LoadPrivateKey: %v", err)
+	}
+
+	binary := filepath.Join(dir, "nodeguarder-agent-linux-amd64")
+	os.WriteFile(binary, []byte("agent binary"), 0755)
+
+	signature, err := SignFile(loadedPriv, binary)
+	if err != nil {
+		t.Fatalf("SignFile: %v", err)
+	}
+	if err := VerifyFile(loadedPub, binary, signature); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+
+	os.WriteFile(binary, []byte("tampered binary"), 0755)
+	if err := VerifyFile(loadedPub, binary, signature); err == nil {
+		t.Fatal("signature accepted for a modified binary")
+	}
+}
@@ -2,11 +2,8 @@ package license
 
 import (
 	"crypto/ed25519"
-	"crypto/x509"
 	"encoding/base64"
-	"encoding/pem"
 	"fmt"
-	"os"
 
 	"github.com/yourusername/health-dashboard-backend/models"
 )
@@ -15,24 +12,9 @@ import (
 // The canonical string format must match the one in license_tool/main.go
 func VerifyLicenseSignature(license models.License, publicKeyPath string) error {
 	// 1. Load Public Key
-	pubKeyData, err := os.ReadFile(publicKeyPath)
+	ed25519Pub, err := LoadPublicKey(publicKeyPath)
 	if err != nil {
-		return fmt.Errorf("failed to read public key from %s: %v", publicKeyPath, err)
-	}
-
-	block, _ := pem.Decode(pubKeyData)
-	if block == nil || block.Type != "PUBLIC KEY" {
-		return fmt.Errorf("failed to decode PEM block containing public key")
-	}
-
-	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
-	if err != nil {
-		return fmt.Errorf("failed to parse public key: %v", err)
-	}
-
-	ed25519Pub, ok := pub.(ed25519.PublicKey)
-	if !ok {
-		return fmt.Errorf("public key is not an Ed25519 key")
+		return err
 	}
 
 	// 2. Decode Signature
@@ -0,0 +1,67 @@
+// Package live fans server status changes, new events and metric ticks out to
+// the dashboards connected to the update stream, so they do not have to poll.
+package live
+
+import "sync"
+
+// Update types, sent as the SSE event name
+const (
+	TypeStatus = "status"
+	TypeEvent  = "event"
+	TypeMetric = "metric"
+)
+
+// Update is one change of a server
+type Update struct {
+	Type     string
+	ServerID string
+	Data     interface{} // sent as JSON
+}
+
+// subscriberBuffer is how many updates a slow dashboard may fall behind
+const subscriberBuffer = 256
+
+var (
+	mu          sync.Mutex
+	subscribers = make(map[chan Update]struct{})
+)
+
+// Subscribe returns a channel receiving every update from now on and a
+// function to unsubscribe. The channel is closed when the subscriber falls
+// too far behind; it should reconnect and reload.
+func Subscribe() (<-chan Update, func()) {
+	ch := make(chan Update, subscriberBuffer)
+	mu.Lock()
+	subscribers[ch] = struct{}{}
+	mu.Unlock()
+
+	return ch, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := subscribers[ch]; ok {
+			delete(subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribers returns the number of connected subscribers
+func Subscribers() int {
+	mu.Lock()
+	defer mu.Unlock()
+	return len(subscribers)
+}
+
+// Publish sends an update to all subscribers without blocking
+func Publish(u Update) {
+	mu.Lock()
+	defer mu.Unlock()
+	for ch := range subscribers {
+		select {
+		case ch <- u:
+		default:
+			delete(subscribers, ch)
+			close(ch)
+		}
+	}
+}
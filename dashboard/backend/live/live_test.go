@@ -0,0 +1,41 @@
+package live
+
+import "testing"
+
+func TestPublishReachesSubscribers(t *testing.T) {
+	a, cancelA := Subscribe()
+	b, cancelB := Subscribe()
+	defer cancelB()
+
+	Publish(Update{Type: TypeStatus, ServerID: "srv-1", Data: "critical"})
+	for _, ch := range []<-chan Update{a, b} {
+		if u := <-ch; u.Type != TypeStatus || u.ServerID != "srv-1" {
+			t.Fatalf("got %+v", u)
+		}
+	}
+
+	cancelA()
+	cancelA() // unsubscribing twice is harmless
+	if _, open := <-a; open {
+		t.Error("channel still open after unsubscribe")
+	}
+	if n := Subscribers(); n != 1 {
+		t.Errorf("%d subscribers, want 1", n)
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	ch, cancel := Subscribe()
+	defer cancel()
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		Publish(Update{Type: TypeMetric, ServerID: "srv-1"})
+	}
+	received := 0
+	for range ch {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("received %d updates before the close, want %d", received, subscriberBuffer)
+	}
+}
@@ -1,19 +1,25 @@
 package main
 
 import (
+	"crypto/tls"
 	"log"
 	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/yourusername/health-dashboard-backend/agentca"
+	"github.com/yourusername/health-dashboard-backend/agentrpc"
 	"github.com/yourusername/health-dashboard-backend/database"
 	"github.com/yourusername/health-dashboard-backend/handlers"
 	"github.com/yourusername/health-dashboard-backend/license"
 
 	"github.com/yourusername/health-dashboard-backend/maintenance"
 	"github.com/yourusername/health-dashboard-backend/middleware"
+	"github.com/yourusername/health-dashboard-backend/secrets"
+	"github.com/yourusername/health-dashboard-backend/web"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
@@ -65,21 +71,32 @@ func main() {
 
 	// Initialize Notifications
 	handlers.InitNotifications()
+
+	// Key for ticket integration credentials and agent signing keys (kept next to the database)
+	if err := secrets.InitKey(filepath.Dir(dbPath)); err != nil {
+		log.Printf("⚠️  Ticket integrations and request signing unavailable: %v", err)
+	}
+
+	// CA issuing agent client certificates for mutual TLS (kept next to the database)
+	if err := agentca.Init(filepath.Dir(dbPath)); err != nil {
+		log.Printf("⚠️  Agent mTLS unavailable: %v", err)
+	}
 	
 	// Sync JWT Secret to Middleware
 	middleware.SetJWTSecret(handlers.GetJWTSecret())
 
-	// Clean up orphaned events/metrics on startup
+	// Clean up data orphaned by deleted servers on startup
 	go func() {
-		log.Println("🧹 Running startup cleanup for orphaned events...")
-		res, err := database.DB.Exec("DELETE FROM events WHERE server_id NOT IN (SELECT id FROM servers)")
-		if err == nil {
-			rows, _ := res.RowsAffected()
+		log.Println("🧹 Running startup cleanup for orphaned data...")
+		deleted, err := handlers.CleanupOrphanedData()
+		if err != nil {
+			log.Printf("❌ Startup cleanup: %v", err)
+		}
+		for table, rows := range deleted {
 			if rows > 0 {
-				log.Printf("✅ Removed %d orphaned events", rows)
+				log.Printf("✅ Removed %d orphaned rows from %s", rows, table)
 			}
 		}
-		database.DB.Exec("DELETE FROM metrics WHERE server_id NOT IN (SELECT id FROM servers)")
 	}()
 
 
@@ -90,7 +107,13 @@ func main() {
 
 	// Start maintenance background worker
 	maintenance.StartJanitor()
+	maintenance.StartMetricsRollup()
 	maintenance.StartHealthWatcher()
+	maintenance.StartNotificationWatcher()
+	maintenance.StartHeartbeatWatcher()
+	handlers.StartTerminalReaper()
+	stopEventTail := handlers.StartEventTail()
+	defer stopEventTail()
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
@@ -106,9 +129,10 @@ func main() {
 	// Middleware
 	app.Use(logger.New())
 	app.Use(cors.New(cors.Config{
-		AllowOrigins: "*",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-Dashboard-URL",
-		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
+		AllowOrigins:  "*",
+		AllowHeaders:  "Origin, Content-Type, Accept, Authorization, X-Dashboard-URL",
+		AllowMethods:  "GET, POST, PUT, DELETE, OPTIONS",
+		ExposeHeaders: "X-Total-Count, X-Page, X-Limit, X-Metrics-Resolution",
 	}))
 
 	// Health check
@@ -121,86 +145,249 @@ func main() {
 	
 
 
-	// Agent endpoints (public, authenticated via API secret)
+	// Agent endpoints (public, authenticated via client certificate or API secret)
+	app.Use("/api/v1/agent", handlers.AgentClientCert, handlers.AgentSignature)
 	app.Post("/api/v1/agent/register", handlers.AgentRegister)
+	app.Post("/api/v1/agent/certificate", handlers.AgentRequestCertificate)
+
+	// Heartbeat pings (public, authenticated by the token in the URL)
+	app.Get("/api/v1/heartbeats/ping/:token", handlers.PingHeartbeat)
+	app.Post("/api/v1/heartbeats/ping/:token", handlers.PingHeartbeat)
 	app.Post("/api/v1/agent/metrics", handlers.AgentPushMetrics)
 	app.Post("/api/v1/agent/events", handlers.AgentPushEvents)
 	app.Post("/api/v1/agent/package/:format", handlers.GenerateAgentPackage)
 	app.Get("/api/v1/agent/package/:format", handlers.GenerateAgentPackage)
 	app.Get("/api/v1/agent/download/:os/:arch", handlers.DownloadAgent)
+	app.Get("/api/v1/agent/download/:os/:arch/signature", handlers.DownloadAgentSignature)
 	app.Get("/api/v1/agent/version", handlers.GetAgentVersion)
+	app.Get("/api/v1/agent/manifest", handlers.GetAgentManifest)
 	app.Get("/api/v1/agent/config", handlers.AgentGetConfig)
+	app.Get("/api/v1/agent/stream", handlers.AgentStream)
     app.Post("/api/v1/agent/logs", handlers.AgentUploadLogs)
+	app.Post("/api/v1/agent/scripts/result", handlers.AgentScriptResult)
+	app.Post("/api/v1/agent/cron/pause", handlers.AgentCronPauseResult)
+	app.Post("/api/v1/agent/remediation/result", handlers.AgentRemediationResult)
+	app.Post("/api/v1/agent/packages", handlers.AgentPushPackages)
+	app.Post("/api/v1/agent/terminal/:sid/input", handlers.AgentTerminalInput)
+	app.Post("/api/v1/agent/terminal/:sid/output", handlers.AgentTerminalOutput)
 
 	// License endpoints (public for status, protected for upload)
 	app.Get("/api/v1/license/status", handlers.GetLicenseStatus)
 
 	// Protected admin endpoints
-	api := app.Group("/api/v1", middleware.AuthRequired)
-	
-	// Servers
+	registerAPIRoutes(app.Group("/api/v1", middleware.AuthRequired))
+
+	// Start server
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	// Serve the frontend (./frontend on disk overrides the embedded build)
+	web.Register(app, "./frontend")
+
+	// Agent gRPC transport (optional, alongside the REST endpoints)
+	if grpcPort := os.Getenv("GRPC_PORT"); grpcPort != "" {
+		if err := agentrpc.ListenAndServe(":"+grpcPort, app, os.Getenv("GRPC_TLS_CERT"), os.Getenv("GRPC_TLS_KEY")); err != nil {
+			log.Fatalf("Failed to start gRPC server: %v", err)
+		}
+		log.Printf("🚀 Agent gRPC transport on port %s", grpcPort)
+	}
+
+	// HTTPS listener requesting agent client certificates (optional, for
+	// deployments without a TLS-terminating proxy)
+	if tlsPort := os.Getenv("TLS_PORT"); tlsPort != "" {
+		cert, err := tls.LoadX509KeyPair(os.Getenv("TLS_CERT"), os.Getenv("TLS_KEY"))
+		if err != nil {
+			log.Fatalf("Failed to load TLS certificate: %v", err)
+		}
+		ln, err := tls.Listen("tcp", ":"+tlsPort, &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientAuth:   tls.VerifyClientCertIfGiven,
+			ClientCAs:    agentca.Pool(),
+			MinVersion:   tls.VersionTLS12,
+		})
+		if err != nil {
+			log.Fatalf("Failed to start TLS listener: %v", err)
+		}
+		go func() {
+			if err := app.Listener(ln); err != nil {
+				log.Printf("⚠️  TLS listener stopped: %v", err)
+			}
+		}()
+		log.Printf("🔒 HTTPS (agent mTLS) on port %s", tlsPort)
+	}
+
+	log.Printf("🚀 Server starting on port %s", port)
+	if err := app.Listen(":" + port); err != nil {
+		log.Fatalf("Failed to start server: %v", err)
+	}
+}
+
+// registerAPIRoutes sets up the dashboard API on a group requiring a logged-in user.
+// Routes that change anything are limited by role, so viewers stay read-only.
+func registerAPIRoutes(api fiber.Router) {
+	// Servers (per-server routes are limited to the user's visible servers)
+	api.Get("/servers/top", handlers.GetTopServers) // before /servers/:id takes "top" as an ID
+	api.All("/servers/:id", middleware.RequireServerVisible)
+	api.All("/servers/:id/*", middleware.RequireServerVisible)
 	api.Get("/servers", handlers.GetServers)
 	api.Get("/servers/:id", handlers.GetServer)
-	api.Delete("/servers/:id", handlers.DeleteServer)
+	api.Delete("/servers/:id", middleware.RequireRole("admin"), handlers.DeleteServer)
 	api.Get("/servers/:id/metrics", handlers.GetServerMetrics)
-	api.Delete("/servers/:id/events", handlers.DeleteServerEvents)
+	api.Get("/servers/:id/metrics/query", handlers.QueryServerMetrics)
+	api.Delete("/servers/:id/events", middleware.RequireRole("admin"), handlers.DeleteServerEvents)
 	api.Get("/servers/:id/events", handlers.GetServerEvents)
 	api.Get("/servers/:id/health", handlers.GetServerHealth)
-    api.Post("/servers/:id/logs/request", handlers.RequestLogs)
+	api.Get("/servers/:id/compare", handlers.CompareServerPeriods)
+	api.Post("/servers/:id/drift/accept", middleware.RequireRole("admin", "operator"), handlers.AcceptDrift)
+	api.Get("/servers/:id/packages", handlers.GetServerPackages)
+	api.Get("/servers/:id/config", handlers.GetServerConfig)
+	api.Put("/servers/:id/config", middleware.RequireRole("admin", "operator"), handlers.SetServerConfig)
+	api.Get("/servers/:id/config/drift", handlers.GetServerDriftConfig)
+	api.Put("/servers/:id/config/drift", middleware.RequireRole("admin", "operator"), handlers.SetServerDriftConfig)
+	api.Delete("/servers/:id/config/drift", middleware.RequireRole("admin", "operator"), handlers.DeleteServerDriftConfig)
+	api.Get("/servers/:id/config/interval", handlers.GetServerInterval)
+	api.Put("/servers/:id/config/interval", middleware.RequireRole("admin", "operator"), handlers.SetServerInterval)
+	api.Get("/servers/:id/config/thresholds", handlers.GetServerThresholds)
+	api.Put("/servers/:id/config/thresholds", middleware.RequireRole("admin", "operator"), handlers.SetServerThresholdProfile)
+	api.Put("/servers/:id/group", middleware.RequireRole("admin", "operator"), handlers.SetServerGroup)
+	api.Post("/servers/:id/maintenance", middleware.RequireRole("admin", "operator"), handlers.StartServerMaintenance)
+	api.Delete("/servers/:id/maintenance", middleware.RequireRole("admin", "operator"), handlers.EndServerMaintenance)
+	api.Get("/servers/:id/tags", handlers.GetServerTags)
+	api.Put("/servers/:id/tags", middleware.RequireRole("admin"), handlers.SetServerTags)
+	api.Post("/servers/:id/tags", middleware.RequireRole("admin"), handlers.AddServerTags)
+	api.Delete("/servers/:id/tags/:tag", middleware.RequireRole("admin"), handlers.RemoveServerTag)
+	api.Get("/tags", handlers.GetTags)
+	api.Delete("/tags/:tag", middleware.RequireRole("admin"), handlers.DeleteTag)
+	api.Get("/packages", handlers.SearchPackages)
+    api.Post("/servers/:id/logs/request", middleware.RequireRole("admin", "operator"), handlers.RequestLogs)
     api.Get("/servers/:id/logs/download", handlers.DownloadLogs)
-    api.Post("/servers/:id/uninstall", handlers.UninstallAgent)
+    api.Post("/servers/:id/uninstall", middleware.RequireRole("admin"), handlers.UninstallAgent)
 
 	// Events
+	api.Get("/summary", handlers.GetFleetSummary)
+	api.Get("/stream", handlers.LiveStream)
 	api.Get("/events", handlers.GetAllEvents)
-    api.Delete("/events/:id", handlers.DeleteEvent)
+	api.Get("/events/search", handlers.SearchEvents)
+	api.Get("/events/:id/diff", handlers.GetEventDiff)
+	api.Post("/events/:id/replay", middleware.RequireRole("admin"), handlers.ReplayEvent)
+	api.Post("/events/:id/ack", middleware.RequireRole("admin", "operator"), handlers.AckEvent)
+	api.Get("/events/:id", handlers.GetEvent)
+	api.Post("/events/:id/comments", middleware.RequireRole("admin"), handlers.AddEventComment)
+    api.Delete("/events/:id", middleware.RequireRole("admin"), handlers.DeleteEvent)
+
+	// Reports
+	api.Get("/reports/capacity", handlers.GetCapacityReport)
+	api.Get("/reports/drift", handlers.GetDriftReport)
 
 	// Settings (admin only)
 	api.Post("/auth/password", middleware.AuthRequired, handlers.ChangePassword)
-	api.Get("/auth/registration-token", middleware.AuthRequired, handlers.GetRegistrationToken)
+	api.Get("/auth/registration-token", middleware.RequireRole("admin"), handlers.GetRegistrationToken)
     
 	// Alert Settings
-	api.Get("/settings/alerts", handlers.GetAlertSettings)
-	api.Get("/admin/logs", handlers.DownloadBackendLogs)
-	api.Post("/settings/alerts", handlers.SaveAlertSettings)
-	api.Post("/settings/alerts/test", handlers.TestAlert)
+	api.Get("/settings/alerts", middleware.RequireRole("admin"), handlers.GetAlertSettings) // webhook URLs, SMTP password
+	api.Get("/admin/logs", middleware.RequireRole("admin"), handlers.DownloadBackendLogs)
+	api.Post("/admin/scrub", middleware.RequireRole("admin"), handlers.ScrubData)
+	api.Post("/settings/alerts", middleware.RequireRole("admin"), handlers.SaveAlertSettings)
+	api.Post("/settings/alerts/test", middleware.RequireRole("admin"), handlers.TestAlert)
+	api.Get("/settings/maintenance-windows", handlers.GetMaintenanceWindows)
+	api.Post("/settings/maintenance-windows", middleware.RequireRole("admin"), handlers.SaveMaintenanceWindows)
+	api.Get("/silences", handlers.GetSilences)
+	api.Post("/silences", middleware.RequireRole("admin", "operator"), handlers.CreateSilence)
+	api.Delete("/silences/:id", middleware.RequireRole("admin", "operator"), handlers.DeleteSilence)
+	api.Get("/settings/retention", handlers.GetRetentionSettings)
+	api.Post("/settings/retention", middleware.RequireRole("admin"), handlers.SaveRetentionSettings)
+	api.Get("/settings/archives", middleware.RequireRole("admin"), handlers.GetMetricsArchives)
+
+	// Ticket Integrations (Jira, GitLab, GitHub Issues)
+	api.Get("/integrations/tickets", middleware.RequireRole("admin"), handlers.GetTicketIntegrations)
+	api.Post("/integrations/tickets", middleware.RequireRole("admin"), handlers.CreateTicketIntegration)
+	api.Put("/integrations/tickets/:id", middleware.RequireRole("admin"), handlers.UpdateTicketIntegration)
+	api.Delete("/integrations/tickets/:id", middleware.RequireRole("admin"), handlers.DeleteTicketIntegration)
+	api.Post("/integrations/tickets/:id/test", middleware.RequireRole("admin"), handlers.TestTicketIntegration)
+	api.Get("/tickets", handlers.GetTickets)
 
 	// Global Configuration
 	api.Get("/config", handlers.GetConfig)
-	api.Post("/config", handlers.SaveConfig)
+	api.Post("/config", middleware.RequireRole("admin"), handlers.SaveConfig)
+
+	// Threshold Profiles (managed by admins, assignable per server or tag)
+	api.Get("/threshold-profiles", handlers.GetThresholdProfiles)
+	api.Post("/threshold-profiles", middleware.RequireRole("admin"), handlers.CreateThresholdProfile)
+	api.Put("/threshold-profiles/:id", middleware.RequireRole("admin"), handlers.UpdateThresholdProfile)
+	api.Delete("/threshold-profiles/:id", middleware.RequireRole("admin"), handlers.DeleteThresholdProfile)
+
+	// Server groups with configuration overrides (global -> group -> server)
+	api.Get("/server-groups", handlers.GetServerGroups)
+	api.Post("/server-groups", middleware.RequireRole("admin"), handlers.CreateServerGroup)
+	api.Put("/server-groups/:id", middleware.RequireRole("admin"), handlers.UpdateServerGroup)
+	api.Delete("/server-groups/:id", middleware.RequireRole("admin"), handlers.DeleteServerGroup)
+
+	// Staged agent rollouts (admin only)
+	api.Get("/rollouts", middleware.RequireRole("admin"), handlers.GetRollouts)
+	api.Post("/rollouts", middleware.RequireRole("admin"), handlers.CreateRollout)
+	api.Get("/rollouts/:id", middleware.RequireRole("admin"), handlers.GetRollout)
+	api.Put("/rollouts/:id", middleware.RequireRole("admin"), handlers.UpdateRollout)
+	api.Post("/rollouts/:id/:action", middleware.RequireRole("admin"), handlers.RolloutAction)
+
+	// Script Runner (allow-list managed by admins, runs by admins/operators)
+	api.Get("/scripts", handlers.GetScripts)
+	api.Post("/scripts", middleware.RequireRole("admin"), handlers.CreateScript)
+	api.Delete("/scripts/:id", middleware.RequireRole("admin"), handlers.DeleteScript)
+	api.Post("/scripts/:id/run", middleware.RequireRole("admin", "operator"), handlers.RunScript)
+	api.Get("/script-runs", handlers.GetScriptRuns)
+	api.Get("/script-runs/:id", handlers.GetScriptRun)
+
+	// Cron Pause Control
+	api.Get("/servers/:id/cron/pauses", handlers.GetCronPauses)
+	api.Get("/servers/:id/cron/runs", handlers.GetCronRunStats)
+	api.Get("/servers/:id/cron/:job/runs", handlers.GetCronJobRuns)
+	api.Post("/servers/:id/cron/pause", middleware.RequireRole("admin", "operator"), handlers.PauseCronJob)
+	api.Post("/servers/:id/cron/resume", middleware.RequireRole("admin", "operator"), handlers.ResumeCronJob)
+
+	// Remediation (restart unit / SIGTERM PID)
+	api.Get("/servers/:id/remediations", handlers.GetRemediations)
+	api.Post("/servers/:id/remediate", middleware.RequireRole("admin", "operator"), handlers.RequestRemediation)
+
+	// Remote Terminal (admin only, per-server opt-in)
+	api.Put("/servers/:id/terminal", middleware.RequireRole("admin"), handlers.SetTerminalEnabled)
+	api.Post("/servers/:id/terminal", middleware.RequireRole("admin"), handlers.OpenTerminal)
+	api.Get("/servers/:id/terminal/sessions", middleware.RequireRole("admin"), handlers.GetTerminalSessions)
+	api.Post("/terminal/:sid/input", middleware.RequireRole("admin"), handlers.TerminalInput)
+	api.Get("/terminal/:sid/output", middleware.RequireRole("admin"), handlers.TerminalOutput)
+	api.Delete("/terminal/:sid", middleware.RequireRole("admin"), handlers.CloseTerminal)
+	api.Get("/terminal/:sid/recording", middleware.RequireRole("admin"), handlers.DownloadTerminalRecording)
+
+	// Users & Audit (admin only)
+	api.Get("/users", middleware.RequireRole("admin"), handlers.GetUsers)
+	api.Post("/users", middleware.RequireRole("admin"), handlers.CreateUser)
+	api.Put("/users/:id/role", middleware.RequireRole("admin"), handlers.UpdateUserRole)
+	api.Put("/users/:id/tags", middleware.RequireRole("admin"), handlers.UpdateUserTags)
+	api.Get("/audit-log", middleware.RequireRole("admin"), handlers.GetAuditLog)
+
+	// Heartbeat (deadman) checks
+	api.Get("/heartbeats", handlers.GetHeartbeats)
+	api.Post("/heartbeats", middleware.RequireRole("admin"), handlers.CreateHeartbeat)
+	api.Put("/heartbeats/:id", middleware.RequireRole("admin"), handlers.UpdateHeartbeat)
+	api.Delete("/heartbeats/:id", middleware.RequireRole("admin"), handlers.DeleteHeartbeat)
+
+	// Install packages (registration source tracking, batch revocation)
+	api.Get("/agent-packages", middleware.RequireRole("admin"), handlers.GetInstallPackages)
+	api.Post("/agent-packages/:id/revoke", middleware.RequireRole("admin"), handlers.RevokeInstallPackage)
+
+	// Agent client certificates (mutual TLS)
+	api.Get("/agent-ca", middleware.RequireRole("admin"), handlers.GetAgentCA)
+	api.Get("/servers/:id/certificates", middleware.RequireRole("admin"), handlers.GetAgentCertificates)
+	api.Delete("/servers/:id/certificates/:serial", middleware.RequireRole("admin"), handlers.RevokeAgentCertificate)
 
 
 	// License management (admin only)
-	api.Post("/license/upload", middleware.AuthRequired, handlers.UploadLicense)
+	api.Post("/license/upload", middleware.RequireRole("admin"), handlers.UploadLicense)
 
 	// License Generator (conditionally enabled for developer image)
 	if os.Getenv("INCLUDE_LICENSE_GENERATOR") == "true" {
-		api.Post("/auth/generate-license", handlers.GenerateLicense)
+		api.Post("/auth/generate-license", middleware.RequireRole("admin"), handlers.GenerateLicense)
 		log.Println("✅ License Generator endpoint enabled")
 	}
-
-	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	// Serve static files (Frontend) if directory exists
-	if _, err := os.Stat("./frontend"); err == nil {
-		app.Static("/", "./frontend")
-		
-		// Handle SPA routing: If file not found, serve index.html
-		app.Get("*", func(c *fiber.Ctx) error {
-            // Check if request is for API, ignore
-            if len(c.Path()) >= 4 && c.Path()[:4] == "/api" {
-                return c.Next()
-            }
-			return c.SendFile("./frontend/index.html")
-		})
-		log.Println("✅ Serving static frontend from ./frontend")
-	}
-
-	log.Printf("🚀 Server starting on port %s", port)
-	if err := app.Listen(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
-	}
 }
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/middleware"
+)
+
+// viewerWritable are the mutating routes open to every logged-in user
+var viewerWritable = map[string]bool{
+	"POST /api/v1/auth/password": true, // own password
+}
+
+// testToken signs a session token claiming the admin role: the current role
+// is read from the users table on every request, so the claim must not count
+func testToken(t *testing.T, userID int, username string) string {
+	t.Helper()
+	middleware.SetJWTSecret([]byte("test-secret"))
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": userID, "username": username, "role": "admin",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("test-secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestViewerCannotMutate(t *testing.T) {
+	if err := database.Init(filepath.Join(t.TempDir(), "health.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if _, err := database.DB.Exec(`INSERT INTO servers (id, hostname, api_secret_hash, first_seen, last_seen) VALUES ('s1', 'web-1', 'x', 0, 0)`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, password_hash, created_at, role) VALUES (1, 'alice', 'x', 0, 'viewer')`); err != nil {
+		t.Fatal(err)
+	}
+	token := testToken(t, 1, "alice")
+
+	app := fiber.New()
+	registerAPIRoutes(app.Group("/api/v1", middleware.AuthRequired))
+	visibility := reflect.ValueOf(middleware.RequireServerVisible).Pointer()
+
+	tested := 0
+	for _, route := range app.GetRoutes(true) {
+		switch route.Method {
+		case fiber.MethodPost, fiber.MethodPut, fiber.MethodDelete, fiber.MethodPatch:
+		default:
+			continue
+		}
+		if len(route.Handlers) == 1 && reflect.ValueOf(route.Handlers[0]).Pointer() == visibility {
+			continue // api.All("/servers/:id...") visibility check
+		}
+		if viewerWritable[route.Method+" "+route.Path] {
+			continue
+		}
+
+		path := route.Path
+		for _, param := range route.Params {
+			path = strings.Replace(path, ":"+param, "1", 1)
+		}
+		path = strings.Replace(path, "/servers/1", "/servers/s1", 1)
+		req := httptest.NewRequest(route.Method, path, strings.NewReader("{}"))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != 403 {
+			t.Errorf("%s %s: status %d for a viewer, want 403", route.Method, route.Path, resp.StatusCode)
+		}
+		tested++
+	}
+	if tested < 50 {
+		t.Fatalf("only %d mutating routes found", tested)
+	}
+}
+
+func TestSecretSettingsAreAdminOnly(t *testing.T) {
+	if err := database.Init(filepath.Join(t.TempDir(), "health.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	if _, err := database.DB.Exec(`INSERT INTO users (id, username, password_hash, created_at, role) VALUES
+		(1, 'alice', 'x', 0, 'viewer'), (2, 'olga', 'x', 0, 'operator'), (3, 'root', 'x', 0, 'admin')`); err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	registerAPIRoutes(app.Group("/api/v1", middleware.AuthRequired))
+	status := func(token, path string) int {
+		t.Helper()
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	for _, path := range []string{"/api/v1/auth/registration-token", "/api/v1/settings/alerts"} {
+		for _, user := range []struct {
+			id   int
+			name string
+		}{{1, "alice"}, {2, "olga"}} {
+			if got := status(testToken(t, user.id, user.name), path); got != 403 {
+				t.Errorf("GET %s as %s: status %d, want 403", path, user.name, got)
+			}
+		}
+		if got := status(testToken(t, 3, "root"), path); got == 403 || got == 401 {
+			t.Errorf("GET %s as admin: status %d", path, got)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package maintenance
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// Metrics archives keep metrics past their retention out of the live
+// database: when metrics_archive_enabled is set the janitor exports expiring
+// raw samples and hourly rollups into one SQLite file per month
+// (metrics-2026-01.db) before deleting them. Long chart ranges read the hourly
+// buckets back from the archives.
+
+// archiveMetricColumns are the raw metric columns copied to an archive
+const archiveMetricColumns = `id, server_id, timestamp, cpu_percent, mem_total_mb, mem_used_mb,
+	disk_total_gb, disk_used_gb, load_avg_1, load_avg_5, load_avg_15, process_count, processes, uptime`
+
+// archiveRollupColumns are the hourly rollup columns copied to an archive
+const archiveRollupColumns = `server_id, bucket, samples, cpu_percent, cpu_percent_max,
+	mem_total_mb, mem_used_mb, mem_used_mb_max, disk_total_gb, disk_used_gb,
+	load_avg_1, load_avg_1_max, load_avg_5, load_avg_15, process_count, uptime`
+
+const archiveSchema = `
+CREATE TABLE IF NOT EXISTS archive.metrics (
+    id INTEGER PRIMARY KEY,
+    server_id TEXT NOT NULL,
+    timestamp INTEGER NOT NULL,
+    cpu_percent REAL,
+    mem_total_mb INTEGER,
+    mem_used_mb INTEGER,
+    disk_total_gb INTEGER,
+    disk_used_gb INTEGER,
+    load_avg_1 REAL,
+    load_avg_5 REAL,
+    load_avg_15 REAL,
+    process_count INTEGER,
+    processes TEXT,
+    uptime INTEGER
+);
+CREATE INDEX IF NOT EXISTS archive.idx_metrics_server_time ON metrics(server_id, timestamp);
+CREATE TABLE IF NOT EXISTS archive.metrics_1h (
+    server_id TEXT NOT NULL,
+    bucket INTEGER NOT NULL,
+    samples INTEGER NOT NULL,
+    cpu_percent REAL,
+    cpu_percent_max REAL,
+    mem_total_mb INTEGER,
+    mem_used_mb INTEGER,
+    mem_used_mb_max INTEGER,
+    disk_total_gb INTEGER,
+    disk_used_gb INTEGER,
+    load_avg_1 REAL,
+    load_avg_1_max REAL,
+    load_avg_5 REAL,
+    load_avg_15 REAL,
+    process_count INTEGER,
+    uptime INTEGER,
+    PRIMARY KEY (server_id, bucket)
+);`
+
+// MetricsArchiveDir is where the monthly archives are written
+// (METRICS_ARCHIVE_DIR, default "archive" next to the database)
+func MetricsArchiveDir() string {
+	if dir := os.Getenv("METRICS_ARCHIVE_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(filepath.Dir(database.Path), "archive")
+}
+
+// MetricsArchiveEnabled reports whether expiring metrics are archived
+func MetricsArchiveEnabled() bool {
+	var val string
+	database.GetSetting("metrics_archive_enabled", &val)
+	return val == "true"
+}
+
+// archivePath is the archive file of the month starting at month (UTC)
+func archivePath(month time.Time) string {
+	return filepath.Join(MetricsArchiveDir(), "metrics-"+month.Format("2006-01")+".db")
+}
+
+// monthsBetween returns the starts of the UTC months overlapping [from, to)
+func monthsBetween(from, to int64) []time.Time {
+	t := time.Unix(from, 0).UTC()
+	month := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	var months []time.Time
+	for ; month.Unix() < to; month = month.AddDate(0, 1, 0) {
+		months = append(months, month)
+	}
+	return months
+}
+
+// archiveExpiredMetrics exports metrics and hourly rollups older than cutoff
+// to their monthly archives. Nothing is deleted here; an error means the
+// caller must keep the rows.
+func archiveExpiredMetrics(cutoff int64) error {
+	if !MetricsArchiveEnabled() {
+		return nil
+	}
+
+	var oldest sql.NullInt64
+	if err := database.DB.QueryRow(`
+		SELECT MIN(t) FROM (
+			SELECT MIN(timestamp) AS t FROM metrics WHERE timestamp < ?1
+			UNION ALL SELECT MIN(bucket) FROM metrics_1h WHERE bucket < ?1
+		)
+	`, cutoff).Scan(&oldest); err != nil {
+		return err
+	}
+	if !oldest.Valid {
+		return nil
+	}
+	if err := os.MkdirAll(MetricsArchiveDir(), 0750); err != nil {
+		return err
+	}
+
+	// ATTACH applies to one connection, so the export holds one
+	ctx := context.Background()
+	conn, err := database.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, month := range monthsBetween(oldest.Int64, cutoff) {
+		end := month.AddDate(0, 1, 0).Unix()
+		if end > cutoff {
+			end = cutoff
+		}
+		if err := archiveMonth(ctx, conn, archivePath(month), month.Unix(), end); err != nil {
+			return fmt.Errorf("%s: %w", month.Format("2006-01"), err)
+		}
+	}
+	return nil
+}
+
+// archiveMonth copies the rows in [from, to) into one archive file
+func archiveMonth(ctx context.Context, conn *sql.Conn, path string, from, to int64) error {
+	if _, err := conn.ExecContext(ctx, "ATTACH DATABASE ? AS archive", path); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "DETACH DATABASE archive")
+
+	if _, err := conn.ExecContext(ctx, archiveSchema); err != nil {
+		return err
+	}
+	raw, err := conn.ExecContext(ctx, `
+		INSERT OR IGNORE INTO archive.metrics (`+archiveMetricColumns+`)
+		SELECT `+archiveMetricColumns+` FROM main.metrics WHERE timestamp >= ? AND timestamp < ?
+	`, from, to)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, `
+		INSERT OR REPLACE INTO archive.metrics_1h (`+archiveRollupColumns+`)
+		SELECT `+archiveRollupColumns+` FROM main.metrics_1h WHERE bucket >= ? AND bucket < ?
+	`, from, to); err != nil {
+		return err
+	}
+
+	if rows, _ := raw.RowsAffected(); rows > 0 {
+		log.Printf("🗄️  Janitor: Archived %d metric records to %s", rows, filepath.Base(path))
+	}
+	return nil
+}
+
+// ArchivedHourlyMetrics returns a server's archived hourly buckets in
+// [from, to), newest first. Months without an archive are skipped.
+func ArchivedHourlyMetrics(serverID string, from, to int64) ([]models.Metric, error) {
+	metrics := []models.Metric{}
+	months := monthsBetween(from, to)
+	for i := len(months) - 1; i >= 0; i-- {
+		path := archivePath(months[i])
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+		if err != nil {
+			return nil, err
+		}
+		rows, err := db.Query(`
+			SELECT bucket, COALESCE(cpu_percent, 0), COALESCE(mem_total_mb, 0), COALESCE(mem_used_mb, 0),
+				COALESCE(disk_total_gb, 0), COALESCE(disk_used_gb, 0), COALESCE(load_avg_1, 0), COALESCE(load_avg_5, 0),
+				COALESCE(load_avg_15, 0), COALESCE(process_count, 0), COALESCE(uptime, 0)
+			FROM metrics_1h
+			WHERE server_id = ? AND bucket >= ? AND bucket < ?
+			ORDER BY bucket DESC
+		`, serverID, from, to)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		for rows.Next() {
+			m := models.Metric{ServerID: serverID}
+			if err := rows.Scan(&m.Timestamp, &m.CPUPercent, &m.MemTotalMB, &m.MemUsedMB, &m.DiskTotalGB,
+				&m.DiskUsedGB, &m.LoadAvg1, &m.LoadAvg5, &m.LoadAvg15, &m.ProcessCount, &m.Uptime); err == nil {
+				metrics = append(metrics, m)
+			}
+		}
+		rows.Close()
+		db.Close()
+	}
+	return metrics, nil
+}
+
+// EachMetricsArchive calls fn with the archive of each month overlapping
+// [from, to), oldest first, opened read-only. Months without an archive are
+// skipped.
+func EachMetricsArchive(from, to int64, fn func(db *sql.DB) error) error {
+	for _, month := range monthsBetween(from, to) {
+		path := archivePath(month)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+		if err != nil {
+			return err
+		}
+		err = fn(db)
+		db.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+// OpenMetricsArchive opens an archive (MetricsArchive.File) for changes
+func OpenMetricsArchive(file string) (*sql.DB, error) {
+	return sql.Open("sqlite3", filepath.Join(MetricsArchiveDir(), filepath.Base(file))+"?_busy_timeout=5000")
+}
+
+// MetricsArchive is one monthly archive file
+type MetricsArchive struct {
+	Month string `json:"month"`
+	File  string `json:"file"`
+	Size  int64  `json:"size"`
+}
+
+// ListMetricsArchives returns the monthly archives, oldest first
+func ListMetricsArchives() []MetricsArchive {
+	paths, _ := filepath.Glob(filepath.Join(MetricsArchiveDir(), "metrics-*.db"))
+	archives := []MetricsArchive{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		name := filepath.Base(path)
+		archives = append(archives, MetricsArchive{
+			Month: name[len("metrics-") : len(name)-len(".db")],
+			File:  name,
+			Size:  info.Size(),
+		})
+	}
+	return archives
+}
@@ -0,0 +1,48 @@
+package maintenance
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+func TestArchiveExpiredMetrics(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("METRICS_ARCHIVE_DIR", filepath.Join(dir, "archive"))
+	if err := database.Init(filepath.Join(dir, "health.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	database.DB.Exec(`INSERT INTO servers (id, hostname, api_secret_hash, first_seen, last_seen) VALUES ('s1', 'web-1', 'x', 0, 0)`)
+
+	// Two months and one month back, both past a 20 day retention
+	twoMonths := time.Now().AddDate(0, -2, 0).Unix()
+	oneMonth := time.Now().AddDate(0, -1, 0).Unix()
+	for _, ts := range []int64{twoMonths, oneMonth} {
+		database.DB.Exec(`INSERT INTO metrics (server_id, timestamp, cpu_percent) VALUES ('s1', ?, 42)`, ts)
+		database.DB.Exec(`INSERT INTO metrics_1h (server_id, bucket, samples, cpu_percent) VALUES ('s1', ?, 1, 42)`, ts-ts%3600)
+	}
+	database.DB.Exec(`INSERT INTO settings (key, value, updated_at) VALUES ('metrics_archive_enabled', 'true', 0)`)
+	database.DB.Exec(`INSERT INTO settings (key, value, updated_at) VALUES ('retention_metrics_days', '20', 0)`)
+
+	runCleanup()
+
+	var left int
+	database.DB.QueryRow(`SELECT COUNT(*) FROM metrics`).Scan(&left)
+	if left != 0 {
+		t.Errorf("%d expired metrics left in the database", left)
+	}
+	if archives := ListMetricsArchives(); len(archives) != 2 {
+		t.Fatalf("archives = %+v, want one per month", archives)
+	}
+
+	archived, err := ArchivedHourlyMetrics("s1", twoMonths-3600, time.Now().Unix())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archived) != 2 || archived[0].Timestamp <= archived[1].Timestamp || archived[0].CPUPercent != 42 {
+		t.Errorf("archived = %+v", archived)
+	}
+}
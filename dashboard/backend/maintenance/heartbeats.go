@@ -0,0 +1,101 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/notifications"
+	"github.com/yourusername/health-dashboard-backend/tickets"
+)
+
+// StartHeartbeatWatcher marks heartbeats as down once their ping is overdue
+func StartHeartbeatWatcher() {
+	go func() {
+		log.Println("💓 Heartbeat Watcher started (Check Interval: 30s)")
+
+		notifier := notifications.NewNotificationService()
+
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			checkHeartbeats(notifier)
+		}
+	}()
+}
+
+// checkHeartbeats raises a critical event and alert for each heartbeat whose last
+// ping (or creation, if it never pinged) is older than its interval plus grace
+func checkHeartbeats(notifier notifications.Service) {
+	now := time.Now().Unix()
+	rows, err := database.DB.Query(`
+		SELECT h.id, h.name, COALESCE(h.server_id, ''), COALESCE(s.hostname, ''), h.interval_seconds, COALESCE(h.last_ping, 0)
+		FROM heartbeats h
+		LEFT JOIN servers s ON s.id = h.server_id
+		WHERE h.status != 'down' AND COALESCE(h.last_ping, h.created_at) + h.interval_seconds + h.grace_seconds < ?
+	`, now)
+	if err != nil {
+		log.Printf("❌ Heartbeat Watcher: Failed to query heartbeats: %v", err)
+		return
+	}
+	type overdue struct {
+		id                 int64
+		name, serverID     string
+		hostname           string
+		interval, lastPing int64
+	}
+	var list []overdue
+	for rows.Next() {
+		var h overdue
+		if rows.Scan(&h.id, &h.name, &h.serverID, &h.hostname, &h.interval, &h.lastPing) == nil {
+			list = append(list, h)
+		}
+	}
+	rows.Close()
+	if len(list) == 0 {
+		return
+	}
+
+	notifier.UpdateSettings(loadNotificationSettings())
+	for _, h := range list {
+		// A ping may have arrived since the query
+		res, err := database.DB.Exec("UPDATE heartbeats SET status = 'down', down_since = ? WHERE id = ? AND status != 'down'", now, h.id)
+		if err != nil {
+			log.Printf("❌ Heartbeat Watcher: Failed to mark heartbeat %d as down: %v", h.id, err)
+			continue
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+
+		last := "never pinged"
+		if h.lastPing > 0 {
+			last = "last ping " + time.Unix(h.lastPing, 0).UTC().Format(time.RFC1123)
+		}
+		msg := fmt.Sprintf("Heartbeat %s is overdue: expected a ping every %s, %s", h.name, time.Duration(h.interval)*time.Second, last)
+		where := ""
+		if h.serverID != "" {
+			where = " on " + h.hostname
+			details, _ := json.Marshal(map[string]interface{}{"heartbeat_id": h.id, "interval": h.interval, "last_ping": h.lastPing})
+			if _, err := database.DB.Exec(`
+				INSERT INTO events (server_id, timestamp, event_type, severity, message, details)
+				VALUES (?, ?, 'heartbeat', 'critical', ?, ?)
+			`, h.serverID, now, msg, string(details)); err != nil {
+				log.Printf("Failed to insert heartbeat event: %v", err)
+			}
+			go tickets.Open(h.serverID, fmt.Sprintf("heartbeat:%d", h.id), fmt.Sprintf("%s: heartbeat %s overdue", h.hostname, h.name), msg)
+		}
+		log.Printf("💔 %s", msg)
+
+		notifier.Notify(notifications.Notification{
+			Subject:   fmt.Sprintf("[CRITICAL] Heartbeat Overdue: %s%s", h.name, where),
+			Message:   msg + ".",
+			Type:      notifications.TypeCritical,
+			ServerID:  h.serverID,
+			EventType: "heartbeat",
+		})
+	}
+}
@@ -8,13 +8,15 @@ import (
 	"time"
 
 	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/health"
 	"github.com/yourusername/health-dashboard-backend/notifications"
+	"github.com/yourusername/health-dashboard-backend/tickets"
 )
 
 // StartJanitor starts the background maintenance worker
 func StartJanitor() {
 	go func() {
-		log.Println("🧹 Janitor started (Interval: 24h, Retention: 90 days)")
+		log.Println("🧹 Janitor started (Interval: 24h, retention from settings)")
 		
 		// Run once on startup after a delay
 		time.Sleep(1 * time.Minute)
@@ -33,34 +35,46 @@ func StartJanitor() {
 func runCleanup() {
 	log.Println("🧹 Janitor: Starting cleaning cycle...")
 
-	// 1. Delete metrics older than 90 days
-	days := 90
+	// 1. Delete metrics past their retention (default 90 days), exported to
+	// the monthly archives first when archiving is enabled
+	days := LoadMetricsRetentionDays()
 	retention := time.Now().AddDate(0, 0, -days).Unix()
-	
-	result, err := database.DB.Exec("DELETE FROM metrics WHERE timestamp < ?", retention)
-	if err != nil {
-		log.Printf("❌ Janitor: Failed to prune metrics: %v", err)
+
+	if err := archiveExpiredMetrics(retention); err != nil {
+		log.Printf("❌ Janitor: Failed to archive metrics, keeping them: %v", err)
 	} else {
-		rows, _ := result.RowsAffected()
-		if rows > 0 {
-			log.Printf("🧹 Janitor: Pruned %d old metric records", rows)
+		result, err := database.DB.Exec("DELETE FROM metrics WHERE timestamp < ?", retention)
+		if err != nil {
+			log.Printf("❌ Janitor: Failed to prune metrics: %v", err)
 		} else {
-			log.Println("🧹 Janitor: No old metrics to prune")
+			rows, _ := result.RowsAffected()
+			if rows > 0 {
+				log.Printf("🧹 Janitor: Pruned %d old metric records", rows)
+			} else {
+				log.Println("🧹 Janitor: No old metrics to prune")
+			}
+		}
+
+		// Rollups are kept as long as the raw metrics
+		for _, r := range []MetricRollup{Rollup5m, Rollup1h} {
+			if _, err := database.DB.Exec("DELETE FROM "+r.Table+" WHERE bucket < ?", retention); err != nil {
+				log.Printf("❌ Janitor: Failed to prune %s: %v", r.Table, err)
+			}
 		}
 	}
 
-	// 2. Delete events older than 90 days
-	result, err = database.DB.Exec("DELETE FROM events WHERE timestamp < ?", retention)
+	// 2. Delete cron run history older than the metrics
+	result, err := database.DB.Exec("DELETE FROM cron_runs WHERE started_at < ?", retention)
 	if err != nil {
-		log.Printf("❌ Janitor: Failed to prune events: %v", err)
-	} else {
-		rows, _ := result.RowsAffected()
-		if rows > 0 {
-			log.Printf("🧹 Janitor: Pruned %d old event records", rows)
-		}
+		log.Printf("❌ Janitor: Failed to prune cron runs: %v", err)
+	} else if rows, _ := result.RowsAffected(); rows > 0 {
+		log.Printf("🧹 Janitor: Pruned %d old cron run records", rows)
 	}
 
-	// 3. Optimize database
+	// 3. Delete events past their per-severity retention
+	pruneEvents()
+
+	// 4. Optimize database
 	_, err = database.DB.Exec("VACUUM")
 	if err != nil {
 		log.Printf("❌ Janitor: Failed to VACUUM database: %v", err)
@@ -87,19 +101,44 @@ func StartHealthWatcher() {
 	}()
 }
 
+// StartNotificationWatcher reports notification providers that keep failing
+// through the providers that still work
+func StartNotificationWatcher() {
+	go func() {
+		log.Println("📣 Notification Watcher started (Check Interval: 1m)")
+
+		notifier := notifications.NewNotificationService()
+
+		ticker := time.NewTicker(1 * time.Minute)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			minutes := notifications.FailureMinutes()
+			if minutes <= 0 {
+				continue
+			}
+			notifier.UpdateSettings(loadNotificationSettings())
+			notifier.CheckProviderHealth(time.Duration(minutes) * time.Minute)
+		}
+	}()
+}
+
 func checkServerHealth(notifier notifications.Service) {
 	// Get timeout from settings (default 120s)
 	timeout := 120
 	var val string
-	if err := database.DB.QueryRow("SELECT value FROM settings WHERE key = 'offline_timeout'").Scan(&val); err == nil {
+	if err := database.GetSetting("offline_timeout", &val); err == nil {
 		fmt.Sscanf(val, "%d", &timeout)
 	}
 
 	// Threshold
 	threshold := time.Now().Unix() - int64(timeout)
 
-	// Identify servers going offline
-	rows, err := database.DB.Query("SELECT id, hostname FROM servers WHERE last_seen < ? AND health_status != 'offline'", threshold)
+	// Identify servers going offline (servers in maintenance are left alone)
+	rows, err := database.DB.Query(`
+		SELECT id, hostname, COALESCE(health_status, 'unknown') FROM servers
+		WHERE last_seen < ? AND health_status != 'offline' AND COALESCE(maintenance_until, 0) <= ?
+	`, threshold, time.Now().Unix())
 	if err != nil {
 		log.Printf("❌ Watchdog: Failed to query offline servers: %v", err)
 		return
@@ -109,14 +148,16 @@ func checkServerHealth(notifier notifications.Service) {
 	var offlineServers []struct {
 		ID       string
 		Hostname string
+		Status   string
 	}
 
 	for rows.Next() {
 		var s struct {
 			ID       string
 			Hostname string
+			Status   string
 		}
-		if err := rows.Scan(&s.ID, &s.Hostname); err == nil {
+		if err := rows.Scan(&s.ID, &s.Hostname, &s.Status); err == nil {
 			offlineServers = append(offlineServers, s)
 		}
 	}
@@ -127,19 +168,30 @@ func checkServerHealth(notifier notifications.Service) {
 		notifier.UpdateSettings(settings)
 
 		for _, s := range offlineServers {
+			// Covered by a maintenance window
+			if health.InMaintenance(s.ID) {
+				continue
+			}
+
 			// Notify
 			notifier.Notify(notifications.Notification{
-				Subject: fmt.Sprintf("[CRITICAL] Server Offline: %s", s.Hostname),
-				Message: fmt.Sprintf("Server %s (%s) has gone OFFLINE (Timeout: %ds). Last seen > %d seconds ago.", s.Hostname, s.ID, timeout, timeout),
-				Type:    notifications.TypeCritical,
+				Subject:   fmt.Sprintf("[CRITICAL] Server Offline: %s", s.Hostname),
+				Message:   fmt.Sprintf("Server %s (%s) has gone OFFLINE (Timeout: %ds). Last seen > %d seconds ago.", s.Hostname, s.ID, timeout, timeout),
+				Type:      notifications.TypeCritical,
+				ServerID:  s.ID,
+				EventType: "offline",
 			})
 
+			go tickets.Open(s.ID, "health", fmt.Sprintf("%s is offline", s.Hostname),
+				fmt.Sprintf("Server %s (%s) has gone OFFLINE at %s (no data for more than %ds).", s.Hostname, s.ID, time.Now().UTC().Format(time.RFC1123), timeout))
+
 			// Update Status
 			_, err := database.DB.Exec("UPDATE servers SET health_status = 'offline' WHERE id = ?", s.ID)
 			if err != nil {
 				log.Printf("❌ Watchdog: Failed to mark server %s as offline: %v", s.ID, err)
 			} else {
 				log.Printf("📉 Watchdog: Marked %s (%s) as OFFLINE", s.Hostname, s.ID)
+				health.PublishStatus(s.ID, health.StatusOffline, s.Status, fmt.Sprintf("No data for more than %ds", timeout))
 			}
 		}
 	}
@@ -0,0 +1,145 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+// EventRetention maps an event severity to the number of days it is kept.
+// The special "security" key applies to security events regardless of severity.
+type EventRetention map[string]int
+
+// DefaultEventRetention keeps routine noise for a month and audit-relevant events for two years
+var DefaultEventRetention = EventRetention{
+	"info":     30,
+	"warning":  90,
+	"error":    365,
+	"critical": 730,
+	"security": 730,
+}
+
+// LoadEventRetention returns the configured per-severity retention, filled in with defaults
+func LoadEventRetention() EventRetention {
+	retention := EventRetention{}
+	for k, v := range DefaultEventRetention {
+		retention[k] = v
+	}
+
+	var val string
+	if err := database.GetSetting("event_retention", &val); err == nil {
+		var saved EventRetention
+		if err := json.Unmarshal([]byte(val), &saved); err == nil {
+			for k, v := range saved {
+				if _, known := retention[k]; known && v > 0 {
+					retention[k] = v
+				}
+			}
+		}
+	}
+	return retention
+}
+
+// DefaultMetricsRetentionDays is how long metrics (and their rollups and cron
+// run history) are kept unless retention_metrics_days is set
+const DefaultMetricsRetentionDays = 90
+
+// LoadMetricsRetentionDays returns the configured metrics retention in days
+func LoadMetricsRetentionDays() int {
+	var val string
+	days := 0
+	if err := database.GetSetting("retention_metrics_days", &val); err == nil {
+		fmt.Sscanf(val, "%d", &days)
+	}
+	if days < 1 {
+		return DefaultMetricsRetentionDays
+	}
+	return days
+}
+
+// LoadEventsRetentionDays returns retention_events_days, a retention for all
+// events except security events and overridden types (0 = per severity)
+func LoadEventsRetentionDays() int {
+	var val string
+	days := 0
+	if err := database.GetSetting("retention_events_days", &val); err == nil {
+		fmt.Sscanf(val, "%d", &days)
+	}
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+// LoadEventTypeRetention returns the per-event-type retention overrides
+// (event_type -> days), which win over every other event retention
+func LoadEventTypeRetention() map[string]int {
+	retention := map[string]int{}
+	var val string
+	if err := database.GetSetting("retention_event_types", &val); err == nil {
+		json.Unmarshal([]byte(val), &retention)
+	}
+	for eventType, days := range retention {
+		if days < 1 {
+			delete(retention, eventType)
+		}
+	}
+	return retention
+}
+
+// pruneEvents deletes events past their retention: the event type's override,
+// else retention_events_days (security events keep their own), else their
+// severity's retention. Unknown severities are treated as info.
+func pruneEvents() {
+	retention := LoadEventRetention()
+	eventsDays := LoadEventsRetentionDays()
+	typeRetention := LoadEventTypeRetention()
+	var total int64
+
+	cutoff := func(days int) int64 {
+		return time.Now().AddDate(0, 0, -days).Unix()
+	}
+	prune := func(what, query string, args ...interface{}) {
+		result, err := database.DB.Exec(query, args...)
+		if err != nil {
+			log.Printf("❌ Janitor: Failed to prune %s events: %v", what, err)
+			return
+		}
+		rows, _ := result.RowsAffected()
+		total += rows
+	}
+
+	// Types with their own retention are left out of the general rules
+	exempt := "'security'"
+	exemptArgs := []interface{}{}
+	for eventType, days := range typeRetention {
+		prune(eventType, "DELETE FROM events WHERE event_type = ? AND timestamp < ?", eventType, cutoff(days))
+		exempt += ", ?"
+		exemptArgs = append(exemptArgs, eventType)
+	}
+	general := "event_type NOT IN (" + exempt + ")"
+
+	if _, overridden := typeRetention["security"]; !overridden {
+		prune("security", "DELETE FROM events WHERE event_type = 'security' AND timestamp < ?", cutoff(retention["security"]))
+	}
+
+	if eventsDays > 0 {
+		prune("old", "DELETE FROM events WHERE "+general+" AND timestamp < ?", append(exemptArgs, cutoff(eventsDays))...)
+	} else {
+		for _, severity := range []string{"warning", "error", "critical"} {
+			prune(severity, "DELETE FROM events WHERE "+general+" AND severity = ? AND timestamp < ?",
+				append(append([]interface{}{}, exemptArgs...), severity, cutoff(retention[severity]))...)
+		}
+		prune("info", `
+			DELETE FROM events
+			WHERE `+general+` AND COALESCE(severity, 'info') NOT IN ('warning', 'error', 'critical') AND timestamp < ?
+		`, append(exemptArgs, cutoff(retention["info"]))...)
+	}
+
+	if total > 0 {
+		log.Printf("🧹 Janitor: Pruned %d old event records", total)
+	}
+}
@@ -0,0 +1,65 @@
+package maintenance
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+func TestPruneEventsRetention(t *testing.T) {
+	if err := database.Init(filepath.Join(t.TempDir(), "health.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+	database.DB.Exec(`INSERT INTO servers (id, hostname, api_secret_hash, first_seen, last_seen) VALUES ('s1', 'web-1', 'x', 0, 0)`)
+
+	daysAgo := func(days int) int64 { return time.Now().AddDate(0, 0, -days).Unix() }
+	insert := func(eventType, severity string, age int) {
+		t.Helper()
+		if _, err := database.DB.Exec(`INSERT INTO events (server_id, timestamp, event_type, severity, message) VALUES ('s1', ?, ?, ?, ?)`,
+			daysAgo(age), eventType, severity, eventType+"/"+severity); err != nil {
+			t.Fatal(err)
+		}
+	}
+	remaining := func() map[string]bool {
+		t.Helper()
+		left := map[string]bool{}
+		rows, _ := database.DB.Query(`SELECT message FROM events`)
+		defer rows.Close()
+		for rows.Next() {
+			var msg string
+			rows.Scan(&msg)
+			left[msg] = true
+		}
+		return left
+	}
+	setting := func(key, value string) {
+		database.DB.Exec(`INSERT INTO settings (key, value, updated_at) VALUES (?, ?, 0)
+			ON CONFLICT(key) DO UPDATE SET value = excluded.value`, key, value)
+		database.InvalidateSettings()
+	}
+
+	// Per severity: info 30 days, error 365 days
+	insert("cron_fail", "info", 40)
+	insert("cron_fail", "error", 40)
+	insert("drift", "warning", 40)
+	insert("security", "info", 400)
+	setting("retention_event_types", `{"drift": 10}`)
+	pruneEvents()
+	left := remaining()
+	if left["cron_fail/info"] || !left["cron_fail/error"] || left["drift/warning"] || !left["security/info"] {
+		t.Fatalf("per severity: left %v", left)
+	}
+
+	// A general events retention replaces the severities, not security or overrides
+	setting("retention_events_days", "20")
+	setting("retention_event_types", `{"cron_fail": 50}`)
+	insert("disk_full", "critical", 25)
+	pruneEvents()
+	left = remaining()
+	if left["disk_full/critical"] || !left["cron_fail/error"] || !left["security/info"] {
+		t.Fatalf("general retention: left %v", left)
+	}
+}
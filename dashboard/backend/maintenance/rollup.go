@@ -0,0 +1,110 @@
+package maintenance
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+// RawMetricsWindow is how long metrics are only kept raw. Older samples are
+// rolled up into 5-minute and hourly buckets for long chart ranges.
+const RawMetricsWindow = 48 * time.Hour
+
+// MetricRollup is an aggregate table and its bucket size in seconds
+type MetricRollup struct {
+	Table string
+	Step  int64
+}
+
+// MetricRollups from finest to coarsest
+var (
+	Rollup5m = MetricRollup{Table: "metrics_5m", Step: 300}
+	Rollup1h = MetricRollup{Table: "metrics_1h", Step: 3600}
+)
+
+// rollupState is where the last rollup stopped: raw rows up to LastID were
+// seen, buckets before RolledUntil are complete
+type rollupState struct {
+	LastID      int64
+	RolledUntil int64
+}
+
+func loadRollupState() rollupState {
+	var state rollupState
+	database.DB.QueryRow("SELECT last_id, rolled_until FROM metrics_rollup_state WHERE id = 1").Scan(&state.LastID, &state.RolledUntil)
+	return state
+}
+
+// MetricsRolledUntil returns the time before which the rollup tables are
+// complete (0 = nothing rolled up yet)
+func MetricsRolledUntil() int64 {
+	return loadRollupState().RolledUntil
+}
+
+// StartMetricsRollup starts the hourly rollup worker
+func StartMetricsRollup() {
+	go func() {
+		log.Printf("📉 Metrics rollup started (Interval: 1h, raw window: %s)", RawMetricsWindow)
+
+		time.Sleep(2 * time.Minute)
+		rollupMetrics()
+
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for range ticker.C {
+			rollupMetrics()
+		}
+	}()
+}
+
+// rollupMetrics aggregates raw metrics older than the raw window. Buckets of
+// samples that aged past the window since the last run are rolled up, and so
+// are buckets of late samples (agents flushing their offline queue) which are
+// recomputed from all their raw rows.
+func rollupMetrics() {
+	state := loadRollupState()
+	cutoff := time.Now().Add(-RawMetricsWindow).Unix()
+	cutoff -= cutoff % Rollup1h.Step
+
+	var maxID int64
+	if err := database.DB.QueryRow("SELECT COALESCE(MAX(id), 0) FROM metrics").Scan(&maxID); err != nil {
+		log.Printf("❌ Rollup: Failed to read metrics: %v", err)
+		return
+	}
+	if maxID == state.LastID && cutoff == state.RolledUntil {
+		return
+	}
+
+	for _, r := range []MetricRollup{Rollup5m, Rollup1h} {
+		res, err := database.DB.Exec(fmt.Sprintf(`
+			INSERT OR REPLACE INTO %s (server_id, bucket, samples, cpu_percent, cpu_percent_max,
+				mem_total_mb, mem_used_mb, mem_used_mb_max, disk_total_gb, disk_used_gb,
+				load_avg_1, load_avg_1_max, load_avg_5, load_avg_15, process_count, uptime)
+			SELECT server_id, timestamp / ?1 * ?1, COUNT(*), AVG(cpu_percent), MAX(cpu_percent),
+				MAX(mem_total_mb), CAST(AVG(mem_used_mb) AS INTEGER), MAX(mem_used_mb), MAX(disk_total_gb), MAX(disk_used_gb),
+				AVG(load_avg_1), MAX(load_avg_1), AVG(load_avg_5), AVG(load_avg_15), CAST(AVG(process_count) AS INTEGER), MAX(uptime)
+			FROM metrics
+			WHERE timestamp < ?2 AND (server_id, timestamp / ?1) IN (
+				SELECT server_id, timestamp / ?1 FROM metrics
+				WHERE timestamp < ?2 AND id <= ?5 AND (id > ?3 OR timestamp >= ?4)
+			)
+			GROUP BY server_id, timestamp / ?1
+		`, r.Table), r.Step, cutoff, state.LastID, state.RolledUntil, maxID)
+		if err != nil {
+			log.Printf("❌ Rollup: Failed to roll up %s: %v", r.Table, err)
+			return
+		}
+		if rows, _ := res.RowsAffected(); rows > 0 {
+			log.Printf("📉 Rollup: Wrote %d %s buckets", rows, r.Table)
+		}
+	}
+
+	if _, err := database.DB.Exec(`
+		INSERT INTO metrics_rollup_state (id, last_id, rolled_until) VALUES (1, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET last_id = excluded.last_id, rolled_until = excluded.rolled_until
+	`, maxID, cutoff); err != nil {
+		log.Printf("❌ Rollup: Failed to save state: %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package maintenance
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+func TestRollupMetrics(t *testing.T) {
+	if err := database.Init(filepath.Join(t.TempDir(), "health.db")); err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	database.DB.Exec(`INSERT INTO servers (id, hostname, api_secret_hash, first_seen, last_seen) VALUES ('s1', 'web-1', 'x', 0, 0)`)
+	insert := func(ts int64, cpu float64) {
+		t.Helper()
+		if _, err := database.DB.Exec(`INSERT INTO metrics (server_id, timestamp, cpu_percent, mem_used_mb) VALUES ('s1', ?, ?, 100)`, ts, cpu); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bucketCPU := func(table string, bucket int64) (samples int, avg, max float64) {
+		t.Helper()
+		database.DB.QueryRow(`SELECT samples, cpu_percent, cpu_percent_max FROM `+table+` WHERE server_id = 's1' AND bucket = ?`, bucket).Scan(&samples, &avg, &max)
+		return
+	}
+
+	old := time.Now().Add(-72 * time.Hour).Unix()
+	old -= old % 3600
+	insert(old, 10)
+	insert(old+60, 30)
+	insert(old+400, 50)
+	insert(time.Now().Unix(), 90) // still in the raw window
+
+	rollupMetrics()
+	if n, avg, max := bucketCPU("metrics_5m", old); n != 2 || avg != 20 || max != 30 {
+		t.Errorf("5m bucket = %d samples, avg %v, max %v", n, avg, max)
+	}
+	if n, avg, _ := bucketCPU("metrics_1h", old); n != 3 || avg != 30 {
+		t.Errorf("1h bucket = %d samples, avg %v", n, avg)
+	}
+	var recent int
+	database.DB.QueryRow(`SELECT COUNT(*) FROM metrics_5m WHERE bucket > ?`, time.Now().Add(-RawMetricsWindow).Unix()).Scan(&recent)
+	if recent != 0 {
+		t.Errorf("rolled up %d buckets inside the raw window", recent)
+	}
+
+	// A late sample from an agent's offline queue recomputes its bucket
+	insert(old+120, 80)
+	rollupMetrics()
+	if n, avg, max := bucketCPU("metrics_5m", old); n != 3 || avg != 40 || max != 80 {
+		t.Errorf("after late sample: 5m bucket = %d samples, avg %v, max %v", n, avg, max)
+	}
+	if MetricsRolledUntil() == 0 {
+		t.Error("rollup state not saved")
+	}
+	var settings int
+	database.DB.QueryRow(`SELECT COUNT(*) FROM settings WHERE key LIKE '%rollup%'`).Scan(&settings)
+	if settings != 0 {
+		t.Error("rollup state written to settings, where it shows up as a config change")
+	}
+}
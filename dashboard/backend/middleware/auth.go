@@ -5,6 +5,7 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/yourusername/health-dashboard-backend/database"
 )
 
 var jwtSecret []byte
@@ -47,10 +48,36 @@ func AuthRequired(c *fiber.Ctx) error {
 	}
 
 	// Extract claims
-	if claims, ok := token.Claims.(jwt.MapClaims); ok {
-		c.Locals("user_id", int64(claims["user_id"].(float64)))
-		c.Locals("username", claims["username"].(string))
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid token"})
+	}
+	userID, _ := claims["user_id"].(float64)
+	username, _ := claims["username"].(string)
+
+	// The role is read on every request, like the allowed tags, so role
+	// changes and deleted users take effect without waiting for a new login
+	var role string
+	if err := database.DB.QueryRow("SELECT COALESCE(role, 'admin') FROM users WHERE id = ?", int64(userID)).Scan(&role); err != nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Invalid token"})
 	}
+	c.Locals("user_id", int64(userID))
+	c.Locals("username", username)
+	c.Locals("role", role)
 
 	return c.Next()
 }
+
+// RequireRole restricts a route to users holding one of the given roles.
+// Must be used after AuthRequired.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		for _, r := range roles {
+			if role == r {
+				return c.Next()
+			}
+		}
+		return c.Status(403).JSON(fiber.Map{"error": "Insufficient permissions"})
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+// ServerScope returns the tags the current user is limited to, or nil if they can see every server.
+// Admins and users without allowed tags see everything. Must be used after AuthRequired.
+func ServerScope(c *fiber.Ctx) []string {
+	if scope, ok := c.Locals("server_scope").([]string); ok {
+		return scope
+	}
+
+	var scope []string
+	if role, _ := c.Locals("role").(string); role != "admin" {
+		userID, _ := c.Locals("user_id").(int64)
+		var allowed string
+		database.DB.QueryRow("SELECT COALESCE(allowed_tags, '') FROM users WHERE id = ?", userID).Scan(&allowed)
+		for _, tag := range strings.Split(allowed, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				scope = append(scope, tag)
+			}
+		}
+	}
+
+	if scope == nil {
+		scope = []string{}
+	}
+	c.Locals("server_scope", scope)
+	return scope
+}
+
+// ServerVisibilityClause returns an SQL condition restricting column (a server ID) to the
+// servers the user may see, with its arguments. Returns "1=1" for unrestricted users.
+func ServerVisibilityClause(c *fiber.Ctx, column string) (string, []interface{}) {
+	scope := ServerScope(c)
+	if len(scope) == 0 {
+		return "1=1", nil
+	}
+
+	args := make([]interface{}, len(scope))
+	for i, tag := range scope {
+		args[i] = tag
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(scope)), ",")
+	return column + " IN (SELECT server_id FROM server_tags WHERE tag IN (" + placeholders + "))", args
+}
+
+// CanSeeServer reports whether the current user may access a server
+func CanSeeServer(c *fiber.Ctx, serverID string) bool {
+	clause, args := ServerVisibilityClause(c, "?")
+	if len(args) == 0 {
+		return true
+	}
+	var visible int
+	err := database.DB.QueryRow("SELECT 1 WHERE "+clause, append([]interface{}{serverID}, args...)...).Scan(&visible)
+	return err == nil
+}
+
+// RequireServerVisible rejects requests for a server (:id) outside the user's scope.
+// Hidden servers answer 404 so their existence is not revealed.
+func RequireServerVisible(c *fiber.Ctx) error {
+	if !CanSeeServer(c, c.Params("id")) {
+		return c.Status(404).JSON(fiber.Map{"error": "Server not found"})
+	}
+	return c.Next()
+}
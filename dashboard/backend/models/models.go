@@ -1,5 +1,7 @@
 package models
 
+import "encoding/json"
+
 // Server represents a monitored server
 type Server struct {
 	ID            string `json:"id"`
@@ -18,6 +20,101 @@ type Server struct {
     LogFilePath       string `json:"log_file_path"`
     LogFileTime       int64  `json:"log_file_time"`
     PendingUninstall  bool   `json:"pending_uninstall"`
+    Tags              []string `json:"tags"`
+    Environment       *HostEnvironment `json:"environment,omitempty"`
+    AgentState        *AgentStateStats `json:"agent_state,omitempty"`
+    AgentSelf         json.RawMessage  `json:"agent_self,omitempty"` // Agent self-metrics, passed through as reported
+    EBPFLevel         string           `json:"ebpf_level,omitempty"` // Zero Touch capability: full, basic or none
+    PackageID         string           `json:"package_id,omitempty"` // Install package that onboarded the server
+    Timezone          string           `json:"timezone,omitempty"`   // IANA time zone reported by the agent
+    Attestation       *AgentAttestation `json:"attestation,omitempty"` // Agent binary check against the published manifest
+    DiskMounts        []DiskMount      `json:"disk_mounts,omitempty"` // Filesystems from the last metrics push
+    AgentQueue        *AgentQueueStats `json:"agent_queue,omitempty"` // Agent offline queue from the last metrics push
+    Maintenance       *Maintenance     `json:"maintenance,omitempty"` // Active maintenance period
+}
+
+// Maintenance is a period in which alerts about a server are held back
+type Maintenance struct {
+	Until  int64  `json:"until"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// MaintenanceWindow is a recurring maintenance period such as a patch night
+// ("0 2 * * sun" for 180 minutes) covering the servers in its scope
+type MaintenanceWindow struct {
+	Name            string   `json:"name"`
+	Schedule        string   `json:"schedule"`           // Cron expression of the start
+	DurationMinutes int      `json:"duration_minutes"`
+	Timezone        string   `json:"timezone,omitempty"` // IANA name; "" = each server's time zone
+	Servers         []string `json:"servers,omitempty"`  // Scope: these servers...
+	Tags            []string `json:"tags,omitempty"`     // ...and servers with one of these tags (neither = all servers)
+}
+
+// Silence mutes the notifications matching all of its set matchers until it expires
+type Silence struct {
+	ID        int64  `json:"id"`
+	ServerID  string `json:"server_id,omitempty"`
+	Tag       string `json:"tag,omitempty"`
+	EventType string `json:"event_type,omitempty"`
+	Severity  string `json:"severity,omitempty"` // critical, warning or info (recoveries)
+	Comment   string `json:"comment,omitempty"`
+	CreatedBy string `json:"created_by,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// DiskMount is the usage of one filesystem as last reported by the agent
+type DiskMount struct {
+	Mount    string  `json:"mount"`
+	Device   string  `json:"device"`
+	FSType   string  `json:"fstype"`
+	TotalMB  int64   `json:"total_mb"`
+	UsedMB   int64   `json:"used_mb"`
+	Percent  float64 `json:"percent"`
+	Excluded bool    `json:"excluded,omitempty"` // Left out by the dashboard's current exclusions
+}
+
+// AgentStateStats reports the size and health of the agent's local state database
+type AgentStateStats struct {
+	SizeBytes     int64  `json:"size_bytes"`
+	SchemaVersion int    `json:"schema_version"`
+	Healthy       bool   `json:"healthy"`
+	Recovered     bool   `json:"recovered"`
+	Error         string `json:"error,omitempty"`
+}
+
+// AgentQueueStats describes the agent's offline resilience queue
+type AgentQueueStats struct {
+	Total            int            `json:"total"`
+	Bytes            int64          `json:"bytes"`
+	ByType           map[string]int `json:"by_type,omitempty"`
+	ByRetries        map[string]int `json:"by_retries,omitempty"` // retry_N -> items
+	OldestAgeSeconds int64          `json:"oldest_age_seconds,omitempty"`
+	ReportedAt       int64          `json:"reported_at"` // timestamp of the push that carried the stats
+}
+
+// Stuck reports whether items older than maxAge seconds are still queued
+// although the agent reached the dashboard
+func (q AgentQueueStats) Stuck(maxAge int64) bool {
+	return q.Total > 0 && q.OldestAgeSeconds >= maxAge
+}
+
+// AgentAttestation is the agent binary and config checksum reported at registration,
+// compared with the published agent manifest
+type AgentAttestation struct {
+	Status         string `json:"status"` // verified, mismatch, outdated, unknown (no published binary), unreported
+	Arch           string `json:"arch,omitempty"`
+	BinarySHA256   string `json:"binary_sha256,omitempty"`
+	ExpectedSHA256 string `json:"expected_sha256,omitempty"` // published hash, when it differs
+	ConfigSHA256   string `json:"config_sha256,omitempty"`
+	CheckedAt      int64  `json:"checked_at"`
+}
+
+// HostEnvironment is the runtime context detected by the agent
+type HostEnvironment struct {
+	Type      string `json:"type"` // bare-metal, kvm, vmware, wsl, lxc, docker, ...
+	Role      string `json:"role"` // host, guest or container
+	Container bool   `json:"container"`
 }
 
 // Metric represents system metrics at a point in time
@@ -37,6 +134,22 @@ type Metric struct {
 	Uptime       int64   `json:"uptime"`
 }
 
+// MetricPoint is one bucket of a metrics query: the aggregate of the samples
+// taken from Timestamp until the next point
+type MetricPoint struct {
+	Timestamp    int64   `json:"timestamp"`
+	Samples      int     `json:"samples"`
+	CPUPercent   float64 `json:"cpu_percent"`
+	MemTotalMB   float64 `json:"mem_total_mb"`
+	MemUsedMB    float64 `json:"mem_used_mb"`
+	DiskTotalGB  float64 `json:"disk_total_gb"`
+	DiskUsedGB   float64 `json:"disk_used_gb"`
+	LoadAvg1     float64 `json:"load_avg_1"`
+	LoadAvg5     float64 `json:"load_avg_5"`
+	LoadAvg15    float64 `json:"load_avg_15"`
+	ProcessCount float64 `json:"process_count"`
+}
+
 // Event represents a system event
 type Event struct {
 	ID        int64  `json:"id"`
@@ -46,6 +159,25 @@ type Event struct {
 	Severity  string `json:"severity"`
 	Message   string `json:"message"`
 	Details   string `json:"details,omitempty"`
+	Acknowledged bool `json:"acknowledged"`
+	AckedBy   string `json:"acked_by,omitempty"`
+	AckedAt   int64  `json:"acked_at,omitempty"`
+	IncidentKey string `json:"incident_key,omitempty"` // Set for events reporting a problem that can clear
+	State       string `json:"state,omitempty"`        // open or resolved, for those events
+	ResolvedAt  int64  `json:"resolved_at,omitempty"`
+	Occurrences int    `json:"occurrences"` // Times it happened, counting identical repeats
+	FirstSeen   int64  `json:"first_seen"`  // First and last of them
+	LastSeen    int64  `json:"last_seen"`
+	Comments    []EventComment `json:"comments,omitempty"` // Only with the event detail
+}
+
+// EventComment is a note attached to an event, such as what was done about it
+type EventComment struct {
+	ID        int64  `json:"id"`
+	EventID   int64  `json:"event_id"`
+	Username  string `json:"username,omitempty"`
+	Body      string `json:"body"`
+	CreatedAt int64  `json:"created_at"`
 }
 
 // User represents an admin user
@@ -55,6 +187,8 @@ type User struct {
 	PasswordHash string `json:"-"` // Never send password hash to client
 	CreatedAt    int64  `json:"created_at"`
 	PasswordChanged bool `json:"password_changed"`
+	Role         string `json:"role"` // admin | operator | viewer
+	AllowedTags  []string `json:"allowed_tags"` // Non-admins only see servers with one of these tags (empty = all)
 }
 
 // LoginRequest represents a login attempt
@@ -115,6 +249,20 @@ type AlertSettings struct {
 	SMTPPassword    string `json:"smtp_password"`
 	AlertsEnabled   bool   `json:"alerts_enabled"`
 	NotifyOnWarning bool   `json:"notify_on_warning"`
+	ProviderFailureMinutes int `json:"provider_failure_minutes"` // report a provider failing this long via the others (0 = off)
+	ProviderStatus  []NotificationProviderStatus `json:"provider_status,omitempty"` // read-only
+}
+
+// NotificationProviderStatus is the delivery health of one notification provider
+type NotificationProviderStatus struct {
+	Provider     string `json:"provider"` // slack, teams, discord, email
+	Name         string `json:"name"`
+	Configured   bool   `json:"configured"`
+	Healthy      bool   `json:"healthy"`
+	LastSuccess  int64  `json:"last_success,omitempty"`
+	LastFailure  int64  `json:"last_failure,omitempty"`
+	LastError    string `json:"last_error,omitempty"`
+	FailingSince int64  `json:"failing_since,omitempty"` // first failure since the last success
 }
 
 // AgentConfig represents the configuration sent to agents
@@ -122,6 +270,10 @@ type AgentConfig struct {
 	DriftIgnore    []string          `json:"drift_ignore"`
 	DriftPaths     []string          `json:"drift_paths"`
     DriftInterval  int               `json:"drift_interval"` // Seconds
+	Interval       int               `json:"interval,omitempty"` // Push interval set for this server in seconds (0 = the agent's config.yaml)
+    NetworkDriftEnabled bool         `json:"network_drift_enabled"` // Track listening ports as drift
+    EgressMonitorEnabled bool        `json:"egress_monitor_enabled"` // eBPF outbound connection monitoring
+    EgressAllow    []string          `json:"egress_allow"`           // [process@]address[:port] rules for expected destinations
     HealthEnabled  bool              `json:"health_enabled"` // Toggle health monitoring
     HealthSustainDuration int        `json:"health_sustain_duration"` // Seconds
     StabilityWindow int              `json:"stability_window"`        // Seconds to wait before resolving alerts
@@ -130,15 +282,47 @@ type AgentConfig struct {
 	CronAutoDiscover bool              `json:"cron_auto_discover"`
 	CronGlobalTimeout int               `json:"cron_global_timeout"`
 	CronTimeouts      map[string]int    `json:"cron_timeouts"`  // Command -> Timeout in seconds
+	CronAnomalyFactor float64           `json:"cron_anomaly_factor"` // Warn when a run takes this many times its median (0 = off, evaluated by the dashboard)
+	CronFailureThreshold int            `json:"cron_failure_threshold"` // Consecutive failures before a job raises an event (evaluated by the dashboard)
     CollectLogs       bool              `json:"collect_logs"`   // Command to collect logs
 	Thresholds       ResourceThresholds `json:"thresholds"`
+	DiskExcludeFSTypes []string         `json:"disk_exclude_fstypes"` // Filesystem types left out of disk metrics
+	DiskExcludePaths   []string         `json:"disk_exclude_paths"`   // Mount points (and below) left out of disk metrics
+	ThresholdProfile string             `json:"threshold_profile,omitempty"` // Profile the thresholds came from
+	Group          string            `json:"group,omitempty"`   // Server group whose overrides apply
+	Source         map[string]string `json:"source,omitempty"`  // Layer each overridable setting came from (global, group, profile, server)
 	OfflineTimeout int               `json:"offline_timeout"` // Seconds
     Uninstall      bool              `json:"uninstall"`       // Command to uninstall
+	Scripts        []AgentScript     `json:"scripts,omitempty"` // Pending script executions
+	TerminalSession string           `json:"terminal_session,omitempty"` // Remote terminal session to connect to
+	CronPauses     []AgentCronPause  `json:"cron_pauses,omitempty"` // Pending cron pause/resume changes
+	Remediations   []AgentRemediation `json:"remediations,omitempty"` // Pending restarts / process kills
+	DriftRebaseline bool             `json:"drift_rebaseline,omitempty"` // Re-baseline drift after acceptance
+	TargetVersion  string            `json:"target_version,omitempty"`   // Agent version assigned by a staged rollout (empty = follow the published version)
+}
+
+// ServerConfiguration holds per-server overrides, stored as JSON in servers.configuration
+type ServerConfiguration struct {
+	Drift            *DriftOverride `json:"drift,omitempty"`
+	ThresholdProfile int64          `json:"threshold_profile,omitempty"` // Assigned threshold profile ID (0 = none)
+	Interval         int            `json:"interval,omitempty"`          // Push interval in seconds (0 = the agent's config.yaml)
+	Overrides        *ConfigOverrides `json:"overrides,omitempty"`       // Thresholds and cron settings (drift lives in Drift)
+}
+
+// DriftOverride adjusts the global drift paths/ignores for one server.
+// Mode "merge" (default) adds to the global lists, "replace" substitutes them.
+type DriftOverride struct {
+	Mode   string   `json:"mode"`
+	Paths  []string `json:"paths"`
+	Ignore []string `json:"ignore"`
 }
 
 // JobRecord tracks the state of a specific cron job (mirrors Agent struct)
 type JobRecord struct {
 	Command      string `json:"Command"`
+	User         string `json:"User,omitempty"`
+	Schedule     string `json:"Schedule,omitempty"` // From crontab discovery
+	Source       string `json:"Source,omitempty"`
 	LastExecTime int64  `json:"LastExecTime"`
 	ActivePID    int32  `json:"ActivePID"`
 	StartTime    int64  `json:"StartTime"`
@@ -155,3 +339,281 @@ type ResourceThresholds struct {
 	DiskWarning     float64 `json:"disk_warning"`
 	DiskCritical    float64 `json:"disk_critical"`
 }
+
+// ThresholdProfile is a named set of resource thresholds (e.g. "database server")
+// assigned to servers directly or through their tags
+type ThresholdProfile struct {
+	ID          int64              `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Thresholds  ResourceThresholds `json:"thresholds"`
+	Tags        []string           `json:"tags"`
+	CreatedAt   int64              `json:"created_at"`
+}
+
+// ServerGroup is a named set of servers (e.g. "db-servers") whose
+// configuration overrides the global settings
+type ServerGroup struct {
+	ID          int64           `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Config      ConfigOverrides `json:"config"`
+	Servers     []string        `json:"servers"`
+	CreatedAt   int64           `json:"created_at"`
+}
+
+// ConfigOverrides holds agent settings that replace the inherited ones; unset
+// fields inherit. Cron ignores and timeouts are merged by command.
+type ConfigOverrides struct {
+	Thresholds        *ResourceThresholds `json:"thresholds,omitempty"`
+	Drift             *DriftOverride      `json:"drift,omitempty"`
+	CronEnabled       *bool               `json:"cron_enabled,omitempty"`
+	CronAutoDiscover  *bool               `json:"cron_auto_discover,omitempty"`
+	CronIgnore        map[string][]int    `json:"cron_ignore,omitempty"`
+	CronTimeouts      map[string]int      `json:"cron_timeouts,omitempty"`
+	CronGlobalTimeout *int                `json:"cron_global_timeout,omitempty"`
+}
+
+// AgentRollout assigns an agent version to a growing share of the fleet,
+// ordered by server tag groups
+type AgentRollout struct {
+	ID          int64           `json:"id"`
+	Version     string          `json:"version"`
+	Percentage  int             `json:"percentage"`   // Share of the servers in scope targeted so far
+	Groups      []string        `json:"groups"`       // Tags in rollout order; untagged servers come last
+	MaxFailures int             `json:"max_failures"` // Rollbacks that halt the rollout
+	Status      string          `json:"status"`       // active, paused, failed, completed, cancelled
+	CreatedBy   string          `json:"created_by"`
+	CreatedAt   int64           `json:"created_at"`
+	UpdatedAt   int64           `json:"updated_at"`
+	Progress    RolloutProgress `json:"progress"`
+	Servers     []RolloutServer `json:"servers,omitempty"`
+}
+
+// RolloutProgress counts the servers of a rollout by state
+type RolloutProgress struct {
+	Total    int `json:"total"`    // Servers in scope
+	Targeted int `json:"targeted"` // Servers given the target version
+	Pending  int `json:"pending"`
+	Updated  int `json:"updated"`
+	Failed   int `json:"failed"`
+}
+
+// RolloutServer is the state of one server in a rollout
+type RolloutServer struct {
+	ServerID     string `json:"server_id"`
+	Hostname     string `json:"hostname"`
+	Group        string `json:"group"` // Tag that placed the server in its stage (empty = untagged)
+	AgentVersion string `json:"agent_version"`
+	Status       string `json:"status"` // pending, updated, failed
+	TargetedAt   int64  `json:"targeted_at"`
+	UpdatedAt    int64  `json:"updated_at,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Script is an allow-listed script that can be run across the fleet
+type Script struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Content     string `json:"content"`
+	SHA256      string `json:"sha256"`
+	Timeout     int    `json:"timeout"` // Seconds
+	CreatedBy   string `json:"created_by"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+// ScriptRun is a scheduled execution of a script against a set of servers
+type ScriptRun struct {
+	ID          int64          `json:"id"`
+	ScriptID    int64          `json:"script_id"`
+	ScriptName  string         `json:"script_name"`
+	ScheduledAt int64          `json:"scheduled_at"`
+	CreatedBy   string         `json:"created_by"`
+	CreatedAt   int64          `json:"created_at"`
+	Total       int            `json:"total"`
+	Completed   int            `json:"completed"`
+	Failed      int            `json:"failed"`
+	Results     []ScriptResult `json:"results,omitempty"`
+}
+
+// ScriptResult is the per-host outcome of a script run
+type ScriptResult struct {
+	ID           int64  `json:"id"`
+	RunID        int64  `json:"run_id"`
+	ServerID     string `json:"server_id"`
+	Hostname     string `json:"hostname"`
+	Status       string `json:"status"` // pending, dispatched, completed
+	ExitCode     *int   `json:"exit_code"`
+	Output       string `json:"output"`
+	DispatchedAt *int64 `json:"dispatched_at"`
+	StartedAt    *int64 `json:"started_at"`
+	FinishedAt   *int64 `json:"finished_at"`
+}
+
+// AgentScript is a script execution delivered to an agent via its config poll
+type AgentScript struct {
+	ResultID int64  `json:"result_id"`
+	Name     string `json:"name"`
+	Content  string `json:"content"`
+	SHA256   string `json:"sha256"`
+	Timeout  int    `json:"timeout"`
+}
+
+// AuditEntry records a privileged action taken by a user
+type AuditEntry struct {
+	ID        int64  `json:"id"`
+	Timestamp int64  `json:"timestamp"`
+	Username  string `json:"username"`
+	Action    string `json:"action"`
+	Target    string `json:"target"`
+	Details   string `json:"details"`
+	IPAddress string `json:"ip_address"`
+}
+
+// TerminalSession is a recorded remote terminal session
+type TerminalSession struct {
+	ID        string `json:"id"`
+	ServerID  string `json:"server_id"`
+	Username  string `json:"username"`
+	StartedAt int64  `json:"started_at"`
+	EndedAt   int64  `json:"ended_at"` // 0 while active
+}
+
+// CronPause is the requested pause state of a cron job on a server
+type CronPause struct {
+	ServerID    string   `json:"server_id"`
+	Command     string   `json:"command"`
+	Paused      bool     `json:"paused"`
+	Status      string   `json:"status"` // pending, applied, failed
+	Error       string   `json:"error"`
+	Files       []string `json:"files"`
+	RequestedBy string   `json:"requested_by"`
+	RequestedAt int64    `json:"requested_at"`
+	AppliedAt   int64    `json:"applied_at"`
+}
+
+// CronRun is one finished run of a cron job, as reported by the agent
+type CronRun struct {
+	Command   string `json:"command"`
+	User      string `json:"user,omitempty"`
+	StartedAt int64  `json:"started_at"`
+	Duration  int64  `json:"duration"`
+	ExitCode  int    `json:"exit_code"`
+	Signal    int    `json:"signal,omitempty"`
+}
+
+// CronRunStats summarizes a job's runs over a period
+type CronRunStats struct {
+	Command     string  `json:"command"`
+	Runs        int     `json:"runs"`
+	Failures    int     `json:"failures"`
+	SuccessRate float64 `json:"success_rate"` // percent
+	AvgDuration float64 `json:"avg_duration"`
+	MaxDuration int64   `json:"max_duration"`
+	LastRun     int64   `json:"last_run"`
+	LastExit    int     `json:"last_exit_code"`
+}
+
+// AgentCronPause is a pause/resume request delivered to an agent
+type AgentCronPause struct {
+	Command string `json:"command"`
+	Paused  bool   `json:"paused"`
+}
+
+// Remediation is a scoped corrective action (restart unit / SIGTERM PID) run by the agent
+type Remediation struct {
+	ID          int64  `json:"id"`
+	ServerID    string `json:"server_id"`
+	Action      string `json:"action"` // restart_unit, kill_pid
+	Target      string `json:"target"`
+	Process     string `json:"process,omitempty"`
+	EventID     *int64 `json:"event_id"`
+	Status      string `json:"status"` // pending, dispatched, succeeded, failed
+	Output      string `json:"output"`
+	RequestedBy string `json:"requested_by"`
+	RequestedAt int64  `json:"requested_at"`
+	CompletedAt int64  `json:"completed_at"`
+}
+
+// AgentRemediation is a remediation command delivered to an agent
+type AgentRemediation struct {
+	ID      int64  `json:"id"`
+	Action  string `json:"action"`
+	Target  string `json:"target"`
+	Process string `json:"process,omitempty"`
+}
+
+// Package is an installed OS package reported by an agent
+type Package struct {
+	ServerID string `json:"server_id,omitempty"`
+	Hostname string `json:"hostname,omitempty"`
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Arch     string `json:"arch,omitempty"`
+}
+
+// ScrubReport summarizes what a data scrub removed or anonymized
+type ScrubReport struct {
+	Mode     string           `json:"mode"` // purge, anonymize
+	DryRun   bool             `json:"dry_run"`
+	ServerID string           `json:"server_id,omitempty"`
+	Pattern  string           `json:"pattern,omitempty"`
+	Tables   map[string]int64 `json:"tables"` // table -> rows deleted or modified
+	Files    []ScrubFile      `json:"files"`
+}
+
+// ScrubFile is a file touched by a data scrub
+type ScrubFile struct {
+	Path    string `json:"path"`
+	Action  string `json:"action"` // deleted, redacted, lines_removed, skipped
+	Matches int    `json:"matches,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// TicketIntegration creates issues in an external tracker from critical incidents
+type TicketIntegration struct {
+	ID             int64             `json:"id"`
+	Name           string            `json:"name"`
+	Kind           string            `json:"kind"`     // jira, gitlab, github
+	BaseURL        string            `json:"base_url"` // empty = gitlab.com / api.github.com
+	DefaultProject string            `json:"default_project"`
+	ProjectMap     map[string]string `json:"project_map"` // server tag -> project, first matching tag wins
+	User           string            `json:"user,omitempty"`  // Jira account email
+	Token          string            `json:"token,omitempty"` // write-only, stored encrypted
+	HasToken       bool              `json:"has_token"`
+	Enabled        bool              `json:"enabled"`
+	CreatedAt      int64             `json:"created_at"`
+}
+
+// Ticket is an issue opened for an incident (server health, a cron job, a port, ...)
+type Ticket struct {
+	ID            int64  `json:"id"`
+	IntegrationID int64  `json:"integration_id"`
+	ServerID      string `json:"server_id"`
+	Hostname      string `json:"hostname,omitempty"`
+	IncidentKey   string `json:"incident_key"` // health, cron:<command>, port:<name>, security
+	Project       string `json:"project"`
+	ExternalID    string `json:"external_id"` // PROJ-12, or the issue number
+	URL           string `json:"url"`
+	Status        string `json:"status"` // open, closed
+	Error         string `json:"error,omitempty"`
+	CreatedAt     int64  `json:"created_at"`
+	UpdatedAt     int64  `json:"updated_at"`
+}
+
+// Heartbeat is a deadman check: a job pings its URL and the dashboard raises a
+// critical event once a ping is overdue
+type Heartbeat struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Token     string `json:"token,omitempty"`     // secret part of the ping URL, admins only
+	ServerID  string `json:"server_id,omitempty"` // optional: events are logged on this server
+	Hostname  string `json:"hostname,omitempty"`
+	Interval  int    `json:"interval"` // seconds between expected pings
+	Grace     int    `json:"grace"`    // extra seconds before the heartbeat is overdue
+	Status    string `json:"status"`   // new, up, down
+	LastPing  int64  `json:"last_ping,omitempty"`
+	DownSince int64  `json:"down_since,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+}
@@ -0,0 +1,138 @@
+package notifications
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// DefaultFailureMinutes is how long a provider may keep failing before the
+// failure is reported through the remaining channels
+const DefaultFailureMinutes = 15
+
+// FailureMinutes returns the configured provider_failure_minutes (0 = reporting off)
+func FailureMinutes() int {
+	minutes := DefaultFailureMinutes
+	var val string
+	if err := database.GetSetting("provider_failure_minutes", &val); err == nil {
+		fmt.Sscanf(val, "%d", &minutes)
+	}
+	return minutes
+}
+
+// RecordDelivery stores the outcome of a send. A success clears the failure streak.
+func RecordDelivery(provider string, err error) {
+	if database.DB == nil {
+		return
+	}
+	now := time.Now().Unix()
+	var dbErr error
+	if err == nil {
+		_, dbErr = database.DB.Exec(`
+			INSERT INTO notification_status (provider, last_success) VALUES (?, ?)
+			ON CONFLICT(provider) DO UPDATE SET last_success = excluded.last_success, failing_since = NULL, alerted_at = NULL
+		`, provider, now)
+	} else {
+		_, dbErr = database.DB.Exec(`
+			INSERT INTO notification_status (provider, last_failure, last_error, failing_since) VALUES (?, ?, ?, ?)
+			ON CONFLICT(provider) DO UPDATE SET
+				last_failure = excluded.last_failure,
+				last_error = excluded.last_error,
+				failing_since = COALESCE(failing_since, excluded.failing_since)
+		`, provider, now, err.Error(), now)
+	}
+	if dbErr != nil {
+		log.Printf("Warning: Failed to record %s delivery status: %v", provider, dbErr)
+	}
+}
+
+// ProviderStatuses returns the delivery health of every known provider
+func ProviderStatuses(s Service) []models.NotificationProviderStatus {
+	providers := s.Providers()
+	statuses := make([]models.NotificationProviderStatus, 0, len(ProviderKeys))
+	for _, key := range ProviderKeys {
+		st := models.NotificationProviderStatus{Provider: key, Name: providerNames[key], Healthy: true}
+		if _, ok := providers[key]; ok {
+			st.Configured = true
+		}
+		var lastSuccess, lastFailure, failingSince sql.NullInt64
+		var lastError sql.NullString
+		if err := database.DB.QueryRow(`
+			SELECT last_success, last_failure, last_error, failing_since FROM notification_status WHERE provider = ?
+		`, key).Scan(&lastSuccess, &lastFailure, &lastError, &failingSince); err == nil {
+			st.LastSuccess, st.LastFailure, st.LastError = lastSuccess.Int64, lastFailure.Int64, lastError.String
+			st.FailingSince = failingSince.Int64
+			st.Healthy = !failingSince.Valid
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+// providerNames mirrors Provider.Name() for providers that are not configured
+var providerNames = map[string]string{
+	"slack":   "Slack",
+	"teams":   "Microsoft Teams",
+	"discord": "Discord",
+	"email":   "Email",
+}
+
+// CheckProviderHealth reports providers that have been failing for longer than
+// failAfter through the providers that are still delivering. Each failure streak
+// is reported once; nothing is sent while alerts are disabled.
+func (s *notificationService) CheckProviderHealth(failAfter time.Duration) {
+	if !s.settings.AlertsEnabled {
+		return
+	}
+	providers := s.Providers()
+	statuses := ProviderStatuses(s)
+
+	var broken []models.NotificationProviderStatus
+	var working []string
+	cutoff := time.Now().Add(-failAfter).Unix()
+	for _, st := range statuses {
+		if !st.Configured {
+			continue
+		}
+		if st.Healthy {
+			working = append(working, st.Provider)
+			continue
+		}
+		var alertedAt sql.NullInt64
+		database.DB.QueryRow("SELECT alerted_at FROM notification_status WHERE provider = ?", st.Provider).Scan(&alertedAt)
+		if st.FailingSince <= cutoff && !alertedAt.Valid {
+			broken = append(broken, st)
+		}
+	}
+	if len(broken) == 0 || len(working) == 0 {
+		return // nothing to report, or no channel left to report it through
+	}
+
+	for _, st := range broken {
+		n := Notification{
+			Subject: fmt.Sprintf("[WARNING] Notification Channel Failing: %s", st.Name),
+			Message: fmt.Sprintf("%s notifications have been failing since %s. Last error: %s",
+				st.Name, time.Unix(st.FailingSince, 0).UTC().Format("2006-01-02 15:04:05 UTC"), st.LastError),
+			Type: TypeWarning,
+		}
+		var delivered []string
+		for _, key := range working {
+			err := providers[key].Send(n)
+			RecordDelivery(key, err)
+			if err != nil {
+				log.Printf("Error sending %s notification: %v", key, err)
+				continue
+			}
+			delivered = append(delivered, key)
+		}
+		if len(delivered) > 0 {
+			database.DB.Exec("UPDATE notification_status SET alerted_at = ? WHERE provider = ?", time.Now().Unix(), st.Provider)
+			log.Printf("⚠️  Reported failing %s notifications via %s", st.Provider, strings.Join(delivered, ", "))
+		}
+	}
+}
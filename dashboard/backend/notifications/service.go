@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
     "strings"
+
+	"github.com/yourusername/health-dashboard-backend/health"
 )
 
 type notificationService struct {
@@ -20,11 +22,39 @@ func (s *notificationService) UpdateSettings(settings Settings) {
 	s.settings = settings
 }
 
+// Providers returns the configured providers keyed by ProviderKeys entry
+func (s *notificationService) Providers() map[string]Provider {
+	providers := make(map[string]Provider)
+	if s.settings.SlackWebhookURL != "" {
+		providers["slack"] = NewSlackProvider(s.settings.SlackWebhookURL)
+	}
+	if s.settings.TeamsWebhookURL != "" {
+		providers["teams"] = NewTeamsProvider(s.settings.TeamsWebhookURL)
+	}
+	if s.settings.DiscordWebhookURL != "" {
+		providers["discord"] = NewDiscordProvider(s.settings.DiscordWebhookURL)
+	}
+	if s.settings.SMTPServer != "" && len(s.settings.EmailRecipients) > 0 {
+		providers["email"] = NewEmailProvider(s.settings.SMTPServer, s.settings.SMTPPort, s.settings.SMTPUser, s.settings.SMTPPassword, s.settings.EmailRecipients)
+	}
+	return providers
+}
+
 func (s *notificationService) Notify(n Notification) error {
 	if !s.settings.AlertsEnabled {
 		return nil
 	}
 
+	// Alerts about a server in maintenance are held back
+	if n.ServerID != "" && health.InMaintenance(n.ServerID) {
+		log.Printf("🔧 Notification held back, %s is in maintenance: %s", n.ServerID, n.Subject)
+		return nil
+	}
+	if id, ok := health.Silenced(n.ServerID, n.EventType, n.Severity()); ok {
+		log.Printf("🔇 Notification silenced by silence #%d: %s", id, n.Subject)
+		return nil
+	}
+
 	// Filter based on severity if required
 	if !s.settings.NotifyOnWarning && n.Type == TypeWarning {
 		return nil
@@ -38,39 +68,16 @@ func (s *notificationService) Notify(n Notification) error {
 	}
 
 	var errs []error
-
-	// Slack
-	if s.settings.SlackWebhookURL != "" {
-		slack := NewSlackProvider(s.settings.SlackWebhookURL)
-		if err := slack.Send(n); err != nil {
-			log.Printf("Error sending slack notification: %v", err)
-			errs = append(errs, err)
-		}
-	}
-
-	// MS Teams
-	if s.settings.TeamsWebhookURL != "" {
-		teams := NewTeamsProvider(s.settings.TeamsWebhookURL)
-		if err := teams.Send(n); err != nil {
-			log.Printf("Error sending teams notification: %v", err)
-			errs = append(errs, err)
+	providers := s.Providers()
+	for _, key := range ProviderKeys {
+		p, ok := providers[key]
+		if !ok {
+			continue
 		}
-	}
-
-    // Discord
-    if s.settings.DiscordWebhookURL != "" {
-        discord := NewDiscordProvider(s.settings.DiscordWebhookURL)
-        if err := discord.Send(n); err != nil {
-            log.Printf("Error sending discord notification: %v", err)
-            errs = append(errs, err)
-        }
-    }
-
-	// Email
-	if s.settings.SMTPServer != "" && len(s.settings.EmailRecipients) > 0 {
-		email := NewEmailProvider(s.settings.SMTPServer, s.settings.SMTPPort, s.settings.SMTPUser, s.settings.SMTPPassword, s.settings.EmailRecipients)
-		if err := email.Send(n); err != nil {
-			log.Printf("Error sending email notification: %v", err)
+		err := p.Send(n)
+		RecordDelivery(key, err)
+		if err != nil {
+			log.Printf("Error sending %s notification: %v", key, err)
 			errs = append(errs, err)
 		}
 	}
@@ -1,5 +1,7 @@
 package notifications
 
+import "time"
+
 type NotificationType string
 
 const (
@@ -10,9 +12,22 @@ const (
 )
 
 type Notification struct {
-	Subject string
-	Message string
-	Type    NotificationType
+	Subject   string
+	Message   string
+	Type      NotificationType
+	ServerID  string // Server the alert is about ("" = not server specific)
+	EventType string // Event type the alert is about, matched by silences
+}
+
+// Severity is the event severity of the notification, as matched by silences
+func (n Notification) Severity() string {
+	switch n.Type {
+	case TypeCritical:
+		return "critical"
+	case TypeWarning:
+		return "warning"
+	}
+	return "info"
 }
 
 type Provider interface {
@@ -20,9 +35,16 @@ type Provider interface {
 	Name() string
 }
 
+// ProviderKeys lists the provider identifiers in send order
+var ProviderKeys = []string{"slack", "teams", "discord", "email"}
+
 type Service interface {
 	Notify(n Notification) error
 	UpdateSettings(settings Settings)
+	// Providers returns the configured providers, ignoring the alert filters
+	Providers() map[string]Provider
+	// CheckProviderHealth reports providers failing for longer than failAfter
+	CheckProviderHealth(failAfter time.Duration)
 }
 
 type Settings struct {
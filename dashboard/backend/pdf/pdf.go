@@ -0,0 +1,130 @@
+// Package pdf writes plain, text-only PDF documents for report exports: a
+// title on every page and monospaced lines, paginated on A4 landscape. It uses
+// the standard Courier and Helvetica fonts, so nothing is embedded.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// A4 landscape in points, and the text layout within it
+const (
+	pageWidth   = 842
+	pageHeight  = 595
+	margin      = 36
+	fontSize    = 8
+	leading     = 10
+	titleSize   = 12
+	headerSpace = 28
+	// Courier glyphs are 0.6 em wide
+	maxChars     = (pageWidth - 2*margin) * 10 / (fontSize * 6)
+	linesPerPage = (pageHeight - 2*margin - headerSpace) / leading
+)
+
+// Document collects lines of text and renders them as a PDF
+type Document struct {
+	title string
+	lines []string
+}
+
+// New starts a document whose title is repeated at the top of every page
+func New(title string) *Document {
+	return &Document{title: title}
+}
+
+// Line appends a line of text; lines wider than the page are wrapped
+func (d *Document) Line(text string) {
+	text = sanitize(text)
+	for len(text) > maxChars {
+		d.lines = append(d.lines, text[:maxChars])
+		text = "  " + text[maxChars:]
+	}
+	d.lines = append(d.lines, text)
+}
+
+// Lines appends several lines
+func (d *Document) Lines(lines ...string) {
+	for _, l := range lines {
+		d.Line(l)
+	}
+}
+
+// Pages returns the number of pages the document renders to
+func (d *Document) Pages() int {
+	if len(d.lines) == 0 {
+		return 1
+	}
+	return (len(d.lines) + linesPerPage - 1) / linesPerPage
+}
+
+// Bytes renders the document
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	pages := d.Pages()
+	// Objects: 1 catalog, 2 page tree, 3-4 fonts, then a page and its content stream per page
+	kids := make([]string, pages)
+	for i := range kids {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+
+	buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pages))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	for p := 0; p < pages; p++ {
+		start := p * linesPerPage
+		end := start + linesPerPage
+		if end > len(d.lines) {
+			end = len(d.lines)
+		}
+
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT /F2 %d Tf %d %d Td (%s) Tj ET\n", titleSize, margin, pageHeight-margin-titleSize, escape(sanitize(d.title)))
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d %d Td (%s) Tj ET\n", fontSize, pageWidth-margin-80, pageHeight-margin-titleSize, escape(fmt.Sprintf("Page %d of %d", p+1, pages)))
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d TL %d %d Td\n", fontSize, leading, margin, pageHeight-margin-headerSpace-fontSize)
+		for _, line := range d.lines[start:end] {
+			fmt.Fprintf(&content, "(%s) Tj T*\n", escape(line))
+		}
+		content.WriteString("ET\n")
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 6+2*p))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+// sanitize keeps printable ASCII; the standard fonts cannot show other runes
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == '\t':
+			return ' '
+		case r < 0x20 || r > 0x7e:
+			return '?'
+		}
+		return r
+	}, s)
+}
+
+// escape quotes a string for a PDF literal
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`).Replace(s)
+}
@@ -0,0 +1,55 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDocumentStructure(t *testing.T) {
+	doc := New("Drift Report (2026-09)")
+	for i := 0; i < linesPerPage+5; i++ {
+		doc.Line(fmt.Sprintf("line %d", i))
+	}
+	if doc.Pages() != 2 {
+		t.Fatalf("expected 2 pages, got %d", doc.Pages())
+	}
+
+	out := doc.Bytes()
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or trailer")
+	}
+	if !bytes.Contains(out, []byte("/Count 2")) {
+		t.Error("page tree should count 2 pages")
+	}
+	if !bytes.Contains(out, []byte(`(Drift Report \(2026-09\)) Tj`)) {
+		t.Error("title parentheses should be escaped")
+	}
+
+	// Every xref offset points at its object
+	xref := bytes.Index(out, []byte("\nxref\n")) + 1
+	lines := strings.Split(string(out[xref:]), "\n")
+	for i, entry := range lines[3 : 3+2+2+2*doc.Pages()] {
+		var off int
+		fmt.Sscanf(entry, "%d", &off)
+		if want := fmt.Sprintf("%d 0 obj", i+1); !bytes.HasPrefix(out[off:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, out[off:off+10])
+		}
+	}
+}
+
+func TestLineWrapAndSanitize(t *testing.T) {
+	doc := New("t")
+	doc.Line(strings.Repeat("x", maxChars+10))
+	doc.Line("café\tbar")
+	if len(doc.lines) != 3 {
+		t.Fatalf("expected the long line to wrap, got %d lines", len(doc.lines))
+	}
+	if doc.lines[2] != "caf? bar" {
+		t.Errorf("unexpected sanitized line %q", doc.lines[2])
+	}
+	if empty := New("empty"); empty.Pages() != 1 || !bytes.Contains(empty.Bytes(), []byte("/Count 1")) {
+		t.Error("an empty document should still render one page")
+	}
+}
@@ -0,0 +1,84 @@
+// Package push wakes agents waiting on the config stream when their
+// configuration or pending commands change, so they do not have to wait for
+// the next poll.
+package push
+
+import (
+	"sync"
+	"time"
+)
+
+var (
+	mu      sync.Mutex
+	seq     int64                    // bumped by every notification
+	global  int64                    // seq of the last NotifyAll
+	servers = make(map[string]int64) // seq of the last Notify per server
+	changed = make(chan struct{})    // closed and replaced on every notification
+)
+
+// Notify marks the configuration of one server as changed
+func Notify(serverID string) {
+	mu.Lock()
+	defer mu.Unlock()
+	seq++
+	servers[serverID] = seq
+	wake()
+}
+
+// NotifyAll marks the configuration of every server as changed
+func NotifyAll() {
+	mu.Lock()
+	defer mu.Unlock()
+	seq++
+	global = seq
+	wake()
+}
+
+// Forget drops the state kept for a deleted server
+func Forget(serverID string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(servers, serverID)
+}
+
+// Seq returns the current sequence number, the starting point for Wait
+func Seq() int64 {
+	mu.Lock()
+	defer mu.Unlock()
+	return seq
+}
+
+// wake releases all waiters; callers hold mu
+func wake() {
+	close(changed)
+	changed = make(chan struct{})
+}
+
+// Wait blocks until serverID has a change newer than since, wait elapses or
+// done is closed. It returns the sequence to pass next time and whether there
+// was a change. A since ahead of the current sequence means the dashboard
+// restarted in between, which counts as a change.
+func Wait(serverID string, since int64, wait time.Duration, done <-chan struct{}) (int64, bool) {
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	for {
+		mu.Lock()
+		current, last := seq, servers[serverID]
+		if global > last {
+			last = global
+		}
+		ch := changed
+		mu.Unlock()
+
+		if last > since || since > current {
+			return current, true
+		}
+		select {
+		case <-ch:
+		case <-deadline.C:
+			return current, false
+		case <-done:
+			return current, false
+		}
+	}
+}
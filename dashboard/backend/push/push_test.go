@@ -0,0 +1,49 @@
+package push
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWaitWakesOnNotify(t *testing.T) {
+	since := Seq()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		Notify("other")
+		time.Sleep(20 * time.Millisecond)
+		Notify("srv-1")
+	}()
+
+	start := time.Now()
+	seq, changed := Wait("srv-1", since, 5*time.Second, nil)
+	if !changed || seq != since+2 {
+		t.Fatalf("got seq=%d changed=%v, want seq=%d changed=true", seq, changed, since+2)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Error("Wait was not woken by Notify")
+	}
+
+	// Nothing new for this server: times out with the same seq
+	if seq2, changed := Wait("srv-1", seq, 30*time.Millisecond, nil); changed || seq2 != seq {
+		t.Errorf("got seq=%d changed=%v, want seq=%d changed=false", seq2, changed, seq)
+	}
+}
+
+func TestWaitNotifyAllAndRestart(t *testing.T) {
+	since := Seq()
+	NotifyAll()
+	if _, changed := Wait("srv-2", since, time.Second, nil); !changed {
+		t.Error("NotifyAll must wake every server")
+	}
+
+	// An agent holding a seq from before a dashboard restart refetches
+	if _, changed := Wait("srv-2", Seq()+100, time.Second, nil); !changed {
+		t.Error("a seq ahead of the dashboard must count as a change")
+	}
+
+	done := make(chan struct{})
+	close(done)
+	if _, changed := Wait("srv-2", Seq(), time.Minute, done); changed {
+		t.Error("closed done must end the wait without a change")
+	}
+}
@@ -0,0 +1,61 @@
+// Package schedule provides time-zone aware scheduling primitives: IANA time
+// zone lookup, the per-server time zone reported by agents, recurring windows
+// ("Mon-Fri 02:00 for 2h") and cron expressions. Times are evaluated on the
+// wall clock of a location, so "nightly at 02:00" means 02:00 server local time.
+package schedule
+
+import (
+	"errors"
+	"sync"
+	"time"
+	_ "time/tzdata" // embedded IANA database, containers often lack /usr/share/zoneinfo
+
+	"github.com/yourusername/health-dashboard-backend/database"
+)
+
+var errLocal = errors.New(`"Local" is not a valid time zone, use an IANA name like "Europe/Berlin"`)
+
+var (
+	locationsMu sync.Mutex
+	locations   = map[string]*time.Location{}
+)
+
+// LoadLocation resolves an IANA time zone name such as "Europe/Berlin".
+// "" and "UTC" return UTC; "Local" is rejected since it depends on the dashboard host.
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || name == "UTC" {
+		return time.UTC, nil
+	}
+	if name == "Local" {
+		return nil, errLocal
+	}
+
+	locationsMu.Lock()
+	defer locationsMu.Unlock()
+	if loc, ok := locations[name]; ok {
+		return loc, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations[name] = loc
+	return loc, nil
+}
+
+// ValidTimezone reports whether name is a known IANA time zone
+func ValidTimezone(name string) bool {
+	_, err := LoadLocation(name)
+	return err == nil
+}
+
+// ServerLocation returns the time zone reported by a server's agent, UTC if unknown
+func ServerLocation(serverID string) *time.Location {
+	var name string
+	database.DB.QueryRow("SELECT COALESCE(timezone, '') FROM servers WHERE id = ?", serverID).Scan(&name)
+	loc, err := LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
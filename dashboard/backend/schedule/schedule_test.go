@@ -0,0 +1,151 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	loc, err := LoadLocation(name)
+	if err != nil {
+		t.Fatalf("LoadLocation(%q): %v", name, err)
+	}
+	return loc
+}
+
+// Test cron expressions against wall-clock times, including DST transitions
+func TestCronNext(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+
+	tests := []struct {
+		name     string
+		expr     string
+		after    time.Time
+		expected time.Time
+	}{
+		{
+			name:     "Nightly in server local time",
+			expr:     "0 2 * * *",
+			after:    time.Date(2024, 6, 10, 12, 0, 0, 0, berlin),
+			expected: time.Date(2024, 6, 11, 2, 0, 0, 0, berlin),
+		},
+		{
+			name:     "Step and range",
+			expr:     "*/15 9-17 * * mon-fri",
+			after:    time.Date(2024, 6, 14, 17, 50, 0, 0, berlin), // Friday
+			expected: time.Date(2024, 6, 17, 9, 0, 0, 0, berlin),
+		},
+		{
+			name:     "Day of month or day of week",
+			expr:     "0 0 1 * sun",
+			after:    time.Date(2024, 6, 10, 0, 0, 0, 0, berlin), // Monday
+			expected: time.Date(2024, 6, 16, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:     "Macro",
+			expr:     "@monthly",
+			after:    time.Date(2024, 6, 10, 0, 0, 0, 0, berlin),
+			expected: time.Date(2024, 7, 1, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:     "Run in DST gap is skipped",
+			expr:     "30 2 * * *",
+			after:    time.Date(2024, 3, 30, 12, 0, 0, 0, berlin),
+			expected: time.Date(2024, 4, 1, 2, 30, 0, 0, berlin),
+		},
+		{
+			name:     "Run in repeated hour fires once",
+			expr:     "30 2 * * *",
+			after:    time.Date(2024, 10, 27, 2, 30, 0, 0, berlin),
+			expected: time.Date(2024, 10, 28, 2, 30, 0, 0, berlin),
+		},
+		{
+			name:     "Sunday as 7",
+			expr:     "0 3 * * 7",
+			after:    time.Date(2024, 6, 10, 0, 0, 0, 0, berlin),
+			expected: time.Date(2024, 6, 16, 3, 0, 0, 0, berlin),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			if got := c.Next(tt.after, berlin); !got.Equal(tt.expected) {
+				t.Errorf("Next() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// Test invalid cron expressions are rejected
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "* * * foo *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, expected error", expr)
+		}
+	}
+
+	c, err := ParseCron("@reboot")
+	if err != nil || !c.Next(time.Now(), nil).IsZero() {
+		t.Errorf("@reboot should parse and never have a next run")
+	}
+}
+
+// Test the run window containing a time, e.g. a Sunday 02:00 patch night of 3 hours
+func TestCronCovering(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	c, err := ParseCron("0 2 * * sun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := 3 * time.Hour
+
+	start, ok := c.Covering(time.Date(2024, 6, 16, 4, 59, 0, 0, berlin), d, berlin)
+	if !ok || !start.Equal(time.Date(2024, 6, 16, 2, 0, 0, 0, berlin)) {
+		t.Errorf("Covering at 04:59 = %v, %v; expected the 02:00 run", start, ok)
+	}
+	if _, ok := c.Covering(time.Date(2024, 6, 16, 5, 0, 0, 0, berlin), d, berlin); ok {
+		t.Errorf("window should have ended at 05:00")
+	}
+	if _, ok := c.Covering(time.Date(2024, 6, 16, 1, 59, 0, 0, berlin), d, berlin); ok {
+		t.Errorf("window should not have started at 01:59")
+	}
+}
+
+// Test recurring windows, including ones crossing midnight
+func TestWindow(t *testing.T) {
+	ny := mustLocation(t, "America/New_York")
+	quiet := Window{Start: "22:00", DurationMinutes: 9 * 60, Timezone: "America/New_York"}
+	sunday := Window{Days: []string{"sun"}, Start: "02:00", DurationMinutes: 120}
+
+	if err := quiet.Validate(); err != nil {
+		t.Fatalf("Validate: %v", err)
+	}
+	for _, w := range []Window{{Start: "2:00", DurationMinutes: 60}, {Start: "24:00", DurationMinutes: 60}, {Start: "02:00"}, {Start: "02:00", DurationMinutes: 60, Days: []string{"someday"}}, {Start: "02:00", DurationMinutes: 60, Timezone: "Mars/Olympus"}} {
+		if w.Validate() == nil {
+			t.Errorf("Validate(%+v) succeeded, expected error", w)
+		}
+	}
+
+	// The window's own time zone wins over the fallback
+	if !quiet.Active(time.Date(2024, 6, 11, 6, 0, 0, 0, ny), time.UTC) {
+		t.Errorf("quiet hours should be active at 06:00 New York time")
+	}
+	if quiet.Active(time.Date(2024, 6, 11, 7, 0, 0, 0, ny), time.UTC) {
+		t.Errorf("quiet hours should have ended at 07:00 New York time")
+	}
+
+	// Without one, the fallback (server) time zone applies
+	if !sunday.Active(time.Date(2024, 6, 16, 3, 0, 0, 0, ny), ny) {
+		t.Errorf("Sunday window should be active at 03:00 in the server time zone")
+	}
+	if sunday.Active(time.Date(2024, 6, 16, 3, 0, 0, 0, ny), time.UTC) {
+		t.Errorf("Sunday window should not be active at 07:00 UTC")
+	}
+	if next := sunday.Next(time.Date(2024, 6, 16, 3, 0, 0, 0, ny), ny); !next.Equal(time.Date(2024, 6, 23, 2, 0, 0, 0, ny)) {
+		t.Errorf("Next() = %v, expected the following Sunday 02:00", next)
+	}
+}
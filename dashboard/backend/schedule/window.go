@@ -0,0 +1,135 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxWindowDuration bounds windows so Active only has to look back a week
+const maxWindowDuration = 7 * 24 * time.Hour
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Window is a recurring time window, e.g. maintenance every Sunday 02:00-04:00
+// or quiet hours 22:00-07:00 daily. Start is local wall-clock time in Timezone.
+type Window struct {
+	Days            []string `json:"days,omitempty"`     // "mon".."sun"; empty = every day
+	Start           string   `json:"start"`              // "HH:MM"
+	DurationMinutes int      `json:"duration_minutes"`   // may cross midnight
+	Timezone        string   `json:"timezone,omitempty"` // IANA name; "" = the server's time zone
+}
+
+// Validate checks the window fields
+func (w Window) Validate() error {
+	if _, _, err := w.startClock(); err != nil {
+		return err
+	}
+	for _, d := range w.Days {
+		if _, ok := weekdays[strings.ToLower(d)]; !ok {
+			return fmt.Errorf("invalid day %q (use mon, tue, ... sun)", d)
+		}
+	}
+	if w.DurationMinutes <= 0 || time.Duration(w.DurationMinutes)*time.Minute > maxWindowDuration {
+		return fmt.Errorf("duration must be between 1 minute and 7 days")
+	}
+	if _, err := LoadLocation(w.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone %q", w.Timezone)
+	}
+	return nil
+}
+
+// Location returns the window's time zone, or fallback (usually ServerLocation) when unset
+func (w Window) Location(fallback *time.Location) *time.Location {
+	if w.Timezone != "" {
+		if loc, err := LoadLocation(w.Timezone); err == nil {
+			return loc
+		}
+	}
+	if fallback == nil {
+		return time.UTC
+	}
+	return fallback
+}
+
+// Active reports whether t falls inside an occurrence of the window
+func (w Window) Active(t time.Time, fallback *time.Location) bool {
+	_, end, ok := w.occurrenceAt(t, fallback)
+	return ok && t.Before(end)
+}
+
+// Current returns the bounds of the occurrence containing t
+func (w Window) Current(t time.Time, fallback *time.Location) (start, end time.Time, ok bool) {
+	start, end, ok = w.occurrenceAt(t, fallback)
+	if !ok || !t.Before(end) {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// Next returns the first occurrence starting strictly after t (zero if the window is invalid)
+func (w Window) Next(t time.Time, fallback *time.Location) time.Time {
+	loc := w.Location(fallback)
+	local := t.In(loc)
+	for i := 0; i <= 8; i++ {
+		start, ok := w.startOn(local.Year(), local.Month(), local.Day()+i, loc)
+		if ok && start.After(t) {
+			return start
+		}
+	}
+	return time.Time{}
+}
+
+// occurrenceAt finds the latest occurrence starting at or before t
+func (w Window) occurrenceAt(t time.Time, fallback *time.Location) (start, end time.Time, ok bool) {
+	loc := w.Location(fallback)
+	local := t.In(loc)
+	dur := time.Duration(w.DurationMinutes) * time.Minute
+	for i := 0; i <= 8; i++ {
+		s, ok := w.startOn(local.Year(), local.Month(), local.Day()-i, loc)
+		if ok && !s.After(t) {
+			return s, s.Add(dur), true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// startOn returns the start of the occurrence on the given local date, if the
+// window runs that day. A start inside a DST gap is moved forward by the gap
+// (02:30 on a spring-forward night becomes 03:30).
+func (w Window) startOn(year int, month time.Month, day int, loc *time.Location) (time.Time, bool) {
+	hour, minute, err := w.startClock()
+	if err != nil {
+		return time.Time{}, false
+	}
+	date := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	if !w.runsOn(date.Weekday()) {
+		return time.Time{}, false
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), hour, minute, 0, 0, loc), true
+}
+
+func (w Window) runsOn(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if wd, ok := weekdays[strings.ToLower(d)]; ok && wd == day {
+			return true
+		}
+	}
+	return false
+}
+
+func (w Window) startClock() (hour, minute int, err error) {
+	if _, err := fmt.Sscanf(w.Start, "%d:%d", &hour, &minute); err != nil || len(w.Start) != 5 {
+		return 0, 0, fmt.Errorf("invalid start time %q (use HH:MM)", w.Start)
+	}
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid start time %q (use HH:MM)", w.Start)
+	}
+	return hour, minute, nil
+}
@@ -0,0 +1,112 @@
+// Package secrets seals the secrets the dashboard stores in its database
+// with a key kept next to it.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// secretKey encrypts the secrets stored in the database (ticket integration
+// credentials, agent signing keys). It is kept outside the database so a
+// leaked database file does not expose them.
+var secretKey []byte
+
+// InitKey loads the secrets key from SECRETS_KEY (64 hex characters) or
+// from secrets.key in dir, generating the file on first start
+func InitKey(dir string) error {
+	if env := os.Getenv("SECRETS_KEY"); env != "" {
+		key, err := hex.DecodeString(strings.TrimSpace(env))
+		if err != nil || len(key) != 32 {
+			return fmt.Errorf("SECRETS_KEY must be 64 hex characters (32 bytes)")
+		}
+		secretKey = key
+		return nil
+	}
+
+	path := filepath.Join(dir, "secrets.key")
+	if data, err := os.ReadFile(path); err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != 32 {
+			return fmt.Errorf("invalid key in %s", path)
+		}
+		secretKey = key
+		return nil
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("failed to generate secrets key: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	secretKey = key
+	log.Printf("🔑 Generated secrets key at %s (back it up with the database)", path)
+	return nil
+}
+
+// Seal encrypts a secret with the secrets key using AES-256-GCM (nonce
+// prepended, base64), for secrets stored in the database
+func Seal(plaintext []byte) (string, error) {
+	if secretKey == nil {
+		return "", errors.New("secrets key not initialized")
+	}
+	block, err := aes.NewCipher(secretKey)
+	if err != nil {
+		return "", err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// Unseal decrypts a secret sealed by Seal
+func Unseal(sealed string) ([]byte, error) {
+	if secretKey == nil {
+		return nil, errors.New("secrets key not initialized")
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(secretKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("sealed value too short")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt (secrets key changed?): %w", err)
+	}
+	return plaintext, nil
+}
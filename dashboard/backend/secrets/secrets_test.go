@@ -0,0 +1,69 @@
+package secrets
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeyFileRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("SECRETS_KEY", "")
+	if err := InitKey(dir); err != nil {
+		t.Fatalf("InitKey failed: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "secrets.key"))
+	if err != nil {
+		t.Fatalf("key file not created: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("key file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	sealed, err := Seal([]byte("glpat-secret"))
+	if err != nil {
+		t.Fatalf("seal failed: %v", err)
+	}
+	if strings.Contains(sealed, "glpat-secret") {
+		t.Fatal("secret stored in plaintext")
+	}
+
+	// A restart reads the same key back
+	secretKey = nil
+	if err := InitKey(dir); err != nil {
+		t.Fatalf("InitKey (existing file) failed: %v", err)
+	}
+	plaintext, err := Unseal(sealed)
+	if err != nil {
+		t.Fatalf("unseal failed: %v", err)
+	}
+	if !bytes.Equal(plaintext, []byte("glpat-secret")) {
+		t.Fatalf("round trip mismatch: %q", plaintext)
+	}
+}
+
+func TestWrongKey(t *testing.T) {
+	t.Setenv("SECRETS_KEY", strings.Repeat("ab", 32))
+	if err := InitKey(t.TempDir()); err != nil {
+		t.Fatalf("InitKey failed: %v", err)
+	}
+	sealed, err := Seal([]byte("ghp_secret"))
+	if err != nil {
+		t.Fatalf("seal failed: %v", err)
+	}
+
+	t.Setenv("SECRETS_KEY", strings.Repeat("cd", 32))
+	if err := InitKey(t.TempDir()); err != nil {
+		t.Fatalf("InitKey failed: %v", err)
+	}
+	if _, err := Unseal(sealed); err == nil {
+		t.Fatal("expected unsealing with another key to fail")
+	}
+
+	t.Setenv("SECRETS_KEY", "short")
+	if err := InitKey(t.TempDir()); err == nil {
+		t.Fatal("expected invalid SECRETS_KEY to be rejected")
+	}
+}
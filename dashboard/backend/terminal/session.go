@@ -0,0 +1,260 @@
+package terminal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// maxBuffered caps the unread output kept in memory per session
+	maxBuffered = 1 << 20
+	// IdleTimeout closes sessions with no input or output
+	IdleTimeout = 15 * time.Minute
+)
+
+// RecordingDir is where session recordings (asciicast v2) are written
+var RecordingDir = "/data/recordings"
+
+// Session is a relayed shell between an admin's browser and an agent
+type Session struct {
+	ID        string
+	ServerID  string
+	Username  string
+	CreatedAt time.Time
+
+	mu         sync.Mutex
+	input      []byte
+	output     []byte
+	outputBase int // absolute offset of output[0]
+	closed     bool
+	dispatched bool
+	lastActive time.Time
+	changed    chan struct{}
+	recording  *os.File
+}
+
+var (
+	sessionsMu sync.Mutex
+	sessions   = make(map[string]*Session)
+)
+
+// Open creates a session and starts its recording
+func Open(serverID, username string) (*Session, error) {
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(RecordingDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	f, err := os.OpenFile(RecordingPath(id), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+
+	now := time.Now()
+	header, _ := json.Marshal(map[string]interface{}{
+		"version":   2,
+		"width":     120,
+		"height":    40,
+		"timestamp": now.Unix(),
+		"title":     fmt.Sprintf("%s@%s", username, serverID),
+	})
+	f.Write(append(header, '\n'))
+
+	s := &Session{
+		ID:         id,
+		ServerID:   serverID,
+		Username:   username,
+		CreatedAt:  now,
+		lastActive: now,
+		changed:    make(chan struct{}),
+		recording:  f,
+	}
+
+	sessionsMu.Lock()
+	sessions[id] = s
+	sessionsMu.Unlock()
+	return s, nil
+}
+
+// Get returns an open or recently closed session
+func Get(id string) (*Session, bool) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	s, ok := sessions[id]
+	return s, ok
+}
+
+// PendingFor returns an undispatched session for a server and marks it dispatched
+func PendingFor(serverID string) (*Session, bool) {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+	for _, s := range sessions {
+		s.mu.Lock()
+		ready := s.ServerID == serverID && !s.dispatched && !s.closed
+		if ready {
+			s.dispatched = true
+		}
+		s.mu.Unlock()
+		if ready {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// RecordingPath returns the recording file for a session
+func RecordingPath(id string) string {
+	return filepath.Join(RecordingDir, id+".cast")
+}
+
+// WriteInput queues keystrokes from the admin for the agent
+func (s *Session) WriteInput(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return fmt.Errorf("session closed")
+	}
+	s.input = append(s.input, data...)
+	s.record("i", data)
+	s.touch()
+	return nil
+}
+
+// WriteOutput appends shell output from the agent
+func (s *Session) WriteOutput(data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.output = append(s.output, data...)
+	if over := len(s.output) - maxBuffered; over > 0 {
+		s.output = s.output[over:]
+		s.outputBase += over
+	}
+	s.record("o", data)
+	s.touch()
+}
+
+// ReadInput returns pending input, waiting up to wait for some to arrive
+func (s *Session) ReadInput(wait time.Duration) ([]byte, bool) {
+	deadline := time.After(wait)
+	for {
+		s.mu.Lock()
+		if len(s.input) > 0 || s.closed {
+			data := s.input
+			s.input = nil
+			closed := s.closed
+			s.mu.Unlock()
+			return data, closed
+		}
+		ch := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-ch:
+		case <-deadline:
+			return nil, false
+		}
+	}
+}
+
+// ReadOutput returns output from the absolute offset, waiting up to wait for more.
+// It returns the data, the next offset and whether the session has ended.
+func (s *Session) ReadOutput(offset int, wait time.Duration) ([]byte, int, bool) {
+	deadline := time.After(wait)
+	for {
+		s.mu.Lock()
+		if offset < s.outputBase {
+			offset = s.outputBase
+		}
+		end := s.outputBase + len(s.output)
+		if offset < end || s.closed {
+			data := append([]byte(nil), s.output[offset-s.outputBase:]...)
+			closed := s.closed
+			s.mu.Unlock()
+			return data, end, closed
+		}
+		ch := s.changed
+		s.mu.Unlock()
+
+		select {
+		case <-ch:
+		case <-deadline:
+			return nil, offset, false
+		}
+	}
+}
+
+// Close ends the session and finalizes the recording
+func (s *Session) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	s.recording.Close()
+	s.touch()
+}
+
+// Closed reports whether the session has ended
+func (s *Session) Closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+// record appends an asciicast event. Caller holds s.mu.
+func (s *Session) record(kind string, data []byte) {
+	line, _ := json.Marshal([]interface{}{time.Since(s.CreatedAt).Seconds(), kind, string(data)})
+	s.recording.Write(append(line, '\n'))
+}
+
+// touch wakes waiting readers. Caller holds s.mu.
+func (s *Session) touch() {
+	s.lastActive = time.Now()
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// Reap closes idle sessions and forgets closed ones. Returns the IDs it closed.
+func Reap() []string {
+	sessionsMu.Lock()
+	defer sessionsMu.Unlock()
+
+	var closed []string
+	for id, s := range sessions {
+		s.mu.Lock()
+		idle := time.Since(s.lastActive)
+		wasClosed := s.closed
+		s.mu.Unlock()
+
+		if !wasClosed && idle > IdleTimeout {
+			s.Close()
+			closed = append(closed, id)
+		}
+		// Keep closed sessions briefly so both ends observe the close
+		if wasClosed && idle > time.Minute {
+			delete(sessions, id)
+		}
+	}
+	return closed
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,36 @@
+package tickets
+
+import (
+	"encoding/json"
+
+	"github.com/yourusername/health-dashboard-backend/secrets"
+)
+
+// credentials are the secrets of an integration, stored encrypted
+type credentials struct {
+	User  string `json:"user,omitempty"`
+	Token string `json:"token"`
+}
+
+// encryptCredentials seals credentials with AES-256-GCM
+func encryptCredentials(c credentials) (string, error) {
+	plaintext, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return secrets.Seal(plaintext)
+}
+
+// decryptCredentials opens credentials sealed by encryptCredentials
+func decryptCredentials(sealed string) (credentials, error) {
+	var c credentials
+	if sealed == "" {
+		return c, nil
+	}
+	plaintext, err := secrets.Unseal(sealed)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(plaintext, &c)
+	return c, err
+}
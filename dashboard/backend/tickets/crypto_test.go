@@ -0,0 +1,33 @@
+package tickets
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yourusername/health-dashboard-backend/secrets"
+)
+
+func TestCredentialsRoundTrip(t *testing.T) {
+	t.Setenv("SECRETS_KEY", strings.Repeat("ab", 32))
+	if err := secrets.InitKey(t.TempDir()); err != nil {
+		t.Fatalf("InitKey failed: %v", err)
+	}
+
+	sealed, err := encryptCredentials(credentials{User: "ops@example.com", Token: "glpat-secret"})
+	if err != nil {
+		t.Fatalf("encrypt failed: %v", err)
+	}
+	if strings.Contains(sealed, "glpat-secret") {
+		t.Fatal("token stored in plaintext")
+	}
+	creds, err := decryptCredentials(sealed)
+	if err != nil {
+		t.Fatalf("decrypt failed: %v", err)
+	}
+	if creds.User != "ops@example.com" || creds.Token != "glpat-secret" {
+		t.Fatalf("round trip mismatch: %+v", creds)
+	}
+	if creds, err := decryptCredentials(""); err != nil || creds.Token != "" {
+		t.Fatalf("empty credentials: %+v, %v", creds, err)
+	}
+}
@@ -0,0 +1,84 @@
+package tickets
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GitHubProvider uses the GitHub REST API with a fine-grained or classic token
+type GitHubProvider struct {
+	BaseURL string
+	Token   string
+}
+
+func (p *GitHubProvider) Name() string {
+	return "GitHub"
+}
+
+func (p *GitHubProvider) headers() map[string]string {
+	return map[string]string{
+		"Authorization":        "Bearer " + p.Token,
+		"Accept":               "application/vnd.github+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+}
+
+// repo returns the repository URL of "owner/repo"
+func (p *GitHubProvider) repo(project string) (string, error) {
+	owner, name, ok := strings.Cut(project, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", fmt.Errorf("github project must be owner/repo, got %q", project)
+	}
+	return p.BaseURL + "/repos/" + owner + "/" + name, nil
+}
+
+func (p *GitHubProvider) Test(project string) error {
+	if project == "" {
+		return doJSON("GET", p.BaseURL+"/user", p.headers(), nil, nil)
+	}
+	repo, err := p.repo(project)
+	if err != nil {
+		return err
+	}
+	return doJSON("GET", repo, p.headers(), nil, nil)
+}
+
+func (p *GitHubProvider) Create(project string, issue Issue) (Ref, error) {
+	repo, err := p.repo(project)
+	if err != nil {
+		return Ref{}, err
+	}
+	var resp struct {
+		Number  int    `json:"number"`
+		HTMLURL string `json:"html_url"`
+	}
+	err = doJSON("POST", repo+"/issues", p.headers(), map[string]interface{}{
+		"title":  issue.Title,
+		"body":   issue.Body,
+		"labels": []string{label},
+	}, &resp)
+	if err != nil {
+		return Ref{}, err
+	}
+	return Ref{ID: strconv.Itoa(resp.Number), URL: resp.HTMLURL}, nil
+}
+
+func (p *GitHubProvider) Comment(project, id, body string) error {
+	repo, err := p.repo(project)
+	if err != nil {
+		return err
+	}
+	return doJSON("POST", repo+"/issues/"+id+"/comments", p.headers(), map[string]string{"body": body}, nil)
+}
+
+func (p *GitHubProvider) Close(project, id, comment string) error {
+	if err := p.Comment(project, id, comment); err != nil {
+		return err
+	}
+	repo, _ := p.repo(project)
+	return doJSON("PATCH", repo+"/issues/"+id, p.headers(), map[string]string{
+		"state":        "closed",
+		"state_reason": "completed",
+	}, nil)
+}
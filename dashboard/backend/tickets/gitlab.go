@@ -0,0 +1,59 @@
+package tickets
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// GitLabProvider uses the GitLab REST API v4 with a personal or project access token
+type GitLabProvider struct {
+	BaseURL string
+	Token   string
+}
+
+func (p *GitLabProvider) Name() string {
+	return "GitLab"
+}
+
+func (p *GitLabProvider) headers() map[string]string {
+	return map[string]string{"PRIVATE-TOKEN": p.Token}
+}
+
+// issues returns the issues URL of a project given as "group/project" or numeric ID
+func (p *GitLabProvider) issues(project string) string {
+	return p.BaseURL + "/api/v4/projects/" + url.PathEscape(project) + "/issues"
+}
+
+func (p *GitLabProvider) Test(project string) error {
+	if project != "" {
+		return doJSON("GET", p.BaseURL+"/api/v4/projects/"+url.PathEscape(project), p.headers(), nil, nil)
+	}
+	return doJSON("GET", p.BaseURL+"/api/v4/user", p.headers(), nil, nil)
+}
+
+func (p *GitLabProvider) Create(project string, issue Issue) (Ref, error) {
+	var resp struct {
+		IID    int    `json:"iid"`
+		WebURL string `json:"web_url"`
+	}
+	err := doJSON("POST", p.issues(project), p.headers(), map[string]string{
+		"title":       issue.Title,
+		"description": issue.Body,
+		"labels":      label,
+	}, &resp)
+	if err != nil {
+		return Ref{}, err
+	}
+	return Ref{ID: strconv.Itoa(resp.IID), URL: resp.WebURL}, nil
+}
+
+func (p *GitLabProvider) Comment(project, id, body string) error {
+	return doJSON("POST", p.issues(project)+"/"+id+"/notes", p.headers(), map[string]string{"body": body}, nil)
+}
+
+func (p *GitLabProvider) Close(project, id, comment string) error {
+	if err := p.Comment(project, id, comment); err != nil {
+		return err
+	}
+	return doJSON("PUT", p.issues(project)+"/"+id, p.headers(), map[string]string{"state_event": "close"}, nil)
+}
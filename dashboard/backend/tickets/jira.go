@@ -0,0 +1,86 @@
+package tickets
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+)
+
+// JiraProvider uses the Jira REST API v2 with an account email and API token
+type JiraProvider struct {
+	BaseURL string
+	User    string
+	Token   string
+}
+
+func (p *JiraProvider) Name() string {
+	return "Jira"
+}
+
+func (p *JiraProvider) headers() map[string]string {
+	auth := base64.StdEncoding.EncodeToString([]byte(p.User + ":" + p.Token))
+	return map[string]string{"Authorization": "Basic " + auth}
+}
+
+func (p *JiraProvider) Test(project string) error {
+	if err := doJSON("GET", p.BaseURL+"/rest/api/2/myself", p.headers(), nil, nil); err != nil {
+		return err
+	}
+	if project != "" {
+		return doJSON("GET", p.BaseURL+"/rest/api/2/project/"+url.PathEscape(project), p.headers(), nil, nil)
+	}
+	return nil
+}
+
+func (p *JiraProvider) Create(project string, issue Issue) (Ref, error) {
+	var resp struct {
+		Key string `json:"key"`
+	}
+	err := doJSON("POST", p.BaseURL+"/rest/api/2/issue", p.headers(), map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": project},
+			"summary":     issue.Title,
+			"description": issue.Body,
+			"issuetype":   map[string]string{"name": "Bug"},
+			"labels":      []string{label},
+		},
+	}, &resp)
+	if err != nil {
+		return Ref{}, err
+	}
+	return Ref{ID: resp.Key, URL: p.BaseURL + "/browse/" + resp.Key}, nil
+}
+
+func (p *JiraProvider) Comment(project, id, body string) error {
+	return doJSON("POST", p.BaseURL+"/rest/api/2/issue/"+url.PathEscape(id)+"/comment", p.headers(),
+		map[string]string{"body": body}, nil)
+}
+
+// Close moves the issue through the first transition into the "done" status category
+func (p *JiraProvider) Close(project, id, comment string) error {
+	if err := p.Comment(project, id, comment); err != nil {
+		return err
+	}
+	var resp struct {
+		Transitions []struct {
+			ID string `json:"id"`
+			To struct {
+				StatusCategory struct {
+					Key string `json:"key"`
+				} `json:"statusCategory"`
+			} `json:"to"`
+		} `json:"transitions"`
+	}
+	transitions := p.BaseURL + "/rest/api/2/issue/" + url.PathEscape(id) + "/transitions"
+	if err := doJSON("GET", transitions, p.headers(), nil, &resp); err != nil {
+		return err
+	}
+	for _, t := range resp.Transitions {
+		if t.To.StatusCategory.Key == "done" {
+			return doJSON("POST", transitions, p.headers(), map[string]interface{}{
+				"transition": map[string]string{"id": t.ID},
+			}, nil)
+		}
+	}
+	return fmt.Errorf("no transition to a done status available for %s", id)
+}
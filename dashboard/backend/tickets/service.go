@@ -0,0 +1,259 @@
+package tickets
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/yourusername/health-dashboard-backend/database"
+	"github.com/yourusername/health-dashboard-backend/health"
+	"github.com/yourusername/health-dashboard-backend/models"
+)
+
+// mu serializes ticket updates so one incident never opens two tickets
+var mu sync.Mutex
+
+const integrationColumns = "id, name, kind, COALESCE(base_url, ''), COALESCE(default_project, ''), COALESCE(project_map, ''), COALESCE(credentials, ''), enabled, created_at"
+
+func scanIntegration(row interface{ Scan(...interface{}) error }) (models.TicketIntegration, string, error) {
+	var in models.TicketIntegration
+	var projectMap, sealed string
+	err := row.Scan(&in.ID, &in.Name, &in.Kind, &in.BaseURL, &in.DefaultProject, &projectMap, &sealed, &in.Enabled, &in.CreatedAt)
+	in.ProjectMap = map[string]string{}
+	json.Unmarshal([]byte(projectMap), &in.ProjectMap)
+	return in, sealed, err
+}
+
+// List returns all integrations; tokens are never returned
+func List() ([]models.TicketIntegration, error) {
+	rows, err := database.DB.Query("SELECT " + integrationColumns + " FROM ticket_integrations ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []models.TicketIntegration{}
+	for rows.Next() {
+		in, sealed, err := scanIntegration(rows)
+		if err != nil {
+			continue
+		}
+		if creds, err := decryptCredentials(sealed); err == nil {
+			in.User, in.HasToken = creds.User, creds.Token != ""
+		}
+		list = append(list, in)
+	}
+	return list, nil
+}
+
+// load returns an integration with a provider for its decrypted credentials
+func load(id int64) (models.TicketIntegration, Provider, error) {
+	in, sealed, err := scanIntegration(database.DB.QueryRow("SELECT "+integrationColumns+" FROM ticket_integrations WHERE id = ?", id))
+	if err != nil {
+		return in, nil, err
+	}
+	creds, err := decryptCredentials(sealed)
+	if err != nil {
+		return in, nil, err
+	}
+	p, err := newProvider(in.Kind, in.BaseURL, creds)
+	return in, p, err
+}
+
+// Validate checks the fields of an integration before it is saved
+func Validate(in models.TicketIntegration) error {
+	if in.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	_, err := newProvider(in.Kind, in.BaseURL, credentials{})
+	return err
+}
+
+// Save creates (ID 0) or updates an integration. An empty token on update
+// keeps the stored credentials.
+func Save(in *models.TicketIntegration) error {
+	creds := credentials{User: in.User, Token: in.Token}
+	if in.ID != 0 {
+		var sealed string
+		if err := database.DB.QueryRow("SELECT COALESCE(credentials, ''), created_at FROM ticket_integrations WHERE id = ?", in.ID).Scan(&sealed, &in.CreatedAt); err != nil {
+			return err
+		}
+		if in.Token == "" {
+			old, err := decryptCredentials(sealed)
+			if err != nil {
+				return err
+			}
+			creds.Token = old.Token
+		}
+	}
+	sealed, err := encryptCredentials(creds)
+	if err != nil {
+		return err
+	}
+	projectMap, _ := json.Marshal(in.ProjectMap)
+
+	if in.ID == 0 {
+		in.CreatedAt = time.Now().Unix()
+		res, err := database.DB.Exec(`
+			INSERT INTO ticket_integrations (name, kind, base_url, default_project, project_map, credentials, enabled, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, in.Name, in.Kind, in.BaseURL, in.DefaultProject, string(projectMap), sealed, in.Enabled, in.CreatedAt)
+		if err != nil {
+			return err
+		}
+		in.ID, _ = res.LastInsertId()
+	} else {
+		if _, err := database.DB.Exec(`
+			UPDATE ticket_integrations SET name = ?, kind = ?, base_url = ?, default_project = ?, project_map = ?, credentials = ?, enabled = ?
+			WHERE id = ?
+		`, in.Name, in.Kind, in.BaseURL, in.DefaultProject, string(projectMap), sealed, in.Enabled, in.ID); err != nil {
+			return err
+		}
+	}
+	in.HasToken, in.Token = creds.Token != "", ""
+	return nil
+}
+
+// Test checks the credentials of an integration and its access to the default project
+func Test(id int64) error {
+	in, p, err := load(id)
+	if err != nil {
+		return err
+	}
+	return p.Test(in.DefaultProject)
+}
+
+// projectFor maps a server to a project through its tags (alphabetical, first
+// mapped tag wins), falling back to the default project
+func projectFor(in models.TicketIntegration, serverID string) string {
+	if len(in.ProjectMap) > 0 {
+		rows, err := database.DB.Query("SELECT tag FROM server_tags WHERE server_id = ? ORDER BY tag", serverID)
+		if err == nil {
+			defer rows.Close()
+			for rows.Next() {
+				var tag string
+				if rows.Scan(&tag) == nil && in.ProjectMap[tag] != "" {
+					return in.ProjectMap[tag]
+				}
+			}
+		}
+	}
+	return in.DefaultProject
+}
+
+// Open files a ticket for an incident in every enabled integration. While the
+// incident's ticket is still open, the body is added as a comment instead.
+// Nothing is filed while the server is in maintenance.
+func Open(serverID, incidentKey, title, body string) {
+	if health.InMaintenance(serverID) {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+
+	rows, err := database.DB.Query("SELECT id FROM ticket_integrations WHERE enabled = 1")
+	if err != nil {
+		return
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if rows.Scan(&id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		in, p, err := load(id)
+		if err != nil {
+			log.Printf("❌ Ticket integration %d unusable: %v", id, err)
+			continue
+		}
+
+		var ticketID int64
+		var project, externalID string
+		err = database.DB.QueryRow(`
+			SELECT id, project, external_id FROM tickets
+			WHERE integration_id = ? AND server_id = ? AND incident_key = ? AND status = 'open'
+		`, id, serverID, incidentKey).Scan(&ticketID, &project, &externalID)
+		now := time.Now().Unix()
+		if err == nil {
+			err := p.Comment(project, externalID, body)
+			recordResult(ticketID, err)
+			if err != nil {
+				log.Printf("❌ Failed to update %s ticket %s: %v", p.Name(), externalID, err)
+			}
+			continue
+		}
+
+		project = projectFor(in, serverID)
+		if project == "" {
+			continue // no project for this server
+		}
+		ref, err := p.Create(project, Issue{Title: title, Body: body})
+		if err != nil {
+			log.Printf("❌ Failed to create %s ticket for %s: %v", p.Name(), incidentKey, err)
+			continue
+		}
+		if _, err := database.DB.Exec(`
+			INSERT INTO tickets (integration_id, server_id, incident_key, project, external_id, url, status, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, 'open', ?, ?)
+		`, id, serverID, incidentKey, project, ref.ID, ref.URL, now, now); err != nil {
+			log.Printf("Failed to save ticket %s: %v", ref.ID, err)
+			continue
+		}
+		log.Printf("🎫 Opened %s ticket %s for %s on %s", p.Name(), ref.ID, incidentKey, serverID)
+	}
+}
+
+// Resolve comments on and closes the open tickets of an incident
+func Resolve(serverID, incidentKey, note string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	rows, err := database.DB.Query(`
+		SELECT id, integration_id, project, external_id FROM tickets
+		WHERE server_id = ? AND incident_key = ? AND status = 'open'
+	`, serverID, incidentKey)
+	if err != nil {
+		return
+	}
+	type openTicket struct {
+		id, integrationID   int64
+		project, externalID string
+	}
+	var open []openTicket
+	for rows.Next() {
+		var t openTicket
+		if rows.Scan(&t.id, &t.integrationID, &t.project, &t.externalID) == nil {
+			open = append(open, t)
+		}
+	}
+	rows.Close()
+
+	for _, t := range open {
+		_, p, err := load(t.integrationID)
+		if err == nil {
+			err = p.Close(t.project, t.externalID, note)
+		}
+		if err != nil {
+			// Kept open so the next occurrence comments on it; closing is retried on the next recovery
+			log.Printf("❌ Failed to close ticket %s: %v", t.externalID, err)
+			recordResult(t.id, err)
+			continue
+		}
+		database.DB.Exec("UPDATE tickets SET status = 'closed', error = NULL, updated_at = ? WHERE id = ?", time.Now().Unix(), t.id)
+		log.Printf("🎫 Closed %s ticket %s for %s on %s", p.Name(), t.externalID, incidentKey, serverID)
+	}
+}
+
+// recordResult stores the outcome of a ticket update
+func recordResult(ticketID int64, err error) {
+	var msg interface{}
+	if err != nil {
+		msg = err.Error()
+	}
+	database.DB.Exec("UPDATE tickets SET error = ?, updated_at = ? WHERE id = ?", msg, time.Now().Unix(), ticketID)
+}
@@ -0,0 +1,107 @@
+package tickets
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Kinds lists the supported trackers
+var Kinds = []string{"jira", "gitlab", "github"}
+
+// Issue is the content of a new ticket
+type Issue struct {
+	Title string
+	Body  string
+}
+
+// Ref identifies a created ticket
+type Ref struct {
+	ID  string // PROJ-12 (Jira), issue number (GitLab iid, GitHub)
+	URL string
+}
+
+// Provider talks to one issue tracker. project is a Jira project key, a GitLab
+// project path or ID, or a GitHub "owner/repo".
+type Provider interface {
+	Name() string
+	// Test checks the credentials, and access to project when set
+	Test(project string) error
+	Create(project string, issue Issue) (Ref, error)
+	Comment(project, id, body string) error
+	// Close comments and closes the ticket
+	Close(project, id, comment string) error
+}
+
+// label marks tickets created by the dashboard
+const label = "nodeguarder"
+
+// newProvider returns the provider for an integration kind
+func newProvider(kind, baseURL string, creds credentials) (Provider, error) {
+	baseURL = strings.TrimRight(baseURL, "/")
+	switch kind {
+	case "jira":
+		if baseURL == "" {
+			return nil, fmt.Errorf("jira requires a base URL")
+		}
+		return &JiraProvider{BaseURL: baseURL, User: creds.User, Token: creds.Token}, nil
+	case "gitlab":
+		if baseURL == "" {
+			baseURL = "https://gitlab.com"
+		}
+		return &GitLabProvider{BaseURL: baseURL, Token: creds.Token}, nil
+	case "github":
+		if baseURL == "" {
+			baseURL = "https://api.github.com"
+		}
+		return &GitHubProvider{BaseURL: baseURL, Token: creds.Token}, nil
+	}
+	return nil, fmt.Errorf("unknown integration kind: %s", kind)
+}
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// doJSON sends body (if not nil) as JSON and decodes the response into out (if not nil)
+func doJSON(method, url string, headers map[string]string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 400 {
+		msg := strings.TrimSpace(string(data))
+		if len(msg) > 200 {
+			msg = msg[:200] + "..."
+		}
+		return fmt.Errorf("%s %s: status %d: %s", method, req.URL.Path, resp.StatusCode, msg)
+	}
+	if out != nil && len(data) > 0 {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
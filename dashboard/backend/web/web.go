@@ -0,0 +1,58 @@
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+)
+
+// dist holds the built frontend when it was copied into web/dist before `go build`.
+// Without it the binary still builds and only serves the API.
+//
+//go:embed all:dist
+var dist embed.FS
+
+// Register serves the frontend SPA. A frontend directory on disk (overrideDir) takes
+// precedence over the embedded build so the UI can be swapped without rebuilding.
+func Register(app *fiber.App, overrideDir string) {
+	isAPI := func(c *fiber.Ctx) bool {
+		return strings.HasPrefix(c.Path(), "/api")
+	}
+
+	if _, err := os.Stat(overrideDir); err == nil {
+		app.Static("/", overrideDir)
+
+		// Handle SPA routing: If file not found, serve index.html
+		app.Get("*", func(c *fiber.Ctx) error {
+			if isAPI(c) {
+				return c.Next()
+			}
+			return c.SendFile(overrideDir + "/index.html")
+		})
+		log.Printf("✅ Serving static frontend from %s", overrideDir)
+		return
+	}
+
+	sub, err := fs.Sub(dist, "dist")
+	if err != nil {
+		return
+	}
+	if _, err := fs.Stat(sub, "index.html"); err != nil {
+		log.Println("ℹ️  No frontend found (neither on disk nor embedded), serving API only")
+		return
+	}
+
+	app.Use("/", filesystem.New(filesystem.Config{
+		Root:         http.FS(sub),
+		Index:        "index.html",
+		NotFoundFile: "index.html", // SPA routing
+		Next:         isAPI,
+	}))
+	log.Println("✅ Serving embedded frontend")
+}
@@ -1,10 +1,20 @@
 import React, { useState } from 'react';
 import { Link, useNavigate } from 'react-router-dom';
 import { formatRelativeTime, formatDate } from '../utils/formatters';
-import { AlertCircle, FileWarning, Clock, Info, CheckCircle2, XCircle, Activity as ActivityIconBase, Trash2, AlertTriangle } from 'lucide-react';
+import { AlertCircle, FileWarning, Clock, Info, CheckCircle2, XCircle, Activity as ActivityIconBase, Trash2, AlertTriangle, Send, MessageSquare } from 'lucide-react';
 import { cn } from '../utils/cn';
 
-export default function EventLog({ events = [], servers = [], limit, showFilters, showTypeFilters = true, showServerFilter = true, onDelete }) {
+// Captured job output attached to cron failure events (details.output)
+const getEventOutput = (event) => {
+    try {
+        const details = JSON.parse(event.details || '{}');
+        return Array.isArray(details.output) && details.output.length > 0 ? details.output : null;
+    } catch {
+        return null;
+    }
+};
+
+export default function EventLog({ events = [], servers = [], limit, showFilters, showTypeFilters = true, showServerFilter = true, onDelete, onReplay, onAck, onComment }) {
     const [filterType, setFilterType] = useState('all');
     const [selectedServer, setSelectedServer] = useState('all');
     const [searchTerm, setSearchTerm] = useState('');
@@ -169,6 +179,27 @@ export default function EventLog({ events = [], servers = [], limit, showFilters
                                                     </Link>
                                                 </span>
                                             )}
+                                            {event.occurrences > 1 && (
+                                                <span
+                                                    className="text-[10px] font-semibold px-1.5 py-0.5 rounded bg-muted text-muted-foreground border border-border"
+                                                    title={`${event.occurrences} times from ${formatDate(event.first_seen)} to ${formatDate(event.last_seen)}`}
+                                                >
+                                                    ×{event.occurrences}
+                                                </span>
+                                            )}
+                                            {event.state === 'open' && (
+                                                <span className="text-[10px] font-semibold uppercase px-1.5 py-0.5 rounded bg-rose-50 text-rose-700 border border-rose-200">
+                                                    Open
+                                                </span>
+                                            )}
+                                            {event.state === 'resolved' && (
+                                                <span
+                                                    className="text-[10px] font-semibold uppercase px-1.5 py-0.5 rounded bg-emerald-50 text-emerald-700 border border-emerald-200"
+                                                    title={`Resolved ${formatDate(event.resolved_at)}`}
+                                                >
+                                                    Resolved
+                                                </span>
+                                            )}
                                         </div>
                                         <div className="flex items-center gap-2">
                                             <span className="text-xs text-muted-foreground whitespace-nowrap font-medium">
@@ -176,6 +207,42 @@ export default function EventLog({ events = [], servers = [], limit, showFilters
                                                 <span className="mx-1.5 opacity-40">•</span>
                                                 {formatDate(event.timestamp)}
                                             </span>
+                                            {onAck && !event.acknowledged && (
+                                                <button
+                                                    onClick={(e) => {
+                                                        e.stopPropagation();
+                                                        onAck(event);
+                                                    }}
+                                                    className="p-1 text-muted-foreground hover:text-emerald-600 hover:bg-emerald-50 rounded opacity-0 group-hover:opacity-100 transition-opacity"
+                                                    title="Acknowledge"
+                                                >
+                                                    <CheckCircle2 className="w-3.5 h-3.5" />
+                                                </button>
+                                            )}
+                                            {onComment && (
+                                                <button
+                                                    onClick={(e) => {
+                                                        e.stopPropagation();
+                                                        onComment(event);
+                                                    }}
+                                                    className="p-1 text-muted-foreground hover:text-primary hover:bg-primary/10 rounded opacity-0 group-hover:opacity-100 transition-opacity"
+                                                    title="Comments"
+                                                >
+                                                    <MessageSquare className="w-3.5 h-3.5" />
+                                                </button>
+                                            )}
+                                            {onReplay && (
+                                                <button
+                                                    onClick={(e) => {
+                                                        e.stopPropagation();
+                                                        onReplay(event);
+                                                    }}
+                                                    className="p-1 text-muted-foreground hover:text-primary hover:bg-primary/10 rounded opacity-0 group-hover:opacity-100 transition-opacity"
+                                                    title="Replay as Test Notification"
+                                                >
+                                                    <Send className="w-3.5 h-3.5" />
+                                                </button>
+                                            )}
                                             {onDelete && (
                                                 <button
                                                     onClick={(e) => {
@@ -193,6 +260,19 @@ export default function EventLog({ events = [], servers = [], limit, showFilters
                                     <p className="text-sm text-muted-foreground line-clamp-2 leading-relaxed">
                                         {event.message}
                                     </p>
+                                    {!limit && (() => {
+                                        const output = getEventOutput(event);
+                                        return output && (
+                                            <details className="mt-2" onClick={(e) => e.stopPropagation()}>
+                                                <summary className="text-xs text-muted-foreground cursor-pointer hover:text-foreground">
+                                                    Output (last {output.length} lines)
+                                                </summary>
+                                                <pre className="mt-2 p-3 bg-muted rounded-md text-xs font-mono whitespace-pre-wrap break-all max-h-64 overflow-auto">
+                                                    {output.join('\n')}
+                                                </pre>
+                                            </details>
+                                        );
+                                    })()}
                                 </div>
                             </div>
                         );
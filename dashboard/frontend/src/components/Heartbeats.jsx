@@ -0,0 +1,197 @@
+import React, { useEffect, useState } from 'react';
+import { HeartPulse, Plus, Trash2, Copy } from 'lucide-react';
+import api from '../services/api';
+import { formatRelativeTime } from '../utils/formatters';
+
+const emptyHeartbeat = { name: '', server_id: '', interval: 24, unit: 3600, grace: 30 };
+
+const units = [
+    [60, 'minutes'],
+    [3600, 'hours'],
+    [86400, 'days'],
+];
+
+const statusStyles = {
+    up: 'bg-emerald-500',
+    down: 'bg-rose-500',
+    new: 'bg-gray-400',
+};
+
+// formatSeconds renders an interval as "24h", "30m" or "7d"
+const formatSeconds = (s) => {
+    if (s % 86400 === 0) return `${s / 86400}d`;
+    if (s % 3600 === 0) return `${s / 3600}h`;
+    if (s % 60 === 0) return `${s / 60}m`;
+    return `${s}s`;
+};
+
+// Deadman checks for jobs the agent cannot see: the job pings its URL, and the
+// dashboard raises a critical event when a ping is overdue
+export default function Heartbeats() {
+    const [heartbeats, setHeartbeats] = useState([]);
+    const [servers, setServers] = useState([]);
+    const [draft, setDraft] = useState(emptyHeartbeat);
+    const [error, setError] = useState('');
+
+    useEffect(() => {
+        fetchHeartbeats();
+        api.get('/api/v1/servers').then(res => setServers(res.data || [])).catch(() => {});
+        const interval = setInterval(fetchHeartbeats, 30000);
+        return () => clearInterval(interval);
+    }, []);
+
+    const fetchHeartbeats = async () => {
+        try {
+            const response = await api.get('/api/v1/heartbeats');
+            setHeartbeats(response.data || []);
+        } catch (err) {
+            console.error('Failed to fetch heartbeats:', err);
+        }
+    };
+
+    const pingURL = (hb) => `${window.location.origin}/api/v1/heartbeats/ping/${hb.token}`;
+
+    const handleCreate = async () => {
+        setError('');
+        try {
+            await api.post('/api/v1/heartbeats', {
+                name: draft.name,
+                server_id: draft.server_id,
+                interval: Math.round(parseFloat(draft.interval) * draft.unit) || 0,
+                grace: Math.round(parseFloat(draft.grace) * 60) || 0,
+            });
+            setDraft(emptyHeartbeat);
+            fetchHeartbeats();
+        } catch (err) {
+            setError(err.response?.data?.error || 'Failed to create heartbeat');
+        }
+    };
+
+    const handleDelete = async (hb) => {
+        if (!window.confirm(`Delete heartbeat "${hb.name}"? Jobs still pinging its URL will get a 404.`)) return;
+        try {
+            await api.delete(`/api/v1/heartbeats/${hb.id}`);
+            fetchHeartbeats();
+        } catch (err) {
+            setError(err.response?.data?.error || 'Failed to delete heartbeat');
+        }
+    };
+
+    return (
+        <div className="bg-card border border-border rounded-xl shadow-sm overflow-hidden">
+            <div className="p-6 border-b border-border flex items-center gap-2">
+                <HeartPulse className="w-5 h-5 text-primary" />
+                <h2 className="text-lg font-semibold text-foreground">Heartbeats</h2>
+            </div>
+            <div className="p-6 space-y-6">
+                <div className="text-xs text-muted-foreground">
+                    For jobs on systems without an agent: the job calls its ping URL when it succeeds
+                    (e.g. <code className="font-mono">backup.sh &amp;&amp; curl -fsS -m 10 &lt;url&gt;</code>). A heartbeat that misses its interval plus grace raises a critical alert.
+                </div>
+
+                {heartbeats.length > 0 && (
+                    <div className="divide-y divide-border border border-border rounded-md">
+                        {heartbeats.map(hb => (
+                            <div key={hb.id} className="p-3 flex items-center justify-between gap-4">
+                                <div className="min-w-0">
+                                    <div className="text-sm font-medium text-foreground flex items-center gap-2">
+                                        <span className={`w-2 h-2 rounded-full ${statusStyles[hb.status] || 'bg-gray-400'}`} />
+                                        {hb.name}
+                                        {hb.hostname && <span className="text-xs text-muted-foreground">on {hb.hostname}</span>}
+                                    </div>
+                                    <div className="text-xs text-muted-foreground">
+                                        every {formatSeconds(hb.interval)}{hb.grace > 0 && ` (+${formatSeconds(hb.grace)} grace)`} ·{' '}
+                                        {hb.status === 'down'
+                                            ? `overdue since ${formatRelativeTime(hb.down_since)}`
+                                            : hb.last_ping ? `last ping ${formatRelativeTime(hb.last_ping)}` : 'waiting for first ping'}
+                                    </div>
+                                    {hb.token && (
+                                        <div className="text-xs font-mono text-muted-foreground truncate">{pingURL(hb)}</div>
+                                    )}
+                                </div>
+                                <div className="flex items-center gap-1 shrink-0">
+                                    {hb.token && (
+                                        <button
+                                            onClick={() => navigator.clipboard?.writeText(pingURL(hb))}
+                                            className="p-2 text-muted-foreground hover:text-foreground"
+                                            title="Copy ping URL"
+                                        >
+                                            <Copy className="w-4 h-4" />
+                                        </button>
+                                    )}
+                                    <button
+                                        onClick={() => handleDelete(hb)}
+                                        className="p-2 text-muted-foreground hover:text-destructive"
+                                        title="Delete heartbeat"
+                                    >
+                                        <Trash2 className="w-4 h-4" />
+                                    </button>
+                                </div>
+                            </div>
+                        ))}
+                    </div>
+                )}
+
+                <div className="space-y-3">
+                    <div className="grid gap-3 md:grid-cols-2">
+                        <input
+                            type="text"
+                            placeholder="Name (e.g. nightly backup)"
+                            value={draft.name}
+                            onChange={(e) => setDraft({ ...draft, name: e.target.value })}
+                            className="w-full px-3 py-2 bg-background border border-input rounded-md text-sm"
+                        />
+                        <select
+                            value={draft.server_id}
+                            onChange={(e) => setDraft({ ...draft, server_id: e.target.value })}
+                            className="w-full px-3 py-2 bg-background border border-input rounded-md text-sm"
+                        >
+                            <option value="">No server (unmanaged system)</option>
+                            {servers.map(s => <option key={s.id} value={s.id}>{s.hostname}</option>)}
+                        </select>
+                    </div>
+                    <div className="grid gap-3 grid-cols-3">
+                        <div>
+                            <label className="text-xs font-medium text-muted-foreground">Expect a ping every</label>
+                            <input
+                                type="number"
+                                min="1"
+                                value={draft.interval}
+                                onChange={(e) => setDraft({ ...draft, interval: e.target.value })}
+                                className="w-full mt-1 px-3 py-2 bg-background border border-input rounded-md text-sm"
+                            />
+                        </div>
+                        <div>
+                            <label className="text-xs font-medium text-muted-foreground">Unit</label>
+                            <select
+                                value={draft.unit}
+                                onChange={(e) => setDraft({ ...draft, unit: parseInt(e.target.value) })}
+                                className="w-full mt-1 px-3 py-2 bg-background border border-input rounded-md text-sm"
+                            >
+                                {units.map(([seconds, label]) => <option key={seconds} value={seconds}>{label}</option>)}
+                            </select>
+                        </div>
+                        <div>
+                            <label className="text-xs font-medium text-muted-foreground">Grace (minutes)</label>
+                            <input
+                                type="number"
+                                min="0"
+                                value={draft.grace}
+                                onChange={(e) => setDraft({ ...draft, grace: e.target.value })}
+                                className="w-full mt-1 px-3 py-2 bg-background border border-input rounded-md text-sm"
+                            />
+                        </div>
+                    </div>
+                    {error && <div className="text-sm text-destructive">{error}</div>}
+                    <button
+                        onClick={handleCreate}
+                        disabled={!draft.name.trim()}
+                        className="inline-flex items-center gap-2 px-4 py-2 bg-primary text-primary-foreground rounded-md text-sm font-medium hover:bg-primary/90 disabled:opacity-50"
+                    >
+                        <Plus className="w-4 h-4" /> Add Heartbeat
+                    </button>
+                </div>
+            </div>
+        </div>
+    );
+}
@@ -141,6 +141,36 @@ export default function CronConfig({
                                 </div>
                             )}
                         </div>
+
+                        <div className="space-y-3">
+                            <label className="text-sm font-medium text-foreground">Slowdown Factor</label>
+                            <div className="text-xs text-muted-foreground mb-3">
+                                Trigger a <b>warning alert</b> when a successful run takes this many times its median duration (last 20 runs), even under the max runtime. 0 disables.
+                            </div>
+                            <input
+                                type="number"
+                                min="0"
+                                step="0.5"
+                                value={config.cron_anomaly_factor ?? 3}
+                                onChange={(e) => setConfig({ ...config, cron_anomaly_factor: parseFloat(e.target.value) || 0 })}
+                                className="w-full px-3 py-2 bg-background border border-input rounded-md text-sm"
+                            />
+                        </div>
+
+                        <div className="space-y-3">
+                            <label className="text-sm font-medium text-foreground">Alert After Failures</label>
+                            <div className="text-xs text-muted-foreground mb-3">
+                                Consecutive failures before a job raises an <b>alert</b>. Further failures update that event (one "still failing" alert), and a successful run records a recovery.
+                            </div>
+                            <input
+                                type="number"
+                                min="1"
+                                max="100"
+                                value={config.cron_failure_threshold ?? 1}
+                                onChange={(e) => setConfig({ ...config, cron_failure_threshold: parseInt(e.target.value) || 1 })}
+                                className="w-full px-3 py-2 bg-background border border-input rounded-md text-sm"
+                            />
+                        </div>
                     </div>
 
                     <div className="space-y-6">
@@ -179,7 +209,23 @@ export default function CronConfig({
                                                     <div className="font-mono text-sm truncate bg-muted/50 px-2 py-1 rounded w-full mb-2" title={job.command}>
                                                         {job.command}
                                                     </div>
-                                                    {(job.lastDuration !== undefined || job.lastExitCode !== undefined) && (
+                                                    {(job.user || job.schedule) && (
+                                                        <div className="flex gap-4 text-xs mb-1">
+                                                            {job.schedule && (
+                                                                <div className="flex items-center gap-1.5" title="Crontab Schedule">
+                                                                    <span className="text-muted-foreground font-medium uppercase">Schedule:</span>
+                                                                    <span className="font-mono">{job.schedule}</span>
+                                                                </div>
+                                                            )}
+                                                            {job.user && (
+                                                                <div className="flex items-center gap-1.5" title="Owning User">
+                                                                    <span className="text-muted-foreground font-medium uppercase">User:</span>
+                                                                    <span className="font-mono">{job.user}</span>
+                                                                </div>
+                                                            )}
+                                                        </div>
+                                                    )}
+                                                    {job.lastExecTime > 0 && (job.lastDuration !== undefined || job.lastExitCode !== undefined) && (
                                                         <div className="flex gap-4 text-xs">
                                                             <div className="flex items-center gap-1.5" title="Execution Duration">
                                                                 <span className="text-muted-foreground font-medium uppercase">Last Run:</span>
@@ -248,7 +294,7 @@ export default function CronConfig({
                                             value={newJobCommand}
                                             onChange={(e) => setNewJobCommand(e.target.value)}
                                             onKeyDown={(e) => e.key === 'Enter' && handleAddJob()}
-                                            placeholder="Enter command (e.g. /usr/bin/backup.sh)"
+                                            placeholder="Command, glob or re:regex (e.g. /usr/bin/backup.sh, /opt/report.sh --date=*)"
                                             className="flex-1 px-3 py-1.5 text-sm bg-background border border-input rounded focus:ring-2 focus:ring-primary"
                                         />
                                         <div className="flex gap-2">
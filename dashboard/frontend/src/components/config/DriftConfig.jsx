@@ -66,6 +66,45 @@ export default function DriftConfig({
                             />
                         </div>
 
+                        <div>
+                            <label className="flex items-center gap-2 text-sm font-medium text-foreground">
+                                <input
+                                    type="checkbox"
+                                    checked={!!config.network_drift_enabled}
+                                    onChange={(e) => setConfig({ ...config, network_drift_enabled: e.target.checked })}
+                                />
+                                Network drift (listening ports)
+                            </label>
+                            <div className="text-xs text-muted-foreground mt-1">Alert when a new TCP port starts listening or a known one disappears (e.g. unexpected services, crypto miners).</div>
+                        </div>
+
+                        <div>
+                            <label className="flex items-center gap-2 text-sm font-medium text-foreground">
+                                <input
+                                    type="checkbox"
+                                    checked={!!config.egress_monitor_enabled}
+                                    onChange={(e) => setConfig({ ...config, egress_monitor_enabled: e.target.checked })}
+                                />
+                                Outbound connections (eBPF)
+                            </label>
+                            <div className="text-xs text-muted-foreground mt-1">Alert when a process connects to a destination not on the allowlist (e.g. a cron job suddenly talking to an unknown IP). Requires a kernel with eBPF support.</div>
+                            {config.egress_monitor_enabled && (
+                                <div className="mt-3">
+                                    <label className="text-sm font-medium text-foreground">Allowed destinations</label>
+                                    <div className="text-xs text-muted-foreground mb-2">
+                                        One per line: <code>[process@]address[:port]</code>. Address is an IP, CIDR or <code>*</code>; IPv6 with a port in brackets. E.g. <code>10.0.0.0/8</code>, <code>*:443</code>, <code>apt@*:80</code>, <code>[2001:db8::/32]:443</code>. Loopback is always allowed.
+                                    </div>
+                                    <textarea
+                                        value={(config.egress_allow || []).join('\n')}
+                                        onChange={(e) => setConfig({ ...config, egress_allow: e.target.value.split('\n') })}
+                                        rows={5}
+                                        className="w-full px-3 py-2 bg-background border border-input rounded-md font-mono text-sm"
+                                        placeholder="10.0.0.0/8&#10;*:443&#10;postgres@10.1.2.3:5432"
+                                    />
+                                </div>
+                            )}
+                        </div>
+
                         <div>
                             <label className="text-sm font-medium text-foreground">Wildcard Patterns to ignore</label>
                             <div className="text-xs text-muted-foreground mb-2">
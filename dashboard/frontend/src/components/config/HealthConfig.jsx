@@ -153,6 +153,32 @@ export default function HealthConfig({ config, setConfig, lastOfflineTimeout, se
                                 </div>
                             </div>
                         </div>
+
+                        <div className="grid gap-6 md:grid-cols-2">
+                            <div>
+                                <label className="text-sm font-medium text-foreground">Disk: Excluded Filesystem Types</label>
+                                <div className="text-xs text-muted-foreground mb-2">Filesystem types left out of disk usage (one per line), e.g. squashfs for snaps or overlay for containers.</div>
+                                <textarea
+                                    value={(config.disk_exclude_fstypes || []).join('\n')}
+                                    onChange={(e) => setConfig({ ...config, disk_exclude_fstypes: e.target.value.split('\n') })}
+                                    rows={5}
+                                    className="w-full px-3 py-2 bg-background border border-input rounded-md text-sm font-mono"
+                                />
+                            </div>
+                            <div>
+                                <label className="text-sm font-medium text-foreground">Disk: Excluded Mount Paths</label>
+                                <div className="text-xs text-muted-foreground mb-2">Mount points left out together with everything below them (one per line), or globs such as /mnt/backup-*.</div>
+                                <textarea
+                                    value={(config.disk_exclude_paths || []).join('\n')}
+                                    onChange={(e) => setConfig({ ...config, disk_exclude_paths: e.target.value.split('\n') })}
+                                    rows={5}
+                                    className="w-full px-3 py-2 bg-background border border-input rounded-md text-sm font-mono"
+                                />
+                            </div>
+                        </div>
+                        <div className="text-xs text-muted-foreground">
+                            Disk alerts use the fullest remaining filesystem. Bind mounts of the same device are counted once.
+                        </div>
                     </div>
                 )}
             </div>
@@ -0,0 +1,151 @@
+import React, { useEffect, useState } from 'react';
+import { Layers, Plus, Trash2 } from 'lucide-react';
+import api from '../../services/api';
+
+const emptyProfile = {
+    name: '',
+    description: '',
+    tags: '',
+    thresholds: {
+        cpu_warning: 80,
+        cpu_critical: 95,
+        memory_warning: 80,
+        memory_critical: 95,
+        disk_warning: 80,
+        disk_critical: 95,
+    },
+};
+
+const fields = [
+    ['cpu_warning', 'CPU Warn'],
+    ['cpu_critical', 'CPU Crit'],
+    ['memory_warning', 'Mem Warn'],
+    ['memory_critical', 'Mem Crit'],
+    ['disk_warning', 'Disk Warn'],
+    ['disk_critical', 'Disk Crit'],
+];
+
+// Named threshold profiles, applied to servers with a matching tag (or assigned per server)
+export default function ThresholdProfiles() {
+    const [profiles, setProfiles] = useState([]);
+    const [draft, setDraft] = useState(emptyProfile);
+    const [error, setError] = useState('');
+
+    useEffect(() => {
+        fetchProfiles();
+    }, []);
+
+    const fetchProfiles = async () => {
+        try {
+            const response = await api.get('/api/v1/threshold-profiles');
+            setProfiles(response.data || []);
+        } catch (err) {
+            console.error('Failed to fetch threshold profiles:', err);
+        }
+    };
+
+    const handleCreate = async () => {
+        setError('');
+        try {
+            await api.post('/api/v1/threshold-profiles', {
+                ...draft,
+                tags: draft.tags.split(',').map(t => t.trim()).filter(Boolean),
+            });
+            setDraft(emptyProfile);
+            fetchProfiles();
+        } catch (err) {
+            setError(err.response?.data?.error || 'Failed to create profile');
+        }
+    };
+
+    const handleDelete = async (profile) => {
+        if (!window.confirm(`Delete profile "${profile.name}"? Servers using it fall back to the global thresholds.`)) return;
+        try {
+            await api.delete(`/api/v1/threshold-profiles/${profile.id}`);
+            fetchProfiles();
+        } catch (err) {
+            setError(err.response?.data?.error || 'Failed to delete profile');
+        }
+    };
+
+    return (
+        <div className="bg-card border border-border rounded-xl shadow-sm overflow-hidden">
+            <div className="p-6 border-b border-border flex items-center gap-2">
+                <Layers className="w-5 h-5 text-primary" />
+                <h2 className="text-lg font-semibold text-foreground">Threshold Profiles</h2>
+            </div>
+            <div className="p-6 space-y-6">
+                <div className="text-xs text-muted-foreground">
+                    Servers with a profile tag (or a profile assigned on the server) use its thresholds instead of the global ones above.
+                </div>
+
+                {profiles.length > 0 && (
+                    <div className="divide-y divide-border border border-border rounded-md">
+                        {profiles.map(profile => (
+                            <div key={profile.id} className="p-3 flex items-center justify-between gap-4">
+                                <div>
+                                    <div className="text-sm font-medium text-foreground">{profile.name}</div>
+                                    <div className="text-xs text-muted-foreground">
+                                        CPU {profile.thresholds.cpu_warning}/{profile.thresholds.cpu_critical}% ·
+                                        Mem {profile.thresholds.memory_warning}/{profile.thresholds.memory_critical}% ·
+                                        Disk {profile.thresholds.disk_warning}/{profile.thresholds.disk_critical}%
+                                        {profile.tags.length > 0 && ` · tags: ${profile.tags.join(', ')}`}
+                                    </div>
+                                </div>
+                                <button
+                                    onClick={() => handleDelete(profile)}
+                                    className="p-2 text-muted-foreground hover:text-destructive"
+                                    title="Delete profile"
+                                >
+                                    <Trash2 className="w-4 h-4" />
+                                </button>
+                            </div>
+                        ))}
+                    </div>
+                )}
+
+                <div className="space-y-3">
+                    <div className="grid gap-3 md:grid-cols-2">
+                        <input
+                            type="text"
+                            placeholder="Profile name (e.g. database server)"
+                            value={draft.name}
+                            onChange={(e) => setDraft({ ...draft, name: e.target.value })}
+                            className="w-full px-3 py-2 bg-background border border-input rounded-md text-sm"
+                        />
+                        <input
+                            type="text"
+                            placeholder="Tags (comma separated)"
+                            value={draft.tags}
+                            onChange={(e) => setDraft({ ...draft, tags: e.target.value })}
+                            className="w-full px-3 py-2 bg-background border border-input rounded-md text-sm"
+                        />
+                    </div>
+                    <div className="grid gap-3 grid-cols-3 md:grid-cols-6">
+                        {fields.map(([key, label]) => (
+                            <div key={key}>
+                                <label className="text-xs font-medium text-muted-foreground">{label} (%)</label>
+                                <input
+                                    type="number"
+                                    min="0"
+                                    max="100"
+                                    value={draft.thresholds[key]}
+                                    onChange={(e) => setDraft({ ...draft, thresholds: { ...draft.thresholds, [key]: parseFloat(e.target.value) || 0 } })}
+                                    className="w-full mt-1 px-3 py-2 bg-background border border-input rounded-md text-sm"
+                                />
+                            </div>
+                        ))}
+                    </div>
+                    {error && <div className="text-sm text-destructive">{error}</div>}
+                    <button
+                        onClick={handleCreate}
+                        disabled={!draft.name.trim()}
+                        className="inline-flex items-center gap-2 px-4 py-2 bg-primary text-primary-foreground rounded-md text-sm font-medium hover:bg-primary/90 disabled:opacity-50"
+                    >
+                        <Plus className="w-4 h-4" /> Add Profile
+                    </button>
+                </div>
+            </div>
+        </div>
+    );
+}
@@ -0,0 +1,274 @@
+import React, { useEffect, useState } from 'react';
+import { Ticket, Plus, Trash2, Pencil, PlugZap, ExternalLink } from 'lucide-react';
+import api from '../../services/api';
+import { formatRelativeTime } from '../../utils/formatters';
+
+const emptyIntegration = {
+    id: 0,
+    name: '',
+    kind: 'github',
+    base_url: '',
+    default_project: '',
+    project_map: '',
+    user: '',
+    token: '',
+    enabled: true,
+};
+
+const projectHints = {
+    jira: 'Project key (e.g. OPS)',
+    gitlab: 'group/project or project ID',
+    github: 'owner/repo',
+};
+
+const baseURLHints = {
+    jira: 'https://yourcompany.atlassian.net',
+    gitlab: 'https://gitlab.com (default)',
+    github: 'https://api.github.com (default)',
+};
+
+// "tag=project" lines <-> project_map object
+const parseProjectMap = (text) => Object.fromEntries(
+    text.split('\n').map(line => line.split('=').map(s => s.trim())).filter(([tag, project]) => tag && project)
+);
+const formatProjectMap = (map) => Object.entries(map || {}).map(([tag, project]) => `${tag}=${project}`).join('\n');
+
+// Issue tracker integrations: critical incidents open a ticket, recoveries close it
+export default function TicketIntegrations() {
+    const [integrations, setIntegrations] = useState([]);
+    const [tickets, setTickets] = useState([]);
+    const [draft, setDraft] = useState(emptyIntegration);
+    const [error, setError] = useState('');
+    const [testResult, setTestResult] = useState({});
+
+    useEffect(() => {
+        fetchIntegrations();
+        fetchTickets();
+    }, []);
+
+    const fetchIntegrations = async () => {
+        try {
+            const response = await api.get('/api/v1/integrations/tickets');
+            setIntegrations(response.data || []);
+        } catch (err) {
+            console.error('Failed to fetch ticket integrations:', err);
+        }
+    };
+
+    const fetchTickets = async () => {
+        try {
+            const response = await api.get('/api/v1/tickets?limit=20');
+            setTickets(response.data || []);
+        } catch (err) {
+            console.error('Failed to fetch tickets:', err);
+        }
+    };
+
+    const handleSave = async () => {
+        setError('');
+        const payload = { ...draft, project_map: parseProjectMap(draft.project_map) };
+        try {
+            if (draft.id) {
+                await api.put(`/api/v1/integrations/tickets/${draft.id}`, payload);
+            } else {
+                await api.post('/api/v1/integrations/tickets', payload);
+            }
+            setDraft(emptyIntegration);
+            fetchIntegrations();
+        } catch (err) {
+            setError(err.response?.data?.error || 'Failed to save integration');
+        }
+    };
+
+    const handleEdit = (integration) => {
+        setError('');
+        setDraft({
+            ...emptyIntegration,
+            ...integration,
+            user: integration.user || '',
+            project_map: formatProjectMap(integration.project_map),
+            token: '',
+        });
+    };
+
+    const handleDelete = async (integration) => {
+        if (!window.confirm(`Delete integration "${integration.name}"? Existing issues stay in the tracker but will no longer be closed automatically.`)) return;
+        try {
+            await api.delete(`/api/v1/integrations/tickets/${integration.id}`);
+            if (draft.id === integration.id) setDraft(emptyIntegration);
+            fetchIntegrations();
+            fetchTickets();
+        } catch (err) {
+            setError(err.response?.data?.error || 'Failed to delete integration');
+        }
+    };
+
+    const handleTest = async (integration) => {
+        setTestResult(prev => ({ ...prev, [integration.id]: 'Testing...' }));
+        try {
+            await api.post(`/api/v1/integrations/tickets/${integration.id}/test`);
+            setTestResult(prev => ({ ...prev, [integration.id]: 'Connection OK' }));
+        } catch (err) {
+            setTestResult(prev => ({ ...prev, [integration.id]: 'Failed: ' + (err.response?.data?.error || err.message) }));
+        }
+    };
+
+    const integrationName = (id) => integrations.find(i => i.id === id)?.name || `#${id}`;
+
+    return (
+        <div className="bg-card border border-border rounded-xl shadow-sm overflow-hidden">
+            <div className="p-6 border-b border-border flex items-center gap-2">
+                <Ticket className="w-5 h-5 text-primary" />
+                <h2 className="text-lg font-semibold text-foreground">Ticket Integrations</h2>
+            </div>
+            <div className="p-6 space-y-6">
+                <div className="text-xs text-muted-foreground">
+                    Critical or offline servers, failing cron jobs, closed ports and critical security events open an issue in each enabled tracker.
+                    Repeats are added as comments and the issue is closed when the incident resolves.
+                </div>
+
+                {integrations.length > 0 && (
+                    <div className="divide-y divide-border border border-border rounded-md">
+                        {integrations.map(integration => (
+                            <div key={integration.id} className="p-3 flex items-center justify-between gap-4">
+                                <div>
+                                    <div className="text-sm font-medium text-foreground">
+                                        {integration.name}
+                                        <span className="ml-2 text-xs uppercase text-muted-foreground">{integration.kind}</span>
+                                        {!integration.enabled && <span className="ml-2 text-xs text-amber-600">disabled</span>}
+                                    </div>
+                                    <div className="text-xs text-muted-foreground">
+                                        {integration.default_project || 'no default project'}
+                                        {Object.keys(integration.project_map || {}).length > 0 && ` · ${Object.keys(integration.project_map).length} tag mapping(s)`}
+                                        {testResult[integration.id] && ` · ${testResult[integration.id]}`}
+                                    </div>
+                                </div>
+                                <div className="flex items-center gap-1">
+                                    <button onClick={() => handleTest(integration)} className="p-2 text-muted-foreground hover:text-foreground" title="Test connection">
+                                        <PlugZap className="w-4 h-4" />
+                                    </button>
+                                    <button onClick={() => handleEdit(integration)} className="p-2 text-muted-foreground hover:text-foreground" title="Edit integration">
+                                        <Pencil className="w-4 h-4" />
+                                    </button>
+                                    <button onClick={() => handleDelete(integration)} className="p-2 text-muted-foreground hover:text-destructive" title="Delete integration">
+                                        <Trash2 className="w-4 h-4" />
+                                    </button>
+                                </div>
+                            </div>
+                        ))}
+                    </div>
+                )}
+
+                <div className="space-y-3">
+                    <div className="grid gap-3 md:grid-cols-3">
+                        <input
+                            type="text"
+                            placeholder="Name (e.g. Ops Jira)"
+                            value={draft.name}
+                            onChange={(e) => setDraft({ ...draft, name: e.target.value })}
+                            className="w-full px-3 py-2 bg-background border border-input rounded-md text-sm"
+                        />
+                        <select
+                            value={draft.kind}
+                            onChange={(e) => setDraft({ ...draft, kind: e.target.value })}
+                            className="w-full px-3 py-2 bg-background border border-input rounded-md text-sm"
+                        >
+                            <option value="github">GitHub Issues</option>
+                            <option value="gitlab">GitLab Issues</option>
+                            <option value="jira">Jira</option>
+                        </select>
+                        <input
+                            type="text"
+                            placeholder={baseURLHints[draft.kind]}
+                            value={draft.base_url}
+                            onChange={(e) => setDraft({ ...draft, base_url: e.target.value })}
+                            className="w-full px-3 py-2 bg-background border border-input rounded-md text-sm"
+                        />
+                    </div>
+                    <div className="grid gap-3 md:grid-cols-3">
+                        <input
+                            type="text"
+                            placeholder={`Default project: ${projectHints[draft.kind]}`}
+                            value={draft.default_project}
+                            onChange={(e) => setDraft({ ...draft, default_project: e.target.value })}
+                            className="w-full px-3 py-2 bg-background border border-input rounded-md text-sm"
+                        />
+                        {draft.kind === 'jira' && (
+                            <input
+                                type="text"
+                                placeholder="Account email"
+                                value={draft.user}
+                                onChange={(e) => setDraft({ ...draft, user: e.target.value })}
+                                className="w-full px-3 py-2 bg-background border border-input rounded-md text-sm"
+                            />
+                        )}
+                        <input
+                            type="password"
+                            placeholder={draft.id ? 'Token (leave empty to keep)' : 'API token'}
+                            value={draft.token}
+                            onChange={(e) => setDraft({ ...draft, token: e.target.value })}
+                            className="w-full px-3 py-2 bg-background border border-input rounded-md text-sm"
+                        />
+                    </div>
+                    <div>
+                        <label className="text-xs font-medium text-muted-foreground">Project per server tag (one tag=project per line)</label>
+                        <textarea
+                            rows={3}
+                            placeholder={'database=DBA\npayments=PAY'}
+                            value={draft.project_map}
+                            onChange={(e) => setDraft({ ...draft, project_map: e.target.value })}
+                            className="w-full mt-1 px-3 py-2 bg-background border border-input rounded-md text-sm font-mono"
+                        />
+                    </div>
+                    <label className="flex items-center gap-2 text-sm text-foreground">
+                        <input
+                            type="checkbox"
+                            checked={draft.enabled}
+                            onChange={(e) => setDraft({ ...draft, enabled: e.target.checked })}
+                        />
+                        Enabled
+                    </label>
+                    {error && <div className="text-sm text-destructive">{error}</div>}
+                    <div className="flex gap-3">
+                        <button
+                            onClick={handleSave}
+                            disabled={!draft.name.trim() || (!draft.id && !draft.token)}
+                            className="inline-flex items-center gap-2 px-4 py-2 bg-primary text-primary-foreground rounded-md text-sm font-medium hover:bg-primary/90 disabled:opacity-50"
+                        >
+                            <Plus className="w-4 h-4" /> {draft.id ? 'Save Integration' : 'Add Integration'}
+                        </button>
+                        {draft.id > 0 && (
+                            <button
+                                onClick={() => setDraft(emptyIntegration)}
+                                className="px-4 py-2 border border-input bg-transparent hover:bg-muted text-foreground rounded-md text-sm font-medium"
+                            >
+                                Cancel
+                            </button>
+                        )}
+                    </div>
+                </div>
+
+                {tickets.length > 0 && (
+                    <div className="space-y-2">
+                        <h3 className="text-sm font-medium text-muted-foreground uppercase border-b pb-2">Recent Tickets</h3>
+                        {tickets.map(t => (
+                            <div key={t.id} className="flex items-center justify-between text-sm">
+                                <div className="flex items-center gap-2">
+                                    <span className={`w-2 h-2 rounded-full ${t.status === 'open' ? 'bg-rose-500' : 'bg-emerald-500'}`} />
+                                    <a href={t.url} target="_blank" rel="noreferrer" className="font-medium text-foreground hover:underline inline-flex items-center gap-1">
+                                        {t.external_id} <ExternalLink className="w-3 h-3" />
+                                    </a>
+                                    <span className="text-muted-foreground">{t.hostname || t.server_id} · {t.incident_key}</span>
+                                    {t.error && <span className="text-xs text-rose-600 truncate max-w-xs" title={t.error}>{t.error}</span>}
+                                </div>
+                                <div className="text-xs text-muted-foreground">
+                                    {integrationName(t.integration_id)} · {t.status} {formatRelativeTime(t.updated_at)}
+                                </div>
+                            </div>
+                        ))}
+                    </div>
+                )}
+            </div>
+        </div>
+    );
+}
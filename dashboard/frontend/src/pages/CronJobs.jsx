@@ -2,7 +2,8 @@ import React, { useEffect, useState } from 'react';
 import api from '../services/api';
 import EventLog from '../components/EventLog';
 import CronConfig from '../components/config/CronConfig';
-import { Clock, Save, Monitor, Settings as SettingsIcon, AlertTriangle } from 'lucide-react';
+import Heartbeats from '../components/Heartbeats';
+import { Clock, Save, Monitor, Settings as SettingsIcon, AlertTriangle, HeartPulse } from 'lucide-react';
 import { cn } from '../utils/cn';
 
 // Simple deep equality check
@@ -11,7 +12,7 @@ const deepEqual = (obj1, obj2) => {
 };
 
 export default function CronJobs() {
-    const [activeTab, setActiveTab] = useState('monitoring'); // 'monitoring' | 'config'
+    const [activeTab, setActiveTab] = useState('monitoring'); // 'monitoring' | 'heartbeats' | 'config'
 
     // Data state
     const [events, setEvents] = useState([]);
@@ -81,7 +82,9 @@ export default function CronJobs() {
                         isDiscovered: true,
                         lastExitCode: job.LastExitCode,
                         lastDuration: job.LastDuration,
-                        lastExecTime: job.LastExecTime
+                        lastExecTime: job.LastExecTime,
+                        user: job.User,
+                        schedule: job.Schedule
                     });
                 });
             }
@@ -101,7 +104,9 @@ export default function CronJobs() {
                     isDiscovered: !!discoveredInfo.isDiscovered,
                     lastExitCode: discoveredInfo.lastExitCode,
                     lastDuration: discoveredInfo.lastDuration,
-                    lastExecTime: discoveredInfo.lastExecTime
+                    lastExecTime: discoveredInfo.lastExecTime,
+                    user: discoveredInfo.user,
+                    schedule: discoveredInfo.schedule
                 };
             });
 
@@ -203,6 +208,16 @@ export default function CronJobs() {
                             <Monitor className="w-4 h-4" />
                             Monitoring
                         </button>
+                        <button
+                            onClick={() => setActiveTab('heartbeats')}
+                            className={cn(
+                                "flex items-center gap-2 px-3 py-1.5 rounded-md text-sm font-medium transition-all",
+                                activeTab === 'heartbeats' ? "bg-background text-foreground shadow-sm" : "text-muted-foreground hover:text-foreground"
+                            )}
+                        >
+                            <HeartPulse className="w-4 h-4" />
+                            Heartbeats
+                        </button>
                         <button
                             onClick={() => setActiveTab('config')}
                             className={cn(
@@ -240,7 +255,11 @@ export default function CronJobs() {
                 </div>
             )}
 
-            {activeTab === 'monitoring' ? (
+            {activeTab === 'heartbeats' ? (
+                <div className="max-w-4xl animate-in fade-in slide-in-from-bottom-2 duration-300">
+                    <Heartbeats />
+                </div>
+            ) : activeTab === 'monitoring' ? (
                 <div className="bg-card border border-border rounded-xl shadow-sm overflow-hidden min-h-[500px]">
                     <div className="p-6">
                         <h2 className="text-lg font-semibold text-foreground mb-6 flex items-center gap-2">
@@ -1,6 +1,7 @@
 import React, { useEffect, useState } from 'react';
 import { Link } from 'react-router-dom';
 import api from '../services/api';
+import { useLiveRefresh } from '../services/live';
 import EventLog from '../components/EventLog';
 import { MetricLineChart } from '../components/Charts';
 import { Server, CheckCircle2, AlertTriangle, XCircle, ArrowRight, Clock, FileWarning } from 'lucide-react';
@@ -15,10 +16,12 @@ export default function Dashboard() {
 
     useEffect(() => {
         fetchData();
-        const interval = setInterval(fetchData, 30000); // Refresh every 30s
+        const interval = setInterval(fetchData, 60000); // Live updates between refreshes
         return () => clearInterval(interval);
     }, [timeRange]);
 
+    useLiveRefresh(() => fetchData());
+
     const fetchData = async () => {
         try {
             const [serversRes, eventsRes] = await Promise.all([
@@ -2,7 +2,7 @@ import React, { useEffect, useState } from 'react';
 import api from '../services/api';
 import EventLog from '../components/EventLog';
 import DriftConfig from '../components/config/DriftConfig';
-import { FileWarning, Save, Monitor, Settings as SettingsIcon, AlertTriangle } from 'lucide-react';
+import { FileWarning, Save, Monitor, Settings as SettingsIcon, AlertTriangle, Download } from 'lucide-react';
 import { cn } from '../utils/cn';
 
 // Simple deep equality check
@@ -31,6 +31,9 @@ export default function DriftDetection() {
     const [driftPathsText, setDriftPathsText] = useState('');
     const [isDriftEnabled, setIsDriftEnabled] = useState(true);
 
+    // Change log export month (YYYY-MM, UTC)
+    const [reportMonth, setReportMonth] = useState(new Date().toISOString().slice(0, 7));
+
     useEffect(() => {
         fetchData();
         fetchConfig();
@@ -45,9 +48,9 @@ export default function DriftDetection() {
                 api.get('/api/v1/servers')
             ]);
 
-            // Filter for only drift events
+            // Filter for drift and unexpected egress events
             const relevantEvents = (eventsRes.data || []).filter(e =>
-                e.event_type === 'drift'
+                e.event_type === 'drift' || e.event_type === 'egress'
             );
             setEvents(relevantEvents);
             setServers(serversRes.data || []);
@@ -79,12 +82,32 @@ export default function DriftDetection() {
         }
     };
 
+    // Configuration change log for auditors: every file/port change of the month
+    const downloadChangeLog = async (format) => {
+        try {
+            const response = await api.get('/api/v1/reports/drift', {
+                params: { month: reportMonth, format },
+                responseType: 'blob'
+            });
+            const url = window.URL.createObjectURL(new Blob([response.data]));
+            const link = document.createElement('a');
+            link.href = url;
+            link.setAttribute('download', `drift-report-${reportMonth}.${format}`);
+            document.body.appendChild(link);
+            link.click();
+            link.remove();
+        } catch (error) {
+            console.error('Failed to download change log:', error);
+        }
+    };
+
     const calculatePayload = (baseConfig, driftIgnore, driftPaths) => {
         return {
             ...baseConfig,
             drift_ignore: driftIgnore,
             drift_paths: driftPaths,
-            drift_interval: baseConfig.drift_interval !== undefined ? parseInt(baseConfig.drift_interval) : 300
+            drift_interval: baseConfig.drift_interval !== undefined ? parseInt(baseConfig.drift_interval) : 300,
+            egress_allow: (baseConfig.egress_allow || []).map(s => s.trim()).filter(s => s)
         };
     };
 
@@ -189,10 +212,31 @@ export default function DriftDetection() {
             {activeTab === 'monitoring' ? (
                 <div className="bg-card border border-border rounded-xl shadow-sm overflow-hidden min-h-[500px]">
                     <div className="p-6">
-                        <h2 className="text-lg font-semibold text-foreground mb-6 flex items-center gap-2">
-                            <FileWarning className="w-5 h-5 text-primary" />
-                            Drift Events
-                        </h2>
+                        <div className="flex flex-col md:flex-row md:items-center justify-between gap-4 mb-6">
+                            <h2 className="text-lg font-semibold text-foreground flex items-center gap-2">
+                                <FileWarning className="w-5 h-5 text-primary" />
+                                Drift Events
+                            </h2>
+                            <div className="flex items-center gap-2" title="Every file and port change of the month, with who changed it and who acknowledged it">
+                                <input
+                                    type="month"
+                                    value={reportMonth}
+                                    onChange={(e) => setReportMonth(e.target.value)}
+                                    className="px-3 py-1.5 text-sm bg-background border border-border rounded-md text-foreground"
+                                />
+                                {['csv', 'pdf'].map(format => (
+                                    <button
+                                        key={format}
+                                        onClick={() => downloadChangeLog(format)}
+                                        disabled={!reportMonth}
+                                        className="flex items-center gap-2 px-3 py-1.5 text-sm font-medium text-foreground bg-card hover:bg-muted border border-border rounded-md transition-colors disabled:opacity-50"
+                                    >
+                                        <Download className="w-4 h-4" />
+                                        Change Log ({format.toUpperCase()})
+                                    </button>
+                                ))}
+                            </div>
+                        </div>
                         <EventLog events={events} servers={servers} showTypeFilters={false} />
                     </div>
                 </div>
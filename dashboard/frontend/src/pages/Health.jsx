@@ -3,6 +3,7 @@ import { useNavigate } from 'react-router-dom';
 import api from '../services/api';
 import EventLog from '../components/EventLog';
 import HealthConfig from '../components/config/HealthConfig';
+import ThresholdProfiles from '../components/config/ThresholdProfiles';
 import { Activity, AlertTriangle, CheckCircle2, Save, Monitor, Settings as SettingsIcon } from 'lucide-react';
 import { cn } from '../utils/cn';
 
@@ -106,7 +107,9 @@ export default function Health() {
                 ...config,
                 health_sustain_duration: parseInt(config.health_sustain_duration),
                 stability_window: parseInt(config.stability_window),
-                offline_timeout: parseInt(config.offline_timeout)
+                offline_timeout: parseInt(config.offline_timeout),
+                disk_exclude_fstypes: (config.disk_exclude_fstypes || []).map(s => s.trim()).filter(s => s),
+                disk_exclude_paths: (config.disk_exclude_paths || []).map(s => s.trim()).filter(s => s)
             };
 
             await api.post('/api/v1/config', payload);
@@ -114,7 +117,7 @@ export default function Health() {
             setInitialConfig(payload);
             setTimeout(() => setSuccess(''), 5000);
         } catch (err) {
-            setError(err.message || 'Failed to save configuration');
+            setError(err.response?.data?.error || err.message || 'Failed to save configuration');
         } finally {
             setSaving(false);
         }
@@ -246,6 +249,9 @@ export default function Health() {
                         lastOfflineTimeout={lastOfflineTimeout}
                         setLastOfflineTimeout={setLastOfflineTimeout}
                     />
+                    <div className="mt-8">
+                        <ThresholdProfiles />
+                    </div>
                 </div>
             )}
         </div>
@@ -1,6 +1,8 @@
 import React, { useState, useEffect } from 'react';
 import api from '../services/api';
 import { Bell } from 'lucide-react';
+import { formatRelativeTime } from '../utils/formatters';
+import TicketIntegrations from '../components/config/TicketIntegrations';
 
 export default function Notifications() {
     const [alertSettings, setAlertSettings] = useState({
@@ -13,7 +15,9 @@ export default function Notifications() {
         smtp_user: '',
         smtp_password: '',
         alerts_enabled: false,
-        notify_on_warning: false
+        notify_on_warning: false,
+        provider_failure_minutes: 15,
+        provider_status: []
     });
     const [alertsLoading, setAlertsLoading] = useState(false);
     const [testingAlert, setTestingAlert] = useState(false);
@@ -52,9 +56,11 @@ export default function Notifications() {
         try {
             await api.post('/api/v1/settings/alerts', {
                 ...alertSettings,
-                smtp_port: parseInt(alertSettings.smtp_port) || 0
+                smtp_port: parseInt(alertSettings.smtp_port) || 0,
+                provider_failure_minutes: parseInt(alertSettings.provider_failure_minutes) || 0
             });
             setSuccess('Alert settings saved successfully!');
+            fetchAlertSettings();
             setTimeout(() => setSuccess(''), 3000);
         } catch (err) {
             setError(err.response?.data?.error || 'Failed to save alert settings');
@@ -73,6 +79,7 @@ export default function Notifications() {
             setError('Test failed: ' + (err.response?.data?.error || err.message));
         } finally {
             setTestingAlert(false);
+            fetchAlertSettings();
         }
     };
 
@@ -242,6 +249,43 @@ export default function Notifications() {
                             </div>
                         </div>
 
+                        <div className="space-y-4">
+                            <h3 className="text-sm font-medium text-muted-foreground uppercase border-b pb-2">Channel Health</h3>
+                            <div className="space-y-2">
+                                {(alertSettings.provider_status || []).filter(p => p.configured).length === 0 ? (
+                                    <div className="text-sm text-muted-foreground">No channels configured.</div>
+                                ) : (alertSettings.provider_status || []).filter(p => p.configured).map(p => (
+                                    <div key={p.provider} className="flex items-center justify-between text-sm">
+                                        <div className="flex items-center gap-2">
+                                            <span className={`w-2 h-2 rounded-full ${p.healthy ? 'bg-emerald-500' : 'bg-rose-500'}`} />
+                                            <span className="font-medium text-foreground">{p.name}</span>
+                                            {!p.healthy && p.last_error && (
+                                                <span className="text-xs text-rose-600 truncate max-w-md" title={p.last_error}>{p.last_error}</span>
+                                            )}
+                                        </div>
+                                        <div className="text-xs text-muted-foreground">
+                                            {p.healthy
+                                                ? (p.last_success ? `Last delivered ${formatRelativeTime(p.last_success)}` : 'No deliveries yet')
+                                                : `Failing since ${formatRelativeTime(p.failing_since)}`}
+                                        </div>
+                                    </div>
+                                ))}
+                            </div>
+                            <div className="space-y-2">
+                                <label className="text-sm font-medium text-foreground">Report Failing Channel After (minutes)</label>
+                                <input
+                                    type="number"
+                                    name="provider_failure_minutes"
+                                    min="0"
+                                    max="1440"
+                                    value={alertSettings.provider_failure_minutes}
+                                    onChange={handleAlertChange}
+                                    className="w-full md:w-48 px-3 py-2 bg-background border border-input rounded-md text-sm"
+                                />
+                                <p className="text-xs text-muted-foreground">A channel failing this long is reported through the channels that still work. 0 disables.</p>
+                            </div>
+                        </div>
+
                         <div className="flex justify-end gap-3 pt-4 border-t border-border">
                             <button
                                 type="submit"
@@ -262,6 +306,8 @@ export default function Notifications() {
                     </form>
                 </div>
             </div>
+
+            <TicketIntegrations />
         </div>
     );
 }
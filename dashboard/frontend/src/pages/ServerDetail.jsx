@@ -1,11 +1,12 @@
 import React, { useEffect, useState } from 'react';
 import { useParams, useNavigate } from 'react-router-dom';
 import api from '../services/api';
+import { useLiveRefresh } from '../services/live';
 import StatusBadge from '../components/StatusBadge';
 import EventLog from '../components/EventLog';
 import { MetricLineChart, HealthMetricCard } from '../components/Charts';
 import { formatRelativeTime, formatDate } from '../utils/formatters';
-import { ArrowLeft, Trash2, Cpu, HardDrive, Zap, Info, Clock, AlertTriangle, CheckCircle2, AlertCircle, XCircle, FileText, Download } from 'lucide-react';
+import { ArrowLeft, Trash2, Cpu, HardDrive, Zap, Info, Clock, AlertTriangle, CheckCircle2, AlertCircle, XCircle, FileText, Download, Wrench } from 'lucide-react';
 import ConfirmationModal from '../components/ConfirmationModal';
 import { cn } from '../utils/cn';
 
@@ -14,6 +15,7 @@ export default function ServerDetail() {
     const navigate = useNavigate();
     const [server, setServer] = useState(null);
     const [events, setEvents] = useState([]);
+    const [cronStats, setCronStats] = useState([]); // Per-job run history summary (7 days)
     const [deleteModalOpen, setDeleteModalOpen] = useState(false);
     const [uninstallModalOpen, setUninstallModalOpen] = useState(false);
     const [clearEventsModalOpen, setClearEventsModalOpen] = useState(false);
@@ -25,10 +27,12 @@ export default function ServerDetail() {
 
     useEffect(() => {
         fetchServerData();
-        const interval = setInterval(fetchServerData, 10000);
+        const interval = setInterval(fetchServerData, 60000); // Live updates between refreshes
         return () => clearInterval(interval);
     }, [id]);
 
+    useLiveRefresh(() => fetchServerData(), { types: ['status', 'event', 'metric'], serverId: id });
+
     useEffect(() => {
         if (allMetrics.length > 0) {
             setMetrics(processMetrics(allMetrics, timeRange));
@@ -78,14 +82,16 @@ export default function ServerDetail() {
 
     const fetchServerData = async () => {
         try {
-            const [serverRes, eventsRes, metricsRes] = await Promise.all([
+            const [serverRes, eventsRes, metricsRes, cronStatsRes] = await Promise.all([
                 api.get(`/api/v1/servers/${id}`),
                 api.get(`/api/v1/servers/${id}/events`),
                 api.get(`/api/v1/servers/${id}/metrics`),
+                api.get(`/api/v1/servers/${id}/cron/runs`).catch(() => ({ data: [] })),
             ]);
 
             setServer(serverRes.data);
             setEvents(eventsRes.data || []);
+            setCronStats(cronStatsRes.data || []);
 
             const processedRaw = (metricsRes.data || []).map(m => ({
                 ...m,
@@ -147,6 +153,23 @@ export default function ServerDetail() {
         }
     };
 
+    const handleMaintenance = async () => {
+        try {
+            if (server.maintenance) {
+                await api.delete(`/api/v1/servers/${id}/maintenance`);
+            } else {
+                const hours = window.prompt('Maintenance duration in hours (alerts are held back meanwhile):', '2');
+                if (!hours) return;
+                const minutes = Math.round(parseFloat(hours) * 60);
+                if (!(minutes > 0)) return;
+                await api.post(`/api/v1/servers/${id}/maintenance`, { duration_minutes: minutes });
+            }
+            fetchServerData();
+        } catch (err) {
+            setError(err.response?.data?.error || 'Failed to change maintenance mode');
+        }
+    };
+
     const handleUninstall = async () => {
         try {
             await api.post(`/api/v1/servers/${id}/uninstall`);
@@ -172,6 +195,46 @@ export default function ServerDetail() {
         }
     };
 
+    const handleAckEvent = async (event) => {
+        try {
+            await api.post(`/api/v1/events/${event.id}/ack`);
+            // Acknowledged events are hidden from the listing
+            setEvents(prev => prev.filter(e => e.id !== event.id));
+        } catch (err) {
+            alert("Failed to acknowledge event: " + (err.response?.data?.error || err.message));
+        }
+    };
+
+    const handleCommentEvent = async (event) => {
+        try {
+            const res = await api.get(`/api/v1/events/${event.id}`);
+            const journal = (res.data.comments || [])
+                .map(c => `${formatDate(c.created_at)} ${c.username || ''}: ${c.body}`)
+                .join('\n');
+            const body = window.prompt(`${journal || 'No comments yet.'}\n\nAdd a comment:`, "");
+            if (!body || !body.trim()) return;
+            await api.post(`/api/v1/events/${event.id}/comments`, { body: body.trim() });
+        } catch (err) {
+            alert("Failed to comment: " + (err.response?.data?.error || err.message));
+        }
+    };
+
+    const handleReplayEvent = async (event) => {
+        const provider = window.prompt("Replay this event as a test notification.\nProvider (slack, teams, discord, email) or empty for all:", "");
+        if (provider === null) return;
+        const summarize = (results) => results.map(r => `${r.name}: ${r.status}${r.error ? ` (${r.error})` : ''}`).join('\n');
+
+        try {
+            const res = await api.post(`/api/v1/events/${event.id}/replay`, { provider: provider.trim().toLowerCase() });
+            alert(summarize(res.data.results));
+        } catch (err) {
+            const results = err.response?.data?.results;
+            alert(results
+                ? summarize(results)
+                : "Replay failed: " + (err.response?.data?.error || err.message));
+        }
+    };
+
     if (loading) {
         return (
             <div className="flex items-center justify-center min-h-screen">
@@ -340,6 +403,9 @@ export default function ServerDetail() {
                                 events={events}
                                 showServerFilter={false}
                                 onDelete={handleDeleteEvent}
+                                onReplay={handleReplayEvent}
+                                onAck={handleAckEvent}
+                                onComment={handleCommentEvent}
                             />
                         </div>
                     </div>
@@ -359,10 +425,105 @@ export default function ServerDetail() {
                                 <div className="text-xs font-medium text-muted-foreground uppercase mb-1">Operating System</div>
                                 <div className="text-sm font-medium">{server.os_name} {server.os_version}</div>
                             </div>
+                            <div>
+                                <div className="text-xs font-medium text-muted-foreground uppercase mb-1">Environment</div>
+                                <div className="text-sm font-medium">
+                                    {server.environment ? `${server.environment.type} (${server.environment.role})` : 'Unknown'}
+                                </div>
+                            </div>
+                            <div>
+                                <div className="text-xs font-medium text-muted-foreground uppercase mb-1">Install Package</div>
+                                <div className="text-sm font-medium font-mono">{server.package_id || 'Unknown'}</div>
+                            </div>
+                            <div>
+                                <div className="text-xs font-medium text-muted-foreground uppercase mb-1">Timezone</div>
+                                <div className="text-sm font-medium">{server.timezone || 'Unknown'}</div>
+                            </div>
                             <div>
                                 <div className="text-xs font-medium text-muted-foreground uppercase mb-1">Agent Version</div>
                                 <div className="text-sm font-medium">{server.agent_version || 'Unknown'}</div>
                             </div>
+                            <div>
+                                <div className="text-xs font-medium text-muted-foreground uppercase mb-1">Binary Attestation</div>
+                                <div className={`text-sm font-medium ${{
+                                    verified: 'text-emerald-600',
+                                    mismatch: 'text-rose-600',
+                                    outdated: 'text-amber-600',
+                                }[server.attestation?.status] || ''}`}>
+                                    {{
+                                        verified: 'Verified',
+                                        mismatch: 'Mismatch (not the published build)',
+                                        outdated: 'Outdated binary',
+                                        unknown: 'No published binary for this arch',
+                                        unreported: 'Not reported by agent',
+                                    }[server.attestation?.status] || 'Unknown'}
+                                </div>
+                                {server.attestation?.binary_sha256 && (
+                                    <div className="text-xs text-muted-foreground font-mono truncate" title={`binary ${server.attestation.binary_sha256}\nconfig ${server.attestation.config_sha256 || '-'}`}>
+                                        {server.attestation.arch} · {server.attestation.binary_sha256.slice(0, 16)}
+                                    </div>
+                                )}
+                            </div>
+                            <div>
+                                <div className="text-xs font-medium text-muted-foreground uppercase mb-1">Zero Touch (eBPF)</div>
+                                <div className={`text-sm font-medium ${{
+                                    full: 'text-emerald-600',
+                                    basic: 'text-amber-600',
+                                }[server.ebpf_level] || ''}`}>
+                                    {{
+                                        full: 'Enabled',
+                                        basic: 'Basic (exit codes by PID)',
+                                        none: server.agent_self?.proc_connector?.enabled ? 'Unavailable (exit codes via proc connector)' : 'Unavailable (cron log parsing)',
+                                    }[server.ebpf_level] || 'Not reported by agent'}
+                                </div>
+                                {server.agent_self?.ebpf?.kernel && (
+                                    <div className="text-xs text-muted-foreground truncate" title={server.agent_self.ebpf.error || ''}>
+                                        Kernel {server.agent_self.ebpf.kernel}
+                                        {server.agent_self.ebpf.btf ? ` · BTF ${server.agent_self.ebpf.btf}` : ''}
+                                        {server.agent_self.ebpf.missing?.length > 0 ? ` · missing ${server.agent_self.ebpf.missing.join(', ')}` : ''}
+                                    </div>
+                                )}
+                            </div>
+                            {server.agent_self?.runtime && (
+                                <div>
+                                    <div className="text-xs font-medium text-muted-foreground uppercase mb-1">Agent Process</div>
+                                    <div className="text-sm font-medium">
+                                        {(server.agent_self.runtime.rss_bytes / 1048576).toFixed(1)} MB RSS
+                                    </div>
+                                    <div className="text-xs text-muted-foreground">
+                                        Heap {(server.agent_self.runtime.heap_alloc_bytes / 1048576).toFixed(1)} MB · {server.agent_self.runtime.goroutines} goroutines · {server.agent_self.runtime.num_gc} GCs
+                                    </div>
+                                </div>
+                            )}
+                            {server.agent_queue && (
+                                <div>
+                                    <div className="text-xs font-medium text-muted-foreground uppercase mb-1">Offline Queue</div>
+                                    <div className={`text-sm font-medium flex items-center gap-2 ${server.agent_queue.total > 0 && server.agent_queue.oldest_age_seconds >= 900 ? 'text-amber-600' : ''}`}>
+                                        {server.agent_queue.total > 0 && server.agent_queue.oldest_age_seconds >= 900 && <AlertTriangle className="w-4 h-4" />}
+                                        {server.agent_queue.total === 0 ? 'Empty' : `${server.agent_queue.total} items (${(server.agent_queue.bytes / 1024).toFixed(0)} KB)`}
+                                    </div>
+                                    {server.agent_queue.total > 0 && (
+                                        <div className="text-xs text-muted-foreground">
+                                            Oldest queued {Math.round((server.agent_queue.oldest_age_seconds || 0) / 60)} min ago
+                                            {Object.entries(server.agent_queue.by_retries || {}).filter(([k]) => k !== 'retry_0').length > 0 &&
+                                                ` · ${Object.entries(server.agent_queue.by_retries).filter(([k]) => k !== 'retry_0').reduce((n, [, c]) => n + c, 0)} retried`}
+                                        </div>
+                                    )}
+                                </div>
+                            )}
+                            {server.disk_mounts?.length > 0 && (
+                                <div>
+                                    <div className="text-xs font-medium text-muted-foreground uppercase mb-1">Filesystems</div>
+                                    <div className="space-y-1">
+                                        {server.disk_mounts.map(m => (
+                                            <div key={m.mount} className={`text-xs flex justify-between gap-2 ${m.excluded ? 'text-muted-foreground line-through' : ''}`} title={`${m.device} (${m.fstype})`}>
+                                                <span className="font-mono truncate">{m.mount}</span>
+                                                <span className="shrink-0">{m.percent.toFixed(1)}% of {m.total_mb >= 1024 ? `${(m.total_mb / 1024).toFixed(1)} GB` : `${m.total_mb} MB`}</span>
+                                            </div>
+                                        ))}
+                                    </div>
+                                </div>
+                            )}
                             <div>
                                 <div className="text-xs font-medium text-muted-foreground uppercase mb-1">Last Seen</div>
                                 <div className="text-sm font-medium flex items-center gap-2">
@@ -434,6 +595,21 @@ export default function ServerDetail() {
                                         </span>
                                     )}
                                 </div>
+                                {cronStats.length > 0 && (
+                                    <div className="mt-3 space-y-1.5">
+                                        {cronStats.map(job => (
+                                            <div key={job.command} className="flex items-center justify-between gap-2 text-xs" title={`${job.runs} runs in 7 days, avg ${Math.round(job.avg_duration)}s, max ${job.max_duration}s`}>
+                                                <span className="font-mono truncate text-muted-foreground">{job.command}</span>
+                                                <span className={cn(
+                                                    "font-medium whitespace-nowrap",
+                                                    job.success_rate >= 100 ? "text-emerald-600" : job.success_rate >= 90 ? "text-amber-600" : "text-rose-600"
+                                                )}>
+                                                    {Math.round(job.success_rate)}% · {Math.round(job.avg_duration)}s
+                                                </span>
+                                            </div>
+                                        ))}
+                                    </div>
+                                )}
                             </div>
 
                             <div className="pt-4 border-t border-border">
@@ -494,6 +670,16 @@ export default function ServerDetail() {
                                             Download Logs ({formatRelativeTime(server.log_file_time)})
                                         </button>
                                     )}
+
+                                    <button
+                                        onClick={handleMaintenance}
+                                        className="flex items-center justify-center gap-2 px-3 py-2 text-sm font-medium text-sky-700 bg-sky-50 hover:bg-sky-100 border border-sky-200 rounded-md transition-colors w-full"
+                                    >
+                                        <Wrench className="w-4 h-4" />
+                                        {server.maintenance
+                                            ? `End Maintenance (until ${new Date(server.maintenance.until * 1000).toLocaleString()})`
+                                            : 'Start Maintenance'}
+                                    </button>
                                 </div>
                             </div>
 
@@ -1,11 +1,12 @@
 import React, { useEffect, useState } from 'react';
 import { useNavigate } from 'react-router-dom';
 import api from '../services/api';
+import { useLiveRefresh } from '../services/live';
 import StatusBadge from '../components/StatusBadge';
 import EventLog from '../components/EventLog';
 import ConfirmationModal from '../components/ConfirmationModal';
 import { formatRelativeTime } from '../utils/formatters';
-import { Server as ServerIcon, AlertTriangle, CheckCircle2, Trash2 } from 'lucide-react';
+import { Server as ServerIcon, AlertTriangle, CheckCircle2, Trash2, Download, ShieldAlert, Zap } from 'lucide-react';
 import { cn } from '../utils/cn';
 
 export default function Servers() {
@@ -18,10 +19,12 @@ export default function Servers() {
 
     useEffect(() => {
         fetchData();
-        const interval = setInterval(fetchData, 10000); // Refresh every 10s
+        const interval = setInterval(fetchData, 60000); // Live updates between refreshes
         return () => clearInterval(interval);
     }, []);
 
+    useLiveRefresh(() => fetchData());
+
     const fetchData = async () => {
         try {
             const [serversRes, eventsRes] = await Promise.all([
@@ -57,6 +60,25 @@ export default function Servers() {
         }
     };
 
+    // Capacity planning export: average/peak utilization with downsize/upgrade flags
+    const downloadCapacityReport = async () => {
+        try {
+            const response = await api.get('/api/v1/reports/capacity', {
+                params: { days: 30, format: 'csv' },
+                responseType: 'blob'
+            });
+            const url = window.URL.createObjectURL(new Blob([response.data]));
+            const link = document.createElement('a');
+            link.href = url;
+            link.setAttribute('download', `capacity-report-${new Date().toISOString().slice(0, 10)}.csv`);
+            document.body.appendChild(link);
+            link.click();
+            link.remove();
+        } catch (error) {
+            console.error('Failed to download capacity report:', error);
+        }
+    };
+
     if (loading) {
         return (
             <div className="flex items-center justify-center min-h-[500px]">
@@ -73,6 +95,14 @@ export default function Servers() {
                     <p className="text-sm text-muted-foreground mt-1">Manage and monitor your infrastructure</p>
                 </div>
                 <div className="flex gap-4">
+                    <button
+                        onClick={downloadCapacityReport}
+                        className="flex items-center gap-2 px-4 py-2 text-sm font-medium text-foreground bg-card hover:bg-muted border border-border rounded-lg transition-colors"
+                        title="Average and peak utilization over the last 30 days, with downsize/upgrade candidates"
+                    >
+                        <Download className="w-4 h-4" />
+                        Capacity Report (CSV)
+                    </button>
                     <div className="bg-blue-50 border border-blue-200 px-4 py-2 rounded-lg flex items-center gap-3">
                         <ServerIcon className="w-5 h-5 text-blue-600" />
                         <div className="flex flex-col">
@@ -115,8 +145,18 @@ export default function Servers() {
                                                 className="group cursor-pointer hover:bg-muted/50 transition-colors"
                                             >
                                                 <td className="px-6 py-4">
-                                                    <div className="font-medium text-foreground group-hover:text-primary transition-colors">
+                                                    <div className="font-medium text-foreground group-hover:text-primary transition-colors flex items-center gap-1.5">
                                                         {server.hostname}
+                                                        {['mismatch', 'outdated'].includes(server.attestation?.status) && (
+                                                            <span title={server.attestation.status === 'mismatch' ? 'Agent binary does not match the published build' : 'Agent binary is outdated'}>
+                                                                <ShieldAlert className={cn("w-4 h-4", server.attestation.status === 'mismatch' ? "text-rose-600" : "text-amber-500")} />
+                                                            </span>
+                                                        )}
+                                                        {['full', 'basic'].includes(server.ebpf_level) && (
+                                                            <span title={server.ebpf_level === 'full' ? 'Zero Touch: eBPF exit codes, command lines and OOM kills' : 'Zero Touch (basic): eBPF exit codes matched by PID'}>
+                                                                <Zap className={cn("w-4 h-4", server.ebpf_level === 'full' ? "text-emerald-600" : "text-amber-500")} />
+                                                            </span>
+                                                        )}
                                                     </div>
                                                     <div className="text-xs text-muted-foreground font-mono mt-0.5">
                                                         {server.id.slice(0, 8)}...
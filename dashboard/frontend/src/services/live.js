@@ -0,0 +1,39 @@
+import { useEffect, useRef } from 'react';
+
+// Live updates from /api/v1/stream (Server-Sent Events): server status
+// changes, new events and metric ticks. EventSource cannot send headers, so
+// the token goes in the query string; it reconnects on its own.
+export function useLiveUpdates(onUpdate) {
+    const handler = useRef(onUpdate);
+    handler.current = onUpdate;
+
+    useEffect(() => {
+        const token = localStorage.getItem('auth_token');
+        if (!token || typeof EventSource === 'undefined') return;
+
+        const source = new EventSource(`/api/v1/stream?token=${encodeURIComponent(token)}`);
+        const listener = (e) => {
+            try {
+                handler.current(e.type, JSON.parse(e.data));
+            } catch (err) {
+                console.error('Bad live update:', err);
+            }
+        };
+        ['status', 'event', 'metric'].forEach((type) => source.addEventListener(type, listener));
+        return () => source.close();
+    }, []);
+}
+
+// Calls refresh shortly after a matching update, once per burst
+export function useLiveRefresh(refresh, { types = ['status', 'event'], serverId } = {}) {
+    const timer = useRef(null);
+
+    useEffect(() => () => clearTimeout(timer.current), []);
+
+    useLiveUpdates((type, data) => {
+        if (!types.includes(type)) return;
+        if (serverId && data.server_id !== serverId) return;
+        clearTimeout(timer.current);
+        timer.current = setTimeout(refresh, 500);
+    });
+}
@@ -26,10 +26,19 @@ PROJECT_ROOT="$( dirname "$SCRIPT_DIR" )"
 
 cd "$PROJECT_ROOT"
 
+# Agent updates must be signed with the license private key
+SIGNING_ARGS=()
+if [ -f "$SCRIPT_DIR/license_tool/private.key" ]; then
+    SIGNING_ARGS=(--secret "id=signing_key,src=$SCRIPT_DIR/license_tool/private.key")
+else
+    echo -e "${YELLOW}⚠️  deploy/license_tool/private.key not found: agent binaries will be unsigned and agents will refuse to update${NC}"
+fi
+
 # 1. Build NodeGuarder Image
 echo -e "${YELLOW}📦 Building NodeGuarder Image${NC}"
 docker build \
     -f deploy/Dockerfile \
+    "${SIGNING_ARGS[@]}" \
     -t nodeguarder:latest \
     -t "nodeguarder:$VERSION" \
     . || { echo -e "${RED}❌ Image build failed${NC}"; exit 1; }